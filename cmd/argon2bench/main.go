@@ -0,0 +1,39 @@
+// Command argon2bench picks Argon2id parameters of password hashes for the
+// target latency on this host. Run it on the host of the server and put the
+// printed variables into the environment of the server.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"passman/pkg/password"
+
+	"github.com/alexedwards/argon2id"
+)
+
+func main() {
+	target := flag.Duration("target", 500*time.Millisecond, "maximal time of hashing one password")
+	maxMemory := flag.Uint("max-memory", 1024, "maximal memory of hashing one password, MiB")
+	parallelism := flag.Uint("parallelism", uint(runtime.NumCPU()), "number of threads")
+	flag.Parse()
+
+	if *target <= 0 || *maxMemory == 0 || *maxMemory > 4*1024 || *parallelism == 0 || *parallelism > 255 {
+		fmt.Fprintln(os.Stderr, "invalid flags")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	params := password.Benchmark(*target, uint32(*maxMemory)*1024, uint8(*parallelism))
+
+	start := time.Now()
+	_, _ = argon2id.CreateHash("benchmark password", params)
+	fmt.Fprintf(os.Stderr, "Hashing takes %s, concurrent logins multiply the memory\n", time.Since(start).Round(time.Millisecond))
+
+	fmt.Printf("ARGON2_MEMORY=%d\n", params.Memory)
+	fmt.Printf("ARGON2_ITERATIONS=%d\n", params.Iterations)
+	fmt.Printf("ARGON2_PARALLELISM=%d\n", params.Parallelism)
+}
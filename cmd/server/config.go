@@ -1,8 +1,19 @@
 package main
 
 import (
+	"crypto/x509"
+	"fmt"
 	"log/slog"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"passman/pkg/password"
+
+	"github.com/alexedwards/argon2id"
 )
 
 type config struct {
@@ -11,6 +22,51 @@ type config struct {
 	BackupDir string
 	AssetsDir string
 	MasterKey string
+	// WebAuthn relying party, the origins are the pages of the client
+	WebAuthnRPID    string
+	WebAuthnOrigins []string
+	// SessionStore is "sqlite" or "memory", sessions in memory are lost on restart
+	SessionStore string
+	// Zero durations are replaced by defaults of the session manager
+	SessionLifetime      time.Duration
+	SessionIdleTimeout   time.Duration
+	SessionReauthTimeout time.Duration
+	// DeletionGracePeriod before removed users are purged, zero is the default
+	DeletionGracePeriod time.Duration
+	// RegistrationMode is "open", "invite" or "closed"
+	RegistrationMode string
+	// PasswordPolicy is applied to new master passwords
+	PasswordPolicy password.Policy
+	// Argon2Params of password hashes, see cmd/argon2bench for tuning
+	Argon2Params argon2id.Params
+	// OIDC login is enabled by OIDCIssuer
+	OIDCIssuer       string
+	OIDCClientID     string
+	OIDCClientSecret string
+	OIDCRedirectURL  string
+	OIDCScopes       []string
+	// Claims of the ID token, see usersHTTP.OIDCConfig
+	OIDCUsernameClaim string
+	OIDCRolesClaim    string
+	// Roles of the provider, see usersUsecases.OIDCOptions
+	OIDCUserRole     string
+	OIDCAdminRole    string
+	OIDCPostLoginURL string
+	// LDAP login is enabled by LDAPURL, see ldap.Config
+	LDAPURL               string
+	LDAPStartTLS          bool
+	LDAPRootCAs           *x509.CertPool
+	LDAPBindDN            string
+	LDAPBindPassword      string
+	LDAPBaseDN            string
+	LDAPUserFilter        string
+	LDAPUsernameAttribute string
+	LDAPGroupAttribute    string
+	LDAPGroupBaseDN       string
+	LDAPGroupFilter       string
+	// Group DNs, see usersUsecases.LDAPOptions
+	LDAPUserGroup  string
+	LDAPAdminGroup string
 }
 
 var logLevelMap = map[string]slog.Level{
@@ -34,9 +90,243 @@ func newConfig() (config, error) {
 
 	cfg.MasterKey = loadMasterKey()
 
+	cfg.WebAuthnRPID = os.Getenv("WEBAUTHN_RP_ID")
+	if len(cfg.WebAuthnRPID) == 0 {
+		cfg.WebAuthnRPID = "localhost"
+	}
+	cfg.WebAuthnOrigins = strings.Split(os.Getenv("WEBAUTHN_ORIGINS"), ",")
+	if len(os.Getenv("WEBAUTHN_ORIGINS")) == 0 {
+		cfg.WebAuthnOrigins = []string{"http://localhost:5000"}
+	}
+
+	cfg.SessionStore = os.Getenv("SESSION_STORE")
+	switch cfg.SessionStore {
+	case "":
+		cfg.SessionStore = "sqlite"
+	case "sqlite", "memory":
+	default:
+		return config{}, fmt.Errorf("unknown session store %q", cfg.SessionStore)
+	}
+
+	cfg.RegistrationMode = os.Getenv("REGISTRATION_MODE")
+	switch cfg.RegistrationMode {
+	case "":
+		cfg.RegistrationMode = "open"
+	case "open", "invite", "closed":
+	default:
+		return config{}, fmt.Errorf("unknown registration mode %q", cfg.RegistrationMode)
+	}
+
+	cfg.PasswordPolicy = password.Policy{
+		MinLength:     8,
+		MinEntropy:    40,
+		CheckCommon:   true,
+		CheckUsername: true,
+	}
+	if value := os.Getenv("PASSWORD_MIN_LENGTH"); len(value) > 0 {
+		minLength, err := strconv.Atoi(value)
+		// Shorter passwords are rejected by the validator anyway
+		if err != nil || minLength < 8 {
+			return config{}, fmt.Errorf("invalid PASSWORD_MIN_LENGTH %q", value)
+		}
+		cfg.PasswordPolicy.MinLength = minLength
+	}
+	if value := os.Getenv("PASSWORD_MIN_ENTROPY"); len(value) > 0 {
+		minEntropy, err := strconv.ParseFloat(value, 64)
+		if err != nil || minEntropy < 0 {
+			return config{}, fmt.Errorf("invalid PASSWORD_MIN_ENTROPY %q", value)
+		}
+		cfg.PasswordPolicy.MinEntropy = minEntropy
+	}
+
+	checks := []struct {
+		env string
+		dst *bool
+	}{
+		{env: "PASSWORD_CHECK_COMMON", dst: &cfg.PasswordPolicy.CheckCommon},
+		{env: "PASSWORD_CHECK_USERNAME", dst: &cfg.PasswordPolicy.CheckUsername},
+	}
+	for _, c := range checks {
+		value := os.Getenv(c.env)
+		if len(value) == 0 {
+			continue
+		}
+		check, err := strconv.ParseBool(value)
+		if err != nil {
+			return config{}, fmt.Errorf("invalid %s %q", c.env, value)
+		}
+		*c.dst = check
+	}
+
+	cfg.Argon2Params = *argon2id.DefaultParams
+	argon2Params := []struct {
+		env string
+		max uint64
+		set func(uint64)
+	}{
+		// The memory is in KiB
+		{env: "ARGON2_MEMORY", max: 4 * 1024 * 1024, set: func(v uint64) { cfg.Argon2Params.Memory = uint32(v) }},
+		{env: "ARGON2_ITERATIONS", max: 100, set: func(v uint64) { cfg.Argon2Params.Iterations = uint32(v) }},
+		{env: "ARGON2_PARALLELISM", max: 255, set: func(v uint64) { cfg.Argon2Params.Parallelism = uint8(v) }},
+	}
+	for _, p := range argon2Params {
+		value := os.Getenv(p.env)
+		if len(value) == 0 {
+			continue
+		}
+		v, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || v == 0 || v > p.max {
+			return config{}, fmt.Errorf("invalid %s %q", p.env, value)
+		}
+		p.set(v)
+	}
+	// Argon2 requires at least 8 KiB per lane
+	if cfg.Argon2Params.Memory < 8*uint32(cfg.Argon2Params.Parallelism) {
+		return config{}, fmt.Errorf("ARGON2_MEMORY must be at least 8 KiB per ARGON2_PARALLELISM")
+	}
+
+	if err := loadOIDC(&cfg); err != nil {
+		return config{}, err
+	}
+	if err := loadLDAP(&cfg); err != nil {
+		return config{}, err
+	}
+
+	durations := []struct {
+		env string
+		dst *time.Duration
+	}{
+		{env: "SESSION_LIFETIME", dst: &cfg.SessionLifetime},
+		{env: "SESSION_IDLE_TIMEOUT", dst: &cfg.SessionIdleTimeout},
+		{env: "SESSION_REAUTH_TIMEOUT", dst: &cfg.SessionReauthTimeout},
+		{env: "DELETION_GRACE_PERIOD", dst: &cfg.DeletionGracePeriod},
+	}
+	for _, d := range durations {
+		value := os.Getenv(d.env)
+		if len(value) == 0 {
+			continue
+		}
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration <= 0 {
+			return config{}, fmt.Errorf("invalid %s %q", d.env, value)
+		}
+		*d.dst = duration
+	}
+
 	return cfg, nil
 }
 
+func loadOIDC(cfg *config) error {
+	cfg.OIDCIssuer = os.Getenv("OIDC_ISSUER")
+	if len(cfg.OIDCIssuer) == 0 {
+		return nil
+	}
+
+	cfg.OIDCClientID = os.Getenv("OIDC_CLIENT_ID")
+	cfg.OIDCClientSecret = os.Getenv("OIDC_CLIENT_SECRET")
+	cfg.OIDCRedirectURL = os.Getenv("OIDC_REDIRECT_URL")
+	if len(cfg.OIDCClientID) == 0 || len(cfg.OIDCRedirectURL) == 0 {
+		return fmt.Errorf("OIDC_CLIENT_ID and OIDC_REDIRECT_URL are required by OIDC_ISSUER")
+	}
+
+	cfg.OIDCScopes = strings.Fields(os.Getenv("OIDC_SCOPES"))
+	if len(cfg.OIDCScopes) == 0 {
+		cfg.OIDCScopes = []string{"openid", "profile", "email"}
+	}
+	if !slices.Contains(cfg.OIDCScopes, "openid") {
+		return fmt.Errorf("OIDC_SCOPES must contain openid")
+	}
+
+	cfg.OIDCUsernameClaim = os.Getenv("OIDC_USERNAME_CLAIM")
+	if len(cfg.OIDCUsernameClaim) == 0 {
+		cfg.OIDCUsernameClaim = "preferred_username"
+	}
+	cfg.OIDCRolesClaim = os.Getenv("OIDC_ROLES_CLAIM")
+	cfg.OIDCUserRole = os.Getenv("OIDC_USER_ROLE")
+	cfg.OIDCAdminRole = os.Getenv("OIDC_ADMIN_ROLE")
+	if (len(cfg.OIDCUserRole) > 0 || len(cfg.OIDCAdminRole) > 0) && len(cfg.OIDCRolesClaim) == 0 {
+		return fmt.Errorf("OIDC_USER_ROLE and OIDC_ADMIN_ROLE require OIDC_ROLES_CLAIM")
+	}
+
+	cfg.OIDCPostLoginURL = os.Getenv("OIDC_POST_LOGIN_URL")
+	if len(cfg.OIDCPostLoginURL) == 0 {
+		cfg.OIDCPostLoginURL = "/"
+	}
+	// The second factor is added to the query of the URL
+	if _, err := url.Parse(cfg.OIDCPostLoginURL); err != nil {
+		return fmt.Errorf("invalid OIDC_POST_LOGIN_URL %q", cfg.OIDCPostLoginURL)
+	}
+
+	return nil
+}
+
+func loadLDAP(cfg *config) error {
+	cfg.LDAPURL = os.Getenv("LDAP_URL")
+	if len(cfg.LDAPURL) == 0 {
+		return nil
+	}
+	if !strings.HasPrefix(cfg.LDAPURL, "ldap://") && !strings.HasPrefix(cfg.LDAPURL, "ldaps://") {
+		return fmt.Errorf("invalid LDAP_URL %q", cfg.LDAPURL)
+	}
+
+	if value := os.Getenv("LDAP_START_TLS"); len(value) > 0 {
+		startTLS, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid LDAP_START_TLS %q", value)
+		}
+		cfg.LDAPStartTLS = startTLS
+	}
+	if cfg.LDAPStartTLS && strings.HasPrefix(cfg.LDAPURL, "ldaps://") {
+		return fmt.Errorf("LDAP_START_TLS requires ldap:// LDAP_URL")
+	}
+
+	if caFile := os.Getenv("LDAP_CA_FILE"); len(caFile) > 0 {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return fmt.Errorf("failed reading LDAP_CA_FILE: %w", err)
+		}
+		cfg.LDAPRootCAs = x509.NewCertPool()
+		if !cfg.LDAPRootCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("LDAP_CA_FILE has no certificates")
+		}
+	}
+
+	cfg.LDAPBindDN = os.Getenv("LDAP_BIND_DN")
+	cfg.LDAPBindPassword = os.Getenv("LDAP_BIND_PASSWORD")
+	cfg.LDAPBaseDN = os.Getenv("LDAP_BASE_DN")
+	if len(cfg.LDAPBaseDN) == 0 {
+		return fmt.Errorf("LDAP_BASE_DN is required by LDAP_URL")
+	}
+
+	cfg.LDAPUserFilter = os.Getenv("LDAP_USER_FILTER")
+	if len(cfg.LDAPUserFilter) == 0 {
+		cfg.LDAPUserFilter = "(uid={username})"
+	}
+	if !strings.Contains(cfg.LDAPUserFilter, "{username}") {
+		return fmt.Errorf("LDAP_USER_FILTER must contain {username}")
+	}
+	cfg.LDAPUsernameAttribute = os.Getenv("LDAP_USERNAME_ATTRIBUTE")
+	if len(cfg.LDAPUsernameAttribute) == 0 {
+		cfg.LDAPUsernameAttribute = "uid"
+	}
+
+	cfg.LDAPGroupAttribute = os.Getenv("LDAP_GROUP_ATTRIBUTE")
+	cfg.LDAPGroupBaseDN = os.Getenv("LDAP_GROUP_BASE_DN")
+	cfg.LDAPGroupFilter = os.Getenv("LDAP_GROUP_FILTER")
+	if len(cfg.LDAPGroupFilter) > 0 && !strings.Contains(cfg.LDAPGroupFilter, "{dn}") {
+		return fmt.Errorf("LDAP_GROUP_FILTER must contain {dn}")
+	}
+
+	cfg.LDAPUserGroup = os.Getenv("LDAP_USER_GROUP")
+	cfg.LDAPAdminGroup = os.Getenv("LDAP_ADMIN_GROUP")
+	groupsFound := len(cfg.LDAPGroupAttribute) > 0 || len(cfg.LDAPGroupFilter) > 0
+	if (len(cfg.LDAPUserGroup) > 0 || len(cfg.LDAPAdminGroup) > 0) && !groupsFound {
+		return fmt.Errorf("LDAP_USER_GROUP and LDAP_ADMIN_GROUP require LDAP_GROUP_ATTRIBUTE or LDAP_GROUP_FILTER")
+	}
+
+	return nil
+}
+
 func loadMasterKey() string {
 	key, _ := os.ReadFile("master.key")
 	if len(key) == 0 {
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"log/slog"
 	"net/http"
@@ -9,11 +10,26 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	// The image has no zoneinfo, profile timezones are checked by the embedded one
+	_ "time/tzdata"
 
 	accountsDB "passman/internal/server/accounts/adapters/db"
 	accountsHTTP "passman/internal/server/accounts/adapters/http"
 	accountsUsecases "passman/internal/server/accounts/usecases"
 	"passman/internal/server/backups"
+	emergencyDB "passman/internal/server/emergency/adapters/db"
+	emergencyHTTP "passman/internal/server/emergency/adapters/http"
+	emergencyUsecases "passman/internal/server/emergency/usecases"
+	exportsHTTP "passman/internal/server/exports/adapters/http"
+	exportsUsecases "passman/internal/server/exports/usecases"
+	importsHTTP "passman/internal/server/imports/adapters/http"
+	importsUsecases "passman/internal/server/imports/usecases"
+	organizationsDB "passman/internal/server/organizations/adapters/db"
+	organizationsHTTP "passman/internal/server/organizations/adapters/http"
+	organizationsUsecases "passman/internal/server/organizations/usecases"
+	sendsDB "passman/internal/server/sends/adapters/db"
+	sendsHTTP "passman/internal/server/sends/adapters/http"
+	sendsUsecases "passman/internal/server/sends/usecases"
 	servicesDB "passman/internal/server/services/adapters/db"
 	servicesHTTP "passman/internal/server/services/adapters/http"
 	servicesUsecases "passman/internal/server/services/usecases"
@@ -23,8 +39,11 @@ import (
 	usersUsecases "passman/internal/server/users/usecases"
 	"passman/pkg/database/migrator"
 	database "passman/pkg/database/sqlite"
+	"passman/pkg/ldap"
 	"passman/pkg/logger"
+	"passman/pkg/oidc"
 	"passman/pkg/session"
+	"passman/pkg/webauthn"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -43,12 +62,6 @@ func main() {
 		os.Exit(1)
 	}
 
-	sm, err := session.NewSessionManager(mainCtx, session.SessionManagerOptions{CookieHTTPOnly: true})
-	if err != nil {
-		slog.Error("Session error", slog.String("error", err.Error()))
-		os.Exit(1)
-	}
-
 	dbStorage, err := database.NewDB(mainCtx, cfg.DBURL)
 	if err != nil {
 		slog.Error("Database error", slog.String("error", err.Error()))
@@ -66,6 +79,21 @@ func main() {
 	}
 	m.Close()
 
+	sessionOpts := session.SessionManagerOptions{
+		Lifetime:       cfg.SessionLifetime,
+		IdleTimeout:    cfg.SessionIdleTimeout,
+		ReauthTimeout:  cfg.SessionReauthTimeout,
+		CookieHTTPOnly: true,
+	}
+	if cfg.SessionStore == "sqlite" {
+		sessionOpts.DB = dbStorage
+	}
+	sm, err := session.NewSessionManager(mainCtx, sessionOpts)
+	if err != nil {
+		slog.Error("Session error", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
 	backupController := backups.New(
 		backups.ControllerOptions{
 			DBURL:     cfg.DBURL,
@@ -93,19 +121,71 @@ func main() {
 
 	globalValidator := validator.New(validator.WithRequiredStructEnabled())
 
+	userRepository := usersDB.New(dbStorage)
+	relyingParty := webauthn.New(cfg.WebAuthnRPID, "passman", cfg.WebAuthnOrigins...)
+	// The nil directory has to stay the nil interface
+	ldapOpts := usersUsecases.LDAPOptions{}
+	if len(cfg.LDAPURL) > 0 {
+		ldapOpts = usersUsecases.LDAPOptions{
+			Directory: ldap.New(ldap.Config{
+				URL:               cfg.LDAPURL,
+				StartTLS:          cfg.LDAPStartTLS,
+				TLS:               &tls.Config{RootCAs: cfg.LDAPRootCAs},
+				BindDN:            cfg.LDAPBindDN,
+				BindPassword:      cfg.LDAPBindPassword,
+				BaseDN:            cfg.LDAPBaseDN,
+				UserFilter:        cfg.LDAPUserFilter,
+				UsernameAttribute: cfg.LDAPUsernameAttribute,
+				GroupAttribute:    cfg.LDAPGroupAttribute,
+				GroupBaseDN:       cfg.LDAPGroupBaseDN,
+				GroupFilter:       cfg.LDAPGroupFilter,
+			}),
+			UserGroup:  cfg.LDAPUserGroup,
+			AdminGroup: cfg.LDAPAdminGroup,
+		}
+	}
+	userUsecase := usersUsecases.New(userRepository, usersUsecases.Options{
+		RelyingParty:        relyingParty,
+		RegistrationMode:    cfg.RegistrationMode,
+		PasswordPolicy:      cfg.PasswordPolicy,
+		Argon2Params:        &cfg.Argon2Params,
+		DeletionGracePeriod: cfg.DeletionGracePeriod,
+		OIDC: usersUsecases.OIDCOptions{
+			UserRole:  cfg.OIDCUserRole,
+			AdminRole: cfg.OIDCAdminRole,
+		},
+		LDAP: ldapOpts,
+	})
+
 	appRouter := chi.NewRouter()
 	appRouter.Use(
 		middleware.Timeout(30*time.Second),
 		traceid.Middleware,
 		logger.HTTPLogger(cfg.LogLevel),
+		// Access tokens are checked before sessions, token requests don't use cookies
+		usersHTTP.NewTokenMiddleware(userUsecase),
 		sm.LoadAndSave,
 	)
 
 	// Users domain
-	userRepository := usersDB.New(dbStorage)
-	userUsecase := usersUsecases.New(userRepository)
-	userRouter := usersHTTP.NewRouter(userUsecase, sm, globalValidator)
-	appRouter.Mount("/users", userRouter)
+	adminRouter := usersHTTP.NewAdminRouter(userUsecase, sm, globalValidator)
+	appRouter.Mount("/admin", adminRouter)
+	if len(cfg.OIDCIssuer) > 0 {
+		oidcProvider := oidc.New(oidc.Config{
+			Issuer:       cfg.OIDCIssuer,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+			Scopes:       cfg.OIDCScopes,
+		})
+		oidcRouter := usersHTTP.NewOIDCRouter(userUsecase, sm, globalValidator, usersHTTP.OIDCConfig{
+			Provider:      oidcProvider,
+			UsernameClaim: cfg.OIDCUsernameClaim,
+			RolesClaim:    cfg.OIDCRolesClaim,
+			PostLoginURL:  cfg.OIDCPostLoginURL,
+		})
+		appRouter.Mount("/users/oidc", oidcRouter)
+	}
 
 	// Accounts domain
 	accountsRepository := accountsDB.New(dbStorage)
@@ -113,12 +193,44 @@ func main() {
 	accountsRouter := accountsHTTP.NewRouter(accountsUsecase, sm, globalValidator)
 	appRouter.Mount("/accounts", accountsRouter)
 
+	// Organizations domain
+	organizationsRepository := organizationsDB.New(dbStorage)
+	organizationsUsecase := organizationsUsecases.New(organizationsRepository)
+	organizationsRouter := organizationsHTTP.NewRouter(organizationsUsecase, sm, globalValidator)
+	appRouter.Mount("/organizations", organizationsRouter)
+
 	// Services domain
 	servicesRepository := servicesDB.New(dbStorage)
 	servicesUsecase := servicesUsecases.New(servicesRepository, cfg.AssetsDir)
 	servicesRouter := servicesHTTP.NewRouter(servicesUsecase, sm, globalValidator)
 	appRouter.Mount("/services", servicesRouter)
 
+	// Imports domain
+	importsUsecase := importsUsecases.New(accountsUsecase, servicesUsecase)
+	importsRouter := importsHTTP.NewRouter(importsUsecase, sm, globalValidator)
+	appRouter.Mount("/imports", importsRouter)
+
+	// Exports domain
+	exportsUsecase := exportsUsecases.New(accountsUsecase, userUsecase)
+	exportsRouter := exportsHTTP.NewRouter(exportsUsecase, sm, globalValidator)
+	appRouter.Mount("/export", exportsRouter)
+
+	// Removed users get the final export, the users router is mounted after the exports domain
+	userRouter := usersHTTP.NewRouter(userUsecase, exportsUsecase, sm, globalValidator)
+	appRouter.Mount("/users", userRouter)
+
+	// Emergency access domain
+	emergencyRepository := emergencyDB.New(dbStorage)
+	emergencyUsecase := emergencyUsecases.New(emergencyRepository, accountsUsecase, userUsecase)
+	emergencyRouter := emergencyHTTP.NewRouter(emergencyUsecase, sm, globalValidator)
+	appRouter.Mount("/users/emergency", emergencyRouter)
+
+	// Sends domain
+	sendsRepository := sendsDB.New(dbStorage)
+	sendsUsecase := sendsUsecases.New(sendsRepository, userUsecase, &cfg.Argon2Params)
+	sendsRouter := sendsHTTP.NewRouter(sendsUsecase, sm, globalValidator)
+	appRouter.Mount("/sends", sendsRouter)
+
 	srv := &http.Server{
 		Addr:    ":5000",
 		Handler: appRouter,
@@ -132,6 +244,25 @@ func main() {
 		slog.Default().Info("Server started", slog.String("address", srv.Addr))
 		return srv.ListenAndServe()
 	})
+	g.Go(func() error {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			purged, err := userUsecase.PurgeDeletedUsers(gCtx)
+			if err != nil {
+				slog.Default().Error("Failed purging deleted users", slog.String("error", err.Error()))
+			} else if purged > 0 {
+				slog.Default().Info("Deleted users purged", slog.Int("count", purged))
+			}
+
+			select {
+			case <-gCtx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	})
 	g.Go(func() error {
 		<-gCtx.Done()
 
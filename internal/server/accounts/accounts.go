@@ -1,7 +1,9 @@
 package accounts
 
 import (
+	"bytes"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -14,6 +16,13 @@ import (
 type QueryParams struct {
 	UserID      uuid.UUID
 	ServiceName string
+	// Organization and Collection select the collection vault instead of
+	// the personal vault of the user
+	Organization string
+	Collection   string
+	// CollectionID is resolved from Organization and Collection by the
+	// usecase, accounts of the collection have no UserID
+	CollectionID uuid.UUID
 }
 
 type AccountDTO struct {
@@ -21,33 +30,62 @@ type AccountDTO struct {
 	Name     string
 	Login    string
 	Password string
+	// TOTP is an optional otpauth:// URI or base32 secret of the account
+	TOTP string
+	// Owner is the username of the owner of the shared account, it's empty for own accounts
+	Owner      string
+	Permission Permission
+}
+
+// payloadVersion marks the JSON payload, the legacy payload is
+// 'login'-:-'password' without the version
+const payloadVersion = 2
+
+type payload struct {
+	Version  int    `json:"v"`
+	Login    string `json:"login"`
+	Password string `json:"password"`
+	TOTP     string `json:"totp,omitempty"`
 }
 
 func (crt *AccountDTO) ToAccount(serviceID uuid.UUID, ciphers []cipher.AESCipher) Account {
 	keyIndx := time.Now().Nanosecond() % len(ciphers)
 
-	src := make([]byte, 0, len(crt.Login)+len(crt.Password)+7)
-	src = fmt.Appendf(src, "'%s'-:-'%s'", crt.Login, crt.Password)
+	// Marshaling of strings doesn't fail
+	src, _ := json.Marshal(payload{
+		Version:  payloadVersion,
+		Login:    crt.Login,
+		Password: crt.Password,
+		TOTP:     crt.TOTP,
+	})
 
 	encryptedSrc := ciphers[keyIndx].Encrypt(src)
 
 	return Account{
-		ID:        uuid.New(),
-		UserID:    crt.UserID,
-		ServiceID: serviceID,
-		Name:      crt.Name,
-		Secret:    int64(keyIndx),
-		Payload:   hex.EncodeToString(encryptedSrc),
+		ID:           uuid.New(),
+		UserID:       crt.UserID,
+		CollectionID: crt.CollectionID,
+		ServiceID:    serviceID,
+		ServiceName:  crt.ServiceName,
+		Name:         crt.Name,
+		Secret:       int64(keyIndx),
+		Payload:      hex.EncodeToString(encryptedSrc),
 	}
 }
 
 type Account struct {
-	ID        uuid.UUID
-	UserID    uuid.UUID
-	ServiceID uuid.UUID
-	Name      string
-	Secret    int64
-	Payload   string
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	ServiceID   uuid.UUID
+	ServiceName string
+	Name        string
+	Secret      int64
+	Payload     string
+	// CollectionID is set instead of UserID for accounts of the collection
+	CollectionID uuid.UUID
+	// Owner and Permission are set only for accounts shared with the user
+	Owner      string
+	Permission Permission
 }
 
 func (cr *Account) ToAccountDTO(ciphers []cipher.AESCipher) (AccountDTO, error) {
@@ -58,16 +96,112 @@ func (cr *Account) ToAccountDTO(ciphers []cipher.AESCipher) (AccountDTO, error)
 
 	decryptedSrc := ciphers[cr.Secret].Decrypt(src)
 
-	splited := strings.Split(string(decryptedSrc), "'-:-'")
-
-	if len(splited) != 2 {
-		return AccountDTO{}, fmt.Errorf("separator not found")
+	pld, err := decodePayload(decryptedSrc)
+	if err != nil {
+		return AccountDTO{}, err
 	}
 
 	return AccountDTO{
-		QueryParams: QueryParams{UserID: cr.UserID},
+		QueryParams: QueryParams{UserID: cr.UserID, ServiceName: cr.ServiceName},
 		Name:        cr.Name,
-		Login:       splited[0][1:],
-		Password:    splited[1][:len(splited[1])-1],
+		Login:       pld.Login,
+		Password:    pld.Password,
+		TOTP:        pld.TOTP,
+		Owner:       cr.Owner,
+		Permission:  cr.Permission,
 	}, nil
 }
+
+// decodePayload reads the JSON payload or the legacy 'login'-:-'password'
+// payload of accounts stored before the TOTP was added
+func decodePayload(src []byte) (payload, error) {
+	if bytes.HasPrefix(src, []byte("{")) {
+		var pld payload
+		if err := json.Unmarshal(src, &pld); err != nil {
+			return payload{}, fmt.Errorf("payload is not in json encoding")
+		}
+		if pld.Version != payloadVersion {
+			return payload{}, fmt.Errorf("unsupported payload version %d", pld.Version)
+		}
+		return pld, nil
+	}
+
+	// The legacy payload with the separator in the login or the password
+	// can't be decoded
+	splited := strings.Split(string(src), "'-:-'")
+
+	if len(splited) != 2 {
+		return payload{}, fmt.Errorf("separator not found")
+	}
+
+	return payload{
+		Login:    strings.TrimPrefix(splited[0], "'"),
+		Password: strings.TrimSuffix(splited[1], "'"),
+	}, nil
+}
+
+type Permission string
+
+const (
+	PermissionOwner Permission = "owner"
+	PermissionRead  Permission = "read"
+	PermissionWrite Permission = "write"
+)
+
+// Share grants the access to the account of the owner to another user.
+// Shared accounts are not copied: accounts are encrypted by the server keys,
+// so the grantee reads the same record as the owner.
+type Share struct {
+	AccountID  uuid.UUID
+	GranteeID  uuid.UUID
+	Grantee    string
+	Permission Permission
+}
+
+type ShareParams struct {
+	QueryParams
+	AccountName string
+	// Grantee is the username of the user who gets the access
+	Grantee    string
+	Permission Permission
+}
+
+type OperationType string
+
+const (
+	OperationCreate OperationType = "create"
+	OperationUpdate OperationType = "update"
+	OperationDelete OperationType = "delete"
+)
+
+type BatchMode string
+
+const (
+	// BatchAtomic applies all operations in one transaction or none of them
+	BatchAtomic BatchMode = "atomic"
+	// BatchBestEffort applies every operation independently
+	BatchBestEffort BatchMode = "best_effort"
+)
+
+type Operation struct {
+	Type    OperationType
+	OldName string
+	AccountDTO
+}
+
+type OperationStatus string
+
+const (
+	OperationApplied    OperationStatus = "applied"
+	OperationFailed     OperationStatus = "failed"
+	OperationRolledBack OperationStatus = "rolled_back"
+	OperationSkipped    OperationStatus = "skipped"
+)
+
+type OperationResult struct {
+	Index  int
+	Type   OperationType
+	Name   string
+	Status OperationStatus
+	Err    error
+}
@@ -1,6 +1,7 @@
 package accounts
 
 import (
+	"encoding/hex"
 	"testing"
 
 	"passman/pkg/cipher"
@@ -28,9 +29,46 @@ func TestEntities(t *testing.T) {
 		t.Errorf("Wrong! Unexpected convertation result!\n\tExpected: %v\n\tActual: %v\n", correctTransfer, checkTransfer)
 	}
 
+	withTOTPTransfer := correctTransfer
+	withTOTPTransfer.TOTP = "otpauth://totp/service:name?secret=JBSWY3DPEHPK3PXP"
+	withTOTPRecord := withTOTPTransfer.ToAccount(uuid.New(), ciphs)
+	if checkTransfer, err := withTOTPRecord.ToAccountDTO(ciphs); err != nil {
+		t.Errorf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v\n", nil, err)
+	} else if checkTransfer != withTOTPTransfer {
+		t.Errorf("Wrong! Unexpected convertation result!\n\tExpected: %v\n\tActual: %v\n", withTOTPTransfer, checkTransfer)
+	}
+
+	withSeparatorTransfer := withTOTPTransfer
+	withSeparatorTransfer.Password = "pass'-:-'word"
+	withSeparatorRecord := withSeparatorTransfer.ToAccount(uuid.New(), ciphs)
+	if checkTransfer, err := withSeparatorRecord.ToAccountDTO(ciphs); err != nil {
+		t.Errorf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v\n", nil, err)
+	} else if checkTransfer != withSeparatorTransfer {
+		t.Errorf("Wrong! Unexpected convertation result!\n\tExpected: %v\n\tActual: %v\n", withSeparatorTransfer, checkTransfer)
+	}
+
+	legacyRecord := correctRecord
+	legacyRecord.Payload = hex.EncodeToString(ciphs[legacyRecord.Secret].Encrypt([]byte("'login'-:-'password'")))
+	if checkTransfer, err := legacyRecord.ToAccountDTO(ciphs); err != nil {
+		t.Errorf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v\n", nil, err)
+	} else if checkTransfer != correctTransfer {
+		t.Errorf("Wrong! Unexpected convertation result!\n\tExpected: %v\n\tActual: %v\n", correctTransfer, checkTransfer)
+	}
+
+	unknownVersionRecord := correctRecord
+	unknownVersionRecord.Payload = hex.EncodeToString(ciphs[unknownVersionRecord.Secret].Encrypt([]byte(`{"v":3,"login":"login"}`)))
+	errMsg := "unsupported payload version 3"
+	if _, err := unknownVersionRecord.ToAccountDTO(ciphs); err != nil {
+		if err.Error() != errMsg {
+			t.Errorf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v\n", errMsg, err.Error())
+		}
+	} else {
+		t.Errorf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: nil\n", errMsg)
+	}
+
 	notHexRecord := correctRecord
 	notHexRecord.Payload = "not in hex"
-	errMsg := "payload is not in hex encoding"
+	errMsg = "payload is not in hex encoding"
 	if _, err := notHexRecord.ToAccountDTO(ciphs); err != nil {
 		if err.Error() != errMsg {
 			t.Errorf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v\n", errMsg, err.Error())
@@ -4,78 +4,277 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 
 	"passman/internal/server/accounts"
 	"passman/internal/server/accounts/adapters/db/queries"
+	"passman/internal/server/organizations"
 
 	"github.com/google/uuid"
 )
 
+type txKey struct{}
+
 type Adapter struct {
+	db      *sql.DB
 	storage *queries.Queries
 }
 
-func New(st queries.DBTX) *Adapter {
-	return &Adapter{storage: queries.New(st)}
+func New(db *sql.DB) *Adapter {
+	return &Adapter{db: db, storage: queries.New(db)}
+}
+
+// WithinTx runs fn in one transaction. Every adapter call made with txCtx
+// is executed inside of it.
+func (a *Adapter) WithinTx(ctx context.Context, fn func(txCtx context.Context) error) (err error) {
+	sqlTx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed starting transaction: %w", err)
+	}
+	defer func() {
+		rollbackErr := sqlTx.Rollback()
+		if rollbackErr != nil && !errors.Is(rollbackErr, sql.ErrTxDone) {
+			err = errors.Join(err, rollbackErr)
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, a.storage.WithTx(sqlTx))); err != nil {
+		return err
+	}
+
+	return sqlTx.Commit()
+}
+
+func (a *Adapter) queries(ctx context.Context) *queries.Queries {
+	if tx, ok := ctx.Value(txKey{}).(*queries.Queries); ok {
+		return tx
+	}
+	return a.storage
 }
 
 func (a *Adapter) AddAccount(ctx context.Context, newAccount accounts.Account) error {
 	params := queries.AddAccountParams{
-		ID:        newAccount.ID,
-		UserID:    newAccount.UserID,
-		ServiceID: newAccount.ServiceID,
-		Name:      newAccount.Name,
-		Secret:    newAccount.Secret,
-		Payload:   newAccount.Payload,
+		ID:           newAccount.ID,
+		UserID:       nullUUID(newAccount.UserID),
+		CollectionID: nullUUID(newAccount.CollectionID),
+		ServiceID:    newAccount.ServiceID,
+		Name:         newAccount.Name,
+		Secret:       newAccount.Secret,
+		Payload:      newAccount.Payload,
 	}
-	return a.storage.AddAccount(ctx, params)
+	return a.queries(ctx).AddAccount(ctx, params)
 }
 
 func (a *Adapter) GetUserAccountsInService(ctx context.Context, queryParams accounts.QueryParams) ([]accounts.Account, error) {
 	params := queries.GetUserAccountsInServiceParams{
-		UserID: queryParams.UserID,
-		Name:   queryParams.ServiceName,
+		UserID:       nullUUID(queryParams.UserID),
+		CollectionID: nullUUID(queryParams.CollectionID),
+		Name:         queryParams.ServiceName,
 	}
 
-	rows, err := a.storage.GetUserAccountsInService(ctx, params)
+	rows, err := a.queries(ctx).GetUserAccountsInService(ctx, params)
 	if err != nil {
 		return nil, err
 	}
 
 	res := make([]accounts.Account, 0, len(rows))
 	for _, row := range rows {
-		res = append(res, accounts.Account{UserID: queryParams.UserID, Name: row.Name, Secret: row.Secret, Payload: row.Payload})
+		res = append(res, accounts.Account{
+			UserID:       queryParams.UserID,
+			CollectionID: queryParams.CollectionID,
+			Name:         row.Name,
+			Secret:       row.Secret,
+			Payload:      row.Payload,
+		})
+	}
+	return res, nil
+}
+
+func (a *Adapter) GetUserAccounts(ctx context.Context, userID uuid.UUID) ([]accounts.Account, error) {
+	rows, err := a.queries(ctx).GetUserAccounts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]accounts.Account, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, accounts.Account{
+			ID:          row.ID,
+			UserID:      userID,
+			ServiceName: row.ServiceName,
+			Name:        row.Name,
+			Secret:      row.Secret,
+			Payload:     row.Payload,
+		})
 	}
 	return res, nil
 }
 
 func (a *Adapter) GetServiceID(ctx context.Context, serviceName string) (uuid.UUID, error) {
-	return a.storage.GetServiceID(ctx, serviceName)
+	return a.queries(ctx).GetServiceID(ctx, serviceName)
 }
 
-func (a *Adapter) GetAccountID(ctx context.Context, userID, serviceID uuid.UUID, credName string) (uuid.UUID, error) {
-	return a.storage.GetAccountID(ctx, queries.GetAccountIDParams{UserID: userID, ServiceID: serviceID, Name: credName})
+func (a *Adapter) GetAccountID(ctx context.Context, queryParams accounts.QueryParams, serviceID uuid.UUID, credName string) (uuid.UUID, error) {
+	params := queries.GetAccountIDParams{
+		UserID:       nullUUID(queryParams.UserID),
+		CollectionID: nullUUID(queryParams.CollectionID),
+		ServiceID:    serviceID,
+		Name:         credName,
+	}
+	return a.queries(ctx).GetAccountID(ctx, params)
 }
 
 func (a *Adapter) UpdateAccount(ctx context.Context, oldServiceName string, updatedAccount accounts.Account) error {
 	params := queries.UpdateAccountParams{
-		UserID:  updatedAccount.UserID,
-		OldName: oldServiceName,
-		Name:    updatedAccount.Name,
-		Secret:  updatedAccount.Secret,
-		Payload: updatedAccount.Payload,
+		UserID:       nullUUID(updatedAccount.UserID),
+		CollectionID: nullUUID(updatedAccount.CollectionID),
+		OldName:      oldServiceName,
+		Name:         updatedAccount.Name,
+		Secret:       updatedAccount.Secret,
+		Payload:      updatedAccount.Payload,
+	}
+	return a.queries(ctx).UpdateAccount(ctx, params)
+}
+
+func (a *Adapter) RemoveAccount(ctx context.Context, queryParams accounts.QueryParams, accName string) error {
+	params := queries.RemoveAccountParams{
+		UserID:       nullUUID(queryParams.UserID),
+		CollectionID: nullUUID(queryParams.CollectionID),
+		Name:         accName,
+		ServiceName:  queryParams.ServiceName,
+	}
+	return a.queries(ctx).RemoveAccount(ctx, params)
+}
+
+func (a *Adapter) RemoveAllAccountsInService(ctx context.Context, queryParams accounts.QueryParams) error {
+	params := queries.RemoveAllAccountsInServiceParams{
+		UserID:       nullUUID(queryParams.UserID),
+		CollectionID: nullUUID(queryParams.CollectionID),
+		Name:         queryParams.ServiceName,
+	}
+	return a.queries(ctx).RemoveAllAccountsInService(ctx, params)
+}
+
+func (a *Adapter) UpdateAccountByID(ctx context.Context, account accounts.Account) error {
+	return a.queries(ctx).UpdateAccountByID(ctx, queries.UpdateAccountByIDParams{ID: account.ID, Secret: account.Secret, Payload: account.Payload})
+}
+
+func (a *Adapter) GetUserID(ctx context.Context, username string) (uuid.UUID, error) {
+	return a.queries(ctx).GetUserID(ctx, username)
+}
+
+func (a *Adapter) AddShare(ctx context.Context, share accounts.Share) error {
+	params := queries.AddShareParams{
+		AccountID:  share.AccountID,
+		GranteeID:  share.GranteeID,
+		Permission: string(share.Permission),
+	}
+	return a.queries(ctx).AddShare(ctx, params)
+}
+
+func (a *Adapter) GetAccountShares(ctx context.Context, accountID uuid.UUID) ([]accounts.Share, error) {
+	rows, err := a.queries(ctx).GetAccountShares(ctx, accountID)
+	if err != nil {
+		return nil, err
 	}
-	return a.storage.UpdateAccount(ctx, params)
+
+	res := make([]accounts.Share, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, accounts.Share{
+			AccountID:  accountID,
+			GranteeID:  row.GranteeID,
+			Grantee:    row.Username,
+			Permission: accounts.Permission(row.Permission),
+		})
+	}
+	return res, nil
 }
 
-func (a *Adapter) RemoveAccount(ctx context.Context, userID uuid.UUID, accName, serviceName string) error {
-	return a.storage.RemoveAccount(ctx, queries.RemoveAccountParams{UserID: userID, Name: accName, ServiceName: serviceName})
+func (a *Adapter) RemoveShare(ctx context.Context, accountID, granteeID uuid.UUID) error {
+	return a.queries(ctx).RemoveShare(ctx, queries.RemoveShareParams{AccountID: accountID, GranteeID: granteeID})
 }
 
-func (a *Adapter) RemoveAllAccountsInService(ctx context.Context, userID uuid.UUID, serviceName string) error {
-	return a.storage.RemoveAllAccountsInService(ctx, queries.RemoveAllAccountsInServiceParams{UserID: userID, Name: serviceName})
+func (a *Adapter) GetSharedAccounts(ctx context.Context, granteeID uuid.UUID) ([]accounts.Account, error) {
+	rows, err := a.queries(ctx).GetSharedAccounts(ctx, granteeID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]accounts.Account, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, accounts.Account{
+			ID:          row.ID,
+			ServiceName: row.ServiceName,
+			Name:        row.Name,
+			Secret:      row.Secret,
+			Payload:     row.Payload,
+			Owner:       row.Owner,
+			Permission:  accounts.Permission(row.Permission),
+		})
+	}
+	return res, nil
+}
+
+func (a *Adapter) GetSharedAccountsInService(ctx context.Context, queryParams accounts.QueryParams) ([]accounts.Account, error) {
+	params := queries.GetSharedAccountsInServiceParams{
+		GranteeID: queryParams.UserID,
+		Name:      queryParams.ServiceName,
+	}
+
+	rows, err := a.queries(ctx).GetSharedAccountsInService(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]accounts.Account, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, accounts.Account{
+			ID:         row.ID,
+			Name:       row.Name,
+			Secret:     row.Secret,
+			Payload:    row.Payload,
+			Owner:      row.Owner,
+			Permission: accounts.Permission(row.Permission),
+		})
+	}
+	return res, nil
+}
+
+func (a *Adapter) GetSharedAccount(ctx context.Context, granteeID, serviceID uuid.UUID, owner, accountName string) (uuid.UUID, accounts.Permission, error) {
+	params := queries.GetSharedAccountParams{
+		GranteeID: granteeID,
+		Username:  owner,
+		ServiceID: serviceID,
+		Name:      accountName,
+	}
+
+	row, err := a.queries(ctx).GetSharedAccount(ctx, params)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	return row.ID, accounts.Permission(row.Permission), nil
+}
+
+func (a *Adapter) GetCollectionRole(ctx context.Context, userID uuid.UUID, orgName, collectionName string) (uuid.UUID, organizations.Role, error) {
+	params := queries.GetCollectionRoleParams{
+		UserID:           userID,
+		OrganizationName: orgName,
+		CollectionName:   collectionName,
+	}
+
+	row, err := a.queries(ctx).GetCollectionRole(ctx, params)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	return row.ID, organizations.Role(row.Role), nil
 }
 
 func (a *Adapter) IsEmptyRows(err error) bool {
 	return errors.Is(err, sql.ErrNoRows)
 }
+
+// nullUUID stores uuid.Nil as NULL, accounts have either the user or the
+// collection
+func nullUUID(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
+}
@@ -12,22 +12,24 @@ import (
 )
 
 const addAccount = `-- name: AddAccount :exec
-insert into accounts (id, user_id, service_id, name, secret, payload) values (?, ?, ?, ?, ?, ?)
+insert into accounts (id, user_id, collection_id, service_id, name, secret, payload) values (?, ?, ?, ?, ?, ?, ?)
 `
 
 type AddAccountParams struct {
-	ID        uuid.UUID
-	UserID    uuid.UUID
-	ServiceID uuid.UUID
-	Name      string
-	Secret    int64
-	Payload   string
+	ID           uuid.UUID
+	UserID       uuid.NullUUID
+	CollectionID uuid.NullUUID
+	ServiceID    uuid.UUID
+	Name         string
+	Secret       int64
+	Payload      string
 }
 
 func (q *Queries) AddAccount(ctx context.Context, arg AddAccountParams) error {
 	_, err := q.db.ExecContext(ctx, addAccount,
 		arg.ID,
 		arg.UserID,
+		arg.CollectionID,
 		arg.ServiceID,
 		arg.Name,
 		arg.Secret,
@@ -37,17 +39,23 @@ func (q *Queries) AddAccount(ctx context.Context, arg AddAccountParams) error {
 }
 
 const getAccountID = `-- name: GetAccountID :one
-select id from accounts where name = ? and service_id = ? and user_id = ?
+select id from accounts where name = ? and service_id = ? and user_id is ? and collection_id is ?
 `
 
 type GetAccountIDParams struct {
-	Name      string
-	ServiceID uuid.UUID
-	UserID    uuid.UUID
+	Name         string
+	ServiceID    uuid.UUID
+	UserID       uuid.NullUUID
+	CollectionID uuid.NullUUID
 }
 
 func (q *Queries) GetAccountID(ctx context.Context, arg GetAccountIDParams) (uuid.UUID, error) {
-	row := q.db.QueryRowContext(ctx, getAccountID, arg.Name, arg.ServiceID, arg.UserID)
+	row := q.db.QueryRowContext(ctx, getAccountID,
+		arg.Name,
+		arg.ServiceID,
+		arg.UserID,
+		arg.CollectionID,
+	)
 	var id uuid.UUID
 	err := row.Scan(&id)
 	return id, err
@@ -64,15 +72,60 @@ func (q *Queries) GetServiceID(ctx context.Context, name string) (uuid.UUID, err
 	return id, err
 }
 
+const getUserAccounts = `-- name: GetUserAccounts :many
+select accounts.id, accounts.name, accounts.secret, accounts.payload, services.name as service_name from accounts
+  join services on services.id = accounts.service_id
+  where accounts.user_id = ?
+  order by services.name, accounts.name
+`
+
+type GetUserAccountsRow struct {
+	ID          uuid.UUID
+	Name        string
+	Secret      int64
+	Payload     string
+	ServiceName string
+}
+
+func (q *Queries) GetUserAccounts(ctx context.Context, userID uuid.UUID) ([]GetUserAccountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUserAccounts, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserAccountsRow
+	for rows.Next() {
+		var i GetUserAccountsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Secret,
+			&i.Payload,
+			&i.ServiceName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserAccountsInService = `-- name: GetUserAccountsInService :many
 select accounts.id, accounts.name, accounts.secret, accounts.payload from accounts
   left join services on services.id = accounts.service_id
-  where accounts.user_id = ? and services.name = ?
+  where accounts.user_id is ? and accounts.collection_id is ? and services.name = ?
 `
 
 type GetUserAccountsInServiceParams struct {
-	UserID uuid.UUID
-	Name   string
+	UserID       uuid.NullUUID
+	CollectionID uuid.NullUUID
+	Name         string
 }
 
 type GetUserAccountsInServiceRow struct {
@@ -83,7 +136,7 @@ type GetUserAccountsInServiceRow struct {
 }
 
 func (q *Queries) GetUserAccountsInService(ctx context.Context, arg GetUserAccountsInServiceParams) ([]GetUserAccountsInServiceRow, error) {
-	rows, err := q.db.QueryContext(ctx, getUserAccountsInService, arg.UserID, arg.Name)
+	rows, err := q.db.QueryContext(ctx, getUserAccountsInService, arg.UserID, arg.CollectionID, arg.Name)
 	if err != nil {
 		return nil, err
 	}
@@ -112,22 +165,29 @@ func (q *Queries) GetUserAccountsInService(ctx context.Context, arg GetUserAccou
 
 const removeAccount = `-- name: RemoveAccount :exec
 delete from accounts
-  where accounts.user_id = ? and
+  where accounts.user_id is ? and
+  accounts.collection_id is ? and
   accounts.name = ? and
   accounts.service_id in (
     select services.id from services
-      where services.name = ?3
+      where services.name = ?4
   )
 `
 
 type RemoveAccountParams struct {
-	UserID      uuid.UUID
-	Name        string
-	ServiceName string
+	UserID       uuid.NullUUID
+	CollectionID uuid.NullUUID
+	Name         string
+	ServiceName  string
 }
 
 func (q *Queries) RemoveAccount(ctx context.Context, arg RemoveAccountParams) error {
-	_, err := q.db.ExecContext(ctx, removeAccount, arg.UserID, arg.Name, arg.ServiceName)
+	_, err := q.db.ExecContext(ctx, removeAccount,
+		arg.UserID,
+		arg.CollectionID,
+		arg.Name,
+		arg.ServiceName,
+	)
 	return err
 }
 
@@ -136,30 +196,32 @@ delete from accounts
   where id in (
     select accounts.id from accounts
       left join services on services.id = accounts.service_id
-      where accounts.user_id = ? and services.name = ?
+      where accounts.user_id is ? and accounts.collection_id is ? and services.name = ?
   )
 `
 
 type RemoveAllAccountsInServiceParams struct {
-	UserID uuid.UUID
-	Name   string
+	UserID       uuid.NullUUID
+	CollectionID uuid.NullUUID
+	Name         string
 }
 
 func (q *Queries) RemoveAllAccountsInService(ctx context.Context, arg RemoveAllAccountsInServiceParams) error {
-	_, err := q.db.ExecContext(ctx, removeAllAccountsInService, arg.UserID, arg.Name)
+	_, err := q.db.ExecContext(ctx, removeAllAccountsInService, arg.UserID, arg.CollectionID, arg.Name)
 	return err
 }
 
 const updateAccount = `-- name: UpdateAccount :exec
-update accounts set name = ?, secret = ?, payload = ? where user_id = ? and name = ?5
+update accounts set name = ?, secret = ?, payload = ? where user_id is ? and collection_id is ? and name = ?6
 `
 
 type UpdateAccountParams struct {
-	Name    string
-	Secret  int64
-	Payload string
-	UserID  uuid.UUID
-	OldName string
+	Name         string
+	Secret       int64
+	Payload      string
+	UserID       uuid.NullUUID
+	CollectionID uuid.NullUUID
+	OldName      string
 }
 
 func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) error {
@@ -168,7 +230,260 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) er
 		arg.Secret,
 		arg.Payload,
 		arg.UserID,
+		arg.CollectionID,
 		arg.OldName,
 	)
 	return err
 }
+
+const updateAccountByID = `-- name: UpdateAccountByID :exec
+update accounts set secret = ?, payload = ? where id = ?
+`
+
+type UpdateAccountByIDParams struct {
+	Secret  int64
+	Payload string
+	ID      uuid.UUID
+}
+
+func (q *Queries) UpdateAccountByID(ctx context.Context, arg UpdateAccountByIDParams) error {
+	_, err := q.db.ExecContext(ctx, updateAccountByID, arg.Secret, arg.Payload, arg.ID)
+	return err
+}
+
+const getUserID = `-- name: GetUserID :one
+select id from users where username = ?
+`
+
+func (q *Queries) GetUserID(ctx context.Context, username string) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, getUserID, username)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const addShare = `-- name: AddShare :exec
+insert into account_shares (account_id, grantee_id, permission) values (?, ?, ?)
+  on conflict (account_id, grantee_id) do update set permission = excluded.permission
+`
+
+type AddShareParams struct {
+	AccountID  uuid.UUID
+	GranteeID  uuid.UUID
+	Permission string
+}
+
+func (q *Queries) AddShare(ctx context.Context, arg AddShareParams) error {
+	_, err := q.db.ExecContext(ctx, addShare, arg.AccountID, arg.GranteeID, arg.Permission)
+	return err
+}
+
+const getAccountShares = `-- name: GetAccountShares :many
+select account_shares.grantee_id, users.username, account_shares.permission from account_shares
+  join users on users.id = account_shares.grantee_id
+  where account_shares.account_id = ?
+  order by users.username
+`
+
+type GetAccountSharesRow struct {
+	GranteeID  uuid.UUID
+	Username   string
+	Permission string
+}
+
+func (q *Queries) GetAccountShares(ctx context.Context, accountID uuid.UUID) ([]GetAccountSharesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAccountShares, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAccountSharesRow
+	for rows.Next() {
+		var i GetAccountSharesRow
+		if err := rows.Scan(&i.GranteeID, &i.Username, &i.Permission); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const removeShare = `-- name: RemoveShare :exec
+delete from account_shares where account_id = ? and grantee_id = ?
+`
+
+type RemoveShareParams struct {
+	AccountID uuid.UUID
+	GranteeID uuid.UUID
+}
+
+func (q *Queries) RemoveShare(ctx context.Context, arg RemoveShareParams) error {
+	_, err := q.db.ExecContext(ctx, removeShare, arg.AccountID, arg.GranteeID)
+	return err
+}
+
+const getSharedAccounts = `-- name: GetSharedAccounts :many
+select accounts.id, accounts.name, accounts.secret, accounts.payload, services.name as service_name,
+  users.username as owner, account_shares.permission from account_shares
+  join accounts on accounts.id = account_shares.account_id
+  join services on services.id = accounts.service_id
+  join users on users.id = accounts.user_id
+  where account_shares.grantee_id = ?
+  order by services.name, accounts.name, users.username
+`
+
+type GetSharedAccountsRow struct {
+	ID          uuid.UUID
+	Name        string
+	Secret      int64
+	Payload     string
+	ServiceName string
+	Owner       string
+	Permission  string
+}
+
+func (q *Queries) GetSharedAccounts(ctx context.Context, granteeID uuid.UUID) ([]GetSharedAccountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSharedAccounts, granteeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSharedAccountsRow
+	for rows.Next() {
+		var i GetSharedAccountsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Secret,
+			&i.Payload,
+			&i.ServiceName,
+			&i.Owner,
+			&i.Permission,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSharedAccountsInService = `-- name: GetSharedAccountsInService :many
+select accounts.id, accounts.name, accounts.secret, accounts.payload, users.username as owner, account_shares.permission from account_shares
+  join accounts on accounts.id = account_shares.account_id
+  join services on services.id = accounts.service_id
+  join users on users.id = accounts.user_id
+  where account_shares.grantee_id = ? and services.name = ?
+  order by accounts.name, users.username
+`
+
+type GetSharedAccountsInServiceParams struct {
+	GranteeID uuid.UUID
+	Name      string
+}
+
+type GetSharedAccountsInServiceRow struct {
+	ID         uuid.UUID
+	Name       string
+	Secret     int64
+	Payload    string
+	Owner      string
+	Permission string
+}
+
+func (q *Queries) GetSharedAccountsInService(ctx context.Context, arg GetSharedAccountsInServiceParams) ([]GetSharedAccountsInServiceRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSharedAccountsInService, arg.GranteeID, arg.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSharedAccountsInServiceRow
+	for rows.Next() {
+		var i GetSharedAccountsInServiceRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Secret,
+			&i.Payload,
+			&i.Owner,
+			&i.Permission,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSharedAccount = `-- name: GetSharedAccount :one
+select accounts.id, account_shares.permission from account_shares
+  join accounts on accounts.id = account_shares.account_id
+  join users on users.id = accounts.user_id
+  where account_shares.grantee_id = ? and users.username = ? and accounts.service_id = ? and accounts.name = ?
+`
+
+type GetSharedAccountParams struct {
+	GranteeID uuid.UUID
+	Username  string
+	ServiceID uuid.UUID
+	Name      string
+}
+
+type GetSharedAccountRow struct {
+	ID         uuid.UUID
+	Permission string
+}
+
+func (q *Queries) GetSharedAccount(ctx context.Context, arg GetSharedAccountParams) (GetSharedAccountRow, error) {
+	row := q.db.QueryRowContext(ctx, getSharedAccount,
+		arg.GranteeID,
+		arg.Username,
+		arg.ServiceID,
+		arg.Name,
+	)
+	var i GetSharedAccountRow
+	err := row.Scan(&i.ID, &i.Permission)
+	return i, err
+}
+
+const getCollectionRole = `-- name: GetCollectionRole :one
+select collections.id, organization_members.role from collections
+  join organizations on organizations.id = collections.organization_id
+  join organization_members on organization_members.organization_id = collections.organization_id
+  where organization_members.user_id = ? and organizations.name = ?2 and collections.name = ?3
+`
+
+type GetCollectionRoleParams struct {
+	UserID           uuid.UUID
+	OrganizationName string
+	CollectionName   string
+}
+
+type GetCollectionRoleRow struct {
+	ID   uuid.UUID
+	Role string
+}
+
+func (q *Queries) GetCollectionRole(ctx context.Context, arg GetCollectionRoleParams) (GetCollectionRoleRow, error) {
+	row := q.db.QueryRowContext(ctx, getCollectionRole, arg.UserID, arg.OrganizationName, arg.CollectionName)
+	var i GetCollectionRoleRow
+	err := row.Scan(&i.ID, &i.Role)
+	return i, err
+}
@@ -10,12 +10,28 @@ import (
 
 	"passman/internal/server/accounts"
 	"passman/internal/server/infra"
+	"passman/internal/server/users"
 
 	"github.com/go-chi/chi/v5"
 	vldtr "github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 )
 
+const maxBatchSize = 100
+
+type batchItemResponse struct {
+	Index  int                      `json:"index"`
+	Type   accounts.OperationType   `json:"type"`
+	Name   string                   `json:"name"`
+	Status accounts.OperationStatus `json:"status"`
+	Error  string                   `json:"error,omitempty"`
+}
+
+type batchResponse struct {
+	Error   string              `json:"error,omitempty"`
+	Results []batchItemResponse `json:"results"`
+}
+
 type Adapter struct {
 	log *slog.Logger
 	cu  accountsUsecases
@@ -33,12 +49,18 @@ func NewRouter(cu accountsUsecases, sm sessionManager, v *vldtr.Validate) chi.Ro
 
 	router := chi.NewRouter()
 
-	router.Use(infra.AuthMiddleware(sm))
+	// Access tokens with the scopes are accepted too
+	router.Use(infra.ScopedAuthMiddleware(sm, users.ScopeAccountsRead, users.ScopeAccountsWrite))
 
+	router.Post("/batch", a.ApplyBatch)
+	router.With(infra.RevealMiddleware(sm, users.ScopeAccountsReveal)).Get("/shared", a.GetSharedAccounts)
 	router.Post("/{serviceName}", a.AddAccount)
-	router.Get("/{serviceName}", a.GetAccountsInService)
+	router.With(infra.RevealMiddleware(sm, users.ScopeAccountsReveal)).Get("/{serviceName}", a.GetAccountsInService)
 	router.Put("/{serviceName}", a.UpdateAccount)
 	router.Delete("/{serviceName}/{accountName}", a.RemoveAccount)
+	router.Post("/{serviceName}/{accountName}/shares", a.ShareAccount)
+	router.Get("/{serviceName}/{accountName}/shares", a.GetAccountShares)
+	router.Delete("/{serviceName}/{accountName}/shares/{username}", a.RevokeShare)
 	router.Delete("/{serviceName}", a.RemoveAllAccountsInService)
 
 	return router
@@ -53,10 +75,17 @@ func (a *Adapter) AddAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	params, err := a.queryParams(r, userID, serviceName)
+	if err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	body := struct {
 		Name     string `json:"name"`
 		Login    string `json:"login"`
 		Password string `json:"password"`
+		TOTP     string `json:"totp"`
 	}{}
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		a.log.ErrorContext(r.Context(), "AddAccount: failed parsing body", slog.Any("error", err))
@@ -69,14 +98,17 @@ func (a *Adapter) AddAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := a.v.ValidateTOTP(body.TOTP); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	transfer := accounts.AccountDTO{
-		QueryParams: accounts.QueryParams{
-			ServiceName: serviceName,
-			UserID:      userID,
-		},
-		Name:     body.Name,
-		Login:    body.Login,
-		Password: body.Password,
+		QueryParams: params,
+		Name:        body.Name,
+		Login:       body.Login,
+		Password:    body.Password,
+		TOTP:        body.TOTP,
 	}
 
 	if err := a.cu.AddAccount(r.Context(), transfer); err != nil {
@@ -97,7 +129,13 @@ func (a *Adapter) GetAccountsInService(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	accounts, err := a.cu.GetAccountsInService(r.Context(), accounts.QueryParams{UserID: userID, ServiceName: serviceName})
+	params, err := a.queryParams(r, userID, serviceName)
+	if err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dtos, err := a.cu.GetAccountsInService(r.Context(), params)
 	if err != nil {
 		code, msg := a.ParseUsecaseError(r.Context(), "GetAccountsInService", err)
 		infra.ErrorHandler(w, code, msg)
@@ -105,14 +143,24 @@ func (a *Adapter) GetAccountsInService(w http.ResponseWriter, r *http.Request) {
 	}
 
 	type responseType struct {
-		Name     string `json:"name"`
-		Login    string `json:"login"`
-		Password string `json:"password"`
+		Name       string              `json:"name"`
+		Login      string              `json:"login"`
+		Password   string              `json:"password"`
+		TOTP       string              `json:"totp,omitempty"`
+		Owner      string              `json:"owner,omitempty"`
+		Permission accounts.Permission `json:"permission"`
 	}
 
-	res := make([]responseType, 0, len(accounts))
-	for _, acc := range accounts {
-		res = append(res, responseType{Name: acc.Name, Login: acc.Login, Password: acc.Password})
+	res := make([]responseType, 0, len(dtos))
+	for _, acc := range dtos {
+		res = append(res, responseType{
+			Name:       acc.Name,
+			Login:      acc.Login,
+			Password:   acc.Password,
+			TOTP:       acc.TOTP,
+			Owner:      acc.Owner,
+			Permission: acc.Permission,
+		})
 	}
 
 	infra.ResponseJSON(w, res, http.StatusOK)
@@ -127,11 +175,19 @@ func (a *Adapter) UpdateAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	params, err := a.queryParams(r, userID, serviceName)
+	if err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	body := struct {
 		OldName  string `json:"old_name"`
 		NewName  string `json:"new_name"`
 		Login    string `json:"login"`
 		Password string `json:"password"`
+		TOTP     string `json:"totp"`
+		Owner    string `json:"owner"`
 	}{}
 
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
@@ -145,14 +201,18 @@ func (a *Adapter) UpdateAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := a.v.ValidateTOTP(body.TOTP); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	dto := accounts.AccountDTO{
-		QueryParams: accounts.QueryParams{
-			ServiceName: serviceName,
-			UserID:      userID,
-		},
-		Name:     body.NewName,
-		Login:    body.Login,
-		Password: body.Password,
+		QueryParams: params,
+		Name:        body.NewName,
+		Login:       body.Login,
+		Password:    body.Password,
+		TOTP:        body.TOTP,
+		Owner:       body.Owner,
 	}
 
 	if err := a.cu.UpdateAccount(r.Context(), body.OldName, dto); err != nil {
@@ -173,13 +233,19 @@ func (a *Adapter) RemoveAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	params, err := a.queryParams(r, userID, serviceName)
+	if err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	accountName := chi.URLParam(r, "accountName")
 	if err := a.v.ValidateName(accountName); err != nil {
 		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	if err := a.cu.RemoveAccount(r.Context(), accountName, accounts.QueryParams{ServiceName: serviceName, UserID: userID}); err != nil {
+	if err := a.cu.RemoveAccount(r.Context(), accountName, params); err != nil {
 		code, msg := a.ParseUsecaseError(r.Context(), "RemoveAccount", err)
 		infra.ErrorHandler(w, code, msg)
 		return
@@ -197,7 +263,13 @@ func (a *Adapter) RemoveAllAccountsInService(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	if err := a.cu.RemoveAllAccountsInService(r.Context(), accounts.QueryParams{ServiceName: serviceName, UserID: userID}); err != nil {
+	params, err := a.queryParams(r, userID, serviceName)
+	if err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := a.cu.RemoveAllAccountsInService(r.Context(), params); err != nil {
 		code, msg := a.ParseUsecaseError(r.Context(), "RemoveAllAccountsInService", err)
 		infra.ErrorHandler(w, code, msg)
 		return
@@ -206,6 +278,267 @@ func (a *Adapter) RemoveAllAccountsInService(w http.ResponseWriter, r *http.Requ
 	w.WriteHeader(http.StatusOK)
 }
 
+func (a *Adapter) ShareAccount(w http.ResponseWriter, r *http.Request) {
+	params, ok := a.shareParams(w, r)
+	if !ok {
+		return
+	}
+
+	body := struct {
+		Username   string              `json:"username"`
+		Permission accounts.Permission `json:"permission"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		a.log.ErrorContext(r.Context(), "ShareAccount: failed parsing body", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	if err := a.v.ValidateShare(body.Username, body.Permission); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params.Grantee, params.Permission = body.Username, body.Permission
+
+	if err := a.cu.ShareAccount(r.Context(), params); err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "ShareAccount", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *Adapter) GetAccountShares(w http.ResponseWriter, r *http.Request) {
+	params, ok := a.shareParams(w, r)
+	if !ok {
+		return
+	}
+
+	shares, err := a.cu.GetAccountShares(r.Context(), params.AccountName, params.QueryParams)
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "GetAccountShares", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	type responseType struct {
+		Username   string              `json:"username"`
+		Permission accounts.Permission `json:"permission"`
+	}
+
+	res := make([]responseType, 0, len(shares))
+	for _, share := range shares {
+		res = append(res, responseType{Username: share.Grantee, Permission: share.Permission})
+	}
+
+	infra.ResponseJSON(w, res, http.StatusOK)
+}
+
+func (a *Adapter) RevokeShare(w http.ResponseWriter, r *http.Request) {
+	params, ok := a.shareParams(w, r)
+	if !ok {
+		return
+	}
+
+	params.Grantee = chi.URLParam(r, "username")
+	if err := a.v.ValidateShare(params.Grantee, accounts.PermissionRead); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := a.cu.RevokeShare(r.Context(), params); err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "RevokeShare", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// shareParams reads the account of the user from the URL
+func (a *Adapter) shareParams(w http.ResponseWriter, r *http.Request) (accounts.ShareParams, bool) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	serviceName := chi.URLParam(r, "serviceName")
+	if err := a.v.ValidateName(serviceName); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return accounts.ShareParams{}, false
+	}
+
+	accountName := chi.URLParam(r, "accountName")
+	if err := a.v.ValidateName(accountName); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return accounts.ShareParams{}, false
+	}
+
+	params, err := a.queryParams(r, userID, serviceName)
+	if err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return accounts.ShareParams{}, false
+	}
+
+	return accounts.ShareParams{QueryParams: params, AccountName: accountName}, true
+}
+
+// queryParams selects the vault by the optional "organization" and
+// "collection" query parameters, the personal vault is used without them
+func (a *Adapter) queryParams(r *http.Request, userID uuid.UUID, serviceName string) (accounts.QueryParams, error) {
+	params := accounts.QueryParams{
+		UserID:       userID,
+		ServiceName:  serviceName,
+		Organization: r.URL.Query().Get("organization"),
+		Collection:   r.URL.Query().Get("collection"),
+	}
+
+	if err := a.v.ValidateCollection(params.Organization, params.Collection); err != nil {
+		return accounts.QueryParams{}, err
+	}
+
+	return params, nil
+}
+
+func (a *Adapter) GetSharedAccounts(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	dtos, err := a.cu.GetSharedAccounts(r.Context(), userID)
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "GetSharedAccounts", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	type responseType struct {
+		ServiceName string              `json:"service_name"`
+		Name        string              `json:"name"`
+		Login       string              `json:"login"`
+		Password    string              `json:"password"`
+		TOTP        string              `json:"totp,omitempty"`
+		Owner       string              `json:"owner"`
+		Permission  accounts.Permission `json:"permission"`
+	}
+
+	res := make([]responseType, 0, len(dtos))
+	for _, dto := range dtos {
+		res = append(res, responseType{
+			ServiceName: dto.ServiceName,
+			Name:        dto.Name,
+			Login:       dto.Login,
+			Password:    dto.Password,
+			TOTP:        dto.TOTP,
+			Owner:       dto.Owner,
+			Permission:  dto.Permission,
+		})
+	}
+
+	infra.ResponseJSON(w, res, http.StatusOK)
+}
+
+func (a *Adapter) ApplyBatch(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	body := struct {
+		Mode       accounts.BatchMode `json:"mode"`
+		Operations []struct {
+			Type         accounts.OperationType `json:"type"`
+			ServiceName  string                 `json:"service_name"`
+			Organization string                 `json:"organization"`
+			Collection   string                 `json:"collection"`
+			OldName      string                 `json:"old_name"`
+			Name         string                 `json:"name"`
+			Login        string                 `json:"login"`
+			Password     string                 `json:"password"`
+			TOTP         string                 `json:"totp"`
+		} `json:"operations"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		a.log.ErrorContext(r.Context(), "ApplyBatch: failed parsing body", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	if len(body.Mode) == 0 {
+		body.Mode = accounts.BatchAtomic
+	}
+	if err := a.v.ValidateBatchMode(body.Mode); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(body.Operations) == 0 || len(body.Operations) > maxBatchSize {
+		infra.ErrorHandler(w, http.StatusBadRequest, fmt.Sprintf("batch must contain from 1 to %d operations", maxBatchSize))
+		return
+	}
+
+	// Validate everything before touching the storage
+	res := make([]batchItemResponse, len(body.Operations))
+	ops := make([]accounts.Operation, 0, len(body.Operations))
+	indexes := make([]int, 0, len(body.Operations))
+	invalid := false
+	for i, item := range body.Operations {
+		op := accounts.Operation{
+			Type:    item.Type,
+			OldName: item.OldName,
+			AccountDTO: accounts.AccountDTO{
+				QueryParams: accounts.QueryParams{
+					ServiceName:  item.ServiceName,
+					UserID:       userID,
+					Organization: item.Organization,
+					Collection:   item.Collection,
+				},
+				Name:     item.Name,
+				Login:    item.Login,
+				Password: item.Password,
+				TOTP:     item.TOTP,
+			},
+		}
+
+		name := item.Name
+		if item.Type == accounts.OperationUpdate {
+			name = item.OldName
+		}
+		res[i] = batchItemResponse{Index: i, Type: item.Type, Name: name, Status: accounts.OperationSkipped}
+
+		if err := a.v.ValidateOperation(op); err != nil {
+			res[i].Status, res[i].Error = accounts.OperationFailed, err.Error()
+			invalid = true
+			continue
+		}
+
+		ops = append(ops, op)
+		indexes = append(indexes, i)
+	}
+
+	if invalid && body.Mode == accounts.BatchAtomic {
+		infra.ResponseJSON(w, batchResponse{Error: "invalid operations", Results: res}, http.StatusBadRequest)
+		return
+	}
+
+	results, err := a.cu.ApplyBatch(r.Context(), body.Mode, ops)
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "ApplyBatch", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	code, errMsg := http.StatusOK, ""
+	for _, result := range results {
+		i := indexes[result.Index]
+		res[i].Status = result.Status
+		if result.Err == nil {
+			continue
+		}
+
+		itemCode, msg := a.ParseUsecaseError(r.Context(), "ApplyBatch", result.Err)
+		res[i].Error = msg
+		if body.Mode == accounts.BatchAtomic {
+			code, errMsg = itemCode, "batch aborted"
+		}
+	}
+
+	infra.ResponseJSON(w, batchResponse{Error: errMsg, Results: res}, code)
+}
+
 func (a *Adapter) ParseUsecaseError(ctx context.Context, component string, usecaseError error) (int, string) {
 	code, msg, err := a.cu.ParseMyError(usecaseError)
 	if code == 0 {
@@ -4,6 +4,8 @@ import (
 	"context"
 
 	"passman/internal/server/accounts"
+
+	"github.com/google/uuid"
 )
 
 type accountsUsecases interface {
@@ -12,10 +14,18 @@ type accountsUsecases interface {
 	UpdateAccount(context.Context, string, accounts.AccountDTO) error
 	RemoveAccount(context.Context, string, accounts.QueryParams) error
 	RemoveAllAccountsInService(context.Context, accounts.QueryParams) error
+	ShareAccount(context.Context, accounts.ShareParams) error
+	GetAccountShares(context.Context, string, accounts.QueryParams) ([]accounts.Share, error)
+	RevokeShare(context.Context, accounts.ShareParams) error
+	GetSharedAccounts(context.Context, uuid.UUID) ([]accounts.AccountDTO, error)
+	ApplyBatch(context.Context, accounts.BatchMode, []accounts.Operation) ([]accounts.OperationResult, error)
 	ParseMyError(error) (int, string, error)
 }
 
 type sessionManager interface {
 	GetString(context.Context, string) string
+	Put(context.Context, string, any)
 	Keys(context.Context) []string
+	Destroy(context.Context) error
+	AuthenticatedRecently(context.Context) bool
 }
@@ -2,6 +2,9 @@ package http
 
 import (
 	"fmt"
+	"strings"
+
+	"passman/internal/server/accounts"
 
 	vldtr "github.com/go-playground/validator/v10"
 )
@@ -43,3 +46,94 @@ func (v *validator) ValidateAccount(name string, login string, password string)
 
 	return nil
 }
+
+// ValidateTOTP accepts an empty value, an otpauth:// URI or a base32 secret
+func (v *validator) ValidateTOTP(totp string) error {
+	if len(totp) == 0 {
+		return nil
+	}
+
+	validatingStruct := struct {
+		TOTP string `validate:"max=1024"`
+	}{TOTP: totp}
+
+	if err := v.v.Struct(validatingStruct); err != nil {
+		return fmt.Errorf("invalid totp")
+	}
+
+	if strings.HasPrefix(totp, "otpauth://") {
+		return nil
+	}
+
+	secret := strings.ToUpper(strings.ReplaceAll(totp, " ", ""))
+	if len(secret) == 0 || len(strings.Trim(secret, "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567=")) > 0 {
+		return fmt.Errorf("invalid totp")
+	}
+
+	return nil
+}
+
+// ValidateCollection accepts both names of the collection and its organization or none of them
+func (v *validator) ValidateCollection(orgName, collectionName string) error {
+	if len(orgName) == 0 && len(collectionName) == 0 {
+		return nil
+	}
+
+	if v.ValidateName(orgName) != nil || v.ValidateName(collectionName) != nil {
+		return fmt.Errorf("invalid collection")
+	}
+
+	return nil
+}
+
+func (v *validator) ValidateShare(username string, permission accounts.Permission) error {
+	validatingStruct := struct {
+		Username   string `validate:"required,alphanum,max=15"`
+		Permission string `validate:"oneof=read write"`
+	}{
+		Username:   username,
+		Permission: string(permission),
+	}
+
+	if err := v.v.Struct(validatingStruct); err != nil {
+		return fmt.Errorf("invalid share parameters")
+	}
+
+	return nil
+}
+
+func (v *validator) ValidateBatchMode(mode accounts.BatchMode) error {
+	if mode != accounts.BatchAtomic && mode != accounts.BatchBestEffort {
+		return fmt.Errorf("invalid batch mode")
+	}
+	return nil
+}
+
+func (v *validator) ValidateOperation(op accounts.Operation) error {
+	if err := v.ValidateName(op.ServiceName); err != nil {
+		return err
+	}
+	if err := v.ValidateCollection(op.Organization, op.Collection); err != nil {
+		return err
+	}
+
+	switch op.Type {
+	case accounts.OperationCreate:
+		if err := v.ValidateAccount(op.Name, op.Login, op.Password); err != nil {
+			return err
+		}
+		return v.ValidateTOTP(op.TOTP)
+	case accounts.OperationUpdate:
+		if err := v.ValidateName(op.OldName); err != nil {
+			return err
+		}
+		if err := v.ValidateAccount(op.Name, op.Login, op.Password); err != nil {
+			return err
+		}
+		return v.ValidateTOTP(op.TOTP)
+	case accounts.OperationDelete:
+		return v.ValidateName(op.Name)
+	default:
+		return fmt.Errorf("invalid operation type")
+	}
+}
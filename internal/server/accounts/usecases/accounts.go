@@ -2,6 +2,7 @@ package usecases
 
 import (
 	"context"
+	"errors"
 
 	"passman/internal/server/accounts"
 	"passman/pkg/cipher"
@@ -19,6 +20,12 @@ func New(r repository, c []cipher.AESCipher) *AccountsUsecase {
 }
 
 func (cu *AccountsUsecase) AddAccount(ctx context.Context, dto accounts.AccountDTO) error {
+	vault, err := cu.writableVault(ctx, "AddAccount", dto.QueryParams)
+	if err != nil {
+		return err
+	}
+	dto.QueryParams = vault
+
 	serviceID, err := cu.repo.GetServiceID(ctx, dto.ServiceName)
 	if err != nil {
 		if cu.repo.IsEmptyRows(err) {
@@ -27,7 +34,7 @@ func (cu *AccountsUsecase) AddAccount(ctx context.Context, dto accounts.AccountD
 		return newInternalError("AddAccount", "failed getting service id", err)
 	}
 
-	dublicateID, err := cu.repo.GetAccountID(ctx, dto.UserID, serviceID, dto.Name)
+	dublicateID, err := cu.repo.GetAccountID(ctx, dto.QueryParams, serviceID, dto.Name)
 	if err != nil && !cu.repo.IsEmptyRows(err) {
 		return newInternalError("AddAccount", "failed checking dublicates", err)
 	}
@@ -44,11 +51,29 @@ func (cu *AccountsUsecase) AddAccount(ctx context.Context, dto accounts.AccountD
 	return nil
 }
 
+// GetAccountsInService returns own accounts of the user followed by accounts
+// shared with the user or accounts of the collection
 func (cu *AccountsUsecase) GetAccountsInService(ctx context.Context, params accounts.QueryParams) ([]accounts.AccountDTO, error) {
-	records, err := cu.repo.GetUserAccountsInService(ctx, params)
+	vault, permission, err := cu.vault(ctx, "GetAccountsInService", params)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := cu.repo.GetUserAccountsInService(ctx, vault)
 	if err != nil {
 		return nil, newInternalError("GetAccountsInService", "failed getting accounts", err)
 	}
+	for i := range records {
+		records[i].Permission = permission
+	}
+
+	if len(params.Collection) == 0 {
+		shared, err := cu.repo.GetSharedAccountsInService(ctx, params)
+		if err != nil {
+			return nil, newInternalError("GetAccountsInService", "failed getting shared accounts", err)
+		}
+		records = append(records, shared...)
+	}
 
 	dtos := make([]accounts.AccountDTO, 0, len(records))
 	for _, r := range records {
@@ -62,7 +87,37 @@ func (cu *AccountsUsecase) GetAccountsInService(ctx context.Context, params acco
 	return dtos, nil
 }
 
+func (cu *AccountsUsecase) GetAllAccounts(ctx context.Context, userID uuid.UUID) ([]accounts.AccountDTO, error) {
+	records, err := cu.repo.GetUserAccounts(ctx, userID)
+	if err != nil {
+		return nil, newInternalError("GetAllAccounts", "failed getting accounts", err)
+	}
+
+	dtos := make([]accounts.AccountDTO, 0, len(records))
+	for _, r := range records {
+		dto, err := r.ToAccountDTO(cu.ciphers)
+		if err != nil {
+			return nil, newInternalError("GetAllAccounts", "failed decrypting account", err)
+		}
+		dtos = append(dtos, dto)
+	}
+
+	return dtos, nil
+}
+
 func (cu *AccountsUsecase) UpdateAccount(ctx context.Context, oldAccountName string, updatedAccountDTO accounts.AccountDTO) error {
+	if len(updatedAccountDTO.Owner) > 0 && len(updatedAccountDTO.Collection) > 0 {
+		return newClientError("shared account can't be in collection")
+	}
+
+	vault, err := cu.writableVault(ctx, "UpdateAccount", updatedAccountDTO.QueryParams)
+	if err != nil {
+		return err
+	}
+	if len(updatedAccountDTO.Owner) == 0 {
+		updatedAccountDTO.QueryParams = vault
+	}
+
 	serviceID, err := cu.repo.GetServiceID(ctx, updatedAccountDTO.ServiceName)
 	if err != nil {
 		if cu.repo.IsEmptyRows(err) {
@@ -71,7 +126,11 @@ func (cu *AccountsUsecase) UpdateAccount(ctx context.Context, oldAccountName str
 		return newInternalError("UpdateAccount", "failed getting service id", err)
 	}
 
-	if _, err := cu.repo.GetAccountID(ctx, updatedAccountDTO.UserID, serviceID, oldAccountName); err != nil {
+	if len(updatedAccountDTO.Owner) > 0 {
+		return cu.updateSharedAccount(ctx, serviceID, oldAccountName, updatedAccountDTO)
+	}
+
+	if _, err := cu.repo.GetAccountID(ctx, updatedAccountDTO.QueryParams, serviceID, oldAccountName); err != nil {
 		if cu.repo.IsEmptyRows(err) {
 			return newClientError("invalid old account name")
 		}
@@ -87,8 +146,40 @@ func (cu *AccountsUsecase) UpdateAccount(ctx context.Context, oldAccountName str
 	return nil
 }
 
+// updateSharedAccount updates the account of another user. Only login, password
+// and TOTP can be changed, the name is controlled by the owner.
+func (cu *AccountsUsecase) updateSharedAccount(ctx context.Context, serviceID uuid.UUID, accountName string, dto accounts.AccountDTO) error {
+	accountID, permission, err := cu.repo.GetSharedAccount(ctx, dto.UserID, serviceID, dto.Owner, accountName)
+	if err != nil {
+		if cu.repo.IsEmptyRows(err) {
+			return newClientError("invalid old account name")
+		}
+		return newInternalError("UpdateAccount", "failed getting shared account", err)
+	}
+	if permission != accounts.PermissionWrite {
+		return newClientError("no write permission")
+	}
+	if dto.Name != accountName {
+		return newClientError("shared account can't be renamed")
+	}
+
+	record := dto.ToAccount(serviceID, cu.ciphers)
+	record.ID = accountID
+
+	if err := cu.repo.UpdateAccountByID(ctx, record); err != nil {
+		return newInternalError("UpdateAccount", "failed updating shared account", err)
+	}
+
+	return nil
+}
+
 func (cu *AccountsUsecase) RemoveAccount(ctx context.Context, accountName string, params accounts.QueryParams) error {
-	if err := cu.repo.RemoveAccount(ctx, params.UserID, accountName, params.ServiceName); err != nil {
+	vault, err := cu.writableVault(ctx, "RemoveAccount", params)
+	if err != nil {
+		return err
+	}
+
+	if err := cu.repo.RemoveAccount(ctx, vault, accountName); err != nil {
 		return newInternalError("RemoveAccount", "failed removing account", err)
 	}
 
@@ -96,13 +187,225 @@ func (cu *AccountsUsecase) RemoveAccount(ctx context.Context, accountName string
 }
 
 func (cu *AccountsUsecase) RemoveAllAccountsInService(ctx context.Context, params accounts.QueryParams) error {
-	if err := cu.repo.RemoveAllAccountsInService(ctx, params.UserID, params.ServiceName); err != nil {
+	vault, err := cu.writableVault(ctx, "RemoveAllAccountsInService", params)
+	if err != nil {
+		return err
+	}
+
+	if err := cu.repo.RemoveAllAccountsInService(ctx, vault); err != nil {
 		return newInternalError("RemoveAllAccountsInService", "failed removing creds records in service", err)
 	}
 
 	return nil
 }
 
+func (cu *AccountsUsecase) ShareAccount(ctx context.Context, params accounts.ShareParams) error {
+	accountID, err := cu.getAccountID(ctx, "ShareAccount", params.QueryParams, params.AccountName)
+	if err != nil {
+		return err
+	}
+
+	granteeID, err := cu.getGranteeID(ctx, "ShareAccount", params.Grantee)
+	if err != nil {
+		return err
+	}
+	if granteeID == params.UserID {
+		return newClientError("can't share account with yourself")
+	}
+
+	share := accounts.Share{AccountID: accountID, GranteeID: granteeID, Permission: params.Permission}
+	if err := cu.repo.AddShare(ctx, share); err != nil {
+		return newInternalError("ShareAccount", "failed adding share", err)
+	}
+
+	return nil
+}
+
+func (cu *AccountsUsecase) GetAccountShares(ctx context.Context, accountName string, params accounts.QueryParams) ([]accounts.Share, error) {
+	accountID, err := cu.getAccountID(ctx, "GetAccountShares", params, accountName)
+	if err != nil {
+		return nil, err
+	}
+
+	shares, err := cu.repo.GetAccountShares(ctx, accountID)
+	if err != nil {
+		return nil, newInternalError("GetAccountShares", "failed getting shares", err)
+	}
+
+	return shares, nil
+}
+
+func (cu *AccountsUsecase) RevokeShare(ctx context.Context, params accounts.ShareParams) error {
+	accountID, err := cu.getAccountID(ctx, "RevokeShare", params.QueryParams, params.AccountName)
+	if err != nil {
+		return err
+	}
+
+	granteeID, err := cu.getGranteeID(ctx, "RevokeShare", params.Grantee)
+	if err != nil {
+		return err
+	}
+
+	if err := cu.repo.RemoveShare(ctx, accountID, granteeID); err != nil {
+		return newInternalError("RevokeShare", "failed removing share", err)
+	}
+
+	return nil
+}
+
+// GetSharedAccounts returns accounts of other users shared with the user
+func (cu *AccountsUsecase) GetSharedAccounts(ctx context.Context, userID uuid.UUID) ([]accounts.AccountDTO, error) {
+	records, err := cu.repo.GetSharedAccounts(ctx, userID)
+	if err != nil {
+		return nil, newInternalError("GetSharedAccounts", "failed getting shared accounts", err)
+	}
+
+	dtos := make([]accounts.AccountDTO, 0, len(records))
+	for _, r := range records {
+		dto, err := r.ToAccountDTO(cu.ciphers)
+		if err != nil {
+			return nil, newInternalError("GetSharedAccounts", "failed decrypting account", err)
+		}
+		dtos = append(dtos, dto)
+	}
+
+	return dtos, nil
+}
+
+// getAccountID returns id of own account of the user
+func (cu *AccountsUsecase) getAccountID(ctx context.Context, component string, params accounts.QueryParams, accountName string) (uuid.UUID, error) {
+	if len(params.Collection) > 0 {
+		return uuid.Nil, newClientError("collection accounts are shared by membership")
+	}
+
+	serviceID, err := cu.repo.GetServiceID(ctx, params.ServiceName)
+	if err != nil {
+		if cu.repo.IsEmptyRows(err) {
+			return uuid.Nil, newClientError("invalid service name")
+		}
+		return uuid.Nil, newInternalError(component, "failed getting service id", err)
+	}
+
+	accountID, err := cu.repo.GetAccountID(ctx, params, serviceID, accountName)
+	if err != nil {
+		if cu.repo.IsEmptyRows(err) {
+			return uuid.Nil, newClientError("account not found")
+		}
+		return uuid.Nil, newInternalError(component, "failed getting account id", err)
+	}
+
+	return accountID, nil
+}
+
+// vault returns params which select the owner of the accounts and the
+// permission of the user on them. The personal vault is owned by the user.
+// Accounts of a collection are owned by the collection instead of the user and
+// the membership role of the user in its organization defines the permission.
+func (cu *AccountsUsecase) vault(ctx context.Context, component string, params accounts.QueryParams) (accounts.QueryParams, accounts.Permission, error) {
+	if len(params.Collection) == 0 {
+		params.CollectionID = uuid.Nil
+		return params, accounts.PermissionOwner, nil
+	}
+
+	collectionID, role, err := cu.repo.GetCollectionRole(ctx, params.UserID, params.Organization, params.Collection)
+	if err != nil {
+		if cu.repo.IsEmptyRows(err) {
+			return accounts.QueryParams{}, "", newClientError("collection not found")
+		}
+		return accounts.QueryParams{}, "", newInternalError(component, "failed getting collection role", err)
+	}
+	params.UserID, params.CollectionID = uuid.Nil, collectionID
+
+	if !role.CanWrite() {
+		return params, accounts.PermissionRead, nil
+	}
+	return params, accounts.PermissionWrite, nil
+}
+
+func (cu *AccountsUsecase) writableVault(ctx context.Context, component string, params accounts.QueryParams) (accounts.QueryParams, error) {
+	vault, permission, err := cu.vault(ctx, component, params)
+	if err != nil {
+		return accounts.QueryParams{}, err
+	}
+	if permission == accounts.PermissionRead {
+		return accounts.QueryParams{}, newClientError("no write permission")
+	}
+	return vault, nil
+}
+
+func (cu *AccountsUsecase) getGranteeID(ctx context.Context, component, username string) (uuid.UUID, error) {
+	granteeID, err := cu.repo.GetUserID(ctx, username)
+	if err != nil {
+		if cu.repo.IsEmptyRows(err) {
+			return uuid.Nil, newClientError("user not found")
+		}
+		return uuid.Nil, newInternalError(component, "failed getting user id", err)
+	}
+
+	return granteeID, nil
+}
+
+func (cu *AccountsUsecase) ApplyBatch(ctx context.Context, mode accounts.BatchMode, ops []accounts.Operation) ([]accounts.OperationResult, error) {
+	results := make([]accounts.OperationResult, 0, len(ops))
+	for i, op := range ops {
+		name := op.Name
+		if op.Type == accounts.OperationUpdate {
+			name = op.OldName
+		}
+		results = append(results, accounts.OperationResult{Index: i, Type: op.Type, Name: name, Status: accounts.OperationSkipped})
+	}
+
+	if mode == accounts.BatchBestEffort {
+		for i, op := range ops {
+			if err := cu.applyOperation(ctx, op); err != nil {
+				results[i].Status, results[i].Err = accounts.OperationFailed, err
+				continue
+			}
+			results[i].Status = accounts.OperationApplied
+		}
+		return results, nil
+	}
+
+	err := cu.repo.WithinTx(ctx, func(txCtx context.Context) error {
+		for i, op := range ops {
+			if err := cu.applyOperation(txCtx, op); err != nil {
+				results[i].Status, results[i].Err = accounts.OperationFailed, err
+				return errBatchAborted
+			}
+			results[i].Status = accounts.OperationApplied
+		}
+		return nil
+	})
+	if err == nil {
+		return results, nil
+	}
+
+	for i := range results {
+		if results[i].Status == accounts.OperationApplied {
+			results[i].Status = accounts.OperationRolledBack
+		}
+	}
+
+	if !errors.Is(err, errBatchAborted) {
+		return nil, newInternalError("ApplyBatch", "failed executing transaction", err)
+	}
+
+	return results, nil
+}
+
+func (cu *AccountsUsecase) applyOperation(ctx context.Context, op accounts.Operation) error {
+	switch op.Type {
+	case accounts.OperationCreate:
+		return cu.AddAccount(ctx, op.AccountDTO)
+	case accounts.OperationUpdate:
+		return cu.UpdateAccount(ctx, op.OldName, op.AccountDTO)
+	case accounts.OperationDelete:
+		return cu.RemoveAccount(ctx, op.Name, op.QueryParams)
+	default:
+		return newClientError("unknown operation type")
+	}
+}
+
 func (cu *AccountsUsecase) ParseMyError(err error) (int, string, error) {
 	return parseAccountsError(err)
 }
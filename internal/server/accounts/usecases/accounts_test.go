@@ -7,6 +7,7 @@ import (
 
 	"passman/internal/server/accounts"
 	mock_usecases "passman/internal/server/accounts/usecases/mock"
+	"passman/internal/server/organizations"
 	"passman/pkg/cipher"
 
 	"github.com/google/uuid"
@@ -37,6 +38,9 @@ func compareDTOs(s1, s2 []accounts.AccountDTO) bool {
 		if s1[i].UserID != s2[i].UserID {
 			return false
 		}
+		if s1[i].Owner != s2[i].Owner || s1[i].Permission != s2[i].Permission {
+			return false
+		}
 	}
 	return true
 }
@@ -172,7 +176,7 @@ func TestAddAccount(t *testing.T) {
 				mockRepo.EXPECT().
 					GetAccountID(
 						ctx,
-						test.input.UserID,
+						test.input.QueryParams,
 						gomock.AssignableToTypeOf(uuid.UUID{}),
 						test.input.Name,
 					).
@@ -243,6 +247,17 @@ func TestGetAccountsInService(t *testing.T) {
 		},
 	}
 
+	sharedAccounts := []accounts.Account{
+		{
+			ID:         uuid.New(),
+			Name:       "acc_name",
+			Secret:     0,
+			Payload:    "c68acc479af6a2caa531d56def51a3caf97304f691440c40d8f0297570c20f2a",
+			Owner:      "owner",
+			Permission: accounts.PermissionRead,
+		},
+	}
+
 	type getAccountsResult struct {
 		records []accounts.Account
 		err     error
@@ -256,6 +271,7 @@ func TestGetAccountsInService(t *testing.T) {
 	tests := []struct {
 		name              string
 		getAccountsResult getAccountsResult
+		getSharedResult   *getAccountsResult
 		expResult         expResult
 	}{
 		{
@@ -272,24 +288,48 @@ func TestGetAccountsInService(t *testing.T) {
 			getAccountsResult: getAccountsResult{
 				records: incorrectAccounts,
 			},
+			getSharedResult: &getAccountsResult{},
 			expResult: expResult{
 				err: errors.New("GetAccountsInService: failed decrypting account"),
 			},
 		},
+		{
+			name: "failed_getting_shared_records",
+			getAccountsResult: getAccountsResult{
+				records: correctAccounts,
+			},
+			getSharedResult: &getAccountsResult{
+				err: errors.New("internal error"),
+			},
+			expResult: expResult{
+				err: errors.New("GetAccountsInService: failed getting shared accounts"),
+			},
+		},
 		{
 			name: "success",
 			getAccountsResult: getAccountsResult{
 				records: correctAccounts,
 			},
+			getSharedResult: &getAccountsResult{
+				records: sharedAccounts,
+			},
 			expResult: expResult{
 				dtos: []accounts.AccountDTO{
 					{
 						QueryParams: accounts.QueryParams{
 							UserID: inputParams.UserID,
 						},
-						Name:     "acc_name",
-						Login:    "acc_login",
-						Password: "acc_password",
+						Name:       "acc_name",
+						Login:      "acc_login",
+						Password:   "acc_password",
+						Permission: accounts.PermissionOwner,
+					},
+					{
+						Name:       "acc_name",
+						Login:      "acc_login",
+						Password:   "acc_password",
+						Owner:      "owner",
+						Permission: accounts.PermissionRead,
 					},
 				},
 			},
@@ -303,6 +343,13 @@ func TestGetAccountsInService(t *testing.T) {
 				Return(test.getAccountsResult.records, test.getAccountsResult.err).
 				Times(1)
 
+			if test.getSharedResult != nil {
+				mockRepo.EXPECT().
+					GetSharedAccountsInService(ctx, inputParams).
+					Return(test.getSharedResult.records, test.getSharedResult.err).
+					Times(1)
+			}
+
 			actDTOs, actErr := accountsUsecase.GetAccountsInService(ctx, inputParams)
 
 			if got, want := actDTOs, test.expResult.dtos; !compareDTOs(got, want) {
@@ -316,6 +363,80 @@ func TestGetAccountsInService(t *testing.T) {
 	}
 }
 
+func TestGetAllAccounts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockrepository(ctrl)
+	testCiphers := generateTestCiphers()
+	accountsUsecase := New(mockRepo, testCiphers)
+
+	ctx := context.Background()
+	userID := uuid.New()
+
+	tests := []struct {
+		name          string
+		records       []accounts.Account
+		getAccountErr error
+		expDTOs       []accounts.AccountDTO
+		expErr        error
+	}{
+		{
+			name:          "failed_getting_records",
+			getAccountErr: errors.New("internal error"),
+			expErr:        errors.New("GetAllAccounts: failed getting accounts"),
+		},
+		{
+			name:    "failed_decrypting",
+			records: []accounts.Account{{UserID: userID, ServiceName: "service", Name: "name", Payload: "incorrect_payload"}},
+			expErr:  errors.New("GetAllAccounts: failed decrypting account"),
+		},
+		{
+			name: "success",
+			records: []accounts.Account{
+				{
+					UserID:      userID,
+					ServiceName: "service",
+					Name:        "acc_name",
+					Payload:     "c68acc479af6a2caa531d56def51a3caf97304f691440c40d8f0297570c20f2a",
+				},
+			},
+			expDTOs: []accounts.AccountDTO{
+				{
+					QueryParams: accounts.QueryParams{UserID: userID, ServiceName: "service"},
+					Name:        "acc_name",
+					Login:       "acc_login",
+					Password:    "acc_password",
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().
+				GetUserAccounts(ctx, userID).
+				Return(test.records, test.getAccountErr).
+				Times(1)
+
+			actDTOs, actErr := accountsUsecase.GetAllAccounts(ctx, userID)
+
+			if got, want := actDTOs, test.expDTOs; !compareDTOs(got, want) {
+				t.Errorf("Wrong! Mismatch account dtos!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			for i := range min(len(actDTOs), len(test.expDTOs)) {
+				if got, want := actDTOs[i].ServiceName, test.expDTOs[i].ServiceName; got != want {
+					t.Errorf("Wrong! Mismatch service name!\n\tExpected: %s\n\tActual: %s", want, got)
+				}
+			}
+
+			if got, want := actErr, test.expErr; !errors.Is(got, want) {
+				t.Errorf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
+
 func TestUpdateAccount(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -486,7 +607,7 @@ func TestUpdateAccount(t *testing.T) {
 				mockRepo.EXPECT().
 					GetAccountID(
 						ctx,
-						test.updatedAccount.UserID,
+						test.updatedAccount.QueryParams,
 						test.getServiceIDResult.serviceID,
 						test.oldAccountName,
 					).
@@ -522,3 +643,501 @@ func TestUpdateAccount(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockrepository(ctrl)
+	testCiphers := generateTestCiphers()
+	accountsUsecase := New(mockRepo, testCiphers)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	params := accounts.QueryParams{UserID: userID, ServiceName: "service"}
+
+	ops := []accounts.Operation{
+		{Type: accounts.OperationDelete, AccountDTO: accounts.AccountDTO{QueryParams: params, Name: "first"}},
+		{Type: accounts.OperationDelete, AccountDTO: accounts.AccountDTO{QueryParams: params, Name: "second"}},
+		{Type: accounts.OperationDelete, AccountDTO: accounts.AccountDTO{QueryParams: params, Name: "third"}},
+	}
+
+	runTx := func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	}
+
+	tests := []struct {
+		name        string
+		mode        accounts.BatchMode
+		txErr       error
+		removeErrs  []error
+		expStatuses []accounts.OperationStatus
+		expErr      error
+	}{
+		{
+			name:        "best_effort_partial",
+			mode:        accounts.BatchBestEffort,
+			removeErrs:  []error{nil, errors.New("internal error"), nil},
+			expStatuses: []accounts.OperationStatus{accounts.OperationApplied, accounts.OperationFailed, accounts.OperationApplied},
+		},
+		{
+			name:        "atomic_success",
+			mode:        accounts.BatchAtomic,
+			removeErrs:  []error{nil, nil, nil},
+			expStatuses: []accounts.OperationStatus{accounts.OperationApplied, accounts.OperationApplied, accounts.OperationApplied},
+		},
+		{
+			name:        "atomic_aborted",
+			mode:        accounts.BatchAtomic,
+			removeErrs:  []error{nil, errors.New("internal error")},
+			expStatuses: []accounts.OperationStatus{accounts.OperationRolledBack, accounts.OperationFailed, accounts.OperationSkipped},
+		},
+		{
+			name:   "failed_transaction",
+			mode:   accounts.BatchAtomic,
+			txErr:  errors.New("failed starting transaction"),
+			expErr: errors.New("ApplyBatch: failed executing transaction"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.mode == accounts.BatchAtomic {
+				tx := mockRepo.EXPECT().WithinTx(ctx, gomock.Any()).Times(1)
+				if test.txErr != nil {
+					tx.Return(test.txErr)
+				} else {
+					tx.DoAndReturn(runTx)
+				}
+			}
+
+			for i, removeErr := range test.removeErrs {
+				mockRepo.EXPECT().
+					RemoveAccount(ctx, params, ops[i].Name).
+					Return(removeErr).
+					Times(1)
+			}
+
+			actResults, actErr := accountsUsecase.ApplyBatch(ctx, test.mode, ops)
+
+			if got, want := actErr, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+
+			if got, want := len(actResults), len(test.expStatuses); got != want {
+				t.Fatalf("Wrong! Unexpected results count!\n\tExpected: %d\n\tActual: %d", want, got)
+			}
+
+			for i, result := range actResults {
+				if got, want := result.Status, test.expStatuses[i]; got != want {
+					t.Errorf("Wrong! Unexpected status of operation %d!\n\tExpected: %s\n\tActual: %s", i, want, got)
+				}
+				if got, want := result.Err != nil, result.Status == accounts.OperationFailed; got != want {
+					t.Errorf("Wrong! Unexpected error of operation %d: %v", i, result.Err)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateSharedAccount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockrepository(ctrl)
+	testCiphers := generateTestCiphers()
+	accountsUsecase := New(mockRepo, testCiphers)
+
+	ctx := context.Background()
+	errNoRows := errors.New("no rows")
+	serviceID := uuid.New()
+	accountID := uuid.New()
+
+	updatedAccount := accounts.AccountDTO{
+		QueryParams: accounts.QueryParams{
+			ServiceName: "ServiceName",
+			UserID:      uuid.New(),
+		},
+		Name:     "SharedName",
+		Login:    "SomeLogin",
+		Password: "SomePassword",
+		Owner:    "owner",
+	}
+
+	type updateAccountResult struct {
+		err error
+	}
+
+	type getSharedAccountResult struct {
+		permission accounts.Permission
+		err        error
+	}
+
+	tests := []struct {
+		name                   string
+		oldAccountName         string
+		getSharedAccountResult getSharedAccountResult
+		updateResult           *updateAccountResult
+		expResult              error
+	}{
+		{
+			name:                   "failed_getting_shared_account",
+			oldAccountName:         "SharedName",
+			getSharedAccountResult: getSharedAccountResult{err: errors.New("internal error")},
+			expResult:              errors.New("UpdateAccount: failed getting shared account"),
+		},
+		{
+			name:                   "not_shared",
+			oldAccountName:         "SharedName",
+			getSharedAccountResult: getSharedAccountResult{err: errNoRows},
+			expResult:              errors.New("ClientError: invalid old account name"),
+		},
+		{
+			name:                   "read_permission",
+			oldAccountName:         "SharedName",
+			getSharedAccountResult: getSharedAccountResult{permission: accounts.PermissionRead},
+			expResult:              errors.New("ClientError: no write permission"),
+		},
+		{
+			name:                   "renaming",
+			oldAccountName:         "OldName",
+			getSharedAccountResult: getSharedAccountResult{permission: accounts.PermissionWrite},
+			expResult:              errors.New("ClientError: shared account can't be renamed"),
+		},
+		{
+			name:                   "failed_updating",
+			oldAccountName:         "SharedName",
+			getSharedAccountResult: getSharedAccountResult{permission: accounts.PermissionWrite},
+			updateResult:           &updateAccountResult{err: errors.New("internal error")},
+			expResult:              errors.New("UpdateAccount: failed updating shared account"),
+		},
+		{
+			name:                   "success",
+			oldAccountName:         "SharedName",
+			getSharedAccountResult: getSharedAccountResult{permission: accounts.PermissionWrite},
+			updateResult:           &updateAccountResult{},
+			expResult:              nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().
+				GetServiceID(ctx, updatedAccount.ServiceName).
+				Return(serviceID, nil).
+				Times(1)
+
+			mockRepo.EXPECT().
+				GetSharedAccount(ctx, updatedAccount.UserID, serviceID, updatedAccount.Owner, test.oldAccountName).
+				Return(accountID, test.getSharedAccountResult.permission, test.getSharedAccountResult.err).
+				Times(1)
+
+			if test.getSharedAccountResult.err != nil {
+				mockRepo.EXPECT().
+					IsEmptyRows(test.getSharedAccountResult.err).
+					Return(test.getSharedAccountResult.err == errNoRows).
+					Times(1)
+			}
+
+			if test.updateResult != nil {
+				mockRepo.EXPECT().
+					UpdateAccountByID(ctx, gomock.Cond(func(acc accounts.Account) bool {
+						return acc.ID == accountID && acc.Name == updatedAccount.Name
+					})).
+					Return(test.updateResult.err).
+					Times(1)
+			}
+
+			actErr := accountsUsecase.UpdateAccount(ctx, test.oldAccountName, updatedAccount)
+
+			if got, want := actErr, test.expResult; !errors.Is(got, want) {
+				t.Errorf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestShareAccount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockrepository(ctrl)
+	testCiphers := generateTestCiphers()
+	accountsUsecase := New(mockRepo, testCiphers)
+
+	ctx := context.Background()
+	errNoRows := errors.New("no rows")
+	serviceID := uuid.New()
+	accountID := uuid.New()
+	granteeID := uuid.New()
+
+	params := accounts.ShareParams{
+		QueryParams: accounts.QueryParams{
+			UserID:      uuid.New(),
+			ServiceName: "ServiceName",
+		},
+		AccountName: "AccountName",
+		Grantee:     "grantee",
+		Permission:  accounts.PermissionRead,
+	}
+
+	type addShareResult struct {
+		err error
+	}
+
+	type idResult struct {
+		id  uuid.UUID
+		err error
+	}
+
+	tests := []struct {
+		name               string
+		getServiceIDResult idResult
+		getAccountIDResult *idResult
+		getUserIDResult    *idResult
+		addShareResult     *addShareResult
+		expResult          error
+	}{
+		{
+			name:               "invalid_service_name",
+			getServiceIDResult: idResult{err: errNoRows},
+			expResult:          errors.New("ClientError: invalid service name"),
+		},
+		{
+			name:               "account_not_found",
+			getServiceIDResult: idResult{id: serviceID},
+			getAccountIDResult: &idResult{err: errNoRows},
+			expResult:          errors.New("ClientError: account not found"),
+		},
+		{
+			name:               "failed_getting_account_id",
+			getServiceIDResult: idResult{id: serviceID},
+			getAccountIDResult: &idResult{err: errors.New("internal error")},
+			expResult:          errors.New("ShareAccount: failed getting account id"),
+		},
+		{
+			name:               "user_not_found",
+			getServiceIDResult: idResult{id: serviceID},
+			getAccountIDResult: &idResult{id: accountID},
+			getUserIDResult:    &idResult{err: errNoRows},
+			expResult:          errors.New("ClientError: user not found"),
+		},
+		{
+			name:               "sharing_with_yourself",
+			getServiceIDResult: idResult{id: serviceID},
+			getAccountIDResult: &idResult{id: accountID},
+			getUserIDResult:    &idResult{id: params.UserID},
+			expResult:          errors.New("ClientError: can't share account with yourself"),
+		},
+		{
+			name:               "failed_adding_share",
+			getServiceIDResult: idResult{id: serviceID},
+			getAccountIDResult: &idResult{id: accountID},
+			getUserIDResult:    &idResult{id: granteeID},
+			addShareResult:     &addShareResult{err: errors.New("internal error")},
+			expResult:          errors.New("ShareAccount: failed adding share"),
+		},
+		{
+			name:               "success",
+			getServiceIDResult: idResult{id: serviceID},
+			getAccountIDResult: &idResult{id: accountID},
+			getUserIDResult:    &idResult{id: granteeID},
+			addShareResult:     &addShareResult{},
+			expResult:          nil,
+		},
+	}
+
+	expectIsEmptyRows := func(err error) {
+		if err != nil {
+			mockRepo.EXPECT().IsEmptyRows(err).Return(err == errNoRows).Times(1)
+		}
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().
+				GetServiceID(ctx, params.ServiceName).
+				Return(test.getServiceIDResult.id, test.getServiceIDResult.err).
+				Times(1)
+			expectIsEmptyRows(test.getServiceIDResult.err)
+
+			if test.getAccountIDResult != nil {
+				mockRepo.EXPECT().
+					GetAccountID(ctx, params.QueryParams, serviceID, params.AccountName).
+					Return(test.getAccountIDResult.id, test.getAccountIDResult.err).
+					Times(1)
+				expectIsEmptyRows(test.getAccountIDResult.err)
+			}
+
+			if test.getUserIDResult != nil {
+				mockRepo.EXPECT().
+					GetUserID(ctx, params.Grantee).
+					Return(test.getUserIDResult.id, test.getUserIDResult.err).
+					Times(1)
+				expectIsEmptyRows(test.getUserIDResult.err)
+			}
+
+			if test.addShareResult != nil {
+				mockRepo.EXPECT().
+					AddShare(ctx, accounts.Share{AccountID: accountID, GranteeID: granteeID, Permission: params.Permission}).
+					Return(test.addShareResult.err).
+					Times(1)
+			}
+
+			actErr := accountsUsecase.ShareAccount(ctx, params)
+
+			if got, want := actErr, test.expResult; !errors.Is(got, want) {
+				t.Errorf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestRemoveAccountInCollection(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockrepository(ctrl)
+	testCiphers := generateTestCiphers()
+	accountsUsecase := New(mockRepo, testCiphers)
+
+	ctx := context.Background()
+	errNoRows := errors.New("no rows")
+	collectionID := uuid.New()
+
+	params := accounts.QueryParams{
+		UserID:       uuid.New(),
+		ServiceName:  "ServiceName",
+		Organization: "acme",
+		Collection:   "ops",
+	}
+
+	// Accounts of the collection are removed by the collection, not by the user
+	vault := params
+	vault.UserID, vault.CollectionID = uuid.Nil, collectionID
+
+	type getCollectionRoleResult struct {
+		role organizations.Role
+		err  error
+	}
+
+	tests := []struct {
+		name                    string
+		getCollectionRoleResult getCollectionRoleResult
+		removeCalls             int
+		expResult               error
+	}{
+		{
+			name:                    "failed_getting_role",
+			getCollectionRoleResult: getCollectionRoleResult{err: errors.New("internal error")},
+			expResult:               errors.New("RemoveAccount: failed getting collection role"),
+		},
+		{
+			name:                    "not_member",
+			getCollectionRoleResult: getCollectionRoleResult{err: errNoRows},
+			expResult:               errors.New("ClientError: collection not found"),
+		},
+		{
+			name:                    "readonly_member",
+			getCollectionRoleResult: getCollectionRoleResult{role: organizations.RoleReadOnly},
+			expResult:               errors.New("ClientError: no write permission"),
+		},
+		{
+			name:                    "member",
+			getCollectionRoleResult: getCollectionRoleResult{role: organizations.RoleMember},
+			removeCalls:             1,
+			expResult:               nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().
+				GetCollectionRole(ctx, params.UserID, params.Organization, params.Collection).
+				Return(collectionID, test.getCollectionRoleResult.role, test.getCollectionRoleResult.err).
+				Times(1)
+
+			if test.getCollectionRoleResult.err != nil {
+				mockRepo.EXPECT().
+					IsEmptyRows(test.getCollectionRoleResult.err).
+					Return(test.getCollectionRoleResult.err == errNoRows).
+					Times(1)
+			}
+
+			mockRepo.EXPECT().
+				RemoveAccount(ctx, vault, "AccountName").
+				Return(nil).
+				Times(test.removeCalls)
+
+			actErr := accountsUsecase.RemoveAccount(ctx, "AccountName", params)
+
+			if got, want := actErr, test.expResult; !errors.Is(got, want) {
+				t.Errorf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestAddAccountInCollection(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockrepository(ctrl)
+	testCiphers := generateTestCiphers()
+	accountsUsecase := New(mockRepo, testCiphers)
+
+	ctx := context.Background()
+	errNoRows := errors.New("no rows")
+	collectionID := uuid.New()
+	serviceID := uuid.New()
+
+	dto := accounts.AccountDTO{
+		QueryParams: accounts.QueryParams{
+			UserID:       uuid.New(),
+			ServiceName:  "ServiceName",
+			Organization: "acme",
+			Collection:   "ops",
+		},
+		Name:     "AccountName",
+		Login:    "Login",
+		Password: "Password",
+	}
+
+	vault := dto.QueryParams
+	vault.UserID, vault.CollectionID = uuid.Nil, collectionID
+
+	mockRepo.EXPECT().
+		GetCollectionRole(ctx, dto.UserID, dto.Organization, dto.Collection).
+		Return(collectionID, organizations.RoleMember, nil).
+		Times(1)
+	mockRepo.EXPECT().
+		GetServiceID(ctx, dto.ServiceName).
+		Return(serviceID, nil).
+		Times(1)
+	mockRepo.EXPECT().
+		GetAccountID(ctx, vault, serviceID, dto.Name).
+		Return(uuid.Nil, errNoRows).
+		Times(1)
+	mockRepo.EXPECT().
+		IsEmptyRows(errNoRows).
+		Return(true).
+		Times(1)
+
+	var added accounts.Account
+	mockRepo.EXPECT().
+		AddAccount(ctx, gomock.AssignableToTypeOf(accounts.Account{})).
+		Do(func(_ context.Context, account accounts.Account) { added = account }).
+		Return(nil).
+		Times(1)
+
+	if err := accountsUsecase.AddAccount(ctx, dto); err != nil {
+		t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", nil, err)
+	}
+
+	if got, want := added.CollectionID, collectionID; got != want {
+		t.Errorf("Wrong! Unexpected collection id!\n\tExpected: %v\n\tActual: %v", want, got)
+	}
+	if got, want := added.UserID, uuid.Nil; got != want {
+		t.Errorf("Wrong! Unexpected user id!\n\tExpected: %v\n\tActual: %v", want, got)
+	}
+}
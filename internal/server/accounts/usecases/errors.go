@@ -5,6 +5,8 @@ import (
 	"fmt"
 )
 
+var errBatchAborted = errors.New("batch aborted")
+
 type accountsError struct {
 	Code      int
 	Component string
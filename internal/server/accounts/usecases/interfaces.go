@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"passman/internal/server/accounts"
+	"passman/internal/server/organizations"
 
 	"github.com/google/uuid"
 )
@@ -12,10 +13,21 @@ import (
 type repository interface {
 	AddAccount(ctx context.Context, newAccount accounts.Account) error
 	GetUserAccountsInService(ctx context.Context, params accounts.QueryParams) ([]accounts.Account, error)
+	GetUserAccounts(ctx context.Context, userID uuid.UUID) ([]accounts.Account, error)
 	GetServiceID(ctx context.Context, serviceName string) (uuid.UUID, error)
-	GetAccountID(ctx context.Context, userID, serviceID uuid.UUID, credName string) (uuid.UUID, error)
+	GetAccountID(ctx context.Context, params accounts.QueryParams, serviceID uuid.UUID, credName string) (uuid.UUID, error)
 	UpdateAccount(ctx context.Context, oldServiceName string, updatedAccount accounts.Account) error
-	RemoveAccount(ctx context.Context, userID uuid.UUID, accountName, serviceName string) error
-	RemoveAllAccountsInService(ctx context.Context, userID uuid.UUID, serviceName string) error
+	RemoveAccount(ctx context.Context, params accounts.QueryParams, accountName string) error
+	RemoveAllAccountsInService(ctx context.Context, params accounts.QueryParams) error
+	UpdateAccountByID(ctx context.Context, account accounts.Account) error
+	GetUserID(ctx context.Context, username string) (uuid.UUID, error)
+	AddShare(ctx context.Context, share accounts.Share) error
+	GetAccountShares(ctx context.Context, accountID uuid.UUID) ([]accounts.Share, error)
+	RemoveShare(ctx context.Context, accountID, granteeID uuid.UUID) error
+	GetSharedAccounts(ctx context.Context, granteeID uuid.UUID) ([]accounts.Account, error)
+	GetSharedAccountsInService(ctx context.Context, params accounts.QueryParams) ([]accounts.Account, error)
+	GetSharedAccount(ctx context.Context, granteeID, serviceID uuid.UUID, owner, accountName string) (uuid.UUID, accounts.Permission, error)
+	GetCollectionRole(ctx context.Context, userID uuid.UUID, orgName, collectionName string) (uuid.UUID, organizations.Role, error)
+	WithinTx(ctx context.Context, fn func(txCtx context.Context) error) error
 	IsEmptyRows(err error) bool
 }
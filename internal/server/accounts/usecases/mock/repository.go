@@ -12,6 +12,7 @@ package mock_usecases
 import (
 	context "context"
 	accounts "passman/internal/server/accounts"
+	organizations "passman/internal/server/organizations"
 	reflect "reflect"
 
 	uuid "github.com/google/uuid"
@@ -56,19 +57,64 @@ func (mr *MockrepositoryMockRecorder) AddAccount(ctx, newAccount any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccount", reflect.TypeOf((*Mockrepository)(nil).AddAccount), ctx, newAccount)
 }
 
+// AddShare mocks base method.
+func (m *Mockrepository) AddShare(ctx context.Context, share accounts.Share) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddShare", ctx, share)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddShare indicates an expected call of AddShare.
+func (mr *MockrepositoryMockRecorder) AddShare(ctx, share any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddShare", reflect.TypeOf((*Mockrepository)(nil).AddShare), ctx, share)
+}
+
 // GetAccountID mocks base method.
-func (m *Mockrepository) GetAccountID(ctx context.Context, userID, serviceID uuid.UUID, credName string) (uuid.UUID, error) {
+func (m *Mockrepository) GetAccountID(ctx context.Context, params accounts.QueryParams, serviceID uuid.UUID, credName string) (uuid.UUID, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccountID", ctx, userID, serviceID, credName)
+	ret := m.ctrl.Call(m, "GetAccountID", ctx, params, serviceID, credName)
 	ret0, _ := ret[0].(uuid.UUID)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAccountID indicates an expected call of GetAccountID.
-func (mr *MockrepositoryMockRecorder) GetAccountID(ctx, userID, serviceID, credName any) *gomock.Call {
+func (mr *MockrepositoryMockRecorder) GetAccountID(ctx, params, serviceID, credName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountID", reflect.TypeOf((*Mockrepository)(nil).GetAccountID), ctx, params, serviceID, credName)
+}
+
+// GetAccountShares mocks base method.
+func (m *Mockrepository) GetAccountShares(ctx context.Context, accountID uuid.UUID) ([]accounts.Share, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountShares", ctx, accountID)
+	ret0, _ := ret[0].([]accounts.Share)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountShares indicates an expected call of GetAccountShares.
+func (mr *MockrepositoryMockRecorder) GetAccountShares(ctx, accountID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountShares", reflect.TypeOf((*Mockrepository)(nil).GetAccountShares), ctx, accountID)
+}
+
+// GetCollectionRole mocks base method.
+func (m *Mockrepository) GetCollectionRole(ctx context.Context, userID uuid.UUID, orgName, collectionName string) (uuid.UUID, organizations.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCollectionRole", ctx, userID, orgName, collectionName)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(organizations.Role)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetCollectionRole indicates an expected call of GetCollectionRole.
+func (mr *MockrepositoryMockRecorder) GetCollectionRole(ctx, userID, orgName, collectionName any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountID", reflect.TypeOf((*Mockrepository)(nil).GetAccountID), ctx, userID, serviceID, credName)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCollectionRole", reflect.TypeOf((*Mockrepository)(nil).GetCollectionRole), ctx, userID, orgName, collectionName)
 }
 
 // GetServiceID mocks base method.
@@ -86,6 +132,67 @@ func (mr *MockrepositoryMockRecorder) GetServiceID(ctx, serviceName any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServiceID", reflect.TypeOf((*Mockrepository)(nil).GetServiceID), ctx, serviceName)
 }
 
+// GetSharedAccount mocks base method.
+func (m *Mockrepository) GetSharedAccount(ctx context.Context, granteeID, serviceID uuid.UUID, owner, accountName string) (uuid.UUID, accounts.Permission, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSharedAccount", ctx, granteeID, serviceID, owner, accountName)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(accounts.Permission)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetSharedAccount indicates an expected call of GetSharedAccount.
+func (mr *MockrepositoryMockRecorder) GetSharedAccount(ctx, granteeID, serviceID, owner, accountName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSharedAccount", reflect.TypeOf((*Mockrepository)(nil).GetSharedAccount), ctx, granteeID, serviceID, owner, accountName)
+}
+
+// GetSharedAccounts mocks base method.
+func (m *Mockrepository) GetSharedAccounts(ctx context.Context, granteeID uuid.UUID) ([]accounts.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSharedAccounts", ctx, granteeID)
+	ret0, _ := ret[0].([]accounts.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSharedAccounts indicates an expected call of GetSharedAccounts.
+func (mr *MockrepositoryMockRecorder) GetSharedAccounts(ctx, granteeID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSharedAccounts", reflect.TypeOf((*Mockrepository)(nil).GetSharedAccounts), ctx, granteeID)
+}
+
+// GetSharedAccountsInService mocks base method.
+func (m *Mockrepository) GetSharedAccountsInService(ctx context.Context, params accounts.QueryParams) ([]accounts.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSharedAccountsInService", ctx, params)
+	ret0, _ := ret[0].([]accounts.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSharedAccountsInService indicates an expected call of GetSharedAccountsInService.
+func (mr *MockrepositoryMockRecorder) GetSharedAccountsInService(ctx, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSharedAccountsInService", reflect.TypeOf((*Mockrepository)(nil).GetSharedAccountsInService), ctx, params)
+}
+
+// GetUserAccounts mocks base method.
+func (m *Mockrepository) GetUserAccounts(ctx context.Context, userID uuid.UUID) ([]accounts.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserAccounts", ctx, userID)
+	ret0, _ := ret[0].([]accounts.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserAccounts indicates an expected call of GetUserAccounts.
+func (mr *MockrepositoryMockRecorder) GetUserAccounts(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserAccounts", reflect.TypeOf((*Mockrepository)(nil).GetUserAccounts), ctx, userID)
+}
+
 // GetUserAccountsInService mocks base method.
 func (m *Mockrepository) GetUserAccountsInService(ctx context.Context, params accounts.QueryParams) ([]accounts.Account, error) {
 	m.ctrl.T.Helper()
@@ -101,6 +208,21 @@ func (mr *MockrepositoryMockRecorder) GetUserAccountsInService(ctx, params any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserAccountsInService", reflect.TypeOf((*Mockrepository)(nil).GetUserAccountsInService), ctx, params)
 }
 
+// GetUserID mocks base method.
+func (m *Mockrepository) GetUserID(ctx context.Context, username string) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserID", ctx, username)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserID indicates an expected call of GetUserID.
+func (mr *MockrepositoryMockRecorder) GetUserID(ctx, username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserID", reflect.TypeOf((*Mockrepository)(nil).GetUserID), ctx, username)
+}
+
 // IsEmptyRows mocks base method.
 func (m *Mockrepository) IsEmptyRows(err error) bool {
 	m.ctrl.T.Helper()
@@ -116,31 +238,45 @@ func (mr *MockrepositoryMockRecorder) IsEmptyRows(err any) *gomock.Call {
 }
 
 // RemoveAccount mocks base method.
-func (m *Mockrepository) RemoveAccount(ctx context.Context, userID uuid.UUID, accountName, serviceName string) error {
+func (m *Mockrepository) RemoveAccount(ctx context.Context, params accounts.QueryParams, accountName string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RemoveAccount", ctx, userID, accountName, serviceName)
+	ret := m.ctrl.Call(m, "RemoveAccount", ctx, params, accountName)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // RemoveAccount indicates an expected call of RemoveAccount.
-func (mr *MockrepositoryMockRecorder) RemoveAccount(ctx, userID, accountName, serviceName any) *gomock.Call {
+func (mr *MockrepositoryMockRecorder) RemoveAccount(ctx, params, accountName any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveAccount", reflect.TypeOf((*Mockrepository)(nil).RemoveAccount), ctx, userID, accountName, serviceName)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveAccount", reflect.TypeOf((*Mockrepository)(nil).RemoveAccount), ctx, params, accountName)
 }
 
 // RemoveAllAccountsInService mocks base method.
-func (m *Mockrepository) RemoveAllAccountsInService(ctx context.Context, userID uuid.UUID, serviceName string) error {
+func (m *Mockrepository) RemoveAllAccountsInService(ctx context.Context, params accounts.QueryParams) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RemoveAllAccountsInService", ctx, userID, serviceName)
+	ret := m.ctrl.Call(m, "RemoveAllAccountsInService", ctx, params)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // RemoveAllAccountsInService indicates an expected call of RemoveAllAccountsInService.
-func (mr *MockrepositoryMockRecorder) RemoveAllAccountsInService(ctx, userID, serviceName any) *gomock.Call {
+func (mr *MockrepositoryMockRecorder) RemoveAllAccountsInService(ctx, params any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveAllAccountsInService", reflect.TypeOf((*Mockrepository)(nil).RemoveAllAccountsInService), ctx, userID, serviceName)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveAllAccountsInService", reflect.TypeOf((*Mockrepository)(nil).RemoveAllAccountsInService), ctx, params)
+}
+
+// RemoveShare mocks base method.
+func (m *Mockrepository) RemoveShare(ctx context.Context, accountID, granteeID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveShare", ctx, accountID, granteeID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveShare indicates an expected call of RemoveShare.
+func (mr *MockrepositoryMockRecorder) RemoveShare(ctx, accountID, granteeID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveShare", reflect.TypeOf((*Mockrepository)(nil).RemoveShare), ctx, accountID, granteeID)
 }
 
 // UpdateAccount mocks base method.
@@ -156,3 +292,31 @@ func (mr *MockrepositoryMockRecorder) UpdateAccount(ctx, oldServiceName, updated
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccount", reflect.TypeOf((*Mockrepository)(nil).UpdateAccount), ctx, oldServiceName, updatedAccount)
 }
+
+// UpdateAccountByID mocks base method.
+func (m *Mockrepository) UpdateAccountByID(ctx context.Context, account accounts.Account) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccountByID", ctx, account)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAccountByID indicates an expected call of UpdateAccountByID.
+func (mr *MockrepositoryMockRecorder) UpdateAccountByID(ctx, account any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountByID", reflect.TypeOf((*Mockrepository)(nil).UpdateAccountByID), ctx, account)
+}
+
+// WithinTx mocks base method.
+func (m *Mockrepository) WithinTx(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithinTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithinTx indicates an expected call of WithinTx.
+func (mr *MockrepositoryMockRecorder) WithinTx(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithinTx", reflect.TypeOf((*Mockrepository)(nil).WithinTx), ctx, fn)
+}
@@ -0,0 +1,192 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"passman/internal/server/emergency"
+	"passman/internal/server/emergency/adapters/db/queries"
+
+	"github.com/google/uuid"
+)
+
+type txKey struct{}
+
+type Adapter struct {
+	db      *sql.DB
+	storage *queries.Queries
+}
+
+func New(db *sql.DB) *Adapter {
+	return &Adapter{db: db, storage: queries.New(db)}
+}
+
+// WithinTx runs fn in one transaction. Every adapter call made with txCtx
+// is executed inside of it.
+func (a *Adapter) WithinTx(ctx context.Context, fn func(txCtx context.Context) error) (err error) {
+	sqlTx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed starting transaction: %w", err)
+	}
+	defer func() {
+		rollbackErr := sqlTx.Rollback()
+		if rollbackErr != nil && !errors.Is(rollbackErr, sql.ErrTxDone) {
+			err = errors.Join(err, rollbackErr)
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, a.storage.WithTx(sqlTx))); err != nil {
+		return err
+	}
+
+	return sqlTx.Commit()
+}
+
+func (a *Adapter) queries(ctx context.Context) *queries.Queries {
+	if tx, ok := ctx.Value(txKey{}).(*queries.Queries); ok {
+		return tx
+	}
+	return a.storage
+}
+
+func (a *Adapter) GetUserID(ctx context.Context, username string) (uuid.UUID, error) {
+	return a.queries(ctx).GetUserID(ctx, username)
+}
+
+func (a *Adapter) AddAccess(ctx context.Context, access emergency.Access) error {
+	params := queries.AddAccessParams{
+		ID:        access.ID,
+		GrantorID: access.GrantorID,
+		GranteeID: access.GranteeID,
+		Type:      string(access.Type),
+		WaitDays:  access.WaitDays,
+		Status:    string(access.Status),
+	}
+	return a.queries(ctx).AddAccess(ctx, params)
+}
+
+func (a *Adapter) GetAccess(ctx context.Context, grantorID, granteeID uuid.UUID) (emergency.Access, error) {
+	row, err := a.queries(ctx).GetAccess(ctx, queries.GetAccessParams{GrantorID: grantorID, GranteeID: granteeID})
+	if err != nil {
+		return emergency.Access{}, err
+	}
+
+	return emergency.Access{
+		ID:                  row.ID,
+		GrantorID:           grantorID,
+		GranteeID:           granteeID,
+		Type:                emergency.AccessType(row.Type),
+		WaitDays:            row.WaitDays,
+		Status:              emergency.Status(row.Status),
+		RecoveryInitiatedAt: fromUnix(row.RecoveryInitiatedAt),
+	}, nil
+}
+
+func (a *Adapter) GetGrantorAccesses(ctx context.Context, grantorID uuid.UUID) ([]emergency.Access, error) {
+	rows, err := a.queries(ctx).GetGrantorAccesses(ctx, grantorID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]emergency.Access, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, emergency.Access{
+			ID:                  row.ID,
+			GrantorID:           grantorID,
+			GranteeID:           row.GranteeID,
+			Grantee:             row.Username,
+			Type:                emergency.AccessType(row.Type),
+			WaitDays:            row.WaitDays,
+			Status:              emergency.Status(row.Status),
+			RecoveryInitiatedAt: fromUnix(row.RecoveryInitiatedAt),
+		})
+	}
+	return res, nil
+}
+
+func (a *Adapter) GetGranteeAccesses(ctx context.Context, granteeID uuid.UUID) ([]emergency.Access, error) {
+	rows, err := a.queries(ctx).GetGranteeAccesses(ctx, granteeID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]emergency.Access, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, emergency.Access{
+			ID:                  row.ID,
+			GrantorID:           row.GrantorID,
+			Grantor:             row.Username,
+			GranteeID:           granteeID,
+			Type:                emergency.AccessType(row.Type),
+			WaitDays:            row.WaitDays,
+			Status:              emergency.Status(row.Status),
+			RecoveryInitiatedAt: fromUnix(row.RecoveryInitiatedAt),
+		})
+	}
+	return res, nil
+}
+
+func (a *Adapter) UpdateAccessStatus(ctx context.Context, access emergency.Access) error {
+	params := queries.UpdateAccessStatusParams{
+		Status:              string(access.Status),
+		RecoveryInitiatedAt: toUnix(access.RecoveryInitiatedAt),
+		ID:                  access.ID,
+	}
+	return a.queries(ctx).UpdateAccessStatus(ctx, params)
+}
+
+func (a *Adapter) RemoveAccess(ctx context.Context, accessID uuid.UUID) error {
+	return a.queries(ctx).RemoveAccess(ctx, accessID)
+}
+
+func (a *Adapter) AddEvent(ctx context.Context, event emergency.Event) error {
+	params := queries.AddEventParams{
+		GrantorID: event.GrantorID,
+		GranteeID: event.GranteeID,
+		Status:    string(event.Status),
+		CreatedAt: toUnix(event.CreatedAt),
+	}
+	return a.queries(ctx).AddEvent(ctx, params)
+}
+
+func (a *Adapter) GetEvents(ctx context.Context, userID uuid.UUID) ([]emergency.Event, error) {
+	rows, err := a.queries(ctx).GetEvents(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]emergency.Event, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, emergency.Event{
+			GrantorID: row.GrantorID,
+			Grantor:   row.Grantor,
+			GranteeID: row.GranteeID,
+			Grantee:   row.Grantee,
+			Status:    emergency.Status(row.Status),
+			CreatedAt: fromUnix(row.CreatedAt),
+		})
+	}
+	return res, nil
+}
+
+func (a *Adapter) IsEmptyRows(err error) bool {
+	return errors.Is(err, sql.ErrNoRows)
+}
+
+// Timestamps are stored as unix seconds, zero stands for the zero time
+func toUnix(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func fromUnix(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0).UTC()
+}
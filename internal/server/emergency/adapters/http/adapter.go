@@ -0,0 +1,367 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"passman/internal/server/emergency"
+	"passman/internal/server/infra"
+
+	"github.com/go-chi/chi/v5"
+	vldtr "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type Adapter struct {
+	log *slog.Logger
+	eu  emergencyUsecase
+	sm  sessionManager
+	v   *validator
+}
+
+func NewRouter(eu emergencyUsecase, sm sessionManager, v *vldtr.Validate) chi.Router {
+	a := &Adapter{
+		log: slog.Default(),
+		eu:  eu,
+		sm:  sm,
+		v:   newValidator(v),
+	}
+
+	router := chi.NewRouter()
+
+	router.Use(infra.AuthMiddleware(sm))
+
+	router.Get("/contacts", a.GetContacts)
+	router.Post("/contacts", a.AddContact)
+	router.Delete("/contacts/{username}", a.RemoveContact)
+	router.Post("/contacts/{username}/approve", a.ApproveRecovery)
+	router.Post("/contacts/{username}/reject", a.RejectRecovery)
+	router.Get("/grants", a.GetGrants)
+	router.Post("/grants/{username}/accept", a.AcceptInvite)
+	router.Delete("/grants/{username}", a.RemoveGrant)
+	router.Post("/grants/{username}/recovery", a.InitiateRecovery)
+	router.With(infra.RecentAuthMiddleware(sm)).Get("/grants/{username}/vault", a.GetVault)
+	router.With(infra.RecentAuthMiddleware(sm)).Post("/grants/{username}/takeover", a.Takeover)
+	router.Get("/history", a.GetHistory)
+
+	return router
+}
+
+type accessResponse struct {
+	Username            string               `json:"username"`
+	Type                emergency.AccessType `json:"type"`
+	WaitDays            int64                `json:"wait_days"`
+	Status              emergency.Status     `json:"status"`
+	RecoveryInitiatedAt *time.Time           `json:"recovery_initiated_at,omitempty"`
+	RecoveryAvailableAt *time.Time           `json:"recovery_available_at,omitempty"`
+}
+
+func newAccessResponse(username string, access emergency.Access) accessResponse {
+	res := accessResponse{
+		Username: username,
+		Type:     access.Type,
+		WaitDays: access.WaitDays,
+		Status:   access.Status,
+	}
+	if !access.RecoveryInitiatedAt.IsZero() {
+		initiatedAt, availableAt := access.RecoveryInitiatedAt, access.RecoveryAvailableAt()
+		res.RecoveryInitiatedAt, res.RecoveryAvailableAt = &initiatedAt, &availableAt
+	}
+	return res
+}
+
+func (a *Adapter) AddContact(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	body := struct {
+		Username string               `json:"username"`
+		Type     emergency.AccessType `json:"type"`
+		WaitDays int64                `json:"wait_days"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		a.log.ErrorContext(r.Context(), "AddContact: failed parsing body", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	if err := a.v.ValidateContact(body.Username, body.Type, body.WaitDays); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := a.eu.AddContact(r.Context(), userID, body.Username, body.Type, body.WaitDays); err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "AddContact", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *Adapter) GetContacts(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	contacts, err := a.eu.GetContacts(r.Context(), userID)
+	if err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "GetContacts", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	res := make([]accessResponse, 0, len(contacts))
+	for _, contact := range contacts {
+		res = append(res, newAccessResponse(contact.Grantee, contact))
+	}
+
+	infra.ResponseJSON(w, res, http.StatusOK)
+}
+
+func (a *Adapter) RemoveContact(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	username := chi.URLParam(r, "username")
+	if err := a.v.ValidateUsername(username); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := a.eu.RemoveContact(r.Context(), userID, username); err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "RemoveContact", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *Adapter) ApproveRecovery(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	username := chi.URLParam(r, "username")
+	if err := a.v.ValidateUsername(username); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := a.eu.ApproveRecovery(r.Context(), userID, username); err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "ApproveRecovery", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *Adapter) RejectRecovery(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	username := chi.URLParam(r, "username")
+	if err := a.v.ValidateUsername(username); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := a.eu.RejectRecovery(r.Context(), userID, username); err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "RejectRecovery", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *Adapter) GetGrants(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	grants, err := a.eu.GetGrants(r.Context(), userID)
+	if err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "GetGrants", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	res := make([]accessResponse, 0, len(grants))
+	for _, grant := range grants {
+		res = append(res, newAccessResponse(grant.Grantor, grant))
+	}
+
+	infra.ResponseJSON(w, res, http.StatusOK)
+}
+
+func (a *Adapter) AcceptInvite(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	username := chi.URLParam(r, "username")
+	if err := a.v.ValidateUsername(username); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := a.eu.AcceptInvite(r.Context(), userID, username); err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "AcceptInvite", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *Adapter) RemoveGrant(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	username := chi.URLParam(r, "username")
+	if err := a.v.ValidateUsername(username); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := a.eu.RemoveGrant(r.Context(), userID, username); err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "RemoveGrant", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *Adapter) InitiateRecovery(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	username := chi.URLParam(r, "username")
+	if err := a.v.ValidateUsername(username); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := a.eu.InitiateRecovery(r.Context(), userID, username); err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "InitiateRecovery", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *Adapter) GetVault(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	username := chi.URLParam(r, "username")
+	if err := a.v.ValidateUsername(username); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dtos, err := a.eu.GetVault(r.Context(), userID, username)
+	if err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "GetVault", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	type responseType struct {
+		ServiceName string `json:"service_name"`
+		Name        string `json:"name"`
+		Login       string `json:"login"`
+		Password    string `json:"password"`
+		TOTP        string `json:"totp,omitempty"`
+	}
+
+	res := make([]responseType, 0, len(dtos))
+	for _, acc := range dtos {
+		res = append(res, responseType{
+			ServiceName: acc.ServiceName,
+			Name:        acc.Name,
+			Login:       acc.Login,
+			Password:    acc.Password,
+			TOTP:        acc.TOTP,
+		})
+	}
+
+	infra.ResponseJSON(w, res, http.StatusOK)
+}
+
+func (a *Adapter) Takeover(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	username := chi.URLParam(r, "username")
+	if err := a.v.ValidateUsername(username); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	body := struct {
+		Password string `json:"password"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		a.log.ErrorContext(r.Context(), "Takeover: failed parsing body", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	if err := a.v.ValidatePassword(body.Password); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := a.eu.Takeover(r.Context(), userID, username, body.Password); err != nil {
+		if infra.WeakPasswordHandler(w, err) {
+			return
+		}
+		code, msg := a.parseUsecaseError(r.Context(), "Takeover", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *Adapter) GetHistory(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	events, err := a.eu.GetHistory(r.Context(), userID)
+	if err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "GetHistory", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	type responseType struct {
+		Grantor   string           `json:"grantor"`
+		Grantee   string           `json:"grantee"`
+		Status    emergency.Status `json:"status"`
+		CreatedAt time.Time        `json:"created_at"`
+	}
+
+	res := make([]responseType, 0, len(events))
+	for _, event := range events {
+		res = append(res, responseType{
+			Grantor:   event.Grantor,
+			Grantee:   event.Grantee,
+			Status:    event.Status,
+			CreatedAt: event.CreatedAt,
+		})
+	}
+
+	infra.ResponseJSON(w, res, http.StatusOK)
+}
+
+func (a *Adapter) parseUsecaseError(ctx context.Context, component string, usecaseError error) (int, string) {
+	code, msg, err := a.eu.ParseMyError(usecaseError)
+	if code == 0 {
+		a.log.ErrorContext(ctx, fmt.Sprintf("%s: wrong type of usecase error", component), slog.Any("error", err))
+		return http.StatusInternalServerError, "internal error"
+	}
+
+	if code >= 500 {
+		a.log.ErrorContext(ctx, msg, slog.Any("error", err))
+		return code, "internal error"
+	}
+
+	a.log.WarnContext(ctx, msg)
+	return code, strings.SplitN(msg, ": ", 2)[1]
+}
@@ -0,0 +1,33 @@
+package http
+
+import (
+	"context"
+
+	"passman/internal/server/accounts"
+	"passman/internal/server/emergency"
+
+	"github.com/google/uuid"
+)
+
+type emergencyUsecase interface {
+	AddContact(context.Context, uuid.UUID, string, emergency.AccessType, int64) error
+	GetContacts(context.Context, uuid.UUID) ([]emergency.Access, error)
+	RemoveContact(context.Context, uuid.UUID, string) error
+	ApproveRecovery(context.Context, uuid.UUID, string) error
+	RejectRecovery(context.Context, uuid.UUID, string) error
+	GetGrants(context.Context, uuid.UUID) ([]emergency.Access, error)
+	AcceptInvite(context.Context, uuid.UUID, string) error
+	RemoveGrant(context.Context, uuid.UUID, string) error
+	InitiateRecovery(context.Context, uuid.UUID, string) error
+	GetVault(context.Context, uuid.UUID, string) ([]accounts.AccountDTO, error)
+	Takeover(context.Context, uuid.UUID, string, string) error
+	GetHistory(context.Context, uuid.UUID) ([]emergency.Event, error)
+	ParseMyError(error) (int, string, error)
+}
+
+type sessionManager interface {
+	GetString(context.Context, string) string
+	Put(context.Context, string, any)
+	Keys(context.Context) []string
+	AuthenticatedRecently(context.Context) bool
+}
@@ -0,0 +1,44 @@
+package http
+
+import (
+	"fmt"
+
+	"passman/internal/server/emergency"
+
+	vldtr "github.com/go-playground/validator/v10"
+)
+
+type validator struct {
+	v *vldtr.Validate
+}
+
+func newValidator(v *vldtr.Validate) *validator {
+	return &validator{v: v}
+}
+
+func (v *validator) ValidateUsername(username string) error {
+	if err := v.v.Var(username, "required,alphanum,max=15"); err != nil {
+		return fmt.Errorf("invalid username")
+	}
+	return nil
+}
+
+func (v *validator) ValidateContact(username string, accessType emergency.AccessType, waitDays int64) error {
+	validatingStruct := struct {
+		Username string `validate:"required,alphanum,max=15"`
+		WaitDays int64  `validate:"min=1,max=90"`
+	}{Username: username, WaitDays: waitDays}
+
+	if err := v.v.Struct(validatingStruct); err != nil || !accessType.IsValid() {
+		return fmt.Errorf("invalid contact parameters")
+	}
+
+	return nil
+}
+
+func (v *validator) ValidatePassword(password string) error {
+	if err := v.v.Var(password, "min=8"); err != nil {
+		return fmt.Errorf("invalid password")
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package emergency
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AccessType string
+
+const (
+	// AccessView allows the contact to read the vault of the grantor
+	AccessView AccessType = "view"
+	// AccessTakeover allows the contact to read the vault and set a new password of the grantor
+	AccessTakeover AccessType = "takeover"
+)
+
+func (t AccessType) IsValid() bool {
+	return t == AccessView || t == AccessTakeover
+}
+
+type Status string
+
+const (
+	StatusInvited           Status = "invited"
+	StatusAccepted          Status = "accepted"
+	StatusRecoveryInitiated Status = "recovery_initiated"
+	StatusRecoveryApproved  Status = "recovery_approved"
+
+	// Statuses below are recorded only in the history. The rejected access
+	// returns to StatusAccepted.
+	StatusRecoveryRejected Status = "recovery_rejected"
+	StatusTakenOver        Status = "taken_over"
+	StatusRemoved          Status = "removed"
+)
+
+// Access is the designation of the grantee as a trusted contact of the grantor
+type Access struct {
+	ID        uuid.UUID
+	GrantorID uuid.UUID
+	Grantor   string
+	GranteeID uuid.UUID
+	Grantee   string
+	Type      AccessType
+	WaitDays  int64
+	Status    Status
+	// RecoveryInitiatedAt is zero when there is no recovery request
+	RecoveryInitiatedAt time.Time
+}
+
+// RecoveryAvailableAt returns the moment the recovery request is approved
+// automatically if the grantor doesn't reject it
+func (a Access) RecoveryAvailableAt() time.Time {
+	return a.RecoveryInitiatedAt.AddDate(0, 0, int(a.WaitDays))
+}
+
+type Event struct {
+	GrantorID uuid.UUID
+	Grantor   string
+	GranteeID uuid.UUID
+	Grantee   string
+	Status    Status
+	CreatedAt time.Time
+}
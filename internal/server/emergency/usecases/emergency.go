@@ -0,0 +1,344 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"passman/internal/server/accounts"
+	"passman/internal/server/emergency"
+	pwpolicy "passman/pkg/password"
+
+	"github.com/google/uuid"
+)
+
+var (
+	errAccessNotFound      = newClientError("emergency access not found")
+	errNoRecoveryRequest   = newClientError("no recovery request")
+	errRecoveryNotApproved = newClientError("recovery not approved")
+)
+
+type emergencyUsecase struct {
+	repo     repository
+	accounts accountsUsecase
+	users    usersUsecase
+	now      func() time.Time
+}
+
+func New(repo repository, au accountsUsecase, uu usersUsecase) *emergencyUsecase {
+	return &emergencyUsecase{repo: repo, accounts: au, users: uu, now: time.Now}
+}
+
+// AddContact designates the user as a trusted contact of the grantor. The
+// contact has to accept the invite before requesting the recovery.
+func (eu *emergencyUsecase) AddContact(ctx context.Context, grantorID uuid.UUID, username string, accessType emergency.AccessType, waitDays int64) error {
+	granteeID, err := eu.userID(ctx, "AddContact", username)
+	if err != nil {
+		return err
+	}
+	if granteeID == grantorID {
+		return newClientError("you can't be your own contact")
+	}
+
+	if _, err := eu.repo.GetAccess(ctx, grantorID, granteeID); err == nil {
+		return newClientError("contact already exist")
+	} else if !eu.repo.IsEmptyRows(err) {
+		return newInternalError("AddContact", "failed checking dublicates", err)
+	}
+
+	access := emergency.Access{
+		ID:        uuid.New(),
+		GrantorID: grantorID,
+		GranteeID: granteeID,
+		Type:      accessType,
+		WaitDays:  waitDays,
+		Status:    emergency.StatusInvited,
+	}
+	err = eu.repo.WithinTx(ctx, func(txCtx context.Context) error {
+		if err := eu.repo.AddAccess(txCtx, access); err != nil {
+			return err
+		}
+		return eu.repo.AddEvent(txCtx, newEvent(access, emergency.StatusInvited, eu.now()))
+	})
+	if err != nil {
+		return newInternalError("AddContact", "failed adding contact", err)
+	}
+
+	return nil
+}
+
+// GetContacts returns the trusted contacts of the grantor
+func (eu *emergencyUsecase) GetContacts(ctx context.Context, grantorID uuid.UUID) ([]emergency.Access, error) {
+	contacts, err := eu.repo.GetGrantorAccesses(ctx, grantorID)
+	if err != nil {
+		return nil, newInternalError("GetContacts", "failed getting contacts", err)
+	}
+
+	for i := range contacts {
+		if err := eu.refresh(ctx, "GetContacts", &contacts[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return contacts, nil
+}
+
+func (eu *emergencyUsecase) RemoveContact(ctx context.Context, grantorID uuid.UUID, username string) error {
+	access, err := eu.grantorAccess(ctx, "RemoveContact", grantorID, username)
+	if err != nil {
+		return err
+	}
+	return eu.remove(ctx, "RemoveContact", access)
+}
+
+// ApproveRecovery grants the access before the end of the waiting period
+func (eu *emergencyUsecase) ApproveRecovery(ctx context.Context, grantorID uuid.UUID, username string) error {
+	access, err := eu.grantorAccess(ctx, "ApproveRecovery", grantorID, username)
+	if err != nil {
+		return err
+	}
+
+	switch access.Status {
+	case emergency.StatusRecoveryApproved:
+		return nil
+	case emergency.StatusRecoveryInitiated:
+		access.Status = emergency.StatusRecoveryApproved
+		return eu.transition(ctx, "ApproveRecovery", access, emergency.StatusRecoveryApproved, eu.now())
+	default:
+		return errNoRecoveryRequest
+	}
+}
+
+// RejectRecovery rejects the recovery request or revokes the approved access.
+// The contact stays trusted and is able to request the recovery again.
+func (eu *emergencyUsecase) RejectRecovery(ctx context.Context, grantorID uuid.UUID, username string) error {
+	access, err := eu.grantorAccess(ctx, "RejectRecovery", grantorID, username)
+	if err != nil {
+		return err
+	}
+	if access.Status != emergency.StatusRecoveryInitiated && access.Status != emergency.StatusRecoveryApproved {
+		return errNoRecoveryRequest
+	}
+
+	access.Status = emergency.StatusAccepted
+	access.RecoveryInitiatedAt = time.Time{}
+	return eu.transition(ctx, "RejectRecovery", access, emergency.StatusRecoveryRejected, eu.now())
+}
+
+// GetGrants returns the emergency accesses of the grantors to the contact
+func (eu *emergencyUsecase) GetGrants(ctx context.Context, granteeID uuid.UUID) ([]emergency.Access, error) {
+	grants, err := eu.repo.GetGranteeAccesses(ctx, granteeID)
+	if err != nil {
+		return nil, newInternalError("GetGrants", "failed getting grants", err)
+	}
+
+	for i := range grants {
+		if err := eu.refresh(ctx, "GetGrants", &grants[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return grants, nil
+}
+
+func (eu *emergencyUsecase) AcceptInvite(ctx context.Context, granteeID uuid.UUID, username string) error {
+	access, err := eu.granteeAccess(ctx, "AcceptInvite", granteeID, username)
+	if err != nil {
+		return err
+	}
+	if access.Status != emergency.StatusInvited {
+		return newClientError("invite already accepted")
+	}
+
+	access.Status = emergency.StatusAccepted
+	return eu.transition(ctx, "AcceptInvite", access, emergency.StatusAccepted, eu.now())
+}
+
+// RemoveGrant declines the invite or stops being a trusted contact of the grantor
+func (eu *emergencyUsecase) RemoveGrant(ctx context.Context, granteeID uuid.UUID, username string) error {
+	access, err := eu.granteeAccess(ctx, "RemoveGrant", granteeID, username)
+	if err != nil {
+		return err
+	}
+	return eu.remove(ctx, "RemoveGrant", access)
+}
+
+// InitiateRecovery requests the access to the vault of the grantor. The access
+// is granted after the waiting period unless the grantor rejects the request.
+func (eu *emergencyUsecase) InitiateRecovery(ctx context.Context, granteeID uuid.UUID, username string) error {
+	access, err := eu.granteeAccess(ctx, "InitiateRecovery", granteeID, username)
+	if err != nil {
+		return err
+	}
+
+	switch access.Status {
+	case emergency.StatusInvited:
+		return newClientError("invite not accepted")
+	case emergency.StatusRecoveryInitiated, emergency.StatusRecoveryApproved:
+		return newClientError("recovery already initiated")
+	}
+
+	access.Status = emergency.StatusRecoveryInitiated
+	access.RecoveryInitiatedAt = eu.now()
+	return eu.transition(ctx, "InitiateRecovery", access, emergency.StatusRecoveryInitiated, access.RecoveryInitiatedAt)
+}
+
+// GetVault returns the accounts of the grantor after the recovery is approved
+func (eu *emergencyUsecase) GetVault(ctx context.Context, granteeID uuid.UUID, username string) ([]accounts.AccountDTO, error) {
+	access, err := eu.granteeAccess(ctx, "GetVault", granteeID, username)
+	if err != nil {
+		return nil, err
+	}
+	if access.Status != emergency.StatusRecoveryApproved {
+		return nil, errRecoveryNotApproved
+	}
+
+	dtos, err := eu.accounts.GetAllAccounts(ctx, access.GrantorID)
+	if err != nil {
+		return nil, newInternalError("GetVault", "failed getting accounts", err)
+	}
+
+	return dtos, nil
+}
+
+// Takeover sets the new password of the grantor after the recovery is approved
+func (eu *emergencyUsecase) Takeover(ctx context.Context, granteeID uuid.UUID, username, password string) error {
+	access, err := eu.granteeAccess(ctx, "Takeover", granteeID, username)
+	if err != nil {
+		return err
+	}
+	if access.Status != emergency.StatusRecoveryApproved {
+		return errRecoveryNotApproved
+	}
+	if access.Type != emergency.AccessTakeover {
+		return newClientError("no takeover permission")
+	}
+
+	if err := eu.users.ResetPassword(ctx, access.GrantorID, password); err != nil {
+		var policyErr *pwpolicy.PolicyError
+		if errors.As(err, &policyErr) {
+			return newWeakPasswordError(policyErr)
+		}
+		return newInternalError("Takeover", "failed resetting password", err)
+	}
+
+	if err := eu.repo.AddEvent(ctx, newEvent(access, emergency.StatusTakenOver, eu.now())); err != nil {
+		return newInternalError("Takeover", "failed adding event", err)
+	}
+
+	return nil
+}
+
+// GetHistory returns the state transitions of the emergency accesses where
+// the user is the grantor or the contact
+func (eu *emergencyUsecase) GetHistory(ctx context.Context, userID uuid.UUID) ([]emergency.Event, error) {
+	// Waiting periods over since the last request are recorded first
+	if _, err := eu.GetContacts(ctx, userID); err != nil {
+		return nil, err
+	}
+	if _, err := eu.GetGrants(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	events, err := eu.repo.GetEvents(ctx, userID)
+	if err != nil {
+		return nil, newInternalError("GetHistory", "failed getting events", err)
+	}
+
+	return events, nil
+}
+
+func (eu *emergencyUsecase) grantorAccess(ctx context.Context, component string, grantorID uuid.UUID, username string) (emergency.Access, error) {
+	granteeID, err := eu.userID(ctx, component, username)
+	if err != nil {
+		return emergency.Access{}, err
+	}
+
+	access, err := eu.access(ctx, component, grantorID, granteeID)
+	access.Grantee = username
+	return access, err
+}
+
+func (eu *emergencyUsecase) granteeAccess(ctx context.Context, component string, granteeID uuid.UUID, username string) (emergency.Access, error) {
+	grantorID, err := eu.userID(ctx, component, username)
+	if err != nil {
+		return emergency.Access{}, err
+	}
+
+	access, err := eu.access(ctx, component, grantorID, granteeID)
+	access.Grantor = username
+	return access, err
+}
+
+func (eu *emergencyUsecase) access(ctx context.Context, component string, grantorID, granteeID uuid.UUID) (emergency.Access, error) {
+	access, err := eu.repo.GetAccess(ctx, grantorID, granteeID)
+	if err != nil {
+		if eu.repo.IsEmptyRows(err) {
+			return emergency.Access{}, errAccessNotFound
+		}
+		return emergency.Access{}, newInternalError(component, "failed getting emergency access", err)
+	}
+
+	if err := eu.refresh(ctx, component, &access); err != nil {
+		return emergency.Access{}, err
+	}
+
+	return access, nil
+}
+
+// refresh approves the recovery request when the waiting period is over
+// without rejection of the grantor
+func (eu *emergencyUsecase) refresh(ctx context.Context, component string, access *emergency.Access) error {
+	if access.Status != emergency.StatusRecoveryInitiated || eu.now().Before(access.RecoveryAvailableAt()) {
+		return nil
+	}
+
+	access.Status = emergency.StatusRecoveryApproved
+	return eu.transition(ctx, component, *access, emergency.StatusRecoveryApproved, access.RecoveryAvailableAt())
+}
+
+// transition persists the new state of the access with its event in the history
+func (eu *emergencyUsecase) transition(ctx context.Context, component string, access emergency.Access, status emergency.Status, at time.Time) error {
+	err := eu.repo.WithinTx(ctx, func(txCtx context.Context) error {
+		if err := eu.repo.UpdateAccessStatus(txCtx, access); err != nil {
+			return err
+		}
+		return eu.repo.AddEvent(txCtx, newEvent(access, status, at))
+	})
+	if err != nil {
+		return newInternalError(component, "failed updating emergency access", err)
+	}
+	return nil
+}
+
+func (eu *emergencyUsecase) remove(ctx context.Context, component string, access emergency.Access) error {
+	err := eu.repo.WithinTx(ctx, func(txCtx context.Context) error {
+		if err := eu.repo.RemoveAccess(txCtx, access.ID); err != nil {
+			return err
+		}
+		return eu.repo.AddEvent(txCtx, newEvent(access, emergency.StatusRemoved, eu.now()))
+	})
+	if err != nil {
+		return newInternalError(component, "failed removing emergency access", err)
+	}
+	return nil
+}
+
+func (eu *emergencyUsecase) userID(ctx context.Context, component, username string) (uuid.UUID, error) {
+	id, err := eu.repo.GetUserID(ctx, username)
+	if err != nil {
+		if eu.repo.IsEmptyRows(err) {
+			return uuid.Nil, newClientError("user not found")
+		}
+		return uuid.Nil, newInternalError(component, "failed getting user id", err)
+	}
+	return id, nil
+}
+
+func newEvent(access emergency.Access, status emergency.Status, at time.Time) emergency.Event {
+	return emergency.Event{GrantorID: access.GrantorID, GranteeID: access.GranteeID, Status: status, CreatedAt: at}
+}
+
+func (eu *emergencyUsecase) ParseMyError(err error) (int, string, error) {
+	return parseEmergencyError(err)
+}
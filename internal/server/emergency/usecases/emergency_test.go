@@ -0,0 +1,318 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"passman/internal/server/accounts"
+	"passman/internal/server/emergency"
+	mock_usecases "passman/internal/server/emergency/usecases/mock"
+
+	"github.com/google/uuid"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAddContact(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockrepository(ctrl)
+	emergencyUsecase := New(mockRepo, nil, nil)
+	ctx := context.Background()
+	errEmptyRows := errors.New("empty rows")
+	grantorID, granteeID := uuid.New(), uuid.New()
+
+	type getUserIDResult struct {
+		id  uuid.UUID
+		err error
+	}
+
+	type txResult struct {
+		addAccessErr error
+	}
+
+	tests := []struct {
+		name            string
+		getUserIDResult getUserIDResult
+		getAccessErr    *error
+		txResult        *txResult
+		expResult       error
+	}{
+		{
+			name:            "user_not_found",
+			getUserIDResult: getUserIDResult{err: errEmptyRows},
+			expResult:       errors.New("ClientError: user not found"),
+		},
+		{
+			name:            "own_contact",
+			getUserIDResult: getUserIDResult{id: grantorID},
+			expResult:       errors.New("ClientError: you can't be your own contact"),
+		},
+		{
+			name:            "contact_exist",
+			getUserIDResult: getUserIDResult{id: granteeID},
+			getAccessErr:    new(error),
+			expResult:       errors.New("ClientError: contact already exist"),
+		},
+		{
+			name:            "failed_adding_contact",
+			getUserIDResult: getUserIDResult{id: granteeID},
+			getAccessErr:    &errEmptyRows,
+			txResult:        &txResult{addAccessErr: errors.New("internal error")},
+			expResult:       errors.New("AddContact: failed adding contact"),
+		},
+		{
+			name:            "success",
+			getUserIDResult: getUserIDResult{id: granteeID},
+			getAccessErr:    &errEmptyRows,
+			txResult:        &txResult{},
+			expResult:       nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().
+				GetUserID(ctx, "bobby").
+				Return(test.getUserIDResult.id, test.getUserIDResult.err).
+				Times(1)
+
+			if test.getUserIDResult.err != nil {
+				mockRepo.EXPECT().IsEmptyRows(test.getUserIDResult.err).Return(true).Times(1)
+			}
+
+			if test.getAccessErr != nil {
+				mockRepo.EXPECT().
+					GetAccess(ctx, grantorID, granteeID).
+					Return(emergency.Access{}, *test.getAccessErr).
+					Times(1)
+				if *test.getAccessErr != nil {
+					mockRepo.EXPECT().IsEmptyRows(*test.getAccessErr).Return(true).Times(1)
+				}
+			}
+
+			if test.txResult != nil {
+				mockRepo.EXPECT().
+					WithinTx(ctx, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+						return fn(ctx)
+					}).
+					Times(1)
+
+				mockRepo.EXPECT().
+					AddAccess(ctx, gomock.Cond(func(a emergency.Access) bool {
+						return a.GrantorID == grantorID && a.GranteeID == granteeID && a.Status == emergency.StatusInvited
+					})).
+					Return(test.txResult.addAccessErr).
+					Times(1)
+
+				if test.txResult.addAccessErr == nil {
+					mockRepo.EXPECT().
+						AddEvent(ctx, gomock.Cond(func(e emergency.Event) bool {
+							return e.Status == emergency.StatusInvited
+						})).
+						Return(nil).
+						Times(1)
+				}
+			}
+
+			actErr := emergencyUsecase.AddContact(ctx, grantorID, "bobby", emergency.AccessView, 7)
+
+			if got, want := actErr, test.expResult; !errors.Is(got, want) {
+				t.Errorf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestInitiateRecovery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockrepository(ctrl)
+	emergencyUsecase := New(mockRepo, nil, nil)
+	ctx := context.Background()
+	now := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	emergencyUsecase.now = func() time.Time { return now }
+	grantorID, granteeID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name        string
+		status      emergency.Status
+		initiatedAt time.Time
+		updates     int
+		expResult   error
+	}{
+		{
+			name:      "invite_not_accepted",
+			status:    emergency.StatusInvited,
+			expResult: errors.New("ClientError: invite not accepted"),
+		},
+		{
+			name:        "already_initiated",
+			status:      emergency.StatusRecoveryInitiated,
+			initiatedAt: now.AddDate(0, 0, -1),
+			expResult:   errors.New("ClientError: recovery already initiated"),
+		},
+		{
+			name:      "success",
+			status:    emergency.StatusAccepted,
+			updates:   1,
+			expResult: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			access := emergency.Access{
+				ID:                  uuid.New(),
+				GrantorID:           grantorID,
+				GranteeID:           granteeID,
+				Type:                emergency.AccessView,
+				WaitDays:            7,
+				Status:              test.status,
+				RecoveryInitiatedAt: test.initiatedAt,
+			}
+
+			mockRepo.EXPECT().GetUserID(ctx, "alice").Return(grantorID, nil).Times(1)
+			mockRepo.EXPECT().GetAccess(ctx, grantorID, granteeID).Return(access, nil).Times(1)
+
+			mockRepo.EXPECT().
+				WithinTx(ctx, gomock.Any()).
+				DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+					return fn(ctx)
+				}).
+				Times(test.updates)
+
+			mockRepo.EXPECT().
+				UpdateAccessStatus(ctx, gomock.Cond(func(a emergency.Access) bool {
+					return a.Status == emergency.StatusRecoveryInitiated && a.RecoveryInitiatedAt.Equal(now)
+				})).
+				Return(nil).
+				Times(test.updates)
+
+			mockRepo.EXPECT().
+				AddEvent(ctx, emergency.Event{
+					GrantorID: grantorID,
+					GranteeID: granteeID,
+					Status:    emergency.StatusRecoveryInitiated,
+					CreatedAt: now,
+				}).
+				Return(nil).
+				Times(test.updates)
+
+			actErr := emergencyUsecase.InitiateRecovery(ctx, granteeID, "alice")
+
+			if got, want := actErr, test.expResult; !errors.Is(got, want) {
+				t.Errorf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestGetVault(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockrepository(ctrl)
+	mockAccounts := mock_usecases.NewMockaccountsUsecase(ctrl)
+	emergencyUsecase := New(mockRepo, mockAccounts, nil)
+	ctx := context.Background()
+	now := time.Date(2025, time.March, 10, 12, 0, 0, 0, time.UTC)
+	emergencyUsecase.now = func() time.Time { return now }
+	grantorID, granteeID := uuid.New(), uuid.New()
+	dtos := []accounts.AccountDTO{{Name: "main", Login: "alice", Password: "secret"}}
+
+	tests := []struct {
+		name        string
+		status      emergency.Status
+		initiatedAt time.Time
+		approves    int
+		expDTOs     []accounts.AccountDTO
+		expResult   error
+	}{
+		{
+			name:      "not_requested",
+			status:    emergency.StatusAccepted,
+			expResult: errors.New("ClientError: recovery not approved"),
+		},
+		{
+			name:        "waiting_period",
+			status:      emergency.StatusRecoveryInitiated,
+			initiatedAt: now.AddDate(0, 0, -6),
+			expResult:   errors.New("ClientError: recovery not approved"),
+		},
+		{
+			name:        "waiting_period_is_over",
+			status:      emergency.StatusRecoveryInitiated,
+			initiatedAt: now.AddDate(0, 0, -7),
+			approves:    1,
+			expDTOs:     dtos,
+			expResult:   nil,
+		},
+		{
+			name:        "approved",
+			status:      emergency.StatusRecoveryApproved,
+			initiatedAt: now.AddDate(0, 0, -1),
+			expDTOs:     dtos,
+			expResult:   nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			access := emergency.Access{
+				ID:                  uuid.New(),
+				GrantorID:           grantorID,
+				GranteeID:           granteeID,
+				Type:                emergency.AccessView,
+				WaitDays:            7,
+				Status:              test.status,
+				RecoveryInitiatedAt: test.initiatedAt,
+			}
+
+			mockRepo.EXPECT().GetUserID(ctx, "alice").Return(grantorID, nil).Times(1)
+			mockRepo.EXPECT().GetAccess(ctx, grantorID, granteeID).Return(access, nil).Times(1)
+
+			mockRepo.EXPECT().
+				WithinTx(ctx, gomock.Any()).
+				DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+					return fn(ctx)
+				}).
+				Times(test.approves)
+
+			mockRepo.EXPECT().
+				UpdateAccessStatus(ctx, gomock.Cond(func(a emergency.Access) bool {
+					return a.Status == emergency.StatusRecoveryApproved
+				})).
+				Return(nil).
+				Times(test.approves)
+
+			mockRepo.EXPECT().
+				AddEvent(ctx, emergency.Event{
+					GrantorID: grantorID,
+					GranteeID: granteeID,
+					Status:    emergency.StatusRecoveryApproved,
+					CreatedAt: test.initiatedAt.AddDate(0, 0, 7),
+				}).
+				Return(nil).
+				Times(test.approves)
+
+			if test.expResult == nil {
+				mockAccounts.EXPECT().GetAllAccounts(ctx, grantorID).Return(dtos, nil).Times(1)
+			}
+
+			actDTOs, actErr := emergencyUsecase.GetVault(ctx, granteeID, "alice")
+
+			if got, want := actErr, test.expResult; !errors.Is(got, want) {
+				t.Errorf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+
+			if got, want := len(actDTOs), len(test.expDTOs); got != want {
+				t.Errorf("Wrong! Unexpected number of accounts!\n\tExpected: %d\n\tActual: %d", want, got)
+			}
+		})
+	}
+}
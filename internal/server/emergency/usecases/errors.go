@@ -0,0 +1,47 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+)
+
+type emergencyError struct {
+	Code      int
+	Component string
+	Msg       string
+	Err       error
+}
+
+func (ce *emergencyError) Error() string {
+	return fmt.Sprintf("%s: %s", ce.Component, ce.Msg)
+}
+
+func (ce *emergencyError) Unwrap() error {
+	return ce.Err
+}
+
+func (ce *emergencyError) Is(target error) bool {
+	return ce.Error() == target.Error()
+}
+
+func newClientError(msg string) error {
+	return &emergencyError{Code: 400, Component: "ClientError", Msg: msg, Err: nil}
+}
+
+// newWeakPasswordError is the client error keeping *password.PolicyError for
+// the detailed response
+func newWeakPasswordError(policyErr error) error {
+	return &emergencyError{Code: 400, Component: "ClientError", Msg: "weak password", Err: policyErr}
+}
+
+func newInternalError(component, msg string, err error) error {
+	return &emergencyError{Code: 500, Component: component, Msg: msg, Err: err}
+}
+
+func parseEmergencyError(err error) (int, string, error) {
+	var ce *emergencyError
+	if errors.As(err, &ce) {
+		return ce.Code, ce.Error(), ce.Err
+	}
+	return 0, "", nil
+}
@@ -0,0 +1,33 @@
+package usecases
+
+import (
+	"context"
+
+	"passman/internal/server/accounts"
+	"passman/internal/server/emergency"
+
+	"github.com/google/uuid"
+)
+
+//go:generate mockgen -source=interfaces.go -destination=mock/usecases.go
+type repository interface {
+	GetUserID(ctx context.Context, username string) (uuid.UUID, error)
+	AddAccess(ctx context.Context, access emergency.Access) error
+	GetAccess(ctx context.Context, grantorID, granteeID uuid.UUID) (emergency.Access, error)
+	GetGrantorAccesses(ctx context.Context, grantorID uuid.UUID) ([]emergency.Access, error)
+	GetGranteeAccesses(ctx context.Context, granteeID uuid.UUID) ([]emergency.Access, error)
+	UpdateAccessStatus(ctx context.Context, access emergency.Access) error
+	RemoveAccess(ctx context.Context, accessID uuid.UUID) error
+	AddEvent(ctx context.Context, event emergency.Event) error
+	GetEvents(ctx context.Context, userID uuid.UUID) ([]emergency.Event, error)
+	WithinTx(ctx context.Context, fn func(txCtx context.Context) error) error
+	IsEmptyRows(err error) bool
+}
+
+type accountsUsecase interface {
+	GetAllAccounts(ctx context.Context, userID uuid.UUID) ([]accounts.AccountDTO, error)
+}
+
+type usersUsecase interface {
+	ResetPassword(ctx context.Context, userID uuid.UUID, password string) error
+}
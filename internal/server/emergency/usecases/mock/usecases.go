@@ -0,0 +1,280 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interfaces.go
+//
+// Generated by this command:
+//
+//	mockgen -source=interfaces.go -destination=mock/usecases.go
+//
+
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	context "context"
+	accounts "passman/internal/server/accounts"
+	emergency "passman/internal/server/emergency"
+	reflect "reflect"
+
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// Mockrepository is a mock of repository interface.
+type Mockrepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockrepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockrepositoryMockRecorder is the mock recorder for Mockrepository.
+type MockrepositoryMockRecorder struct {
+	mock *Mockrepository
+}
+
+// NewMockrepository creates a new mock instance.
+func NewMockrepository(ctrl *gomock.Controller) *Mockrepository {
+	mock := &Mockrepository{ctrl: ctrl}
+	mock.recorder = &MockrepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockrepository) EXPECT() *MockrepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddAccess mocks base method.
+func (m *Mockrepository) AddAccess(ctx context.Context, access emergency.Access) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAccess", ctx, access)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAccess indicates an expected call of AddAccess.
+func (mr *MockrepositoryMockRecorder) AddAccess(ctx, access any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccess", reflect.TypeOf((*Mockrepository)(nil).AddAccess), ctx, access)
+}
+
+// AddEvent mocks base method.
+func (m *Mockrepository) AddEvent(ctx context.Context, event emergency.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddEvent", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddEvent indicates an expected call of AddEvent.
+func (mr *MockrepositoryMockRecorder) AddEvent(ctx, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddEvent", reflect.TypeOf((*Mockrepository)(nil).AddEvent), ctx, event)
+}
+
+// GetAccess mocks base method.
+func (m *Mockrepository) GetAccess(ctx context.Context, grantorID, granteeID uuid.UUID) (emergency.Access, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccess", ctx, grantorID, granteeID)
+	ret0, _ := ret[0].(emergency.Access)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccess indicates an expected call of GetAccess.
+func (mr *MockrepositoryMockRecorder) GetAccess(ctx, grantorID, granteeID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccess", reflect.TypeOf((*Mockrepository)(nil).GetAccess), ctx, grantorID, granteeID)
+}
+
+// GetEvents mocks base method.
+func (m *Mockrepository) GetEvents(ctx context.Context, userID uuid.UUID) ([]emergency.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEvents", ctx, userID)
+	ret0, _ := ret[0].([]emergency.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEvents indicates an expected call of GetEvents.
+func (mr *MockrepositoryMockRecorder) GetEvents(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEvents", reflect.TypeOf((*Mockrepository)(nil).GetEvents), ctx, userID)
+}
+
+// GetGranteeAccesses mocks base method.
+func (m *Mockrepository) GetGranteeAccesses(ctx context.Context, granteeID uuid.UUID) ([]emergency.Access, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGranteeAccesses", ctx, granteeID)
+	ret0, _ := ret[0].([]emergency.Access)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGranteeAccesses indicates an expected call of GetGranteeAccesses.
+func (mr *MockrepositoryMockRecorder) GetGranteeAccesses(ctx, granteeID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGranteeAccesses", reflect.TypeOf((*Mockrepository)(nil).GetGranteeAccesses), ctx, granteeID)
+}
+
+// GetGrantorAccesses mocks base method.
+func (m *Mockrepository) GetGrantorAccesses(ctx context.Context, grantorID uuid.UUID) ([]emergency.Access, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGrantorAccesses", ctx, grantorID)
+	ret0, _ := ret[0].([]emergency.Access)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGrantorAccesses indicates an expected call of GetGrantorAccesses.
+func (mr *MockrepositoryMockRecorder) GetGrantorAccesses(ctx, grantorID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGrantorAccesses", reflect.TypeOf((*Mockrepository)(nil).GetGrantorAccesses), ctx, grantorID)
+}
+
+// GetUserID mocks base method.
+func (m *Mockrepository) GetUserID(ctx context.Context, username string) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserID", ctx, username)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserID indicates an expected call of GetUserID.
+func (mr *MockrepositoryMockRecorder) GetUserID(ctx, username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserID", reflect.TypeOf((*Mockrepository)(nil).GetUserID), ctx, username)
+}
+
+// IsEmptyRows mocks base method.
+func (m *Mockrepository) IsEmptyRows(err error) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsEmptyRows", err)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsEmptyRows indicates an expected call of IsEmptyRows.
+func (mr *MockrepositoryMockRecorder) IsEmptyRows(err any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsEmptyRows", reflect.TypeOf((*Mockrepository)(nil).IsEmptyRows), err)
+}
+
+// RemoveAccess mocks base method.
+func (m *Mockrepository) RemoveAccess(ctx context.Context, accessID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveAccess", ctx, accessID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveAccess indicates an expected call of RemoveAccess.
+func (mr *MockrepositoryMockRecorder) RemoveAccess(ctx, accessID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveAccess", reflect.TypeOf((*Mockrepository)(nil).RemoveAccess), ctx, accessID)
+}
+
+// UpdateAccessStatus mocks base method.
+func (m *Mockrepository) UpdateAccessStatus(ctx context.Context, access emergency.Access) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccessStatus", ctx, access)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAccessStatus indicates an expected call of UpdateAccessStatus.
+func (mr *MockrepositoryMockRecorder) UpdateAccessStatus(ctx, access any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccessStatus", reflect.TypeOf((*Mockrepository)(nil).UpdateAccessStatus), ctx, access)
+}
+
+// WithinTx mocks base method.
+func (m *Mockrepository) WithinTx(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithinTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithinTx indicates an expected call of WithinTx.
+func (mr *MockrepositoryMockRecorder) WithinTx(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithinTx", reflect.TypeOf((*Mockrepository)(nil).WithinTx), ctx, fn)
+}
+
+// MockaccountsUsecase is a mock of accountsUsecase interface.
+type MockaccountsUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockaccountsUsecaseMockRecorder
+	isgomock struct{}
+}
+
+// MockaccountsUsecaseMockRecorder is the mock recorder for MockaccountsUsecase.
+type MockaccountsUsecaseMockRecorder struct {
+	mock *MockaccountsUsecase
+}
+
+// NewMockaccountsUsecase creates a new mock instance.
+func NewMockaccountsUsecase(ctrl *gomock.Controller) *MockaccountsUsecase {
+	mock := &MockaccountsUsecase{ctrl: ctrl}
+	mock.recorder = &MockaccountsUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockaccountsUsecase) EXPECT() *MockaccountsUsecaseMockRecorder {
+	return m.recorder
+}
+
+// GetAllAccounts mocks base method.
+func (m *MockaccountsUsecase) GetAllAccounts(ctx context.Context, userID uuid.UUID) ([]accounts.AccountDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllAccounts", ctx, userID)
+	ret0, _ := ret[0].([]accounts.AccountDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllAccounts indicates an expected call of GetAllAccounts.
+func (mr *MockaccountsUsecaseMockRecorder) GetAllAccounts(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllAccounts", reflect.TypeOf((*MockaccountsUsecase)(nil).GetAllAccounts), ctx, userID)
+}
+
+// MockusersUsecase is a mock of usersUsecase interface.
+type MockusersUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockusersUsecaseMockRecorder
+	isgomock struct{}
+}
+
+// MockusersUsecaseMockRecorder is the mock recorder for MockusersUsecase.
+type MockusersUsecaseMockRecorder struct {
+	mock *MockusersUsecase
+}
+
+// NewMockusersUsecase creates a new mock instance.
+func NewMockusersUsecase(ctrl *gomock.Controller) *MockusersUsecase {
+	mock := &MockusersUsecase{ctrl: ctrl}
+	mock.recorder = &MockusersUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockusersUsecase) EXPECT() *MockusersUsecaseMockRecorder {
+	return m.recorder
+}
+
+// ResetPassword mocks base method.
+func (m *MockusersUsecase) ResetPassword(ctx context.Context, userID uuid.UUID, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", ctx, userID, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockusersUsecaseMockRecorder) ResetPassword(ctx, userID, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockusersUsecase)(nil).ResetPassword), ctx, userID, password)
+}
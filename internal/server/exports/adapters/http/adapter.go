@@ -0,0 +1,93 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"passman/internal/server/exports"
+	"passman/internal/server/infra"
+
+	"github.com/go-chi/chi/v5"
+	vldtr "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+const (
+	passwordHeader       = "X-Password"
+	exportPasswordHeader = "X-Export-Password"
+)
+
+type Adapter struct {
+	log *slog.Logger
+	eu  exportUsecase
+	sm  sessionManager
+	v   *validator
+}
+
+func NewRouter(eu exportUsecase, sm sessionManager, v *vldtr.Validate) chi.Router {
+	a := &Adapter{
+		log: slog.Default(),
+		eu:  eu,
+		sm:  sm,
+		v:   newValidator(v),
+	}
+
+	router := chi.NewRouter()
+
+	router.Use(infra.AuthMiddleware(sm))
+
+	router.With(infra.RecentAuthMiddleware(sm)).Get("/", a.Export)
+
+	return router
+}
+
+func (a *Adapter) Export(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	req := exports.Request{
+		UserID:         userID,
+		Format:         exports.Format(r.URL.Query().Get("format")),
+		Password:       r.Header.Get(passwordHeader),
+		ExportPassword: r.Header.Get(exportPasswordHeader),
+	}
+	if len(req.Format) == 0 {
+		req.Format = exports.FormatEncrypted
+	}
+
+	if err := a.v.ValidateRequest(req); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	file, err := a.eu.Export(r.Context(), req)
+	if err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "Export", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.Header().Set("Content-Type", file.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.Name))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(file.Data)
+}
+
+func (a *Adapter) parseUsecaseError(ctx context.Context, component string, usecaseError error) (int, string) {
+	code, msg, err := a.eu.ParseMyError(usecaseError)
+	if code == 0 {
+		a.log.ErrorContext(ctx, fmt.Sprintf("%s: wrong type of usecase error", component), slog.Any("error", err))
+		return http.StatusInternalServerError, "internal error"
+	}
+
+	if code >= 500 {
+		a.log.ErrorContext(ctx, msg, slog.Any("error", err))
+		return code, "internal error"
+	}
+
+	a.log.WarnContext(ctx, msg)
+	return code, strings.SplitN(msg, ": ", 2)[1]
+}
@@ -0,0 +1,19 @@
+package http
+
+import (
+	"context"
+
+	"passman/internal/server/exports"
+)
+
+type exportUsecase interface {
+	Export(context.Context, exports.Request) (exports.File, error)
+	ParseMyError(error) (int, string, error)
+}
+
+type sessionManager interface {
+	GetString(context.Context, string) string
+	Put(context.Context, string, any)
+	Keys(context.Context) []string
+	AuthenticatedRecently(context.Context) bool
+}
@@ -0,0 +1,35 @@
+package http
+
+import (
+	"fmt"
+
+	"passman/internal/server/exports"
+
+	vldtr "github.com/go-playground/validator/v10"
+)
+
+type validator struct {
+	v *vldtr.Validate
+}
+
+func newValidator(v *vldtr.Validate) *validator {
+	return &validator{v: v}
+}
+
+func (v *validator) ValidateRequest(req exports.Request) error {
+	validatingStruct := struct {
+		Format         string `validate:"oneof=json csv encrypted kdbx"`
+		Password       string `validate:"required"`
+		ExportPassword string `validate:"required_if=Format encrypted,required_if=Format kdbx,omitempty,min=8"`
+	}{
+		Format:         string(req.Format),
+		Password:       req.Password,
+		ExportPassword: req.ExportPassword,
+	}
+
+	if err := v.v.Struct(validatingStruct); err != nil {
+		return fmt.Errorf("invalid format, password or export password")
+	}
+
+	return nil
+}
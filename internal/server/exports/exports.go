@@ -0,0 +1,27 @@
+package exports
+
+import "github.com/google/uuid"
+
+type Format string
+
+const (
+	FormatJSON      Format = "json"
+	FormatCSV       Format = "csv"
+	FormatEncrypted Format = "encrypted"
+	FormatKDBX      Format = "kdbx"
+)
+
+type Request struct {
+	UserID uuid.UUID
+	Format Format
+	// Password of the user for re-confirmation
+	Password string
+	// ExportPassword protects the encrypted export file and KeePass database
+	ExportPassword string
+}
+
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
@@ -0,0 +1,41 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+)
+
+type exportsError struct {
+	Code      int
+	Component string
+	Msg       string
+	Err       error
+}
+
+func (ee *exportsError) Error() string {
+	return fmt.Sprintf("%s: %s", ee.Component, ee.Msg)
+}
+
+func (ee *exportsError) Unwrap() error {
+	return ee.Err
+}
+
+func (ee *exportsError) Is(target error) bool {
+	return ee.Error() == target.Error()
+}
+
+func newClientError(msg string) error {
+	return &exportsError{Code: 400, Component: "ClientError", Msg: msg, Err: nil}
+}
+
+func newInternalError(component, msg string, err error) error {
+	return &exportsError{Code: 500, Component: component, Msg: msg, Err: err}
+}
+
+func parseExportsError(err error) (int, string, error) {
+	var ee *exportsError
+	if errors.As(err, &ee) {
+		return ee.Code, ee.Error(), ee.Err
+	}
+	return 0, "", nil
+}
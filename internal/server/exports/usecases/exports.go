@@ -0,0 +1,152 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"passman/internal/server/exports"
+	"passman/pkg/kdbx"
+	"passman/pkg/vaultfile"
+
+	"github.com/google/uuid"
+)
+
+var errIncorrectPassword = newClientError("incorrect password")
+
+type exportUsecase struct {
+	accounts accountsUsecase
+	users    usersUsecase
+}
+
+func New(au accountsUsecase, uu usersUsecase) *exportUsecase {
+	return &exportUsecase{accounts: au, users: uu}
+}
+
+func (eu *exportUsecase) Export(ctx context.Context, req exports.Request) (exports.File, error) {
+	match, err := eu.users.VerifyPassword(ctx, req.UserID, req.Password)
+	if err != nil {
+		return exports.File{}, newInternalError("Export", "failed verifying password", err)
+	}
+	if !match {
+		return exports.File{}, errIncorrectPassword
+	}
+
+	return eu.export(ctx, req)
+}
+
+// FinalExport is the encrypted export of the user who is deleted, the caller
+// has already confirmed the password
+func (eu *exportUsecase) FinalExport(ctx context.Context, userID uuid.UUID, exportPassword string) (exports.File, error) {
+	return eu.export(ctx, exports.Request{UserID: userID, Format: exports.FormatEncrypted, ExportPassword: exportPassword})
+}
+
+func (eu *exportUsecase) export(ctx context.Context, req exports.Request) (exports.File, error) {
+	vault, err := eu.buildVault(ctx, req.UserID)
+	if err != nil {
+		return exports.File{}, err
+	}
+
+	filename := fmt.Sprintf("passman-export-%s", vault.ExportedAt.Format("20060102-150405"))
+	buf := bytes.NewBuffer(nil)
+
+	switch req.Format {
+	case exports.FormatJSON:
+		if err := vaultfile.WriteJSON(buf, vault); err != nil {
+			return exports.File{}, newInternalError("Export", "failed writing json", err)
+		}
+		return exports.File{Name: filename + ".json", ContentType: "application/json", Data: buf.Bytes()}, nil
+	case exports.FormatCSV:
+		if err := vaultfile.WriteCSV(buf, vault); err != nil {
+			return exports.File{}, newInternalError("Export", "failed writing csv", err)
+		}
+		return exports.File{Name: filename + ".csv", ContentType: "text/csv", Data: buf.Bytes()}, nil
+	case exports.FormatEncrypted:
+		if len(req.ExportPassword) == 0 {
+			return exports.File{}, newClientError("export password required")
+		}
+		if err := vaultfile.WriteEncrypted(buf, vault, req.ExportPassword); err != nil {
+			return exports.File{}, newInternalError("Export", "failed writing encrypted file", err)
+		}
+		return exports.File{Name: filename + ".encrypted.json", ContentType: "application/json", Data: buf.Bytes()}, nil
+	case exports.FormatKDBX:
+		if len(req.ExportPassword) == 0 {
+			return exports.File{}, newClientError("export password required")
+		}
+		if err := kdbx.Write(buf, buildKDBX(vault), req.ExportPassword, kdbx.DefaultOptions()); err != nil {
+			return exports.File{}, newInternalError("Export", "failed writing kdbx", err)
+		}
+		return exports.File{Name: filename + ".kdbx", ContentType: "application/octet-stream", Data: buf.Bytes()}, nil
+	default:
+		return exports.File{}, newClientError("unsupported format")
+	}
+}
+
+func (eu *exportUsecase) buildVault(ctx context.Context, userID uuid.UUID) (vaultfile.Vault, error) {
+	dtos, err := eu.accounts.GetAllAccounts(ctx, userID)
+	if err != nil {
+		return vaultfile.Vault{}, newInternalError("Export", "failed getting accounts", err)
+	}
+
+	vault := vaultfile.New(time.Now())
+	seenServices := make(map[string]bool)
+	for _, dto := range dtos {
+		if !seenServices[dto.ServiceName] {
+			seenServices[dto.ServiceName] = true
+			vault.Services = append(vault.Services, vaultfile.Service{Name: dto.ServiceName})
+		}
+
+		vault.Accounts = append(vault.Accounts, vaultfile.Account{
+			Service:  dto.ServiceName,
+			Name:     dto.Name,
+			Login:    dto.Login,
+			Password: dto.Password,
+			TOTP:     dto.TOTP,
+		})
+	}
+
+	return vault, nil
+}
+
+// buildKDBX maps services to groups of the root group and accounts to their entries
+func buildKDBX(vault vaultfile.Vault) kdbx.Database {
+	db := kdbx.Database{Name: "passman", Root: kdbx.Group{Name: "passman"}}
+
+	groups := make(map[string]int, len(vault.Services))
+	for _, srv := range vault.Services {
+		groups[srv.Name] = len(db.Root.Groups)
+		db.Root.Groups = append(db.Root.Groups, kdbx.Group{Name: srv.Name})
+	}
+
+	for _, acc := range vault.Accounts {
+		i := groups[acc.Service]
+		db.Root.Groups[i].Entries = append(db.Root.Groups[i].Entries, kdbx.Entry{
+			Title:    acc.Name,
+			UserName: acc.Login,
+			Password: acc.Password,
+			OTP:      otpURI(acc.Service, acc.Name, acc.TOTP),
+		})
+	}
+
+	return db
+}
+
+// otpURI converts the base32 secret to otpauth:// URI expected in "otp" attribute
+func otpURI(serviceName, accountName, totp string) string {
+	if len(totp) == 0 || strings.HasPrefix(totp, "otpauth://") {
+		return totp
+	}
+
+	query := url.Values{}
+	query.Set("secret", strings.ToUpper(strings.ReplaceAll(totp, " ", "")))
+	query.Set("issuer", serviceName)
+
+	return fmt.Sprintf("otpauth://totp/%s?%s", url.PathEscape(serviceName+":"+accountName), query.Encode())
+}
+
+func (eu *exportUsecase) ParseMyError(err error) (int, string, error) {
+	return parseExportsError(err)
+}
@@ -0,0 +1,203 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"passman/internal/server/accounts"
+	"passman/internal/server/exports"
+	mock_usecases "passman/internal/server/exports/usecases/mock"
+	"passman/pkg/kdbx"
+	"passman/pkg/vaultfile"
+
+	"github.com/google/uuid"
+	"go.uber.org/mock/gomock"
+)
+
+func TestExport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAccounts := mock_usecases.NewMockaccountsUsecase(ctrl)
+	mockUsers := mock_usecases.NewMockusersUsecase(ctrl)
+	exportUsecase := New(mockAccounts, mockUsers)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	dtos := []accounts.AccountDTO{
+		{QueryParams: accounts.QueryParams{UserID: userID, ServiceName: "google"}, Name: "main", Login: "l1", Password: "p1"},
+		{QueryParams: accounts.QueryParams{UserID: userID, ServiceName: "google"}, Name: "work", Login: "l2", Password: "p2"},
+		{QueryParams: accounts.QueryParams{UserID: userID, ServiceName: "youtube"}, Name: "main", Login: "l3", Password: "p3", TOTP: "jbsw y3dp ehpk 3pxp"},
+	}
+
+	type verifyResult struct {
+		match bool
+		err   error
+	}
+
+	tests := []struct {
+		name           string
+		format         exports.Format
+		exportPassword string
+		verifyResult   verifyResult
+		getAccountsErr error
+		expContains    string
+		expErr         error
+	}{
+		{
+			name:         "failed_verifying_password",
+			format:       exports.FormatJSON,
+			verifyResult: verifyResult{err: errors.New("internal error")},
+			expErr:       errors.New("Export: failed verifying password"),
+		},
+		{
+			name:         "incorrect_password",
+			format:       exports.FormatJSON,
+			verifyResult: verifyResult{match: false},
+			expErr:       errors.New("ClientError: incorrect password"),
+		},
+		{
+			name:           "failed_getting_accounts",
+			format:         exports.FormatJSON,
+			verifyResult:   verifyResult{match: true},
+			getAccountsErr: errors.New("internal error"),
+			expErr:         errors.New("Export: failed getting accounts"),
+		},
+		{
+			name:         "export_password_required",
+			format:       exports.FormatEncrypted,
+			verifyResult: verifyResult{match: true},
+			expErr:       errors.New("ClientError: export password required"),
+		},
+		{
+			name:         "csv",
+			format:       exports.FormatCSV,
+			verifyResult: verifyResult{match: true},
+			expContains:  "google,,work,l2,p2,\n",
+		},
+		{
+			name:         "json",
+			format:       exports.FormatJSON,
+			verifyResult: verifyResult{match: true},
+			expContains:  `"format": "passman-export"`,
+		},
+		{
+			name:           "encrypted",
+			format:         exports.FormatEncrypted,
+			exportPassword: "export password",
+			verifyResult:   verifyResult{match: true},
+			expContains:    `"format": "passman-encrypted-export"`,
+		},
+		{
+			name:         "kdbx_export_password_required",
+			format:       exports.FormatKDBX,
+			verifyResult: verifyResult{match: true},
+			expErr:       errors.New("ClientError: export password required"),
+		},
+		{
+			name:           "kdbx",
+			format:         exports.FormatKDBX,
+			exportPassword: "export password",
+			verifyResult:   verifyResult{match: true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockUsers.EXPECT().
+				VerifyPassword(ctx, userID, "password").
+				Return(test.verifyResult.match, test.verifyResult.err).
+				Times(1)
+
+			if test.verifyResult.match {
+				mockAccounts.EXPECT().
+					GetAllAccounts(ctx, userID).
+					Return(dtos, test.getAccountsErr).
+					Times(1)
+			}
+
+			req := exports.Request{
+				UserID:         userID,
+				Format:         test.format,
+				Password:       "password",
+				ExportPassword: test.exportPassword,
+			}
+			actFile, actErr := exportUsecase.Export(ctx, req)
+
+			if got, want := actErr, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if test.expErr != nil {
+				return
+			}
+
+			if !strings.Contains(string(actFile.Data), test.expContains) {
+				t.Errorf("Wrong! Unexpected file content!\n\tExpected to contain: %s\n\tActual: %s", test.expContains, actFile.Data)
+			}
+
+			if test.format == exports.FormatCSV {
+				return
+			}
+
+			if test.format == exports.FormatKDBX {
+				db, err := kdbx.Read(bytes.NewReader(actFile.Data), test.exportPassword)
+				if err != nil {
+					t.Fatalf("Wrong! Exported database is not readable: %v", err)
+				}
+				if got, want := len(db.Root.Groups), 2; got != want {
+					t.Fatalf("Wrong! Unexpected groups count!\n\tExpected: %d\n\tActual: %d", want, got)
+				}
+				if got, want := db.Root.Groups[1].Entries[0].OTP, "otpauth://totp/youtube:main?issuer=youtube&secret=JBSWY3DPEHPK3PXP"; got != want {
+					t.Errorf("Wrong! Unexpected otp!\n\tExpected: %s\n\tActual: %s", want, got)
+				}
+				return
+			}
+
+			vault, err := vaultfile.Read(bytes.NewReader(actFile.Data), test.exportPassword)
+			if err != nil {
+				t.Fatalf("Wrong! Exported file is not readable: %v", err)
+			}
+			if got, want := len(vault.Services), 2; got != want {
+				t.Errorf("Wrong! Unexpected services count!\n\tExpected: %d\n\tActual: %d", want, got)
+			}
+			if got, want := len(vault.Accounts), len(dtos); got != want {
+				t.Errorf("Wrong! Unexpected accounts count!\n\tExpected: %d\n\tActual: %d", want, got)
+			}
+		})
+	}
+}
+
+func TestFinalExport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAccounts := mock_usecases.NewMockaccountsUsecase(ctrl)
+	mockUsers := mock_usecases.NewMockusersUsecase(ctrl)
+	exportUsecase := New(mockAccounts, mockUsers)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	dtos := []accounts.AccountDTO{
+		{QueryParams: accounts.QueryParams{UserID: userID, ServiceName: "google"}, Name: "main", Login: "l1", Password: "p1"},
+	}
+
+	// The password is confirmed by the deletion, it isn't asked again
+	mockUsers.EXPECT().VerifyPassword(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	mockAccounts.EXPECT().GetAllAccounts(ctx, userID).Return(dtos, nil).Times(1)
+
+	file, err := exportUsecase.FinalExport(ctx, userID, "export password")
+	if err != nil {
+		t.Fatalf("Wrong! Unexpected error!\n\tExpected: nil\n\tActual: %v", err)
+	}
+
+	vault, err := vaultfile.Read(bytes.NewReader(file.Data), "export password")
+	if err != nil {
+		t.Fatalf("Wrong! Exported file is not readable: %v", err)
+	}
+	if got, want := len(vault.Accounts), len(dtos); got != want {
+		t.Errorf("Wrong! Unexpected accounts count!\n\tExpected: %d\n\tActual: %d", want, got)
+	}
+}
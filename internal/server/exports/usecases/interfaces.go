@@ -0,0 +1,18 @@
+package usecases
+
+import (
+	"context"
+
+	"passman/internal/server/accounts"
+
+	"github.com/google/uuid"
+)
+
+//go:generate mockgen -source=interfaces.go -destination=mock/usecases.go
+type accountsUsecase interface {
+	GetAllAccounts(ctx context.Context, userID uuid.UUID) ([]accounts.AccountDTO, error)
+}
+
+type usersUsecase interface {
+	VerifyPassword(ctx context.Context, userID uuid.UUID, password string) (bool, error)
+}
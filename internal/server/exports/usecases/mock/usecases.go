@@ -0,0 +1,97 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interfaces.go
+//
+// Generated by this command:
+//
+//	mockgen -source=interfaces.go -destination=mock/usecases.go
+//
+
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	context "context"
+	accounts "passman/internal/server/accounts"
+	reflect "reflect"
+
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockaccountsUsecase is a mock of accountsUsecase interface.
+type MockaccountsUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockaccountsUsecaseMockRecorder
+	isgomock struct{}
+}
+
+// MockaccountsUsecaseMockRecorder is the mock recorder for MockaccountsUsecase.
+type MockaccountsUsecaseMockRecorder struct {
+	mock *MockaccountsUsecase
+}
+
+// NewMockaccountsUsecase creates a new mock instance.
+func NewMockaccountsUsecase(ctrl *gomock.Controller) *MockaccountsUsecase {
+	mock := &MockaccountsUsecase{ctrl: ctrl}
+	mock.recorder = &MockaccountsUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockaccountsUsecase) EXPECT() *MockaccountsUsecaseMockRecorder {
+	return m.recorder
+}
+
+// GetAllAccounts mocks base method.
+func (m *MockaccountsUsecase) GetAllAccounts(ctx context.Context, userID uuid.UUID) ([]accounts.AccountDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllAccounts", ctx, userID)
+	ret0, _ := ret[0].([]accounts.AccountDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllAccounts indicates an expected call of GetAllAccounts.
+func (mr *MockaccountsUsecaseMockRecorder) GetAllAccounts(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllAccounts", reflect.TypeOf((*MockaccountsUsecase)(nil).GetAllAccounts), ctx, userID)
+}
+
+// MockusersUsecase is a mock of usersUsecase interface.
+type MockusersUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockusersUsecaseMockRecorder
+	isgomock struct{}
+}
+
+// MockusersUsecaseMockRecorder is the mock recorder for MockusersUsecase.
+type MockusersUsecaseMockRecorder struct {
+	mock *MockusersUsecase
+}
+
+// NewMockusersUsecase creates a new mock instance.
+func NewMockusersUsecase(ctrl *gomock.Controller) *MockusersUsecase {
+	mock := &MockusersUsecase{ctrl: ctrl}
+	mock.recorder = &MockusersUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockusersUsecase) EXPECT() *MockusersUsecaseMockRecorder {
+	return m.recorder
+}
+
+// VerifyPassword mocks base method.
+func (m *MockusersUsecase) VerifyPassword(ctx context.Context, userID uuid.UUID, password string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyPassword", ctx, userID, password)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyPassword indicates an expected call of VerifyPassword.
+func (mr *MockusersUsecaseMockRecorder) VerifyPassword(ctx, userID, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyPassword", reflect.TypeOf((*MockusersUsecase)(nil).VerifyPassword), ctx, userID, password)
+}
@@ -0,0 +1,150 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"passman/internal/server/imports"
+	"passman/internal/server/infra"
+	"passman/pkg/importer"
+
+	"github.com/go-chi/chi/v5"
+	vldtr "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type itemResponse struct {
+	Title   string `json:"title"`
+	Service string `json:"service,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+type reportResponse struct {
+	DryRun          bool           `json:"dry_run"`
+	CreatedServices []string       `json:"created_services"`
+	Imported        []itemResponse `json:"imported"`
+	Skipped         []itemResponse `json:"skipped"`
+	Duplicates      []itemResponse `json:"duplicates"`
+}
+
+type Adapter struct {
+	log *slog.Logger
+	iu  importUsecase
+	sm  sessionManager
+	v   *validator
+}
+
+func NewRouter(iu importUsecase, sm sessionManager, v *vldtr.Validate) chi.Router {
+	a := &Adapter{
+		log: slog.Default(),
+		iu:  iu,
+		sm:  sm,
+		v:   newValidator(v),
+	}
+
+	router := chi.NewRouter()
+
+	router.Use(infra.AuthMiddleware(sm))
+
+	router.Post("/{format}", a.Import)
+
+	return router
+}
+
+func (a *Adapter) Import(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	format := importer.Format(chi.URLParam(r, "format"))
+	if err := a.v.ValidateFormat(format); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dryRun := false
+	if raw := r.URL.Query().Get("dry_run"); len(raw) > 0 {
+		var err error
+		if dryRun, err = strconv.ParseBool(raw); err != nil {
+			infra.ErrorHandler(w, http.StatusBadRequest, "invalid dry_run parameter")
+			return
+		}
+	}
+
+	file, err := infra.RecieveFile(r, infra.RecieveFileOptions{FormFileKey: "file"})
+	if err != nil {
+		code, msg := a.parseRecieveFileError(r.Context(), "Import", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+	defer file.Close()
+
+	opts := imports.Options{
+		UserID:   userID,
+		Format:   format,
+		DryRun:   dryRun,
+		Password: r.FormValue("password"),
+	}
+
+	report, err := a.iu.Import(r.Context(), opts, file)
+	if err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "Import", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	infra.ResponseJSON(w, reportResponse{
+		DryRun:          report.DryRun,
+		CreatedServices: append([]string{}, report.CreatedServices...),
+		Imported:        toItemsResponse(report.Imported),
+		Skipped:         toItemsResponse(report.Skipped),
+		Duplicates:      toItemsResponse(report.Duplicates),
+	}, http.StatusOK)
+}
+
+func toItemsResponse(items []imports.Item) []itemResponse {
+	res := make([]itemResponse, 0, len(items))
+	for _, item := range items {
+		res = append(res, itemResponse{Title: item.Title, Service: item.Service, Name: item.Name, Reason: item.Reason})
+	}
+	return res
+}
+
+func (a *Adapter) parseUsecaseError(ctx context.Context, component string, usecaseError error) (int, string) {
+	code, msg, err := a.iu.ParseMyError(usecaseError)
+	if code == 0 {
+		a.log.ErrorContext(ctx, fmt.Sprintf("%s: wrong type of usecase error", component), slog.Any("error", err))
+		return http.StatusInternalServerError, "internal error"
+	}
+
+	if code >= 500 {
+		a.log.ErrorContext(ctx, msg, slog.Any("error", err))
+		return code, "internal error"
+	}
+
+	a.log.WarnContext(ctx, msg)
+	return code, strings.SplitN(msg, ": ", 2)[1]
+}
+
+func (a *Adapter) parseRecieveFileError(ctx context.Context, component string, err error) (int, string) {
+	var recieveError *infra.RecieveFileError
+	if errors.As(err, &recieveError) {
+		var msg string
+
+		if recieveError.Code == http.StatusInternalServerError {
+			a.log.ErrorContext(ctx, fmt.Sprintf("%s: multipart error", component), slog.Any("error", err))
+			msg = "internal error"
+		} else {
+			msg = recieveError.Error()
+		}
+
+		return recieveError.Code, msg
+	}
+
+	a.log.ErrorContext(ctx, fmt.Sprintf("%s: wrong type of multipart error", component), slog.String("error", "error expected to be recieveFileError type"))
+	return http.StatusInternalServerError, "internal error"
+}
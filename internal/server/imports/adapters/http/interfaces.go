@@ -0,0 +1,19 @@
+package http
+
+import (
+	"context"
+	"io"
+
+	"passman/internal/server/imports"
+)
+
+type importUsecase interface {
+	Import(context.Context, imports.Options, io.Reader) (imports.Report, error)
+	ParseMyError(error) (int, string, error)
+}
+
+type sessionManager interface {
+	GetString(context.Context, string) string
+	Put(context.Context, string, any)
+	Keys(context.Context) []string
+}
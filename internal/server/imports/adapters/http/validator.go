@@ -0,0 +1,25 @@
+package http
+
+import (
+	"fmt"
+	"slices"
+
+	"passman/pkg/importer"
+
+	vldtr "github.com/go-playground/validator/v10"
+)
+
+type validator struct {
+	v *vldtr.Validate
+}
+
+func newValidator(v *vldtr.Validate) *validator {
+	return &validator{v: v}
+}
+
+func (v *validator) ValidateFormat(format importer.Format) error {
+	if !slices.Contains(importer.Formats(), format) {
+		return fmt.Errorf("unsupported format, expect one of %v", importer.Formats())
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package imports
+
+import (
+	"passman/pkg/importer"
+
+	"github.com/google/uuid"
+)
+
+type Options struct {
+	UserID uuid.UUID
+	Format importer.Format
+	DryRun bool
+	// Password of the encrypted export file
+	Password string
+}
+
+type Item struct {
+	Title   string
+	Service string
+	Name    string
+	Reason  string
+}
+
+type Report struct {
+	DryRun          bool
+	CreatedServices []string
+	Imported        []Item
+	Skipped         []Item
+	Duplicates      []Item
+}
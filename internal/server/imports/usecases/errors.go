@@ -0,0 +1,41 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+)
+
+type importsError struct {
+	Code      int
+	Component string
+	Msg       string
+	Err       error
+}
+
+func (ie *importsError) Error() string {
+	return fmt.Sprintf("%s: %s", ie.Component, ie.Msg)
+}
+
+func (ie *importsError) Unwrap() error {
+	return ie.Err
+}
+
+func (ie *importsError) Is(target error) bool {
+	return ie.Error() == target.Error()
+}
+
+func newClientError(msg string) error {
+	return &importsError{Code: 400, Component: "ClientError", Msg: msg, Err: nil}
+}
+
+func newInternalError(component, msg string, err error) error {
+	return &importsError{Code: 500, Component: component, Msg: msg, Err: err}
+}
+
+func parseImportsError(err error) (int, string, error) {
+	var ie *importsError
+	if errors.As(err, &ie) {
+		return ie.Code, ie.Error(), ie.Err
+	}
+	return 0, "", nil
+}
@@ -0,0 +1,259 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode"
+
+	"passman/internal/server/accounts"
+	"passman/internal/server/imports"
+	"passman/internal/server/services"
+	"passman/pkg/importer"
+)
+
+// Logo of the services created by import
+//
+//go:embed default_logo.svg
+var defaultLogo []byte
+
+// Second-level domains which are not the service name (bbc.co.uk)
+var genericSLD = []string{"co", "com", "org", "net", "gov", "edu", "ac"}
+
+type importUsecase struct {
+	accounts accountsUsecase
+	services servicesUsecase
+}
+
+func New(au accountsUsecase, su servicesUsecase) *importUsecase {
+	return &importUsecase{accounts: au, services: su}
+}
+
+func (iu *importUsecase) Import(ctx context.Context, opts imports.Options, file io.Reader) (imports.Report, error) {
+	entries, err := importer.Parse(opts.Format, file, importer.Options{Password: opts.Password})
+	if err != nil {
+		return imports.Report{}, newClientError(fmt.Sprintf("invalid %s file: %v", opts.Format, err))
+	}
+
+	return iu.importEntries(ctx, opts, entries)
+}
+
+func (iu *importUsecase) importEntries(ctx context.Context, opts imports.Options, entries []importer.Entry) (imports.Report, error) {
+	srvs, err := iu.services.GetAllServices(ctx)
+	if err != nil {
+		return imports.Report{}, newInternalError("Import", "failed getting services", err)
+	}
+
+	// Lower case name -> stored name
+	existingServices := make(map[string]string, len(srvs))
+	for _, srv := range srvs {
+		existingServices[strings.ToLower(srv.Name)] = srv.Name
+	}
+
+	report := imports.Report{DryRun: opts.DryRun}
+	loadedServices := make(map[string]bool)
+	knownAccounts := make(map[string]bool)
+	ops := make([]accounts.Operation, 0, len(entries))
+
+	for _, entry := range entries {
+		item := imports.Item{Title: entry.Title}
+
+		if len(entry.Username) == 0 || len(entry.Password) == 0 {
+			item.Reason = "no login or password"
+			report.Skipped = append(report.Skipped, item)
+			continue
+		}
+
+		if item.Service = detectServiceName(entry); len(item.Service) == 0 {
+			item.Reason = "unable to detect service"
+			report.Skipped = append(report.Skipped, item)
+			continue
+		}
+
+		if item.Name = detectAccountName(entry); len(item.Name) == 0 {
+			item.Reason = "invalid account name"
+			report.Skipped = append(report.Skipped, item)
+			continue
+		}
+
+		if stored, ok := existingServices[strings.ToLower(item.Service)]; ok {
+			item.Service = stored
+			if !loadedServices[stored] {
+				if err := iu.loadAccounts(ctx, opts, stored, knownAccounts); err != nil {
+					return imports.Report{}, err
+				}
+				loadedServices[stored] = true
+			}
+		} else {
+			// Later entries of the service take the name of the first one,
+			// new services have no stored accounts
+			existingServices[strings.ToLower(item.Service)] = item.Service
+			loadedServices[item.Service] = true
+			report.CreatedServices = append(report.CreatedServices, item.Service)
+		}
+
+		key := accountKey(item.Service, item.Name)
+		if knownAccounts[key] {
+			item.Reason = "account with this name already exist"
+			report.Duplicates = append(report.Duplicates, item)
+			continue
+		}
+		knownAccounts[key] = true
+
+		ops = append(ops, accounts.Operation{
+			Type: accounts.OperationCreate,
+			AccountDTO: accounts.AccountDTO{
+				QueryParams: accounts.QueryParams{
+					UserID:      opts.UserID,
+					ServiceName: item.Service,
+				},
+				Name:     item.Name,
+				Login:    entry.Username,
+				Password: entry.Password,
+				TOTP:     entry.TOTP,
+			},
+		})
+		report.Imported = append(report.Imported, item)
+	}
+
+	if opts.DryRun || len(ops) == 0 {
+		return report, nil
+	}
+
+	// Services live apart from the accounts, so they're created before the
+	// atomic batch and removed if the import fails
+	created := make([]string, 0, len(report.CreatedServices))
+	for _, serviceName := range report.CreatedServices {
+		if err := iu.services.AddService(ctx, serviceName, bytes.NewReader(defaultLogo)); err != nil {
+			return imports.Report{}, iu.removeServices(ctx, opts, created, newInternalError("Import", "failed creating service", err))
+		}
+		created = append(created, serviceName)
+	}
+
+	results, err := iu.accounts.ApplyBatch(ctx, accounts.BatchAtomic, ops)
+	for _, result := range results {
+		if err == nil && result.Err != nil {
+			err = result.Err
+		}
+	}
+	if err != nil {
+		return imports.Report{}, iu.removeServices(ctx, opts, created, newInternalError("Import", "failed importing accounts", err))
+	}
+
+	return report, nil
+}
+
+// removeServices rolls back services created by the failed import and returns
+// the cause of the failure. The request may be canceled already, the removal
+// isn't.
+func (iu *importUsecase) removeServices(ctx context.Context, opts imports.Options, serviceNames []string, cause error) error {
+	ctx = context.WithoutCancel(ctx)
+
+	errs := []error{cause}
+	for _, serviceName := range serviceNames {
+		if err := iu.services.RemoveService(ctx, opts.UserID, serviceName); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 1 {
+		return newInternalError("Import", "failed removing created services", errors.Join(errs...))
+	}
+	return cause
+}
+
+func (iu *importUsecase) loadAccounts(ctx context.Context, opts imports.Options, serviceName string, known map[string]bool) error {
+	dtos, err := iu.accounts.GetAccountsInService(ctx, accounts.QueryParams{UserID: opts.UserID, ServiceName: serviceName})
+	if err != nil {
+		return newInternalError("Import", "failed getting existing accounts", err)
+	}
+
+	for _, dto := range dtos {
+		// Accounts shared with the user don't conflict with own ones
+		if len(dto.Owner) > 0 {
+			continue
+		}
+		known[accountKey(serviceName, dto.Name)] = true
+	}
+
+	return nil
+}
+
+func (iu *importUsecase) ParseMyError(err error) (int, string, error) {
+	return parseImportsError(err)
+}
+
+func accountKey(serviceName, accountName string) string {
+	return serviceName + "/" + accountName
+}
+
+// detectServiceName takes the service name from the entry if the format knows
+// it, from the domain of the entry URL (accounts.google.com -> google) or from
+// the entry title if URL is empty.
+func detectServiceName(entry importer.Entry) string {
+	if len(entry.Service) > 0 {
+		// Service name is used as logo filename, so path symbols are removed
+		name := strings.Map(func(r rune) rune {
+			if strings.ContainsRune("~!@#$%^&*?<>/\\", r) || unicode.IsControl(r) {
+				return -1
+			}
+			return r
+		}, entry.Service)
+		return truncateServiceName(strings.TrimLeft(strings.TrimSpace(name), "."))
+	}
+
+	name := entry.Title
+	if host := entry.Host(); len(host) > 0 {
+		labels := strings.Split(host, ".")
+		name = labels[0]
+		if len(labels) > 1 {
+			name = labels[len(labels)-2]
+		}
+		if len(labels) > 2 && slices.Contains(genericSLD, name) {
+			name = labels[len(labels)-3]
+		}
+	}
+
+	// Service name is used as logo filename, so only safe symbols are kept
+	return truncateServiceName(strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return unicode.ToLower(r)
+		default:
+			return -1
+		}
+	}, name))
+}
+
+// truncateServiceName cuts long titles and hosts, logos of services are stored
+// by the name
+func truncateServiceName(name string) string {
+	if runes := []rune(name); len(runes) > services.MaxNameLength {
+		name = strings.TrimSpace(string(runes[:services.MaxNameLength]))
+	}
+	return name
+}
+
+// detectAccountName returns the first of entry title and username which is
+// valid account name after removing forbidden symbols.
+func detectAccountName(entry importer.Entry) string {
+	for _, candidate := range []string{entry.Title, entry.Username} {
+		name := strings.TrimSpace(strings.Map(func(r rune) rune {
+			if strings.ContainsRune("~!@#$%^&*?<>/", r) || unicode.IsControl(r) {
+				return -1
+			}
+			return r
+		}, candidate))
+
+		if len([]rune(name)) >= 3 {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,263 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"passman/internal/server/accounts"
+	"passman/internal/server/imports"
+	mock_usecases "passman/internal/server/imports/usecases/mock"
+	"passman/internal/server/services"
+	"passman/pkg/importer"
+
+	"github.com/google/uuid"
+	"go.uber.org/mock/gomock"
+)
+
+func TestImport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAccounts := mock_usecases.NewMockaccountsUsecase(ctrl)
+	mockServices := mock_usecases.NewMockservicesUsecase(ctrl)
+	importUsecase := New(mockAccounts, mockServices)
+
+	ctx := context.Background()
+	userID := uuid.New()
+
+	chromeCSV := "name,url,username,password,note\n" +
+		"Main,https://www.youtube.com/,yt,ytpass,\n" +
+		"Work,https://youtube.com/,yt2,ytpass2,\n" +
+		"Main,https://www.youtube.com/,yt,other,\n" +
+		"Git,https://github.com/login,octo,cat,\n" +
+		"Empty,https://example.com,,,\n" +
+		"??,,user,pass,\n"
+
+	existingServices := []services.ServiceDTO{{Name: "youtube", Logo: "/assets/youtube"}}
+	existingAccounts := []accounts.AccountDTO{{Name: "Work"}}
+
+	expReport := imports.Report{
+		CreatedServices: []string{"github"},
+		Imported: []imports.Item{
+			{Title: "Main", Service: "youtube", Name: "Main"},
+			{Title: "Git", Service: "github", Name: "Git"},
+		},
+		Skipped: []imports.Item{
+			{Title: "Empty", Reason: "no login or password"},
+			{Title: "??", Reason: "unable to detect service"},
+		},
+		Duplicates: []imports.Item{
+			{Title: "Work", Service: "youtube", Name: "Work", Reason: "account with this name already exist"},
+			{Title: "Main", Service: "youtube", Name: "Main", Reason: "account with this name already exist"},
+		},
+	}
+
+	type batchResult struct {
+		results []accounts.OperationResult
+		err     error
+	}
+
+	tests := []struct {
+		name           string
+		format         importer.Format
+		dryRun         bool
+		getServicesErr error
+		batchResult    *batchResult
+		removeErr      error
+		expReport      imports.Report
+		expErr         error
+	}{
+		{
+			name:   "invalid_file",
+			format: importer.Bitwarden,
+			expErr: errors.New("ClientError: invalid bitwarden file: failed decoding json: invalid character 'a' in literal null (expecting 'u')"),
+		},
+		{
+			name:           "failed_getting_services",
+			format:         importer.ChromeCSV,
+			getServicesErr: errors.New("internal error"),
+			expErr:         errors.New("Import: failed getting services"),
+		},
+		{
+			name:      "dry_run",
+			format:    importer.ChromeCSV,
+			dryRun:    true,
+			expReport: expReport,
+		},
+		{
+			name:   "failed_importing_account",
+			format: importer.ChromeCSV,
+			batchResult: &batchResult{
+				results: []accounts.OperationResult{
+					{Index: 0, Status: accounts.OperationFailed, Err: errors.New("internal error")},
+					{Index: 1, Status: accounts.OperationSkipped},
+				},
+			},
+			expErr: errors.New("Import: failed importing accounts"),
+		},
+		{
+			name:   "failed_applying_batch",
+			format: importer.ChromeCSV,
+			batchResult: &batchResult{
+				err: errors.New("internal error"),
+			},
+			expErr: errors.New("Import: failed importing accounts"),
+		},
+		{
+			name:   "failed_removing_created_services",
+			format: importer.ChromeCSV,
+			batchResult: &batchResult{
+				err: errors.New("internal error"),
+			},
+			removeErr: errors.New("internal error"),
+			expErr:    errors.New("Import: failed removing created services"),
+		},
+		{
+			name:   "success",
+			format: importer.ChromeCSV,
+			batchResult: &batchResult{
+				results: []accounts.OperationResult{
+					{Index: 0, Status: accounts.OperationApplied},
+					{Index: 1, Status: accounts.OperationApplied},
+				},
+			},
+			expReport: expReport,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.format == importer.ChromeCSV {
+				mockServices.EXPECT().
+					GetAllServices(ctx).
+					Return(existingServices, test.getServicesErr).
+					Times(1)
+
+				if test.getServicesErr == nil {
+					mockAccounts.EXPECT().
+						GetAccountsInService(ctx, accounts.QueryParams{UserID: userID, ServiceName: "youtube"}).
+						Return(existingAccounts, nil).
+						Times(1)
+				}
+			}
+
+			if test.batchResult != nil {
+				mockServices.EXPECT().
+					AddService(ctx, "github", gomock.Any()).
+					Return(nil).
+					Times(1)
+
+				mockAccounts.EXPECT().
+					ApplyBatch(ctx, accounts.BatchAtomic, gomock.Len(2)).
+					Return(test.batchResult.results, test.batchResult.err).
+					Times(1)
+
+				if test.expErr != nil {
+					mockServices.EXPECT().
+						RemoveService(gomock.Any(), userID, "github").
+						Return(test.removeErr).
+						Times(1)
+				}
+			}
+
+			opts := imports.Options{UserID: userID, Format: test.format, DryRun: test.dryRun}
+			actReport, actErr := importUsecase.Import(ctx, opts, strings.NewReader(chromeCSV))
+
+			if got, want := actErr, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+
+			test.expReport.DryRun = test.dryRun
+			if test.expErr != nil {
+				test.expReport = imports.Report{}
+			}
+			if got, want := actReport, test.expReport; !reflect.DeepEqual(got, want) {
+				t.Errorf("Wrong! Unexpected report!\n\tExpected: %+v\n\tActual: %+v", want, got)
+			}
+		})
+	}
+}
+
+func TestImportServiceNameCase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAccounts := mock_usecases.NewMockaccountsUsecase(ctrl)
+	mockServices := mock_usecases.NewMockservicesUsecase(ctrl)
+	importUsecase := New(mockAccounts, mockServices)
+
+	ctx := context.Background()
+	opts := imports.Options{UserID: uuid.New(), DryRun: true}
+
+	entries := []importer.Entry{
+		{Service: "GitHub", Title: "Main", Username: "octo", Password: "cat"},
+		{Service: "github", Title: "Work", Username: "octo2", Password: "cat2"},
+		{Service: "GITHUB", Title: "Main", Username: "octo3", Password: "cat3"},
+	}
+
+	expReport := imports.Report{
+		DryRun:          true,
+		CreatedServices: []string{"GitHub"},
+		Imported: []imports.Item{
+			{Title: "Main", Service: "GitHub", Name: "Main"},
+			{Title: "Work", Service: "GitHub", Name: "Work"},
+		},
+		Duplicates: []imports.Item{
+			{Title: "Main", Service: "GitHub", Name: "Main", Reason: "account with this name already exist"},
+		},
+	}
+
+	mockServices.EXPECT().
+		GetAllServices(ctx).
+		Return(nil, nil).
+		Times(1)
+
+	actReport, actErr := importUsecase.importEntries(ctx, opts, entries)
+
+	if actErr != nil {
+		t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", nil, actErr)
+	}
+	if got, want := actReport, expReport; !reflect.DeepEqual(got, want) {
+		t.Errorf("Wrong! Unexpected report!\n\tExpected: %+v\n\tActual: %+v", want, got)
+	}
+}
+
+func TestDetectServiceName(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   importer.Entry
+		expName string
+	}{
+		{
+			name:    "host",
+			entry:   importer.Entry{Title: "Main", URL: "https://www.bbc.co.uk/login"},
+			expName: "bbc",
+		},
+		{
+			name:    "service",
+			entry:   importer.Entry{Service: " ../Work/Mail "},
+			expName: "WorkMail",
+		},
+		{
+			name:    "long_title",
+			entry:   importer.Entry{Title: strings.Repeat("a", services.MaxNameLength+10)},
+			expName: strings.Repeat("a", services.MaxNameLength),
+		},
+		{
+			name:    "long_service",
+			entry:   importer.Entry{Service: strings.Repeat("я", services.MaxNameLength-1) + " b"},
+			expName: strings.Repeat("я", services.MaxNameLength-1),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := detectServiceName(test.entry), test.expName; got != want {
+				t.Errorf("Wrong! Unexpected service name!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
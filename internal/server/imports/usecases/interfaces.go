@@ -0,0 +1,23 @@
+package usecases
+
+import (
+	"context"
+	"io"
+
+	"passman/internal/server/accounts"
+	"passman/internal/server/services"
+
+	"github.com/google/uuid"
+)
+
+//go:generate mockgen -source=interfaces.go -destination=mock/usecases.go
+type accountsUsecase interface {
+	GetAccountsInService(ctx context.Context, params accounts.QueryParams) ([]accounts.AccountDTO, error)
+	ApplyBatch(ctx context.Context, mode accounts.BatchMode, ops []accounts.Operation) ([]accounts.OperationResult, error)
+}
+
+type servicesUsecase interface {
+	AddService(ctx context.Context, serviceName string, logoFile io.Reader) error
+	GetAllServices(ctx context.Context) ([]services.ServiceDTO, error)
+	RemoveService(ctx context.Context, userID uuid.UUID, serviceName string) error
+}
@@ -0,0 +1,142 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interfaces.go
+//
+// Generated by this command:
+//
+//	mockgen -source=interfaces.go -destination=mock/usecases.go
+//
+
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	context "context"
+	io "io"
+	accounts "passman/internal/server/accounts"
+	services "passman/internal/server/services"
+	reflect "reflect"
+
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockaccountsUsecase is a mock of accountsUsecase interface.
+type MockaccountsUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockaccountsUsecaseMockRecorder
+	isgomock struct{}
+}
+
+// MockaccountsUsecaseMockRecorder is the mock recorder for MockaccountsUsecase.
+type MockaccountsUsecaseMockRecorder struct {
+	mock *MockaccountsUsecase
+}
+
+// NewMockaccountsUsecase creates a new mock instance.
+func NewMockaccountsUsecase(ctrl *gomock.Controller) *MockaccountsUsecase {
+	mock := &MockaccountsUsecase{ctrl: ctrl}
+	mock.recorder = &MockaccountsUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockaccountsUsecase) EXPECT() *MockaccountsUsecaseMockRecorder {
+	return m.recorder
+}
+
+// ApplyBatch mocks base method.
+func (m *MockaccountsUsecase) ApplyBatch(ctx context.Context, mode accounts.BatchMode, ops []accounts.Operation) ([]accounts.OperationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyBatch", ctx, mode, ops)
+	ret0, _ := ret[0].([]accounts.OperationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyBatch indicates an expected call of ApplyBatch.
+func (mr *MockaccountsUsecaseMockRecorder) ApplyBatch(ctx, mode, ops any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyBatch", reflect.TypeOf((*MockaccountsUsecase)(nil).ApplyBatch), ctx, mode, ops)
+}
+
+// GetAccountsInService mocks base method.
+func (m *MockaccountsUsecase) GetAccountsInService(ctx context.Context, params accounts.QueryParams) ([]accounts.AccountDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountsInService", ctx, params)
+	ret0, _ := ret[0].([]accounts.AccountDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountsInService indicates an expected call of GetAccountsInService.
+func (mr *MockaccountsUsecaseMockRecorder) GetAccountsInService(ctx, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountsInService", reflect.TypeOf((*MockaccountsUsecase)(nil).GetAccountsInService), ctx, params)
+}
+
+// MockservicesUsecase is a mock of servicesUsecase interface.
+type MockservicesUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockservicesUsecaseMockRecorder
+	isgomock struct{}
+}
+
+// MockservicesUsecaseMockRecorder is the mock recorder for MockservicesUsecase.
+type MockservicesUsecaseMockRecorder struct {
+	mock *MockservicesUsecase
+}
+
+// NewMockservicesUsecase creates a new mock instance.
+func NewMockservicesUsecase(ctrl *gomock.Controller) *MockservicesUsecase {
+	mock := &MockservicesUsecase{ctrl: ctrl}
+	mock.recorder = &MockservicesUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockservicesUsecase) EXPECT() *MockservicesUsecaseMockRecorder {
+	return m.recorder
+}
+
+// AddService mocks base method.
+func (m *MockservicesUsecase) AddService(ctx context.Context, serviceName string, logoFile io.Reader) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddService", ctx, serviceName, logoFile)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddService indicates an expected call of AddService.
+func (mr *MockservicesUsecaseMockRecorder) AddService(ctx, serviceName, logoFile any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddService", reflect.TypeOf((*MockservicesUsecase)(nil).AddService), ctx, serviceName, logoFile)
+}
+
+// GetAllServices mocks base method.
+func (m *MockservicesUsecase) GetAllServices(ctx context.Context) ([]services.ServiceDTO, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllServices", ctx)
+	ret0, _ := ret[0].([]services.ServiceDTO)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllServices indicates an expected call of GetAllServices.
+func (mr *MockservicesUsecaseMockRecorder) GetAllServices(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllServices", reflect.TypeOf((*MockservicesUsecase)(nil).GetAllServices), ctx)
+}
+
+// RemoveService mocks base method.
+func (m *MockservicesUsecase) RemoveService(ctx context.Context, userID uuid.UUID, serviceName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveService", ctx, userID, serviceName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveService indicates an expected call of RemoveService.
+func (mr *MockservicesUsecaseMockRecorder) RemoveService(ctx, userID, serviceName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveService", reflect.TypeOf((*MockservicesUsecase)(nil).RemoveService), ctx, userID, serviceName)
+}
@@ -3,20 +3,150 @@ package infra
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"slices"
+	"time"
+
+	"passman/pkg/password"
 )
 
 type ISessionManager interface {
+	Put(context.Context, string, any)
 	Keys(context.Context) []string
 }
 
+// ITokenSessionManager is needed for routers accepting access tokens, the user
+// of the token is put to the session living only during the request
+type ITokenSessionManager interface {
+	ISessionManager
+	Destroy(context.Context) error
+}
+
+// IRecentAuthSessionManager tells whether the user has entered credentials
+// recently
+type IRecentAuthSessionManager interface {
+	AuthenticatedRecently(context.Context) bool
+}
+
+// AccessToken is the verified bearer token of the request
+type AccessToken struct {
+	UserID string
+	Scopes []string
+}
+
+type accessTokenKey struct{}
+
+func WithAccessToken(ctx context.Context, token AccessToken) context.Context {
+	return context.WithValue(ctx, accessTokenKey{}, token)
+}
+
+func AccessTokenFromContext(ctx context.Context) (AccessToken, bool) {
+	token, ok := ctx.Value(accessTokenKey{}).(AccessToken)
+	return token, ok
+}
+
+// AuthMiddleware lets in only logged in sessions, access tokens are rejected.
+// Sessions of users who have to reset the password are rejected too.
 func AuthMiddleware(sm ISessionManager) func(next http.Handler) http.Handler {
+	return authMiddleware(sm, false)
+}
+
+// PasswordResetAuthMiddleware is AuthMiddleware letting in sessions of users
+// who have to reset the password, it protects the password change
+func PasswordResetAuthMiddleware(sm ISessionManager) func(next http.Handler) http.Handler {
+	return authMiddleware(sm, true)
+}
+
+func authMiddleware(sm ISessionManager, allowPasswordReset bool) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if keys := sm.Keys(r.Context()); len(keys) == 0 {
+			if _, ok := AccessTokenFromContext(r.Context()); ok {
+				ErrorHandler(w, http.StatusForbidden, "access tokens are not allowed")
+				return
+			}
+			// Sessions waiting for the second factor hold only pending_user_id
+			keys := sm.Keys(r.Context())
+			if !slices.Contains(keys, "user_id") {
 				ErrorHandler(w, http.StatusUnauthorized, "unauthorized")
 				return
 			}
+			if !allowPasswordReset && slices.Contains(keys, "password_reset_required") {
+				ErrorHandler(w, http.StatusForbidden, "password reset required")
+				return
+			}
+			sm.Put(r.Context(), "last_active_at", time.Now().Unix())
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ScopedAuthMiddleware lets in logged in sessions and access tokens with the
+// scope: readScope for GET and HEAD requests, writeScope for the rest
+func ScopedAuthMiddleware(sm ITokenSessionManager, readScope, writeScope string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		sessionAuth := AuthMiddleware(sm)(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := AccessTokenFromContext(r.Context())
+			if !ok {
+				sessionAuth.ServeHTTP(w, r)
+				return
+			}
+
+			scope := writeScope
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				scope = readScope
+			}
+			if !slices.Contains(token.Scopes, scope) {
+				ErrorHandler(w, http.StatusForbidden, fmt.Sprintf("access token has no %s scope", scope))
+				return
+			}
+
+			// Handlers read the user from the session, it's destroyed after
+			// the request so the token never turns into a cookie
+			sm.Put(r.Context(), "user_id", token.UserID)
+			defer func() { _ = sm.Destroy(r.Context()) }()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RecentAuthMiddleware protects sensitive actions, the session has to be
+// authenticated recently, otherwise the user confirms the password by
+// POST /users/reauth. Access tokens are rejected, they can't enter the password.
+func RecentAuthMiddleware(sm IRecentAuthSessionManager) func(next http.Handler) http.Handler {
+	return recentAuthMiddleware(sm, "")
+}
+
+// RevealMiddleware is RecentAuthMiddleware for reading secrets, access tokens
+// are let in only with the dedicated tokenScope
+func RevealMiddleware(sm IRecentAuthSessionManager, tokenScope string) func(next http.Handler) http.Handler {
+	return recentAuthMiddleware(sm, tokenScope)
+}
+
+func recentAuthMiddleware(sm IRecentAuthSessionManager, tokenScope string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token, ok := AccessTokenFromContext(r.Context()); ok {
+				if len(tokenScope) == 0 {
+					ErrorHandler(w, http.StatusForbidden, "access tokens are not allowed")
+					return
+				}
+				if !slices.Contains(token.Scopes, tokenScope) {
+					ErrorHandler(w, http.StatusForbidden, fmt.Sprintf("access token has no %s scope", tokenScope))
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !sm.AuthenticatedRecently(r.Context()) {
+				ErrorHandler(w, http.StatusForbidden, "reauthentication required")
+				return
+			}
 			next.ServeHTTP(w, r)
 		})
 	}
@@ -33,3 +163,46 @@ func ErrorHandler(w http.ResponseWriter, code int, msg string) {
 		Error string `json:"error"`
 	}{Error: msg}, code)
 }
+
+// WeakPasswordHandler responds with violated requirements and the whole policy
+// if the error is caused by the password policy. It returns false for other
+// errors, they're handled by the caller.
+func WeakPasswordHandler(w http.ResponseWriter, err error) bool {
+	var policyErr *password.PolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+
+	type policyResponse struct {
+		MinLength     int     `json:"min_length"`
+		MinEntropy    float64 `json:"min_entropy_bits"`
+		CheckCommon   bool    `json:"check_common"`
+		CheckUsername bool    `json:"check_username"`
+	}
+
+	ResponseJSON(w, struct {
+		Error      string         `json:"error"`
+		Violations []string       `json:"violations"`
+		Policy     policyResponse `json:"policy"`
+	}{
+		Error:      "weak password",
+		Violations: policyErr.Violations,
+		Policy: policyResponse{
+			MinLength:     policyErr.Policy.MinLength,
+			MinEntropy:    policyErr.Policy.MinEntropy,
+			CheckCommon:   policyErr.Policy.CheckCommon,
+			CheckUsername: policyErr.Policy.CheckUsername,
+		},
+	}, http.StatusBadRequest)
+	return true
+}
+
+// ClientIP returns the ip of the direct client. Forwarding headers aren't
+// trusted, they're set by the client when there is no proxy.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
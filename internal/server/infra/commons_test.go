@@ -0,0 +1,87 @@
+package infra
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type recentAuthSessionManager bool
+
+func (sm recentAuthSessionManager) AuthenticatedRecently(context.Context) bool {
+	return bool(sm)
+}
+
+func TestRecentAuthMiddleware(t *testing.T) {
+	revealScope := "accounts:reveal"
+
+	tests := []struct {
+		name       string
+		middleware func(IRecentAuthSessionManager) func(http.Handler) http.Handler
+		recent     bool
+		token      *AccessToken
+		expCode    int
+	}{
+		{
+			name:       "recent_session",
+			middleware: RecentAuthMiddleware,
+			recent:     true,
+			expCode:    http.StatusOK,
+		},
+		{
+			name:       "stale_session",
+			middleware: RecentAuthMiddleware,
+			expCode:    http.StatusForbidden,
+		},
+		{
+			name:       "access_token",
+			middleware: RecentAuthMiddleware,
+			token:      &AccessToken{UserID: "user", Scopes: []string{revealScope}},
+			expCode:    http.StatusForbidden,
+		},
+		{
+			name: "reveal_stale_session",
+			middleware: func(sm IRecentAuthSessionManager) func(http.Handler) http.Handler {
+				return RevealMiddleware(sm, revealScope)
+			},
+			expCode: http.StatusForbidden,
+		},
+		{
+			name: "reveal_token_without_scope",
+			middleware: func(sm IRecentAuthSessionManager) func(http.Handler) http.Handler {
+				return RevealMiddleware(sm, revealScope)
+			},
+			token:   &AccessToken{UserID: "user", Scopes: []string{"accounts:read"}},
+			expCode: http.StatusForbidden,
+		},
+		{
+			name: "reveal_token_with_scope",
+			middleware: func(sm IRecentAuthSessionManager) func(http.Handler) http.Handler {
+				return RevealMiddleware(sm, revealScope)
+			},
+			token:   &AccessToken{UserID: "user", Scopes: []string{"accounts:read", revealScope}},
+			expCode: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := test.middleware(recentAuthSessionManager(test.recent))(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/accounts/github", nil)
+			if test.token != nil {
+				req = req.WithContext(WithAccessToken(req.Context(), *test.token))
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if got, want := rec.Code, test.expCode; got != want {
+				t.Errorf("Wrong! Unexpected status code!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"passman/internal/server/organizations"
+	"passman/internal/server/organizations/adapters/db/queries"
+
+	"github.com/google/uuid"
+)
+
+type txKey struct{}
+
+type Adapter struct {
+	db      *sql.DB
+	storage *queries.Queries
+}
+
+func New(db *sql.DB) *Adapter {
+	return &Adapter{db: db, storage: queries.New(db)}
+}
+
+// WithinTx runs fn in one transaction. Every adapter call made with txCtx
+// is executed inside of it.
+func (a *Adapter) WithinTx(ctx context.Context, fn func(txCtx context.Context) error) (err error) {
+	sqlTx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed starting transaction: %w", err)
+	}
+	defer func() {
+		rollbackErr := sqlTx.Rollback()
+		if rollbackErr != nil && !errors.Is(rollbackErr, sql.ErrTxDone) {
+			err = errors.Join(err, rollbackErr)
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, a.storage.WithTx(sqlTx))); err != nil {
+		return err
+	}
+
+	return sqlTx.Commit()
+}
+
+func (a *Adapter) queries(ctx context.Context) *queries.Queries {
+	if tx, ok := ctx.Value(txKey{}).(*queries.Queries); ok {
+		return tx
+	}
+	return a.storage
+}
+
+func (a *Adapter) AddOrganization(ctx context.Context, org organizations.Organization) error {
+	return a.queries(ctx).AddOrganization(ctx, queries.AddOrganizationParams{ID: org.ID, Name: org.Name})
+}
+
+func (a *Adapter) GetOrganizationID(ctx context.Context, name string) (uuid.UUID, error) {
+	return a.queries(ctx).GetOrganizationID(ctx, name)
+}
+
+func (a *Adapter) GetUserOrganizations(ctx context.Context, userID uuid.UUID) ([]organizations.Membership, error) {
+	rows, err := a.queries(ctx).GetUserOrganizations(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]organizations.Membership, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, organizations.Membership{Organization: row.Name, Role: organizations.Role(row.Role)})
+	}
+	return res, nil
+}
+
+func (a *Adapter) RemoveOrganization(ctx context.Context, orgID uuid.UUID) error {
+	return a.queries(ctx).RemoveOrganization(ctx, orgID)
+}
+
+func (a *Adapter) AddMember(ctx context.Context, orgID uuid.UUID, member organizations.Member) error {
+	params := queries.AddMemberParams{
+		OrganizationID: orgID,
+		UserID:         member.UserID,
+		Role:           string(member.Role),
+	}
+	return a.queries(ctx).AddMember(ctx, params)
+}
+
+func (a *Adapter) GetMemberRole(ctx context.Context, orgID, userID uuid.UUID) (organizations.Role, error) {
+	role, err := a.queries(ctx).GetMemberRole(ctx, queries.GetMemberRoleParams{OrganizationID: orgID, UserID: userID})
+	return organizations.Role(role), err
+}
+
+func (a *Adapter) GetMembers(ctx context.Context, orgID uuid.UUID) ([]organizations.Member, error) {
+	rows, err := a.queries(ctx).GetMembers(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]organizations.Member, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, organizations.Member{UserID: row.UserID, Username: row.Username, Role: organizations.Role(row.Role)})
+	}
+	return res, nil
+}
+
+func (a *Adapter) CountOwners(ctx context.Context, orgID uuid.UUID) (int64, error) {
+	return a.queries(ctx).CountOwners(ctx, orgID)
+}
+
+func (a *Adapter) UpdateMemberRole(ctx context.Context, orgID, userID uuid.UUID, role organizations.Role) error {
+	params := queries.UpdateMemberRoleParams{
+		OrganizationID: orgID,
+		UserID:         userID,
+		Role:           string(role),
+	}
+	return a.queries(ctx).UpdateMemberRole(ctx, params)
+}
+
+func (a *Adapter) RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error {
+	return a.queries(ctx).RemoveMember(ctx, queries.RemoveMemberParams{OrganizationID: orgID, UserID: userID})
+}
+
+func (a *Adapter) GetUserID(ctx context.Context, username string) (uuid.UUID, error) {
+	return a.queries(ctx).GetUserID(ctx, username)
+}
+
+func (a *Adapter) AddInvite(ctx context.Context, invite organizations.Invite) error {
+	params := queries.AddInviteParams{
+		OrganizationID: invite.OrganizationID,
+		UserID:         invite.UserID,
+		Role:           string(invite.Role),
+	}
+	return a.queries(ctx).AddInvite(ctx, params)
+}
+
+func (a *Adapter) GetInviteRole(ctx context.Context, orgID, userID uuid.UUID) (organizations.Role, error) {
+	role, err := a.queries(ctx).GetInviteRole(ctx, queries.GetInviteRoleParams{OrganizationID: orgID, UserID: userID})
+	return organizations.Role(role), err
+}
+
+func (a *Adapter) GetUserInvites(ctx context.Context, userID uuid.UUID) ([]organizations.Invite, error) {
+	rows, err := a.queries(ctx).GetUserInvites(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]organizations.Invite, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, organizations.Invite{
+			OrganizationID: row.ID,
+			Organization:   row.Name,
+			UserID:         userID,
+			Role:           organizations.Role(row.Role),
+		})
+	}
+	return res, nil
+}
+
+func (a *Adapter) RemoveInvite(ctx context.Context, orgID, userID uuid.UUID) error {
+	return a.queries(ctx).RemoveInvite(ctx, queries.RemoveInviteParams{OrganizationID: orgID, UserID: userID})
+}
+
+func (a *Adapter) AddCollection(ctx context.Context, collection organizations.Collection) error {
+	params := queries.AddCollectionParams{
+		ID:             collection.ID,
+		OrganizationID: collection.OrganizationID,
+		Name:           collection.Name,
+	}
+	return a.queries(ctx).AddCollection(ctx, params)
+}
+
+func (a *Adapter) GetCollectionID(ctx context.Context, orgID uuid.UUID, name string) (uuid.UUID, error) {
+	return a.queries(ctx).GetCollectionID(ctx, queries.GetCollectionIDParams{OrganizationID: orgID, Name: name})
+}
+
+func (a *Adapter) GetCollections(ctx context.Context, orgID uuid.UUID) ([]organizations.Collection, error) {
+	rows, err := a.queries(ctx).GetCollections(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]organizations.Collection, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, organizations.Collection{ID: row.ID, OrganizationID: orgID, Name: row.Name})
+	}
+	return res, nil
+}
+
+func (a *Adapter) RemoveCollection(ctx context.Context, collectionID uuid.UUID) error {
+	return a.queries(ctx).RemoveCollection(ctx, collectionID)
+}
+
+func (a *Adapter) IsEmptyRows(err error) bool {
+	return errors.Is(err, sql.ErrNoRows)
+}
@@ -0,0 +1,370 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"passman/internal/server/infra"
+	"passman/internal/server/organizations"
+
+	"github.com/go-chi/chi/v5"
+	vldtr "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type Adapter struct {
+	log *slog.Logger
+	ou  organizationsUsecase
+	sm  sessionManager
+	v   *validator
+}
+
+func NewRouter(ou organizationsUsecase, sm sessionManager, v *vldtr.Validate) chi.Router {
+	a := &Adapter{
+		log: slog.Default(),
+		ou:  ou,
+		sm:  sm,
+		v:   newValidator(v),
+	}
+
+	router := chi.NewRouter()
+
+	router.Use(infra.AuthMiddleware(sm))
+
+	router.Get("/my", a.GetUserOrganizations)
+	router.Get("/invites", a.GetUserInvites)
+	router.Post("/invites/{orgName}", a.AcceptInvite)
+	router.Delete("/invites/{orgName}", a.DeclineInvite)
+	router.Post("/{orgName}", a.CreateOrganization)
+	router.Delete("/{orgName}", a.RemoveOrganization)
+	router.Get("/{orgName}/members", a.GetMembers)
+	router.Post("/{orgName}/members", a.InviteMember)
+	router.Put("/{orgName}/members/{username}", a.UpdateMemberRole)
+	router.Delete("/{orgName}/members/{username}", a.RemoveMember)
+	router.Get("/{orgName}/collections", a.GetCollections)
+	router.Post("/{orgName}/collections/{collectionName}", a.CreateCollection)
+	router.Delete("/{orgName}/collections/{collectionName}", a.RemoveCollection)
+
+	return router
+}
+
+func (a *Adapter) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	orgName := chi.URLParam(r, "orgName")
+	if err := a.v.ValidateNames(orgName); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := a.ou.CreateOrganization(r.Context(), userID, orgName); err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "CreateOrganization", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *Adapter) GetUserOrganizations(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	memberships, err := a.ou.GetUserOrganizations(r.Context(), userID)
+	if err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "GetUserOrganizations", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	type responseType struct {
+		Name string             `json:"name"`
+		Role organizations.Role `json:"role"`
+	}
+
+	res := make([]responseType, 0, len(memberships))
+	for _, m := range memberships {
+		res = append(res, responseType{Name: m.Organization, Role: m.Role})
+	}
+
+	infra.ResponseJSON(w, res, http.StatusOK)
+}
+
+func (a *Adapter) RemoveOrganization(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	orgName := chi.URLParam(r, "orgName")
+	if err := a.v.ValidateNames(orgName); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := a.ou.RemoveOrganization(r.Context(), userID, orgName); err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "RemoveOrganization", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *Adapter) InviteMember(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	orgName := chi.URLParam(r, "orgName")
+	if err := a.v.ValidateNames(orgName); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	body := struct {
+		Username string             `json:"username"`
+		Role     organizations.Role `json:"role"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		a.log.ErrorContext(r.Context(), "InviteMember: failed parsing body", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	if err := a.v.ValidateMember(body.Username, body.Role); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := a.ou.InviteMember(r.Context(), userID, orgName, body.Username, body.Role); err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "InviteMember", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *Adapter) GetUserInvites(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	invites, err := a.ou.GetUserInvites(r.Context(), userID)
+	if err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "GetUserInvites", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	type responseType struct {
+		Organization string             `json:"organization"`
+		Role         organizations.Role `json:"role"`
+	}
+
+	res := make([]responseType, 0, len(invites))
+	for _, invite := range invites {
+		res = append(res, responseType{Organization: invite.Organization, Role: invite.Role})
+	}
+
+	infra.ResponseJSON(w, res, http.StatusOK)
+}
+
+func (a *Adapter) AcceptInvite(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	orgName := chi.URLParam(r, "orgName")
+	if err := a.v.ValidateNames(orgName); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := a.ou.AcceptInvite(r.Context(), userID, orgName); err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "AcceptInvite", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *Adapter) DeclineInvite(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	orgName := chi.URLParam(r, "orgName")
+	if err := a.v.ValidateNames(orgName); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := a.ou.DeclineInvite(r.Context(), userID, orgName); err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "DeclineInvite", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *Adapter) GetMembers(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	orgName := chi.URLParam(r, "orgName")
+	if err := a.v.ValidateNames(orgName); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	members, err := a.ou.GetMembers(r.Context(), userID, orgName)
+	if err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "GetMembers", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	type responseType struct {
+		Username string             `json:"username"`
+		Role     organizations.Role `json:"role"`
+	}
+
+	res := make([]responseType, 0, len(members))
+	for _, member := range members {
+		res = append(res, responseType{Username: member.Username, Role: member.Role})
+	}
+
+	infra.ResponseJSON(w, res, http.StatusOK)
+}
+
+func (a *Adapter) UpdateMemberRole(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	orgName := chi.URLParam(r, "orgName")
+	if err := a.v.ValidateNames(orgName); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	body := struct {
+		Role organizations.Role `json:"role"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		a.log.ErrorContext(r.Context(), "UpdateMemberRole: failed parsing body", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	username := chi.URLParam(r, "username")
+	if err := a.v.ValidateMember(username, body.Role); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := a.ou.UpdateMemberRole(r.Context(), userID, orgName, username, body.Role); err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "UpdateMemberRole", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *Adapter) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	orgName := chi.URLParam(r, "orgName")
+	if err := a.v.ValidateNames(orgName); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	username := chi.URLParam(r, "username")
+	if err := a.v.ValidateMember(username, organizations.RoleMember); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := a.ou.RemoveMember(r.Context(), userID, orgName, username); err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "RemoveMember", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *Adapter) CreateCollection(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	orgName, collectionName := chi.URLParam(r, "orgName"), chi.URLParam(r, "collectionName")
+	if err := a.v.ValidateNames(orgName, collectionName); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := a.ou.CreateCollection(r.Context(), userID, orgName, collectionName); err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "CreateCollection", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *Adapter) GetCollections(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	orgName := chi.URLParam(r, "orgName")
+	if err := a.v.ValidateNames(orgName); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	collections, err := a.ou.GetCollections(r.Context(), userID, orgName)
+	if err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "GetCollections", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	type responseType struct {
+		Name string `json:"name"`
+	}
+
+	res := make([]responseType, 0, len(collections))
+	for _, collection := range collections {
+		res = append(res, responseType{Name: collection.Name})
+	}
+
+	infra.ResponseJSON(w, res, http.StatusOK)
+}
+
+func (a *Adapter) RemoveCollection(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	orgName, collectionName := chi.URLParam(r, "orgName"), chi.URLParam(r, "collectionName")
+	if err := a.v.ValidateNames(orgName, collectionName); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := a.ou.RemoveCollection(r.Context(), userID, orgName, collectionName); err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "RemoveCollection", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *Adapter) parseUsecaseError(ctx context.Context, component string, usecaseError error) (int, string) {
+	code, msg, err := a.ou.ParseMyError(usecaseError)
+	if code == 0 {
+		a.log.ErrorContext(ctx, fmt.Sprintf("%s: wrong type of usecase error", component), slog.Any("error", err))
+		return http.StatusInternalServerError, "internal error"
+	}
+
+	if code >= 500 {
+		a.log.ErrorContext(ctx, msg, slog.Any("error", err))
+		return code, "internal error"
+	}
+
+	a.log.WarnContext(ctx, msg)
+	return code, strings.SplitN(msg, ": ", 2)[1]
+}
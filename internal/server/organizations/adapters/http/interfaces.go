@@ -0,0 +1,32 @@
+package http
+
+import (
+	"context"
+
+	"passman/internal/server/organizations"
+
+	"github.com/google/uuid"
+)
+
+type organizationsUsecase interface {
+	CreateOrganization(context.Context, uuid.UUID, string) error
+	GetUserOrganizations(context.Context, uuid.UUID) ([]organizations.Membership, error)
+	RemoveOrganization(context.Context, uuid.UUID, string) error
+	InviteMember(context.Context, uuid.UUID, string, string, organizations.Role) error
+	GetUserInvites(context.Context, uuid.UUID) ([]organizations.Invite, error)
+	AcceptInvite(context.Context, uuid.UUID, string) error
+	DeclineInvite(context.Context, uuid.UUID, string) error
+	GetMembers(context.Context, uuid.UUID, string) ([]organizations.Member, error)
+	UpdateMemberRole(context.Context, uuid.UUID, string, string, organizations.Role) error
+	RemoveMember(context.Context, uuid.UUID, string, string) error
+	CreateCollection(context.Context, uuid.UUID, string, string) error
+	GetCollections(context.Context, uuid.UUID, string) ([]organizations.Collection, error)
+	RemoveCollection(context.Context, uuid.UUID, string, string) error
+	ParseMyError(error) (int, string, error)
+}
+
+type sessionManager interface {
+	GetString(context.Context, string) string
+	Put(context.Context, string, any)
+	Keys(context.Context) []string
+}
@@ -0,0 +1,47 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+
+	"passman/internal/server/organizations"
+
+	vldtr "github.com/go-playground/validator/v10"
+)
+
+type validator struct {
+	v *vldtr.Validate
+}
+
+func newValidator(v *vldtr.Validate) *validator {
+	return &validator{v: v}
+}
+
+func (v *validator) ValidateNames(names ...string) error {
+	var errs []error
+
+	validatingStruct := struct {
+		Name string `validate:"required,max=64,excludesall=~!@#$%^&*?<>/"`
+	}{}
+
+	for _, name := range names {
+		validatingStruct.Name = name
+		if err := v.v.Struct(validatingStruct); err != nil {
+			errs = append(errs, fmt.Errorf("%s is invalid", name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (v *validator) ValidateMember(username string, role organizations.Role) error {
+	validatingStruct := struct {
+		Username string `validate:"required,alphanum,max=15"`
+	}{Username: username}
+
+	if err := v.v.Struct(validatingStruct); err != nil || !role.IsValid() {
+		return fmt.Errorf("invalid member parameters")
+	}
+
+	return nil
+}
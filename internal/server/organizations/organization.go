@@ -0,0 +1,63 @@
+package organizations
+
+import "github.com/google/uuid"
+
+type Role string
+
+const (
+	RoleOwner    Role = "owner"
+	RoleAdmin    Role = "admin"
+	RoleMember   Role = "member"
+	RoleReadOnly Role = "readonly"
+)
+
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleOwner, RoleAdmin, RoleMember, RoleReadOnly:
+		return true
+	default:
+		return false
+	}
+}
+
+// CanManage reports whether the role can manage members and collections
+func (r Role) CanManage() bool {
+	return r == RoleOwner || r == RoleAdmin
+}
+
+// CanWrite reports whether the role can change accounts of collections
+func (r Role) CanWrite() bool {
+	return r == RoleOwner || r == RoleAdmin || r == RoleMember
+}
+
+type Organization struct {
+	ID   uuid.UUID
+	Name string
+}
+
+// Membership is the organization of the user with the role of the user in it
+type Membership struct {
+	Organization string
+	Role         Role
+}
+
+type Member struct {
+	UserID   uuid.UUID
+	Username string
+	Role     Role
+}
+
+type Invite struct {
+	OrganizationID uuid.UUID
+	Organization   string
+	UserID         uuid.UUID
+	Role           Role
+}
+
+// Collection is a vault of the organization. Its accounts belong to the
+// organization and are available to members according to their roles.
+type Collection struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+	Name           string
+}
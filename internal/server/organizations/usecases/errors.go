@@ -0,0 +1,41 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+)
+
+type organizationsError struct {
+	Code      int
+	Component string
+	Msg       string
+	Err       error
+}
+
+func (ce *organizationsError) Error() string {
+	return fmt.Sprintf("%s: %s", ce.Component, ce.Msg)
+}
+
+func (ce *organizationsError) Unwrap() error {
+	return ce.Err
+}
+
+func (ce *organizationsError) Is(target error) bool {
+	return ce.Error() == target.Error()
+}
+
+func newClientError(msg string) error {
+	return &organizationsError{Code: 400, Component: "ClientError", Msg: msg, Err: nil}
+}
+
+func newInternalError(component, msg string, err error) error {
+	return &organizationsError{Code: 500, Component: component, Msg: msg, Err: err}
+}
+
+func parseOrganizationsError(err error) (int, string, error) {
+	var ce *organizationsError
+	if errors.As(err, &ce) {
+		return ce.Code, ce.Error(), ce.Err
+	}
+	return 0, "", nil
+}
@@ -0,0 +1,34 @@
+package usecases
+
+import (
+	"context"
+
+	"passman/internal/server/organizations"
+
+	"github.com/google/uuid"
+)
+
+//go:generate mockgen -source=interfaces.go -destination=mock/repository.go
+type repository interface {
+	AddOrganization(ctx context.Context, org organizations.Organization) error
+	GetOrganizationID(ctx context.Context, name string) (uuid.UUID, error)
+	GetUserOrganizations(ctx context.Context, userID uuid.UUID) ([]organizations.Membership, error)
+	RemoveOrganization(ctx context.Context, orgID uuid.UUID) error
+	AddMember(ctx context.Context, orgID uuid.UUID, member organizations.Member) error
+	GetMemberRole(ctx context.Context, orgID, userID uuid.UUID) (organizations.Role, error)
+	GetMembers(ctx context.Context, orgID uuid.UUID) ([]organizations.Member, error)
+	CountOwners(ctx context.Context, orgID uuid.UUID) (int64, error)
+	UpdateMemberRole(ctx context.Context, orgID, userID uuid.UUID, role organizations.Role) error
+	RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error
+	GetUserID(ctx context.Context, username string) (uuid.UUID, error)
+	AddInvite(ctx context.Context, invite organizations.Invite) error
+	GetInviteRole(ctx context.Context, orgID, userID uuid.UUID) (organizations.Role, error)
+	GetUserInvites(ctx context.Context, userID uuid.UUID) ([]organizations.Invite, error)
+	RemoveInvite(ctx context.Context, orgID, userID uuid.UUID) error
+	AddCollection(ctx context.Context, collection organizations.Collection) error
+	GetCollectionID(ctx context.Context, orgID uuid.UUID, name string) (uuid.UUID, error)
+	GetCollections(ctx context.Context, orgID uuid.UUID) ([]organizations.Collection, error)
+	RemoveCollection(ctx context.Context, collectionID uuid.UUID) error
+	WithinTx(ctx context.Context, fn func(txCtx context.Context) error) error
+	IsEmptyRows(err error) bool
+}
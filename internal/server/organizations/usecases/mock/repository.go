@@ -0,0 +1,347 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interfaces.go
+//
+// Generated by this command:
+//
+//	mockgen -source=interfaces.go -destination=mock/repository.go
+//
+
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	context "context"
+	organizations "passman/internal/server/organizations"
+	reflect "reflect"
+
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// Mockrepository is a mock of repository interface.
+type Mockrepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockrepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockrepositoryMockRecorder is the mock recorder for Mockrepository.
+type MockrepositoryMockRecorder struct {
+	mock *Mockrepository
+}
+
+// NewMockrepository creates a new mock instance.
+func NewMockrepository(ctrl *gomock.Controller) *Mockrepository {
+	mock := &Mockrepository{ctrl: ctrl}
+	mock.recorder = &MockrepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockrepository) EXPECT() *MockrepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddCollection mocks base method.
+func (m *Mockrepository) AddCollection(ctx context.Context, collection organizations.Collection) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddCollection", ctx, collection)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddCollection indicates an expected call of AddCollection.
+func (mr *MockrepositoryMockRecorder) AddCollection(ctx, collection any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCollection", reflect.TypeOf((*Mockrepository)(nil).AddCollection), ctx, collection)
+}
+
+// AddInvite mocks base method.
+func (m *Mockrepository) AddInvite(ctx context.Context, invite organizations.Invite) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddInvite", ctx, invite)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddInvite indicates an expected call of AddInvite.
+func (mr *MockrepositoryMockRecorder) AddInvite(ctx, invite any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddInvite", reflect.TypeOf((*Mockrepository)(nil).AddInvite), ctx, invite)
+}
+
+// AddMember mocks base method.
+func (m *Mockrepository) AddMember(ctx context.Context, orgID uuid.UUID, member organizations.Member) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddMember", ctx, orgID, member)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddMember indicates an expected call of AddMember.
+func (mr *MockrepositoryMockRecorder) AddMember(ctx, orgID, member any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddMember", reflect.TypeOf((*Mockrepository)(nil).AddMember), ctx, orgID, member)
+}
+
+// AddOrganization mocks base method.
+func (m *Mockrepository) AddOrganization(ctx context.Context, org organizations.Organization) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddOrganization", ctx, org)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddOrganization indicates an expected call of AddOrganization.
+func (mr *MockrepositoryMockRecorder) AddOrganization(ctx, org any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddOrganization", reflect.TypeOf((*Mockrepository)(nil).AddOrganization), ctx, org)
+}
+
+// CountOwners mocks base method.
+func (m *Mockrepository) CountOwners(ctx context.Context, orgID uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountOwners", ctx, orgID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountOwners indicates an expected call of CountOwners.
+func (mr *MockrepositoryMockRecorder) CountOwners(ctx, orgID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountOwners", reflect.TypeOf((*Mockrepository)(nil).CountOwners), ctx, orgID)
+}
+
+// GetCollectionID mocks base method.
+func (m *Mockrepository) GetCollectionID(ctx context.Context, orgID uuid.UUID, name string) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCollectionID", ctx, orgID, name)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCollectionID indicates an expected call of GetCollectionID.
+func (mr *MockrepositoryMockRecorder) GetCollectionID(ctx, orgID, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCollectionID", reflect.TypeOf((*Mockrepository)(nil).GetCollectionID), ctx, orgID, name)
+}
+
+// GetCollections mocks base method.
+func (m *Mockrepository) GetCollections(ctx context.Context, orgID uuid.UUID) ([]organizations.Collection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCollections", ctx, orgID)
+	ret0, _ := ret[0].([]organizations.Collection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCollections indicates an expected call of GetCollections.
+func (mr *MockrepositoryMockRecorder) GetCollections(ctx, orgID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCollections", reflect.TypeOf((*Mockrepository)(nil).GetCollections), ctx, orgID)
+}
+
+// GetInviteRole mocks base method.
+func (m *Mockrepository) GetInviteRole(ctx context.Context, orgID, userID uuid.UUID) (organizations.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInviteRole", ctx, orgID, userID)
+	ret0, _ := ret[0].(organizations.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInviteRole indicates an expected call of GetInviteRole.
+func (mr *MockrepositoryMockRecorder) GetInviteRole(ctx, orgID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInviteRole", reflect.TypeOf((*Mockrepository)(nil).GetInviteRole), ctx, orgID, userID)
+}
+
+// GetMemberRole mocks base method.
+func (m *Mockrepository) GetMemberRole(ctx context.Context, orgID, userID uuid.UUID) (organizations.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMemberRole", ctx, orgID, userID)
+	ret0, _ := ret[0].(organizations.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMemberRole indicates an expected call of GetMemberRole.
+func (mr *MockrepositoryMockRecorder) GetMemberRole(ctx, orgID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMemberRole", reflect.TypeOf((*Mockrepository)(nil).GetMemberRole), ctx, orgID, userID)
+}
+
+// GetMembers mocks base method.
+func (m *Mockrepository) GetMembers(ctx context.Context, orgID uuid.UUID) ([]organizations.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMembers", ctx, orgID)
+	ret0, _ := ret[0].([]organizations.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMembers indicates an expected call of GetMembers.
+func (mr *MockrepositoryMockRecorder) GetMembers(ctx, orgID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMembers", reflect.TypeOf((*Mockrepository)(nil).GetMembers), ctx, orgID)
+}
+
+// GetOrganizationID mocks base method.
+func (m *Mockrepository) GetOrganizationID(ctx context.Context, name string) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrganizationID", ctx, name)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrganizationID indicates an expected call of GetOrganizationID.
+func (mr *MockrepositoryMockRecorder) GetOrganizationID(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrganizationID", reflect.TypeOf((*Mockrepository)(nil).GetOrganizationID), ctx, name)
+}
+
+// GetUserID mocks base method.
+func (m *Mockrepository) GetUserID(ctx context.Context, username string) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserID", ctx, username)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserID indicates an expected call of GetUserID.
+func (mr *MockrepositoryMockRecorder) GetUserID(ctx, username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserID", reflect.TypeOf((*Mockrepository)(nil).GetUserID), ctx, username)
+}
+
+// GetUserInvites mocks base method.
+func (m *Mockrepository) GetUserInvites(ctx context.Context, userID uuid.UUID) ([]organizations.Invite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserInvites", ctx, userID)
+	ret0, _ := ret[0].([]organizations.Invite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserInvites indicates an expected call of GetUserInvites.
+func (mr *MockrepositoryMockRecorder) GetUserInvites(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserInvites", reflect.TypeOf((*Mockrepository)(nil).GetUserInvites), ctx, userID)
+}
+
+// GetUserOrganizations mocks base method.
+func (m *Mockrepository) GetUserOrganizations(ctx context.Context, userID uuid.UUID) ([]organizations.Membership, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserOrganizations", ctx, userID)
+	ret0, _ := ret[0].([]organizations.Membership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserOrganizations indicates an expected call of GetUserOrganizations.
+func (mr *MockrepositoryMockRecorder) GetUserOrganizations(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserOrganizations", reflect.TypeOf((*Mockrepository)(nil).GetUserOrganizations), ctx, userID)
+}
+
+// IsEmptyRows mocks base method.
+func (m *Mockrepository) IsEmptyRows(err error) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsEmptyRows", err)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsEmptyRows indicates an expected call of IsEmptyRows.
+func (mr *MockrepositoryMockRecorder) IsEmptyRows(err any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsEmptyRows", reflect.TypeOf((*Mockrepository)(nil).IsEmptyRows), err)
+}
+
+// RemoveCollection mocks base method.
+func (m *Mockrepository) RemoveCollection(ctx context.Context, collectionID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveCollection", ctx, collectionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveCollection indicates an expected call of RemoveCollection.
+func (mr *MockrepositoryMockRecorder) RemoveCollection(ctx, collectionID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveCollection", reflect.TypeOf((*Mockrepository)(nil).RemoveCollection), ctx, collectionID)
+}
+
+// RemoveInvite mocks base method.
+func (m *Mockrepository) RemoveInvite(ctx context.Context, orgID, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveInvite", ctx, orgID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveInvite indicates an expected call of RemoveInvite.
+func (mr *MockrepositoryMockRecorder) RemoveInvite(ctx, orgID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveInvite", reflect.TypeOf((*Mockrepository)(nil).RemoveInvite), ctx, orgID, userID)
+}
+
+// RemoveMember mocks base method.
+func (m *Mockrepository) RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveMember", ctx, orgID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveMember indicates an expected call of RemoveMember.
+func (mr *MockrepositoryMockRecorder) RemoveMember(ctx, orgID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveMember", reflect.TypeOf((*Mockrepository)(nil).RemoveMember), ctx, orgID, userID)
+}
+
+// RemoveOrganization mocks base method.
+func (m *Mockrepository) RemoveOrganization(ctx context.Context, orgID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveOrganization", ctx, orgID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveOrganization indicates an expected call of RemoveOrganization.
+func (mr *MockrepositoryMockRecorder) RemoveOrganization(ctx, orgID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveOrganization", reflect.TypeOf((*Mockrepository)(nil).RemoveOrganization), ctx, orgID)
+}
+
+// UpdateMemberRole mocks base method.
+func (m *Mockrepository) UpdateMemberRole(ctx context.Context, orgID, userID uuid.UUID, role organizations.Role) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMemberRole", ctx, orgID, userID, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateMemberRole indicates an expected call of UpdateMemberRole.
+func (mr *MockrepositoryMockRecorder) UpdateMemberRole(ctx, orgID, userID, role any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMemberRole", reflect.TypeOf((*Mockrepository)(nil).UpdateMemberRole), ctx, orgID, userID, role)
+}
+
+// WithinTx mocks base method.
+func (m *Mockrepository) WithinTx(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithinTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithinTx indicates an expected call of WithinTx.
+func (mr *MockrepositoryMockRecorder) WithinTx(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithinTx", reflect.TypeOf((*Mockrepository)(nil).WithinTx), ctx, fn)
+}
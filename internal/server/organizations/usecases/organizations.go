@@ -0,0 +1,348 @@
+package usecases
+
+import (
+	"context"
+
+	"passman/internal/server/organizations"
+
+	"github.com/google/uuid"
+)
+
+var errNotEnoughRights = newClientError("not enough rights")
+
+type organizationsUsecase struct {
+	repo repository
+}
+
+func New(repo repository) *organizationsUsecase {
+	return &organizationsUsecase{repo: repo}
+}
+
+// CreateOrganization creates the organization with the user as its owner
+func (ou *organizationsUsecase) CreateOrganization(ctx context.Context, userID uuid.UUID, name string) error {
+	if dublicateID, err := ou.repo.GetOrganizationID(ctx, name); err != nil && !ou.repo.IsEmptyRows(err) {
+		return newInternalError("CreateOrganization", "failed checking dublicates", err)
+	} else if dublicateID != uuid.Nil {
+		return newClientError("organization with this name already exist")
+	}
+
+	org := organizations.Organization{ID: uuid.New(), Name: name}
+	err := ou.repo.WithinTx(ctx, func(txCtx context.Context) error {
+		if err := ou.repo.AddOrganization(txCtx, org); err != nil {
+			return err
+		}
+		return ou.repo.AddMember(txCtx, org.ID, organizations.Member{UserID: userID, Role: organizations.RoleOwner})
+	})
+	if err != nil {
+		return newInternalError("CreateOrganization", "failed adding organization", err)
+	}
+
+	return nil
+}
+
+func (ou *organizationsUsecase) GetUserOrganizations(ctx context.Context, userID uuid.UUID) ([]organizations.Membership, error) {
+	memberships, err := ou.repo.GetUserOrganizations(ctx, userID)
+	if err != nil {
+		return nil, newInternalError("GetUserOrganizations", "failed getting organizations", err)
+	}
+	return memberships, nil
+}
+
+// RemoveOrganization removes the organization with its collections and their accounts
+func (ou *organizationsUsecase) RemoveOrganization(ctx context.Context, userID uuid.UUID, name string) error {
+	orgID, role, err := ou.membership(ctx, "RemoveOrganization", userID, name)
+	if err != nil {
+		return err
+	}
+	if role != organizations.RoleOwner {
+		return errNotEnoughRights
+	}
+
+	if err := ou.repo.RemoveOrganization(ctx, orgID); err != nil {
+		return newInternalError("RemoveOrganization", "failed removing organization", err)
+	}
+
+	return nil
+}
+
+// InviteMember invites the user to the organization. The user becomes a member
+// after accepting the invite.
+func (ou *organizationsUsecase) InviteMember(ctx context.Context, userID uuid.UUID, orgName, username string, role organizations.Role) error {
+	orgID, actorRole, err := ou.membership(ctx, "InviteMember", userID, orgName)
+	if err != nil {
+		return err
+	}
+	if !actorRole.CanManage() || (role == organizations.RoleOwner && actorRole != organizations.RoleOwner) {
+		return errNotEnoughRights
+	}
+
+	inviteeID, err := ou.userID(ctx, "InviteMember", username)
+	if err != nil {
+		return err
+	}
+
+	if _, err := ou.repo.GetMemberRole(ctx, orgID, inviteeID); err == nil {
+		return newClientError("user is already a member")
+	} else if !ou.repo.IsEmptyRows(err) {
+		return newInternalError("InviteMember", "failed checking membership", err)
+	}
+
+	if err := ou.repo.AddInvite(ctx, organizations.Invite{OrganizationID: orgID, UserID: inviteeID, Role: role}); err != nil {
+		return newInternalError("InviteMember", "failed adding invite", err)
+	}
+
+	return nil
+}
+
+func (ou *organizationsUsecase) GetUserInvites(ctx context.Context, userID uuid.UUID) ([]organizations.Invite, error) {
+	invites, err := ou.repo.GetUserInvites(ctx, userID)
+	if err != nil {
+		return nil, newInternalError("GetUserInvites", "failed getting invites", err)
+	}
+	return invites, nil
+}
+
+func (ou *organizationsUsecase) AcceptInvite(ctx context.Context, userID uuid.UUID, orgName string) error {
+	orgID, err := ou.repo.GetOrganizationID(ctx, orgName)
+	if err != nil {
+		if ou.repo.IsEmptyRows(err) {
+			return newClientError("invite not found")
+		}
+		return newInternalError("AcceptInvite", "failed getting organization id", err)
+	}
+
+	role, err := ou.repo.GetInviteRole(ctx, orgID, userID)
+	if err != nil {
+		if ou.repo.IsEmptyRows(err) {
+			return newClientError("invite not found")
+		}
+		return newInternalError("AcceptInvite", "failed getting invite", err)
+	}
+
+	err = ou.repo.WithinTx(ctx, func(txCtx context.Context) error {
+		if err := ou.repo.AddMember(txCtx, orgID, organizations.Member{UserID: userID, Role: role}); err != nil {
+			return err
+		}
+		return ou.repo.RemoveInvite(txCtx, orgID, userID)
+	})
+	if err != nil {
+		return newInternalError("AcceptInvite", "failed adding member", err)
+	}
+
+	return nil
+}
+
+func (ou *organizationsUsecase) DeclineInvite(ctx context.Context, userID uuid.UUID, orgName string) error {
+	orgID, err := ou.repo.GetOrganizationID(ctx, orgName)
+	if err != nil {
+		if ou.repo.IsEmptyRows(err) {
+			return newClientError("invite not found")
+		}
+		return newInternalError("DeclineInvite", "failed getting organization id", err)
+	}
+
+	if err := ou.repo.RemoveInvite(ctx, orgID, userID); err != nil {
+		return newInternalError("DeclineInvite", "failed removing invite", err)
+	}
+
+	return nil
+}
+
+func (ou *organizationsUsecase) GetMembers(ctx context.Context, userID uuid.UUID, orgName string) ([]organizations.Member, error) {
+	orgID, _, err := ou.membership(ctx, "GetMembers", userID, orgName)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := ou.repo.GetMembers(ctx, orgID)
+	if err != nil {
+		return nil, newInternalError("GetMembers", "failed getting members", err)
+	}
+
+	return members, nil
+}
+
+// UpdateMemberRole changes the role of the member. Only owners grant and
+// revoke the owner role and the last owner can't be demoted.
+func (ou *organizationsUsecase) UpdateMemberRole(ctx context.Context, userID uuid.UUID, orgName, username string, role organizations.Role) error {
+	orgID, actorRole, err := ou.membership(ctx, "UpdateMemberRole", userID, orgName)
+	if err != nil {
+		return err
+	}
+	if !actorRole.CanManage() {
+		return errNotEnoughRights
+	}
+
+	memberID, memberRole, err := ou.member(ctx, "UpdateMemberRole", orgID, username)
+	if err != nil {
+		return err
+	}
+	if (role == organizations.RoleOwner || memberRole == organizations.RoleOwner) && actorRole != organizations.RoleOwner {
+		return errNotEnoughRights
+	}
+	if memberRole == organizations.RoleOwner && role != organizations.RoleOwner {
+		if err := ou.checkOtherOwners(ctx, "UpdateMemberRole", orgID); err != nil {
+			return err
+		}
+	}
+
+	if err := ou.repo.UpdateMemberRole(ctx, orgID, memberID, role); err != nil {
+		return newInternalError("UpdateMemberRole", "failed updating role", err)
+	}
+
+	return nil
+}
+
+// RemoveMember removes the member from the organization. Every member can
+// leave the organization, managers remove other members.
+func (ou *organizationsUsecase) RemoveMember(ctx context.Context, userID uuid.UUID, orgName, username string) error {
+	orgID, actorRole, err := ou.membership(ctx, "RemoveMember", userID, orgName)
+	if err != nil {
+		return err
+	}
+
+	memberID, memberRole, err := ou.member(ctx, "RemoveMember", orgID, username)
+	if err != nil {
+		return err
+	}
+	if memberID != userID && (!actorRole.CanManage() || (memberRole == organizations.RoleOwner && actorRole != organizations.RoleOwner)) {
+		return errNotEnoughRights
+	}
+	if memberRole == organizations.RoleOwner {
+		if err := ou.checkOtherOwners(ctx, "RemoveMember", orgID); err != nil {
+			return err
+		}
+	}
+
+	if err := ou.repo.RemoveMember(ctx, orgID, memberID); err != nil {
+		return newInternalError("RemoveMember", "failed removing member", err)
+	}
+
+	return nil
+}
+
+func (ou *organizationsUsecase) CreateCollection(ctx context.Context, userID uuid.UUID, orgName, name string) error {
+	orgID, role, err := ou.membership(ctx, "CreateCollection", userID, orgName)
+	if err != nil {
+		return err
+	}
+	if !role.CanManage() {
+		return errNotEnoughRights
+	}
+
+	if dublicateID, err := ou.repo.GetCollectionID(ctx, orgID, name); err != nil && !ou.repo.IsEmptyRows(err) {
+		return newInternalError("CreateCollection", "failed checking dublicates", err)
+	} else if dublicateID != uuid.Nil {
+		return newClientError("collection with this name already exist")
+	}
+
+	if err := ou.repo.AddCollection(ctx, organizations.Collection{ID: uuid.New(), OrganizationID: orgID, Name: name}); err != nil {
+		return newInternalError("CreateCollection", "failed adding collection", err)
+	}
+
+	return nil
+}
+
+func (ou *organizationsUsecase) GetCollections(ctx context.Context, userID uuid.UUID, orgName string) ([]organizations.Collection, error) {
+	orgID, _, err := ou.membership(ctx, "GetCollections", userID, orgName)
+	if err != nil {
+		return nil, err
+	}
+
+	collections, err := ou.repo.GetCollections(ctx, orgID)
+	if err != nil {
+		return nil, newInternalError("GetCollections", "failed getting collections", err)
+	}
+
+	return collections, nil
+}
+
+// RemoveCollection removes the collection with its accounts
+func (ou *organizationsUsecase) RemoveCollection(ctx context.Context, userID uuid.UUID, orgName, name string) error {
+	orgID, role, err := ou.membership(ctx, "RemoveCollection", userID, orgName)
+	if err != nil {
+		return err
+	}
+	if !role.CanManage() {
+		return errNotEnoughRights
+	}
+
+	collectionID, err := ou.repo.GetCollectionID(ctx, orgID, name)
+	if err != nil {
+		if ou.repo.IsEmptyRows(err) {
+			return newClientError("collection not found")
+		}
+		return newInternalError("RemoveCollection", "failed getting collection id", err)
+	}
+
+	if err := ou.repo.RemoveCollection(ctx, collectionID); err != nil {
+		return newInternalError("RemoveCollection", "failed removing collection", err)
+	}
+
+	return nil
+}
+
+// membership returns the organization id and the role of the user in it.
+// Organizations of other users are reported as not found.
+func (ou *organizationsUsecase) membership(ctx context.Context, component string, userID uuid.UUID, orgName string) (uuid.UUID, organizations.Role, error) {
+	orgID, err := ou.repo.GetOrganizationID(ctx, orgName)
+	if err != nil {
+		if ou.repo.IsEmptyRows(err) {
+			return uuid.Nil, "", newClientError("organization not found")
+		}
+		return uuid.Nil, "", newInternalError(component, "failed getting organization id", err)
+	}
+
+	role, err := ou.repo.GetMemberRole(ctx, orgID, userID)
+	if err != nil {
+		if ou.repo.IsEmptyRows(err) {
+			return uuid.Nil, "", newClientError("organization not found")
+		}
+		return uuid.Nil, "", newInternalError(component, "failed getting member role", err)
+	}
+
+	return orgID, role, nil
+}
+
+func (ou *organizationsUsecase) member(ctx context.Context, component string, orgID uuid.UUID, username string) (uuid.UUID, organizations.Role, error) {
+	memberID, err := ou.userID(ctx, component, username)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	role, err := ou.repo.GetMemberRole(ctx, orgID, memberID)
+	if err != nil {
+		if ou.repo.IsEmptyRows(err) {
+			return uuid.Nil, "", newClientError("user is not a member")
+		}
+		return uuid.Nil, "", newInternalError(component, "failed getting member role", err)
+	}
+
+	return memberID, role, nil
+}
+
+func (ou *organizationsUsecase) userID(ctx context.Context, component, username string) (uuid.UUID, error) {
+	id, err := ou.repo.GetUserID(ctx, username)
+	if err != nil {
+		if ou.repo.IsEmptyRows(err) {
+			return uuid.Nil, newClientError("user not found")
+		}
+		return uuid.Nil, newInternalError(component, "failed getting user id", err)
+	}
+	return id, nil
+}
+
+func (ou *organizationsUsecase) checkOtherOwners(ctx context.Context, component string, orgID uuid.UUID) error {
+	owners, err := ou.repo.CountOwners(ctx, orgID)
+	if err != nil {
+		return newInternalError(component, "failed counting owners", err)
+	}
+	if owners < 2 {
+		return newClientError("organization must have an owner")
+	}
+	return nil
+}
+
+func (ou *organizationsUsecase) ParseMyError(err error) (int, string, error) {
+	return parseOrganizationsError(err)
+}
@@ -0,0 +1,306 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"passman/internal/server/organizations"
+	mock_usecases "passman/internal/server/organizations/usecases/mock"
+
+	"github.com/google/uuid"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateOrganization(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockrepository(ctrl)
+	orgUsecase := New(mockRepo)
+	ctx := context.Background()
+	errEmptyRows := errors.New("empty rows")
+	userID := uuid.New()
+
+	type getOrganizationIDResult struct {
+		id  uuid.UUID
+		err error
+	}
+
+	type addMemberResult struct {
+		err error
+	}
+
+	type txResult struct {
+		addOrganizationErr error
+		addMemberResult    *addMemberResult
+	}
+
+	tests := []struct {
+		name                    string
+		getOrganizationIDResult getOrganizationIDResult
+		txResult                *txResult
+		expResult               error
+	}{
+		{
+			name:                    "failed_checking_dublicates",
+			getOrganizationIDResult: getOrganizationIDResult{err: errors.New("internal error")},
+			expResult:               errors.New("CreateOrganization: failed checking dublicates"),
+		},
+		{
+			name:                    "dublicate_exist",
+			getOrganizationIDResult: getOrganizationIDResult{id: uuid.New()},
+			expResult:               errors.New("ClientError: organization with this name already exist"),
+		},
+		{
+			name:                    "failed_adding_organization",
+			getOrganizationIDResult: getOrganizationIDResult{err: errEmptyRows},
+			txResult:                &txResult{addOrganizationErr: errors.New("internal error")},
+			expResult:               errors.New("CreateOrganization: failed adding organization"),
+		},
+		{
+			name:                    "success",
+			getOrganizationIDResult: getOrganizationIDResult{err: errEmptyRows},
+			txResult:                &txResult{addMemberResult: &addMemberResult{}},
+			expResult:               nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().
+				GetOrganizationID(ctx, "acme").
+				Return(test.getOrganizationIDResult.id, test.getOrganizationIDResult.err).
+				Times(1)
+
+			if test.getOrganizationIDResult.err != nil {
+				mockRepo.EXPECT().
+					IsEmptyRows(test.getOrganizationIDResult.err).
+					Return(test.getOrganizationIDResult.err == errEmptyRows).
+					Times(1)
+			}
+
+			if test.txResult != nil {
+				mockRepo.EXPECT().
+					WithinTx(ctx, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+						return fn(ctx)
+					}).
+					Times(1)
+
+				mockRepo.EXPECT().
+					AddOrganization(ctx, gomock.AssignableToTypeOf(organizations.Organization{})).
+					Return(test.txResult.addOrganizationErr).
+					Times(1)
+
+				if test.txResult.addMemberResult != nil {
+					mockRepo.EXPECT().
+						AddMember(ctx, gomock.Any(), organizations.Member{UserID: userID, Role: organizations.RoleOwner}).
+						Return(test.txResult.addMemberResult.err).
+						Times(1)
+				}
+			}
+
+			actErr := orgUsecase.CreateOrganization(ctx, userID, "acme")
+
+			if got, want := actErr, test.expResult; !errors.Is(got, want) {
+				t.Errorf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestInviteMember(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockrepository(ctrl)
+	orgUsecase := New(mockRepo)
+	ctx := context.Background()
+	errEmptyRows := errors.New("empty rows")
+	userID, orgID, inviteeID := uuid.New(), uuid.New(), uuid.New()
+
+	type memberRoleResult struct {
+		role organizations.Role
+		err  error
+	}
+
+	type errResult struct {
+		err error
+	}
+
+	tests := []struct {
+		name              string
+		actorRole         memberRoleResult
+		role              organizations.Role
+		getUserIDResult   *errResult
+		inviteeRoleResult *memberRoleResult
+		addInviteResult   *errResult
+		expResult         error
+	}{
+		{
+			name:      "not_member",
+			actorRole: memberRoleResult{err: errEmptyRows},
+			role:      organizations.RoleMember,
+			expResult: errors.New("ClientError: organization not found"),
+		},
+		{
+			name:      "member_invites",
+			actorRole: memberRoleResult{role: organizations.RoleMember},
+			role:      organizations.RoleMember,
+			expResult: errors.New("ClientError: not enough rights"),
+		},
+		{
+			name:      "admin_invites_owner",
+			actorRole: memberRoleResult{role: organizations.RoleAdmin},
+			role:      organizations.RoleOwner,
+			expResult: errors.New("ClientError: not enough rights"),
+		},
+		{
+			name:            "user_not_found",
+			actorRole:       memberRoleResult{role: organizations.RoleAdmin},
+			role:            organizations.RoleMember,
+			getUserIDResult: &errResult{err: errEmptyRows},
+			expResult:       errors.New("ClientError: user not found"),
+		},
+		{
+			name:              "already_member",
+			actorRole:         memberRoleResult{role: organizations.RoleAdmin},
+			role:              organizations.RoleMember,
+			getUserIDResult:   &errResult{},
+			inviteeRoleResult: &memberRoleResult{role: organizations.RoleReadOnly},
+			expResult:         errors.New("ClientError: user is already a member"),
+		},
+		{
+			name:              "success",
+			actorRole:         memberRoleResult{role: organizations.RoleOwner},
+			role:              organizations.RoleOwner,
+			getUserIDResult:   &errResult{},
+			inviteeRoleResult: &memberRoleResult{err: errEmptyRows},
+			addInviteResult:   &errResult{},
+			expResult:         nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().GetOrganizationID(ctx, "acme").Return(orgID, nil).Times(1)
+			mockRepo.EXPECT().GetMemberRole(ctx, orgID, userID).Return(test.actorRole.role, test.actorRole.err).Times(1)
+			if test.actorRole.err != nil {
+				mockRepo.EXPECT().IsEmptyRows(test.actorRole.err).Return(true).Times(1)
+			}
+
+			if test.getUserIDResult != nil {
+				mockRepo.EXPECT().GetUserID(ctx, "bobby").Return(inviteeID, test.getUserIDResult.err).Times(1)
+				if test.getUserIDResult.err != nil {
+					mockRepo.EXPECT().IsEmptyRows(test.getUserIDResult.err).Return(true).Times(1)
+				}
+			}
+
+			if test.inviteeRoleResult != nil {
+				mockRepo.EXPECT().
+					GetMemberRole(ctx, orgID, inviteeID).
+					Return(test.inviteeRoleResult.role, test.inviteeRoleResult.err).
+					Times(1)
+				if test.inviteeRoleResult.err != nil {
+					mockRepo.EXPECT().IsEmptyRows(test.inviteeRoleResult.err).Return(true).Times(1)
+				}
+			}
+
+			if test.addInviteResult != nil {
+				mockRepo.EXPECT().
+					AddInvite(ctx, organizations.Invite{OrganizationID: orgID, UserID: inviteeID, Role: test.role}).
+					Return(test.addInviteResult.err).
+					Times(1)
+			}
+
+			actErr := orgUsecase.InviteMember(ctx, userID, "acme", "bobby", test.role)
+
+			if got, want := actErr, test.expResult; !errors.Is(got, want) {
+				t.Errorf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestRemoveMember(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockrepository(ctrl)
+	orgUsecase := New(mockRepo)
+	ctx := context.Background()
+	userID, orgID, memberID := uuid.New(), uuid.New(), uuid.New()
+	oneOwner, twoOwners := int64(1), int64(2)
+
+	tests := []struct {
+		name        string
+		actorRole   organizations.Role
+		memberID    uuid.UUID
+		memberRole  organizations.Role
+		owners      *int64
+		removeCalls int
+		expResult   error
+	}{
+		{
+			name:       "member_removes_other",
+			actorRole:  organizations.RoleMember,
+			memberID:   memberID,
+			memberRole: organizations.RoleReadOnly,
+			expResult:  errors.New("ClientError: not enough rights"),
+		},
+		{
+			name:       "admin_removes_owner",
+			actorRole:  organizations.RoleAdmin,
+			memberID:   memberID,
+			memberRole: organizations.RoleOwner,
+			expResult:  errors.New("ClientError: not enough rights"),
+		},
+		{
+			name:       "last_owner_leaves",
+			actorRole:  organizations.RoleOwner,
+			memberID:   userID,
+			memberRole: organizations.RoleOwner,
+			owners:     &oneOwner,
+			expResult:  errors.New("ClientError: organization must have an owner"),
+		},
+		{
+			name:        "readonly_leaves",
+			actorRole:   organizations.RoleReadOnly,
+			memberID:    userID,
+			memberRole:  organizations.RoleReadOnly,
+			removeCalls: 1,
+			expResult:   nil,
+		},
+		{
+			name:        "owner_removes_owner",
+			actorRole:   organizations.RoleOwner,
+			memberID:    memberID,
+			memberRole:  organizations.RoleOwner,
+			owners:      &twoOwners,
+			removeCalls: 1,
+			expResult:   nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().GetOrganizationID(ctx, "acme").Return(orgID, nil).Times(1)
+			mockRepo.EXPECT().GetMemberRole(ctx, orgID, userID).Return(test.actorRole, nil).Times(1)
+			mockRepo.EXPECT().GetUserID(ctx, "bobby").Return(test.memberID, nil).Times(1)
+			mockRepo.EXPECT().GetMemberRole(ctx, orgID, test.memberID).Return(test.memberRole, nil).Times(1)
+
+			if test.owners != nil {
+				mockRepo.EXPECT().CountOwners(ctx, orgID).Return(*test.owners, nil).Times(1)
+			}
+
+			mockRepo.EXPECT().RemoveMember(ctx, orgID, test.memberID).Return(nil).Times(test.removeCalls)
+
+			actErr := orgUsecase.RemoveMember(ctx, userID, "acme", "bobby")
+
+			if got, want := actErr, test.expResult; !errors.Is(got, want) {
+				t.Errorf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
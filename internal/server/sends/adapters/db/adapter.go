@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"passman/internal/server/sends"
+	"passman/internal/server/sends/adapters/db/queries"
+
+	"github.com/google/uuid"
+)
+
+type Adapter struct {
+	storage *queries.Queries
+}
+
+func New(db *sql.DB) *Adapter {
+	return &Adapter{storage: queries.New(db)}
+}
+
+func (a *Adapter) AddSend(ctx context.Context, send sends.Send) error {
+	params := queries.AddSendParams{
+		ID:        send.ID,
+		UserID:    send.UserID,
+		Type:      string(send.Type),
+		FileName:  send.FileName,
+		Nonce:     send.Nonce,
+		Data:      send.Data,
+		Password:  send.Password,
+		MaxViews:  send.MaxViews,
+		ExpiresAt: send.ExpiresAt.Unix(),
+		CreatedAt: send.CreatedAt.Unix(),
+	}
+	return a.storage.AddSend(ctx, params)
+}
+
+func (a *Adapter) GetSend(ctx context.Context, sendID uuid.UUID) (sends.Send, error) {
+	row, err := a.storage.GetSend(ctx, sendID)
+	if err != nil {
+		return sends.Send{}, err
+	}
+
+	return sends.Send{
+		ID:        sendID,
+		UserID:    row.UserID,
+		Type:      sends.Type(row.Type),
+		FileName:  row.FileName,
+		Nonce:     row.Nonce,
+		Data:      row.Data,
+		Password:  row.Password,
+		MaxViews:  row.MaxViews,
+		Views:     row.Views,
+		ExpiresAt: time.Unix(row.ExpiresAt, 0).UTC(),
+		CreatedAt: time.Unix(row.CreatedAt, 0).UTC(),
+	}, nil
+}
+
+func (a *Adapter) GetUserSends(ctx context.Context, userID uuid.UUID) ([]sends.Send, error) {
+	rows, err := a.storage.GetUserSends(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]sends.Send, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, sends.Send{
+			ID:        row.ID,
+			UserID:    userID,
+			Type:      sends.Type(row.Type),
+			FileName:  row.FileName,
+			Password:  row.Password,
+			MaxViews:  row.MaxViews,
+			Views:     row.Views,
+			ExpiresAt: time.Unix(row.ExpiresAt, 0).UTC(),
+			CreatedAt: time.Unix(row.CreatedAt, 0).UTC(),
+		})
+	}
+	return res, nil
+}
+
+// AddView counts the view of the send which isn't expired or burned. It
+// returns false when there was nothing to count.
+func (a *Adapter) AddView(ctx context.Context, sendID uuid.UUID, now time.Time) (bool, error) {
+	affected, err := a.storage.AddView(ctx, queries.AddViewParams{ID: sendID, ExpiresAt: now.Unix()})
+	return affected > 0, err
+}
+
+func (a *Adapter) RemoveSend(ctx context.Context, sendID uuid.UUID) error {
+	return a.storage.RemoveSend(ctx, sendID)
+}
+
+func (a *Adapter) RemoveExpiredSends(ctx context.Context, now time.Time) error {
+	return a.storage.RemoveExpiredSends(ctx, now.Unix())
+}
+
+func (a *Adapter) IsEmptyRows(err error) bool {
+	return errors.Is(err, sql.ErrNoRows)
+}
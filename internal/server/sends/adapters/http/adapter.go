@@ -0,0 +1,291 @@
+package http
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"passman/internal/server/infra"
+	"passman/internal/server/sends"
+
+	"github.com/go-chi/chi/v5"
+	vldtr "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+const (
+	passwordHeader = "X-Send-Password"
+	maxFileSize    = 1 << 20
+)
+
+type Adapter struct {
+	log *slog.Logger
+	su  sendsUsecase
+	sm  sessionManager
+	v   *validator
+}
+
+func NewRouter(su sendsUsecase, sm sessionManager, v *vldtr.Validate) chi.Router {
+	a := &Adapter{
+		log: slog.Default(),
+		su:  su,
+		sm:  sm,
+		v:   newValidator(v),
+	}
+
+	router := chi.NewRouter()
+
+	// Recipients of the send have no account
+	router.Get("/{sendID}", a.OpenSend)
+
+	router.Group(func(routerAuth chi.Router) {
+		routerAuth.Use(infra.AuthMiddleware(sm))
+
+		routerAuth.Post("/text", a.CreateTextSend)
+		routerAuth.Post("/file", a.CreateFileSend)
+		routerAuth.Get("/my", a.GetUserSends)
+		routerAuth.Delete("/{sendID}", a.RemoveSend)
+	})
+
+	return router
+}
+
+type createdResponse struct {
+	ID  uuid.UUID `json:"id"`
+	Key string    `json:"key"`
+	// URL carries the key in the fragment, which is never sent to the server
+	URL string `json:"url"`
+}
+
+func (a *Adapter) CreateTextSend(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	body := struct {
+		Text            string `json:"text"`
+		Password        string `json:"password"`
+		MaxViews        int64  `json:"max_views"`
+		ExpirationHours int64  `json:"expiration_hours"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		a.log.ErrorContext(r.Context(), "CreateTextSend: failed parsing body", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	params := sendParams{Password: body.Password, MaxViews: body.MaxViews, ExpirationHours: body.ExpirationHours}
+	if err := a.v.ValidateText(body.Text, params); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dto := sends.SendDTO{
+		UserID:   userID,
+		Type:     sends.TypeText,
+		Content:  []byte(body.Text),
+		Password: body.Password,
+		MaxViews: body.MaxViews,
+		TTL:      time.Duration(body.ExpirationHours) * time.Hour,
+	}
+
+	a.createSend(w, r, "CreateTextSend", dto)
+}
+
+func (a *Adapter) CreateFileSend(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	file, err := infra.RecieveFile(r, infra.RecieveFileOptions{FormFileKey: "file"})
+	if err != nil {
+		code, msg := a.parseRecieveFileError(r.Context(), "CreateFileSend", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, maxFileSize+1))
+	if err != nil {
+		a.log.ErrorContext(r.Context(), "CreateFileSend: failed reading file", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if len(content) > maxFileSize {
+		infra.ErrorHandler(w, http.StatusBadRequest, "file is too large")
+		return
+	}
+
+	maxViews, _ := strconv.ParseInt(r.FormValue("max_views"), 10, 64)
+	expirationHours, _ := strconv.ParseInt(r.FormValue("expiration_hours"), 10, 64)
+	params := sendParams{Password: r.FormValue("password"), MaxViews: maxViews, ExpirationHours: expirationHours}
+
+	// The file is in the form, RecieveFile has already checked it
+	fileName := r.MultipartForm.File["file"][0].Filename
+	if err := a.v.ValidateFile(fileName, params); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dto := sends.SendDTO{
+		UserID:   userID,
+		Type:     sends.TypeFile,
+		FileName: fileName,
+		Content:  content,
+		Password: params.Password,
+		MaxViews: maxViews,
+		TTL:      time.Duration(expirationHours) * time.Hour,
+	}
+
+	a.createSend(w, r, "CreateFileSend", dto)
+}
+
+func (a *Adapter) createSend(w http.ResponseWriter, r *http.Request, component string, dto sends.SendDTO) {
+	created, err := a.su.CreateSend(r.Context(), dto)
+	if err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), component, err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	key := base64.RawURLEncoding.EncodeToString(created.Key)
+	infra.ResponseJSON(w, createdResponse{
+		ID:  created.ID,
+		Key: key,
+		URL: fmt.Sprintf("%s://%s/sends/%s#%s", scheme, r.Host, created.ID, key),
+	}, http.StatusOK)
+}
+
+func (a *Adapter) GetUserSends(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	userSends, err := a.su.GetUserSends(r.Context(), userID)
+	if err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "GetUserSends", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	type responseType struct {
+		ID          uuid.UUID  `json:"id"`
+		Type        sends.Type `json:"type"`
+		FileName    string     `json:"file_name,omitempty"`
+		HasPassword bool       `json:"has_password"`
+		MaxViews    int64      `json:"max_views"`
+		Views       int64      `json:"views"`
+		ExpiresAt   time.Time  `json:"expires_at"`
+		CreatedAt   time.Time  `json:"created_at"`
+	}
+
+	res := make([]responseType, 0, len(userSends))
+	for _, send := range userSends {
+		res = append(res, responseType{
+			ID:          send.ID,
+			Type:        send.Type,
+			FileName:    send.FileName,
+			HasPassword: len(send.Password) > 0,
+			MaxViews:    send.MaxViews,
+			Views:       send.Views,
+			ExpiresAt:   send.ExpiresAt,
+			CreatedAt:   send.CreatedAt,
+		})
+	}
+
+	infra.ResponseJSON(w, res, http.StatusOK)
+}
+
+func (a *Adapter) RemoveSend(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.sm.GetString(r.Context(), "user_id"))
+
+	sendID := chi.URLParam(r, "sendID")
+	if err := a.v.ValidateSendID(sendID); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := a.su.RemoveSend(r.Context(), userID, uuid.MustParse(sendID)); err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "RemoveSend", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *Adapter) OpenSend(w http.ResponseWriter, r *http.Request) {
+	sendID := chi.URLParam(r, "sendID")
+	if err := a.v.ValidateSendID(sendID); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	send, err := a.su.OpenSend(r.Context(), uuid.MustParse(sendID), r.Header.Get(passwordHeader), infra.ClientIP(r))
+	if err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "OpenSend", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	type responseType struct {
+		Type      sends.Type `json:"type"`
+		FileName  string     `json:"file_name,omitempty"`
+		Nonce     []byte     `json:"nonce"`
+		Data      []byte     `json:"data"`
+		ViewsLeft int64      `json:"views_left"`
+		ExpiresAt time.Time  `json:"expires_at"`
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	infra.ResponseJSON(w, responseType{
+		Type:      send.Type,
+		FileName:  send.FileName,
+		Nonce:     send.Nonce,
+		Data:      send.Data,
+		ViewsLeft: send.MaxViews - send.Views,
+		ExpiresAt: send.ExpiresAt,
+	}, http.StatusOK)
+}
+
+func (a *Adapter) parseUsecaseError(ctx context.Context, component string, usecaseError error) (int, string) {
+	code, msg, err := a.su.ParseMyError(usecaseError)
+	if code == 0 {
+		a.log.ErrorContext(ctx, fmt.Sprintf("%s: wrong type of usecase error", component), slog.Any("error", err))
+		return http.StatusInternalServerError, "internal error"
+	}
+
+	if code >= 500 {
+		a.log.ErrorContext(ctx, msg, slog.Any("error", err))
+		return code, "internal error"
+	}
+
+	a.log.WarnContext(ctx, msg)
+	return code, strings.SplitN(msg, ": ", 2)[1]
+}
+
+func (a *Adapter) parseRecieveFileError(ctx context.Context, component string, err error) (int, string) {
+	var recieveError *infra.RecieveFileError
+	if errors.As(err, &recieveError) {
+		var msg string
+
+		if recieveError.Code == http.StatusInternalServerError {
+			a.log.ErrorContext(ctx, fmt.Sprintf("%s: multipart error", component), slog.Any("error", err))
+			msg = "internal error"
+		} else {
+			msg = recieveError.Error()
+		}
+
+		return recieveError.Code, msg
+	}
+
+	a.log.ErrorContext(ctx, fmt.Sprintf("%s: wrong type of multipart error", component), slog.String("error", "error expected to be recieveFileError type"))
+	return http.StatusInternalServerError, "internal error"
+}
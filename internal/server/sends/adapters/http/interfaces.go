@@ -0,0 +1,23 @@
+package http
+
+import (
+	"context"
+
+	"passman/internal/server/sends"
+
+	"github.com/google/uuid"
+)
+
+type sendsUsecase interface {
+	CreateSend(context.Context, sends.SendDTO) (sends.Created, error)
+	GetUserSends(context.Context, uuid.UUID) ([]sends.Send, error)
+	RemoveSend(context.Context, uuid.UUID, uuid.UUID) error
+	OpenSend(context.Context, uuid.UUID, string, string) (sends.Send, error)
+	ParseMyError(error) (int, string, error)
+}
+
+type sessionManager interface {
+	GetString(context.Context, string) string
+	Put(context.Context, string, any)
+	Keys(context.Context) []string
+}
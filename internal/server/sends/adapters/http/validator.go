@@ -0,0 +1,49 @@
+package http
+
+import (
+	"fmt"
+
+	vldtr "github.com/go-playground/validator/v10"
+)
+
+type validator struct {
+	v *vldtr.Validate
+}
+
+func newValidator(v *vldtr.Validate) *validator {
+	return &validator{v: v}
+}
+
+type sendParams struct {
+	Password        string `validate:"omitempty,min=4,max=64"`
+	MaxViews        int64  `validate:"min=1,max=100"`
+	ExpirationHours int64  `validate:"min=1,max=720"`
+}
+
+func (v *validator) ValidateText(text string, params sendParams) error {
+	if err := v.v.Var(text, "required,max=10000"); err != nil {
+		return fmt.Errorf("invalid text")
+	}
+	return v.validateParams(params)
+}
+
+func (v *validator) ValidateFile(fileName string, params sendParams) error {
+	if err := v.v.Var(fileName, "required,max=255,excludesall=/\\"); err != nil {
+		return fmt.Errorf("invalid file name")
+	}
+	return v.validateParams(params)
+}
+
+func (v *validator) validateParams(params sendParams) error {
+	if err := v.v.Struct(params); err != nil {
+		return fmt.Errorf("invalid password, max views or expiration")
+	}
+	return nil
+}
+
+func (v *validator) ValidateSendID(sendID string) error {
+	if err := v.v.Var(sendID, "uuid"); err != nil {
+		return fmt.Errorf("invalid send id")
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package sends
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Type string
+
+const (
+	TypeText Type = "text"
+	TypeFile Type = "file"
+)
+
+// Send is a one-off secret encrypted by the random key. The key isn't stored,
+// it's returned to the creator only once.
+type Send struct {
+	ID       uuid.UUID
+	UserID   uuid.UUID
+	Type     Type
+	FileName string
+	Nonce    []byte
+	Data     []byte
+	// Password is the Argon2id hash of the optional password
+	Password  string
+	MaxViews  int64
+	Views     int64
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type SendDTO struct {
+	UserID   uuid.UUID
+	Type     Type
+	FileName string
+	Content  []byte
+	Password string
+	MaxViews int64
+	TTL      time.Duration
+}
+
+type Created struct {
+	ID  uuid.UUID
+	Key []byte
+}
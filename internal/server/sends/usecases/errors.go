@@ -0,0 +1,46 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+)
+
+type sendsError struct {
+	Code      int
+	Component string
+	Msg       string
+	Err       error
+}
+
+func (ce *sendsError) Error() string {
+	return fmt.Sprintf("%s: %s", ce.Component, ce.Msg)
+}
+
+func (ce *sendsError) Unwrap() error {
+	return ce.Err
+}
+
+func (ce *sendsError) Is(target error) bool {
+	return ce.Error() == target.Error()
+}
+
+func newClientError(msg string) error {
+	return &sendsError{Code: 400, Component: "ClientError", Msg: msg, Err: nil}
+}
+
+// newThrottledError is the client error with 429 code
+func newThrottledError(msg string) error {
+	return &sendsError{Code: 429, Component: "ClientError", Msg: msg, Err: nil}
+}
+
+func newInternalError(component, msg string, err error) error {
+	return &sendsError{Code: 500, Component: component, Msg: msg, Err: err}
+}
+
+func parseSendsError(err error) (int, string, error) {
+	var ce *sendsError
+	if errors.As(err, &ce) {
+		return ce.Code, ce.Error(), ce.Err
+	}
+	return 0, "", nil
+}
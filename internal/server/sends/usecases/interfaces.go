@@ -0,0 +1,28 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"passman/internal/server/sends"
+
+	"github.com/google/uuid"
+)
+
+//go:generate mockgen -source=interfaces.go -destination=mock/repository.go
+type repository interface {
+	AddSend(ctx context.Context, send sends.Send) error
+	GetSend(ctx context.Context, sendID uuid.UUID) (sends.Send, error)
+	GetUserSends(ctx context.Context, userID uuid.UUID) ([]sends.Send, error)
+	AddView(ctx context.Context, sendID uuid.UUID, now time.Time) (bool, error)
+	RemoveSend(ctx context.Context, sendID uuid.UUID) error
+	RemoveExpiredSends(ctx context.Context, now time.Time) error
+	IsEmptyRows(err error) bool
+}
+
+// usersUsecase throttles wrong passwords of sends like logins
+type usersUsecase interface {
+	SendAttemptAllowed(ctx context.Context, sendID uuid.UUID, ip string) (bool, error)
+	AddSendFailure(ctx context.Context, sendID uuid.UUID, ip string) error
+	RemoveSendThrottle(ctx context.Context, sendID uuid.UUID) error
+}
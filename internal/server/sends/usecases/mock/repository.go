@@ -0,0 +1,212 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interfaces.go
+//
+// Generated by this command:
+//
+//	mockgen -source=interfaces.go -destination=mock/repository.go
+//
+
+// Package mock_usecases is a generated GoMock package.
+package mock_usecases
+
+import (
+	context "context"
+	sends "passman/internal/server/sends"
+	reflect "reflect"
+	time "time"
+
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// Mockrepository is a mock of repository interface.
+type Mockrepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockrepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockrepositoryMockRecorder is the mock recorder for Mockrepository.
+type MockrepositoryMockRecorder struct {
+	mock *Mockrepository
+}
+
+// NewMockrepository creates a new mock instance.
+func NewMockrepository(ctrl *gomock.Controller) *Mockrepository {
+	mock := &Mockrepository{ctrl: ctrl}
+	mock.recorder = &MockrepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockrepository) EXPECT() *MockrepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddSend mocks base method.
+func (m *Mockrepository) AddSend(ctx context.Context, send sends.Send) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddSend", ctx, send)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddSend indicates an expected call of AddSend.
+func (mr *MockrepositoryMockRecorder) AddSend(ctx, send any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddSend", reflect.TypeOf((*Mockrepository)(nil).AddSend), ctx, send)
+}
+
+// AddView mocks base method.
+func (m *Mockrepository) AddView(ctx context.Context, sendID uuid.UUID, now time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddView", ctx, sendID, now)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddView indicates an expected call of AddView.
+func (mr *MockrepositoryMockRecorder) AddView(ctx, sendID, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddView", reflect.TypeOf((*Mockrepository)(nil).AddView), ctx, sendID, now)
+}
+
+// GetSend mocks base method.
+func (m *Mockrepository) GetSend(ctx context.Context, sendID uuid.UUID) (sends.Send, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSend", ctx, sendID)
+	ret0, _ := ret[0].(sends.Send)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSend indicates an expected call of GetSend.
+func (mr *MockrepositoryMockRecorder) GetSend(ctx, sendID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSend", reflect.TypeOf((*Mockrepository)(nil).GetSend), ctx, sendID)
+}
+
+// GetUserSends mocks base method.
+func (m *Mockrepository) GetUserSends(ctx context.Context, userID uuid.UUID) ([]sends.Send, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserSends", ctx, userID)
+	ret0, _ := ret[0].([]sends.Send)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserSends indicates an expected call of GetUserSends.
+func (mr *MockrepositoryMockRecorder) GetUserSends(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserSends", reflect.TypeOf((*Mockrepository)(nil).GetUserSends), ctx, userID)
+}
+
+// IsEmptyRows mocks base method.
+func (m *Mockrepository) IsEmptyRows(err error) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsEmptyRows", err)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsEmptyRows indicates an expected call of IsEmptyRows.
+func (mr *MockrepositoryMockRecorder) IsEmptyRows(err any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsEmptyRows", reflect.TypeOf((*Mockrepository)(nil).IsEmptyRows), err)
+}
+
+// RemoveExpiredSends mocks base method.
+func (m *Mockrepository) RemoveExpiredSends(ctx context.Context, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveExpiredSends", ctx, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveExpiredSends indicates an expected call of RemoveExpiredSends.
+func (mr *MockrepositoryMockRecorder) RemoveExpiredSends(ctx, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveExpiredSends", reflect.TypeOf((*Mockrepository)(nil).RemoveExpiredSends), ctx, now)
+}
+
+// RemoveSend mocks base method.
+func (m *Mockrepository) RemoveSend(ctx context.Context, sendID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveSend", ctx, sendID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveSend indicates an expected call of RemoveSend.
+func (mr *MockrepositoryMockRecorder) RemoveSend(ctx, sendID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveSend", reflect.TypeOf((*Mockrepository)(nil).RemoveSend), ctx, sendID)
+}
+
+// MockusersUsecase is a mock of usersUsecase interface.
+type MockusersUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockusersUsecaseMockRecorder
+	isgomock struct{}
+}
+
+// MockusersUsecaseMockRecorder is the mock recorder for MockusersUsecase.
+type MockusersUsecaseMockRecorder struct {
+	mock *MockusersUsecase
+}
+
+// NewMockusersUsecase creates a new mock instance.
+func NewMockusersUsecase(ctrl *gomock.Controller) *MockusersUsecase {
+	mock := &MockusersUsecase{ctrl: ctrl}
+	mock.recorder = &MockusersUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockusersUsecase) EXPECT() *MockusersUsecaseMockRecorder {
+	return m.recorder
+}
+
+// AddSendFailure mocks base method.
+func (m *MockusersUsecase) AddSendFailure(ctx context.Context, sendID uuid.UUID, ip string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddSendFailure", ctx, sendID, ip)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddSendFailure indicates an expected call of AddSendFailure.
+func (mr *MockusersUsecaseMockRecorder) AddSendFailure(ctx, sendID, ip any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddSendFailure", reflect.TypeOf((*MockusersUsecase)(nil).AddSendFailure), ctx, sendID, ip)
+}
+
+// RemoveSendThrottle mocks base method.
+func (m *MockusersUsecase) RemoveSendThrottle(ctx context.Context, sendID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveSendThrottle", ctx, sendID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveSendThrottle indicates an expected call of RemoveSendThrottle.
+func (mr *MockusersUsecaseMockRecorder) RemoveSendThrottle(ctx, sendID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveSendThrottle", reflect.TypeOf((*MockusersUsecase)(nil).RemoveSendThrottle), ctx, sendID)
+}
+
+// SendAttemptAllowed mocks base method.
+func (m *MockusersUsecase) SendAttemptAllowed(ctx context.Context, sendID uuid.UUID, ip string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendAttemptAllowed", ctx, sendID, ip)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SendAttemptAllowed indicates an expected call of SendAttemptAllowed.
+func (mr *MockusersUsecaseMockRecorder) SendAttemptAllowed(ctx, sendID, ip any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendAttemptAllowed", reflect.TypeOf((*MockusersUsecase)(nil).SendAttemptAllowed), ctx, sendID, ip)
+}
@@ -0,0 +1,179 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"passman/internal/server/sends"
+	"passman/pkg/cipher"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/google/uuid"
+)
+
+var (
+	errSendNotFound      = newClientError("send not found")
+	errIncorrectPassword = newClientError("incorrect password")
+	errTooManyAttempts   = newThrottledError("too many attempts, try again later")
+)
+
+type sendsUsecase struct {
+	repo         repository
+	users        usersUsecase
+	argon2Params *argon2id.Params
+	now          func() time.Time
+}
+
+// New creates the usecase, passwords of sends are hashed by argon2Params or
+// by the default parameters if it's nil
+func New(repo repository, users usersUsecase, argon2Params *argon2id.Params) *sendsUsecase {
+	if argon2Params == nil {
+		argon2Params = argon2id.DefaultParams
+	}
+	return &sendsUsecase{repo: repo, users: users, argon2Params: argon2Params, now: time.Now}
+}
+
+// CreateSend encrypts the content by the new random key. The key is returned
+// only once, the send can't be decrypted without it.
+func (su *sendsUsecase) CreateSend(ctx context.Context, dto sends.SendDTO) (sends.Created, error) {
+	key, err := cipher.GenerateKey()
+	if err != nil {
+		return sends.Created{}, newInternalError("CreateSend", "failed generating key", err)
+	}
+
+	now := su.now().UTC()
+	send := sends.Send{
+		ID:        uuid.New(),
+		UserID:    dto.UserID,
+		Type:      dto.Type,
+		FileName:  dto.FileName,
+		MaxViews:  dto.MaxViews,
+		ExpiresAt: now.Add(dto.TTL),
+		CreatedAt: now,
+	}
+
+	// The id is authenticated with the content, so the ciphertext can't be
+	// served under another send
+	send.Nonce, send.Data, err = cipher.SealGCM(key, dto.Content, []byte(send.ID.String()))
+	if err != nil {
+		return sends.Created{}, newInternalError("CreateSend", "failed encrypting content", err)
+	}
+
+	if len(dto.Password) > 0 {
+		if send.Password, err = argon2id.CreateHash(dto.Password, su.argon2Params); err != nil {
+			return sends.Created{}, newInternalError("CreateSend", "failed creating password hash", err)
+		}
+	}
+
+	if err := su.repo.AddSend(ctx, send); err != nil {
+		return sends.Created{}, newInternalError("CreateSend", "failed adding send", err)
+	}
+
+	return sends.Created{ID: send.ID, Key: key}, nil
+}
+
+// GetUserSends returns the active sends of the user without their content
+func (su *sendsUsecase) GetUserSends(ctx context.Context, userID uuid.UUID) ([]sends.Send, error) {
+	if err := su.repo.RemoveExpiredSends(ctx, su.now()); err != nil {
+		return nil, newInternalError("GetUserSends", "failed removing expired sends", err)
+	}
+
+	userSends, err := su.repo.GetUserSends(ctx, userID)
+	if err != nil {
+		return nil, newInternalError("GetUserSends", "failed getting sends", err)
+	}
+
+	return userSends, nil
+}
+
+func (su *sendsUsecase) RemoveSend(ctx context.Context, userID, sendID uuid.UUID) error {
+	send, err := su.repo.GetSend(ctx, sendID)
+	if err != nil {
+		if su.repo.IsEmptyRows(err) {
+			return errSendNotFound
+		}
+		return newInternalError("RemoveSend", "failed getting send", err)
+	}
+	if send.UserID != userID {
+		return errSendNotFound
+	}
+
+	if err := su.repo.RemoveSend(ctx, sendID); err != nil {
+		return newInternalError("RemoveSend", "failed removing send", err)
+	}
+
+	return nil
+}
+
+// OpenSend returns the encrypted send to anyone who knows its id and password.
+// Every call counts a view, the send is burned after the last one. Wrong
+// passwords are throttled by the send and the client ip.
+func (su *sendsUsecase) OpenSend(ctx context.Context, sendID uuid.UUID, password, ip string) (sends.Send, error) {
+	now := su.now()
+
+	if err := su.repo.RemoveExpiredSends(ctx, now); err != nil {
+		return sends.Send{}, newInternalError("OpenSend", "failed removing expired sends", err)
+	}
+
+	send, err := su.repo.GetSend(ctx, sendID)
+	if err != nil {
+		if su.repo.IsEmptyRows(err) {
+			return sends.Send{}, errSendNotFound
+		}
+		return sends.Send{}, newInternalError("OpenSend", "failed getting send", err)
+	}
+
+	if len(send.Password) > 0 {
+		if len(password) == 0 {
+			return sends.Send{}, newClientError("password required")
+		}
+
+		// The hash is checked only when allowed, so the endpoint can't be
+		// used for brute force or loading the server
+		allowed, err := su.users.SendAttemptAllowed(ctx, sendID, ip)
+		if err != nil {
+			return sends.Send{}, newInternalError("OpenSend", "failed checking throttle", err)
+		}
+		if !allowed {
+			return sends.Send{}, errTooManyAttempts
+		}
+
+		match, err := argon2id.ComparePasswordAndHash(password, send.Password)
+		if err != nil {
+			return sends.Send{}, newInternalError("OpenSend", "failed comparing password", err)
+		}
+		if !match {
+			if err := su.users.AddSendFailure(ctx, sendID, ip); err != nil {
+				return sends.Send{}, newInternalError("OpenSend", "failed adding failure", err)
+			}
+			return sends.Send{}, errIncorrectPassword
+		}
+
+		if err := su.users.RemoveSendThrottle(ctx, sendID); err != nil {
+			return sends.Send{}, newInternalError("OpenSend", "failed removing throttle", err)
+		}
+	}
+
+	// The view is counted only if the send is still available, so concurrent
+	// requests can't exceed the limit
+	counted, err := su.repo.AddView(ctx, sendID, now)
+	if err != nil {
+		return sends.Send{}, newInternalError("OpenSend", "failed counting view", err)
+	}
+	if !counted {
+		return sends.Send{}, errSendNotFound
+	}
+
+	send.Views++
+	if send.Views >= send.MaxViews {
+		if err := su.repo.RemoveSend(ctx, sendID); err != nil {
+			return sends.Send{}, newInternalError("OpenSend", "failed burning send", err)
+		}
+	}
+
+	return send, nil
+}
+
+func (su *sendsUsecase) ParseMyError(err error) (int, string, error) {
+	return parseSendsError(err)
+}
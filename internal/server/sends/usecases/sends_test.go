@@ -0,0 +1,216 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"passman/internal/server/sends"
+	mock_usecases "passman/internal/server/sends/usecases/mock"
+	"passman/pkg/cipher"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/google/uuid"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateSend(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockrepository(ctrl)
+	mockUsers := mock_usecases.NewMockusersUsecase(ctrl)
+	params := &argon2id.Params{Memory: 8 * 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	sendsUsecase := New(mockRepo, mockUsers, params)
+	ctx := context.Background()
+	now := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	sendsUsecase.now = func() time.Time { return now }
+
+	dto := sends.SendDTO{
+		UserID:   uuid.New(),
+		Type:     sends.TypeText,
+		Content:  []byte("contractor password"),
+		Password: "pass",
+		MaxViews: 2,
+		TTL:      24 * time.Hour,
+	}
+
+	tests := []struct {
+		name       string
+		addSendErr error
+		expErr     error
+	}{
+		{
+			name:       "failed_adding_send",
+			addSendErr: errors.New("internal error"),
+			expErr:     errors.New("CreateSend: failed adding send"),
+		},
+		{
+			name: "success",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stored sends.Send
+			mockRepo.EXPECT().
+				AddSend(ctx, gomock.Any()).
+				DoAndReturn(func(_ context.Context, send sends.Send) error {
+					stored = send
+					return test.addSendErr
+				}).
+				Times(1)
+
+			created, actErr := sendsUsecase.CreateSend(ctx, dto)
+
+			if got, want := actErr, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if actErr != nil {
+				return
+			}
+
+			if got, want := stored.ExpiresAt, now.Add(dto.TTL); !got.Equal(want) {
+				t.Errorf("Wrong! Unexpected expiration!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+
+			if len(stored.Password) == 0 || stored.Password == dto.Password {
+				t.Errorf("Wrong! Password must be stored as hash!\n\tActual: %v", stored.Password)
+			}
+
+			if storedParams, _, _, err := argon2id.DecodeHash(stored.Password); err != nil || *storedParams != *params {
+				t.Errorf("Wrong! Unexpected hash parameters!\n\tExpected: %+v\n\tActual: %+v", params, storedParams)
+			}
+
+			plaintext, err := cipher.OpenGCM(created.Key, stored.Nonce, stored.Data, []byte(created.ID.String()))
+			if err != nil {
+				t.Fatalf("Wrong! Unexpected decryption error!\n\tActual: %v", err)
+			}
+			if got, want := string(plaintext), string(dto.Content); got != want {
+				t.Errorf("Wrong! Unexpected content!\n\tExpected: %s\n\tActual: %s", want, got)
+			}
+		})
+	}
+}
+
+func TestOpenSend(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockrepository(ctrl)
+	mockUsers := mock_usecases.NewMockusersUsecase(ctrl)
+	sendsUsecase := New(mockRepo, mockUsers, nil)
+	ctx := context.Background()
+	now := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	sendsUsecase.now = func() time.Time { return now }
+	errEmptyRows := errors.New("empty rows")
+	sendID := uuid.New()
+	ip := "192.0.2.1"
+	// Hash of "user_password"
+	passwordHash := "$argon2id$v=19$m=65536,t=1,p=4$XAPvqtpAVs/NGpyd1H5Fmg$pvbpnBLwlbXfFyuRmochGwJwetm1rv2m1/MmCw7qcPc"
+
+	type addViewResult struct {
+		counted bool
+	}
+
+	// throttle is set for sends with the password
+	type throttle struct {
+		allowed      bool
+		failureCalls int
+		removeCalls  int
+	}
+
+	tests := []struct {
+		name          string
+		getSendErr    error
+		sendPassword  string
+		password      string
+		views         int64
+		throttle      *throttle
+		addViewResult *addViewResult
+		burnCalls     int
+		expErr        error
+	}{
+		{
+			name:       "not_found",
+			getSendErr: errEmptyRows,
+			expErr:     errors.New("ClientError: send not found"),
+		},
+		{
+			name:         "password_required",
+			sendPassword: passwordHash,
+			expErr:       errors.New("ClientError: password required"),
+		},
+		{
+			name:         "too_many_attempts",
+			sendPassword: passwordHash,
+			password:     "user_password",
+			throttle:     &throttle{allowed: false},
+			expErr:       errors.New("ClientError: too many attempts, try again later"),
+		},
+		{
+			name:         "incorrect_password",
+			sendPassword: passwordHash,
+			password:     "incorrect_password",
+			throttle:     &throttle{allowed: true, failureCalls: 1},
+			expErr:       errors.New("ClientError: incorrect password"),
+		},
+		{
+			name:          "burned_concurrently",
+			addViewResult: &addViewResult{counted: false},
+			expErr:        errors.New("ClientError: send not found"),
+		},
+		{
+			name:          "success",
+			sendPassword:  passwordHash,
+			password:      "user_password",
+			throttle:      &throttle{allowed: true, removeCalls: 1},
+			addViewResult: &addViewResult{counted: true},
+			expErr:        nil,
+		},
+		{
+			name:          "last_view",
+			views:         2,
+			addViewResult: &addViewResult{counted: true},
+			burnCalls:     1,
+			expErr:        nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().RemoveExpiredSends(ctx, now).Return(nil).Times(1)
+
+			send := sends.Send{ID: sendID, Password: test.sendPassword, MaxViews: 3, Views: test.views}
+			mockRepo.EXPECT().GetSend(ctx, sendID).Return(send, test.getSendErr).Times(1)
+			if test.getSendErr != nil {
+				mockRepo.EXPECT().IsEmptyRows(test.getSendErr).Return(true).Times(1)
+			}
+
+			if test.throttle != nil {
+				mockUsers.EXPECT().SendAttemptAllowed(ctx, sendID, ip).Return(test.throttle.allowed, nil).Times(1)
+				mockUsers.EXPECT().AddSendFailure(ctx, sendID, ip).Return(nil).Times(test.throttle.failureCalls)
+				mockUsers.EXPECT().RemoveSendThrottle(ctx, sendID).Return(nil).Times(test.throttle.removeCalls)
+			}
+
+			if test.addViewResult != nil {
+				mockRepo.EXPECT().AddView(ctx, sendID, now).Return(test.addViewResult.counted, nil).Times(1)
+			}
+
+			mockRepo.EXPECT().RemoveSend(ctx, sendID).Return(nil).Times(test.burnCalls)
+
+			actSend, actErr := sendsUsecase.OpenSend(ctx, sendID, test.password, ip)
+
+			if got, want := actErr, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+
+			if actErr == nil {
+				if got, want := actSend.Views, test.views+1; got != want {
+					t.Errorf("Wrong! Unexpected views!\n\tExpected: %d\n\tActual: %d", want, got)
+				}
+			}
+		})
+	}
+}
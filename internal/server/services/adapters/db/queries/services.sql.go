@@ -28,7 +28,7 @@ func (q *Queries) AddService(ctx context.Context, arg AddServiceParams) error {
 
 const checkExistingRecord = `-- name: CheckExistingRecord :one
 select accounts.id from accounts
-  where accounts.user_id <> ?
+  where accounts.user_id is not ?
   and accounts.service_id in (
     select services.id from services
       where services.name = ?
@@ -1,14 +1,18 @@
 package http
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"passman/internal/server/infra"
+	"passman/internal/server/users"
 
 	"github.com/go-chi/chi/v5"
 	vldtr "github.com/go-playground/validator/v10"
@@ -32,11 +36,13 @@ func NewRouter(su serviceUsecase, sm sessionManager, v *vldtr.Validate) chi.Rout
 
 	router := chi.NewRouter()
 
-	router.Use(infra.AuthMiddleware(sm))
+	// Access tokens with the scopes are accepted too
+	router.Use(infra.ScopedAuthMiddleware(sm, users.ScopeServices, users.ScopeServices))
 
 	router.Post("/{serviceName}", a.AddService)
 	router.Get("/all", a.GetAllServices)
 	router.Get("/my", a.GetAllUserServices)
+	router.Get("/{serviceName}/logo", a.GetLogo)
 	router.Put("/{oldServiceName}/{newServiceName}", a.UpdateService)
 	router.Delete("/{serviceName}", a.RemoveService)
 
@@ -86,7 +92,7 @@ func (a *Adapter) GetAllServices(w http.ResponseWriter, r *http.Request) {
 	}
 	res := make([]responseType, 0, len(services))
 	for _, serv := range services {
-		res = append(res, responseType{Name: serv.Name, LogoPath: serv.Logo})
+		res = append(res, responseType{Name: serv.Name, LogoPath: logoURL(serv.Name)})
 	}
 
 	infra.ResponseJSON(w, res, http.StatusOK)
@@ -109,12 +115,39 @@ func (a *Adapter) GetAllUserServices(w http.ResponseWriter, r *http.Request) {
 
 	res := make([]responseType, 0, len(services))
 	for _, serv := range services {
-		res = append(res, responseType{Name: serv.Name, LogoPath: serv.Logo})
+		res = append(res, responseType{Name: serv.Name, LogoPath: logoURL(serv.Name)})
 	}
 
 	infra.ResponseJSON(w, res, http.StatusOK)
 }
 
+// GetLogo serves the logo of the service. Logos are revalidated by the ETag on
+// every use, so updated logos are shown at once.
+func (a *Adapter) GetLogo(w http.ResponseWriter, r *http.Request) {
+	serviceName := chi.URLParam(r, "serviceName")
+	if err := a.v.ValidateServiceNames(serviceName); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	logo, err := a.su.GetLogo(r.Context(), serviceName)
+	if err != nil {
+		code, msg := a.parseUsecaseError(r.Context(), "GetLogo", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.Header().Set("Content-Type", logo.ContentType)
+	w.Header().Set("ETag", logo.ETag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// Scripts of SVG logos aren't run if the logo is opened directly
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+
+	// ServeContent answers 304 for the matching If-None-Match
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(logo.Data))
+}
+
 func (a *Adapter) UpdateService(w http.ResponseWriter, r *http.Request) {
 	oldServiceName := chi.URLParam(r, "oldServiceName")
 	newServiceName := chi.URLParam(r, "newServiceName")
@@ -164,6 +197,11 @@ func (a *Adapter) RemoveService(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// logoURL is the path of GetLogo, the router is mounted on /services
+func logoURL(serviceName string) string {
+	return "/services/" + url.PathEscape(serviceName) + "/logo"
+}
+
 func (a *Adapter) parseUsecaseError(ctx context.Context, component string, usecaseError error) (int, string) {
 	code, msg, err := a.su.ParseUserError(usecaseError)
 	if code == 0 {
@@ -12,6 +12,7 @@ import (
 type serviceUsecase interface {
 	AddService(context.Context, string, io.Reader) error
 	GetAllServices(context.Context) ([]services.ServiceDTO, error)
+	GetLogo(context.Context, string) (services.LogoFile, error)
 	GetAllUserServices(context.Context, uuid.UUID) ([]services.ServiceDTO, error)
 	UpdateService(context.Context, string, string, io.Reader) error
 	RemoveService(context.Context, uuid.UUID, string) error
@@ -20,5 +21,7 @@ type serviceUsecase interface {
 
 type sessionManager interface {
 	GetString(context.Context, string) string
+	Put(context.Context, string, any)
 	Keys(context.Context) []string
+	Destroy(context.Context) error
 }
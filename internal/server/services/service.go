@@ -2,6 +2,10 @@ package services
 
 import "github.com/google/uuid"
 
+// MaxNameLength of services in runes, logos are stored by the name, so the
+// filename has to fit 255 bytes
+const MaxNameLength = 50
+
 type Service struct {
 	ID   uuid.UUID
 	Name string
@@ -12,3 +16,10 @@ type ServiceDTO struct {
 	Name string
 	Logo string
 }
+
+// LogoFile is the logo of the service, the ETag is the hash of the content
+type LogoFile struct {
+	Data        []byte
+	ContentType string
+	ETag        string
+}
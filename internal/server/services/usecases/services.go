@@ -2,15 +2,20 @@ package usecases
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"io"
 	"os"
 	"path/filepath"
 
 	"passman/internal/server/services"
 
+	"github.com/gabriel-vasile/mimetype"
 	"github.com/google/uuid"
 )
 
+var errServiceNotFound = newClientError("service not found")
+
 type serviceUsecase struct {
 	repo    repository
 	saveDir string
@@ -55,6 +60,30 @@ func (su *serviceUsecase) GetAllServices(ctx context.Context) ([]services.Servic
 	return srvs, nil
 }
 
+// GetLogo reads the logo of the service. The content type is detected by the
+// content, so renamed files without extensions are served correctly.
+func (su *serviceUsecase) GetLogo(ctx context.Context, serviceName string) (services.LogoFile, error) {
+	// Only names of existing services are mapped to files
+	if _, err := su.repo.GetService(ctx, serviceName); err != nil {
+		if su.repo.IsEmptyRows(err) {
+			return services.LogoFile{}, errServiceNotFound
+		}
+		return services.LogoFile{}, newInternalError("GetLogo", "failed getting service", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(su.saveDir, serviceName))
+	if err != nil {
+		return services.LogoFile{}, newInternalError("GetLogo", "failed reading logo file", err)
+	}
+
+	sum := sha256.Sum256(data)
+	return services.LogoFile{
+		Data:        data,
+		ContentType: mimetype.Detect(data).String(),
+		ETag:        `"` + base64.RawURLEncoding.EncodeToString(sum[:]) + `"`,
+	}, nil
+}
+
 func (su *serviceUsecase) GetAllUserServices(ctx context.Context, userID uuid.UUID) ([]services.ServiceDTO, error) {
 	srvs, err := su.repo.GetAllUserServices(ctx, userID)
 	if err != nil && !su.repo.IsEmptyRows(err) {
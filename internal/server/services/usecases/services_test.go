@@ -344,3 +344,83 @@ func TestRemoveService(t *testing.T) {
 		})
 	}
 }
+
+func TestGetLogo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	saveDir := t.TempDir()
+	mockRepo := mock_usecases.NewMockrepository(ctrl)
+	serviceUsecase := New(mockRepo, saveDir)
+	ctx := context.Background()
+	errEmptyRows := errors.New("empty rows")
+
+	svgLogo := `<svg xmlns="http://www.w3.org/2000/svg" width="1" height="1"></svg>`
+	pngLogo := "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+
+	tests := []struct {
+		name           string
+		logo           *string
+		getServiceErr  error
+		expContentType string
+		expResult      error
+	}{
+		{
+			name:          "service_not_found",
+			getServiceErr: errEmptyRows,
+			expResult:     errors.New("ClientError: service not found"),
+		},
+		{
+			name:          "failed_getting_service",
+			getServiceErr: errors.New("internal error"),
+			expResult:     errors.New("GetLogo: failed getting service"),
+		},
+		{
+			name:      "missing_file",
+			expResult: errors.New("GetLogo: failed reading logo file"),
+		},
+		{
+			name:           "svg",
+			logo:           &svgLogo,
+			expContentType: "image/svg+xml",
+		},
+		{
+			name:           "png",
+			logo:           &pngLogo,
+			expContentType: "image/png",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			serviceName := "service" + test.name
+			if test.logo != nil {
+				if err := os.WriteFile(filepath.Join(saveDir, serviceName), []byte(*test.logo), 0o664); err != nil {
+					t.Fatalf("Unexpected error with creation logo test file: %v", err)
+				}
+			}
+
+			mockRepo.EXPECT().GetService(ctx, serviceName).Return(services.Service{Name: serviceName}, test.getServiceErr).Times(1)
+			if test.getServiceErr != nil {
+				mockRepo.EXPECT().IsEmptyRows(test.getServiceErr).Return(test.getServiceErr == errEmptyRows).Times(1)
+			}
+
+			logo, actErr := serviceUsecase.GetLogo(ctx, serviceName)
+
+			if got, want := actErr, test.expResult; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if got, want := logo.ContentType, test.expContentType; got != want {
+				t.Errorf("Wrong! Unexpected content type!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if test.logo != nil {
+				if got, want := string(logo.Data), *test.logo; got != want {
+					t.Errorf("Wrong! Mismatch logo!\n\tExpected: %s\n\tActual: %s", want, got)
+				}
+				if !strings.HasPrefix(logo.ETag, `"`) || !strings.HasSuffix(logo.ETag, `"`) || len(logo.ETag) < 3 {
+					t.Errorf("Wrong! Invalid ETag: %s", logo.ETag)
+				}
+			}
+		})
+	}
+}
@@ -4,63 +4,661 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"passman/internal/server/users"
 	"passman/internal/server/users/adapters/db/queries"
 
 	"github.com/google/uuid"
+	"github.com/mattn/go-sqlite3"
 )
 
+type txKey struct{}
+
 type Adapter struct {
+	db      *sql.DB
 	storage *queries.Queries
 }
 
-func New(st queries.DBTX) *Adapter {
-	return &Adapter{storage: queries.New(st)}
+func New(db *sql.DB) *Adapter {
+	return &Adapter{db: db, storage: queries.New(db)}
+}
+
+// WithinTx runs fn in one transaction. Every adapter call made with txCtx
+// is executed inside of it.
+func (a *Adapter) WithinTx(ctx context.Context, fn func(txCtx context.Context) error) (err error) {
+	sqlTx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed starting transaction: %w", err)
+	}
+	defer func() {
+		rollbackErr := sqlTx.Rollback()
+		if rollbackErr != nil && !errors.Is(rollbackErr, sql.ErrTxDone) {
+			err = errors.Join(err, rollbackErr)
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, a.storage.WithTx(sqlTx))); err != nil {
+		return err
+	}
+
+	return sqlTx.Commit()
+}
+
+func (a *Adapter) queries(ctx context.Context) *queries.Queries {
+	if tx, ok := ctx.Value(txKey{}).(*queries.Queries); ok {
+		return tx
+	}
+	return a.storage
 }
 
 func (a *Adapter) AddUser(ctx context.Context, userCreds users.User) error {
-	return a.storage.AddUser(
-		ctx,
-		queries.AddUserParams{
-			ID:       userCreds.ID,
-			Username: userCreds.Username,
-			Password: userCreds.Password,
-		},
-	)
+	params := queries.AddUserParams{
+		ID:       userCreds.ID,
+		Username: userCreds.Username,
+		Password: userCreds.Password,
+	}
+	if !userCreds.CreatedAt.IsZero() {
+		params.CreatedAt = userCreds.CreatedAt.Unix()
+	}
+	return a.queries(ctx).AddUser(ctx, params)
 }
 
 func (a *Adapter) GetUser(ctx context.Context, username string) (users.User, error) {
-	row, err := a.storage.GetUser(ctx, username)
+	row, err := a.queries(ctx).GetUser(ctx, username)
 	if err != nil {
 		return users.User{}, err
 	}
-	return users.User{ID: row.ID, Username: username, Password: row.Password}, nil
+	return users.User{
+		ID:                    row.ID,
+		Username:              username,
+		Password:              row.Password,
+		IsAdmin:               row.IsAdmin,
+		Disabled:              row.Disabled,
+		PasswordResetRequired: row.PasswordResetRequired,
+		Profile: users.Profile{
+			DisplayName: row.DisplayName,
+			Email:       row.Email,
+			Locale:      row.Locale,
+			Timezone:    row.Timezone,
+		},
+		CreatedAt:   unixOrZero(row.CreatedAt),
+		LastLoginAt: unixOrZero(row.LastLoginAt),
+		DeleteAt:    unixOrZero(row.DeleteAt),
+	}, nil
 }
 
 func (a *Adapter) GetUserByID(ctx context.Context, userID uuid.UUID) (users.User, error) {
-	row, err := a.storage.GetUserByID(ctx, userID)
+	row, err := a.queries(ctx).GetUserByID(ctx, userID)
 	if err != nil {
 		return users.User{}, err
 	}
-	return users.User{ID: userID, Username: row.Username, Password: row.Password}, nil
+	return users.User{
+		ID:                    userID,
+		Username:              row.Username,
+		Password:              row.Password,
+		IsAdmin:               row.IsAdmin,
+		Disabled:              row.Disabled,
+		PasswordResetRequired: row.PasswordResetRequired,
+		Profile: users.Profile{
+			DisplayName: row.DisplayName,
+			Email:       row.Email,
+			Locale:      row.Locale,
+			Timezone:    row.Timezone,
+		},
+		CreatedAt:   unixOrZero(row.CreatedAt),
+		LastLoginAt: unixOrZero(row.LastLoginAt),
+		DeleteAt:    unixOrZero(row.DeleteAt),
+	}, nil
 }
 
 func (a *Adapter) UpdateUser(ctx context.Context, updatedUser users.User) error {
-	return a.storage.UpdateUser(
+	return a.queries(ctx).UpdateUser(
 		ctx,
 		queries.UpdateUserParams{
-			ID:       updatedUser.ID,
-			Username: updatedUser.Username,
-			Password: updatedUser.Password,
+			ID:                    updatedUser.ID,
+			Username:              updatedUser.Username,
+			Password:              updatedUser.Password,
+			PasswordResetRequired: updatedUser.PasswordResetRequired,
+		},
+	)
+}
+
+func (a *Adapter) UpdateProfile(ctx context.Context, userID uuid.UUID, profile users.Profile) error {
+	return a.queries(ctx).UpdateProfile(
+		ctx,
+		queries.UpdateProfileParams{
+			DisplayName: profile.DisplayName,
+			Email:       profile.Email,
+			Locale:      profile.Locale,
+			Timezone:    profile.Timezone,
+			ID:          userID,
+		},
+	)
+}
+
+func (a *Adapter) UpdateLastLogin(ctx context.Context, userID uuid.UUID, loginAt time.Time) error {
+	return a.queries(ctx).UpdateLastLogin(ctx, queries.UpdateLastLoginParams{LastLoginAt: loginAt.Unix(), ID: userID})
+}
+
+func (a *Adapter) UpdatePasswordHash(ctx context.Context, userID uuid.UUID, oldHash, newHash string) error {
+	return a.queries(ctx).UpdatePasswordHash(
+		ctx,
+		queries.UpdatePasswordHashParams{
+			ID:          userID,
+			Password:    newHash,
+			OldPassword: oldHash,
 		},
 	)
 }
 
 func (a *Adapter) RemoveUser(ctx context.Context, userID uuid.UUID) error {
-	return a.storage.RemoveUser(ctx, userID)
+	return a.queries(ctx).RemoveUser(ctx, userID)
+}
+
+func (a *Adapter) GetUsers(ctx context.Context) ([]users.UserSummary, error) {
+	rows, err := a.queries(ctx).GetUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]users.UserSummary, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, users.UserSummary{
+			ID:                    row.ID,
+			Username:              row.Username,
+			IsAdmin:               row.IsAdmin,
+			Disabled:              row.Disabled,
+			PasswordResetRequired: row.PasswordResetRequired,
+			DeleteAt:              unixOrZero(row.DeleteAt),
+			Accounts:              row.Accounts,
+			Sends:                 row.Sends,
+			StorageBytes:          row.StorageBytes,
+		})
+	}
+	return result, nil
+}
+
+// SetUserDisabled returns false if there is no such user
+func (a *Adapter) SetUserDisabled(ctx context.Context, userID uuid.UUID, disabled bool) (bool, error) {
+	affected, err := a.queries(ctx).SetUserDisabled(ctx, queries.SetUserDisabledParams{ID: userID, Disabled: disabled})
+	return affected > 0, err
+}
+
+// ScheduleDeletion returns false if there is no such user or the deletion is
+// already scheduled
+func (a *Adapter) ScheduleDeletion(ctx context.Context, userID uuid.UUID, deleteAt time.Time) (bool, error) {
+	affected, err := a.queries(ctx).ScheduleDeletion(ctx, queries.ScheduleDeletionParams{DeleteAt: deleteAt.Unix(), ID: userID})
+	return affected > 0, err
+}
+
+// CancelDeletion returns false if the deletion of the user isn't scheduled
+func (a *Adapter) CancelDeletion(ctx context.Context, userID uuid.UUID) (bool, error) {
+	affected, err := a.queries(ctx).CancelDeletion(ctx, userID)
+	return affected > 0, err
+}
+
+// GetDueDeletions returns users whose grace period is over by now
+func (a *Adapter) GetDueDeletions(ctx context.Context, now time.Time) ([]users.User, error) {
+	rows, err := a.queries(ctx).GetDueDeletions(ctx, now.Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]users.User, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, users.User{ID: row.ID, Username: row.Username})
+	}
+	return res, nil
+}
+
+// RemoveDeletedUser returns false if the user is restored or isn't due yet
+func (a *Adapter) RemoveDeletedUser(ctx context.Context, userID uuid.UUID, now time.Time) (bool, error) {
+	affected, err := a.queries(ctx).RemoveDeletedUser(ctx, queries.RemoveDeletedUserParams{ID: userID, DeleteAt: now.Unix()})
+	return affected > 0, err
+}
+
+// RequirePasswordReset returns false if there is no such user
+func (a *Adapter) RequirePasswordReset(ctx context.Context, userID uuid.UUID) (bool, error) {
+	affected, err := a.queries(ctx).RequirePasswordReset(ctx, userID)
+	return affected > 0, err
+}
+
+func (a *Adapter) GetTOTP(ctx context.Context, userID uuid.UUID) (users.TOTP, error) {
+	row, err := a.queries(ctx).GetTOTP(ctx, userID)
+	if err != nil {
+		return users.TOTP{}, err
+	}
+	return users.TOTP{UserID: userID, Secret: row.Secret, Enabled: row.Enabled, LastStep: row.LastStep}, nil
+}
+
+func (a *Adapter) SetTOTP(ctx context.Context, totp users.TOTP) error {
+	params := queries.SetTOTPParams{
+		UserID:   totp.UserID,
+		Secret:   totp.Secret,
+		Enabled:  totp.Enabled,
+		LastStep: totp.LastStep,
+	}
+	return a.queries(ctx).SetTOTP(ctx, params)
+}
+
+// UpdateTOTPStep saves the step of the accepted code. It returns false if
+// the same or a later step has already been used.
+func (a *Adapter) UpdateTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	affected, err := a.queries(ctx).UpdateTOTPStep(ctx, queries.UpdateTOTPStepParams{UserID: userID, LastStep: step})
+	return affected > 0, err
+}
+
+func (a *Adapter) RemoveTOTP(ctx context.Context, userID uuid.UUID) error {
+	return a.queries(ctx).RemoveTOTP(ctx, userID)
+}
+
+func (a *Adapter) AddRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	for _, codeHash := range codeHashes {
+		if err := a.queries(ctx).AddRecoveryCode(ctx, queries.AddRecoveryCodeParams{UserID: userID, CodeHash: codeHash}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *Adapter) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return a.queries(ctx).CountRecoveryCodes(ctx, userID)
+}
+
+// RemoveRecoveryCode consumes the recovery code. It returns false if there
+// is no such code.
+func (a *Adapter) RemoveRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	affected, err := a.queries(ctx).RemoveRecoveryCode(ctx, queries.RemoveRecoveryCodeParams{UserID: userID, CodeHash: codeHash})
+	return affected > 0, err
+}
+
+func (a *Adapter) RemoveRecoveryCodes(ctx context.Context, userID uuid.UUID) error {
+	return a.queries(ctx).RemoveRecoveryCodes(ctx, userID)
+}
+
+func (a *Adapter) AddWebAuthnCredential(ctx context.Context, cred users.WebAuthnCredential) error {
+	params := queries.AddWebAuthnCredentialParams{
+		ID:        cred.ID,
+		UserID:    cred.UserID,
+		Name:      cred.Name,
+		PublicKey: cred.PublicKey,
+		SignCount: int64(cred.SignCount),
+		CreatedAt: cred.CreatedAt.Unix(),
+	}
+	return a.queries(ctx).AddWebAuthnCredential(ctx, params)
+}
+
+func (a *Adapter) GetWebAuthnCredential(ctx context.Context, id []byte) (users.WebAuthnCredential, error) {
+	row, err := a.queries(ctx).GetWebAuthnCredential(ctx, id)
+	if err != nil {
+		return users.WebAuthnCredential{}, err
+	}
+
+	return users.WebAuthnCredential{
+		ID:         id,
+		UserID:     row.UserID,
+		Name:       row.Name,
+		PublicKey:  row.PublicKey,
+		SignCount:  uint32(row.SignCount),
+		CreatedAt:  time.Unix(row.CreatedAt, 0),
+		LastUsedAt: unixOrZero(row.LastUsedAt),
+	}, nil
+}
+
+func (a *Adapter) GetWebAuthnCredentials(ctx context.Context, userID uuid.UUID) ([]users.WebAuthnCredential, error) {
+	rows, err := a.queries(ctx).GetWebAuthnCredentials(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]users.WebAuthnCredential, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, users.WebAuthnCredential{
+			ID:         row.ID,
+			UserID:     userID,
+			Name:       row.Name,
+			PublicKey:  row.PublicKey,
+			SignCount:  uint32(row.SignCount),
+			CreatedAt:  time.Unix(row.CreatedAt, 0),
+			LastUsedAt: unixOrZero(row.LastUsedAt),
+		})
+	}
+	return res, nil
+}
+
+func (a *Adapter) UpdateWebAuthnSignCount(ctx context.Context, id []byte, signCount uint32, usedAt time.Time) error {
+	params := queries.UpdateWebAuthnSignCountParams{
+		SignCount:  int64(signCount),
+		LastUsedAt: usedAt.Unix(),
+		ID:         id,
+	}
+	return a.queries(ctx).UpdateWebAuthnSignCount(ctx, params)
+}
+
+// RemoveWebAuthnCredential returns false if the user has no such credential
+func (a *Adapter) RemoveWebAuthnCredential(ctx context.Context, userID uuid.UUID, id []byte) (bool, error) {
+	affected, err := a.queries(ctx).RemoveWebAuthnCredential(ctx, queries.RemoveWebAuthnCredentialParams{ID: id, UserID: userID})
+	return affected > 0, err
+}
+
+func (a *Adapter) RemoveWebAuthnCredentials(ctx context.Context, userID uuid.UUID) error {
+	return a.queries(ctx).RemoveWebAuthnCredentials(ctx, userID)
+}
+
+func (a *Adapter) GetLoginThrottle(ctx context.Context, kind, subject string) (users.LoginThrottle, error) {
+	row, err := a.queries(ctx).GetLoginThrottle(ctx, queries.GetLoginThrottleParams{Kind: kind, Subject: subject})
+	if err != nil {
+		return users.LoginThrottle{}, err
+	}
+
+	return users.LoginThrottle{
+		Kind:          kind,
+		Subject:       subject,
+		Failures:      row.Failures,
+		LockedUntil:   unixOrZero(row.LockedUntil),
+		LastFailureAt: time.Unix(row.LastFailureAt, 0),
+	}, nil
+}
+
+// AddLoginFailure counts the failure and returns the count of failures since
+// resetBefore
+func (a *Adapter) AddLoginFailure(ctx context.Context, kind, subject string, failedAt, resetBefore time.Time) (int64, error) {
+	params := queries.AddLoginFailureParams{
+		Kind:          kind,
+		Subject:       subject,
+		LastFailureAt: failedAt.Unix(),
+		ResetBefore:   resetBefore.Unix(),
+	}
+	return a.queries(ctx).AddLoginFailure(ctx, params)
+}
+
+func (a *Adapter) LockLogin(ctx context.Context, kind, subject string, until time.Time) error {
+	return a.queries(ctx).LockLogin(ctx, queries.LockLoginParams{LockedUntil: until.Unix(), Kind: kind, Subject: subject})
+}
+
+func (a *Adapter) RemoveLoginThrottle(ctx context.Context, kind, subject string) error {
+	return a.queries(ctx).RemoveLoginThrottle(ctx, queries.RemoveLoginThrottleParams{Kind: kind, Subject: subject})
+}
+
+// RemoveStaleLoginThrottles removes unlocked throttles without failures since before
+func (a *Adapter) RemoveStaleLoginThrottles(ctx context.Context, before, now time.Time) error {
+	params := queries.RemoveStaleLoginThrottlesParams{LastFailureAt: before.Unix(), LockedUntil: now.Unix()}
+	return a.queries(ctx).RemoveStaleLoginThrottles(ctx, params)
+}
+
+func (a *Adapter) GetLockedLogins(ctx context.Context, kind string, now time.Time) ([]users.LoginThrottle, error) {
+	rows, err := a.queries(ctx).GetLockedLogins(ctx, queries.GetLockedLoginsParams{Kind: kind, LockedUntil: now.Unix()})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]users.LoginThrottle, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, users.LoginThrottle{
+			Kind:          kind,
+			Subject:       row.Subject,
+			Failures:      row.Failures,
+			LockedUntil:   time.Unix(row.LockedUntil, 0),
+			LastFailureAt: time.Unix(row.LastFailureAt, 0),
+		})
+	}
+	return res, nil
+}
+
+func (a *Adapter) AddAccessToken(ctx context.Context, token users.AccessToken) error {
+	params := queries.AddAccessTokenParams{
+		ID:        token.ID,
+		UserID:    token.UserID,
+		Name:      token.Name,
+		TokenHash: token.Hash,
+		Scopes:    strings.Join(token.Scopes, " "),
+		CreatedAt: token.CreatedAt.Unix(),
+	}
+	if !token.ExpiresAt.IsZero() {
+		params.ExpiresAt = token.ExpiresAt.Unix()
+	}
+	return a.queries(ctx).AddAccessToken(ctx, params)
+}
+
+func (a *Adapter) GetAccessTokenByHash(ctx context.Context, hash []byte) (users.AccessToken, error) {
+	row, err := a.queries(ctx).GetAccessTokenByHash(ctx, hash)
+	if err != nil {
+		return users.AccessToken{}, err
+	}
+
+	return users.AccessToken{
+		ID:         row.ID,
+		UserID:     row.UserID,
+		Name:       row.Name,
+		Hash:       hash,
+		Scopes:     strings.Fields(row.Scopes),
+		CreatedAt:  time.Unix(row.CreatedAt, 0),
+		ExpiresAt:  unixOrZero(row.ExpiresAt),
+		LastUsedAt: unixOrZero(row.LastUsedAt),
+	}, nil
+}
+
+func (a *Adapter) GetAccessTokens(ctx context.Context, userID uuid.UUID) ([]users.AccessToken, error) {
+	rows, err := a.queries(ctx).GetAccessTokens(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]users.AccessToken, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, users.AccessToken{
+			ID:         row.ID,
+			UserID:     userID,
+			Name:       row.Name,
+			Scopes:     strings.Fields(row.Scopes),
+			CreatedAt:  time.Unix(row.CreatedAt, 0),
+			ExpiresAt:  unixOrZero(row.ExpiresAt),
+			LastUsedAt: unixOrZero(row.LastUsedAt),
+		})
+	}
+	return res, nil
+}
+
+func (a *Adapter) UpdateAccessTokenLastUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	return a.queries(ctx).UpdateAccessTokenLastUsed(ctx, queries.UpdateAccessTokenLastUsedParams{LastUsedAt: usedAt.Unix(), ID: id})
+}
+
+// RemoveAccessToken returns false if the user has no such token
+func (a *Adapter) RemoveAccessToken(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	affected, err := a.queries(ctx).RemoveAccessToken(ctx, queries.RemoveAccessTokenParams{ID: id, UserID: userID})
+	return affected > 0, err
+}
+
+func (a *Adapter) RemoveAccessTokens(ctx context.Context, userID uuid.UUID) error {
+	return a.queries(ctx).RemoveAccessTokens(ctx, userID)
+}
+
+func (a *Adapter) CountUsers(ctx context.Context) (int64, error) {
+	return a.queries(ctx).CountUsers(ctx)
+}
+
+func (a *Adapter) CountAdmins(ctx context.Context) (int64, error) {
+	return a.queries(ctx).CountAdmins(ctx)
+}
+
+func (a *Adapter) AddInvite(ctx context.Context, invite users.Invite) error {
+	return a.queries(ctx).AddInvite(ctx, queries.AddInviteParams{
+		ID:        invite.ID,
+		TokenHash: invite.Hash,
+		Username:  invite.Username,
+		CreatedBy: invite.CreatedBy,
+		CreatedAt: invite.CreatedAt.Unix(),
+		ExpiresAt: invite.ExpiresAt.Unix(),
+	})
+}
+
+func (a *Adapter) GetInviteByHash(ctx context.Context, hash []byte) (users.Invite, error) {
+	row, err := a.queries(ctx).GetInviteByHash(ctx, hash)
+	if err != nil {
+		return users.Invite{}, err
+	}
+
+	return users.Invite{
+		ID:        row.ID,
+		Hash:      hash,
+		Username:  row.Username,
+		CreatedBy: row.CreatedBy,
+		CreatedAt: time.Unix(row.CreatedAt, 0),
+		ExpiresAt: time.Unix(row.ExpiresAt, 0),
+		UsedAt:    unixOrZero(row.UsedAt),
+		UsedBy:    row.UsedBy,
+	}, nil
+}
+
+func (a *Adapter) GetInvites(ctx context.Context) ([]users.Invite, error) {
+	rows, err := a.queries(ctx).GetInvites(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]users.Invite, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, users.Invite{
+			ID:        row.ID,
+			Username:  row.Username,
+			CreatedBy: row.CreatedBy,
+			CreatedAt: time.Unix(row.CreatedAt, 0),
+			ExpiresAt: time.Unix(row.ExpiresAt, 0),
+			UsedAt:    unixOrZero(row.UsedAt),
+			UsedBy:    row.UsedBy,
+		})
+	}
+	return res, nil
+}
+
+// UseInvite returns false if the invite is already used
+func (a *Adapter) UseInvite(ctx context.Context, id uuid.UUID, username string, usedAt time.Time) (bool, error) {
+	affected, err := a.queries(ctx).UseInvite(ctx, queries.UseInviteParams{UsedAt: usedAt.Unix(), UsedBy: username, ID: id})
+	return affected > 0, err
+}
+
+// RemoveInvite returns false if there is no such invite
+func (a *Adapter) RemoveInvite(ctx context.Context, id uuid.UUID) (bool, error) {
+	affected, err := a.queries(ctx).RemoveInvite(ctx, id)
+	return affected > 0, err
+}
+
+func (a *Adapter) SetUserAdmin(ctx context.Context, userID uuid.UUID, isAdmin bool) error {
+	return a.queries(ctx).SetUserAdmin(ctx, queries.SetUserAdminParams{ID: userID, IsAdmin: isAdmin})
+}
+
+func (a *Adapter) AddIdentity(ctx context.Context, identity users.Identity) error {
+	return a.queries(ctx).AddIdentity(
+		ctx,
+		queries.AddIdentityParams{
+			Issuer:    identity.Issuer,
+			Subject:   identity.Subject,
+			UserID:    identity.UserID,
+			CreatedAt: identity.CreatedAt.Unix(),
+		},
+	)
+}
+
+func (a *Adapter) GetIdentityUserID(ctx context.Context, issuer, subject string) (uuid.UUID, error) {
+	return a.queries(ctx).GetIdentityUserID(ctx, queries.GetIdentityUserIDParams{Issuer: issuer, Subject: subject})
+}
+
+func (a *Adapter) GetUserIdentity(ctx context.Context, userID uuid.UUID, issuer string) (users.Identity, error) {
+	row, err := a.queries(ctx).GetUserIdentity(ctx, queries.GetUserIdentityParams{UserID: userID, Issuer: issuer})
+	if err != nil {
+		return users.Identity{}, err
+	}
+
+	return users.Identity{
+		Issuer:    issuer,
+		Subject:   row.Subject,
+		UserID:    userID,
+		CreatedAt: time.Unix(row.CreatedAt, 0),
+	}, nil
+}
+
+// RemoveIdentity returns false if the user has no identity of the issuer
+func (a *Adapter) RemoveIdentity(ctx context.Context, userID uuid.UUID, issuer string) (bool, error) {
+	affected, err := a.queries(ctx).RemoveIdentity(ctx, queries.RemoveIdentityParams{UserID: userID, Issuer: issuer})
+	return affected > 0, err
+}
+
+func (a *Adapter) SetRecoveryKey(ctx context.Context, key users.RecoveryKey) error {
+	return a.queries(ctx).SetRecoveryKey(
+		ctx,
+		queries.SetRecoveryKeyParams{
+			UserID:    key.UserID,
+			KeyHash:   key.Hash,
+			CreatedAt: key.CreatedAt.Unix(),
+		},
+	)
+}
+
+func (a *Adapter) GetRecoveryKey(ctx context.Context, userID uuid.UUID) (users.RecoveryKey, error) {
+	row, err := a.queries(ctx).GetRecoveryKey(ctx, userID)
+	if err != nil {
+		return users.RecoveryKey{}, err
+	}
+
+	return users.RecoveryKey{
+		UserID:    userID,
+		Hash:      row.KeyHash,
+		CreatedAt: time.Unix(row.CreatedAt, 0),
+	}, nil
+}
+
+func (a *Adapter) AddAuditEvent(ctx context.Context, event users.AuditEvent) error {
+	return a.queries(ctx).AddAuditEvent(
+		ctx,
+		queries.AddAuditEventParams{
+			ID:             event.ID,
+			Action:         event.Action,
+			ActorID:        event.ActorID,
+			ActorUsername:  event.ActorUsername,
+			TargetID:       event.TargetID,
+			TargetUsername: event.TargetUsername,
+			CreatedAt:      event.CreatedAt.Unix(),
+		},
+	)
+}
+
+func (a *Adapter) GetAuditEvents(ctx context.Context) ([]users.AuditEvent, error) {
+	rows, err := a.queries(ctx).GetAuditEvents(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]users.AuditEvent, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, users.AuditEvent{
+			ID:             row.ID,
+			Action:         row.Action,
+			ActorID:        row.ActorID,
+			ActorUsername:  row.ActorUsername,
+			TargetID:       row.TargetID,
+			TargetUsername: row.TargetUsername,
+			CreatedAt:      time.Unix(row.CreatedAt, 0),
+		})
+	}
+	return res, nil
 }
 
 func (a *Adapter) IsEmptyRows(err error) bool {
 	return errors.Is(err, sql.ErrNoRows)
 }
+
+func (a *Adapter) IsUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+// unixOrZero keeps zero time for never set timestamps
+func unixOrZero(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
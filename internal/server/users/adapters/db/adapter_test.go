@@ -0,0 +1,227 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"passman/internal/server/users"
+
+	"github.com/google/uuid"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const migrationsDir = "../../../../../migrations"
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "data.db"))
+	if err != nil {
+		t.Fatalf("Wrong! Unexpected error: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	// Migrations are applied in order of their versions like the migrator does
+	for version := 1; ; version++ {
+		files, err := filepath.Glob(filepath.Join(migrationsDir, fmt.Sprintf("%d_*.up.sql", version)))
+		if err != nil {
+			t.Fatalf("Wrong! Unexpected error: %v", err)
+		}
+		if len(files) == 0 {
+			break
+		}
+
+		schema, err := os.ReadFile(files[0])
+		if err != nil {
+			t.Fatalf("Wrong! Unexpected error: %v", err)
+		}
+		if _, err := db.Exec(string(schema)); err != nil {
+			t.Fatalf("Wrong! Unexpected error in %s: %v", files[0], err)
+		}
+	}
+
+	return db
+}
+
+// addTestAccount adds the account of the user with the share to another user
+func addTestAccount(t *testing.T, db *sql.DB, userID, granteeID uuid.UUID) uuid.UUID {
+	t.Helper()
+
+	serviceID, accountID := uuid.New(), uuid.New()
+	queries := []struct {
+		query string
+		args  []any
+	}{
+		{query: "insert into services (id, name, logo) values (?, ?, ?)", args: []any{serviceID, serviceID.String(), serviceID.String()}},
+		{query: "insert into accounts (id, user_id, service_id, name, secret, payload) values (?, ?, ?, 'account', 0, '')", args: []any{accountID, userID, serviceID}},
+		{query: "insert into account_shares (account_id, grantee_id, permission) values (?, ?, 'read')", args: []any{accountID, granteeID}},
+	}
+	for _, q := range queries {
+		if _, err := db.Exec(q.query, q.args...); err != nil {
+			t.Fatalf("Wrong! Unexpected error: %v", err)
+		}
+	}
+
+	return accountID
+}
+
+func countRows(t *testing.T, db *sql.DB, query string, args ...any) int {
+	t.Helper()
+
+	var count int
+	if err := db.QueryRow(query, args...).Scan(&count); err != nil {
+		t.Fatalf("Wrong! Unexpected error: %v", err)
+	}
+	return count
+}
+
+func TestRemoveUser(t *testing.T) {
+	db := newTestDB(t)
+	adapter := New(db)
+	ctx := context.Background()
+
+	user := users.User{ID: uuid.New(), Username: "user1", Password: "hash"}
+	other := users.User{ID: uuid.New(), Username: "user2", Password: "hash"}
+	for _, u := range []users.User{user, other} {
+		if err := adapter.AddUser(ctx, u); err != nil {
+			t.Fatalf("Wrong! Unexpected error: %v", err)
+		}
+	}
+	accountID := addTestAccount(t, db, user.ID, other.ID)
+	otherAccountID := addTestAccount(t, db, other.ID, user.ID)
+
+	if err := adapter.RemoveUser(ctx, user.ID); err != nil {
+		t.Fatalf("Wrong! Unexpected error: %v", err)
+	}
+
+	if got, want := countRows(t, db, "select count(*) from accounts where user_id = ?", user.ID), 0; got != want {
+		t.Errorf("Wrong! Unexpected accounts of the removed user!\n\tExpected: %d\n\tActual: %d", want, got)
+	}
+	if got, want := countRows(t, db, "select count(*) from account_shares where account_id = ?", accountID), 0; got != want {
+		t.Errorf("Wrong! Unexpected shares of the removed accounts!\n\tExpected: %d\n\tActual: %d", want, got)
+	}
+	if got, want := countRows(t, db, "select count(*) from accounts where id = ?", otherAccountID), 1; got != want {
+		t.Errorf("Wrong! Unexpected accounts of another user!\n\tExpected: %d\n\tActual: %d", want, got)
+	}
+}
+
+func TestRemoveDeletedUser(t *testing.T) {
+	db := newTestDB(t)
+	adapter := New(db)
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+
+	user := users.User{ID: uuid.New(), Username: "user1", Password: "hash"}
+	other := users.User{ID: uuid.New(), Username: "user2", Password: "hash"}
+	for _, u := range []users.User{user, other} {
+		if err := adapter.AddUser(ctx, u); err != nil {
+			t.Fatalf("Wrong! Unexpected error: %v", err)
+		}
+	}
+	accountID := addTestAccount(t, db, user.ID, other.ID)
+
+	if _, err := adapter.ScheduleDeletion(ctx, user.ID, now); err != nil {
+		t.Fatalf("Wrong! Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		now         time.Time
+		expRemoved  bool
+		expAccounts int
+	}{
+		{
+			name:        "not_due",
+			now:         now.Add(-time.Second),
+			expAccounts: 1,
+		},
+		{
+			name:       "purged",
+			now:        now,
+			expRemoved: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			removed, err := adapter.RemoveDeletedUser(ctx, user.ID, test.now)
+			if err != nil {
+				t.Fatalf("Wrong! Unexpected error: %v", err)
+			}
+
+			if got, want := removed, test.expRemoved; got != want {
+				t.Errorf("Wrong! Unexpected removal!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if got, want := countRows(t, db, "select count(*) from accounts where user_id = ?", user.ID), test.expAccounts; got != want {
+				t.Errorf("Wrong! Unexpected accounts of the user!\n\tExpected: %d\n\tActual: %d", want, got)
+			}
+			if got, want := countRows(t, db, "select count(*) from account_shares where account_id = ?", accountID), test.expAccounts; got != want {
+				t.Errorf("Wrong! Unexpected shares of the accounts!\n\tExpected: %d\n\tActual: %d", want, got)
+			}
+		})
+	}
+}
+
+func TestCancelDeletion(t *testing.T) {
+	db := newTestDB(t)
+	adapter := New(db)
+	ctx := context.Background()
+	deleteAt := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name         string
+		adminDisable bool
+		expDisabled  bool
+	}{
+		{
+			name: "restored",
+		},
+		{
+			name:         "disabled_by_admin",
+			adminDisable: true,
+			expDisabled:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			user := users.User{ID: uuid.New(), Username: test.name, Password: "hash"}
+			if err := adapter.AddUser(ctx, user); err != nil {
+				t.Fatalf("Wrong! Unexpected error: %v", err)
+			}
+			if _, err := adapter.ScheduleDeletion(ctx, user.ID, deleteAt); err != nil {
+				t.Fatalf("Wrong! Unexpected error: %v", err)
+			}
+			// The admin disables the user during the grace period
+			if test.adminDisable {
+				if _, err := adapter.SetUserDisabled(ctx, user.ID, true); err != nil {
+					t.Fatalf("Wrong! Unexpected error: %v", err)
+				}
+			}
+
+			canceled, err := adapter.CancelDeletion(ctx, user.ID)
+			if err != nil {
+				t.Fatalf("Wrong! Unexpected error: %v", err)
+			}
+			if !canceled {
+				t.Fatalf("Wrong! Deletion isn't canceled!")
+			}
+
+			restored, err := adapter.GetUserByID(ctx, user.ID)
+			if err != nil {
+				t.Fatalf("Wrong! Unexpected error: %v", err)
+			}
+			if got, want := restored.Disabled, test.expDisabled; got != want {
+				t.Errorf("Wrong! Unexpected disabled!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if !restored.DeleteAt.IsZero() {
+				t.Errorf("Wrong! Unexpected delete at!\n\tExpected: zero\n\tActual: %v", restored.DeleteAt)
+			}
+		})
+	}
+}
@@ -11,53 +11,961 @@ import (
 	"github.com/google/uuid"
 )
 
+const addAccessToken = `-- name: AddAccessToken :exec
+insert into access_tokens (id, user_id, name, token_hash, scopes, created_at, expires_at) values (?, ?, ?, ?, ?, ?, ?)
+`
+
+type AddAccessTokenParams struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+	TokenHash []byte
+	Scopes    string
+	CreatedAt int64
+	ExpiresAt int64
+}
+
+func (q *Queries) AddAccessToken(ctx context.Context, arg AddAccessTokenParams) error {
+	_, err := q.db.ExecContext(ctx, addAccessToken,
+		arg.ID,
+		arg.UserID,
+		arg.Name,
+		arg.TokenHash,
+		arg.Scopes,
+		arg.CreatedAt,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const addAuditEvent = `-- name: AddAuditEvent :exec
+insert into audit_events (id, action, actor_id, actor_username, target_id, target_username, created_at) values (?, ?, ?, ?, ?, ?, ?)
+`
+
+type AddAuditEventParams struct {
+	ID             uuid.UUID
+	Action         string
+	ActorID        uuid.UUID
+	ActorUsername  string
+	TargetID       uuid.UUID
+	TargetUsername string
+	CreatedAt      int64
+}
+
+func (q *Queries) AddAuditEvent(ctx context.Context, arg AddAuditEventParams) error {
+	_, err := q.db.ExecContext(ctx, addAuditEvent,
+		arg.ID,
+		arg.Action,
+		arg.ActorID,
+		arg.ActorUsername,
+		arg.TargetID,
+		arg.TargetUsername,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const addIdentity = `-- name: AddIdentity :exec
+insert into user_identities (issuer, subject, user_id, created_at) values (?, ?, ?, ?)
+`
+
+type AddIdentityParams struct {
+	Issuer    string
+	Subject   string
+	UserID    uuid.UUID
+	CreatedAt int64
+}
+
+func (q *Queries) AddIdentity(ctx context.Context, arg AddIdentityParams) error {
+	_, err := q.db.ExecContext(ctx, addIdentity,
+		arg.Issuer,
+		arg.Subject,
+		arg.UserID,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const addInvite = `-- name: AddInvite :exec
+insert into invites (id, token_hash, username, created_by, created_at, expires_at) values (?, ?, ?, ?, ?, ?)
+`
+
+type AddInviteParams struct {
+	ID        uuid.UUID
+	TokenHash []byte
+	Username  string
+	CreatedBy uuid.UUID
+	CreatedAt int64
+	ExpiresAt int64
+}
+
+func (q *Queries) AddInvite(ctx context.Context, arg AddInviteParams) error {
+	_, err := q.db.ExecContext(ctx, addInvite,
+		arg.ID,
+		arg.TokenHash,
+		arg.Username,
+		arg.CreatedBy,
+		arg.CreatedAt,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const addLoginFailure = `-- name: AddLoginFailure :one
+insert into login_throttles (kind, subject, failures, last_failure_at) values (?1, ?2, 1, ?3)
+  on conflict (kind, subject) do update set
+    failures = case when login_throttles.last_failure_at < ?4 then 1 else login_throttles.failures + 1 end,
+    last_failure_at = excluded.last_failure_at
+  returning failures
+`
+
+type AddLoginFailureParams struct {
+	Kind          string
+	Subject       string
+	LastFailureAt int64
+	ResetBefore   int64
+}
+
+// Failures are counted from scratch if the last one is older than ?4
+func (q *Queries) AddLoginFailure(ctx context.Context, arg AddLoginFailureParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, addLoginFailure,
+		arg.Kind,
+		arg.Subject,
+		arg.LastFailureAt,
+		arg.ResetBefore,
+	)
+	var failures int64
+	err := row.Scan(&failures)
+	return failures, err
+}
+
+const addRecoveryCode = `-- name: AddRecoveryCode :exec
+insert into user_recovery_codes (user_id, code_hash) values (?, ?)
+`
+
+type AddRecoveryCodeParams struct {
+	UserID   uuid.UUID
+	CodeHash string
+}
+
+func (q *Queries) AddRecoveryCode(ctx context.Context, arg AddRecoveryCodeParams) error {
+	_, err := q.db.ExecContext(ctx, addRecoveryCode, arg.UserID, arg.CodeHash)
+	return err
+}
+
 const addUser = `-- name: AddUser :exec
-insert into users (id, username, password) values (?, ?, ?)
+insert into users (id, username, password, created_at, is_admin) values (?, ?, ?, ?, not exists (select 1 from users))
 `
 
 type AddUserParams struct {
-	ID       uuid.UUID
-	Username string
-	Password string
+	ID        uuid.UUID
+	Username  string
+	Password  string
+	CreatedAt int64
 }
 
+// The first registered user becomes the admin
 func (q *Queries) AddUser(ctx context.Context, arg AddUserParams) error {
-	_, err := q.db.ExecContext(ctx, addUser, arg.ID, arg.Username, arg.Password)
+	_, err := q.db.ExecContext(ctx, addUser,
+		arg.ID,
+		arg.Username,
+		arg.Password,
+		arg.CreatedAt,
+	)
 	return err
 }
 
+const addWebAuthnCredential = `-- name: AddWebAuthnCredential :exec
+insert into webauthn_credentials (id, user_id, name, public_key, sign_count, created_at) values (?, ?, ?, ?, ?, ?)
+`
+
+type AddWebAuthnCredentialParams struct {
+	ID        []byte
+	UserID    uuid.UUID
+	Name      string
+	PublicKey []byte
+	SignCount int64
+	CreatedAt int64
+}
+
+func (q *Queries) AddWebAuthnCredential(ctx context.Context, arg AddWebAuthnCredentialParams) error {
+	_, err := q.db.ExecContext(ctx, addWebAuthnCredential,
+		arg.ID,
+		arg.UserID,
+		arg.Name,
+		arg.PublicKey,
+		arg.SignCount,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const cancelDeletion = `-- name: CancelDeletion :execrows
+update users set delete_at = 0 where id = ? and delete_at > 0
+`
+
+// The user disabled by the admin stays disabled
+func (q *Queries) CancelDeletion(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, cancelDeletion, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countRecoveryCodes = `-- name: CountRecoveryCodes :one
+select count(*) from user_recovery_codes where user_id = ?
+`
+
+func (q *Queries) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countRecoveryCodes, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUsers = `-- name: CountUsers :one
+select count(*) from users
+`
+
+func (q *Queries) CountUsers(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUsers)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countAdmins = `-- name: CountAdmins :one
+select count(*) from users where is_admin and not disabled and delete_at = 0
+`
+
+func (q *Queries) CountAdmins(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAdmins)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getAccessTokenByHash = `-- name: GetAccessTokenByHash :one
+select t.id, t.user_id, t.name, t.scopes, t.created_at, t.expires_at, t.last_used_at from access_tokens t
+  join users u on u.id = t.user_id
+  where t.token_hash = ? and not u.disabled and u.delete_at = 0
+`
+
+type GetAccessTokenByHashRow struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Name       string
+	Scopes     string
+	CreatedAt  int64
+	ExpiresAt  int64
+	LastUsedAt int64
+}
+
+// Tokens of disabled users and users scheduled for deletion aren't found
+func (q *Queries) GetAccessTokenByHash(ctx context.Context, tokenHash []byte) (GetAccessTokenByHashRow, error) {
+	row := q.db.QueryRowContext(ctx, getAccessTokenByHash, tokenHash)
+	var i GetAccessTokenByHashRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Scopes,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const getAccessTokens = `-- name: GetAccessTokens :many
+select id, name, scopes, created_at, expires_at, last_used_at from access_tokens where user_id = ? order by created_at
+`
+
+type GetAccessTokensRow struct {
+	ID         uuid.UUID
+	Name       string
+	Scopes     string
+	CreatedAt  int64
+	ExpiresAt  int64
+	LastUsedAt int64
+}
+
+func (q *Queries) GetAccessTokens(ctx context.Context, userID uuid.UUID) ([]GetAccessTokensRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAccessTokens, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAccessTokensRow
+	for rows.Next() {
+		var i GetAccessTokensRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Scopes,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAuditEvents = `-- name: GetAuditEvents :many
+select id, action, actor_id, actor_username, target_id, target_username, created_at from audit_events order by created_at desc, rowid desc
+`
+
+type GetAuditEventsRow struct {
+	ID             uuid.UUID
+	Action         string
+	ActorID        uuid.UUID
+	ActorUsername  string
+	TargetID       uuid.UUID
+	TargetUsername string
+	CreatedAt      int64
+}
+
+func (q *Queries) GetAuditEvents(ctx context.Context) ([]GetAuditEventsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAuditEvents)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAuditEventsRow
+	for rows.Next() {
+		var i GetAuditEventsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Action,
+			&i.ActorID,
+			&i.ActorUsername,
+			&i.TargetID,
+			&i.TargetUsername,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDueDeletions = `-- name: GetDueDeletions :many
+select id, username from users where delete_at > 0 and delete_at <= ?
+`
+
+type GetDueDeletionsRow struct {
+	ID       uuid.UUID
+	Username string
+}
+
+func (q *Queries) GetDueDeletions(ctx context.Context, deleteAt int64) ([]GetDueDeletionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDueDeletions, deleteAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDueDeletionsRow
+	for rows.Next() {
+		var i GetDueDeletionsRow
+		if err := rows.Scan(&i.ID, &i.Username); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getIdentityUserID = `-- name: GetIdentityUserID :one
+select user_id from user_identities where issuer = ? and subject = ?
+`
+
+type GetIdentityUserIDParams struct {
+	Issuer  string
+	Subject string
+}
+
+func (q *Queries) GetIdentityUserID(ctx context.Context, arg GetIdentityUserIDParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, getIdentityUserID, arg.Issuer, arg.Subject)
+	var user_id uuid.UUID
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const getInviteByHash = `-- name: GetInviteByHash :one
+select id, username, created_by, created_at, expires_at, used_at, used_by from invites where token_hash = ?
+`
+
+type GetInviteByHashRow struct {
+	ID        uuid.UUID
+	Username  string
+	CreatedBy uuid.UUID
+	CreatedAt int64
+	ExpiresAt int64
+	UsedAt    int64
+	UsedBy    string
+}
+
+func (q *Queries) GetInviteByHash(ctx context.Context, tokenHash []byte) (GetInviteByHashRow, error) {
+	row := q.db.QueryRowContext(ctx, getInviteByHash, tokenHash)
+	var i GetInviteByHashRow
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.UsedBy,
+	)
+	return i, err
+}
+
+const getInvites = `-- name: GetInvites :many
+select id, username, created_by, created_at, expires_at, used_at, used_by from invites order by created_at desc
+`
+
+type GetInvitesRow struct {
+	ID        uuid.UUID
+	Username  string
+	CreatedBy uuid.UUID
+	CreatedAt int64
+	ExpiresAt int64
+	UsedAt    int64
+	UsedBy    string
+}
+
+func (q *Queries) GetInvites(ctx context.Context) ([]GetInvitesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getInvites)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetInvitesRow
+	for rows.Next() {
+		var i GetInvitesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.UsedAt,
+			&i.UsedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLockedLogins = `-- name: GetLockedLogins :many
+select subject, failures, locked_until, last_failure_at from login_throttles where kind = ? and locked_until > ? order by locked_until desc
+`
+
+type GetLockedLoginsParams struct {
+	Kind        string
+	LockedUntil int64
+}
+
+type GetLockedLoginsRow struct {
+	Subject       string
+	Failures      int64
+	LockedUntil   int64
+	LastFailureAt int64
+}
+
+func (q *Queries) GetLockedLogins(ctx context.Context, arg GetLockedLoginsParams) ([]GetLockedLoginsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getLockedLogins, arg.Kind, arg.LockedUntil)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLockedLoginsRow
+	for rows.Next() {
+		var i GetLockedLoginsRow
+		if err := rows.Scan(
+			&i.Subject,
+			&i.Failures,
+			&i.LockedUntil,
+			&i.LastFailureAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLoginThrottle = `-- name: GetLoginThrottle :one
+select failures, locked_until, last_failure_at from login_throttles where kind = ? and subject = ?
+`
+
+type GetLoginThrottleParams struct {
+	Kind    string
+	Subject string
+}
+
+type GetLoginThrottleRow struct {
+	Failures      int64
+	LockedUntil   int64
+	LastFailureAt int64
+}
+
+func (q *Queries) GetLoginThrottle(ctx context.Context, arg GetLoginThrottleParams) (GetLoginThrottleRow, error) {
+	row := q.db.QueryRowContext(ctx, getLoginThrottle, arg.Kind, arg.Subject)
+	var i GetLoginThrottleRow
+	err := row.Scan(&i.Failures, &i.LockedUntil, &i.LastFailureAt)
+	return i, err
+}
+
+const getRecoveryKey = `-- name: GetRecoveryKey :one
+select key_hash, created_at from user_recovery_keys where user_id = ?
+`
+
+type GetRecoveryKeyRow struct {
+	KeyHash   []byte
+	CreatedAt int64
+}
+
+func (q *Queries) GetRecoveryKey(ctx context.Context, userID uuid.UUID) (GetRecoveryKeyRow, error) {
+	row := q.db.QueryRowContext(ctx, getRecoveryKey, userID)
+	var i GetRecoveryKeyRow
+	err := row.Scan(&i.KeyHash, &i.CreatedAt)
+	return i, err
+}
+
+const getTOTP = `-- name: GetTOTP :one
+select secret, enabled, last_step from user_totp where user_id = ?
+`
+
+type GetTOTPRow struct {
+	Secret   string
+	Enabled  bool
+	LastStep int64
+}
+
+func (q *Queries) GetTOTP(ctx context.Context, userID uuid.UUID) (GetTOTPRow, error) {
+	row := q.db.QueryRowContext(ctx, getTOTP, userID)
+	var i GetTOTPRow
+	err := row.Scan(&i.Secret, &i.Enabled, &i.LastStep)
+	return i, err
+}
+
 const getUser = `-- name: GetUser :one
-select id, password from users where username = ?
+select id, password, is_admin, disabled, password_reset_required, display_name, email, locale, timezone, created_at, last_login_at, delete_at
+from users where username = ?
 `
 
 type GetUserRow struct {
-	ID       uuid.UUID
-	Password string
+	ID                    uuid.UUID
+	Password              string
+	IsAdmin               bool
+	Disabled              bool
+	PasswordResetRequired bool
+	DisplayName           string
+	Email                 string
+	Locale                string
+	Timezone              string
+	CreatedAt             int64
+	LastLoginAt           int64
+	DeleteAt              int64
 }
 
 func (q *Queries) GetUser(ctx context.Context, username string) (GetUserRow, error) {
 	row := q.db.QueryRowContext(ctx, getUser, username)
 	var i GetUserRow
-	err := row.Scan(&i.ID, &i.Password)
+	err := row.Scan(
+		&i.ID,
+		&i.Password,
+		&i.IsAdmin,
+		&i.Disabled,
+		&i.PasswordResetRequired,
+		&i.DisplayName,
+		&i.Email,
+		&i.Locale,
+		&i.Timezone,
+		&i.CreatedAt,
+		&i.LastLoginAt,
+		&i.DeleteAt,
+	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-select username, password from users where id = ?
+select username, password, is_admin, disabled, password_reset_required, display_name, email, locale, timezone, created_at, last_login_at, delete_at
+from users where id = ?
 `
 
 type GetUserByIDRow struct {
-	Username string
-	Password string
+	Username              string
+	Password              string
+	IsAdmin               bool
+	Disabled              bool
+	PasswordResetRequired bool
+	DisplayName           string
+	Email                 string
+	Locale                string
+	Timezone              string
+	CreatedAt             int64
+	LastLoginAt           int64
+	DeleteAt              int64
 }
 
 func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (GetUserByIDRow, error) {
 	row := q.db.QueryRowContext(ctx, getUserByID, id)
 	var i GetUserByIDRow
-	err := row.Scan(&i.Username, &i.Password)
+	err := row.Scan(
+		&i.Username,
+		&i.Password,
+		&i.IsAdmin,
+		&i.Disabled,
+		&i.PasswordResetRequired,
+		&i.DisplayName,
+		&i.Email,
+		&i.Locale,
+		&i.Timezone,
+		&i.CreatedAt,
+		&i.LastLoginAt,
+		&i.DeleteAt,
+	)
+	return i, err
+}
+
+const getUserIdentity = `-- name: GetUserIdentity :one
+select subject, created_at from user_identities where user_id = ? and issuer = ?
+`
+
+type GetUserIdentityParams struct {
+	UserID uuid.UUID
+	Issuer string
+}
+
+type GetUserIdentityRow struct {
+	Subject   string
+	CreatedAt int64
+}
+
+func (q *Queries) GetUserIdentity(ctx context.Context, arg GetUserIdentityParams) (GetUserIdentityRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserIdentity, arg.UserID, arg.Issuer)
+	var i GetUserIdentityRow
+	err := row.Scan(&i.Subject, &i.CreatedAt)
 	return i, err
 }
 
+const getUsers = `-- name: GetUsers :many
+select
+  u.id, u.username, u.is_admin, u.disabled, u.password_reset_required, u.delete_at,
+  (select count(*) from accounts where accounts.user_id = u.id) as accounts,
+  (select count(*) from sends where sends.user_id = u.id) as sends,
+  cast(
+    coalesce((select sum(length(payload)) from accounts where accounts.user_id = u.id), 0) +
+    coalesce((select sum(length(data)) from sends where sends.user_id = u.id), 0)
+  as integer) as storage_bytes
+from users u order by u.username
+`
+
+type GetUsersRow struct {
+	ID                    uuid.UUID
+	Username              string
+	IsAdmin               bool
+	Disabled              bool
+	PasswordResetRequired bool
+	DeleteAt              int64
+	Accounts              int64
+	Sends                 int64
+	StorageBytes          int64
+}
+
+func (q *Queries) GetUsers(ctx context.Context) ([]GetUsersRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUsersRow
+	for rows.Next() {
+		var i GetUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.IsAdmin,
+			&i.Disabled,
+			&i.PasswordResetRequired,
+			&i.DeleteAt,
+			&i.Accounts,
+			&i.Sends,
+			&i.StorageBytes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWebAuthnCredential = `-- name: GetWebAuthnCredential :one
+select user_id, name, public_key, sign_count, created_at, last_used_at from webauthn_credentials where id = ?
+`
+
+type GetWebAuthnCredentialRow struct {
+	UserID     uuid.UUID
+	Name       string
+	PublicKey  []byte
+	SignCount  int64
+	CreatedAt  int64
+	LastUsedAt int64
+}
+
+func (q *Queries) GetWebAuthnCredential(ctx context.Context, id []byte) (GetWebAuthnCredentialRow, error) {
+	row := q.db.QueryRowContext(ctx, getWebAuthnCredential, id)
+	var i GetWebAuthnCredentialRow
+	err := row.Scan(
+		&i.UserID,
+		&i.Name,
+		&i.PublicKey,
+		&i.SignCount,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const getWebAuthnCredentials = `-- name: GetWebAuthnCredentials :many
+select id, name, public_key, sign_count, created_at, last_used_at from webauthn_credentials where user_id = ? order by created_at
+`
+
+type GetWebAuthnCredentialsRow struct {
+	ID         []byte
+	Name       string
+	PublicKey  []byte
+	SignCount  int64
+	CreatedAt  int64
+	LastUsedAt int64
+}
+
+func (q *Queries) GetWebAuthnCredentials(ctx context.Context, userID uuid.UUID) ([]GetWebAuthnCredentialsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getWebAuthnCredentials, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetWebAuthnCredentialsRow
+	for rows.Next() {
+		var i GetWebAuthnCredentialsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.PublicKey,
+			&i.SignCount,
+			&i.CreatedAt,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockLogin = `-- name: LockLogin :exec
+update login_throttles set locked_until = ? where kind = ? and subject = ?
+`
+
+type LockLoginParams struct {
+	LockedUntil int64
+	Kind        string
+	Subject     string
+}
+
+func (q *Queries) LockLogin(ctx context.Context, arg LockLoginParams) error {
+	_, err := q.db.ExecContext(ctx, lockLogin, arg.LockedUntil, arg.Kind, arg.Subject)
+	return err
+}
+
+const removeAccessToken = `-- name: RemoveAccessToken :execrows
+delete from access_tokens where id = ? and user_id = ?
+`
+
+type RemoveAccessTokenParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) RemoveAccessToken(ctx context.Context, arg RemoveAccessTokenParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, removeAccessToken, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const removeAccessTokens = `-- name: RemoveAccessTokens :exec
+delete from access_tokens where user_id = ?
+`
+
+func (q *Queries) RemoveAccessTokens(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, removeAccessTokens, userID)
+	return err
+}
+
+const removeDeletedUser = `-- name: RemoveDeletedUser :execrows
+delete from users where id = ? and delete_at > 0 and delete_at <= ?
+`
+
+type RemoveDeletedUserParams struct {
+	ID       uuid.UUID
+	DeleteAt int64
+}
+
+// The user restored in the meantime isn't removed
+func (q *Queries) RemoveDeletedUser(ctx context.Context, arg RemoveDeletedUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, removeDeletedUser, arg.ID, arg.DeleteAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const removeIdentity = `-- name: RemoveIdentity :execrows
+delete from user_identities where user_id = ? and issuer = ?
+`
+
+type RemoveIdentityParams struct {
+	UserID uuid.UUID
+	Issuer string
+}
+
+func (q *Queries) RemoveIdentity(ctx context.Context, arg RemoveIdentityParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, removeIdentity, arg.UserID, arg.Issuer)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const removeInvite = `-- name: RemoveInvite :execrows
+delete from invites where id = ?
+`
+
+func (q *Queries) RemoveInvite(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, removeInvite, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const removeLoginThrottle = `-- name: RemoveLoginThrottle :exec
+delete from login_throttles where kind = ? and subject = ?
+`
+
+type RemoveLoginThrottleParams struct {
+	Kind    string
+	Subject string
+}
+
+func (q *Queries) RemoveLoginThrottle(ctx context.Context, arg RemoveLoginThrottleParams) error {
+	_, err := q.db.ExecContext(ctx, removeLoginThrottle, arg.Kind, arg.Subject)
+	return err
+}
+
+const removeRecoveryCode = `-- name: RemoveRecoveryCode :execrows
+delete from user_recovery_codes where user_id = ? and code_hash = ?
+`
+
+type RemoveRecoveryCodeParams struct {
+	UserID   uuid.UUID
+	CodeHash string
+}
+
+func (q *Queries) RemoveRecoveryCode(ctx context.Context, arg RemoveRecoveryCodeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, removeRecoveryCode, arg.UserID, arg.CodeHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const removeRecoveryCodes = `-- name: RemoveRecoveryCodes :exec
+delete from user_recovery_codes where user_id = ?
+`
+
+func (q *Queries) RemoveRecoveryCodes(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, removeRecoveryCodes, userID)
+	return err
+}
+
+const removeStaleLoginThrottles = `-- name: RemoveStaleLoginThrottles :exec
+delete from login_throttles where last_failure_at < ?1 and locked_until < ?2
+`
+
+type RemoveStaleLoginThrottlesParams struct {
+	LastFailureAt int64
+	LockedUntil   int64
+}
+
+func (q *Queries) RemoveStaleLoginThrottles(ctx context.Context, arg RemoveStaleLoginThrottlesParams) error {
+	_, err := q.db.ExecContext(ctx, removeStaleLoginThrottles, arg.LastFailureAt, arg.LockedUntil)
+	return err
+}
+
+const removeTOTP = `-- name: RemoveTOTP :exec
+delete from user_totp where user_id = ?
+`
+
+func (q *Queries) RemoveTOTP(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, removeTOTP, userID)
+	return err
+}
+
 const removeUser = `-- name: RemoveUser :exec
 delete from users where id = ?
 `
@@ -67,17 +975,265 @@ func (q *Queries) RemoveUser(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const removeWebAuthnCredential = `-- name: RemoveWebAuthnCredential :execrows
+delete from webauthn_credentials where id = ? and user_id = ?
+`
+
+type RemoveWebAuthnCredentialParams struct {
+	ID     []byte
+	UserID uuid.UUID
+}
+
+func (q *Queries) RemoveWebAuthnCredential(ctx context.Context, arg RemoveWebAuthnCredentialParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, removeWebAuthnCredential, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const removeWebAuthnCredentials = `-- name: RemoveWebAuthnCredentials :exec
+delete from webauthn_credentials where user_id = ?
+`
+
+func (q *Queries) RemoveWebAuthnCredentials(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, removeWebAuthnCredentials, userID)
+	return err
+}
+
+const requirePasswordReset = `-- name: RequirePasswordReset :execrows
+update users set password_reset_required = true where id = ?
+`
+
+func (q *Queries) RequirePasswordReset(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, requirePasswordReset, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const scheduleDeletion = `-- name: ScheduleDeletion :execrows
+update users set delete_at = ? where id = ? and delete_at = 0
+`
+
+type ScheduleDeletionParams struct {
+	DeleteAt int64
+	ID       uuid.UUID
+}
+
+func (q *Queries) ScheduleDeletion(ctx context.Context, arg ScheduleDeletionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, scheduleDeletion, arg.DeleteAt, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setRecoveryKey = `-- name: SetRecoveryKey :exec
+insert into user_recovery_keys (user_id, key_hash, created_at) values (?, ?, ?)
+on conflict (user_id) do update set key_hash = excluded.key_hash, created_at = excluded.created_at
+`
+
+type SetRecoveryKeyParams struct {
+	UserID    uuid.UUID
+	KeyHash   []byte
+	CreatedAt int64
+}
+
+func (q *Queries) SetRecoveryKey(ctx context.Context, arg SetRecoveryKeyParams) error {
+	_, err := q.db.ExecContext(ctx, setRecoveryKey, arg.UserID, arg.KeyHash, arg.CreatedAt)
+	return err
+}
+
+const setTOTP = `-- name: SetTOTP :exec
+insert into user_totp (user_id, secret, enabled, last_step) values (?, ?, ?, ?)
+  on conflict (user_id) do update set secret = excluded.secret, enabled = excluded.enabled, last_step = excluded.last_step
+`
+
+type SetTOTPParams struct {
+	UserID   uuid.UUID
+	Secret   string
+	Enabled  bool
+	LastStep int64
+}
+
+func (q *Queries) SetTOTP(ctx context.Context, arg SetTOTPParams) error {
+	_, err := q.db.ExecContext(ctx, setTOTP,
+		arg.UserID,
+		arg.Secret,
+		arg.Enabled,
+		arg.LastStep,
+	)
+	return err
+}
+
+const setUserAdmin = `-- name: SetUserAdmin :exec
+update users set is_admin = ? where id = ?
+`
+
+type SetUserAdminParams struct {
+	IsAdmin bool
+	ID      uuid.UUID
+}
+
+func (q *Queries) SetUserAdmin(ctx context.Context, arg SetUserAdminParams) error {
+	_, err := q.db.ExecContext(ctx, setUserAdmin, arg.IsAdmin, arg.ID)
+	return err
+}
+
+const setUserDisabled = `-- name: SetUserDisabled :execrows
+update users set disabled = ?1, delete_at = case when ?1 then delete_at else 0 end where id = ?2
+`
+
+type SetUserDisabledParams struct {
+	Disabled bool
+	ID       uuid.UUID
+}
+
+// Enabling the user cancels the scheduled deletion
+func (q *Queries) SetUserDisabled(ctx context.Context, arg SetUserDisabledParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setUserDisabled, arg.Disabled, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateAccessTokenLastUsed = `-- name: UpdateAccessTokenLastUsed :exec
+update access_tokens set last_used_at = ? where id = ?
+`
+
+type UpdateAccessTokenLastUsedParams struct {
+	LastUsedAt int64
+	ID         uuid.UUID
+}
+
+func (q *Queries) UpdateAccessTokenLastUsed(ctx context.Context, arg UpdateAccessTokenLastUsedParams) error {
+	_, err := q.db.ExecContext(ctx, updateAccessTokenLastUsed, arg.LastUsedAt, arg.ID)
+	return err
+}
+
+const updateLastLogin = `-- name: UpdateLastLogin :exec
+update users set last_login_at = ? where id = ?
+`
+
+type UpdateLastLoginParams struct {
+	LastLoginAt int64
+	ID          uuid.UUID
+}
+
+func (q *Queries) UpdateLastLogin(ctx context.Context, arg UpdateLastLoginParams) error {
+	_, err := q.db.ExecContext(ctx, updateLastLogin, arg.LastLoginAt, arg.ID)
+	return err
+}
+
+const updatePasswordHash = `-- name: UpdatePasswordHash :exec
+update users set password = ? where id = ? and password = ?3
+`
+
+type UpdatePasswordHashParams struct {
+	Password    string
+	ID          uuid.UUID
+	OldPassword string
+}
+
+// Rehashed password isn't saved if the password is changed concurrently
+func (q *Queries) UpdatePasswordHash(ctx context.Context, arg UpdatePasswordHashParams) error {
+	_, err := q.db.ExecContext(ctx, updatePasswordHash, arg.Password, arg.ID, arg.OldPassword)
+	return err
+}
+
+const updateProfile = `-- name: UpdateProfile :exec
+update users set display_name = ?, email = ?, locale = ?, timezone = ? where id = ?
+`
+
+type UpdateProfileParams struct {
+	DisplayName string
+	Email       string
+	Locale      string
+	Timezone    string
+	ID          uuid.UUID
+}
+
+func (q *Queries) UpdateProfile(ctx context.Context, arg UpdateProfileParams) error {
+	_, err := q.db.ExecContext(ctx, updateProfile,
+		arg.DisplayName,
+		arg.Email,
+		arg.Locale,
+		arg.Timezone,
+		arg.ID,
+	)
+	return err
+}
+
+const updateTOTPStep = `-- name: UpdateTOTPStep :execrows
+update user_totp set last_step = ?2 where user_id = ?1 and last_step < ?2
+`
+
+type UpdateTOTPStepParams struct {
+	UserID   uuid.UUID
+	LastStep int64
+}
+
+func (q *Queries) UpdateTOTPStep(ctx context.Context, arg UpdateTOTPStepParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateTOTPStep, arg.UserID, arg.LastStep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateUser = `-- name: UpdateUser :exec
-update users set username = ?, password = ? where id = ?
+update users set username = ?, password = ?, password_reset_required = ? where id = ?
 `
 
 type UpdateUserParams struct {
-	Username string
-	Password string
-	ID       uuid.UUID
+	Username              string
+	Password              string
+	PasswordResetRequired bool
+	ID                    uuid.UUID
 }
 
 func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) error {
-	_, err := q.db.ExecContext(ctx, updateUser, arg.Username, arg.Password, arg.ID)
+	_, err := q.db.ExecContext(ctx, updateUser,
+		arg.Username,
+		arg.Password,
+		arg.PasswordResetRequired,
+		arg.ID,
+	)
 	return err
 }
+
+const updateWebAuthnSignCount = `-- name: UpdateWebAuthnSignCount :exec
+update webauthn_credentials set sign_count = ?, last_used_at = ? where id = ?
+`
+
+type UpdateWebAuthnSignCountParams struct {
+	SignCount  int64
+	LastUsedAt int64
+	ID         []byte
+}
+
+func (q *Queries) UpdateWebAuthnSignCount(ctx context.Context, arg UpdateWebAuthnSignCountParams) error {
+	_, err := q.db.ExecContext(ctx, updateWebAuthnSignCount, arg.SignCount, arg.LastUsedAt, arg.ID)
+	return err
+}
+
+const useInvite = `-- name: UseInvite :execrows
+update invites set used_at = ?, used_by = ? where id = ? and used_at = 0
+`
+
+type UseInviteParams struct {
+	UsedAt int64
+	UsedBy string
+	ID     uuid.UUID
+}
+
+func (q *Queries) UseInvite(ctx context.Context, arg UseInviteParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useInvite, arg.UsedAt, arg.UsedBy, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -6,54 +6,149 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 
 	"passman/internal/server/infra"
 	"passman/internal/server/users"
+	"passman/pkg/webauthn"
 
 	"github.com/go-chi/chi/v5"
 	vldtr "github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 )
 
+const (
+	// Failed second factor codes allowed in one login
+	maxTwoFactorAttempts = 5
+	// Failed passwords allowed in one session by reauthentication
+	maxReauthAttempts  = 5
+	maxUserAgentLength = 256
+)
+
 type Adapter struct {
 	log     *slog.Logger
 	uu      userUsecase
+	ex      exporter
 	session sessionManager
 	v       *validator
+	oidc    OIDCConfig
 }
 
-func NewRouter(ua userUsecase, sm sessionManager, v *vldtr.Validate) chi.Router {
+// NewRouter of users, the exporter makes the final export of the deleted user
+func NewRouter(ua userUsecase, ex exporter, sm sessionManager, v *vldtr.Validate) chi.Router {
 	a := &Adapter{
 		log:     slog.Default(),
 		uu:      ua,
+		ex:      ex,
 		session: sm,
 		v:       newValidator(v),
 	}
 
 	router := chi.NewRouter()
 
+	router.Get("/registration", a.GetRegistrationMode)
 	router.Post("/registration", a.Registration)
 	router.Post("/login", a.Login)
+	router.Post("/login/totp", a.LoginTOTP)
+	router.Post("/webauthn/login/begin", a.BeginWebAuthnLogin)
+	router.Post("/webauthn/login/finish", a.FinishWebAuthnLogin)
 	router.Delete("/logout", a.Logout)
+	router.Post("/recovery", a.RecoverAccount)
+	router.Post("/restore", a.RestoreUser)
+	// The password change is the only action of users who have to reset it
+	router.With(infra.PasswordResetAuthMiddleware(sm)).Put("/update/password", a.UpdatePassword)
 
 	routerAuth := chi.NewRouter()
 
 	routerAuth.Use(infra.AuthMiddleware(sm))
 
+	routerAuth.Post("/reauth", a.Reauthenticate)
 	routerAuth.Put("/update/username", a.UpdateUsername)
-	routerAuth.Put("/update/password", a.UpdatePassword)
-	routerAuth.Delete("/delete", a.RemoveUser)
+	routerAuth.Get("/me", a.GetMe)
+	routerAuth.Put("/me", a.UpdateProfile)
+	routerAuth.Get("/2fa", a.GetTwoFactorStatus)
+	routerAuth.Get("/webauthn/credentials", a.GetWebAuthnCredentials)
+	routerAuth.Get("/tokens", a.GetAccessTokens)
+	routerAuth.Delete("/tokens/{tokenID}", a.RemoveAccessToken)
+	routerAuth.Get("/sessions", a.GetSessions)
+	routerAuth.Get("/recovery-key", a.GetRecoveryKey)
+	routerAuth.Delete("/sessions", a.RemoveAllSessions)
+	routerAuth.Delete("/sessions/{sessionID}", a.RemoveSession)
+
+	// Sensitive actions require the recent authentication
+	routerAuth.Group(func(routerRecent chi.Router) {
+		routerRecent.Use(infra.RecentAuthMiddleware(sm))
+
+		routerRecent.Post("/delete/webauthn", a.BeginDeletionWebAuthn)
+		routerRecent.Delete("/delete", a.RemoveUser)
+		routerRecent.Post("/2fa/totp", a.SetupTOTP)
+		routerRecent.Post("/2fa/totp/enable", a.EnableTOTP)
+		routerRecent.Delete("/2fa/totp", a.DisableTOTP)
+		routerRecent.Post("/2fa/recovery-codes", a.RegenerateRecoveryCodes)
+		routerRecent.Post("/recovery-key", a.RegenerateRecoveryKey)
+		routerRecent.Post("/webauthn/register/begin", a.BeginWebAuthnRegistration)
+		routerRecent.Post("/webauthn/register/finish", a.FinishWebAuthnRegistration)
+		routerRecent.Delete("/webauthn/credentials/{credentialID}", a.RemoveWebAuthnCredential)
+		routerRecent.Post("/tokens", a.CreateAccessToken)
+	})
 
 	router.Mount("/", routerAuth)
 
 	return router
 }
 
+// NewTokenMiddleware authenticates requests with "Authorization: Bearer"
+// access tokens. It has to run before the session middleware: cookies of token
+// requests are dropped, so the token never acts with the browser session.
+func NewTokenMiddleware(ua userUsecase) func(next http.Handler) http.Handler {
+	a := &Adapter{log: slog.Default(), uu: ua}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if len(header) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			scheme, plain, _ := strings.Cut(header, " ")
+			if !strings.EqualFold(scheme, "Bearer") || len(plain) == 0 {
+				infra.ErrorHandler(w, http.StatusUnauthorized, "invalid authorization header")
+				return
+			}
+
+			token, err := a.uu.VerifyAccessToken(r.Context(), plain)
+			if err != nil {
+				code, msg := a.ParseUsecaseError(r.Context(), "TokenMiddleware", err)
+				if code < http.StatusInternalServerError {
+					code = http.StatusUnauthorized
+				}
+				infra.ErrorHandler(w, code, msg)
+				return
+			}
+
+			ctx := infra.WithAccessToken(r.Context(), infra.AccessToken{UserID: token.UserID.String(), Scopes: token.Scopes})
+			r = r.Clone(ctx)
+			r.Header.Del("Cookie")
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (a *Adapter) GetRegistrationMode(w http.ResponseWriter, r *http.Request) {
+	infra.ResponseJSON(w, struct {
+		Mode string `json:"mode"`
+	}{Mode: a.uu.RegistrationMode()}, http.StatusOK)
+}
+
 func (a *Adapter) Registration(w http.ResponseWriter, r *http.Request) {
 	candidate := struct {
 		Username string `json:"username"`
 		Password string `json:"password"`
+		Invite   string `json:"invite"`
 	}{}
 	if err := json.NewDecoder(r.Body).Decode(&candidate); err != nil {
 		a.log.ErrorContext(r.Context(), "Registration: failed parsing body", slog.Any("error", err))
@@ -66,24 +161,30 @@ func (a *Adapter) Registration(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, err := a.uu.Registration(
+	userID, recoveryKey, err := a.uu.Registration(
 		r.Context(),
 		users.UserDTO{
 			Username: candidate.Username,
 			Password: candidate.Password,
 		},
+		candidate.Invite,
 	)
 	if err != nil {
+		if infra.WeakPasswordHandler(w, err) {
+			return
+		}
 		code, msg := a.ParseUsecaseError(r.Context(), "Registration", err)
 		infra.ErrorHandler(w, code, msg)
 		return
 	}
 
-	a.session.Put(r.Context(), "user_id", userID.String())
+	a.authenticate(r, userID)
 
+	// The recovery key is shown only once
 	infra.ResponseJSON(w, struct {
-		UserID string `json:"user_id"`
-	}{UserID: userID.String()}, http.StatusOK)
+		UserID      string `json:"user_id"`
+		RecoveryKey string `json:"recovery_key"`
+	}{UserID: userID.String(), RecoveryKey: recoveryKey}, http.StatusOK)
 }
 
 func (a *Adapter) Login(w http.ResponseWriter, r *http.Request) {
@@ -102,12 +203,13 @@ func (a *Adapter) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, err := a.uu.Login(
+	result, err := a.uu.Login(
 		r.Context(),
 		users.UserDTO{
 			Username: candidate.Username,
 			Password: candidate.Password,
 		},
+		infra.ClientIP(r),
 	)
 	if err != nil {
 		code, msg := a.ParseUsecaseError(r.Context(), "Login", err)
@@ -115,11 +217,196 @@ func (a *Adapter) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if methods := a.startLogin(r, result); len(methods) > 0 {
+		infra.ResponseJSON(w, struct {
+			TwoFactorRequired bool     `json:"two_factor_required"`
+			Methods           []string `json:"methods"`
+		}{TwoFactorRequired: true, Methods: methods}, http.StatusOK)
+		return
+	}
+
+	a.completeLogin(w, r, result.UserID)
+}
+
+// startLogin keeps the result of the primary login in the session and returns
+// methods of the second factor. If there are methods, the session isn't
+// authenticated until one of them is passed.
+func (a *Adapter) startLogin(r *http.Request, result users.LoginResult) []string {
+	// The flag survives the second factor, it's removed by the password change
+	if result.PasswordResetRequired {
+		a.session.Put(r.Context(), "password_reset_required", true)
+	} else {
+		a.session.Remove(r.Context(), "password_reset_required")
+	}
+
+	if !result.TwoFactorRequired() {
+		return nil
+	}
+
+	a.session.Remove(r.Context(), "user_id")
+	a.session.Put(r.Context(), "pending_user_id", result.UserID.String())
+	a.session.Put(r.Context(), "two_factor_attempts", 0)
+
+	methods := make([]string, 0, 2)
+	if result.TOTP {
+		methods = append(methods, "totp")
+	}
+	if result.WebAuthn {
+		methods = append(methods, "webauthn")
+	}
+	return methods
+}
+
+func (a *Adapter) LoginTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := a.twoFactorAttempt(w, r, "LoginTOTP")
+	if !ok {
+		return
+	}
+
+	body := struct {
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		a.log.ErrorContext(r.Context(), "LoginTOTP: failed parsing body", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	if err := a.v.ValidateTwoFactorCode(body.Code, body.RecoveryCode); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := a.uu.LoginTOTP(r.Context(), userID, body.Code, body.RecoveryCode, infra.ClientIP(r)); err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "LoginTOTP", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	a.completeLogin(w, r, userID)
+}
+
+// twoFactorAttempt returns the user waiting for the second factor and counts
+// the attempt. The session is destroyed after too many attempts.
+func (a *Adapter) twoFactorAttempt(w http.ResponseWriter, r *http.Request, component string) (uuid.UUID, bool) {
+	pendingUserID := a.session.GetString(r.Context(), "pending_user_id")
+	if len(pendingUserID) == 0 {
+		infra.ErrorHandler(w, http.StatusUnauthorized, "unauthorized")
+		return uuid.Nil, false
+	}
+
+	attempts := a.session.GetInt(r.Context(), "two_factor_attempts")
+	if attempts >= maxTwoFactorAttempts {
+		if err := a.session.Destroy(r.Context()); err != nil {
+			a.log.ErrorContext(r.Context(), fmt.Sprintf("%s: failed destroying session", component), slog.Any("error", err))
+			infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+			return uuid.Nil, false
+		}
+		infra.ErrorHandler(w, http.StatusUnauthorized, "too many attempts")
+		return uuid.Nil, false
+	}
+	a.session.Put(r.Context(), "two_factor_attempts", attempts+1)
+
+	return uuid.MustParse(pendingUserID), true
+}
+
+// authenticate marks the session as logged in by the user. The session id
+// and details are shown in the session list, the token of the session can't
+// be the id because it's renewed on every request.
+func (a *Adapter) authenticate(r *http.Request, userID uuid.UUID) {
+	userAgent := r.UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	now := time.Now().Unix()
+
 	a.session.Put(r.Context(), "user_id", userID.String())
+	a.session.Put(r.Context(), "session_id", uuid.NewString())
+	a.session.Put(r.Context(), "created_at", now)
+	a.session.Put(r.Context(), "last_active_at", now)
+	a.session.Put(r.Context(), "ip", infra.ClientIP(r))
+	a.session.Put(r.Context(), "user_agent", userAgent)
+	a.session.MarkAuthenticated(r.Context())
+
+	// The login is already done, the missed time isn't worth failing it
+	if err := a.uu.RecordLogin(r.Context(), userID); err != nil {
+		a.log.WarnContext(r.Context(), "failed recording login", slog.Any("error", err))
+	}
+}
+
+// completeLogin marks the session waiting for the second factor as
+// authenticated
+func (a *Adapter) completeLogin(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	a.session.Remove(r.Context(), "pending_user_id")
+	a.session.Remove(r.Context(), "two_factor_attempts")
+	a.authenticate(r, userID)
 
 	infra.ResponseJSON(w, struct {
-		UserID string `json:"user_id"`
-	}{UserID: userID.String()}, http.StatusOK)
+		UserID                string `json:"user_id"`
+		PasswordResetRequired bool   `json:"password_reset_required,omitempty"`
+	}{
+		UserID:                userID.String(),
+		PasswordResetRequired: a.session.GetBool(r.Context(), "password_reset_required"),
+	}, http.StatusOK)
+}
+
+// BeginWebAuthnLogin starts the second factor for the session waiting for it,
+// otherwise the passwordless login
+func (a *Adapter) BeginWebAuthnLogin(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.Nil
+	if pendingUserID := a.session.GetString(r.Context(), "pending_user_id"); len(pendingUserID) > 0 {
+		userID = uuid.MustParse(pendingUserID)
+	}
+
+	opts, err := a.uu.BeginWebAuthnLogin(r.Context(), userID)
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "BeginWebAuthnLogin", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	a.session.Put(r.Context(), "webauthn_login_challenge", opts.Challenge)
+
+	infra.ResponseJSON(w, opts, http.StatusOK)
+}
+
+func (a *Adapter) FinishWebAuthnLogin(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.Nil
+	if len(a.session.GetString(r.Context(), "pending_user_id")) > 0 {
+		var ok bool
+		if userID, ok = a.twoFactorAttempt(w, r, "FinishWebAuthnLogin"); !ok {
+			return
+		}
+	}
+
+	challenge := a.session.PopString(r.Context(), "webauthn_login_challenge")
+	if len(challenge) == 0 {
+		infra.ErrorHandler(w, http.StatusBadRequest, "webauthn login is not started")
+		return
+	}
+
+	var body webauthn.AssertionResponse
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		a.log.ErrorContext(r.Context(), "FinishWebAuthnLogin: failed parsing body", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	result, err := a.uu.FinishWebAuthnLogin(r.Context(), userID, challenge, body)
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "FinishWebAuthnLogin", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	// The passwordless login has no primary step, the passkey passes both
+	// factors and the result keeps the required password reset
+	if userID == uuid.Nil {
+		a.startLogin(r, result)
+	}
+
+	a.completeLogin(w, r, result.UserID)
 }
 
 func (a *Adapter) Logout(w http.ResponseWriter, r *http.Request) {
@@ -131,6 +418,54 @@ func (a *Adapter) Logout(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// Reauthenticate confirms the password of the logged in user before sensitive
+// actions. The session is destroyed after too many failed attempts.
+func (a *Adapter) Reauthenticate(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
+
+	body := struct {
+		Password string `json:"password"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		a.log.ErrorContext(r.Context(), "Reauthenticate: failed parsing body", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	if err := a.v.ValidatePassword(body.Password); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	attempts := a.session.GetInt(r.Context(), "reauth_attempts")
+	if attempts >= maxReauthAttempts {
+		if err := a.session.Destroy(r.Context()); err != nil {
+			a.log.ErrorContext(r.Context(), "Reauthenticate: failed destroying session", slog.Any("error", err))
+			infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		infra.ErrorHandler(w, http.StatusUnauthorized, "too many attempts")
+		return
+	}
+
+	match, err := a.uu.VerifyPassword(r.Context(), userID, body.Password)
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "Reauthenticate", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+	if !match {
+		a.session.Put(r.Context(), "reauth_attempts", attempts+1)
+		infra.ErrorHandler(w, http.StatusBadRequest, "incorrect password")
+		return
+	}
+
+	a.session.Remove(r.Context(), "reauth_attempts")
+	a.session.MarkAuthenticated(r.Context())
+
+	w.WriteHeader(http.StatusOK)
+}
+
 func (a *Adapter) UpdateUsername(w http.ResponseWriter, r *http.Request) {
 	userID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
 
@@ -195,32 +530,445 @@ func (a *Adapter) UpdatePassword(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := a.uu.UpdateUser(r.Context(), updatedUser); err != nil {
+		if infra.WeakPasswordHandler(w, err) {
+			return
+		}
 		code, msg := a.ParseUsecaseError(r.Context(), "UpdatePassword", err)
 		infra.ErrorHandler(w, code, msg)
 		return
 	}
 
+	// Sessions opened with the old password are revoked
+	if _, err := a.destroyOtherSessions(r.Context(), userID, func(string) bool { return true }); err != nil {
+		a.log.ErrorContext(r.Context(), "UpdatePassword: failed revoking sessions", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	a.session.Remove(r.Context(), "password_reset_required")
+	a.session.MarkAuthenticated(r.Context())
+
 	w.WriteHeader(http.StatusOK)
 }
 
-func (a *Adapter) RemoveUser(w http.ResponseWriter, r *http.Request) {
+func (a *Adapter) GetTwoFactorStatus(w http.ResponseWriter, r *http.Request) {
 	userID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
 
-	if err := a.uu.RemoveUser(r.Context(), userID); err != nil {
-		code, msg := a.ParseUsecaseError(r.Context(), "DeleteUser", err)
+	status, err := a.uu.GetTwoFactorStatus(r.Context(), userID)
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "GetTwoFactorStatus", err)
 		infra.ErrorHandler(w, code, msg)
 		return
 	}
 
-	if err := a.session.Destroy(r.Context()); err != nil {
-		a.log.ErrorContext(r.Context(), "RemoveUser: failed destroying body", slog.Any("error", err))
+	infra.ResponseJSON(w, struct {
+		Enabled           bool  `json:"enabled"`
+		RecoveryCodesLeft int64 `json:"recovery_codes_left"`
+	}{Enabled: status.Enabled, RecoveryCodesLeft: status.RecoveryCodesLeft}, http.StatusOK)
+}
+
+func (a *Adapter) SetupTOTP(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
+
+	setup, err := a.uu.SetupTOTP(r.Context(), userID)
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "SetupTOTP", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	infra.ResponseJSON(w, struct {
+		Secret string `json:"secret"`
+		URI    string `json:"uri"`
+	}{Secret: setup.Secret, URI: setup.URI}, http.StatusOK)
+}
+
+func (a *Adapter) EnableTOTP(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
+
+	body := struct {
+		Code string `json:"code"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		a.log.ErrorContext(r.Context(), "EnableTOTP: failed parsing body", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	if err := a.v.ValidateTwoFactorCode(body.Code, ""); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recoveryCodes, err := a.uu.EnableTOTP(r.Context(), userID, body.Code)
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "EnableTOTP", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	infra.ResponseJSON(w, struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}{RecoveryCodes: recoveryCodes}, http.StatusOK)
+}
+
+func (a *Adapter) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
+
+	body := struct {
+		Password string `json:"password"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		a.log.ErrorContext(r.Context(), "DisableTOTP: failed parsing body", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	if err := a.v.ValidatePassword(body.Password); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := a.uu.DisableTOTP(r.Context(), userID, body.Password); err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "DisableTOTP", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *Adapter) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
+
+	body := struct {
+		Password string `json:"password"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		a.log.ErrorContext(r.Context(), "RegenerateRecoveryCodes: failed parsing body", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	if err := a.v.ValidatePassword(body.Password); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recoveryCodes, err := a.uu.RegenerateRecoveryCodes(r.Context(), userID, body.Password)
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "RegenerateRecoveryCodes", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	infra.ResponseJSON(w, struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}{RecoveryCodes: recoveryCodes}, http.StatusOK)
+}
+
+func (a *Adapter) BeginWebAuthnRegistration(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
+
+	opts, err := a.uu.BeginWebAuthnRegistration(r.Context(), userID)
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "BeginWebAuthnRegistration", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	a.session.Put(r.Context(), "webauthn_registration_challenge", opts.Challenge)
+
+	infra.ResponseJSON(w, opts, http.StatusOK)
+}
+
+func (a *Adapter) FinishWebAuthnRegistration(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
+
+	challenge := a.session.PopString(r.Context(), "webauthn_registration_challenge")
+	if len(challenge) == 0 {
+		infra.ErrorHandler(w, http.StatusBadRequest, "webauthn registration is not started")
+		return
+	}
+
+	body := struct {
+		Name       string                        `json:"name"`
+		Credential webauthn.RegistrationResponse `json:"credential"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		a.log.ErrorContext(r.Context(), "FinishWebAuthnRegistration: failed parsing body", slog.Any("error", err))
 		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
 		return
 	}
 
+	if err := a.v.ValidatePasskeyName(body.Name); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := a.uu.FinishWebAuthnRegistration(r.Context(), userID, challenge, body.Name, body.Credential); err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "FinishWebAuthnRegistration", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *Adapter) GetWebAuthnCredentials(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
+
+	passkeys, err := a.uu.GetWebAuthnCredentials(r.Context(), userID)
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "GetWebAuthnCredentials", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	type passkeyResponse struct {
+		ID         string     `json:"id"`
+		Name       string     `json:"name"`
+		CreatedAt  time.Time  `json:"created_at"`
+		LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	}
+
+	res := make([]passkeyResponse, 0, len(passkeys))
+	for _, passkey := range passkeys {
+		item := passkeyResponse{
+			ID:        webauthn.EncodeBase64(passkey.ID),
+			Name:      passkey.Name,
+			CreatedAt: passkey.CreatedAt,
+		}
+		if !passkey.LastUsedAt.IsZero() {
+			item.LastUsedAt = &passkey.LastUsedAt
+		}
+		res = append(res, item)
+	}
+
+	infra.ResponseJSON(w, res, http.StatusOK)
+}
+
+func (a *Adapter) RemoveWebAuthnCredential(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
+
+	id, err := webauthn.DecodeBase64(chi.URLParam(r, "credentialID"))
+	if err != nil || len(id) == 0 {
+		infra.ErrorHandler(w, http.StatusBadRequest, "invalid passkey id")
+		return
+	}
+
+	if err := a.uu.RemoveWebAuthnCredential(r.Context(), userID, id); err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "RemoveWebAuthnCredential", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
 	w.WriteHeader(http.StatusOK)
 }
 
+func (a *Adapter) CreateAccessToken(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
+
+	body := struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		a.log.ErrorContext(r.Context(), "CreateAccessToken: failed parsing body", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	if err := a.v.ValidateAccessToken(body.Name, body.Scopes, body.ExpiresInDays); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ttl := time.Duration(body.ExpiresInDays) * 24 * time.Hour
+	token, plain, err := a.uu.CreateAccessToken(r.Context(), userID, body.Name, body.Scopes, ttl)
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "CreateAccessToken", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	res := struct {
+		accessTokenResponse
+		Token string `json:"token"`
+	}{accessTokenResponse: newAccessTokenResponse(token), Token: plain}
+
+	infra.ResponseJSON(w, res, http.StatusOK)
+}
+
+func (a *Adapter) GetAccessTokens(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
+
+	tokens, err := a.uu.GetAccessTokens(r.Context(), userID)
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "GetAccessTokens", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	res := make([]accessTokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		res = append(res, newAccessTokenResponse(token))
+	}
+
+	infra.ResponseJSON(w, res, http.StatusOK)
+}
+
+func (a *Adapter) RemoveAccessToken(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
+
+	tokenID, err := uuid.Parse(chi.URLParam(r, "tokenID"))
+	if err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, "invalid token id")
+		return
+	}
+
+	if err := a.uu.RemoveAccessToken(r.Context(), userID, tokenID); err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "RemoveAccessToken", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+type sessionResponse struct {
+	ID           string    `json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastActiveAt time.Time `json:"last_active_at"`
+	IP           string    `json:"ip"`
+	UserAgent    string    `json:"user_agent"`
+	Current      bool      `json:"current"`
+}
+
+func (a *Adapter) GetSessions(w http.ResponseWriter, r *http.Request) {
+	userID := a.session.GetString(r.Context(), "user_id")
+
+	newSessionResponse := func(ctx context.Context) sessionResponse {
+		return sessionResponse{
+			ID:           a.session.GetString(ctx, "session_id"),
+			CreatedAt:    time.Unix(a.session.GetInt64(ctx, "created_at"), 0),
+			LastActiveAt: time.Unix(a.session.GetInt64(ctx, "last_active_at"), 0),
+			IP:           a.session.GetString(ctx, "ip"),
+			UserAgent:    a.session.GetString(ctx, "user_agent"),
+		}
+	}
+
+	// The stored copy of the current session is older than the request one
+	current := newSessionResponse(r.Context())
+	current.Current = true
+	res := []sessionResponse{current}
+
+	err := a.session.Iterate(r.Context(), func(ctx context.Context) error {
+		sessionID := a.session.GetString(ctx, "session_id")
+		if a.session.GetString(ctx, "user_id") == userID && len(sessionID) > 0 && sessionID != current.ID {
+			res = append(res, newSessionResponse(ctx))
+		}
+		return nil
+	})
+	if err != nil {
+		a.log.ErrorContext(r.Context(), "GetSessions: failed iterating sessions", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	slices.SortFunc(res[1:], func(x, y sessionResponse) int {
+		return y.LastActiveAt.Compare(x.LastActiveAt)
+	})
+
+	infra.ResponseJSON(w, res, http.StatusOK)
+}
+
+// RemoveSession logs out the session of the user, the current session as well
+func (a *Adapter) RemoveSession(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
+	sessionID := chi.URLParam(r, "sessionID")
+
+	if sessionID == a.session.GetString(r.Context(), "session_id") {
+		a.Logout(w, r)
+		return
+	}
+
+	destroyed, err := a.destroyOtherSessions(r.Context(), userID, func(id string) bool { return id == sessionID })
+	if err != nil {
+		a.log.ErrorContext(r.Context(), "RemoveSession: failed revoking session", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if destroyed == 0 {
+		infra.ErrorHandler(w, http.StatusBadRequest, "session not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// RemoveAllSessions logs out the user everywhere
+func (a *Adapter) RemoveAllSessions(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
+
+	if _, err := a.destroyOtherSessions(r.Context(), userID, func(string) bool { return true }); err != nil {
+		a.log.ErrorContext(r.Context(), "RemoveAllSessions: failed revoking sessions", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	a.Logout(w, r)
+}
+
+// destroyOtherSessions destroys sessions of the user except the current one,
+// including sessions waiting for the second factor. match selects sessions by
+// their ids.
+func (a *Adapter) destroyOtherSessions(ctx context.Context, userID uuid.UUID, match func(sessionID string) bool) (int, error) {
+	currentID := a.session.GetString(ctx, "session_id")
+	destroyed := 0
+
+	err := a.session.Iterate(ctx, func(sessionCtx context.Context) error {
+		sessionID := a.session.GetString(sessionCtx, "session_id")
+		owner := a.session.GetString(sessionCtx, "user_id")
+		if len(owner) == 0 {
+			owner = a.session.GetString(sessionCtx, "pending_user_id")
+		}
+		if owner != userID.String() || (len(sessionID) > 0 && sessionID == currentID) || !match(sessionID) {
+			return nil
+		}
+
+		destroyed++
+		return a.session.Destroy(sessionCtx)
+	})
+
+	return destroyed, err
+}
+
+type accessTokenResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+func newAccessTokenResponse(token users.AccessToken) accessTokenResponse {
+	res := accessTokenResponse{
+		ID:        token.ID.String(),
+		Name:      token.Name,
+		Scopes:    token.Scopes,
+		CreatedAt: token.CreatedAt,
+	}
+	if !token.ExpiresAt.IsZero() {
+		res.ExpiresAt = &token.ExpiresAt
+	}
+	if !token.LastUsedAt.IsZero() {
+		res.LastUsedAt = &token.LastUsedAt
+	}
+	return res
+}
+
 func (a *Adapter) ParseUsecaseError(ctx context.Context, component string, usecaseError error) (int, string) {
 	code, msg, err := a.uu.ParseUserError(usecaseError)
 	if code == 0 {
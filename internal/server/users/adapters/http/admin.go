@@ -0,0 +1,360 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"passman/internal/server/infra"
+	"passman/internal/server/users"
+
+	"github.com/go-chi/chi/v5"
+	vldtr "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// NewAdminRouter is the user management of admins. Changes of users require
+// the recent authentication of the admin.
+func NewAdminRouter(ua userUsecase, sm sessionManager, v *vldtr.Validate) chi.Router {
+	a := &Adapter{
+		log:     slog.Default(),
+		uu:      ua,
+		session: sm,
+		v:       newValidator(v),
+	}
+
+	router := chi.NewRouter()
+
+	router.Use(infra.AuthMiddleware(sm), a.adminMiddleware)
+
+	router.Get("/users", a.GetUsers)
+	router.Get("/locked", a.GetLockedAccounts)
+	router.Get("/invites", a.GetInvites)
+	router.Get("/audit", a.GetAuditEvents)
+
+	router.Group(func(routerRecent chi.Router) {
+		routerRecent.Use(infra.RecentAuthMiddleware(sm))
+
+		routerRecent.Put("/users/{userID}/disable", a.DisableUser)
+		routerRecent.Put("/users/{userID}/enable", a.EnableUser)
+		routerRecent.Put("/users/{userID}/password-reset", a.RequirePasswordReset)
+		routerRecent.Post("/users/{userID}/temporary-password", a.SetTemporaryPassword)
+		routerRecent.Delete("/users/{userID}", a.RemoveUserByAdmin)
+		routerRecent.Delete("/locked/{username}", a.UnlockAccount)
+		routerRecent.Post("/invites", a.CreateInvite)
+		routerRecent.Delete("/invites/{inviteID}", a.RemoveInvite)
+	})
+
+	return router
+}
+
+func (a *Adapter) adminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
+
+		isAdmin, err := a.uu.IsAdmin(r.Context(), userID)
+		if err != nil {
+			code, msg := a.ParseUsecaseError(r.Context(), "AdminMiddleware", err)
+			infra.ErrorHandler(w, code, msg)
+			return
+		}
+		if !isAdmin {
+			infra.ErrorHandler(w, http.StatusForbidden, "admin role required")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+type userSummaryResponse struct {
+	UserID                string     `json:"user_id"`
+	Username              string     `json:"username"`
+	IsAdmin               bool       `json:"is_admin"`
+	Disabled              bool       `json:"disabled"`
+	PasswordResetRequired bool       `json:"password_reset_required"`
+	DeleteAt              *time.Time `json:"delete_at,omitempty"`
+	Accounts              int64      `json:"accounts"`
+	Sends                 int64      `json:"sends"`
+	StorageBytes          int64      `json:"storage_bytes"`
+}
+
+func (a *Adapter) GetUsers(w http.ResponseWriter, r *http.Request) {
+	summaries, err := a.uu.GetUsers(r.Context())
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "GetUsers", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	res := make([]userSummaryResponse, 0, len(summaries))
+	for _, summary := range summaries {
+		var deleteAt *time.Time
+		if !summary.DeleteAt.IsZero() {
+			deleteAt = &summary.DeleteAt
+		}
+		res = append(res, userSummaryResponse{
+			UserID:                summary.ID.String(),
+			Username:              summary.Username,
+			IsAdmin:               summary.IsAdmin,
+			Disabled:              summary.Disabled,
+			PasswordResetRequired: summary.PasswordResetRequired,
+			DeleteAt:              deleteAt,
+			Accounts:              summary.Accounts,
+			Sends:                 summary.Sends,
+			StorageBytes:          summary.StorageBytes,
+		})
+	}
+
+	infra.ResponseJSON(w, res, http.StatusOK)
+}
+
+func (a *Adapter) DisableUser(w http.ResponseWriter, r *http.Request) {
+	a.manageUser(w, r, "DisableUser", func(ctx context.Context, adminID, userID uuid.UUID) error {
+		return a.uu.SetUserDisabled(ctx, adminID, userID, true)
+	})
+}
+
+func (a *Adapter) EnableUser(w http.ResponseWriter, r *http.Request) {
+	a.manageUser(w, r, "EnableUser", func(ctx context.Context, adminID, userID uuid.UUID) error {
+		return a.uu.SetUserDisabled(ctx, adminID, userID, false)
+	})
+}
+
+func (a *Adapter) RequirePasswordReset(w http.ResponseWriter, r *http.Request) {
+	a.manageUser(w, r, "RequirePasswordReset", a.uu.RequirePasswordReset)
+}
+
+func (a *Adapter) RemoveUserByAdmin(w http.ResponseWriter, r *http.Request) {
+	a.manageUser(w, r, "RemoveUserByAdmin", a.uu.RemoveUserByAdmin)
+}
+
+// SetTemporaryPassword returns the new password of the user, the admin passes
+// it to the user out of band
+func (a *Adapter) SetTemporaryPassword(w http.ResponseWriter, r *http.Request) {
+	adminID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
+
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	password, err := a.uu.SetTemporaryPassword(r.Context(), adminID, userID)
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "SetTemporaryPassword", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	// The password is already replaced, so it's returned anyway
+	var warning string
+	if _, err := a.destroyOtherSessions(r.Context(), userID, func(string) bool { return true }); err != nil {
+		a.log.ErrorContext(r.Context(), "SetTemporaryPassword: failed revoking sessions", slog.Any("error", err))
+		warning = sessionsNotRevoked
+	}
+
+	infra.ResponseJSON(w, struct {
+		Password string `json:"password"`
+		Warning  string `json:"warning,omitempty"`
+	}{Password: password, Warning: warning}, http.StatusOK)
+}
+
+// sessionsNotRevoked warns the admin that the committed change isn't followed
+// by the logout of the user
+const sessionsNotRevoked = "the change is applied, but sessions of the user aren't revoked"
+
+// manageUser applies the admin action to the user from the path and logs the
+// user out everywhere. Enabled users have no sessions, it's a no-op for them.
+// The failed logout doesn't hide the applied change, it's returned as the
+// warning.
+func (a *Adapter) manageUser(w http.ResponseWriter, r *http.Request, component string, action func(ctx context.Context, adminID, userID uuid.UUID) error) {
+	adminID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
+
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	if err := action(r.Context(), adminID, userID); err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), component, err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	if _, err := a.destroyOtherSessions(r.Context(), userID, func(string) bool { return true }); err != nil {
+		a.log.ErrorContext(r.Context(), component+": failed revoking sessions", slog.Any("error", err))
+		infra.ResponseJSON(w, struct {
+			Warning string `json:"warning"`
+		}{Warning: sessionsNotRevoked}, http.StatusOK)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+type lockedAccountResponse struct {
+	Username      string    `json:"username"`
+	Failures      int64     `json:"failures"`
+	LockedUntil   time.Time `json:"locked_until"`
+	LastFailureAt time.Time `json:"last_failure_at"`
+}
+
+func (a *Adapter) GetLockedAccounts(w http.ResponseWriter, r *http.Request) {
+	locked, err := a.uu.GetLockedAccounts(r.Context())
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "GetLockedAccounts", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	res := make([]lockedAccountResponse, 0, len(locked))
+	for _, throttle := range locked {
+		res = append(res, lockedAccountResponse{
+			Username:      throttle.Subject,
+			Failures:      throttle.Failures,
+			LockedUntil:   throttle.LockedUntil,
+			LastFailureAt: throttle.LastFailureAt,
+		})
+	}
+
+	infra.ResponseJSON(w, res, http.StatusOK)
+}
+
+func (a *Adapter) UnlockAccount(w http.ResponseWriter, r *http.Request) {
+	if err := a.uu.UnlockAccount(r.Context(), chi.URLParam(r, "username")); err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "UnlockAccount", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+type inviteResponse struct {
+	ID        string     `json:"id"`
+	Username  string     `json:"username,omitempty"`
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	UsedBy    string     `json:"used_by,omitempty"`
+}
+
+func newInviteResponse(invite users.Invite) inviteResponse {
+	res := inviteResponse{
+		ID:        invite.ID.String(),
+		Username:  invite.Username,
+		CreatedBy: invite.CreatedBy.String(),
+		CreatedAt: invite.CreatedAt,
+		ExpiresAt: invite.ExpiresAt,
+		UsedBy:    invite.UsedBy,
+	}
+	if invite.Used() {
+		res.UsedAt = &invite.UsedAt
+	}
+	return res
+}
+
+func (a *Adapter) CreateInvite(w http.ResponseWriter, r *http.Request) {
+	adminID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
+
+	body := struct {
+		Username      string `json:"username"`
+		ExpiresInDays int    `json:"expires_in_days"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		a.log.ErrorContext(r.Context(), "CreateInvite: failed parsing body", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	if err := a.v.ValidateInvite(body.Username, body.ExpiresInDays); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ttl := time.Duration(body.ExpiresInDays) * 24 * time.Hour
+	invite, plain, err := a.uu.CreateInvite(r.Context(), adminID, body.Username, ttl)
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "CreateInvite", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	res := struct {
+		inviteResponse
+		Token string `json:"token"`
+	}{inviteResponse: newInviteResponse(invite), Token: plain}
+
+	infra.ResponseJSON(w, res, http.StatusOK)
+}
+
+func (a *Adapter) GetInvites(w http.ResponseWriter, r *http.Request) {
+	invites, err := a.uu.GetInvites(r.Context())
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "GetInvites", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	res := make([]inviteResponse, 0, len(invites))
+	for _, invite := range invites {
+		res = append(res, newInviteResponse(invite))
+	}
+
+	infra.ResponseJSON(w, res, http.StatusOK)
+}
+
+func (a *Adapter) RemoveInvite(w http.ResponseWriter, r *http.Request) {
+	inviteID, err := uuid.Parse(chi.URLParam(r, "inviteID"))
+	if err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, "invalid invite id")
+		return
+	}
+
+	if err := a.uu.RemoveInvite(r.Context(), inviteID); err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "RemoveInvite", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+type auditEventResponse struct {
+	ID             string    `json:"id"`
+	Action         string    `json:"action"`
+	ActorID        string    `json:"actor_id"`
+	ActorUsername  string    `json:"actor_username"`
+	TargetID       string    `json:"target_id"`
+	TargetUsername string    `json:"target_username"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (a *Adapter) GetAuditEvents(w http.ResponseWriter, r *http.Request) {
+	events, err := a.uu.GetAuditEvents(r.Context())
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "GetAuditEvents", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	res := make([]auditEventResponse, 0, len(events))
+	for _, event := range events {
+		res = append(res, auditEventResponse{
+			ID:             event.ID.String(),
+			Action:         event.Action,
+			ActorID:        event.ActorID.String(),
+			ActorUsername:  event.ActorUsername,
+			TargetID:       event.TargetID.String(),
+			TargetUsername: event.TargetUsername,
+			CreatedAt:      event.CreatedAt,
+		})
+	}
+
+	infra.ResponseJSON(w, res, http.StatusOK)
+}
@@ -0,0 +1,104 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// manageUserUsecase applies every admin action
+type manageUserUsecase struct {
+	userUsecase
+}
+
+func (manageUserUsecase) SetUserDisabled(context.Context, uuid.UUID, uuid.UUID, bool) error {
+	return nil
+}
+
+func (manageUserUsecase) SetTemporaryPassword(context.Context, uuid.UUID, uuid.UUID) (string, error) {
+	return "temporary", nil
+}
+
+// brokenSessionManager holds the session of the admin and fails iterating
+// sessions of other users
+type brokenSessionManager struct {
+	sessionManager
+	adminID uuid.UUID
+}
+
+func (sm brokenSessionManager) GetString(_ context.Context, key string) string {
+	if key == "user_id" {
+		return sm.adminID.String()
+	}
+	return ""
+}
+
+func (brokenSessionManager) Iterate(context.Context, func(context.Context) error) error {
+	return errors.New("store error")
+}
+
+func TestManageUserFailedRevokingSessions(t *testing.T) {
+	a := &Adapter{
+		log:     slog.New(slog.DiscardHandler),
+		uu:      manageUserUsecase{},
+		session: brokenSessionManager{adminID: uuid.New()},
+	}
+
+	router := chi.NewRouter()
+	router.Put("/users/{userID}/disable", a.DisableUser)
+	router.Post("/users/{userID}/temporary-password", a.SetTemporaryPassword)
+
+	userID := uuid.NewString()
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		expPassword string
+	}{
+		{
+			name:   "disable_user",
+			method: http.MethodPut,
+			path:   "/users/" + userID + "/disable",
+		},
+		{
+			name:        "temporary_password",
+			method:      http.MethodPost,
+			path:        "/users/" + userID + "/temporary-password",
+			expPassword: "temporary",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+
+			// The change is applied, so the response doesn't hide it
+			if got, want := w.Code, http.StatusOK; got != want {
+				t.Fatalf("Wrong! Unexpected status code!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+
+			var res struct {
+				Password string `json:"password"`
+				Warning  string `json:"warning"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+				t.Fatalf("failed decoding response: %v", err)
+			}
+			if got, want := res.Password, test.expPassword; got != want {
+				t.Errorf("Wrong! Unexpected password!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if got, want := res.Warning, sessionsNotRevoked; got != want {
+				t.Errorf("Wrong! Unexpected warning!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
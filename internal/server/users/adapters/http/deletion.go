@@ -0,0 +1,157 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"passman/internal/server/infra"
+	"passman/internal/server/users"
+	"passman/pkg/webauthn"
+
+	"github.com/google/uuid"
+)
+
+const deleteAtHeader = "X-Delete-At"
+
+// BeginDeletionWebAuthn starts the passkey confirmation of the deletion for
+// users with passkeys
+func (a *Adapter) BeginDeletionWebAuthn(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
+
+	opts, err := a.uu.BeginWebAuthnLogin(r.Context(), userID)
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "BeginDeletionWebAuthn", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	a.session.Put(r.Context(), "webauthn_deletion_challenge", opts.Challenge)
+
+	infra.ResponseJSON(w, opts, http.StatusOK)
+}
+
+// RemoveUser schedules the deletion of the confirmed user and responds with
+// the final encrypted export. The user can't log in and is logged out
+// everywhere, the data is removed after the grace period.
+func (a *Adapter) RemoveUser(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
+
+	body := struct {
+		Password       string                      `json:"password"`
+		Code           string                      `json:"code"`
+		RecoveryCode   string                      `json:"recovery_code"`
+		Passkey        *webauthn.AssertionResponse `json:"passkey"`
+		ExportPassword string                      `json:"export_password"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		a.log.ErrorContext(r.Context(), "RemoveUser: failed parsing body", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	if err := a.v.ValidateDeletion(body.Password, body.ExportPassword, body.Code, body.RecoveryCode); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	confirmation := users.DeletionConfirmation{
+		Password:     body.Password,
+		Code:         body.Code,
+		RecoveryCode: body.RecoveryCode,
+		Passkey:      body.Passkey,
+	}
+	if body.Passkey != nil {
+		confirmation.PasskeyChallenge = a.session.PopString(r.Context(), "webauthn_deletion_challenge")
+		if len(confirmation.PasskeyChallenge) == 0 {
+			infra.ErrorHandler(w, http.StatusBadRequest, "webauthn confirmation is not started")
+			return
+		}
+	}
+
+	// Failed confirmations share the limit of the reauthentication
+	attempts := a.session.GetInt(r.Context(), "reauth_attempts")
+	if attempts >= maxReauthAttempts {
+		if err := a.session.Destroy(r.Context()); err != nil {
+			a.log.ErrorContext(r.Context(), "RemoveUser: failed destroying session", slog.Any("error", err))
+			infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		infra.ErrorHandler(w, http.StatusUnauthorized, "too many attempts")
+		return
+	}
+
+	if err := a.uu.ConfirmDeletion(r.Context(), userID, confirmation); err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "RemoveUser", err)
+		if code == http.StatusBadRequest {
+			a.session.Put(r.Context(), "reauth_attempts", attempts+1)
+		}
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+	a.session.Remove(r.Context(), "reauth_attempts")
+
+	// Nothing is scheduled if the export fails
+	file, err := a.ex.FinalExport(r.Context(), userID, body.ExportPassword)
+	if err != nil {
+		a.log.ErrorContext(r.Context(), "RemoveUser: failed exporting vault", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	deleteAt, err := a.uu.ScheduleDeletion(r.Context(), userID)
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "RemoveUser", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	if _, err := a.destroyOtherSessions(r.Context(), userID, func(string) bool { return true }); err != nil {
+		a.log.ErrorContext(r.Context(), "RemoveUser: failed revoking sessions", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	if err := a.session.Destroy(r.Context()); err != nil {
+		a.log.ErrorContext(r.Context(), "RemoveUser: failed destroying session", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.Header().Set("Content-Type", file.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.Name))
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set(deleteAtHeader, deleteAt.UTC().Format(time.RFC3339))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(file.Data)
+}
+
+// RestoreUser cancels the deletion during the grace period, the user logs in
+// as usual after it
+func (a *Adapter) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	body := struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		a.log.ErrorContext(r.Context(), "RestoreUser: failed parsing body", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	if err := a.v.ValidateUserCreds(body.Username, body.Password); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userCreds := users.UserDTO{Username: body.Username, Password: body.Password}
+	if err := a.uu.RestoreUser(r.Context(), userCreds, infra.ClientIP(r)); err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "RestoreUser", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
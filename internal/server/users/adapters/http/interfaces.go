@@ -2,23 +2,87 @@ package http
 
 import (
 	"context"
+	"time"
 
+	"passman/internal/server/exports"
 	"passman/internal/server/users"
+	"passman/pkg/oidc"
+	"passman/pkg/webauthn"
 
 	"github.com/google/uuid"
 )
 
 type sessionManager interface {
 	GetString(context.Context, string) string
+	GetBool(context.Context, string) bool
+	GetInt(context.Context, string) int
+	GetInt64(context.Context, string) int64
+	PopString(context.Context, string) string
 	Put(context.Context, string, any)
+	Remove(context.Context, string)
 	Keys(context.Context) []string
 	Destroy(context.Context) error
+	Iterate(context.Context, func(context.Context) error) error
+	MarkAuthenticated(context.Context)
+	AuthenticatedRecently(context.Context) bool
 }
 
 type userUsecase interface {
-	Registration(context.Context, users.UserDTO) (uuid.UUID, error)
-	Login(context.Context, users.UserDTO) (uuid.UUID, error)
+	RegistrationMode() string
+	Registration(ctx context.Context, userCreds users.UserDTO, inviteToken string) (uuid.UUID, string, error)
+	Login(ctx context.Context, userCreds users.UserDTO, ip string) (users.LoginResult, error)
+	LoginTOTP(ctx context.Context, userID uuid.UUID, code, recoveryCode, ip string) error
+	GetTwoFactorStatus(context.Context, uuid.UUID) (users.TwoFactorStatus, error)
+	SetupTOTP(context.Context, uuid.UUID) (users.TOTPSetup, error)
+	EnableTOTP(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, userID uuid.UUID, password string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, password string) ([]string, error)
+	VerifyPassword(ctx context.Context, userID uuid.UUID, password string) (bool, error)
 	UpdateUser(context.Context, users.UpdatedUserParams) error
-	RemoveUser(context.Context, uuid.UUID) error
+	GetUser(context.Context, uuid.UUID) (users.User, error)
+	UpdateProfile(context.Context, uuid.UUID, users.Profile) error
+	RecordLogin(context.Context, uuid.UUID) error
+	ConfirmDeletion(ctx context.Context, userID uuid.UUID, confirmation users.DeletionConfirmation) error
+	ScheduleDeletion(context.Context, uuid.UUID) (time.Time, error)
+	RestoreUser(ctx context.Context, userCreds users.UserDTO, ip string) error
+	BeginWebAuthnRegistration(context.Context, uuid.UUID) (webauthn.CreationOptions, error)
+	FinishWebAuthnRegistration(ctx context.Context, userID uuid.UUID, challenge, name string, resp webauthn.RegistrationResponse) error
+	GetWebAuthnCredentials(context.Context, uuid.UUID) ([]users.WebAuthnCredential, error)
+	RemoveWebAuthnCredential(ctx context.Context, userID uuid.UUID, id []byte) error
+	BeginWebAuthnLogin(context.Context, uuid.UUID) (webauthn.RequestOptions, error)
+	FinishWebAuthnLogin(ctx context.Context, userID uuid.UUID, challenge string, resp webauthn.AssertionResponse) (users.LoginResult, error)
+	CreateAccessToken(ctx context.Context, userID uuid.UUID, name string, scopes []string, ttl time.Duration) (users.AccessToken, string, error)
+	GetAccessTokens(context.Context, uuid.UUID) ([]users.AccessToken, error)
+	RemoveAccessToken(ctx context.Context, userID, tokenID uuid.UUID) error
+	VerifyAccessToken(context.Context, string) (users.AccessToken, error)
+	IsAdmin(context.Context, uuid.UUID) (bool, error)
+	GetUsers(context.Context) ([]users.UserSummary, error)
+	SetUserDisabled(ctx context.Context, adminID, userID uuid.UUID, disabled bool) error
+	RequirePasswordReset(ctx context.Context, adminID, userID uuid.UUID) error
+	RemoveUserByAdmin(ctx context.Context, adminID, userID uuid.UUID) error
+	GetLockedAccounts(context.Context) ([]users.LoginThrottle, error)
+	UnlockAccount(ctx context.Context, username string) error
+	CreateInvite(ctx context.Context, adminID uuid.UUID, username string, ttl time.Duration) (users.Invite, string, error)
+	GetInvites(context.Context) ([]users.Invite, error)
+	RemoveInvite(context.Context, uuid.UUID) error
+	LoginOIDC(context.Context, users.OIDCClaims) (users.LoginResult, error)
+	LinkOIDC(ctx context.Context, userID uuid.UUID, claims users.OIDCClaims) error
+	GetOIDCIdentity(ctx context.Context, userID uuid.UUID, issuer string) (users.Identity, bool, error)
+	UnlinkOIDC(ctx context.Context, userID uuid.UUID, issuer string) error
+	GetRecoveryKey(context.Context, uuid.UUID) (users.RecoveryKey, bool, error)
+	RegenerateRecoveryKey(context.Context, uuid.UUID) (string, error)
+	RecoverAccount(ctx context.Context, recovery users.AccountRecovery, ip string) (uuid.UUID, string, error)
+	SetTemporaryPassword(ctx context.Context, adminID, userID uuid.UUID) (string, error)
+	GetAuditEvents(context.Context) ([]users.AuditEvent, error)
 	ParseUserError(error) (int, string, error)
 }
+
+type exporter interface {
+	FinalExport(ctx context.Context, userID uuid.UUID, exportPassword string) (exports.File, error)
+}
+
+type oidcProvider interface {
+	Issuer() string
+	AuthCodeURL(context.Context, oidc.AuthRequest) (string, error)
+	Exchange(ctx context.Context, code string, req oidc.AuthRequest) (oidc.IDToken, error)
+}
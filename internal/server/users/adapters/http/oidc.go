@@ -0,0 +1,240 @@
+package http
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"passman/internal/server/infra"
+	"passman/internal/server/users"
+	"passman/pkg/oidc"
+
+	"github.com/go-chi/chi/v5"
+	vldtr "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// OIDCConfig is the identity provider and the mapping of its claims
+type OIDCConfig struct {
+	Provider oidcProvider
+	// UsernameClaim of new users, the local part is taken from emails
+	UsernameClaim string
+	// RolesClaim is the string or the array of roles, dots address nested
+	// claims, e.g. "realm_access.roles". Empty gives no roles.
+	RolesClaim string
+	// PostLoginURL is the page of the client opened after the callback
+	PostLoginURL string
+}
+
+// NewOIDCRouter is the login by the identity provider. The browser is
+// redirected to the provider and back to the callback, so the state of the
+// flow is kept in the session.
+func NewOIDCRouter(ua userUsecase, sm sessionManager, v *vldtr.Validate, cfg OIDCConfig) chi.Router {
+	a := &Adapter{
+		log:     slog.Default(),
+		uu:      ua,
+		session: sm,
+		v:       newValidator(v),
+		oidc:    cfg,
+	}
+
+	router := chi.NewRouter()
+
+	router.Get("/login", a.BeginOIDCLogin)
+	router.Get("/callback", a.OIDCCallback)
+
+	routerAuth := chi.NewRouter()
+
+	routerAuth.Use(infra.AuthMiddleware(sm))
+
+	routerAuth.Get("/", a.GetOIDCIdentity)
+
+	routerAuth.Group(func(routerRecent chi.Router) {
+		routerRecent.Use(infra.RecentAuthMiddleware(sm))
+
+		routerRecent.Get("/link", a.BeginOIDCLink)
+		routerRecent.Delete("/", a.UnlinkOIDC)
+	})
+
+	router.Mount("/", routerAuth)
+
+	return router
+}
+
+// BeginOIDCLogin redirects to the provider. Logged in users use it for the
+// reauthentication too, users without the password have no other way.
+func (a *Adapter) BeginOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	a.beginOIDC(w, r, "BeginOIDCLogin", "")
+}
+
+// BeginOIDCLink redirects to the provider, the identity is linked to the
+// logged in user by the callback
+func (a *Adapter) BeginOIDCLink(w http.ResponseWriter, r *http.Request) {
+	a.beginOIDC(w, r, "BeginOIDCLink", a.session.GetString(r.Context(), "user_id"))
+}
+
+func (a *Adapter) beginOIDC(w http.ResponseWriter, r *http.Request, component, linkUserID string) {
+	req, err := oidc.NewAuthRequest()
+	if err != nil {
+		a.log.ErrorContext(r.Context(), fmt.Sprintf("%s: failed creating auth request", component), slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	authURL, err := a.oidc.Provider.AuthCodeURL(r.Context(), req)
+	if err != nil {
+		a.log.ErrorContext(r.Context(), fmt.Sprintf("%s: failed building auth url", component), slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusBadGateway, "identity provider is unavailable")
+		return
+	}
+
+	a.session.Put(r.Context(), "oidc_state", req.State)
+	a.session.Put(r.Context(), "oidc_nonce", req.Nonce)
+	a.session.Put(r.Context(), "oidc_verifier", req.Verifier)
+	if len(linkUserID) > 0 {
+		a.session.Put(r.Context(), "oidc_link_user_id", linkUserID)
+	} else {
+		a.session.Remove(r.Context(), "oidc_link_user_id")
+	}
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallback finishes the flow started by BeginOIDCLogin or BeginOIDCLink.
+// Users with second factors of the server pass them after the callback.
+func (a *Adapter) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	// Values are popped, so the state is used once
+	req := oidc.AuthRequest{
+		State:    a.session.PopString(r.Context(), "oidc_state"),
+		Nonce:    a.session.PopString(r.Context(), "oidc_nonce"),
+		Verifier: a.session.PopString(r.Context(), "oidc_verifier"),
+	}
+	linkUserID := a.session.PopString(r.Context(), "oidc_link_user_id")
+
+	query := r.URL.Query()
+	if len(req.State) == 0 || subtle.ConstantTimeCompare([]byte(req.State), []byte(query.Get("state"))) != 1 {
+		infra.ErrorHandler(w, http.StatusBadRequest, "invalid state")
+		return
+	}
+	if providerErr := query.Get("error"); len(providerErr) > 0 {
+		a.log.InfoContext(
+			r.Context(),
+			"OIDCCallback: provider returned error",
+			slog.String("error", providerErr),
+			slog.String("description", query.Get("error_description")),
+		)
+		infra.ErrorHandler(w, http.StatusBadRequest, "identity provider denied login")
+		return
+	}
+
+	token, err := a.oidc.Provider.Exchange(r.Context(), query.Get("code"), req)
+	if err != nil {
+		a.log.WarnContext(r.Context(), "OIDCCallback: failed exchanging code", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusBadRequest, "identity provider login failed")
+		return
+	}
+
+	claims := users.OIDCClaims{
+		Issuer:   token.Issuer,
+		Subject:  token.Subject,
+		Username: token.String(a.oidc.UsernameClaim),
+	}
+	if len(a.oidc.RolesClaim) > 0 {
+		claims.Roles = token.Strings(a.oidc.RolesClaim)
+	}
+
+	if len(linkUserID) > 0 {
+		// The user could log out or switch the account during the redirect
+		if a.session.GetString(r.Context(), "user_id") != linkUserID {
+			infra.ErrorHandler(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		if err := a.uu.LinkOIDC(r.Context(), uuid.MustParse(linkUserID), claims); err != nil {
+			code, msg := a.ParseUsecaseError(r.Context(), "OIDCCallback", err)
+			infra.ErrorHandler(w, code, msg)
+			return
+		}
+		// The provider has just authenticated the user
+		a.session.MarkAuthenticated(r.Context())
+
+		http.Redirect(w, r, a.oidc.PostLoginURL, http.StatusFound)
+		return
+	}
+
+	result, err := a.uu.LoginOIDC(r.Context(), claims)
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "OIDCCallback", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	// Second factors of the server are passed by /users/login/totp or
+	// /users/webauthn/login/*, the client finds the methods in the query
+	if methods := a.startLogin(r, result); len(methods) > 0 {
+		http.Redirect(w, r, a.postLoginURL(methods), http.StatusFound)
+		return
+	}
+
+	a.session.Remove(r.Context(), "pending_user_id")
+	a.session.Remove(r.Context(), "two_factor_attempts")
+	a.authenticate(r, result.UserID)
+
+	http.Redirect(w, r, a.oidc.PostLoginURL, http.StatusFound)
+}
+
+// postLoginURL is PostLoginURL with two_factor_required, the comma-separated
+// methods of the second factor
+func (a *Adapter) postLoginURL(methods []string) string {
+	u, err := url.Parse(a.oidc.PostLoginURL)
+	if err != nil {
+		// The URL is parsed by the config already
+		return a.oidc.PostLoginURL
+	}
+	query := u.Query()
+	query.Set("two_factor_required", strings.Join(methods, ","))
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+func (a *Adapter) GetOIDCIdentity(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
+
+	identity, linked, err := a.uu.GetOIDCIdentity(r.Context(), userID, a.oidc.Provider.Issuer())
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "GetOIDCIdentity", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	res := struct {
+		Issuer   string     `json:"issuer"`
+		Linked   bool       `json:"linked"`
+		Subject  string     `json:"subject,omitempty"`
+		LinkedAt *time.Time `json:"linked_at,omitempty"`
+	}{
+		Issuer: a.oidc.Provider.Issuer(),
+		Linked: linked,
+	}
+	if linked {
+		res.Subject = identity.Subject
+		res.LinkedAt = &identity.CreatedAt
+	}
+
+	infra.ResponseJSON(w, res, http.StatusOK)
+}
+
+func (a *Adapter) UnlinkOIDC(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
+
+	if err := a.uu.UnlinkOIDC(r.Context(), userID, a.oidc.Provider.Issuer()); err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "UnlinkOIDC", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,91 @@
+package http
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"passman/internal/server/infra"
+	"passman/internal/server/users"
+
+	"github.com/google/uuid"
+)
+
+type profileRequest struct {
+	DisplayName string `json:"display_name"`
+	Email       string `json:"email"`
+	Locale      string `json:"locale"`
+	Timezone    string `json:"timezone"`
+}
+
+type meResponse struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	profileRequest
+	IsAdmin     bool       `json:"is_admin"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+func (a *Adapter) GetMe(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
+
+	user, err := a.uu.GetUser(r.Context(), userID)
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "GetMe", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	res := meResponse{
+		ID:       user.ID.String(),
+		Username: user.Username,
+		profileRequest: profileRequest{
+			DisplayName: user.Profile.DisplayName,
+			Email:       user.Profile.Email,
+			Locale:      user.Profile.Locale,
+			Timezone:    user.Profile.Timezone,
+		},
+		IsAdmin: user.IsAdmin,
+	}
+	if !user.CreatedAt.IsZero() {
+		res.CreatedAt = &user.CreatedAt
+	}
+	if !user.LastLoginAt.IsZero() {
+		res.LastLoginAt = &user.LastLoginAt
+	}
+
+	infra.ResponseJSON(w, res, http.StatusOK)
+}
+
+func (a *Adapter) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
+
+	body := profileRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		a.log.ErrorContext(r.Context(), "UpdateProfile: failed parsing body", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	profile := users.Profile{
+		DisplayName: strings.TrimSpace(body.DisplayName),
+		Email:       strings.TrimSpace(body.Email),
+		Locale:      body.Locale,
+		Timezone:    body.Timezone,
+	}
+	if err := a.v.ValidateProfile(profile); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := a.uu.UpdateProfile(r.Context(), userID, profile); err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "UpdateProfile", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,105 @@
+package http
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"passman/internal/server/infra"
+	"passman/internal/server/users"
+
+	"github.com/google/uuid"
+)
+
+// maxRecoveryKeyLength allows separators and spaces around the key
+const maxRecoveryKeyLength = 64
+
+type recoveryKeyResponse struct {
+	RecoveryKey string `json:"recovery_key"`
+}
+
+// RecoverAccount sets the new password by the recovery key. The user isn't
+// logged in: the login with the new password still asks for second factors.
+func (a *Adapter) RecoverAccount(w http.ResponseWriter, r *http.Request) {
+	body := struct {
+		Username    string `json:"username"`
+		RecoveryKey string `json:"recovery_key"`
+		Password    string `json:"password"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		a.log.ErrorContext(r.Context(), "RecoverAccount: failed parsing body", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	if err := a.v.ValidateUserCreds(body.Username, body.Password); err != nil {
+		infra.ErrorHandler(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(body.RecoveryKey) == 0 || len(body.RecoveryKey) > maxRecoveryKeyLength {
+		infra.ErrorHandler(w, http.StatusBadRequest, "invalid recovery key")
+		return
+	}
+
+	userID, recoveryKey, err := a.uu.RecoverAccount(
+		r.Context(),
+		users.AccountRecovery{
+			Username:    body.Username,
+			RecoveryKey: body.RecoveryKey,
+			Password:    body.Password,
+		},
+		infra.ClientIP(r),
+	)
+	if err != nil {
+		if infra.WeakPasswordHandler(w, err) {
+			return
+		}
+		code, msg := a.ParseUsecaseError(r.Context(), "RecoverAccount", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	// Whoever knew the old password is logged out
+	if _, err := a.destroyOtherSessions(r.Context(), userID, func(string) bool { return true }); err != nil {
+		a.log.ErrorContext(r.Context(), "RecoverAccount: failed revoking sessions", slog.Any("error", err))
+		infra.ErrorHandler(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	infra.ResponseJSON(w, recoveryKeyResponse{RecoveryKey: recoveryKey}, http.StatusOK)
+}
+
+func (a *Adapter) GetRecoveryKey(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
+
+	key, exists, err := a.uu.GetRecoveryKey(r.Context(), userID)
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "GetRecoveryKey", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	res := struct {
+		Exists    bool       `json:"exists"`
+		CreatedAt *time.Time `json:"created_at,omitempty"`
+	}{Exists: exists}
+	if exists {
+		res.CreatedAt = &key.CreatedAt
+	}
+
+	infra.ResponseJSON(w, res, http.StatusOK)
+}
+
+func (a *Adapter) RegenerateRecoveryKey(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.MustParse(a.session.GetString(r.Context(), "user_id"))
+
+	recoveryKey, err := a.uu.RegenerateRecoveryKey(r.Context(), userID)
+	if err != nil {
+		code, msg := a.ParseUsecaseError(r.Context(), "RegenerateRecoveryKey", err)
+		infra.ErrorHandler(w, code, msg)
+		return
+	}
+
+	infra.ResponseJSON(w, recoveryKeyResponse{RecoveryKey: recoveryKey}, http.StatusOK)
+}
@@ -0,0 +1,297 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"passman/pkg/session"
+
+	"github.com/go-chi/chi/v5"
+	vldtr "github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// recordLoginUsecase is enough for the session endpoints, other methods of
+// userUsecase aren't called
+type recordLoginUsecase struct {
+	userUsecase
+}
+
+func (recordLoginUsecase) RecordLogin(context.Context, uuid.UUID) error {
+	return nil
+}
+
+type sessionsTestServer struct {
+	*httptest.Server
+	t *testing.T
+}
+
+// newSessionsTestServer serves the users router with the memory session store.
+// Test routes log in the client like Login does and start the login waiting
+// for the second factor.
+func newSessionsTestServer(t *testing.T) sessionsTestServer {
+	return newUsersTestServer(t, recordLoginUsecase{})
+}
+
+func newUsersTestServer(t *testing.T, uu userUsecase) sessionsTestServer {
+	sm, err := session.NewSessionManager(context.Background(), session.SessionManagerOptions{})
+	if err != nil {
+		t.Fatalf("failed creating session manager: %v", err)
+	}
+	a := &Adapter{log: slog.New(slog.DiscardHandler), uu: uu, session: sm}
+
+	router := chi.NewRouter()
+	router.Use(sm.LoadAndSave)
+	router.Post("/test/login/{userID}", func(w http.ResponseWriter, r *http.Request) {
+		a.authenticate(r, uuid.MustParse(chi.URLParam(r, "userID")))
+		w.WriteHeader(http.StatusOK)
+	})
+	router.Post("/test/pending/{userID}", func(w http.ResponseWriter, r *http.Request) {
+		sm.Put(r.Context(), "pending_user_id", chi.URLParam(r, "userID"))
+		w.WriteHeader(http.StatusOK)
+	})
+	router.Get("/test/pending", func(w http.ResponseWriter, r *http.Request) {
+		if len(sm.GetString(r.Context(), "pending_user_id")) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	router.Delete("/test/others", func(w http.ResponseWriter, r *http.Request) {
+		userID := uuid.MustParse(sm.GetString(r.Context(), "user_id"))
+		if _, err := a.destroyOtherSessions(r.Context(), userID, func(string) bool { return true }); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	router.Mount("/users", NewRouter(uu, nil, sm, vldtr.New()))
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	return sessionsTestServer{Server: server, t: t}
+}
+
+// client returns the client with own cookies, like the separate browser
+func (s sessionsTestServer) client() *http.Client {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		s.t.Fatalf("failed creating cookie jar: %v", err)
+	}
+	return &http.Client{Jar: jar}
+}
+
+func (s sessionsTestServer) do(client *http.Client, method, path string) *http.Response {
+	return s.doBody(client, method, path, "")
+}
+
+func (s sessionsTestServer) doBody(client *http.Client, method, path, body string) *http.Response {
+	req, err := http.NewRequest(method, s.URL+path, strings.NewReader(body))
+	if err != nil {
+		s.t.Fatalf("failed creating request: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		s.t.Fatalf("failed sending request: %v", err)
+	}
+	s.t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func (s sessionsTestServer) login(userID uuid.UUID) *http.Client {
+	client := s.client()
+	if resp := s.do(client, http.MethodPost, "/test/login/"+userID.String()); resp.StatusCode != http.StatusOK {
+		s.t.Fatalf("failed logging in: %v", resp.Status)
+	}
+	return client
+}
+
+func (s sessionsTestServer) sessions(client *http.Client) []sessionResponse {
+	resp := s.do(client, http.MethodGet, "/users/sessions")
+	if resp.StatusCode != http.StatusOK {
+		s.t.Fatalf("failed getting sessions: %v", resp.Status)
+	}
+
+	var res []sessionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		s.t.Fatalf("failed decoding sessions: %v", err)
+	}
+	return res
+}
+
+func (s sessionsTestServer) startLogin(userID uuid.UUID) *http.Client {
+	client := s.client()
+	if resp := s.do(client, http.MethodPost, "/test/pending/"+userID.String()); resp.StatusCode != http.StatusOK {
+		s.t.Fatalf("failed starting login: %v", resp.Status)
+	}
+	return client
+}
+
+// loggedIn tells whether the session of the client is still alive
+func (s sessionsTestServer) loggedIn(client *http.Client) bool {
+	return s.do(client, http.MethodGet, "/users/sessions").StatusCode == http.StatusOK
+}
+
+// loginPending tells whether the session waiting for the second factor is
+// still alive
+func (s sessionsTestServer) loginPending(client *http.Client) bool {
+	return s.do(client, http.MethodGet, "/test/pending").StatusCode == http.StatusOK
+}
+
+func (s sessionsTestServer) currentID(client *http.Client) string {
+	return s.sessions(client)[0].ID
+}
+
+func TestGetSessions(t *testing.T) {
+	s := newSessionsTestServer(t)
+	aliceID, bobID := uuid.New(), uuid.New()
+
+	alice := s.login(aliceID)
+	aliceOther := s.login(aliceID)
+	bob := s.login(bobID)
+
+	aliceOtherID := s.currentID(aliceOther)
+	bobSessionID := s.currentID(bob)
+
+	res := s.sessions(alice)
+
+	if got, want := len(res), 2; got != want {
+		t.Fatalf("Wrong! Unexpected number of sessions!\n\tExpected: %v\n\tActual: %v", want, got)
+	}
+	if !res[0].Current || res[1].Current {
+		t.Errorf("Wrong! The current session isn't the first one!\n\tActual: %+v", res)
+	}
+	if got, want := res[1].ID, aliceOtherID; got != want {
+		t.Errorf("Wrong! Unexpected session!\n\tExpected: %v\n\tActual: %v", want, got)
+	}
+	if slices.ContainsFunc(res, func(r sessionResponse) bool { return r.ID == bobSessionID }) {
+		t.Errorf("Wrong! Session of another user is listed!")
+	}
+}
+
+func TestRemoveSession(t *testing.T) {
+	s := newSessionsTestServer(t)
+	aliceID, bobID := uuid.New(), uuid.New()
+
+	alice := s.login(aliceID)
+	aliceOther := s.login(aliceID)
+	bob := s.login(bobID)
+
+	aliceOtherID := s.currentID(aliceOther)
+	bobSessionID := s.currentID(bob)
+
+	tests := []struct {
+		name      string
+		sessionID string
+		expCode   int
+		expAlive  map[*http.Client]bool
+	}{
+		{
+			name:      "unknown_session",
+			sessionID: uuid.NewString(),
+			expCode:   http.StatusBadRequest,
+			expAlive:  map[*http.Client]bool{alice: true, aliceOther: true, bob: true},
+		},
+		{
+			name:      "session_of_another_user",
+			sessionID: bobSessionID,
+			expCode:   http.StatusBadRequest,
+			expAlive:  map[*http.Client]bool{alice: true, aliceOther: true, bob: true},
+		},
+		{
+			name:      "own_session",
+			sessionID: aliceOtherID,
+			expCode:   http.StatusOK,
+			expAlive:  map[*http.Client]bool{alice: true, aliceOther: false, bob: true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := s.do(alice, http.MethodDelete, "/users/sessions/"+test.sessionID)
+
+			if got, want := resp.StatusCode, test.expCode; got != want {
+				t.Fatalf("Wrong! Unexpected status code!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			for client, want := range test.expAlive {
+				if got := s.loggedIn(client); got != want {
+					t.Errorf("Wrong! Unexpected session state!\n\tExpected: %v\n\tActual: %v", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRemoveCurrentSession(t *testing.T) {
+	s := newSessionsTestServer(t)
+	aliceID := uuid.New()
+
+	alice := s.login(aliceID)
+	aliceOther := s.login(aliceID)
+
+	if resp := s.do(alice, http.MethodDelete, "/users/sessions/"+s.currentID(alice)); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Wrong! Unexpected status code!\n\tExpected: %v\n\tActual: %v", http.StatusOK, resp.StatusCode)
+	}
+
+	if s.loggedIn(alice) {
+		t.Errorf("Wrong! The current session isn't logged out!")
+	}
+	if !s.loggedIn(aliceOther) {
+		t.Errorf("Wrong! Another session is logged out!")
+	}
+}
+
+func TestRemoveAllSessions(t *testing.T) {
+	s := newSessionsTestServer(t)
+	aliceID, bobID := uuid.New(), uuid.New()
+
+	alice := s.login(aliceID)
+	aliceOther := s.login(aliceID)
+	alicePending := s.startLogin(aliceID)
+	bob := s.login(bobID)
+	bobPending := s.startLogin(bobID)
+
+	if resp := s.do(alice, http.MethodDelete, "/users/sessions"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Wrong! Unexpected status code!\n\tExpected: %v\n\tActual: %v", http.StatusOK, resp.StatusCode)
+	}
+
+	if s.loggedIn(alice) || s.loggedIn(aliceOther) || s.loginPending(alicePending) {
+		t.Errorf("Wrong! Session of the user isn't logged out!")
+	}
+	if !s.loggedIn(bob) || !s.loginPending(bobPending) {
+		t.Errorf("Wrong! Session of another user is logged out!")
+	}
+}
+
+func TestDestroyOtherSessions(t *testing.T) {
+	s := newSessionsTestServer(t)
+	aliceID, bobID := uuid.New(), uuid.New()
+
+	alice := s.login(aliceID)
+	aliceOther := s.login(aliceID)
+	alicePending := s.startLogin(aliceID)
+	bob := s.login(bobID)
+	bobPending := s.startLogin(bobID)
+
+	if resp := s.do(alice, http.MethodDelete, "/test/others"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Wrong! Unexpected status code!\n\tExpected: %v\n\tActual: %v", http.StatusOK, resp.StatusCode)
+	}
+
+	if !s.loggedIn(alice) {
+		t.Errorf("Wrong! The current session is logged out!")
+	}
+	if s.loggedIn(aliceOther) || s.loginPending(alicePending) {
+		t.Errorf("Wrong! Other session of the user isn't logged out!")
+	}
+	if !s.loggedIn(bob) || !s.loginPending(bobPending) {
+		t.Errorf("Wrong! Session of another user is logged out!")
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"regexp"
 
+	"passman/internal/server/users"
+
 	vldtr "github.com/go-playground/validator/v10"
 )
 
@@ -45,3 +47,104 @@ func (v *validator) ValidatePasswords(passwords ...string) error {
 	}
 	return nil
 }
+
+func (v *validator) ValidatePassword(password string) error {
+	if err := v.v.Var(password, "password"); err != nil {
+		return fmt.Errorf("invalid password")
+	}
+	return nil
+}
+
+// ValidateTwoFactorCode checks that exactly one of the codes is passed
+func (v *validator) ValidateTwoFactorCode(code, recoveryCode string) error {
+	errInvalidCode := fmt.Errorf("invalid two-factor code")
+
+	switch {
+	case len(code) > 0 && len(recoveryCode) > 0:
+		return errInvalidCode
+	case len(recoveryCode) > 0:
+		if err := v.v.Var(recoveryCode, "max=16"); err != nil {
+			return errInvalidCode
+		}
+	default:
+		if err := v.v.Var(code, "numeric,len=6"); err != nil {
+			return errInvalidCode
+		}
+	}
+
+	return nil
+}
+
+func (v *validator) ValidatePasskeyName(name string) error {
+	if err := v.v.Var(name, "required,max=64"); err != nil {
+		return fmt.Errorf("invalid passkey name")
+	}
+	return nil
+}
+
+// ValidateAccessToken checks the name, scopes and expiry in days, zero days
+// means the token never expires
+func (v *validator) ValidateAccessToken(name string, scopes []string, expiresInDays int) error {
+	if err := v.v.Var(name, "required,max=64"); err != nil {
+		return fmt.Errorf("invalid token name")
+	}
+
+	scopesTag := fmt.Sprintf("required,max=4,dive,oneof=%s %s %s %s", users.ScopeAccountsRead, users.ScopeAccountsWrite, users.ScopeAccountsReveal, users.ScopeServices)
+	if err := v.v.Var(scopes, scopesTag); err != nil {
+		return fmt.Errorf("invalid token scopes")
+	}
+
+	if err := v.v.Var(expiresInDays, "min=0,max=365"); err != nil {
+		return fmt.Errorf("invalid token expiry")
+	}
+
+	return nil
+}
+
+// ValidateInvite checks the optional username and expiry in days
+func (v *validator) ValidateInvite(username string, expiresInDays int) error {
+	if err := v.v.Var(username, "omitempty,username"); err != nil {
+		return fmt.Errorf("invalid username")
+	}
+
+	if err := v.v.Var(expiresInDays, "min=1,max=30"); err != nil {
+		return fmt.Errorf("invalid invite expiry")
+	}
+
+	return nil
+}
+
+// ValidateProfile checks the optional profile fields, the locale is the BCP 47
+// language tag and the timezone is the IANA time zone name
+func (v *validator) ValidateProfile(profile users.Profile) error {
+	if err := v.v.Var(profile.DisplayName, "max=64"); err != nil {
+		return fmt.Errorf("invalid display name")
+	}
+	if err := v.v.Var(profile.Email, "omitempty,max=254,email"); err != nil {
+		return fmt.Errorf("invalid email")
+	}
+	if err := v.v.Var(profile.Locale, "omitempty,max=35,bcp47_language_tag"); err != nil {
+		return fmt.Errorf("invalid locale")
+	}
+	// The server's Local zone isn't a portable name
+	if err := v.v.Var(profile.Timezone, "omitempty,max=64,ne=Local,timezone"); err != nil {
+		return fmt.Errorf("invalid timezone")
+	}
+	return nil
+}
+
+// ValidateDeletion checks the confirmation of the deletion. The password is
+// empty for users of the identity provider, the second factor is checked only
+// if it's passed.
+func (v *validator) ValidateDeletion(password, exportPassword, code, recoveryCode string) error {
+	if err := v.v.Var(password, "omitempty,password"); err != nil {
+		return fmt.Errorf("invalid password")
+	}
+	if err := v.v.Var(exportPassword, "password"); err != nil {
+		return fmt.Errorf("invalid export password")
+	}
+	if len(code) > 0 || len(recoveryCode) > 0 {
+		return v.ValidateTwoFactorCode(code, recoveryCode)
+	}
+	return nil
+}
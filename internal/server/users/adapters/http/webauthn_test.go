@@ -0,0 +1,78 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"passman/internal/server/users"
+	"passman/pkg/webauthn"
+
+	"github.com/google/uuid"
+)
+
+// passwordlessUsecase accepts any passkey of the passwordless login
+type passwordlessUsecase struct {
+	recordLoginUsecase
+	result users.LoginResult
+}
+
+func (passwordlessUsecase) BeginWebAuthnLogin(context.Context, uuid.UUID) (webauthn.RequestOptions, error) {
+	return webauthn.RequestOptions{Challenge: "challenge"}, nil
+}
+
+func (uu passwordlessUsecase) FinishWebAuthnLogin(context.Context, uuid.UUID, string, webauthn.AssertionResponse) (users.LoginResult, error) {
+	return uu.result, nil
+}
+
+func TestFinishWebAuthnLoginPasswordless(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name                  string
+		passwordResetRequired bool
+	}{
+		{
+			name: "success",
+		},
+		{
+			name:                  "password_reset_required",
+			passwordResetRequired: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newUsersTestServer(t, passwordlessUsecase{
+				result: users.LoginResult{UserID: userID, PasswordResetRequired: test.passwordResetRequired},
+			})
+			client := s.client()
+
+			if resp := s.do(client, http.MethodPost, "/users/webauthn/login/begin"); resp.StatusCode != http.StatusOK {
+				t.Fatalf("failed beginning login: %v", resp.Status)
+			}
+
+			resp := s.doBody(client, http.MethodPost, "/users/webauthn/login/finish", "{}")
+			if got, want := resp.StatusCode, http.StatusOK; got != want {
+				t.Fatalf("Wrong! Unexpected status code!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+
+			var res struct {
+				UserID                string `json:"user_id"`
+				PasswordResetRequired bool   `json:"password_reset_required"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+				t.Fatalf("failed decoding response: %v", err)
+			}
+			if got, want := res.PasswordResetRequired, test.passwordResetRequired; got != want {
+				t.Errorf("Wrong! Unexpected password reset!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+
+			// Other requests of the user who has to reset the password are forbidden
+			if got, want := s.loggedIn(client), !test.passwordResetRequired; got != want {
+				t.Errorf("Wrong! Unexpected session state!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+
+	"passman/internal/server/users"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/google/uuid"
+)
+
+var (
+	errUserNotFound  = newClientError("user not found")
+	errManageOwnUser = newClientError("admin can't manage own user")
+	// The local password would bypass the identity provider or the directory,
+	// the reset can't be finished without it
+	errUserWithoutPassword = newClientError("user without the password logs in by the identity provider")
+)
+
+// IsAdmin tells whether the user may call the admin API
+func (uu *userUsecase) IsAdmin(ctx context.Context, userID uuid.UUID) (bool, error) {
+	user, err := uu.dbRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if uu.dbRepo.IsEmptyRows(err) {
+			return false, nil
+		}
+		return false, newInternalError("IsAdmin", "failed finding user", err)
+	}
+	return user.IsAdmin && !user.Disabled && user.DeleteAt.IsZero(), nil
+}
+
+func (uu *userUsecase) GetUsers(ctx context.Context) ([]users.UserSummary, error) {
+	summaries, err := uu.dbRepo.GetUsers(ctx)
+	if err != nil {
+		return nil, newInternalError("GetUsers", "failed getting users", err)
+	}
+	return summaries, nil
+}
+
+// SetUserDisabled disables or enables the user. Enabling the user cancels the
+// scheduled deletion, the cancellation is audited. Sessions of the disabled
+// user are destroyed by the caller, access tokens stop working at once.
+func (uu *userUsecase) SetUserDisabled(ctx context.Context, adminID, userID uuid.UUID, disabled bool) error {
+	if adminID == userID {
+		return errManageOwnUser
+	}
+
+	admin, user, err := uu.getManagedUser(ctx, "SetUserDisabled", adminID, userID)
+	if err != nil {
+		return err
+	}
+
+	err = uu.dbRepo.WithinTx(ctx, func(txCtx context.Context) error {
+		found, err := uu.dbRepo.SetUserDisabled(txCtx, userID, disabled)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return errUserNotFound
+		}
+		if disabled || user.DeleteAt.IsZero() {
+			return nil
+		}
+		return uu.dbRepo.AddAuditEvent(txCtx, newAuditEvent(users.AuditDeletionCanceled, admin, user, uu.now()))
+	})
+	if errors.Is(err, errUserNotFound) {
+		return errUserNotFound
+	}
+	if err != nil {
+		return newInternalError("SetUserDisabled", "failed updating user", err)
+	}
+	return nil
+}
+
+// RequirePasswordReset makes the user change the password after the next
+// login, by the password or by the identity provider. Sessions of the user are
+// destroyed by the caller.
+func (uu *userUsecase) RequirePasswordReset(ctx context.Context, adminID, userID uuid.UUID) error {
+	if adminID == userID {
+		return errManageOwnUser
+	}
+
+	admin, user, err := uu.getManagedUser(ctx, "RequirePasswordReset", adminID, userID)
+	if err != nil {
+		return err
+	}
+	if len(user.Password) == 0 {
+		return errUserWithoutPassword
+	}
+
+	err = uu.dbRepo.WithinTx(ctx, func(txCtx context.Context) error {
+		found, err := uu.dbRepo.RequirePasswordReset(txCtx, userID)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return errUserNotFound
+		}
+		return uu.dbRepo.AddAuditEvent(txCtx, newAuditEvent(users.AuditPasswordResetRequired, admin, user, uu.now()))
+	})
+	if errors.Is(err, errUserNotFound) {
+		return errUserNotFound
+	}
+	if err != nil {
+		return newInternalError("RequirePasswordReset", "failed updating user", err)
+	}
+	return nil
+}
+
+// SetTemporaryPassword replaces the password of the user who can't recover it
+// by the recovery key. The returned password has to be changed after the next
+// login. Second factors and the recovery key are kept, access tokens are
+// revoked and sessions are destroyed by the caller.
+func (uu *userUsecase) SetTemporaryPassword(ctx context.Context, adminID, userID uuid.UUID) (string, error) {
+	if adminID == userID {
+		return "", errManageOwnUser
+	}
+
+	admin, user, err := uu.getManagedUser(ctx, "SetTemporaryPassword", adminID, userID)
+	if err != nil {
+		return "", err
+	}
+	if len(user.Password) == 0 {
+		return "", errUserWithoutPassword
+	}
+
+	raw := make([]byte, 18)
+	if _, err := rand.Read(raw); err != nil {
+		return "", newInternalError("SetTemporaryPassword", "failed generating password", err)
+	}
+	plain := base64.RawURLEncoding.EncodeToString(raw)
+
+	hash, err := argon2id.CreateHash(plain, uu.argon2Params)
+	if err != nil {
+		return "", newInternalError("SetTemporaryPassword", "failed creating password hash", err)
+	}
+
+	updatedUser := users.User{
+		ID:                    user.ID,
+		Username:              user.Username,
+		Password:              hash,
+		PasswordResetRequired: true,
+	}
+
+	err = uu.dbRepo.WithinTx(ctx, func(txCtx context.Context) error {
+		if err := uu.dbRepo.UpdateUser(txCtx, updatedUser); err != nil {
+			return err
+		}
+		if err := uu.dbRepo.RemoveAccessTokens(txCtx, user.ID); err != nil {
+			return err
+		}
+		return uu.dbRepo.AddAuditEvent(txCtx, newAuditEvent(users.AuditTemporaryPassword, admin, user, uu.now()))
+	})
+	if err != nil {
+		return "", newInternalError("SetTemporaryPassword", "failed updating user", err)
+	}
+
+	return plain, nil
+}
+
+// GetAuditEvents returns the audit log, the newest events first
+func (uu *userUsecase) GetAuditEvents(ctx context.Context) ([]users.AuditEvent, error) {
+	events, err := uu.dbRepo.GetAuditEvents(ctx)
+	if err != nil {
+		return nil, newInternalError("GetAuditEvents", "failed getting audit events", err)
+	}
+	return events, nil
+}
+
+// getManagedUser returns the admin and the user for the audit log
+func (uu *userUsecase) getManagedUser(ctx context.Context, component string, adminID, userID uuid.UUID) (users.User, users.User, error) {
+	user, err := uu.dbRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if uu.dbRepo.IsEmptyRows(err) {
+			return users.User{}, users.User{}, errUserNotFound
+		}
+		return users.User{}, users.User{}, newInternalError(component, "failed finding user", err)
+	}
+
+	admin, err := uu.dbRepo.GetUserByID(ctx, adminID)
+	if err != nil {
+		return users.User{}, users.User{}, newInternalError(component, "failed finding admin", err)
+	}
+
+	return admin, user, nil
+}
+
+// RemoveUserByAdmin removes the user with all data, like RemoveUser
+func (uu *userUsecase) RemoveUserByAdmin(ctx context.Context, adminID, userID uuid.UUID) error {
+	if adminID == userID {
+		return errManageOwnUser
+	}
+
+	admin, user, err := uu.getManagedUser(ctx, "RemoveUserByAdmin", adminID, userID)
+	if err != nil {
+		return err
+	}
+
+	err = uu.dbRepo.WithinTx(ctx, func(txCtx context.Context) error {
+		if err := uu.dbRepo.RemoveUser(txCtx, userID); err != nil {
+			return err
+		}
+		return uu.dbRepo.AddAuditEvent(txCtx, newAuditEvent(users.AuditUserRemoved, admin, user, uu.now()))
+	})
+	if err != nil {
+		return newInternalError("RemoveUserByAdmin", "failed removing user", err)
+	}
+	return nil
+}
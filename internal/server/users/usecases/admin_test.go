@@ -0,0 +1,507 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"passman/internal/server/users"
+	mock_usecases "passman/internal/server/users/usecases/mock"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/google/uuid"
+	"go.uber.org/mock/gomock"
+)
+
+func TestIsAdmin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	userUsecase := New(mockRepo, Options{})
+
+	ctx := context.Background()
+	errEmptyRows := errors.New("empty rows")
+	userID := uuid.New()
+
+	tests := []struct {
+		name     string
+		user     users.User
+		getErr   error
+		expAdmin bool
+		expErr   error
+	}{
+		{
+			name:   "failed_finding_user",
+			getErr: errors.New("internal error"),
+			expErr: errors.New("IsAdmin: failed finding user"),
+		},
+		{
+			name:   "user_not_found",
+			getErr: errEmptyRows,
+		},
+		{
+			name: "regular_user",
+			user: users.User{ID: userID},
+		},
+		{
+			name: "disabled_admin",
+			user: users.User{ID: userID, IsAdmin: true, Disabled: true},
+		},
+		{
+			name: "admin_scheduled_for_deletion",
+			user: users.User{ID: userID, IsAdmin: true, DeleteAt: time.Unix(1700000000, 0)},
+		},
+		{
+			name:     "admin",
+			user:     users.User{ID: userID, IsAdmin: true},
+			expAdmin: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().GetUserByID(ctx, userID).Return(test.user, test.getErr).Times(1)
+			if test.getErr != nil {
+				mockRepo.EXPECT().IsEmptyRows(test.getErr).Return(test.getErr == errEmptyRows).Times(1)
+			}
+
+			isAdmin, actErr := userUsecase.IsAdmin(ctx, userID)
+
+			if got, want := actErr, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if got, want := isAdmin, test.expAdmin; got != want {
+				t.Errorf("Wrong! Unexpected result!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestSetUserDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	userUsecase := New(mockRepo, Options{})
+	now := time.Unix(1700000000, 0)
+	userUsecase.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	errEmptyRows := errors.New("empty rows")
+	admin := users.User{ID: uuid.New(), Username: "admin", IsAdmin: true}
+	user := users.User{ID: uuid.New(), Username: "user"}
+	scheduledUser := users.User{ID: user.ID, Username: user.Username, DeleteAt: now.Add(time.Hour)}
+
+	tests := []struct {
+		name      string
+		userID    uuid.UUID
+		user      users.User
+		getErr    error
+		disabled  bool
+		found     bool
+		updateErr error
+		audit     bool
+		expErr    error
+	}{
+		{
+			name:   "own_user",
+			userID: admin.ID,
+			expErr: errors.New("ClientError: admin can't manage own user"),
+		},
+		{
+			name:   "user_not_found",
+			userID: user.ID,
+			getErr: errEmptyRows,
+			expErr: errors.New("ClientError: user not found"),
+		},
+		{
+			name:      "failed_updating_user",
+			userID:    user.ID,
+			user:      user,
+			disabled:  true,
+			updateErr: errors.New("internal error"),
+			expErr:    errors.New("SetUserDisabled: failed updating user"),
+		},
+		{
+			name:     "removed_user",
+			userID:   user.ID,
+			user:     user,
+			disabled: true,
+			expErr:   errors.New("ClientError: user not found"),
+		},
+		{
+			name:     "disable",
+			userID:   user.ID,
+			user:     user,
+			disabled: true,
+			found:    true,
+		},
+		{
+			name:     "disable_scheduled_user",
+			userID:   user.ID,
+			user:     scheduledUser,
+			disabled: true,
+			found:    true,
+		},
+		{
+			name:   "enable",
+			userID: user.ID,
+			user:   user,
+			found:  true,
+		},
+		{
+			name:   "enable_scheduled_user",
+			userID: user.ID,
+			user:   scheduledUser,
+			found:  true,
+			audit:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.userID != admin.ID {
+				mockRepo.EXPECT().GetUserByID(ctx, test.userID).Return(test.user, test.getErr).Times(1)
+			}
+			if test.getErr != nil {
+				mockRepo.EXPECT().IsEmptyRows(test.getErr).Return(true).Times(1)
+			}
+
+			if test.userID != admin.ID && test.getErr == nil {
+				mockRepo.EXPECT().GetUserByID(ctx, admin.ID).Return(admin, nil).Times(1)
+				mockRepo.EXPECT().
+					WithinTx(ctx, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+						return fn(ctx)
+					}).
+					Times(1)
+				mockRepo.EXPECT().
+					SetUserDisabled(ctx, test.userID, test.disabled).
+					Return(test.found, test.updateErr).
+					Times(1)
+			}
+
+			if test.audit {
+				mockRepo.EXPECT().
+					AddAuditEvent(ctx, gomock.Any()).
+					DoAndReturn(func(_ context.Context, e users.AuditEvent) error {
+						if e.Action != users.AuditDeletionCanceled || e.ActorID != admin.ID || e.TargetID != test.user.ID {
+							t.Errorf("Wrong! Unexpected audit event: %+v", e)
+						}
+						return nil
+					}).
+					Times(1)
+			}
+
+			actErr := userUsecase.SetUserDisabled(ctx, admin.ID, test.userID, test.disabled)
+
+			if got, want := actErr, test.expErr; !errors.Is(got, want) {
+				t.Errorf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestRequirePasswordReset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	userUsecase := New(mockRepo, Options{})
+	now := time.Unix(1700000000, 0)
+	userUsecase.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	errEmptyRows := errors.New("empty rows")
+	admin := users.User{ID: uuid.New(), Username: "admin", IsAdmin: true}
+	user := users.User{ID: uuid.New(), Username: "user", Password: "hash"}
+
+	tests := []struct {
+		name            string
+		userID          uuid.UUID
+		findErr         error
+		withoutPassword bool
+		updateCalls     int
+		found           bool
+		expErr          error
+	}{
+		{
+			name:   "own_user",
+			userID: admin.ID,
+			expErr: errors.New("ClientError: admin can't manage own user"),
+		},
+		{
+			name:    "user_not_found",
+			userID:  user.ID,
+			findErr: errEmptyRows,
+			expErr:  errors.New("ClientError: user not found"),
+		},
+		{
+			name:            "user_without_password",
+			userID:          user.ID,
+			withoutPassword: true,
+			expErr:          errors.New("ClientError: user without the password logs in by the identity provider"),
+		},
+		{
+			name:        "user_removed",
+			userID:      user.ID,
+			updateCalls: 1,
+			expErr:      errors.New("ClientError: user not found"),
+		},
+		{
+			name:        "success",
+			userID:      user.ID,
+			updateCalls: 1,
+			found:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.userID != admin.ID {
+				foundUser := user
+				if test.withoutPassword {
+					foundUser.Password = ""
+				}
+				mockRepo.EXPECT().GetUserByID(ctx, user.ID).Return(foundUser, test.findErr).Times(1)
+				if test.findErr != nil {
+					mockRepo.EXPECT().IsEmptyRows(test.findErr).Return(true).Times(1)
+				} else {
+					mockRepo.EXPECT().GetUserByID(ctx, admin.ID).Return(admin, nil).Times(1)
+				}
+				if test.findErr == nil && !test.withoutPassword {
+					mockRepo.EXPECT().
+						WithinTx(ctx, gomock.Any()).
+						DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+							return fn(ctx)
+						}).
+						Times(1)
+				}
+			}
+			mockRepo.EXPECT().RequirePasswordReset(ctx, test.userID).Return(test.found, nil).Times(test.updateCalls)
+
+			var event users.AuditEvent
+			auditCalls := 0
+			if test.found {
+				auditCalls = 1
+			}
+			mockRepo.EXPECT().
+				AddAuditEvent(ctx, gomock.Any()).
+				DoAndReturn(func(_ context.Context, e users.AuditEvent) error {
+					event = e
+					return nil
+				}).
+				Times(auditCalls)
+
+			actErr := userUsecase.RequirePasswordReset(ctx, admin.ID, test.userID)
+
+			if got, want := actErr, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if !test.found {
+				return
+			}
+
+			expEvent := users.AuditEvent{
+				ID:             event.ID,
+				Action:         users.AuditPasswordResetRequired,
+				ActorID:        admin.ID,
+				ActorUsername:  admin.Username,
+				TargetID:       user.ID,
+				TargetUsername: user.Username,
+				CreatedAt:      now,
+			}
+			if got, want := event, expEvent; got != want {
+				t.Errorf("Wrong! Unexpected audit event!\n\tExpected: %+v\n\tActual: %+v", want, got)
+			}
+		})
+	}
+}
+
+func TestSetTemporaryPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	userUsecase := New(mockRepo, Options{})
+	now := time.Unix(1700000000, 0)
+	userUsecase.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	admin := users.User{ID: uuid.New(), Username: "admin", IsAdmin: true}
+	user := users.User{ID: uuid.New(), Username: "user", Password: "hash"}
+	providerUser := users.User{ID: uuid.New(), Username: "provider"}
+
+	tests := []struct {
+		name   string
+		user   users.User
+		update bool
+		expErr error
+	}{
+		{
+			name:   "own_user",
+			user:   admin,
+			expErr: errors.New("ClientError: admin can't manage own user"),
+		},
+		{
+			name:   "user_without_password",
+			user:   providerUser,
+			expErr: errors.New("ClientError: user without the password logs in by the identity provider"),
+		},
+		{
+			name:   "success",
+			user:   user,
+			update: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.user.ID != admin.ID {
+				mockRepo.EXPECT().GetUserByID(ctx, test.user.ID).Return(test.user, nil).Times(1)
+				mockRepo.EXPECT().GetUserByID(ctx, admin.ID).Return(admin, nil).Times(1)
+			}
+
+			var updated users.User
+			if test.update {
+				mockRepo.EXPECT().
+					WithinTx(ctx, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+						return fn(ctx)
+					}).
+					Times(1)
+				mockRepo.EXPECT().
+					UpdateUser(ctx, gomock.Any()).
+					DoAndReturn(func(_ context.Context, u users.User) error {
+						updated = u
+						return nil
+					}).
+					Times(1)
+				mockRepo.EXPECT().RemoveAccessTokens(ctx, test.user.ID).Return(nil).Times(1)
+				mockRepo.EXPECT().
+					AddAuditEvent(ctx, gomock.Any()).
+					DoAndReturn(func(_ context.Context, e users.AuditEvent) error {
+						if e.Action != users.AuditTemporaryPassword || e.ActorID != admin.ID || e.TargetID != test.user.ID {
+							t.Errorf("Wrong! Unexpected audit event: %+v", e)
+						}
+						return nil
+					}).
+					Times(1)
+			}
+
+			password, actErr := userUsecase.SetTemporaryPassword(ctx, admin.ID, test.user.ID)
+
+			if got, want := actErr, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if !test.update {
+				return
+			}
+
+			if !updated.PasswordResetRequired {
+				t.Errorf("Wrong! Temporary password doesn't require the reset")
+			}
+			if match, _ := argon2id.ComparePasswordAndHash(password, updated.Password); !match {
+				t.Errorf("Wrong! Returned password doesn't match the hash")
+			}
+		})
+	}
+}
+
+func TestRemoveUserByAdmin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	userUsecase := New(mockRepo, Options{})
+	userUsecase.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	errEmptyRows := errors.New("empty rows")
+	admin := users.User{ID: uuid.New(), Username: "admin", IsAdmin: true}
+	user := users.User{ID: uuid.New(), Username: "user"}
+
+	tests := []struct {
+		name      string
+		userID    uuid.UUID
+		getCalls  int
+		getErr    error
+		removeErr error
+		auditErr  error
+		expErr    error
+	}{
+		{
+			name:   "own_user",
+			userID: admin.ID,
+			expErr: errors.New("ClientError: admin can't manage own user"),
+		},
+		{
+			name:     "user_not_found",
+			userID:   user.ID,
+			getCalls: 1,
+			getErr:   errEmptyRows,
+			expErr:   errors.New("ClientError: user not found"),
+		},
+		{
+			name:      "failed_removing_user",
+			userID:    user.ID,
+			getCalls:  1,
+			removeErr: errors.New("internal error"),
+			expErr:    errors.New("RemoveUserByAdmin: failed removing user"),
+		},
+		{
+			name:     "failed_adding_audit_event",
+			userID:   user.ID,
+			getCalls: 1,
+			auditErr: errors.New("internal error"),
+			expErr:   errors.New("RemoveUserByAdmin: failed removing user"),
+		},
+		{
+			name:     "success",
+			userID:   user.ID,
+			getCalls: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().GetUserByID(ctx, test.userID).Return(user, test.getErr).Times(test.getCalls)
+			if test.getErr != nil {
+				mockRepo.EXPECT().IsEmptyRows(test.getErr).Return(true).Times(1)
+			}
+
+			if test.getCalls > 0 && test.getErr == nil {
+				mockRepo.EXPECT().GetUserByID(ctx, admin.ID).Return(admin, nil).Times(1)
+				mockRepo.EXPECT().
+					WithinTx(ctx, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+						return fn(ctx)
+					}).
+					Times(1)
+				mockRepo.EXPECT().RemoveUser(ctx, test.userID).Return(test.removeErr).Times(1)
+
+				if test.removeErr == nil {
+					mockRepo.EXPECT().
+						AddAuditEvent(ctx, gomock.Any()).
+						DoAndReturn(func(_ context.Context, e users.AuditEvent) error {
+							if e.Action != users.AuditUserRemoved || e.ActorID != admin.ID || e.TargetID != user.ID ||
+								e.TargetUsername != user.Username || !e.CreatedAt.Equal(now) {
+								t.Errorf("Wrong! Unexpected audit event: %+v", e)
+							}
+							return test.auditErr
+						}).
+						Times(1)
+				}
+			}
+
+			actErr := userUsecase.RemoveUserByAdmin(ctx, admin.ID, test.userID)
+
+			if got, want := actErr, test.expErr; !errors.Is(got, want) {
+				t.Errorf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"passman/internal/server/users"
+
+	"github.com/google/uuid"
+)
+
+const defaultDeletionGracePeriod = 7 * 24 * time.Hour
+
+var (
+	errDeletionScheduled    = newForbiddenError("user is scheduled for deletion")
+	errDeletionNotScheduled = newClientError("user isn't scheduled for deletion")
+	errSecondFactorRequired = newClientError("two-factor code or passkey required")
+	errGracePeriodOver      = newClientError("grace period is over")
+	// Local admins are made only by the registration of the first user, so
+	// the installation without admins can't get them back
+	errLastAdmin = newClientError("the last admin can't delete own user")
+)
+
+// ConfirmDeletion checks the password and the second factor of the user who
+// asks for the deletion. Users of the identity provider have no password, the
+// recent login by the provider confirms them.
+func (uu *userUsecase) ConfirmDeletion(ctx context.Context, userID uuid.UUID, confirmation users.DeletionConfirmation) error {
+	user, err := uu.dbRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return newInternalError("ConfirmDeletion", "failed finding user", err)
+	}
+
+	if len(user.Password) > 0 || uu.ldap.Directory != nil {
+		if err := uu.confirmPassword(ctx, "ConfirmDeletion", userID, confirmation.Password); err != nil {
+			return err
+		}
+	}
+
+	return uu.confirmSecondFactor(ctx, userID, confirmation)
+}
+
+// confirmSecondFactor checks the code, the recovery code or the passkey of
+// the user with two-factor authentication
+func (uu *userUsecase) confirmSecondFactor(ctx context.Context, userID uuid.UUID, confirmation users.DeletionConfirmation) error {
+	userTOTP, err := uu.dbRepo.GetTOTP(ctx, userID)
+	if err != nil && !uu.dbRepo.IsEmptyRows(err) {
+		return newInternalError("ConfirmDeletion", "failed getting two-factor settings", err)
+	}
+
+	passkeys, err := uu.dbRepo.GetWebAuthnCredentials(ctx, userID)
+	if err != nil {
+		return newInternalError("ConfirmDeletion", "failed getting passkeys", err)
+	}
+
+	switch {
+	case userTOTP.Enabled && (len(confirmation.Code) > 0 || len(confirmation.RecoveryCode) > 0):
+		return uu.verifyTOTP(ctx, userID, confirmation.Code, confirmation.RecoveryCode)
+	case len(passkeys) > 0 && confirmation.Passkey != nil:
+		_, err := uu.verifyPasskey(ctx, userID, confirmation.PasskeyChallenge, *confirmation.Passkey)
+		return err
+	case userTOTP.Enabled || len(passkeys) > 0:
+		return errSecondFactorRequired
+	}
+	return nil
+}
+
+// ScheduleDeletion blocks the login of the confirmed user and returns the time
+// when the user is removed. Access tokens are revoked, sessions are destroyed
+// by the caller.
+func (uu *userUsecase) ScheduleDeletion(ctx context.Context, userID uuid.UUID) (time.Time, error) {
+	user, err := uu.dbRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return time.Time{}, newInternalError("ScheduleDeletion", "failed finding user", err)
+	}
+
+	now := uu.now()
+	deleteAt := now.Add(uu.deletionGrace)
+
+	err = uu.dbRepo.WithinTx(ctx, func(txCtx context.Context) error {
+		if user.IsAdmin {
+			admins, err := uu.dbRepo.CountAdmins(txCtx)
+			if err != nil {
+				return err
+			}
+			if admins <= 1 {
+				return errLastAdmin
+			}
+		}
+
+		scheduled, err := uu.dbRepo.ScheduleDeletion(txCtx, userID, deleteAt)
+		if err != nil {
+			return err
+		}
+		if !scheduled {
+			return errDeletionScheduled
+		}
+		if err := uu.dbRepo.RemoveAccessTokens(txCtx, userID); err != nil {
+			return err
+		}
+		return uu.dbRepo.AddAuditEvent(txCtx, newAuditEvent(users.AuditDeletionScheduled, user, user, now))
+	})
+	if errors.Is(err, errDeletionScheduled) || errors.Is(err, errLastAdmin) {
+		return time.Time{}, err
+	}
+	if err != nil {
+		return time.Time{}, newInternalError("ScheduleDeletion", "failed scheduling deletion", err)
+	}
+
+	return deleteAt, nil
+}
+
+// RestoreUser cancels the scheduled deletion by the password of the user.
+// Attempts are throttled like logins, the second factor is asked by the next
+// login. The user disabled by the admin stays disabled.
+func (uu *userUsecase) RestoreUser(ctx context.Context, userCreds users.UserDTO, ip string) error {
+	now := uu.now()
+	if err := uu.checkThrottles(ctx, userCreds.Username, ip, now); err != nil {
+		return err
+	}
+
+	user, err := uu.dbRepo.GetUser(ctx, userCreds.Username)
+	userExists := err == nil
+	if err != nil && !uu.dbRepo.IsEmptyRows(err) {
+		return newInternalError("RestoreUser", "failed finding user", err)
+	}
+
+	// Unknown directory users aren't provisioned, unlike the login
+	hasPassword := userExists && len(user.Password) > 0
+	var match bool
+	if userExists && !hasPassword && uu.ldap.Directory != nil {
+		if match, err = uu.verifyDirectoryPassword(ctx, user.ID, userCreds.Password); err != nil {
+			return err
+		}
+	} else {
+		_, _, err = uu.loginLocal(user, hasPassword, userCreds.Password)
+		if err != nil && !errors.Is(err, errInvalidCredentials) {
+			return err
+		}
+		match = err == nil
+	}
+	if !match {
+		if err := uu.addLoginFailure(ctx, userCreds.Username, ip, now); err != nil {
+			return err
+		}
+		return errInvalidCredentials
+	}
+	if user.DeleteAt.IsZero() {
+		return errDeletionNotScheduled
+	}
+	// The user waits for the purge
+	if !user.DeleteAt.After(now) {
+		return errGracePeriodOver
+	}
+
+	err = uu.dbRepo.WithinTx(ctx, func(txCtx context.Context) error {
+		canceled, err := uu.dbRepo.CancelDeletion(txCtx, user.ID)
+		if err != nil {
+			return err
+		}
+		// The user is removed or enabled by the admin in the meantime
+		if !canceled {
+			return errDeletionNotScheduled
+		}
+		return uu.dbRepo.AddAuditEvent(txCtx, newAuditEvent(users.AuditDeletionCanceled, user, user, now))
+	})
+	if errors.Is(err, errDeletionNotScheduled) {
+		return errDeletionNotScheduled
+	}
+	if err != nil {
+		return newInternalError("RestoreUser", "failed canceling deletion", err)
+	}
+
+	if err := uu.dbRepo.RemoveLoginThrottle(ctx, users.ThrottleUsername, userCreds.Username); err != nil {
+		return newInternalError("RestoreUser", "failed removing login throttle", err)
+	}
+	return nil
+}
+
+// PurgeDeletedUsers removes users whose grace period is over with all their
+// data and returns the number of removed users
+func (uu *userUsecase) PurgeDeletedUsers(ctx context.Context) (int, error) {
+	now := uu.now()
+	due, err := uu.dbRepo.GetDueDeletions(ctx, now)
+	if err != nil {
+		return 0, newInternalError("PurgeDeletedUsers", "failed getting due deletions", err)
+	}
+
+	purged := 0
+	for _, user := range due {
+		removed := false
+		err := uu.dbRepo.WithinTx(ctx, func(txCtx context.Context) error {
+			var err error
+			if removed, err = uu.dbRepo.RemoveDeletedUser(txCtx, user.ID, now); err != nil || !removed {
+				return err
+			}
+			return uu.dbRepo.AddAuditEvent(txCtx, newAuditEvent(users.AuditUserPurged, user, user, now))
+		})
+		if err != nil {
+			return purged, newInternalError("PurgeDeletedUsers", "failed removing user", err)
+		}
+		if removed {
+			purged++
+		}
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,372 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"passman/internal/server/users"
+	mock_usecases "passman/internal/server/users/usecases/mock"
+	"passman/pkg/totp"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/google/uuid"
+	"go.uber.org/mock/gomock"
+)
+
+func TestConfirmDeletion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	userUsecase := New(mockRepo, Options{})
+	now := time.Unix(1700000000, 0)
+	userUsecase.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	errEmptyRows := errors.New("empty rows")
+	userID := uuid.New()
+	hash, _ := argon2id.CreateHash("password", &argon2id.Params{Memory: 8 * 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	secret := "JBSWY3DPEHPK3PXP"
+	validCode, _ := totp.Code(secret, now)
+	step := totp.Step(now)
+	enabledTOTP := users.TOTP{UserID: userID, Secret: secret, Enabled: true, LastStep: step - 1}
+
+	tests := []struct {
+		name         string
+		user         users.User
+		confirmation users.DeletionConfirmation
+		totp         users.TOTP
+		totpErr      error
+		passkeys     []users.WebAuthnCredential
+		secondFactor bool
+		loginTOTP    bool
+		expErr       error
+	}{
+		{
+			name:         "incorrect_password",
+			user:         users.User{ID: userID, Password: hash},
+			confirmation: users.DeletionConfirmation{Password: "wrong"},
+			expErr:       errors.New("ClientError: incorrect password"),
+		},
+		{
+			name:         "without_second_factor",
+			user:         users.User{ID: userID, Password: hash},
+			confirmation: users.DeletionConfirmation{Password: "password"},
+			totpErr:      errEmptyRows,
+			secondFactor: true,
+		},
+		{
+			name:         "identity_provider_user",
+			user:         users.User{ID: userID},
+			totpErr:      errEmptyRows,
+			secondFactor: true,
+		},
+		{
+			name:         "second_factor_required",
+			user:         users.User{ID: userID, Password: hash},
+			confirmation: users.DeletionConfirmation{Password: "password"},
+			totp:         enabledTOTP,
+			secondFactor: true,
+			expErr:       errors.New("ClientError: two-factor code or passkey required"),
+		},
+		{
+			name:         "passkey_required",
+			user:         users.User{ID: userID, Password: hash},
+			confirmation: users.DeletionConfirmation{Password: "password", Code: validCode},
+			totpErr:      errEmptyRows,
+			passkeys:     []users.WebAuthnCredential{{UserID: userID}},
+			secondFactor: true,
+			expErr:       errors.New("ClientError: two-factor code or passkey required"),
+		},
+		{
+			name:         "two_factor_code",
+			user:         users.User{ID: userID, Password: hash},
+			confirmation: users.DeletionConfirmation{Password: "password", Code: validCode},
+			totp:         enabledTOTP,
+			secondFactor: true,
+			loginTOTP:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// The password is verified by the second lookup of the user
+			getCalls := 1
+			if len(test.user.Password) > 0 {
+				getCalls = 2
+			}
+			mockRepo.EXPECT().GetUserByID(ctx, userID).Return(test.user, nil).Times(getCalls)
+			mockRepo.EXPECT().IsEmptyRows(errEmptyRows).Return(true).AnyTimes()
+			if test.secondFactor {
+				totpCalls := 1
+				if test.loginTOTP {
+					totpCalls = 2
+				}
+				mockRepo.EXPECT().GetTOTP(ctx, userID).Return(test.totp, test.totpErr).Times(totpCalls)
+				mockRepo.EXPECT().GetWebAuthnCredentials(ctx, userID).Return(test.passkeys, nil).Times(1)
+			}
+			if test.loginTOTP {
+				mockRepo.EXPECT().UpdateTOTPStep(ctx, userID, step).Return(true, nil).Times(1)
+			}
+
+			err := userUsecase.ConfirmDeletion(ctx, userID, test.confirmation)
+
+			if got, want := err, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestScheduleDeletion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	userUsecase := New(mockRepo, Options{DeletionGracePeriod: 24 * time.Hour})
+	now := time.Unix(1700000000, 0)
+	userUsecase.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	userID := uuid.New()
+	deleteAt := now.Add(24 * time.Hour)
+
+	tests := []struct {
+		name        string
+		isAdmin     bool
+		admins      int64
+		scheduled   bool
+		scheduleErr error
+		expDeleteAt time.Time
+		expErr      error
+	}{
+		{
+			name:    "last_admin",
+			isAdmin: true,
+			admins:  1,
+			expErr:  errors.New("ClientError: the last admin can't delete own user"),
+		},
+		{
+			name:   "already_scheduled",
+			expErr: errors.New("ClientError: user is scheduled for deletion"),
+		},
+		{
+			name:        "failed_scheduling",
+			scheduleErr: errors.New("internal error"),
+			expErr:      errors.New("ScheduleDeletion: failed scheduling deletion"),
+		},
+		{
+			name:        "success",
+			scheduled:   true,
+			expDeleteAt: deleteAt,
+		},
+		{
+			name:        "admin_with_other_admins",
+			isAdmin:     true,
+			admins:      2,
+			scheduled:   true,
+			expDeleteAt: deleteAt,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().GetUserByID(ctx, userID).Return(users.User{ID: userID, IsAdmin: test.isAdmin}, nil).Times(1)
+			mockRepo.EXPECT().
+				WithinTx(ctx, gomock.Any()).
+				DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error { return fn(ctx) }).
+				Times(1)
+			if test.isAdmin {
+				mockRepo.EXPECT().CountAdmins(ctx).Return(test.admins, nil).Times(1)
+			}
+			if !test.isAdmin || test.admins > 1 {
+				mockRepo.EXPECT().ScheduleDeletion(ctx, userID, deleteAt).Return(test.scheduled, test.scheduleErr).Times(1)
+				if test.scheduled {
+					mockRepo.EXPECT().RemoveAccessTokens(ctx, userID).Return(nil).Times(1)
+					mockRepo.EXPECT().
+						AddAuditEvent(ctx, gomock.Any()).
+						DoAndReturn(func(_ context.Context, event users.AuditEvent) error {
+							if event.Action != users.AuditDeletionScheduled {
+								t.Errorf("Wrong! Unexpected audit action!\n\tExpected: %v\n\tActual: %v", users.AuditDeletionScheduled, event.Action)
+							}
+							return nil
+						}).
+						Times(1)
+				}
+			}
+
+			actDeleteAt, err := userUsecase.ScheduleDeletion(ctx, userID)
+
+			if got, want := err, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if got, want := actDeleteAt, test.expDeleteAt; !got.Equal(want) {
+				t.Errorf("Wrong! Unexpected time of deletion!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestRestoreUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	userUsecase := New(mockRepo, Options{})
+	now := time.Unix(1700000000, 0)
+	userUsecase.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	errEmptyRows := errors.New("empty rows")
+	ip := "192.0.2.1"
+	userCreds := users.UserDTO{Username: "alice", Password: "password"}
+	hash, _ := argon2id.CreateHash(userCreds.Password, &argon2id.Params{Memory: 8 * 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	scheduledUser := users.User{ID: uuid.New(), Username: userCreds.Username, Password: hash, DeleteAt: now.Add(time.Hour)}
+
+	tests := []struct {
+		name      string
+		password  string
+		user      users.User
+		getErr    error
+		canceled  *bool
+		expErr    error
+		expFailed bool
+	}{
+		{
+			name:      "unknown_user",
+			password:  userCreds.Password,
+			getErr:    errEmptyRows,
+			expFailed: true,
+			expErr:    errors.New("ClientError: invalid username or password"),
+		},
+		{
+			name:      "wrong_password",
+			password:  "wrong",
+			user:      scheduledUser,
+			expFailed: true,
+			expErr:    errors.New("ClientError: invalid username or password"),
+		},
+		{
+			name:     "not_scheduled",
+			password: userCreds.Password,
+			user:     users.User{ID: scheduledUser.ID, Username: userCreds.Username, Password: hash},
+			expErr:   errors.New("ClientError: user isn't scheduled for deletion"),
+		},
+		{
+			name:     "grace_period_over",
+			password: userCreds.Password,
+			user:     users.User{ID: scheduledUser.ID, Username: userCreds.Username, Password: hash, DeleteAt: now},
+			expErr:   errors.New("ClientError: grace period is over"),
+		},
+		{
+			name:     "canceled_meanwhile",
+			password: userCreds.Password,
+			user:     scheduledUser,
+			canceled: new(bool),
+			expErr:   errors.New("ClientError: user isn't scheduled for deletion"),
+		},
+		{
+			name:     "success",
+			password: userCreds.Password,
+			user:     scheduledUser,
+			canceled: func() *bool { b := true; return &b }(),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().IsEmptyRows(errEmptyRows).Return(true).AnyTimes()
+			mockRepo.EXPECT().GetLoginThrottle(ctx, users.ThrottleUsername, userCreds.Username).Return(users.LoginThrottle{}, errEmptyRows).Times(1)
+			mockRepo.EXPECT().GetLoginThrottle(ctx, users.ThrottleIP, ip).Return(users.LoginThrottle{}, errEmptyRows).Times(1)
+			mockRepo.EXPECT().GetUser(ctx, userCreds.Username).Return(test.user, test.getErr).Times(1)
+			if test.expFailed {
+				mockRepo.EXPECT().AddLoginFailure(ctx, gomock.Any(), gomock.Any(), now, gomock.Any()).Return(int64(1), nil).Times(2)
+				mockRepo.EXPECT().RemoveStaleLoginThrottles(ctx, gomock.Any(), now).Return(nil).Times(1)
+			}
+			if test.canceled != nil {
+				mockRepo.EXPECT().
+					WithinTx(ctx, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error { return fn(ctx) }).
+					Times(1)
+				mockRepo.EXPECT().CancelDeletion(ctx, scheduledUser.ID).Return(*test.canceled, nil).Times(1)
+				if *test.canceled {
+					mockRepo.EXPECT().AddAuditEvent(ctx, gomock.Any()).Return(nil).Times(1)
+					mockRepo.EXPECT().RemoveLoginThrottle(ctx, users.ThrottleUsername, userCreds.Username).Return(nil).Times(1)
+				}
+			}
+
+			err := userUsecase.RestoreUser(ctx, users.UserDTO{Username: userCreds.Username, Password: test.password}, ip)
+
+			if got, want := err, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestPurgeDeletedUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	userUsecase := New(mockRepo, Options{})
+	now := time.Unix(1700000000, 0)
+	userUsecase.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	due := []users.User{{ID: uuid.New(), Username: "alice"}, {ID: uuid.New(), Username: "bob"}}
+
+	tests := []struct {
+		name      string
+		getErr    error
+		removed   []bool
+		removeErr error
+		expPurged int
+		expErr    error
+	}{
+		{
+			name:   "failed_getting_due_deletions",
+			getErr: errors.New("internal error"),
+			expErr: errors.New("PurgeDeletedUsers: failed getting due deletions"),
+		},
+		{
+			name:      "failed_removing_user",
+			removed:   []bool{false},
+			removeErr: errors.New("internal error"),
+			expErr:    errors.New("PurgeDeletedUsers: failed removing user"),
+		},
+		{
+			// The second user is restored after the due deletions are read
+			name:      "restored_meanwhile",
+			removed:   []bool{true, false},
+			expPurged: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().GetDueDeletions(ctx, now).Return(due, test.getErr).Times(1)
+			for i, removed := range test.removed {
+				mockRepo.EXPECT().
+					WithinTx(ctx, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error { return fn(ctx) }).
+					Times(1)
+				mockRepo.EXPECT().RemoveDeletedUser(ctx, due[i].ID, now).Return(removed, test.removeErr).Times(1)
+				if removed {
+					mockRepo.EXPECT().AddAuditEvent(ctx, gomock.Any()).Return(nil).Times(1)
+				}
+			}
+
+			purged, err := userUsecase.PurgeDeletedUsers(ctx)
+
+			if got, want := err, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if got, want := purged, test.expPurged; got != want {
+				t.Errorf("Wrong! Unexpected result!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
@@ -28,6 +28,27 @@ func newClientError(msg string) error {
 	return &userError{Code: 400, Component: "ClientError", Msg: msg, Err: nil}
 }
 
+// newThrottledError is the client error with 429 code
+func newThrottledError(msg string) error {
+	return &userError{Code: 429, Component: "ClientError", Msg: msg, Err: nil}
+}
+
+// newForbiddenError is the client error with 403 code
+func newForbiddenError(msg string) error {
+	return &userError{Code: 403, Component: "ClientError", Msg: msg, Err: nil}
+}
+
+// newConflictError is the client error with 409 code
+func newConflictError(msg string) error {
+	return &userError{Code: 409, Component: "ClientError", Msg: msg, Err: nil}
+}
+
+// newWeakPasswordError is the client error keeping *password.PolicyError for
+// the detailed response
+func newWeakPasswordError(policyErr error) error {
+	return &userError{Code: 400, Component: "ClientError", Msg: "weak password", Err: policyErr}
+}
+
 func newInternalError(component string, msg string, err error) error {
 	return &userError{Code: 500, Component: component, Msg: msg, Err: err}
 }
@@ -2,8 +2,10 @@ package usecases
 
 import (
 	"context"
+	"time"
 
 	"passman/internal/server/users"
+	"passman/pkg/ldap"
 
 	"github.com/google/uuid"
 )
@@ -14,6 +16,66 @@ type dbRepo interface {
 	GetUser(context.Context, string) (users.User, error)
 	GetUserByID(context.Context, uuid.UUID) (users.User, error)
 	UpdateUser(context.Context, users.User) error
+	UpdateProfile(context.Context, uuid.UUID, users.Profile) error
+	UpdateLastLogin(ctx context.Context, userID uuid.UUID, loginAt time.Time) error
+	// UpdatePasswordHash replaces the hash only if it's still oldHash
+	UpdatePasswordHash(ctx context.Context, userID uuid.UUID, oldHash, newHash string) error
 	RemoveUser(context.Context, uuid.UUID) error
+	GetUsers(context.Context) ([]users.UserSummary, error)
+	SetUserDisabled(ctx context.Context, userID uuid.UUID, disabled bool) (bool, error)
+	RequirePasswordReset(context.Context, uuid.UUID) (bool, error)
+	ScheduleDeletion(ctx context.Context, userID uuid.UUID, deleteAt time.Time) (bool, error)
+	CancelDeletion(context.Context, uuid.UUID) (bool, error)
+	GetDueDeletions(ctx context.Context, now time.Time) ([]users.User, error)
+	RemoveDeletedUser(ctx context.Context, userID uuid.UUID, now time.Time) (bool, error)
+	GetTOTP(context.Context, uuid.UUID) (users.TOTP, error)
+	SetTOTP(context.Context, users.TOTP) error
+	UpdateTOTPStep(context.Context, uuid.UUID, int64) (bool, error)
+	RemoveTOTP(context.Context, uuid.UUID) error
+	AddRecoveryCodes(context.Context, uuid.UUID, []string) error
+	CountRecoveryCodes(context.Context, uuid.UUID) (int64, error)
+	RemoveRecoveryCode(context.Context, uuid.UUID, string) (bool, error)
+	RemoveRecoveryCodes(context.Context, uuid.UUID) error
+	AddWebAuthnCredential(context.Context, users.WebAuthnCredential) error
+	GetWebAuthnCredential(context.Context, []byte) (users.WebAuthnCredential, error)
+	GetWebAuthnCredentials(context.Context, uuid.UUID) ([]users.WebAuthnCredential, error)
+	UpdateWebAuthnSignCount(ctx context.Context, id []byte, signCount uint32, usedAt time.Time) error
+	RemoveWebAuthnCredential(context.Context, uuid.UUID, []byte) (bool, error)
+	RemoveWebAuthnCredentials(context.Context, uuid.UUID) error
+	GetLoginThrottle(ctx context.Context, kind, subject string) (users.LoginThrottle, error)
+	AddLoginFailure(ctx context.Context, kind, subject string, failedAt, resetBefore time.Time) (int64, error)
+	LockLogin(ctx context.Context, kind, subject string, until time.Time) error
+	RemoveLoginThrottle(ctx context.Context, kind, subject string) error
+	RemoveStaleLoginThrottles(ctx context.Context, before, now time.Time) error
+	GetLockedLogins(ctx context.Context, kind string, now time.Time) ([]users.LoginThrottle, error)
+	AddAccessToken(context.Context, users.AccessToken) error
+	GetAccessTokenByHash(context.Context, []byte) (users.AccessToken, error)
+	GetAccessTokens(context.Context, uuid.UUID) ([]users.AccessToken, error)
+	UpdateAccessTokenLastUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+	RemoveAccessToken(ctx context.Context, userID, id uuid.UUID) (bool, error)
+	RemoveAccessTokens(context.Context, uuid.UUID) error
+	CountUsers(context.Context) (int64, error)
+	CountAdmins(context.Context) (int64, error)
+	AddInvite(context.Context, users.Invite) error
+	GetInviteByHash(context.Context, []byte) (users.Invite, error)
+	GetInvites(context.Context) ([]users.Invite, error)
+	UseInvite(ctx context.Context, id uuid.UUID, username string, usedAt time.Time) (bool, error)
+	RemoveInvite(context.Context, uuid.UUID) (bool, error)
+	SetUserAdmin(ctx context.Context, userID uuid.UUID, isAdmin bool) error
+	AddIdentity(context.Context, users.Identity) error
+	GetIdentityUserID(ctx context.Context, issuer, subject string) (uuid.UUID, error)
+	GetUserIdentity(ctx context.Context, userID uuid.UUID, issuer string) (users.Identity, error)
+	RemoveIdentity(ctx context.Context, userID uuid.UUID, issuer string) (bool, error)
+	SetRecoveryKey(context.Context, users.RecoveryKey) error
+	GetRecoveryKey(context.Context, uuid.UUID) (users.RecoveryKey, error)
+	AddAuditEvent(context.Context, users.AuditEvent) error
+	GetAuditEvents(context.Context) ([]users.AuditEvent, error)
+	WithinTx(context.Context, func(context.Context) error) error
 	IsEmptyRows(error) bool
+	IsUniqueViolation(error) bool
+}
+
+type directory interface {
+	Authenticate(ctx context.Context, username, password string) (ldap.User, error)
+	Bind(ctx context.Context, dn, password string) error
 }
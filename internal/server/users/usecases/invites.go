@@ -0,0 +1,117 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"passman/internal/server/users"
+
+	"github.com/google/uuid"
+)
+
+// inviteTokenPrefix makes invites recognizable by secret scanners
+const inviteTokenPrefix = "pmi_"
+
+var (
+	errRegistrationClosed = newForbiddenError("registration is closed")
+	errInviteRequired     = newForbiddenError("invite required")
+	errInvalidInvite      = newClientError("invalid invite")
+)
+
+// CreateInvite returns the invite and its token, the token isn't stored and
+// can't be shown again. Empty username lets the invite register any username.
+func (uu *userUsecase) CreateInvite(ctx context.Context, adminID uuid.UUID, username string, ttl time.Duration) (users.Invite, string, error) {
+	if len(username) > 0 {
+		if _, err := uu.dbRepo.GetUser(ctx, username); err == nil {
+			return users.Invite{}, "", errUserExist
+		} else if !uu.dbRepo.IsEmptyRows(err) {
+			return users.Invite{}, "", newInternalError("CreateInvite", "failed finding user", err)
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return users.Invite{}, "", newInternalError("CreateInvite", "failed generating token", err)
+	}
+	plain := inviteTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	now := uu.now()
+	invite := users.Invite{
+		ID:        uuid.New(),
+		Hash:      hashToken(plain),
+		Username:  username,
+		CreatedBy: adminID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	if err := uu.dbRepo.AddInvite(ctx, invite); err != nil {
+		return users.Invite{}, "", newInternalError("CreateInvite", "failed adding invite", err)
+	}
+
+	return invite, plain, nil
+}
+
+func (uu *userUsecase) GetInvites(ctx context.Context) ([]users.Invite, error) {
+	invites, err := uu.dbRepo.GetInvites(ctx)
+	if err != nil {
+		return nil, newInternalError("GetInvites", "failed getting invites", err)
+	}
+	return invites, nil
+}
+
+func (uu *userUsecase) RemoveInvite(ctx context.Context, inviteID uuid.UUID) error {
+	removed, err := uu.dbRepo.RemoveInvite(ctx, inviteID)
+	if err != nil {
+		return newInternalError("RemoveInvite", "failed removing invite", err)
+	}
+	if !removed {
+		return newClientError("invite not found")
+	}
+	return nil
+}
+
+// checkRegistration applies the registration mode. It returns the invite and
+// true if the registration has to use it. The first user is registered in any
+// mode, otherwise nobody could administrate the instance.
+func (uu *userUsecase) checkRegistration(ctx context.Context, username, inviteToken string) (users.Invite, bool, error) {
+	if uu.registrationMode == users.RegistrationOpen {
+		return users.Invite{}, false, nil
+	}
+
+	count, err := uu.dbRepo.CountUsers(ctx)
+	if err != nil {
+		return users.Invite{}, false, newInternalError("Registration", "failed counting users", err)
+	}
+	if count == 0 {
+		return users.Invite{}, false, nil
+	}
+
+	if uu.registrationMode != users.RegistrationInvite {
+		return users.Invite{}, false, errRegistrationClosed
+	}
+	if len(inviteToken) == 0 {
+		return users.Invite{}, false, errInviteRequired
+	}
+
+	invite, err := uu.dbRepo.GetInviteByHash(ctx, hashToken(inviteToken))
+	if err != nil {
+		if uu.dbRepo.IsEmptyRows(err) {
+			return users.Invite{}, false, errInvalidInvite
+		}
+		return users.Invite{}, false, newInternalError("Registration", "failed getting invite", err)
+	}
+
+	switch {
+	case invite.Used():
+		return users.Invite{}, false, errInvalidInvite
+	case invite.Expired(uu.now()):
+		return users.Invite{}, false, newClientError("invite expired")
+	case len(invite.Username) > 0 && invite.Username != username:
+		return users.Invite{}, false, newClientError("invite is issued for another username")
+	}
+
+	return invite, true, nil
+}
@@ -0,0 +1,239 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"passman/internal/server/users"
+	mock_usecases "passman/internal/server/users/usecases/mock"
+
+	"github.com/google/uuid"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRegistrationModes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	now := time.Unix(1700000000, 0)
+
+	ctx := context.Background()
+	errEmptyRows := errors.New("empty rows")
+	userCreds := users.UserDTO{
+		Username: "newuser",
+		Password: "test_password",
+	}
+	plain := inviteTokenPrefix + "secret"
+	inviteID := uuid.New()
+
+	tests := []struct {
+		name       string
+		mode       string
+		userCount  int64
+		token      string
+		invite     users.Invite
+		inviteErr  error
+		useInvite  bool
+		inviteUsed bool
+		expErr     error
+	}{
+		{
+			name: "open",
+			mode: users.RegistrationOpen,
+		},
+		{
+			name:      "closed",
+			mode:      users.RegistrationClosed,
+			userCount: 1,
+			expErr:    errors.New("ClientError: registration is closed"),
+		},
+		{
+			name: "closed_first_user",
+			mode: users.RegistrationClosed,
+		},
+		{
+			name:      "invite_required",
+			mode:      users.RegistrationInvite,
+			userCount: 1,
+			expErr:    errors.New("ClientError: invite required"),
+		},
+		{
+			name:      "unknown_invite",
+			mode:      users.RegistrationInvite,
+			userCount: 1,
+			token:     plain,
+			inviteErr: errEmptyRows,
+			expErr:    errors.New("ClientError: invalid invite"),
+		},
+		{
+			name:      "used_invite",
+			mode:      users.RegistrationInvite,
+			userCount: 1,
+			token:     plain,
+			invite:    users.Invite{ID: inviteID, ExpiresAt: now.Add(time.Hour), UsedAt: now},
+			expErr:    errors.New("ClientError: invalid invite"),
+		},
+		{
+			name:      "expired_invite",
+			mode:      users.RegistrationInvite,
+			userCount: 1,
+			token:     plain,
+			invite:    users.Invite{ID: inviteID, ExpiresAt: now},
+			expErr:    errors.New("ClientError: invite expired"),
+		},
+		{
+			name:      "another_username",
+			mode:      users.RegistrationInvite,
+			userCount: 1,
+			token:     plain,
+			invite:    users.Invite{ID: inviteID, Username: "another", ExpiresAt: now.Add(time.Hour)},
+			expErr:    errors.New("ClientError: invite is issued for another username"),
+		},
+		{
+			name:      "concurrently_used_invite",
+			mode:      users.RegistrationInvite,
+			userCount: 1,
+			token:     plain,
+			invite:    users.Invite{ID: inviteID, ExpiresAt: now.Add(time.Hour)},
+			useInvite: true,
+			expErr:    errors.New("ClientError: invalid invite"),
+		},
+		{
+			name:       "invite",
+			mode:       users.RegistrationInvite,
+			userCount:  1,
+			token:      plain,
+			invite:     users.Invite{ID: inviteID, Username: userCreds.Username, ExpiresAt: now.Add(time.Hour)},
+			useInvite:  true,
+			inviteUsed: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			userUsecase := New(mockRepo, Options{RegistrationMode: test.mode})
+			userUsecase.now = func() time.Time { return now }
+
+			mockRepo.EXPECT().GetUser(ctx, userCreds.Username).Return(users.User{}, errEmptyRows).Times(1)
+			mockRepo.EXPECT().IsEmptyRows(errEmptyRows).Return(true).Times(1)
+
+			if test.mode != users.RegistrationOpen {
+				mockRepo.EXPECT().CountUsers(ctx).Return(test.userCount, nil).Times(1)
+			}
+
+			if len(test.token) > 0 {
+				mockRepo.EXPECT().GetInviteByHash(ctx, hashToken(test.token)).Return(test.invite, test.inviteErr).Times(1)
+				if test.inviteErr != nil {
+					mockRepo.EXPECT().IsEmptyRows(test.inviteErr).Return(true).Times(1)
+				}
+			}
+
+			if test.expErr == nil || test.useInvite {
+				mockRepo.EXPECT().
+					WithinTx(ctx, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+						return fn(ctx)
+					}).
+					Times(1)
+			}
+			if test.useInvite {
+				mockRepo.EXPECT().UseInvite(ctx, inviteID, userCreds.Username, now).Return(test.inviteUsed, nil).Times(1)
+			}
+			if test.expErr == nil {
+				mockRepo.EXPECT().AddUser(ctx, gomock.AssignableToTypeOf(users.User{})).Return(nil).Times(1)
+				mockRepo.EXPECT().SetRecoveryKey(ctx, gomock.AssignableToTypeOf(users.RecoveryKey{})).Return(nil).Times(1)
+			}
+
+			_, _, actErr := userUsecase.Registration(ctx, userCreds, test.token)
+
+			if got, want := actErr, test.expErr; !errors.Is(got, want) {
+				t.Errorf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestCreateInvite(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	userUsecase := New(mockRepo, Options{RegistrationMode: users.RegistrationInvite})
+	now := time.Unix(1700000000, 0)
+	userUsecase.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	errEmptyRows := errors.New("empty rows")
+	adminID := uuid.New()
+
+	tests := []struct {
+		name     string
+		username string
+		getErr   error
+		addCalls int
+		expErr   error
+	}{
+		{
+			name:     "user_exists",
+			username: "existed",
+			expErr:   errors.New("ClientError: user already exist"),
+		},
+		{
+			name:     "bound_to_username",
+			username: "newuser",
+			getErr:   errEmptyRows,
+			addCalls: 1,
+		},
+		{
+			name:     "any_username",
+			addCalls: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if len(test.username) > 0 {
+				mockRepo.EXPECT().GetUser(ctx, test.username).Return(users.User{}, test.getErr).Times(1)
+				if test.getErr != nil {
+					mockRepo.EXPECT().IsEmptyRows(test.getErr).Return(true).Times(1)
+				}
+			}
+
+			var added users.Invite
+			mockRepo.EXPECT().
+				AddInvite(ctx, gomock.Any()).
+				DoAndReturn(func(_ context.Context, invite users.Invite) error {
+					added = invite
+					return nil
+				}).
+				Times(test.addCalls)
+
+			invite, plain, actErr := userUsecase.CreateInvite(ctx, adminID, test.username, 24*time.Hour)
+
+			if got, want := actErr, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if test.addCalls == 0 {
+				return
+			}
+
+			if !strings.HasPrefix(plain, inviteTokenPrefix) {
+				t.Errorf("Wrong! Invite without prefix: %s", plain)
+			}
+			if !bytes.Equal(added.Hash, hashToken(plain)) {
+				t.Errorf("Wrong! Stored hash doesn't match the invite")
+			}
+			if got, want := invite.ExpiresAt, now.Add(24*time.Hour); !got.Equal(want) {
+				t.Errorf("Wrong! Unexpected expiry!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if got, want := added.Username, test.username; got != want {
+				t.Errorf("Wrong! Unexpected username!\n\tExpected: %s\n\tActual: %s", want, got)
+			}
+		})
+	}
+}
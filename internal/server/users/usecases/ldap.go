@@ -0,0 +1,120 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"passman/internal/server/users"
+	"passman/pkg/ldap"
+
+	"github.com/google/uuid"
+)
+
+// LDAPOptions enable the login by the directory. Users with the local password
+// keep logging in by it, so the local admin still works while the directory is
+// down.
+type LDAPOptions struct {
+	// Directory is nil when the login by the directory is disabled
+	Directory directory
+	// UserGroup is the DN of the group required to log in, empty allows everyone
+	UserGroup string
+	// AdminGroup is the DN of the group granting the admin role. The role of
+	// directory users is synced on every login. Empty keeps admins managed by
+	// the server.
+	AdminGroup string
+}
+
+// directoryIssuer is the issuer of identities of directory users, the subject
+// is the lower-cased DN of the entry
+const directoryIssuer = "ldap"
+
+var errDirectoryGroupRequired = newForbiddenError("group required by the directory is missing")
+
+// loginDirectory authenticates the user by the directory and returns the user
+// of the entry. Unknown entries get the new user without the password, but
+// local users aren't taken over by the same username.
+func (uu *userUsecase) loginDirectory(ctx context.Context, username, password string) (users.User, error) {
+	entry, err := uu.ldap.Directory.Authenticate(ctx, username, password)
+	if errors.Is(err, ldap.ErrInvalidCredentials) {
+		return users.User{}, errInvalidCredentials
+	}
+	if err != nil {
+		return users.User{}, newInternalError("Login", "failed authenticating by directory", err)
+	}
+
+	if len(uu.ldap.UserGroup) > 0 && !inGroup(entry.Groups, uu.ldap.UserGroup) {
+		return users.User{}, errDirectoryGroupRequired
+	}
+
+	var user users.User
+	provisioned := false
+	subject := strings.ToLower(entry.DN)
+
+	userID, err := uu.dbRepo.GetIdentityUserID(ctx, directoryIssuer, subject)
+	switch {
+	case err == nil:
+		if user, err = uu.dbRepo.GetUserByID(ctx, userID); err != nil {
+			return users.User{}, newInternalError("Login", "failed finding user", err)
+		}
+	case uu.dbRepo.IsEmptyRows(err):
+		if user, err = uu.provisionDirectoryUser(ctx, entry.Username, subject); err != nil {
+			return users.User{}, err
+		}
+		provisioned = true
+	default:
+		return users.User{}, newInternalError("Login", "failed finding identity", err)
+	}
+
+	if len(uu.ldap.AdminGroup) > 0 {
+		isAdmin := inGroup(entry.Groups, uu.ldap.AdminGroup)
+		if err := uu.syncAdmin(ctx, "Login", user, provisioned, isAdmin); err != nil {
+			return users.User{}, err
+		}
+	}
+
+	return user, nil
+}
+
+func (uu *userUsecase) provisionDirectoryUser(ctx context.Context, username, subject string) (users.User, error) {
+	if !usernameRegexp.MatchString(username) {
+		return users.User{}, newClientError("directory gave no valid username")
+	}
+
+	if _, err := uu.dbRepo.GetUser(ctx, username); err == nil {
+		return users.User{}, newClientError("username is taken by the local user")
+	} else if !uu.dbRepo.IsEmptyRows(err) {
+		return users.User{}, newInternalError("Login", "failed finding user", err)
+	}
+
+	return uu.addIdentityUser(ctx, "Login", username, directoryIssuer, subject)
+}
+
+// verifyDirectoryPassword binds as the directory entry of the user, false is
+// returned for users without the entry
+func (uu *userUsecase) verifyDirectoryPassword(ctx context.Context, userID uuid.UUID, password string) (bool, error) {
+	identity, err := uu.dbRepo.GetUserIdentity(ctx, userID, directoryIssuer)
+	if err != nil {
+		if uu.dbRepo.IsEmptyRows(err) {
+			return false, nil
+		}
+		return false, newInternalError("VerifyPassword", "failed finding identity", err)
+	}
+
+	err = uu.ldap.Directory.Bind(ctx, identity.Subject, password)
+	if errors.Is(err, ldap.ErrInvalidCredentials) {
+		return false, nil
+	}
+	if err != nil {
+		return false, newInternalError("VerifyPassword", "failed binding to directory", err)
+	}
+	return true, nil
+}
+
+// inGroup compares DNs case-insensitively
+func inGroup(groups []string, group string) bool {
+	return slices.ContainsFunc(groups, func(g string) bool {
+		return strings.EqualFold(g, group)
+	})
+}
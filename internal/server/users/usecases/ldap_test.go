@@ -0,0 +1,299 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"passman/internal/server/users"
+	mock_usecases "passman/internal/server/users/usecases/mock"
+	"passman/pkg/ldap"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/google/uuid"
+	"go.uber.org/mock/gomock"
+)
+
+const (
+	testEntryDN  = "uid=Alice,ou=people,dc=example,dc=com"
+	testSubject  = "uid=alice,ou=people,dc=example,dc=com"
+	testUsersDN  = "cn=users,ou=groups,dc=example,dc=com"
+	testAdminsDN = "cn=admins,ou=groups,dc=example,dc=com"
+)
+
+func TestLoginDirectory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	mockDirectory := mock_usecases.NewMockdirectory(ctrl)
+	userUsecase := New(mockRepo, Options{LDAP: LDAPOptions{
+		Directory:  mockDirectory,
+		UserGroup:  "CN=users,ou=groups,dc=example,dc=com",
+		AdminGroup: testAdminsDN,
+	}})
+	now := time.Unix(1700000000, 0)
+	userUsecase.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	errEmptyRows := errors.New("empty rows")
+	ip := "192.0.2.1"
+	userCreds := users.UserDTO{Username: "alice", Password: "directory_password"}
+
+	hash, _ := argon2id.CreateHash(userCreds.Password, argon2id.DefaultParams)
+	localUser := users.User{ID: uuid.New(), Username: "alice", Password: hash}
+	linkedUser := users.User{ID: uuid.New(), Username: "Alice"}
+	adminUser := users.User{ID: uuid.New(), Username: "Alice", IsAdmin: true}
+	disabledUser := users.User{ID: uuid.New(), Username: "Alice", Disabled: true}
+
+	memberGroups := []string{testUsersDN}
+	adminGroups := []string{testUsersDN, testAdminsDN}
+
+	tests := []struct {
+		name        string
+		existedUser users.User
+		authErr     error
+		groups      []string
+		linkedUser  *users.User
+		provision   bool
+		taken       bool
+		adminCalls  int
+		expAdmin    bool
+		failure     bool
+		expErr      error
+	}{
+		{
+			name:        "local_fallback",
+			existedUser: localUser,
+		},
+		{
+			name:       "linked_user",
+			groups:     memberGroups,
+			linkedUser: &linkedUser,
+		},
+		{
+			name:        "linked_user_without_password",
+			existedUser: users.User{ID: linkedUser.ID, Username: "alice"},
+			groups:      memberGroups,
+			linkedUser:  &linkedUser,
+		},
+		{
+			name:       "admin_group_granted",
+			groups:     adminGroups,
+			linkedUser: &linkedUser,
+			adminCalls: 1,
+			expAdmin:   true,
+		},
+		{
+			name:       "admin_group_revoked",
+			groups:     memberGroups,
+			linkedUser: &adminUser,
+			adminCalls: 1,
+		},
+		{
+			name:       "provisioned",
+			groups:     adminGroups,
+			provision:  true,
+			adminCalls: 1,
+			expAdmin:   true,
+		},
+		{
+			name:   "username_taken",
+			groups: memberGroups,
+			taken:  true,
+			expErr: errors.New("ClientError: username is taken by the local user"),
+		},
+		{
+			name:       "disabled",
+			groups:     memberGroups,
+			linkedUser: &disabledUser,
+			expErr:     errors.New("ClientError: user is disabled"),
+		},
+		{
+			name:   "group_missing",
+			groups: []string{testAdminsDN},
+			expErr: errors.New("ClientError: group required by the directory is missing"),
+		},
+		{
+			name:    "invalid_credentials",
+			authErr: ldap.ErrInvalidCredentials,
+			failure: true,
+			expErr:  errors.New("ClientError: invalid username or password"),
+		},
+		{
+			name:    "directory_unavailable",
+			authErr: fmt.Errorf("%w: connection refused", ldap.ErrDirectory),
+			expErr:  errors.New("Login: failed authenticating by directory"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().IsEmptyRows(errEmptyRows).Return(true).AnyTimes()
+			mockRepo.EXPECT().GetLoginThrottle(ctx, users.ThrottleUsername, userCreds.Username).Return(users.LoginThrottle{}, errEmptyRows).Times(1)
+			mockRepo.EXPECT().GetLoginThrottle(ctx, users.ThrottleIP, ip).Return(users.LoginThrottle{}, errEmptyRows).Times(1)
+
+			if len(test.existedUser.Username) > 0 {
+				mockRepo.EXPECT().GetUser(ctx, userCreds.Username).Return(test.existedUser, nil).Times(1)
+			} else {
+				mockRepo.EXPECT().GetUser(ctx, userCreds.Username).Return(users.User{}, errEmptyRows).Times(1)
+			}
+
+			// Users with the local password never reach the directory
+			directoryLogin := len(test.existedUser.Password) == 0
+			if directoryLogin {
+				entry := ldap.User{DN: testEntryDN, Username: "Alice", Groups: test.groups}
+				if test.authErr != nil {
+					entry = ldap.User{}
+				}
+				mockDirectory.EXPECT().Authenticate(ctx, userCreds.Username, userCreds.Password).Return(entry, test.authErr).Times(1)
+			}
+
+			groupMember := test.authErr == nil && inGroup(test.groups, testUsersDN)
+			if directoryLogin && groupMember {
+				if test.linkedUser != nil {
+					mockRepo.EXPECT().GetIdentityUserID(ctx, directoryIssuer, testSubject).Return(test.linkedUser.ID, nil).Times(1)
+					mockRepo.EXPECT().GetUserByID(ctx, test.linkedUser.ID).Return(*test.linkedUser, nil).Times(1)
+				} else {
+					mockRepo.EXPECT().GetIdentityUserID(ctx, directoryIssuer, testSubject).Return(uuid.Nil, errEmptyRows).Times(1)
+				}
+			}
+
+			if test.taken {
+				mockRepo.EXPECT().GetUser(ctx, "Alice").Return(linkedUser, nil).Times(1)
+			}
+
+			var added users.User
+			if test.provision {
+				mockRepo.EXPECT().GetUser(ctx, "Alice").Return(users.User{}, errEmptyRows).Times(1)
+				mockRepo.EXPECT().
+					WithinTx(ctx, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+						return fn(ctx)
+					}).
+					Times(1)
+				mockRepo.EXPECT().
+					AddUser(ctx, gomock.Any()).
+					DoAndReturn(func(_ context.Context, user users.User) error {
+						added = user
+						return nil
+					}).
+					Times(1)
+				mockRepo.EXPECT().
+					AddIdentity(ctx, gomock.Any()).
+					DoAndReturn(func(_ context.Context, identity users.Identity) error {
+						if identity.UserID != added.ID || identity.Issuer != directoryIssuer || identity.Subject != testSubject || !identity.CreatedAt.Equal(now) {
+							t.Errorf("Wrong! Unexpected identity: %+v", identity)
+						}
+						return nil
+					}).
+					Times(1)
+			}
+
+			mockRepo.EXPECT().SetUserAdmin(ctx, gomock.Any(), test.expAdmin).Return(nil).Times(test.adminCalls)
+
+			if test.failure {
+				resetBefore := now.Add(-failuresWindow)
+				mockRepo.EXPECT().AddLoginFailure(ctx, users.ThrottleUsername, userCreds.Username, now, resetBefore).Return(int64(1), nil).Times(1)
+				mockRepo.EXPECT().AddLoginFailure(ctx, users.ThrottleIP, ip, now, resetBefore).Return(int64(1), nil).Times(1)
+				mockRepo.EXPECT().RemoveStaleLoginThrottles(ctx, resetBefore, now).Return(nil).Times(1)
+			}
+
+			if test.expErr == nil {
+				mockRepo.EXPECT().RemoveLoginThrottle(ctx, users.ThrottleUsername, userCreds.Username).Return(nil).Times(1)
+				mockRepo.EXPECT().GetTOTP(ctx, gomock.Any()).Return(users.TOTP{}, errEmptyRows).Times(1)
+				mockRepo.EXPECT().GetWebAuthnCredentials(ctx, gomock.Any()).Return(nil, nil).Times(1)
+			}
+
+			result, err := userUsecase.Login(ctx, userCreds, ip)
+
+			if got, want := err, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if test.expErr != nil {
+				return
+			}
+
+			expUserID := test.existedUser.ID
+			switch {
+			case test.provision:
+				expUserID = added.ID
+				if got, want := added.Username, "Alice"; got != want {
+					t.Errorf("Wrong! Unexpected username!\n\tExpected: %s\n\tActual: %s", want, got)
+				}
+				if len(added.Password) > 0 {
+					t.Errorf("Wrong! Provisioned user has the password")
+				}
+			case test.linkedUser != nil:
+				expUserID = test.linkedUser.ID
+			}
+			if got, want := result.UserID, expUserID; got != want {
+				t.Errorf("Wrong! Unexpected user id!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestVerifyDirectoryPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	mockDirectory := mock_usecases.NewMockdirectory(ctrl)
+	userUsecase := New(mockRepo, Options{LDAP: LDAPOptions{Directory: mockDirectory}})
+
+	ctx := context.Background()
+	errEmptyRows := errors.New("empty rows")
+	userID := uuid.New()
+	identity := users.Identity{Issuer: directoryIssuer, Subject: testSubject, UserID: userID}
+
+	tests := []struct {
+		name        string
+		identityErr error
+		bindErr     error
+		expMatch    bool
+		expErr      error
+	}{
+		{
+			name:     "success",
+			expMatch: true,
+		},
+		{
+			name:    "incorrect_password",
+			bindErr: ldap.ErrInvalidCredentials,
+		},
+		{
+			name:        "no_identity",
+			identityErr: errEmptyRows,
+		},
+		{
+			name:    "directory_unavailable",
+			bindErr: ldap.ErrDirectory,
+			expErr:  errors.New("VerifyPassword: failed binding to directory"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().GetUserByID(ctx, userID).Return(users.User{ID: userID, Username: "Alice"}, nil).Times(1)
+			mockRepo.EXPECT().GetUserIdentity(ctx, userID, directoryIssuer).Return(identity, test.identityErr).Times(1)
+
+			if test.identityErr != nil {
+				mockRepo.EXPECT().IsEmptyRows(test.identityErr).Return(true).Times(1)
+			} else {
+				mockDirectory.EXPECT().Bind(ctx, testSubject, "directory_password").Return(test.bindErr).Times(1)
+			}
+
+			match, err := userUsecase.VerifyPassword(ctx, userID, "directory_password")
+
+			if got, want := err, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if got, want := match, test.expMatch; got != want {
+				t.Errorf("Wrong! Unexpected match!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
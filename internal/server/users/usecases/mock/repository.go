@@ -11,9 +11,10 @@ package mock_usecases
 
 import (
 	context "context"
-	reflect "reflect"
-
 	users "passman/internal/server/users"
+	ldap "passman/pkg/ldap"
+	reflect "reflect"
+	time "time"
 
 	uuid "github.com/google/uuid"
 	gomock "go.uber.org/mock/gomock"
@@ -43,6 +44,91 @@ func (m *MockdbRepo) EXPECT() *MockdbRepoMockRecorder {
 	return m.recorder
 }
 
+// AddAccessToken mocks base method.
+func (m *MockdbRepo) AddAccessToken(arg0 context.Context, arg1 users.AccessToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAccessToken", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAccessToken indicates an expected call of AddAccessToken.
+func (mr *MockdbRepoMockRecorder) AddAccessToken(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccessToken", reflect.TypeOf((*MockdbRepo)(nil).AddAccessToken), arg0, arg1)
+}
+
+// AddAuditEvent mocks base method.
+func (m *MockdbRepo) AddAuditEvent(arg0 context.Context, arg1 users.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAuditEvent", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAuditEvent indicates an expected call of AddAuditEvent.
+func (mr *MockdbRepoMockRecorder) AddAuditEvent(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAuditEvent", reflect.TypeOf((*MockdbRepo)(nil).AddAuditEvent), arg0, arg1)
+}
+
+// AddIdentity mocks base method.
+func (m *MockdbRepo) AddIdentity(arg0 context.Context, arg1 users.Identity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddIdentity", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddIdentity indicates an expected call of AddIdentity.
+func (mr *MockdbRepoMockRecorder) AddIdentity(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddIdentity", reflect.TypeOf((*MockdbRepo)(nil).AddIdentity), arg0, arg1)
+}
+
+// AddInvite mocks base method.
+func (m *MockdbRepo) AddInvite(arg0 context.Context, arg1 users.Invite) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddInvite", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddInvite indicates an expected call of AddInvite.
+func (mr *MockdbRepoMockRecorder) AddInvite(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddInvite", reflect.TypeOf((*MockdbRepo)(nil).AddInvite), arg0, arg1)
+}
+
+// AddLoginFailure mocks base method.
+func (m *MockdbRepo) AddLoginFailure(ctx context.Context, kind, subject string, failedAt, resetBefore time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddLoginFailure", ctx, kind, subject, failedAt, resetBefore)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddLoginFailure indicates an expected call of AddLoginFailure.
+func (mr *MockdbRepoMockRecorder) AddLoginFailure(ctx, kind, subject, failedAt, resetBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLoginFailure", reflect.TypeOf((*MockdbRepo)(nil).AddLoginFailure), ctx, kind, subject, failedAt, resetBefore)
+}
+
+// AddRecoveryCodes mocks base method.
+func (m *MockdbRepo) AddRecoveryCodes(arg0 context.Context, arg1 uuid.UUID, arg2 []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRecoveryCodes", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddRecoveryCodes indicates an expected call of AddRecoveryCodes.
+func (mr *MockdbRepoMockRecorder) AddRecoveryCodes(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRecoveryCodes", reflect.TypeOf((*MockdbRepo)(nil).AddRecoveryCodes), arg0, arg1, arg2)
+}
+
 // AddUser mocks base method.
 func (m *MockdbRepo) AddUser(arg0 context.Context, arg1 users.User) error {
 	m.ctrl.T.Helper()
@@ -57,6 +143,245 @@ func (mr *MockdbRepoMockRecorder) AddUser(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUser", reflect.TypeOf((*MockdbRepo)(nil).AddUser), arg0, arg1)
 }
 
+// AddWebAuthnCredential mocks base method.
+func (m *MockdbRepo) AddWebAuthnCredential(arg0 context.Context, arg1 users.WebAuthnCredential) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddWebAuthnCredential", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddWebAuthnCredential indicates an expected call of AddWebAuthnCredential.
+func (mr *MockdbRepoMockRecorder) AddWebAuthnCredential(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddWebAuthnCredential", reflect.TypeOf((*MockdbRepo)(nil).AddWebAuthnCredential), arg0, arg1)
+}
+
+// CancelDeletion mocks base method.
+func (m *MockdbRepo) CancelDeletion(arg0 context.Context, arg1 uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelDeletion", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelDeletion indicates an expected call of CancelDeletion.
+func (mr *MockdbRepoMockRecorder) CancelDeletion(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelDeletion", reflect.TypeOf((*MockdbRepo)(nil).CancelDeletion), arg0, arg1)
+}
+
+// CountAdmins mocks base method.
+func (m *MockdbRepo) CountAdmins(arg0 context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountAdmins", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountAdmins indicates an expected call of CountAdmins.
+func (mr *MockdbRepoMockRecorder) CountAdmins(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountAdmins", reflect.TypeOf((*MockdbRepo)(nil).CountAdmins), arg0)
+}
+
+// CountRecoveryCodes mocks base method.
+func (m *MockdbRepo) CountRecoveryCodes(arg0 context.Context, arg1 uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountRecoveryCodes", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountRecoveryCodes indicates an expected call of CountRecoveryCodes.
+func (mr *MockdbRepoMockRecorder) CountRecoveryCodes(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountRecoveryCodes", reflect.TypeOf((*MockdbRepo)(nil).CountRecoveryCodes), arg0, arg1)
+}
+
+// CountUsers mocks base method.
+func (m *MockdbRepo) CountUsers(arg0 context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUsers", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUsers indicates an expected call of CountUsers.
+func (mr *MockdbRepoMockRecorder) CountUsers(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUsers", reflect.TypeOf((*MockdbRepo)(nil).CountUsers), arg0)
+}
+
+// GetAccessTokenByHash mocks base method.
+func (m *MockdbRepo) GetAccessTokenByHash(arg0 context.Context, arg1 []byte) (users.AccessToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccessTokenByHash", arg0, arg1)
+	ret0, _ := ret[0].(users.AccessToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccessTokenByHash indicates an expected call of GetAccessTokenByHash.
+func (mr *MockdbRepoMockRecorder) GetAccessTokenByHash(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccessTokenByHash", reflect.TypeOf((*MockdbRepo)(nil).GetAccessTokenByHash), arg0, arg1)
+}
+
+// GetAccessTokens mocks base method.
+func (m *MockdbRepo) GetAccessTokens(arg0 context.Context, arg1 uuid.UUID) ([]users.AccessToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccessTokens", arg0, arg1)
+	ret0, _ := ret[0].([]users.AccessToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccessTokens indicates an expected call of GetAccessTokens.
+func (mr *MockdbRepoMockRecorder) GetAccessTokens(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccessTokens", reflect.TypeOf((*MockdbRepo)(nil).GetAccessTokens), arg0, arg1)
+}
+
+// GetAuditEvents mocks base method.
+func (m *MockdbRepo) GetAuditEvents(arg0 context.Context) ([]users.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditEvents", arg0)
+	ret0, _ := ret[0].([]users.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditEvents indicates an expected call of GetAuditEvents.
+func (mr *MockdbRepoMockRecorder) GetAuditEvents(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditEvents", reflect.TypeOf((*MockdbRepo)(nil).GetAuditEvents), arg0)
+}
+
+// GetDueDeletions mocks base method.
+func (m *MockdbRepo) GetDueDeletions(ctx context.Context, now time.Time) ([]users.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDueDeletions", ctx, now)
+	ret0, _ := ret[0].([]users.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDueDeletions indicates an expected call of GetDueDeletions.
+func (mr *MockdbRepoMockRecorder) GetDueDeletions(ctx, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDueDeletions", reflect.TypeOf((*MockdbRepo)(nil).GetDueDeletions), ctx, now)
+}
+
+// GetIdentityUserID mocks base method.
+func (m *MockdbRepo) GetIdentityUserID(ctx context.Context, issuer, subject string) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdentityUserID", ctx, issuer, subject)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdentityUserID indicates an expected call of GetIdentityUserID.
+func (mr *MockdbRepoMockRecorder) GetIdentityUserID(ctx, issuer, subject any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdentityUserID", reflect.TypeOf((*MockdbRepo)(nil).GetIdentityUserID), ctx, issuer, subject)
+}
+
+// GetInviteByHash mocks base method.
+func (m *MockdbRepo) GetInviteByHash(arg0 context.Context, arg1 []byte) (users.Invite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInviteByHash", arg0, arg1)
+	ret0, _ := ret[0].(users.Invite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInviteByHash indicates an expected call of GetInviteByHash.
+func (mr *MockdbRepoMockRecorder) GetInviteByHash(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInviteByHash", reflect.TypeOf((*MockdbRepo)(nil).GetInviteByHash), arg0, arg1)
+}
+
+// GetInvites mocks base method.
+func (m *MockdbRepo) GetInvites(arg0 context.Context) ([]users.Invite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInvites", arg0)
+	ret0, _ := ret[0].([]users.Invite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInvites indicates an expected call of GetInvites.
+func (mr *MockdbRepoMockRecorder) GetInvites(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInvites", reflect.TypeOf((*MockdbRepo)(nil).GetInvites), arg0)
+}
+
+// GetLockedLogins mocks base method.
+func (m *MockdbRepo) GetLockedLogins(ctx context.Context, kind string, now time.Time) ([]users.LoginThrottle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLockedLogins", ctx, kind, now)
+	ret0, _ := ret[0].([]users.LoginThrottle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLockedLogins indicates an expected call of GetLockedLogins.
+func (mr *MockdbRepoMockRecorder) GetLockedLogins(ctx, kind, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLockedLogins", reflect.TypeOf((*MockdbRepo)(nil).GetLockedLogins), ctx, kind, now)
+}
+
+// GetLoginThrottle mocks base method.
+func (m *MockdbRepo) GetLoginThrottle(ctx context.Context, kind, subject string) (users.LoginThrottle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginThrottle", ctx, kind, subject)
+	ret0, _ := ret[0].(users.LoginThrottle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginThrottle indicates an expected call of GetLoginThrottle.
+func (mr *MockdbRepoMockRecorder) GetLoginThrottle(ctx, kind, subject any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginThrottle", reflect.TypeOf((*MockdbRepo)(nil).GetLoginThrottle), ctx, kind, subject)
+}
+
+// GetRecoveryKey mocks base method.
+func (m *MockdbRepo) GetRecoveryKey(arg0 context.Context, arg1 uuid.UUID) (users.RecoveryKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecoveryKey", arg0, arg1)
+	ret0, _ := ret[0].(users.RecoveryKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecoveryKey indicates an expected call of GetRecoveryKey.
+func (mr *MockdbRepoMockRecorder) GetRecoveryKey(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecoveryKey", reflect.TypeOf((*MockdbRepo)(nil).GetRecoveryKey), arg0, arg1)
+}
+
+// GetTOTP mocks base method.
+func (m *MockdbRepo) GetTOTP(arg0 context.Context, arg1 uuid.UUID) (users.TOTP, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTOTP", arg0, arg1)
+	ret0, _ := ret[0].(users.TOTP)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTOTP indicates an expected call of GetTOTP.
+func (mr *MockdbRepoMockRecorder) GetTOTP(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTOTP", reflect.TypeOf((*MockdbRepo)(nil).GetTOTP), arg0, arg1)
+}
+
 // GetUser mocks base method.
 func (m *MockdbRepo) GetUser(arg0 context.Context, arg1 string) (users.User, error) {
 	m.ctrl.T.Helper()
@@ -87,6 +412,66 @@ func (mr *MockdbRepoMockRecorder) GetUserByID(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockdbRepo)(nil).GetUserByID), arg0, arg1)
 }
 
+// GetUserIdentity mocks base method.
+func (m *MockdbRepo) GetUserIdentity(ctx context.Context, userID uuid.UUID, issuer string) (users.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserIdentity", ctx, userID, issuer)
+	ret0, _ := ret[0].(users.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserIdentity indicates an expected call of GetUserIdentity.
+func (mr *MockdbRepoMockRecorder) GetUserIdentity(ctx, userID, issuer any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIdentity", reflect.TypeOf((*MockdbRepo)(nil).GetUserIdentity), ctx, userID, issuer)
+}
+
+// GetUsers mocks base method.
+func (m *MockdbRepo) GetUsers(arg0 context.Context) ([]users.UserSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsers", arg0)
+	ret0, _ := ret[0].([]users.UserSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsers indicates an expected call of GetUsers.
+func (mr *MockdbRepoMockRecorder) GetUsers(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockdbRepo)(nil).GetUsers), arg0)
+}
+
+// GetWebAuthnCredential mocks base method.
+func (m *MockdbRepo) GetWebAuthnCredential(arg0 context.Context, arg1 []byte) (users.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebAuthnCredential", arg0, arg1)
+	ret0, _ := ret[0].(users.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebAuthnCredential indicates an expected call of GetWebAuthnCredential.
+func (mr *MockdbRepoMockRecorder) GetWebAuthnCredential(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebAuthnCredential", reflect.TypeOf((*MockdbRepo)(nil).GetWebAuthnCredential), arg0, arg1)
+}
+
+// GetWebAuthnCredentials mocks base method.
+func (m *MockdbRepo) GetWebAuthnCredentials(arg0 context.Context, arg1 uuid.UUID) ([]users.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebAuthnCredentials", arg0, arg1)
+	ret0, _ := ret[0].([]users.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebAuthnCredentials indicates an expected call of GetWebAuthnCredentials.
+func (mr *MockdbRepoMockRecorder) GetWebAuthnCredentials(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebAuthnCredentials", reflect.TypeOf((*MockdbRepo)(nil).GetWebAuthnCredentials), arg0, arg1)
+}
+
 // IsEmptyRows mocks base method.
 func (m *MockdbRepo) IsEmptyRows(arg0 error) bool {
 	m.ctrl.T.Helper()
@@ -101,6 +486,179 @@ func (mr *MockdbRepoMockRecorder) IsEmptyRows(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsEmptyRows", reflect.TypeOf((*MockdbRepo)(nil).IsEmptyRows), arg0)
 }
 
+// IsUniqueViolation mocks base method.
+func (m *MockdbRepo) IsUniqueViolation(arg0 error) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsUniqueViolation", arg0)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsUniqueViolation indicates an expected call of IsUniqueViolation.
+func (mr *MockdbRepoMockRecorder) IsUniqueViolation(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsUniqueViolation", reflect.TypeOf((*MockdbRepo)(nil).IsUniqueViolation), arg0)
+}
+
+// LockLogin mocks base method.
+func (m *MockdbRepo) LockLogin(ctx context.Context, kind, subject string, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockLogin", ctx, kind, subject, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockLogin indicates an expected call of LockLogin.
+func (mr *MockdbRepoMockRecorder) LockLogin(ctx, kind, subject, until any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockLogin", reflect.TypeOf((*MockdbRepo)(nil).LockLogin), ctx, kind, subject, until)
+}
+
+// RemoveAccessToken mocks base method.
+func (m *MockdbRepo) RemoveAccessToken(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveAccessToken", ctx, userID, id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveAccessToken indicates an expected call of RemoveAccessToken.
+func (mr *MockdbRepoMockRecorder) RemoveAccessToken(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveAccessToken", reflect.TypeOf((*MockdbRepo)(nil).RemoveAccessToken), ctx, userID, id)
+}
+
+// RemoveAccessTokens mocks base method.
+func (m *MockdbRepo) RemoveAccessTokens(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveAccessTokens", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveAccessTokens indicates an expected call of RemoveAccessTokens.
+func (mr *MockdbRepoMockRecorder) RemoveAccessTokens(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveAccessTokens", reflect.TypeOf((*MockdbRepo)(nil).RemoveAccessTokens), arg0, arg1)
+}
+
+// RemoveDeletedUser mocks base method.
+func (m *MockdbRepo) RemoveDeletedUser(ctx context.Context, userID uuid.UUID, now time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveDeletedUser", ctx, userID, now)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveDeletedUser indicates an expected call of RemoveDeletedUser.
+func (mr *MockdbRepoMockRecorder) RemoveDeletedUser(ctx, userID, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveDeletedUser", reflect.TypeOf((*MockdbRepo)(nil).RemoveDeletedUser), ctx, userID, now)
+}
+
+// RemoveIdentity mocks base method.
+func (m *MockdbRepo) RemoveIdentity(ctx context.Context, userID uuid.UUID, issuer string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveIdentity", ctx, userID, issuer)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveIdentity indicates an expected call of RemoveIdentity.
+func (mr *MockdbRepoMockRecorder) RemoveIdentity(ctx, userID, issuer any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveIdentity", reflect.TypeOf((*MockdbRepo)(nil).RemoveIdentity), ctx, userID, issuer)
+}
+
+// RemoveInvite mocks base method.
+func (m *MockdbRepo) RemoveInvite(arg0 context.Context, arg1 uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveInvite", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveInvite indicates an expected call of RemoveInvite.
+func (mr *MockdbRepoMockRecorder) RemoveInvite(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveInvite", reflect.TypeOf((*MockdbRepo)(nil).RemoveInvite), arg0, arg1)
+}
+
+// RemoveLoginThrottle mocks base method.
+func (m *MockdbRepo) RemoveLoginThrottle(ctx context.Context, kind, subject string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveLoginThrottle", ctx, kind, subject)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveLoginThrottle indicates an expected call of RemoveLoginThrottle.
+func (mr *MockdbRepoMockRecorder) RemoveLoginThrottle(ctx, kind, subject any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveLoginThrottle", reflect.TypeOf((*MockdbRepo)(nil).RemoveLoginThrottle), ctx, kind, subject)
+}
+
+// RemoveRecoveryCode mocks base method.
+func (m *MockdbRepo) RemoveRecoveryCode(arg0 context.Context, arg1 uuid.UUID, arg2 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveRecoveryCode", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveRecoveryCode indicates an expected call of RemoveRecoveryCode.
+func (mr *MockdbRepoMockRecorder) RemoveRecoveryCode(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveRecoveryCode", reflect.TypeOf((*MockdbRepo)(nil).RemoveRecoveryCode), arg0, arg1, arg2)
+}
+
+// RemoveRecoveryCodes mocks base method.
+func (m *MockdbRepo) RemoveRecoveryCodes(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveRecoveryCodes", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveRecoveryCodes indicates an expected call of RemoveRecoveryCodes.
+func (mr *MockdbRepoMockRecorder) RemoveRecoveryCodes(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveRecoveryCodes", reflect.TypeOf((*MockdbRepo)(nil).RemoveRecoveryCodes), arg0, arg1)
+}
+
+// RemoveStaleLoginThrottles mocks base method.
+func (m *MockdbRepo) RemoveStaleLoginThrottles(ctx context.Context, before, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveStaleLoginThrottles", ctx, before, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveStaleLoginThrottles indicates an expected call of RemoveStaleLoginThrottles.
+func (mr *MockdbRepoMockRecorder) RemoveStaleLoginThrottles(ctx, before, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveStaleLoginThrottles", reflect.TypeOf((*MockdbRepo)(nil).RemoveStaleLoginThrottles), ctx, before, now)
+}
+
+// RemoveTOTP mocks base method.
+func (m *MockdbRepo) RemoveTOTP(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveTOTP", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveTOTP indicates an expected call of RemoveTOTP.
+func (mr *MockdbRepoMockRecorder) RemoveTOTP(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveTOTP", reflect.TypeOf((*MockdbRepo)(nil).RemoveTOTP), arg0, arg1)
+}
+
 // RemoveUser mocks base method.
 func (m *MockdbRepo) RemoveUser(arg0 context.Context, arg1 uuid.UUID) error {
 	m.ctrl.T.Helper()
@@ -115,6 +673,193 @@ func (mr *MockdbRepoMockRecorder) RemoveUser(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveUser", reflect.TypeOf((*MockdbRepo)(nil).RemoveUser), arg0, arg1)
 }
 
+// RemoveWebAuthnCredential mocks base method.
+func (m *MockdbRepo) RemoveWebAuthnCredential(arg0 context.Context, arg1 uuid.UUID, arg2 []byte) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveWebAuthnCredential", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveWebAuthnCredential indicates an expected call of RemoveWebAuthnCredential.
+func (mr *MockdbRepoMockRecorder) RemoveWebAuthnCredential(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveWebAuthnCredential", reflect.TypeOf((*MockdbRepo)(nil).RemoveWebAuthnCredential), arg0, arg1, arg2)
+}
+
+// RemoveWebAuthnCredentials mocks base method.
+func (m *MockdbRepo) RemoveWebAuthnCredentials(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveWebAuthnCredentials", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveWebAuthnCredentials indicates an expected call of RemoveWebAuthnCredentials.
+func (mr *MockdbRepoMockRecorder) RemoveWebAuthnCredentials(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveWebAuthnCredentials", reflect.TypeOf((*MockdbRepo)(nil).RemoveWebAuthnCredentials), arg0, arg1)
+}
+
+// RequirePasswordReset mocks base method.
+func (m *MockdbRepo) RequirePasswordReset(arg0 context.Context, arg1 uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequirePasswordReset", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequirePasswordReset indicates an expected call of RequirePasswordReset.
+func (mr *MockdbRepoMockRecorder) RequirePasswordReset(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequirePasswordReset", reflect.TypeOf((*MockdbRepo)(nil).RequirePasswordReset), arg0, arg1)
+}
+
+// ScheduleDeletion mocks base method.
+func (m *MockdbRepo) ScheduleDeletion(ctx context.Context, userID uuid.UUID, deleteAt time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleDeletion", ctx, userID, deleteAt)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScheduleDeletion indicates an expected call of ScheduleDeletion.
+func (mr *MockdbRepoMockRecorder) ScheduleDeletion(ctx, userID, deleteAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleDeletion", reflect.TypeOf((*MockdbRepo)(nil).ScheduleDeletion), ctx, userID, deleteAt)
+}
+
+// SetRecoveryKey mocks base method.
+func (m *MockdbRepo) SetRecoveryKey(arg0 context.Context, arg1 users.RecoveryKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRecoveryKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRecoveryKey indicates an expected call of SetRecoveryKey.
+func (mr *MockdbRepoMockRecorder) SetRecoveryKey(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRecoveryKey", reflect.TypeOf((*MockdbRepo)(nil).SetRecoveryKey), arg0, arg1)
+}
+
+// SetTOTP mocks base method.
+func (m *MockdbRepo) SetTOTP(arg0 context.Context, arg1 users.TOTP) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTOTP", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetTOTP indicates an expected call of SetTOTP.
+func (mr *MockdbRepoMockRecorder) SetTOTP(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTOTP", reflect.TypeOf((*MockdbRepo)(nil).SetTOTP), arg0, arg1)
+}
+
+// SetUserAdmin mocks base method.
+func (m *MockdbRepo) SetUserAdmin(ctx context.Context, userID uuid.UUID, isAdmin bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserAdmin", ctx, userID, isAdmin)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserAdmin indicates an expected call of SetUserAdmin.
+func (mr *MockdbRepoMockRecorder) SetUserAdmin(ctx, userID, isAdmin any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserAdmin", reflect.TypeOf((*MockdbRepo)(nil).SetUserAdmin), ctx, userID, isAdmin)
+}
+
+// SetUserDisabled mocks base method.
+func (m *MockdbRepo) SetUserDisabled(ctx context.Context, userID uuid.UUID, disabled bool) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserDisabled", ctx, userID, disabled)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserDisabled indicates an expected call of SetUserDisabled.
+func (mr *MockdbRepoMockRecorder) SetUserDisabled(ctx, userID, disabled any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserDisabled", reflect.TypeOf((*MockdbRepo)(nil).SetUserDisabled), ctx, userID, disabled)
+}
+
+// UpdateAccessTokenLastUsed mocks base method.
+func (m *MockdbRepo) UpdateAccessTokenLastUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccessTokenLastUsed", ctx, id, usedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAccessTokenLastUsed indicates an expected call of UpdateAccessTokenLastUsed.
+func (mr *MockdbRepoMockRecorder) UpdateAccessTokenLastUsed(ctx, id, usedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccessTokenLastUsed", reflect.TypeOf((*MockdbRepo)(nil).UpdateAccessTokenLastUsed), ctx, id, usedAt)
+}
+
+// UpdateLastLogin mocks base method.
+func (m *MockdbRepo) UpdateLastLogin(ctx context.Context, userID uuid.UUID, loginAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLastLogin", ctx, userID, loginAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateLastLogin indicates an expected call of UpdateLastLogin.
+func (mr *MockdbRepoMockRecorder) UpdateLastLogin(ctx, userID, loginAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLastLogin", reflect.TypeOf((*MockdbRepo)(nil).UpdateLastLogin), ctx, userID, loginAt)
+}
+
+// UpdatePasswordHash mocks base method.
+func (m *MockdbRepo) UpdatePasswordHash(ctx context.Context, userID uuid.UUID, oldHash, newHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePasswordHash", ctx, userID, oldHash, newHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePasswordHash indicates an expected call of UpdatePasswordHash.
+func (mr *MockdbRepoMockRecorder) UpdatePasswordHash(ctx, userID, oldHash, newHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePasswordHash", reflect.TypeOf((*MockdbRepo)(nil).UpdatePasswordHash), ctx, userID, oldHash, newHash)
+}
+
+// UpdateProfile mocks base method.
+func (m *MockdbRepo) UpdateProfile(arg0 context.Context, arg1 uuid.UUID, arg2 users.Profile) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProfile", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateProfile indicates an expected call of UpdateProfile.
+func (mr *MockdbRepoMockRecorder) UpdateProfile(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockdbRepo)(nil).UpdateProfile), arg0, arg1, arg2)
+}
+
+// UpdateTOTPStep mocks base method.
+func (m *MockdbRepo) UpdateTOTPStep(arg0 context.Context, arg1 uuid.UUID, arg2 int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTOTPStep", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateTOTPStep indicates an expected call of UpdateTOTPStep.
+func (mr *MockdbRepoMockRecorder) UpdateTOTPStep(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTOTPStep", reflect.TypeOf((*MockdbRepo)(nil).UpdateTOTPStep), arg0, arg1, arg2)
+}
+
 // UpdateUser mocks base method.
 func (m *MockdbRepo) UpdateUser(arg0 context.Context, arg1 users.User) error {
 	m.ctrl.T.Helper()
@@ -128,3 +873,99 @@ func (mr *MockdbRepoMockRecorder) UpdateUser(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockdbRepo)(nil).UpdateUser), arg0, arg1)
 }
+
+// UpdateWebAuthnSignCount mocks base method.
+func (m *MockdbRepo) UpdateWebAuthnSignCount(ctx context.Context, id []byte, signCount uint32, usedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebAuthnSignCount", ctx, id, signCount, usedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebAuthnSignCount indicates an expected call of UpdateWebAuthnSignCount.
+func (mr *MockdbRepoMockRecorder) UpdateWebAuthnSignCount(ctx, id, signCount, usedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebAuthnSignCount", reflect.TypeOf((*MockdbRepo)(nil).UpdateWebAuthnSignCount), ctx, id, signCount, usedAt)
+}
+
+// UseInvite mocks base method.
+func (m *MockdbRepo) UseInvite(ctx context.Context, id uuid.UUID, username string, usedAt time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseInvite", ctx, id, username, usedAt)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseInvite indicates an expected call of UseInvite.
+func (mr *MockdbRepoMockRecorder) UseInvite(ctx, id, username, usedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseInvite", reflect.TypeOf((*MockdbRepo)(nil).UseInvite), ctx, id, username, usedAt)
+}
+
+// WithinTx mocks base method.
+func (m *MockdbRepo) WithinTx(arg0 context.Context, arg1 func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithinTx", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithinTx indicates an expected call of WithinTx.
+func (mr *MockdbRepoMockRecorder) WithinTx(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithinTx", reflect.TypeOf((*MockdbRepo)(nil).WithinTx), arg0, arg1)
+}
+
+// Mockdirectory is a mock of directory interface.
+type Mockdirectory struct {
+	ctrl     *gomock.Controller
+	recorder *MockdirectoryMockRecorder
+	isgomock struct{}
+}
+
+// MockdirectoryMockRecorder is the mock recorder for Mockdirectory.
+type MockdirectoryMockRecorder struct {
+	mock *Mockdirectory
+}
+
+// NewMockdirectory creates a new mock instance.
+func NewMockdirectory(ctrl *gomock.Controller) *Mockdirectory {
+	mock := &Mockdirectory{ctrl: ctrl}
+	mock.recorder = &MockdirectoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockdirectory) EXPECT() *MockdirectoryMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *Mockdirectory) Authenticate(ctx context.Context, username, password string) (ldap.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", ctx, username, password)
+	ret0, _ := ret[0].(ldap.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockdirectoryMockRecorder) Authenticate(ctx, username, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*Mockdirectory)(nil).Authenticate), ctx, username, password)
+}
+
+// Bind mocks base method.
+func (m *Mockdirectory) Bind(ctx context.Context, dn, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Bind", ctx, dn, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Bind indicates an expected call of Bind.
+func (mr *MockdirectoryMockRecorder) Bind(ctx, dn, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Bind", reflect.TypeOf((*Mockdirectory)(nil).Bind), ctx, dn, password)
+}
@@ -0,0 +1,214 @@
+package usecases
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+
+	"passman/internal/server/users"
+
+	"github.com/google/uuid"
+)
+
+// OIDCOptions map roles of the identity provider to users of the server
+type OIDCOptions struct {
+	// UserRole is required to log in by the provider, empty allows everyone
+	UserRole string
+	// AdminRole grants the admin role. The role of provider users is synced on
+	// every login, so admins are managed by the provider. Empty keeps admins
+	// managed by the server.
+	AdminRole string
+}
+
+// The same rule as the validator of registration
+var usernameRegexp = regexp.MustCompile("^[a-zA-Z][a-zA-Z0-9]{3,14}$")
+
+var (
+	errOIDCRoleRequired = newForbiddenError("role required by the identity provider is missing")
+	errIdentityLinked   = newClientError("identity is linked to another user")
+)
+
+// LoginOIDC returns the user of the identity. Unknown identities get the new
+// user without the password. Existing users aren't linked by the username,
+// otherwise the provider user could take over the account: they log in by the
+// password and link the identity by LinkOIDC. Second factors of the server and
+// the required password reset are asked like after the password login.
+func (uu *userUsecase) LoginOIDC(ctx context.Context, claims users.OIDCClaims) (users.LoginResult, error) {
+	if len(uu.oidc.UserRole) > 0 && !slices.Contains(claims.Roles, uu.oidc.UserRole) {
+		return users.LoginResult{}, errOIDCRoleRequired
+	}
+
+	var user users.User
+	provisioned := false
+
+	userID, err := uu.dbRepo.GetIdentityUserID(ctx, claims.Issuer, claims.Subject)
+	switch {
+	case err == nil:
+		if user, err = uu.dbRepo.GetUserByID(ctx, userID); err != nil {
+			return users.LoginResult{}, newInternalError("LoginOIDC", "failed finding user", err)
+		}
+	case uu.dbRepo.IsEmptyRows(err):
+		if user, err = uu.provisionOIDCUser(ctx, claims); err != nil {
+			return users.LoginResult{}, err
+		}
+		provisioned = true
+	default:
+		return users.LoginResult{}, newInternalError("LoginOIDC", "failed finding identity", err)
+	}
+
+	if !user.DeleteAt.IsZero() {
+		return users.LoginResult{}, errDeletionScheduled
+	}
+	if user.Disabled {
+		return users.LoginResult{}, errUserDisabled
+	}
+
+	if len(uu.oidc.AdminRole) > 0 {
+		isAdmin := slices.Contains(claims.Roles, uu.oidc.AdminRole)
+		if err := uu.syncAdmin(ctx, "LoginOIDC", user, provisioned, isAdmin); err != nil {
+			return users.LoginResult{}, err
+		}
+	}
+
+	// New users have no second factors yet
+	if provisioned {
+		return users.LoginResult{UserID: user.ID}, nil
+	}
+	return uu.loginResult(ctx, "LoginOIDC", user)
+}
+
+func (uu *userUsecase) provisionOIDCUser(ctx context.Context, claims users.OIDCClaims) (users.User, error) {
+	username := oidcUsername(claims.Username)
+	if len(username) == 0 {
+		return users.User{}, newClientError("identity provider gave no valid username, log in by the password and link the identity")
+	}
+
+	if _, err := uu.dbRepo.GetUser(ctx, username); err == nil {
+		return users.User{}, newClientError("username is taken, log in by the password and link the identity")
+	} else if !uu.dbRepo.IsEmptyRows(err) {
+		return users.User{}, newInternalError("LoginOIDC", "failed finding user", err)
+	}
+
+	return uu.addIdentityUser(ctx, "LoginOIDC", username, claims.Issuer, claims.Subject)
+}
+
+// addIdentityUser adds the user without the password and the identity of it
+func (uu *userUsecase) addIdentityUser(ctx context.Context, component, username, issuer, subject string) (users.User, error) {
+	now := uu.now()
+	newUser := users.User{
+		ID:        uuid.New(),
+		Username:  username,
+		CreatedAt: now,
+	}
+	identity := users.Identity{
+		Issuer:    issuer,
+		Subject:   subject,
+		UserID:    newUser.ID,
+		CreatedAt: now,
+	}
+
+	err := uu.dbRepo.WithinTx(ctx, func(txCtx context.Context) error {
+		if err := uu.dbRepo.AddUser(txCtx, newUser); err != nil {
+			return err
+		}
+		return uu.dbRepo.AddIdentity(txCtx, identity)
+	})
+	if err != nil {
+		return users.User{}, newInternalError(component, "failed adding user", err)
+	}
+
+	return newUser, nil
+}
+
+// syncAdmin sets the admin role given by the external provider. The first
+// user may be the admin already, so provisioned users are always set.
+func (uu *userUsecase) syncAdmin(ctx context.Context, component string, user users.User, provisioned, isAdmin bool) error {
+	if !provisioned && isAdmin == user.IsAdmin {
+		return nil
+	}
+	if err := uu.dbRepo.SetUserAdmin(ctx, user.ID, isAdmin); err != nil {
+		return newInternalError(component, "failed syncing admin role", err)
+	}
+	return nil
+}
+
+// oidcUsername returns the username claim or the local part of the email if
+// it's the valid username. Other characters aren't dropped, "j.doe" and "jdoe"
+// would become the same user.
+func oidcUsername(claim string) string {
+	local, _, _ := strings.Cut(claim, "@")
+	if !usernameRegexp.MatchString(local) {
+		return ""
+	}
+	return local
+}
+
+// LinkOIDC links the identity to the logged in user
+func (uu *userUsecase) LinkOIDC(ctx context.Context, userID uuid.UUID, claims users.OIDCClaims) error {
+	if len(uu.oidc.UserRole) > 0 && !slices.Contains(claims.Roles, uu.oidc.UserRole) {
+		return errOIDCRoleRequired
+	}
+
+	linkedID, err := uu.dbRepo.GetIdentityUserID(ctx, claims.Issuer, claims.Subject)
+	switch {
+	case err == nil && linkedID == userID:
+		return nil
+	case err == nil:
+		return errIdentityLinked
+	case !uu.dbRepo.IsEmptyRows(err):
+		return newInternalError("LinkOIDC", "failed finding identity", err)
+	}
+
+	if _, err := uu.dbRepo.GetUserIdentity(ctx, userID, claims.Issuer); err == nil {
+		return newClientError("another identity of the provider is linked, unlink it first")
+	} else if !uu.dbRepo.IsEmptyRows(err) {
+		return newInternalError("LinkOIDC", "failed finding identity", err)
+	}
+
+	identity := users.Identity{
+		Issuer:    claims.Issuer,
+		Subject:   claims.Subject,
+		UserID:    userID,
+		CreatedAt: uu.now(),
+	}
+	if err := uu.dbRepo.AddIdentity(ctx, identity); err != nil {
+		return newInternalError("LinkOIDC", "failed adding identity", err)
+	}
+
+	return nil
+}
+
+// GetOIDCIdentity returns the identity of the issuer linked to the user and
+// false if there is none
+func (uu *userUsecase) GetOIDCIdentity(ctx context.Context, userID uuid.UUID, issuer string) (users.Identity, bool, error) {
+	identity, err := uu.dbRepo.GetUserIdentity(ctx, userID, issuer)
+	if err != nil {
+		if uu.dbRepo.IsEmptyRows(err) {
+			return users.Identity{}, false, nil
+		}
+		return users.Identity{}, false, newInternalError("GetOIDCIdentity", "failed finding identity", err)
+	}
+	return identity, true, nil
+}
+
+// UnlinkOIDC removes the identity of the issuer. Users without the password
+// would lose the access, so they keep it.
+func (uu *userUsecase) UnlinkOIDC(ctx context.Context, userID uuid.UUID, issuer string) error {
+	user, err := uu.dbRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return newInternalError("UnlinkOIDC", "failed finding user", err)
+	}
+	if len(user.Password) == 0 {
+		return newClientError("user without the password can't unlink the identity")
+	}
+
+	removed, err := uu.dbRepo.RemoveIdentity(ctx, userID, issuer)
+	if err != nil {
+		return newInternalError("UnlinkOIDC", "failed removing identity", err)
+	}
+	if !removed {
+		return newClientError("identity not found")
+	}
+	return nil
+}
@@ -0,0 +1,314 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"passman/internal/server/users"
+	mock_usecases "passman/internal/server/users/usecases/mock"
+
+	"github.com/google/uuid"
+	"go.uber.org/mock/gomock"
+)
+
+const testIssuer = "https://idp.example.com"
+
+func TestLoginOIDC(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	userUsecase := New(mockRepo, Options{OIDC: OIDCOptions{UserRole: "passman", AdminRole: "passman-admin"}})
+	now := time.Unix(1700000000, 0)
+	userUsecase.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	errEmptyRows := errors.New("empty rows")
+	linkedUser := users.User{ID: uuid.New(), Username: "alice"}
+	adminUser := users.User{ID: uuid.New(), Username: "alice", IsAdmin: true}
+	disabledUser := users.User{ID: uuid.New(), Username: "alice", Disabled: true}
+	scheduledUser := users.User{ID: uuid.New(), Username: "alice", DeleteAt: now.Add(time.Hour)}
+	resetUser := users.User{ID: uuid.New(), Username: "alice", Password: "hash", PasswordResetRequired: true}
+
+	tests := []struct {
+		name        string
+		claims      users.OIDCClaims
+		linkedUser  *users.User
+		totp        users.TOTP
+		passkeys    []users.WebAuthnCredential
+		existedUser bool
+		provision   bool
+		adminCalls  int
+		expAdmin    bool
+		roleMissing bool
+		expResult   users.LoginResult
+		expErr      error
+	}{
+		{
+			name:        "user_role_missing",
+			claims:      users.OIDCClaims{Username: "alice", Roles: []string{"other"}},
+			roleMissing: true,
+			expErr:      errors.New("ClientError: role required by the identity provider is missing"),
+		},
+		{
+			name:       "linked_user",
+			claims:     users.OIDCClaims{Username: "renamed", Roles: []string{"passman"}},
+			linkedUser: &linkedUser,
+		},
+		{
+			name:       "admin_role_granted",
+			claims:     users.OIDCClaims{Username: "alice", Roles: []string{"passman", "passman-admin"}},
+			linkedUser: &linkedUser,
+			adminCalls: 1,
+			expAdmin:   true,
+		},
+		{
+			name:       "admin_role_revoked",
+			claims:     users.OIDCClaims{Username: "alice", Roles: []string{"passman"}},
+			linkedUser: &adminUser,
+			adminCalls: 1,
+		},
+		{
+			name:       "two_factor_enabled",
+			claims:     users.OIDCClaims{Username: "alice", Roles: []string{"passman"}},
+			linkedUser: &linkedUser,
+			totp:       users.TOTP{UserID: linkedUser.ID, Enabled: true},
+			expResult:  users.LoginResult{TOTP: true},
+		},
+		{
+			name:       "passkey_registered",
+			claims:     users.OIDCClaims{Username: "alice", Roles: []string{"passman"}},
+			linkedUser: &linkedUser,
+			passkeys:   []users.WebAuthnCredential{{UserID: linkedUser.ID}},
+			expResult:  users.LoginResult{WebAuthn: true},
+		},
+		{
+			name:       "password_reset_required",
+			claims:     users.OIDCClaims{Username: "alice", Roles: []string{"passman"}},
+			linkedUser: &resetUser,
+			expResult:  users.LoginResult{PasswordResetRequired: true},
+		},
+		{
+			name:       "disabled",
+			claims:     users.OIDCClaims{Username: "alice", Roles: []string{"passman"}},
+			linkedUser: &disabledUser,
+			expErr:     errors.New("ClientError: user is disabled"),
+		},
+		{
+			name:       "deletion_scheduled",
+			claims:     users.OIDCClaims{Username: "alice", Roles: []string{"passman"}},
+			linkedUser: &scheduledUser,
+			expErr:     errors.New("ClientError: user is scheduled for deletion"),
+		},
+		{
+			name:       "provisioned",
+			claims:     users.OIDCClaims{Username: "bobby@example.com", Roles: []string{"passman"}},
+			provision:  true,
+			adminCalls: 1,
+		},
+		{
+			name:        "username_taken",
+			claims:      users.OIDCClaims{Username: "alice", Roles: []string{"passman"}},
+			existedUser: true,
+			expErr:      errors.New("ClientError: username is taken, log in by the password and link the identity"),
+		},
+		{
+			name:   "invalid_username",
+			claims: users.OIDCClaims{Username: "j.doe@example.com", Roles: []string{"passman"}},
+			expErr: errors.New("ClientError: identity provider gave no valid username, log in by the password and link the identity"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.claims.Issuer = testIssuer
+			test.claims.Subject = "subject"
+
+			if !test.roleMissing {
+				if test.linkedUser != nil {
+					mockRepo.EXPECT().GetIdentityUserID(ctx, testIssuer, "subject").Return(test.linkedUser.ID, nil).Times(1)
+					mockRepo.EXPECT().GetUserByID(ctx, test.linkedUser.ID).Return(*test.linkedUser, nil).Times(1)
+					if !test.linkedUser.Disabled && test.linkedUser.DeleteAt.IsZero() {
+						mockRepo.EXPECT().GetTOTP(ctx, test.linkedUser.ID).Return(test.totp, nil).Times(1)
+						mockRepo.EXPECT().GetWebAuthnCredentials(ctx, test.linkedUser.ID).Return(test.passkeys, nil).Times(1)
+					}
+				} else {
+					mockRepo.EXPECT().GetIdentityUserID(ctx, testIssuer, "subject").Return(uuid.Nil, errEmptyRows).Times(1)
+					mockRepo.EXPECT().IsEmptyRows(errEmptyRows).Return(true).Times(1)
+				}
+			}
+
+			if test.existedUser {
+				mockRepo.EXPECT().GetUser(ctx, test.claims.Username).Return(linkedUser, nil).Times(1)
+			}
+
+			var added users.User
+			if test.provision {
+				mockRepo.EXPECT().GetUser(ctx, "bobby").Return(users.User{}, errEmptyRows).Times(1)
+				mockRepo.EXPECT().IsEmptyRows(errEmptyRows).Return(true).Times(1)
+				mockRepo.EXPECT().
+					WithinTx(ctx, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+						return fn(ctx)
+					}).
+					Times(1)
+				mockRepo.EXPECT().
+					AddUser(ctx, gomock.Any()).
+					DoAndReturn(func(_ context.Context, user users.User) error {
+						added = user
+						return nil
+					}).
+					Times(1)
+				mockRepo.EXPECT().
+					AddIdentity(ctx, gomock.Any()).
+					DoAndReturn(func(_ context.Context, identity users.Identity) error {
+						if identity.UserID != added.ID || identity.Issuer != testIssuer || identity.Subject != "subject" || !identity.CreatedAt.Equal(now) {
+							t.Errorf("Wrong! Unexpected identity: %+v", identity)
+						}
+						return nil
+					}).
+					Times(1)
+			}
+
+			mockRepo.EXPECT().SetUserAdmin(ctx, gomock.Any(), test.expAdmin).Return(nil).Times(test.adminCalls)
+
+			result, err := userUsecase.LoginOIDC(ctx, test.claims)
+
+			if got, want := err, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if test.expErr != nil {
+				return
+			}
+
+			if test.provision {
+				if got, want := added.Username, "bobby"; got != want {
+					t.Errorf("Wrong! Unexpected username!\n\tExpected: %s\n\tActual: %s", want, got)
+				}
+				if len(added.Password) > 0 {
+					t.Errorf("Wrong! Provisioned user has the password")
+				}
+				test.expResult.UserID = added.ID
+			} else {
+				test.expResult.UserID = test.linkedUser.ID
+			}
+			if got, want := result, test.expResult; got != want {
+				t.Errorf("Wrong! Unexpected result!\n\tExpected: %+v\n\tActual: %+v", want, got)
+			}
+		})
+	}
+}
+
+func TestLinkOIDC(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	userUsecase := New(mockRepo, Options{})
+
+	ctx := context.Background()
+	errEmptyRows := errors.New("empty rows")
+	userID := uuid.New()
+	claims := users.OIDCClaims{Issuer: testIssuer, Subject: "subject"}
+
+	tests := []struct {
+		name         string
+		linkedID     uuid.UUID
+		userIdentity bool
+		addCalls     int
+		expErr       error
+	}{
+		{name: "linked", addCalls: 1},
+		{name: "already_linked", linkedID: userID},
+		{name: "linked_to_another_user", linkedID: uuid.New(), expErr: errors.New("ClientError: identity is linked to another user")},
+		{
+			name:         "another_identity",
+			userIdentity: true,
+			expErr:       errors.New("ClientError: another identity of the provider is linked, unlink it first"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.linkedID != uuid.Nil {
+				mockRepo.EXPECT().GetIdentityUserID(ctx, testIssuer, "subject").Return(test.linkedID, nil).Times(1)
+			} else {
+				mockRepo.EXPECT().GetIdentityUserID(ctx, testIssuer, "subject").Return(uuid.Nil, errEmptyRows).Times(1)
+				mockRepo.EXPECT().IsEmptyRows(errEmptyRows).Return(true).Times(1)
+
+				if test.userIdentity {
+					mockRepo.EXPECT().GetUserIdentity(ctx, userID, testIssuer).Return(users.Identity{Subject: "another"}, nil).Times(1)
+				} else {
+					mockRepo.EXPECT().GetUserIdentity(ctx, userID, testIssuer).Return(users.Identity{}, errEmptyRows).Times(1)
+					mockRepo.EXPECT().IsEmptyRows(errEmptyRows).Return(true).Times(1)
+				}
+			}
+			mockRepo.EXPECT().AddIdentity(ctx, gomock.AssignableToTypeOf(users.Identity{})).Return(nil).Times(test.addCalls)
+
+			err := userUsecase.LinkOIDC(ctx, userID, claims)
+
+			if got, want := err, test.expErr; !errors.Is(got, want) {
+				t.Errorf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestUnlinkOIDC(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	userUsecase := New(mockRepo, Options{})
+
+	ctx := context.Background()
+	userID := uuid.New()
+
+	tests := []struct {
+		name        string
+		password    string
+		removed     bool
+		removeCalls int
+		expErr      error
+	}{
+		{name: "unlinked", password: "hash", removed: true, removeCalls: 1},
+		{name: "not_linked", password: "hash", removeCalls: 1, expErr: errors.New("ClientError: identity not found")},
+		{name: "no_password", expErr: errors.New("ClientError: user without the password can't unlink the identity")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().GetUserByID(ctx, userID).Return(users.User{ID: userID, Password: test.password}, nil).Times(1)
+			mockRepo.EXPECT().RemoveIdentity(ctx, userID, testIssuer).Return(test.removed, nil).Times(test.removeCalls)
+
+			err := userUsecase.UnlinkOIDC(ctx, userID, testIssuer)
+
+			if got, want := err, test.expErr; !errors.Is(got, want) {
+				t.Errorf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestOIDCUsername(t *testing.T) {
+	tests := []struct {
+		claim string
+		exp   string
+	}{
+		{claim: "alice", exp: "alice"},
+		{claim: "alice@example.com", exp: "alice"},
+		{claim: "j.doe@example.com", exp: ""},
+		{claim: "1alice", exp: ""},
+		{claim: "al", exp: ""},
+		{claim: "", exp: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.claim, func(t *testing.T) {
+			if got, want := oidcUsername(test.claim), test.exp; got != want {
+				t.Errorf("Wrong! Unexpected username!\n\tExpected: %q\n\tActual: %q", want, got)
+			}
+		})
+	}
+}
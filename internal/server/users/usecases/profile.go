@@ -0,0 +1,35 @@
+package usecases
+
+import (
+	"context"
+
+	"passman/internal/server/users"
+
+	"github.com/google/uuid"
+)
+
+// GetUser returns the logged in user with the profile
+func (uu *userUsecase) GetUser(ctx context.Context, userID uuid.UUID) (users.User, error) {
+	user, err := uu.dbRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return users.User{}, newInternalError("GetUser", "failed finding user", err)
+	}
+	return user, nil
+}
+
+// UpdateProfile replaces the whole profile, omitted fields are cleared
+func (uu *userUsecase) UpdateProfile(ctx context.Context, userID uuid.UUID, profile users.Profile) error {
+	if err := uu.dbRepo.UpdateProfile(ctx, userID, profile); err != nil {
+		return newInternalError("UpdateProfile", "failed updating profile", err)
+	}
+	return nil
+}
+
+// RecordLogin saves the time of the authenticated login, it's called after
+// the second factor if the user has one
+func (uu *userUsecase) RecordLogin(ctx context.Context, userID uuid.UUID) error {
+	if err := uu.dbRepo.UpdateLastLogin(ctx, userID, uu.now()); err != nil {
+		return newInternalError("RecordLogin", "failed updating last login", err)
+	}
+	return nil
+}
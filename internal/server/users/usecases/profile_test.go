@@ -0,0 +1,97 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"passman/internal/server/users"
+	mock_usecases "passman/internal/server/users/usecases/mock"
+
+	"github.com/google/uuid"
+	"go.uber.org/mock/gomock"
+)
+
+func TestUpdateProfile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	userUsecase := New(mockRepo, Options{})
+
+	ctx := context.Background()
+	userID := uuid.New()
+
+	tests := []struct {
+		name      string
+		profile   users.Profile
+		updateErr error
+		expErr    error
+	}{
+		{
+			name:    "success",
+			profile: users.Profile{DisplayName: "Alice Smith", Email: "alice@example.com", Locale: "en-US", Timezone: "Europe/Berlin"},
+		},
+		{
+			name: "cleared",
+		},
+		{
+			name:      "failed_updating_profile",
+			updateErr: errors.New("internal error"),
+			expErr:    errors.New("UpdateProfile: failed updating profile"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().UpdateProfile(ctx, userID, test.profile).Return(test.updateErr).Times(1)
+
+			err := userUsecase.UpdateProfile(ctx, userID, test.profile)
+
+			if got, want := err, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestRecordLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	userUsecase := New(mockRepo, Options{})
+	now := time.Unix(1700000000, 0)
+	userUsecase.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	userID := uuid.New()
+
+	tests := []struct {
+		name      string
+		updateErr error
+		expErr    error
+	}{
+		{
+			name: "success",
+		},
+		{
+			name:      "failed_updating_last_login",
+			updateErr: errors.New("internal error"),
+			expErr:    errors.New("RecordLogin: failed updating last login"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().UpdateLastLogin(ctx, userID, now).Return(test.updateErr).Times(1)
+
+			err := userUsecase.RecordLogin(ctx, userID)
+
+			if got, want := err, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"strings"
+	"time"
+
+	"passman/internal/server/users"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/google/uuid"
+)
+
+// recoveryKeySize is 160 bits, the key is written down by the user, so it's
+// shorter than tokens
+const recoveryKeySize = 20
+
+var errInvalidRecoveryKey = newClientError("invalid username or recovery key")
+
+// newRecoveryKey returns the key grouped by 4 characters and its stored hash
+func (uu *userUsecase) newRecoveryKey(userID uuid.UUID) (string, users.RecoveryKey, error) {
+	raw := make([]byte, recoveryKeySize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", users.RecoveryKey{}, err
+	}
+	encoded := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw)
+
+	groups := make([]string, 0, len(encoded)/4)
+	for i := 0; i < len(encoded); i += 4 {
+		groups = append(groups, encoded[i:min(i+4, len(encoded))])
+	}
+	plain := strings.Join(groups, "-")
+
+	return plain, users.RecoveryKey{
+		UserID:    userID,
+		Hash:      hashRecoveryKey(plain),
+		CreatedAt: uu.now(),
+	}, nil
+}
+
+// hashRecoveryKey ignores separators and the case, the key may be retyped
+// from the paper
+func hashRecoveryKey(key string) []byte {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(key))
+	return hashToken(normalized)
+}
+
+// GetRecoveryKey returns the key of the user without the secret and false if
+// the user has none
+func (uu *userUsecase) GetRecoveryKey(ctx context.Context, userID uuid.UUID) (users.RecoveryKey, bool, error) {
+	key, err := uu.dbRepo.GetRecoveryKey(ctx, userID)
+	if err != nil {
+		if uu.dbRepo.IsEmptyRows(err) {
+			return users.RecoveryKey{}, false, nil
+		}
+		return users.RecoveryKey{}, false, newInternalError("GetRecoveryKey", "failed getting recovery key", err)
+	}
+	key.Hash = nil
+	return key, true, nil
+}
+
+// RegenerateRecoveryKey replaces the recovery key, the previous one stops
+// working. Users registered before recovery keys get the first one by it.
+func (uu *userUsecase) RegenerateRecoveryKey(ctx context.Context, userID uuid.UUID) (string, error) {
+	user, err := uu.dbRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return "", newInternalError("RegenerateRecoveryKey", "failed finding user", err)
+	}
+	if len(user.Password) == 0 {
+		return "", newClientError("user without the password has no recovery key")
+	}
+
+	plain, key, err := uu.newRecoveryKey(userID)
+	if err != nil {
+		return "", newInternalError("RegenerateRecoveryKey", "failed generating recovery key", err)
+	}
+	if err := uu.dbRepo.SetRecoveryKey(ctx, key); err != nil {
+		return "", newInternalError("RegenerateRecoveryKey", "failed saving recovery key", err)
+	}
+
+	return plain, nil
+}
+
+// RecoverAccount sets the new password by the recovery key. Accounts are
+// encrypted by the server key, so the vault stays accessible. Second factors
+// are kept: the user still passes them on the next login. The used key is
+// replaced by the returned one, access tokens are revoked and sessions are
+// destroyed by the caller. Attempts are throttled like logins.
+func (uu *userUsecase) RecoverAccount(ctx context.Context, recovery users.AccountRecovery, ip string) (uuid.UUID, string, error) {
+	now := uu.now()
+	if err := uu.checkThrottles(ctx, recovery.Username, ip, now); err != nil {
+		return uuid.Nil, "", err
+	}
+
+	user, err := uu.dbRepo.GetUser(ctx, recovery.Username)
+	if err != nil && !uu.dbRepo.IsEmptyRows(err) {
+		return uuid.Nil, "", newInternalError("RecoverAccount", "failed finding user", err)
+	}
+	valid := false
+	if err == nil {
+		if valid, err = uu.checkRecoveryKey(ctx, user.ID, recovery.RecoveryKey); err != nil {
+			return uuid.Nil, "", err
+		}
+	}
+	if !valid {
+		if err := uu.addLoginFailure(ctx, recovery.Username, ip, now); err != nil {
+			return uuid.Nil, "", err
+		}
+		return uuid.Nil, "", errInvalidRecoveryKey
+	}
+	if !user.DeleteAt.IsZero() {
+		return uuid.Nil, "", errDeletionScheduled
+	}
+	if user.Disabled {
+		return uuid.Nil, "", errUserDisabled
+	}
+
+	if err := uu.checkPassword(user.Username, recovery.Password); err != nil {
+		return uuid.Nil, "", err
+	}
+	hash, err := argon2id.CreateHash(recovery.Password, uu.argon2Params)
+	if err != nil {
+		return uuid.Nil, "", newInternalError("RecoverAccount", "failed creating password hash", err)
+	}
+
+	plain, key, err := uu.newRecoveryKey(user.ID)
+	if err != nil {
+		return uuid.Nil, "", newInternalError("RecoverAccount", "failed generating recovery key", err)
+	}
+
+	updatedUser := users.User{
+		ID:       user.ID,
+		Username: user.Username,
+		Password: hash,
+	}
+
+	err = uu.dbRepo.WithinTx(ctx, func(txCtx context.Context) error {
+		if err := uu.dbRepo.UpdateUser(txCtx, updatedUser); err != nil {
+			return err
+		}
+		if err := uu.dbRepo.SetRecoveryKey(txCtx, key); err != nil {
+			return err
+		}
+		if err := uu.dbRepo.RemoveAccessTokens(txCtx, user.ID); err != nil {
+			return err
+		}
+		return uu.dbRepo.AddAuditEvent(txCtx, newAuditEvent(users.AuditAccountRecovered, user, user, now))
+	})
+	if err != nil {
+		return uuid.Nil, "", newInternalError("RecoverAccount", "failed updating user", err)
+	}
+
+	if err := uu.dbRepo.RemoveLoginThrottle(ctx, users.ThrottleUsername, recovery.Username); err != nil {
+		return uuid.Nil, "", newInternalError("RecoverAccount", "failed removing login throttle", err)
+	}
+
+	return user.ID, plain, nil
+}
+
+// checkRecoveryKey compares hashes in constant time, users without the key
+// never match
+func (uu *userUsecase) checkRecoveryKey(ctx context.Context, userID uuid.UUID, plain string) (bool, error) {
+	key, err := uu.dbRepo.GetRecoveryKey(ctx, userID)
+	if err != nil {
+		if uu.dbRepo.IsEmptyRows(err) {
+			return false, nil
+		}
+		return false, newInternalError("RecoverAccount", "failed getting recovery key", err)
+	}
+	return subtle.ConstantTimeCompare(hashRecoveryKey(plain), key.Hash) == 1, nil
+}
+
+func newAuditEvent(action string, actor, target users.User, now time.Time) users.AuditEvent {
+	return users.AuditEvent{
+		ID:             uuid.New(),
+		Action:         action,
+		ActorID:        actor.ID,
+		ActorUsername:  actor.Username,
+		TargetID:       target.ID,
+		TargetUsername: target.Username,
+		CreatedAt:      now,
+	}
+}
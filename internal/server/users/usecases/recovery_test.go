@@ -0,0 +1,272 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"passman/internal/server/users"
+	mock_usecases "passman/internal/server/users/usecases/mock"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/google/uuid"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRecoveryKey(t *testing.T) {
+	userUsecase := New(nil, Options{})
+	now := time.Unix(1700000000, 0)
+	userUsecase.now = func() time.Time { return now }
+	userID := uuid.New()
+
+	plain, key, err := userUsecase.newRecoveryKey(userID)
+	if err != nil {
+		t.Fatalf("Wrong! Unexpected error!\n\tExpected: nil\n\tActual: %v", err)
+	}
+
+	if got, want := len(strings.Split(plain, "-")), 8; got != want {
+		t.Errorf("Wrong! Unexpected number of groups in %q!\n\tExpected: %d\n\tActual: %d", plain, want, got)
+	}
+	if key.UserID != userID || !key.CreatedAt.Equal(now) {
+		t.Errorf("Wrong! Unexpected recovery key: %+v", key)
+	}
+
+	// The key retyped from the paper
+	retyped := strings.ToLower(strings.ReplaceAll(plain, "-", " "))
+	if !bytes.Equal(hashRecoveryKey(retyped), key.Hash) {
+		t.Errorf("Wrong! Retyped key %q doesn't match", retyped)
+	}
+	if bytes.Equal(hashRecoveryKey(plain[1:]), key.Hash) {
+		t.Errorf("Wrong! Truncated key matches")
+	}
+}
+
+func TestRecoverAccount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	userUsecase := New(mockRepo, Options{})
+	now := time.Unix(1700000000, 0)
+	userUsecase.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	errEmptyRows := errors.New("empty rows")
+	ip := "192.0.2.1"
+	user := users.User{ID: uuid.New(), Username: "alice", Password: "old_hash", PasswordResetRequired: true}
+	disabledUser := users.User{ID: user.ID, Username: "alice", Password: "old_hash", Disabled: true}
+	scheduledUser := users.User{ID: user.ID, Username: "alice", Password: "old_hash", DeleteAt: now.Add(time.Hour)}
+
+	plainKey, key, _ := userUsecase.newRecoveryKey(user.ID)
+	recovery := users.AccountRecovery{
+		Username:    "alice",
+		RecoveryKey: plainKey,
+		Password:    "New-Strong-Password-42",
+	}
+
+	tests := []struct {
+		name        string
+		recovery    users.AccountRecovery
+		user        *users.User
+		keyErr      error
+		lockedUntil time.Time
+		failure     bool
+		update      bool
+		expErr      error
+	}{
+		{
+			name:        "locked",
+			recovery:    recovery,
+			lockedUntil: now.Add(time.Minute),
+			expErr:      errors.New("ClientError: too many login attempts, try again later"),
+		},
+		{
+			name:     "unknown_user",
+			recovery: recovery,
+			failure:  true,
+			expErr:   errors.New("ClientError: invalid username or recovery key"),
+		},
+		{
+			name:     "no_recovery_key",
+			recovery: recovery,
+			user:     &user,
+			keyErr:   errEmptyRows,
+			failure:  true,
+			expErr:   errors.New("ClientError: invalid username or recovery key"),
+		},
+		{
+			name:     "wrong_recovery_key",
+			recovery: users.AccountRecovery{Username: "alice", RecoveryKey: "AAAA-BBBB", Password: recovery.Password},
+			user:     &user,
+			failure:  true,
+			expErr:   errors.New("ClientError: invalid username or recovery key"),
+		},
+		{
+			name:     "disabled",
+			recovery: recovery,
+			user:     &disabledUser,
+			expErr:   errors.New("ClientError: user is disabled"),
+		},
+		{
+			name:     "deletion_scheduled",
+			recovery: recovery,
+			user:     &scheduledUser,
+			expErr:   errors.New("ClientError: user is scheduled for deletion"),
+		},
+		{
+			name:     "success",
+			recovery: recovery,
+			user:     &user,
+			update:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().IsEmptyRows(errEmptyRows).Return(true).AnyTimes()
+			mockRepo.EXPECT().
+				GetLoginThrottle(ctx, users.ThrottleUsername, test.recovery.Username).
+				Return(users.LoginThrottle{LockedUntil: test.lockedUntil}, nil).
+				Times(1)
+
+			if test.lockedUntil.IsZero() {
+				mockRepo.EXPECT().GetLoginThrottle(ctx, users.ThrottleIP, ip).Return(users.LoginThrottle{}, errEmptyRows).Times(1)
+
+				if test.user != nil {
+					mockRepo.EXPECT().GetUser(ctx, test.recovery.Username).Return(*test.user, nil).Times(1)
+					mockRepo.EXPECT().GetRecoveryKey(ctx, test.user.ID).Return(key, test.keyErr).Times(1)
+				} else {
+					mockRepo.EXPECT().GetUser(ctx, test.recovery.Username).Return(users.User{}, errEmptyRows).Times(1)
+				}
+			}
+
+			if test.failure {
+				resetBefore := now.Add(-failuresWindow)
+				mockRepo.EXPECT().AddLoginFailure(ctx, users.ThrottleUsername, test.recovery.Username, now, resetBefore).Return(int64(1), nil).Times(1)
+				mockRepo.EXPECT().AddLoginFailure(ctx, users.ThrottleIP, ip, now, resetBefore).Return(int64(1), nil).Times(1)
+				mockRepo.EXPECT().RemoveStaleLoginThrottles(ctx, resetBefore, now).Return(nil).Times(1)
+			}
+
+			var updated users.User
+			var newKey users.RecoveryKey
+			if test.update {
+				mockRepo.EXPECT().
+					WithinTx(ctx, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+						return fn(ctx)
+					}).
+					Times(1)
+				mockRepo.EXPECT().
+					UpdateUser(ctx, gomock.Any()).
+					DoAndReturn(func(_ context.Context, u users.User) error {
+						updated = u
+						return nil
+					}).
+					Times(1)
+				mockRepo.EXPECT().
+					SetRecoveryKey(ctx, gomock.Any()).
+					DoAndReturn(func(_ context.Context, k users.RecoveryKey) error {
+						newKey = k
+						return nil
+					}).
+					Times(1)
+				mockRepo.EXPECT().RemoveAccessTokens(ctx, user.ID).Return(nil).Times(1)
+				mockRepo.EXPECT().
+					AddAuditEvent(ctx, gomock.Any()).
+					DoAndReturn(func(_ context.Context, e users.AuditEvent) error {
+						if e.Action != users.AuditAccountRecovered || e.ActorID != user.ID || e.TargetID != user.ID || !e.CreatedAt.Equal(now) {
+							t.Errorf("Wrong! Unexpected audit event: %+v", e)
+						}
+						return nil
+					}).
+					Times(1)
+				mockRepo.EXPECT().RemoveLoginThrottle(ctx, users.ThrottleUsername, test.recovery.Username).Return(nil).Times(1)
+			}
+
+			userID, plain, err := userUsecase.RecoverAccount(ctx, test.recovery, ip)
+
+			if got, want := err, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if !test.update {
+				return
+			}
+
+			if got, want := userID, user.ID; got != want {
+				t.Errorf("Wrong! Unexpected user id!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if match, _ := argon2id.ComparePasswordAndHash(test.recovery.Password, updated.Password); !match {
+				t.Errorf("Wrong! New password doesn't match the hash")
+			}
+			if updated.PasswordResetRequired {
+				t.Errorf("Wrong! Recovered user still has to reset the password")
+			}
+			// The used key is replaced
+			if plain == plainKey || !bytes.Equal(hashRecoveryKey(plain), newKey.Hash) {
+				t.Errorf("Wrong! Recovery key isn't rotated")
+			}
+		})
+	}
+}
+
+func TestRegenerateRecoveryKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	userUsecase := New(mockRepo, Options{})
+
+	ctx := context.Background()
+	user := users.User{ID: uuid.New(), Username: "alice", Password: "hash"}
+	providerUser := users.User{ID: uuid.New(), Username: "provider"}
+
+	tests := []struct {
+		name   string
+		user   users.User
+		expErr error
+	}{
+		{
+			name:   "user_without_password",
+			user:   providerUser,
+			expErr: errors.New("ClientError: user without the password has no recovery key"),
+		},
+		{
+			name: "success",
+			user: user,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().GetUserByID(ctx, test.user.ID).Return(test.user, nil).Times(1)
+
+			var saved users.RecoveryKey
+			saveCalls := 0
+			if test.expErr == nil {
+				saveCalls = 1
+			}
+			mockRepo.EXPECT().
+				SetRecoveryKey(ctx, gomock.Any()).
+				DoAndReturn(func(_ context.Context, k users.RecoveryKey) error {
+					saved = k
+					return nil
+				}).
+				Times(saveCalls)
+
+			plain, err := userUsecase.RegenerateRecoveryKey(ctx, test.user.ID)
+
+			if got, want := err, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if test.expErr != nil {
+				return
+			}
+			if saved.UserID != test.user.ID || !bytes.Equal(hashRecoveryKey(plain), saved.Hash) {
+				t.Errorf("Wrong! Unexpected saved key: %+v", saved)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"passman/internal/server/users"
+
+	"github.com/google/uuid"
+)
+
+// Failures older than failuresWindow are forgotten
+const failuresWindow = time.Hour
+
+var errTooManyAttempts = newThrottledError("too many login attempts, try again later")
+
+// throttlePolicy delays logins after freeFailures with exponential backoff and
+// locks them for lockout after lockoutFailures
+type throttlePolicy struct {
+	freeFailures    int64
+	lockoutFailures int64
+	maxBackoff      time.Duration
+	lockout         time.Duration
+}
+
+var (
+	usernamePolicy = throttlePolicy{freeFailures: 3, lockoutFailures: 10, maxBackoff: 5 * time.Minute, lockout: 30 * time.Minute}
+	// Many users may share the ip behind NAT, so it's limited softer
+	ipPolicy = throttlePolicy{freeFailures: 20, lockoutFailures: 100, maxBackoff: 5 * time.Minute, lockout: 30 * time.Minute}
+)
+
+// lockedUntil returns the time of the next allowed login after the failures,
+// it's zero if the login isn't delayed
+func (tp throttlePolicy) lockedUntil(failures int64, now time.Time) time.Time {
+	switch {
+	case failures >= tp.lockoutFailures:
+		return now.Add(tp.lockout)
+	case failures > tp.freeFailures:
+		// 1s, 2s, 4s... up to maxBackoff, the shift is capped to avoid overflow
+		backoff := time.Second << min(failures-tp.freeFailures-1, 30)
+		return now.Add(min(backoff, tp.maxBackoff))
+	}
+	return time.Time{}
+}
+
+type loginThrottle struct {
+	kind    string
+	subject string
+	policy  throttlePolicy
+}
+
+func loginThrottles(username, ip string) []loginThrottle {
+	return []loginThrottle{
+		{kind: users.ThrottleUsername, subject: username, policy: usernamePolicy},
+		{kind: users.ThrottleIP, subject: ip, policy: ipPolicy},
+	}
+}
+
+// sendThrottles limit passwords of the send like passwords of the username
+func sendThrottles(sendID uuid.UUID, ip string) []loginThrottle {
+	return []loginThrottle{
+		{kind: users.ThrottleSend, subject: sendID.String(), policy: usernamePolicy},
+		{kind: users.ThrottleIP, subject: ip, policy: ipPolicy},
+	}
+}
+
+func (uu *userUsecase) checkThrottles(ctx context.Context, username, ip string, now time.Time) error {
+	return uu.checkLoginThrottles(ctx, loginThrottles(username, ip), now)
+}
+
+func (uu *userUsecase) checkLoginThrottles(ctx context.Context, throttles []loginThrottle, now time.Time) error {
+	for _, lt := range throttles {
+		throttle, err := uu.dbRepo.GetLoginThrottle(ctx, lt.kind, lt.subject)
+		if err != nil {
+			if uu.dbRepo.IsEmptyRows(err) {
+				continue
+			}
+			return newInternalError("Login", "failed getting login throttle", err)
+		}
+		if throttle.LockedUntil.After(now) {
+			return errTooManyAttempts
+		}
+	}
+	return nil
+}
+
+func (uu *userUsecase) addLoginFailure(ctx context.Context, username, ip string, now time.Time) error {
+	return uu.addLoginFailures(ctx, loginThrottles(username, ip), now)
+}
+
+func (uu *userUsecase) addLoginFailures(ctx context.Context, throttles []loginThrottle, now time.Time) error {
+	for _, lt := range throttles {
+		failures, err := uu.dbRepo.AddLoginFailure(ctx, lt.kind, lt.subject, now, now.Add(-failuresWindow))
+		if err != nil {
+			return newInternalError("Login", "failed adding login failure", err)
+		}
+
+		if lockedUntil := lt.policy.lockedUntil(failures, now); !lockedUntil.IsZero() {
+			if err := uu.dbRepo.LockLogin(ctx, lt.kind, lt.subject, lockedUntil); err != nil {
+				return newInternalError("Login", "failed locking login", err)
+			}
+		}
+	}
+
+	if err := uu.dbRepo.RemoveStaleLoginThrottles(ctx, now.Add(-failuresWindow), now); err != nil {
+		return newInternalError("Login", "failed removing stale login throttles", err)
+	}
+
+	return nil
+}
+
+// completeLogin forgets failed logins of the username after the last factor of
+// the login, the password alone doesn't reset the lockout of the user with the
+// second factor
+func (uu *userUsecase) completeLogin(ctx context.Context, component string, user users.User) error {
+	if err := uu.dbRepo.RemoveLoginThrottle(ctx, users.ThrottleUsername, user.Username); err != nil {
+		return newInternalError(component, "failed removing login throttle", err)
+	}
+	return nil
+}
+
+// GetLockedAccounts returns usernames which can't log in because of failed
+// attempts
+func (uu *userUsecase) GetLockedAccounts(ctx context.Context) ([]users.LoginThrottle, error) {
+	locked, err := uu.dbRepo.GetLockedLogins(ctx, users.ThrottleUsername, uu.now())
+	if err != nil {
+		return nil, newInternalError("GetLockedAccounts", "failed getting locked logins", err)
+	}
+	return locked, nil
+}
+
+// UnlockAccount forgets failed attempts of the username
+func (uu *userUsecase) UnlockAccount(ctx context.Context, username string) error {
+	if err := uu.dbRepo.RemoveLoginThrottle(ctx, users.ThrottleUsername, username); err != nil {
+		return newInternalError("UnlockAccount", "failed removing login throttle", err)
+	}
+	return nil
+}
+
+// SendAttemptAllowed tells whether the password of the send may be checked,
+// wrong passwords of the send or the client ip delay next attempts
+func (uu *userUsecase) SendAttemptAllowed(ctx context.Context, sendID uuid.UUID, ip string) (bool, error) {
+	err := uu.checkLoginThrottles(ctx, sendThrottles(sendID, ip), uu.now())
+	if errors.Is(err, errTooManyAttempts) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// AddSendFailure counts the wrong password of the send
+func (uu *userUsecase) AddSendFailure(ctx context.Context, sendID uuid.UUID, ip string) error {
+	return uu.addLoginFailures(ctx, sendThrottles(sendID, ip), uu.now())
+}
+
+// RemoveSendThrottle forgets wrong passwords of the send after the correct one
+func (uu *userUsecase) RemoveSendThrottle(ctx context.Context, sendID uuid.UUID) error {
+	if err := uu.dbRepo.RemoveLoginThrottle(ctx, users.ThrottleSend, sendID.String()); err != nil {
+		return newInternalError("RemoveSendThrottle", "failed removing send throttle", err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"passman/internal/server/users"
+	mock_usecases "passman/internal/server/users/usecases/mock"
+
+	"github.com/google/uuid"
+	"go.uber.org/mock/gomock"
+)
+
+func TestThrottlePolicyLockedUntil(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	policy := throttlePolicy{freeFailures: 3, lockoutFailures: 10, maxBackoff: 30 * time.Second, lockout: time.Hour}
+
+	tests := []struct {
+		failures int64
+		want     time.Time
+	}{
+		{failures: 1, want: time.Time{}},
+		{failures: 3, want: time.Time{}},
+		{failures: 4, want: now.Add(time.Second)},
+		{failures: 5, want: now.Add(2 * time.Second)},
+		{failures: 7, want: now.Add(8 * time.Second)},
+		{failures: 9, want: now.Add(30 * time.Second)},
+		{failures: 10, want: now.Add(time.Hour)},
+		{failures: 1000, want: now.Add(time.Hour)},
+	}
+
+	for _, test := range tests {
+		if got := policy.lockedUntil(test.failures, now); !got.Equal(test.want) {
+			t.Errorf("Wrong! Unexpected lock for %d failures!\n\tExpected: %v\n\tActual: %v", test.failures, test.want, got)
+		}
+	}
+}
+
+func TestSendAttemptAllowed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	userUsecase := New(mockRepo, Options{})
+	now := time.Unix(1700000000, 0)
+	userUsecase.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	errEmptyRows := errors.New("empty rows")
+	sendID := uuid.New()
+	ip := "192.0.2.1"
+
+	tests := []struct {
+		name       string
+		sendLocked bool
+		ipLocked   bool
+		getErr     error
+		expAllowed bool
+		expErr     error
+	}{
+		{
+			name:   "failed_getting_throttle",
+			getErr: errors.New("internal error"),
+			expErr: errors.New("Login: failed getting login throttle"),
+		},
+		{
+			name:       "send_locked",
+			sendLocked: true,
+		},
+		{
+			name:     "ip_locked",
+			ipLocked: true,
+		},
+		{
+			name:       "allowed",
+			expAllowed: true,
+		},
+	}
+
+	throttle := func(locked bool) (users.LoginThrottle, error) {
+		if locked {
+			return users.LoginThrottle{LockedUntil: now.Add(time.Second)}, nil
+		}
+		return users.LoginThrottle{}, errEmptyRows
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.getErr != nil {
+				mockRepo.EXPECT().GetLoginThrottle(ctx, users.ThrottleSend, sendID.String()).Return(users.LoginThrottle{}, test.getErr).Times(1)
+				mockRepo.EXPECT().IsEmptyRows(test.getErr).Return(false).Times(1)
+			} else {
+				mockRepo.EXPECT().GetLoginThrottle(ctx, users.ThrottleSend, sendID.String()).Return(throttle(test.sendLocked)).Times(1)
+				if !test.sendLocked {
+					mockRepo.EXPECT().IsEmptyRows(errEmptyRows).Return(true).Times(1)
+					mockRepo.EXPECT().GetLoginThrottle(ctx, users.ThrottleIP, ip).Return(throttle(test.ipLocked)).Times(1)
+					if !test.ipLocked {
+						mockRepo.EXPECT().IsEmptyRows(errEmptyRows).Return(true).Times(1)
+					}
+				}
+			}
+
+			allowed, actErr := userUsecase.SendAttemptAllowed(ctx, sendID, ip)
+
+			if got, want := actErr, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if got, want := allowed, test.expAllowed; got != want {
+				t.Errorf("Wrong! Unexpected result!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
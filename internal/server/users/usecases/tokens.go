@@ -0,0 +1,123 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"slices"
+	"time"
+
+	"passman/internal/server/users"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// accessTokenPrefix makes tokens recognizable by secret scanners
+	accessTokenPrefix = "pm_"
+	maxAccessTokens   = 50
+	// Last usage is tracked with minute precision to avoid a write on every request
+	lastUsedPrecision = time.Minute
+)
+
+var (
+	errInvalidToken = newClientError("invalid access token")
+	errExpiredToken = newClientError("access token expired")
+)
+
+// CreateAccessToken returns the new token of the user, it isn't stored and
+// can't be shown again. Zero ttl creates the token without expiry.
+func (uu *userUsecase) CreateAccessToken(ctx context.Context, userID uuid.UUID, name string, scopes []string, ttl time.Duration) (users.AccessToken, string, error) {
+	tokens, err := uu.dbRepo.GetAccessTokens(ctx, userID)
+	if err != nil {
+		return users.AccessToken{}, "", newInternalError("CreateAccessToken", "failed getting tokens", err)
+	}
+	if len(tokens) >= maxAccessTokens {
+		return users.AccessToken{}, "", newClientError("too many access tokens")
+	}
+	for _, token := range tokens {
+		if token.Name == name {
+			return users.AccessToken{}, "", newClientError("access token with this name already exist")
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return users.AccessToken{}, "", newInternalError("CreateAccessToken", "failed generating token", err)
+	}
+	plain := accessTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+
+	now := uu.now()
+	token := users.AccessToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		Hash:      hashToken(plain),
+		Scopes:    slices.Compact(scopes),
+		CreatedAt: now,
+	}
+	if ttl > 0 {
+		token.ExpiresAt = now.Add(ttl)
+	}
+
+	if err := uu.dbRepo.AddAccessToken(ctx, token); err != nil {
+		return users.AccessToken{}, "", newInternalError("CreateAccessToken", "failed adding token", err)
+	}
+
+	return token, plain, nil
+}
+
+func (uu *userUsecase) GetAccessTokens(ctx context.Context, userID uuid.UUID) ([]users.AccessToken, error) {
+	tokens, err := uu.dbRepo.GetAccessTokens(ctx, userID)
+	if err != nil {
+		return nil, newInternalError("GetAccessTokens", "failed getting tokens", err)
+	}
+	return tokens, nil
+}
+
+func (uu *userUsecase) RemoveAccessToken(ctx context.Context, userID, tokenID uuid.UUID) error {
+	removed, err := uu.dbRepo.RemoveAccessToken(ctx, userID, tokenID)
+	if err != nil {
+		return newInternalError("RemoveAccessToken", "failed removing token", err)
+	}
+	if !removed {
+		return newClientError("access token not found")
+	}
+	return nil
+}
+
+// VerifyAccessToken returns the token by its plain value and tracks its usage
+func (uu *userUsecase) VerifyAccessToken(ctx context.Context, plain string) (users.AccessToken, error) {
+	token, err := uu.dbRepo.GetAccessTokenByHash(ctx, hashToken(plain))
+	if err != nil {
+		if uu.dbRepo.IsEmptyRows(err) {
+			return users.AccessToken{}, errInvalidToken
+		}
+		return users.AccessToken{}, newInternalError("VerifyAccessToken", "failed getting token", err)
+	}
+
+	now := uu.now()
+	if token.Expired(now) {
+		return users.AccessToken{}, errExpiredToken
+	}
+
+	if now.Sub(token.LastUsedAt) >= lastUsedPrecision {
+		if err := uu.dbRepo.UpdateAccessTokenLastUsed(ctx, token.ID, now); err != nil {
+			return users.AccessToken{}, newInternalError("VerifyAccessToken", "failed updating last usage", err)
+		}
+		token.LastUsedAt = now
+	}
+
+	return token, nil
+}
+
+// hashToken doesn't need a slow hash, access tokens and invites have 256 bits
+// of entropy, recovery keys have 160 bits
+func hashToken(plain string) []byte {
+	sum := sha256.Sum256([]byte(plain))
+	return sum[:]
+}
@@ -0,0 +1,160 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"passman/internal/server/users"
+	mock_usecases "passman/internal/server/users/usecases/mock"
+
+	"github.com/google/uuid"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateAccessToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	userUsecase := New(mockRepo, Options{})
+	now := time.Unix(1700000000, 0)
+	userUsecase.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	userID := uuid.New()
+
+	tooMany := make([]users.AccessToken, maxAccessTokens)
+
+	tests := []struct {
+		name      string
+		existing  []users.AccessToken
+		ttl       time.Duration
+		addCalls  int
+		expExpiry time.Time
+		expErr    error
+	}{
+		{
+			name:     "dublicate_name",
+			existing: []users.AccessToken{{Name: "ci"}},
+			expErr:   errors.New("ClientError: access token with this name already exist"),
+		},
+		{
+			name:     "too_many_tokens",
+			existing: tooMany,
+			expErr:   errors.New("ClientError: too many access tokens"),
+		},
+		{
+			name:     "without_expiry",
+			existing: []users.AccessToken{{Name: "backup"}},
+			addCalls: 1,
+		},
+		{
+			name:      "with_expiry",
+			ttl:       24 * time.Hour,
+			addCalls:  1,
+			expExpiry: now.Add(24 * time.Hour),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().GetAccessTokens(ctx, userID).Return(test.existing, nil).Times(1)
+
+			var added users.AccessToken
+			mockRepo.EXPECT().
+				AddAccessToken(ctx, gomock.Any()).
+				DoAndReturn(func(_ context.Context, token users.AccessToken) error {
+					added = token
+					return nil
+				}).
+				Times(test.addCalls)
+
+			scopes := []string{users.ScopeServices, users.ScopeAccountsRead, users.ScopeServices}
+			token, plain, actErr := userUsecase.CreateAccessToken(ctx, userID, "ci", scopes, test.ttl)
+
+			if got, want := actErr, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if test.addCalls == 0 {
+				return
+			}
+
+			if !strings.HasPrefix(plain, accessTokenPrefix) {
+				t.Errorf("Wrong! Token without prefix: %s", plain)
+			}
+			if !bytes.Equal(added.Hash, hashToken(plain)) || strings.Contains(string(added.Hash), plain) {
+				t.Errorf("Wrong! Stored hash doesn't match the token")
+			}
+			if got, want := added.Scopes, []string{users.ScopeAccountsRead, users.ScopeServices}; !slices.Equal(got, want) {
+				t.Errorf("Wrong! Unexpected scopes!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if got, want := token.ExpiresAt, test.expExpiry; !got.Equal(want) {
+				t.Errorf("Wrong! Unexpected expiry!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestVerifyAccessToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	userUsecase := New(mockRepo, Options{})
+	now := time.Unix(1700000000, 0)
+	userUsecase.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	errEmptyRows := errors.New("empty rows")
+	tokenID := uuid.New()
+	plain := accessTokenPrefix + "secret"
+
+	tests := []struct {
+		name        string
+		token       users.AccessToken
+		getErr      error
+		updateCalls int
+		expErr      error
+	}{
+		{
+			name:   "unknown_token",
+			getErr: errEmptyRows,
+			expErr: errors.New("ClientError: invalid access token"),
+		},
+		{
+			name:   "expired",
+			token:  users.AccessToken{ID: tokenID, ExpiresAt: now},
+			expErr: errors.New("ClientError: access token expired"),
+		},
+		{
+			name:        "first_usage",
+			token:       users.AccessToken{ID: tokenID, ExpiresAt: now.Add(time.Hour)},
+			updateCalls: 1,
+		},
+		{
+			name:  "recently_used",
+			token: users.AccessToken{ID: tokenID, LastUsedAt: now.Add(-time.Second)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().GetAccessTokenByHash(ctx, hashToken(plain)).Return(test.token, test.getErr).Times(1)
+			if test.getErr != nil {
+				mockRepo.EXPECT().IsEmptyRows(test.getErr).Return(test.getErr == errEmptyRows).Times(1)
+			}
+			mockRepo.EXPECT().UpdateAccessTokenLastUsed(ctx, tokenID, now).Return(nil).Times(test.updateCalls)
+
+			_, actErr := userUsecase.VerifyAccessToken(ctx, plain)
+
+			if got, want := actErr, test.expErr; !errors.Is(got, want) {
+				t.Errorf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"passman/internal/server/users"
+	"passman/pkg/totp"
+
+	"github.com/google/uuid"
+)
+
+const (
+	totpIssuer         = "passman"
+	recoveryCodesCount = 10
+)
+
+var (
+	errInvalidCode         = newClientError("invalid two-factor code")
+	errTwoFactorEnabled    = newClientError("two-factor authentication already enabled")
+	errTwoFactorNotEnabled = newClientError("two-factor authentication is not enabled")
+)
+
+func (uu *userUsecase) GetTwoFactorStatus(ctx context.Context, userID uuid.UUID) (users.TwoFactorStatus, error) {
+	userTOTP, err := uu.dbRepo.GetTOTP(ctx, userID)
+	if err != nil {
+		if uu.dbRepo.IsEmptyRows(err) {
+			return users.TwoFactorStatus{}, nil
+		}
+		return users.TwoFactorStatus{}, newInternalError("GetTwoFactorStatus", "failed getting two-factor settings", err)
+	}
+
+	count, err := uu.dbRepo.CountRecoveryCodes(ctx, userID)
+	if err != nil {
+		return users.TwoFactorStatus{}, newInternalError("GetTwoFactorStatus", "failed counting recovery codes", err)
+	}
+
+	return users.TwoFactorStatus{Enabled: userTOTP.Enabled, RecoveryCodesLeft: count}, nil
+}
+
+// SetupTOTP generates the new secret. It isn't used for login until the user
+// confirms it by EnableTOTP.
+func (uu *userUsecase) SetupTOTP(ctx context.Context, userID uuid.UUID) (users.TOTPSetup, error) {
+	if userTOTP, err := uu.dbRepo.GetTOTP(ctx, userID); err != nil && !uu.dbRepo.IsEmptyRows(err) {
+		return users.TOTPSetup{}, newInternalError("SetupTOTP", "failed getting two-factor settings", err)
+	} else if userTOTP.Enabled {
+		return users.TOTPSetup{}, errTwoFactorEnabled
+	}
+
+	user, err := uu.dbRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return users.TOTPSetup{}, newInternalError("SetupTOTP", "failed finding user", err)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return users.TOTPSetup{}, newInternalError("SetupTOTP", "failed generating secret", err)
+	}
+
+	if err := uu.dbRepo.SetTOTP(ctx, users.TOTP{UserID: userID, Secret: secret}); err != nil {
+		return users.TOTPSetup{}, newInternalError("SetupTOTP", "failed saving secret", err)
+	}
+
+	return users.TOTPSetup{Secret: secret, URI: totp.URI(totpIssuer, user.Username, secret)}, nil
+}
+
+// EnableTOTP enables two-factor authentication after the first valid code and
+// returns the recovery codes. They are shown only once.
+func (uu *userUsecase) EnableTOTP(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	userTOTP, err := uu.dbRepo.GetTOTP(ctx, userID)
+	if err != nil {
+		if uu.dbRepo.IsEmptyRows(err) {
+			return nil, newClientError("two-factor authentication is not set up")
+		}
+		return nil, newInternalError("EnableTOTP", "failed getting two-factor settings", err)
+	}
+	if userTOTP.Enabled {
+		return nil, errTwoFactorEnabled
+	}
+
+	step, ok, err := totp.Validate(userTOTP.Secret, code, uu.now())
+	if err != nil {
+		return nil, newInternalError("EnableTOTP", "failed validating code", err)
+	}
+	if !ok {
+		return nil, errInvalidCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, newInternalError("EnableTOTP", "failed generating recovery codes", err)
+	}
+
+	userTOTP.Enabled, userTOTP.LastStep = true, step
+	err = uu.dbRepo.WithinTx(ctx, func(txCtx context.Context) error {
+		if err := uu.dbRepo.SetTOTP(txCtx, userTOTP); err != nil {
+			return err
+		}
+		if err := uu.dbRepo.RemoveRecoveryCodes(txCtx, userID); err != nil {
+			return err
+		}
+		return uu.dbRepo.AddRecoveryCodes(txCtx, userID, hashes)
+	})
+	if err != nil {
+		return nil, newInternalError("EnableTOTP", "failed enabling two-factor authentication", err)
+	}
+
+	return codes, nil
+}
+
+// LoginTOTP is the second step of the login. It accepts either the code of the
+// authenticator or one of the recovery codes, the recovery code is consumed.
+// Invalid codes are throttled like passwords by the username and the client
+// ip, so new sessions don't get new attempts. Failed logins of the username
+// are forgotten only after the second factor.
+func (uu *userUsecase) LoginTOTP(ctx context.Context, userID uuid.UUID, code, recoveryCode, ip string) error {
+	user, err := uu.dbRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return newInternalError("LoginTOTP", "failed finding user", err)
+	}
+
+	now := uu.now()
+	if err := uu.checkThrottles(ctx, user.Username, ip, now); err != nil {
+		return err
+	}
+
+	err = uu.verifyTOTP(ctx, userID, code, recoveryCode)
+	if errors.Is(err, errInvalidCode) {
+		if err := uu.addLoginFailure(ctx, user.Username, ip, now); err != nil {
+			return err
+		}
+		return errInvalidCode
+	}
+	if err != nil {
+		return err
+	}
+
+	return uu.completeLogin(ctx, "LoginTOTP", user)
+}
+
+// verifyTOTP checks the code or consumes the recovery code of the user
+func (uu *userUsecase) verifyTOTP(ctx context.Context, userID uuid.UUID, code, recoveryCode string) error {
+	if len(recoveryCode) > 0 {
+		consumed, err := uu.dbRepo.RemoveRecoveryCode(ctx, userID, hashRecoveryCode(recoveryCode))
+		if err != nil {
+			return newInternalError("LoginTOTP", "failed consuming recovery code", err)
+		}
+		if !consumed {
+			return errInvalidCode
+		}
+		return nil
+	}
+
+	userTOTP, err := uu.dbRepo.GetTOTP(ctx, userID)
+	if err != nil {
+		if uu.dbRepo.IsEmptyRows(err) {
+			return errTwoFactorNotEnabled
+		}
+		return newInternalError("LoginTOTP", "failed getting two-factor settings", err)
+	}
+	if !userTOTP.Enabled {
+		return errTwoFactorNotEnabled
+	}
+
+	step, ok, err := totp.Validate(userTOTP.Secret, code, uu.now())
+	if err != nil {
+		return newInternalError("LoginTOTP", "failed validating code", err)
+	}
+	if !ok || step <= userTOTP.LastStep {
+		return errInvalidCode
+	}
+
+	// The step is saved only if it's still newer, so the code can't be
+	// replayed by concurrent requests
+	updated, err := uu.dbRepo.UpdateTOTPStep(ctx, userID, step)
+	if err != nil {
+		return newInternalError("LoginTOTP", "failed saving code step", err)
+	}
+	if !updated {
+		return errInvalidCode
+	}
+
+	return nil
+}
+
+func (uu *userUsecase) DisableTOTP(ctx context.Context, userID uuid.UUID, password string) error {
+	if err := uu.confirmPassword(ctx, "DisableTOTP", userID, password); err != nil {
+		return err
+	}
+
+	err := uu.dbRepo.WithinTx(ctx, func(txCtx context.Context) error {
+		if err := uu.dbRepo.RemoveTOTP(txCtx, userID); err != nil {
+			return err
+		}
+		return uu.dbRepo.RemoveRecoveryCodes(txCtx, userID)
+	})
+	if err != nil {
+		return newInternalError("DisableTOTP", "failed disabling two-factor authentication", err)
+	}
+
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of the user with new ones
+func (uu *userUsecase) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, password string) ([]string, error) {
+	if err := uu.confirmPassword(ctx, "RegenerateRecoveryCodes", userID, password); err != nil {
+		return nil, err
+	}
+
+	userTOTP, err := uu.dbRepo.GetTOTP(ctx, userID)
+	if err != nil && !uu.dbRepo.IsEmptyRows(err) {
+		return nil, newInternalError("RegenerateRecoveryCodes", "failed getting two-factor settings", err)
+	}
+	if !userTOTP.Enabled {
+		return nil, errTwoFactorNotEnabled
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, newInternalError("RegenerateRecoveryCodes", "failed generating recovery codes", err)
+	}
+
+	err = uu.dbRepo.WithinTx(ctx, func(txCtx context.Context) error {
+		if err := uu.dbRepo.RemoveRecoveryCodes(txCtx, userID); err != nil {
+			return err
+		}
+		return uu.dbRepo.AddRecoveryCodes(txCtx, userID, hashes)
+	})
+	if err != nil {
+		return nil, newInternalError("RegenerateRecoveryCodes", "failed saving recovery codes", err)
+	}
+
+	return codes, nil
+}
+
+func (uu *userUsecase) confirmPassword(ctx context.Context, component string, userID uuid.UUID, password string) error {
+	match, err := uu.VerifyPassword(ctx, userID, password)
+	if err != nil {
+		return newInternalError(component, "failed verifying password", err)
+	}
+	if !match {
+		return errIncorrectPassword
+	}
+	return nil
+}
+
+// generateRecoveryCodes returns the codes in the form of xxxxx-xxxxx and their hashes
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+
+	for range recoveryCodesCount {
+		raw := make([]byte, 10)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+
+		encoded := strings.ToLower(base32.StdEncoding.EncodeToString(raw))[:10]
+		code := encoded[:5] + "-" + encoded[5:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// Recovery codes are random, so a fast hash is enough to protect them
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
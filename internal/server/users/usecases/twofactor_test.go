@@ -0,0 +1,238 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"passman/internal/server/users"
+	mock_usecases "passman/internal/server/users/usecases/mock"
+	"passman/pkg/totp"
+
+	"github.com/google/uuid"
+	"go.uber.org/mock/gomock"
+)
+
+func TestEnableTOTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	userUsecase := New(mockRepo, Options{})
+	now := time.Unix(1700000000, 0)
+	userUsecase.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	errEmptyRows := errors.New("empty rows")
+	userID := uuid.New()
+	secret := "JBSWY3DPEHPK3PXP"
+	validCode, _ := totp.Code(secret, now)
+
+	tests := []struct {
+		name       string
+		totp       users.TOTP
+		getTOTPErr error
+		code       string
+		txErr      error
+		txCalls    int
+		expErr     error
+	}{
+		{
+			name:       "not_set_up",
+			getTOTPErr: errEmptyRows,
+			code:       validCode,
+			expErr:     errors.New("ClientError: two-factor authentication is not set up"),
+		},
+		{
+			name:   "already_enabled",
+			totp:   users.TOTP{UserID: userID, Secret: secret, Enabled: true},
+			code:   validCode,
+			expErr: errors.New("ClientError: two-factor authentication already enabled"),
+		},
+		{
+			name:   "invalid_code",
+			totp:   users.TOTP{UserID: userID, Secret: secret},
+			code:   "000000",
+			expErr: errors.New("ClientError: invalid two-factor code"),
+		},
+		{
+			name:    "failed_enabling",
+			totp:    users.TOTP{UserID: userID, Secret: secret},
+			code:    validCode,
+			txErr:   errors.New("internal error"),
+			txCalls: 1,
+			expErr:  errors.New("EnableTOTP: failed enabling two-factor authentication"),
+		},
+		{
+			name:    "success",
+			totp:    users.TOTP{UserID: userID, Secret: secret},
+			code:    validCode,
+			txCalls: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().GetTOTP(ctx, userID).Return(test.totp, test.getTOTPErr).Times(1)
+			if test.getTOTPErr != nil {
+				mockRepo.EXPECT().IsEmptyRows(test.getTOTPErr).Return(true).Times(1)
+			}
+
+			mockRepo.EXPECT().WithinTx(ctx, gomock.Any()).Return(test.txErr).Times(test.txCalls)
+
+			codes, err := userUsecase.EnableTOTP(ctx, userID, test.code)
+
+			if got, want := err, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+
+			if test.expErr == nil && len(codes) != recoveryCodesCount {
+				t.Fatalf("Wrong! Unexpected count of recovery codes!\n\tExpected: %d\n\tActual: %d", recoveryCodesCount, len(codes))
+			}
+		})
+	}
+}
+
+func TestLoginTOTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	userUsecase := New(mockRepo, Options{})
+	now := time.Unix(1700000000, 0)
+	userUsecase.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	userID := uuid.New()
+	secret := "JBSWY3DPEHPK3PXP"
+	validCode, _ := totp.Code(secret, now)
+	step := totp.Step(now)
+	enabledTOTP := users.TOTP{UserID: userID, Secret: secret, Enabled: true, LastStep: step - 1}
+	user := users.User{ID: userID, Username: "test_user"}
+	ip := "192.0.2.1"
+	errEmptyRows := errors.New("empty rows")
+	resetBefore := now.Add(-failuresWindow)
+
+	type removeRecoveryCodeResult struct {
+		removed bool
+		err     error
+	}
+
+	type updateStepResult struct {
+		updated bool
+		err     error
+	}
+
+	tests := []struct {
+		name                     string
+		lockedUntil              time.Time
+		code                     string
+		recoveryCode             string
+		removeRecoveryCodeResult *removeRecoveryCodeResult
+		totp                     *users.TOTP
+		updateStepResult         *updateStepResult
+		expErr                   error
+	}{
+		{
+			name:        "locked",
+			lockedUntil: now.Add(time.Minute),
+			code:        validCode,
+			expErr:      errors.New("ClientError: too many login attempts, try again later"),
+		},
+		{
+			name:                     "unknown_recovery_code",
+			recoveryCode:             "abcde-fghij",
+			removeRecoveryCodeResult: &removeRecoveryCodeResult{},
+			expErr:                   errors.New("ClientError: invalid two-factor code"),
+		},
+		{
+			name:                     "recovery_code",
+			recoveryCode:             "ABCDE-FGHIJ",
+			removeRecoveryCodeResult: &removeRecoveryCodeResult{removed: true},
+		},
+		{
+			name:   "not_enabled",
+			code:   validCode,
+			totp:   &users.TOTP{UserID: userID, Secret: secret},
+			expErr: errors.New("ClientError: two-factor authentication is not enabled"),
+		},
+		{
+			name:   "replayed_code",
+			code:   validCode,
+			totp:   &users.TOTP{UserID: userID, Secret: secret, Enabled: true, LastStep: step},
+			expErr: errors.New("ClientError: invalid two-factor code"),
+		},
+		{
+			name:             "concurrent_replay",
+			code:             validCode,
+			totp:             &enabledTOTP,
+			updateStepResult: &updateStepResult{},
+			expErr:           errors.New("ClientError: invalid two-factor code"),
+		},
+		{
+			name:             "failed_saving_step",
+			code:             validCode,
+			totp:             &enabledTOTP,
+			updateStepResult: &updateStepResult{err: errors.New("internal error")},
+			expErr:           errors.New("LoginTOTP: failed saving code step"),
+		},
+		{
+			name:             "success",
+			code:             validCode,
+			totp:             &enabledTOTP,
+			updateStepResult: &updateStepResult{updated: true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().GetUserByID(ctx, userID).Return(user, nil).Times(1)
+
+			mockRepo.EXPECT().
+				GetLoginThrottle(ctx, users.ThrottleUsername, user.Username).
+				Return(users.LoginThrottle{LockedUntil: test.lockedUntil}, nil).
+				Times(1)
+			if test.lockedUntil.IsZero() {
+				mockRepo.EXPECT().GetLoginThrottle(ctx, users.ThrottleIP, ip).Return(users.LoginThrottle{}, errEmptyRows).Times(1)
+				mockRepo.EXPECT().IsEmptyRows(errEmptyRows).Return(true).Times(1)
+			}
+
+			if test.removeRecoveryCodeResult != nil {
+				mockRepo.EXPECT().
+					RemoveRecoveryCode(ctx, userID, hashRecoveryCode("abcdefghij")).
+					Return(test.removeRecoveryCodeResult.removed, test.removeRecoveryCodeResult.err).
+					Times(1)
+			}
+
+			if test.totp != nil {
+				mockRepo.EXPECT().GetTOTP(ctx, userID).Return(*test.totp, nil).Times(1)
+			}
+
+			if test.updateStepResult != nil {
+				mockRepo.EXPECT().
+					UpdateTOTPStep(ctx, userID, step).
+					Return(test.updateStepResult.updated, test.updateStepResult.err).
+					Times(1)
+			}
+
+			// Invalid codes are counted like incorrect passwords
+			if errors.Is(errInvalidCode, test.expErr) {
+				mockRepo.EXPECT().AddLoginFailure(ctx, users.ThrottleUsername, user.Username, now, resetBefore).Return(int64(1), nil).Times(1)
+				mockRepo.EXPECT().AddLoginFailure(ctx, users.ThrottleIP, ip, now, resetBefore).Return(int64(1), nil).Times(1)
+				mockRepo.EXPECT().RemoveStaleLoginThrottles(ctx, resetBefore, now).Return(nil).Times(1)
+			}
+
+			// Failed logins are forgotten only after the second factor
+			if test.expErr == nil {
+				mockRepo.EXPECT().RemoveLoginThrottle(ctx, users.ThrottleUsername, user.Username).Return(nil).Times(1)
+			}
+
+			err := userUsecase.LoginTOTP(ctx, userID, test.code, test.recoveryCode, ip)
+
+			if got, want := err, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
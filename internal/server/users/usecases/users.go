@@ -2,70 +2,255 @@ package usecases
 
 import (
 	"context"
+	"errors"
+	"sync"
+	"time"
 
 	"passman/internal/server/users"
+	"passman/pkg/password"
+	"passman/pkg/webauthn"
 
 	"github.com/alexedwards/argon2id"
 	"github.com/google/uuid"
 )
 
 var (
-	errUserExist         = newClientError("user already exist")
-	errUserNotFound      = newClientError("user not found")
-	errIncorrectPassword = newClientError("incorrect password")
+	errUserExist          = newClientError("user already exist")
+	errIncorrectPassword  = newClientError("incorrect password")
+	errInvalidCredentials = newClientError("invalid username or password")
+	errUserDisabled       = newForbiddenError("user is disabled")
+	errUsernameTaken      = newConflictError("username is taken")
 )
 
+type Options struct {
+	RelyingParty *webauthn.RelyingParty
+	// RegistrationMode is one of users.Registration* modes, open by default
+	RegistrationMode string
+	// PasswordPolicy is checked for new passwords, existing ones still log in
+	PasswordPolicy password.Policy
+	// Argon2Params of new password hashes, argon2id.DefaultParams by default.
+	// Hashes with weaker parameters are recomputed on the login.
+	Argon2Params *argon2id.Params
+	OIDC         OIDCOptions
+	LDAP         LDAPOptions
+	// DeletionGracePeriod is the time to restore the deleted user, 7 days by
+	// default
+	DeletionGracePeriod time.Duration
+}
+
 type userUsecase struct {
-	dbRepo dbRepo
+	dbRepo           dbRepo
+	rp               *webauthn.RelyingParty
+	registrationMode string
+	passwordPolicy   password.Policy
+	argon2Params     *argon2id.Params
+	oidc             OIDCOptions
+	ldap             LDAPOptions
+	deletionGrace    time.Duration
+	// dummyHash is compared with passwords of unknown users, so the response
+	// time doesn't reveal whether the username exists
+	dummyHash func() string
+	now       func() time.Time
+}
+
+func New(db dbRepo, opts Options) *userUsecase {
+	if len(opts.RegistrationMode) == 0 {
+		opts.RegistrationMode = users.RegistrationOpen
+	}
+	if opts.Argon2Params == nil {
+		opts.Argon2Params = argon2id.DefaultParams
+	}
+	if opts.DeletionGracePeriod == 0 {
+		opts.DeletionGracePeriod = defaultDeletionGracePeriod
+	}
+
+	return &userUsecase{
+		dbRepo:           db,
+		rp:               opts.RelyingParty,
+		registrationMode: opts.RegistrationMode,
+		passwordPolicy:   opts.PasswordPolicy,
+		argon2Params:     opts.Argon2Params,
+		oidc:             opts.OIDC,
+		ldap:             opts.LDAP,
+		deletionGrace:    opts.DeletionGracePeriod,
+		dummyHash: sync.OnceValue(func() string {
+			hash, _ := argon2id.CreateHash("dummy password", opts.Argon2Params)
+			return hash
+		}),
+		now: time.Now,
+	}
 }
 
-func New(db dbRepo) *userUsecase {
-	return &userUsecase{dbRepo: db}
+// RegistrationMode tells the client whether the invite is required
+func (uu *userUsecase) RegistrationMode() string {
+	return uu.registrationMode
 }
 
-func (uu *userUsecase) Registration(ctx context.Context, userCreds users.UserDTO) (uuid.UUID, error) {
+// Registration adds the user if the registration mode allows it, the invite is
+// used only by the invite-only registration. The returned recovery key isn't
+// stored and can't be shown again.
+func (uu *userUsecase) Registration(ctx context.Context, userCreds users.UserDTO, inviteToken string) (uuid.UUID, string, error) {
 	if existedUser, err := uu.dbRepo.GetUser(ctx, userCreds.Username); err != nil && !uu.dbRepo.IsEmptyRows(err) {
-		return uuid.UUID{}, newInternalError("Registration", "failed finding user", err)
+		return uuid.UUID{}, "", newInternalError("Registration", "failed finding user", err)
 	} else if len(existedUser.Username) > 0 {
-		return uuid.UUID{}, errUserExist
+		return uuid.UUID{}, "", errUserExist
+	}
+
+	invite, inviteRequired, err := uu.checkRegistration(ctx, userCreds.Username, inviteToken)
+	if err != nil {
+		return uuid.UUID{}, "", err
 	}
 
-	hash, err := argon2id.CreateHash(userCreds.Password, argon2id.DefaultParams)
+	if err := uu.checkPassword(userCreds.Username, userCreds.Password); err != nil {
+		return uuid.UUID{}, "", err
+	}
+
+	hash, err := argon2id.CreateHash(userCreds.Password, uu.argon2Params)
 	if err != nil {
-		return uuid.UUID{}, newInternalError("Registration", "failed creating password hash", err)
+		return uuid.UUID{}, "", newInternalError("Registration", "failed creating password hash", err)
 	}
 
 	newUser := users.User{
-		ID:       uuid.New(),
-		Username: userCreds.Username,
-		Password: hash,
+		ID:        uuid.New(),
+		Username:  userCreds.Username,
+		Password:  hash,
+		CreatedAt: uu.now(),
+	}
+
+	recoveryKey, key, err := uu.newRecoveryKey(newUser.ID)
+	if err != nil {
+		return uuid.UUID{}, "", newInternalError("Registration", "failed generating recovery key", err)
 	}
 
-	if err := uu.dbRepo.AddUser(ctx, newUser); err != nil {
-		return uuid.UUID{}, newInternalError("Registration", "failed adding user to db", err)
+	err = uu.dbRepo.WithinTx(ctx, func(txCtx context.Context) error {
+		if inviteRequired {
+			used, err := uu.dbRepo.UseInvite(txCtx, invite.ID, newUser.Username, uu.now())
+			if err != nil {
+				return err
+			}
+			// The invite is used by the concurrent registration
+			if !used {
+				return errInvalidInvite
+			}
+		}
+		if err := uu.dbRepo.AddUser(txCtx, newUser); err != nil {
+			return err
+		}
+		return uu.dbRepo.SetRecoveryKey(txCtx, key)
+	})
+	if errors.Is(err, errInvalidInvite) {
+		return uuid.UUID{}, "", errInvalidInvite
+	}
+	if err != nil {
+		return uuid.UUID{}, "", newInternalError("Registration", "failed adding user to db", err)
 	}
 
-	return newUser.ID, nil
+	return newUser.ID, recoveryKey, nil
 }
 
-func (uu *userUsecase) Login(ctx context.Context, userCreds users.UserDTO) (uuid.UUID, error) {
+// Login checks the password of the user. Users with enabled two-factor
+// authentication have to pass LoginTOTP or FinishWebAuthnLogin after it.
+// Failed attempts are throttled by the username and the client ip, unknown
+// users get the same error as the incorrect password.
+func (uu *userUsecase) Login(ctx context.Context, userCreds users.UserDTO, ip string) (users.LoginResult, error) {
+	now := uu.now()
+	if err := uu.checkThrottles(ctx, userCreds.Username, ip, now); err != nil {
+		return users.LoginResult{}, err
+	}
+
 	user, err := uu.dbRepo.GetUser(ctx, userCreds.Username)
+	userExists := err == nil
+	if err != nil && !uu.dbRepo.IsEmptyRows(err) {
+		return users.LoginResult{}, newInternalError("Login", "failed finding user", err)
+	}
+	// Users provisioned by the identity provider or the directory have no password
+	hasPassword := userExists && len(user.Password) > 0
+
+	var params *argon2id.Params
+	if !hasPassword && uu.ldap.Directory != nil {
+		user, err = uu.loginDirectory(ctx, userCreds.Username, userCreds.Password)
+	} else {
+		user, params, err = uu.loginLocal(user, hasPassword, userCreds.Password)
+	}
+	if errors.Is(err, errInvalidCredentials) {
+		if err := uu.addLoginFailure(ctx, userCreds.Username, ip, now); err != nil {
+			return users.LoginResult{}, err
+		}
+		return users.LoginResult{}, errInvalidCredentials
+	}
+	if err != nil {
+		return users.LoginResult{}, err
+	}
+	// The user can restore the account during the grace period
+	if !user.DeleteAt.IsZero() {
+		return users.LoginResult{}, errDeletionScheduled
+	}
+	if user.Disabled {
+		return users.LoginResult{}, errUserDisabled
+	}
+
+	// Directory users have no local hash to recompute
+	if params != nil && password.Weaker(params, uu.argon2Params) {
+		hash, err := argon2id.CreateHash(userCreds.Password, uu.argon2Params)
+		if err != nil {
+			return users.LoginResult{}, newInternalError("Login", "failed creating password hash", err)
+		}
+		if err := uu.dbRepo.UpdatePasswordHash(ctx, user.ID, user.Password, hash); err != nil {
+			return users.LoginResult{}, newInternalError("Login", "failed updating password hash", err)
+		}
+	}
+
+	result, err := uu.loginResult(ctx, "Login", user)
 	if err != nil {
-		if uu.dbRepo.IsEmptyRows(err) {
-			return uuid.UUID{}, errUserNotFound
+		return users.LoginResult{}, err
+	}
+	// The second factor completes the login of users with it
+	if !result.TwoFactorRequired() {
+		if err := uu.completeLogin(ctx, "Login", users.User{Username: userCreds.Username}); err != nil {
+			return users.LoginResult{}, err
 		}
-		return uuid.UUID{}, newInternalError("Login", "failed finding user", err)
 	}
 
-	match, err := argon2id.ComparePasswordAndHash(userCreds.Password, user.Password)
+	return result, nil
+}
+
+// loginResult tells the second factors of the user and the required password
+// reset, they're asked after every primary login
+func (uu *userUsecase) loginResult(ctx context.Context, component string, user users.User) (users.LoginResult, error) {
+	userTOTP, err := uu.dbRepo.GetTOTP(ctx, user.ID)
+	if err != nil && !uu.dbRepo.IsEmptyRows(err) {
+		return users.LoginResult{}, newInternalError(component, "failed getting two-factor settings", err)
+	}
+
+	passkeys, err := uu.dbRepo.GetWebAuthnCredentials(ctx, user.ID)
 	if err != nil {
-		return uuid.UUID{}, newInternalError("Login", "failed comparing password", err)
+		return users.LoginResult{}, newInternalError(component, "failed getting passkeys", err)
 	}
-	if !match {
-		return uuid.UUID{}, errIncorrectPassword
+
+	return users.LoginResult{
+		UserID:                user.ID,
+		TOTP:                  userTOTP.Enabled,
+		WebAuthn:              len(passkeys) > 0,
+		PasswordResetRequired: user.PasswordResetRequired,
+	}, nil
+}
+
+// loginLocal compares the password with the local hash, the dummy hash is
+// compared for users without it
+func (uu *userUsecase) loginLocal(user users.User, hasPassword bool, pass string) (users.User, *argon2id.Params, error) {
+	hash := user.Password
+	if !hasPassword {
+		hash = uu.dummyHash()
 	}
 
-	return user.ID, nil
+	match, params, err := argon2id.CheckHash(pass, hash)
+	if err != nil {
+		return users.User{}, nil, newInternalError("Login", "failed comparing password", err)
+	}
+	if !hasPassword || !match {
+		return users.User{}, nil, errInvalidCredentials
+	}
+	return user, params, nil
 }
 
 func (uu *userUsecase) UpdateUser(ctx context.Context, updatedParameters users.UpdatedUserParams) error {
@@ -74,20 +259,40 @@ func (uu *userUsecase) UpdateUser(ctx context.Context, updatedParameters users.U
 		return newInternalError("UpdateUser", "failed finding user", err)
 	}
 
-	match, err := argon2id.ComparePasswordAndHash(updatedParameters.OldPassword, user.Password)
+	match, err := comparePassword(updatedParameters.OldPassword, user.Password)
 	if err != nil {
 		return newInternalError("UpdateUser", "failed comparing password", err)
 	}
 	if !match {
 		return errIncorrectPassword
 	}
+	if user.PasswordResetRequired && updatedParameters.Password == updatedParameters.OldPassword {
+		return newClientError("new password must differ from the old one")
+	}
 
 	if len(updatedParameters.Username) == 0 {
 		updatedParameters.Username = user.Username
 	}
 
-	if len(updatedParameters.Password) > 0 {
-		hash, err := argon2id.CreateHash(updatedParameters.Password, argon2id.DefaultParams)
+	passwordChanged := len(updatedParameters.Password) > 0
+	if passwordChanged {
+		if err := uu.checkPassword(updatedParameters.Username, updatedParameters.Password); err != nil {
+			return err
+		}
+	}
+
+	if updatedParameters.Username != user.Username {
+		existedUser, err := uu.dbRepo.GetUser(ctx, updatedParameters.Username)
+		if err != nil && !uu.dbRepo.IsEmptyRows(err) {
+			return newInternalError("UpdateUser", "failed finding user", err)
+		}
+		if err == nil && existedUser.ID != user.ID {
+			return errUsernameTaken
+		}
+	}
+
+	if passwordChanged {
+		hash, err := argon2id.CreateHash(updatedParameters.Password, uu.argon2Params)
 		if err != nil {
 			return newInternalError("UpdateUser", "failed creating password hash", err)
 		}
@@ -97,25 +302,106 @@ func (uu *userUsecase) UpdateUser(ctx context.Context, updatedParameters users.U
 	}
 
 	updatedUser := users.User{
-		ID:       updatedParameters.UserID,
-		Username: updatedParameters.Username,
-		Password: updatedParameters.Password,
+		ID:                    updatedParameters.UserID,
+		Username:              updatedParameters.Username,
+		Password:              updatedParameters.Password,
+		PasswordResetRequired: user.PasswordResetRequired && !passwordChanged,
 	}
 
 	if err := uu.dbRepo.UpdateUser(ctx, updatedUser); err != nil {
+		// The username is taken by the concurrent registration or update
+		if uu.dbRepo.IsUniqueViolation(err) {
+			return errUsernameTaken
+		}
 		return newInternalError("UpdateUser", "failed updating user", err)
 	}
 
 	return nil
 }
 
-func (uu *userUsecase) RemoveUser(ctx context.Context, userID uuid.UUID) error {
-	if err := uu.dbRepo.RemoveUser(ctx, userID); err != nil {
-		return newInternalError("DeleteUser", "failed removing user", err)
+// VerifyPassword checks the password of the logged in user before sensitive operations
+func (uu *userUsecase) VerifyPassword(ctx context.Context, userID uuid.UUID, password string) (bool, error) {
+	user, err := uu.dbRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return false, newInternalError("VerifyPassword", "failed finding user", err)
+	}
+
+	if len(user.Password) == 0 && uu.ldap.Directory != nil {
+		return uu.verifyDirectoryPassword(ctx, userID, password)
+	}
+
+	match, err := comparePassword(password, user.Password)
+	if err != nil {
+		return false, newInternalError("VerifyPassword", "failed comparing password", err)
+	}
+
+	return match, nil
+}
+
+// ResetPassword sets the new password of the user without the old one, it's
+// used by the emergency access takeover. Two-factor authentication and passkeys
+// are removed as well, otherwise the new password is useless without the old
+// authenticator. Access tokens of the previous owner are revoked.
+func (uu *userUsecase) ResetPassword(ctx context.Context, userID uuid.UUID, password string) error {
+	user, err := uu.dbRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return newInternalError("ResetPassword", "failed finding user", err)
+	}
+
+	if err := uu.checkPassword(user.Username, password); err != nil {
+		return err
+	}
+
+	hash, err := argon2id.CreateHash(password, uu.argon2Params)
+	if err != nil {
+		return newInternalError("ResetPassword", "failed creating password hash", err)
+	}
+
+	updatedUser := users.User{
+		ID:       userID,
+		Username: user.Username,
+		Password: hash,
+	}
+
+	err = uu.dbRepo.WithinTx(ctx, func(txCtx context.Context) error {
+		if err := uu.dbRepo.UpdateUser(txCtx, updatedUser); err != nil {
+			return err
+		}
+		if err := uu.dbRepo.RemoveTOTP(txCtx, userID); err != nil {
+			return err
+		}
+		if err := uu.dbRepo.RemoveRecoveryCodes(txCtx, userID); err != nil {
+			return err
+		}
+		if err := uu.dbRepo.RemoveWebAuthnCredentials(txCtx, userID); err != nil {
+			return err
+		}
+		return uu.dbRepo.RemoveAccessTokens(txCtx, userID)
+	})
+	if err != nil {
+		return newInternalError("ResetPassword", "failed updating user", err)
+	}
+
+	return nil
+}
+
+// checkPassword applies the password policy to the new password
+func (uu *userUsecase) checkPassword(username, pass string) error {
+	if err := uu.passwordPolicy.Check(username, pass); err != nil {
+		return newWeakPasswordError(err)
 	}
 	return nil
 }
 
+// comparePassword reports whether the password matches the hash. Users
+// provisioned by the identity provider have no password, nothing matches it.
+func comparePassword(password, hash string) (bool, error) {
+	if len(hash) == 0 {
+		return false, nil
+	}
+	return argon2id.ComparePasswordAndHash(password, hash)
+}
+
 func (uu *userUsecase) ParseUserError(err error) (int, string, error) {
 	return parseError(err)
 }
@@ -3,10 +3,13 @@ package usecases
 import (
 	"context"
 	"errors"
+	"slices"
 	"testing"
+	"time"
 
 	"passman/internal/server/users"
 	mock_usecases "passman/internal/server/users/usecases/mock"
+	"passman/pkg/password"
 
 	"github.com/alexedwards/argon2id"
 	"github.com/google/uuid"
@@ -18,7 +21,7 @@ func TestRegistration(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
-	userUsecase := New(mockRepo)
+	userUsecase := New(mockRepo, Options{})
 	ctx := context.Background()
 	errEmptyRows := errors.New("empty rows")
 	userCreds := users.UserDTO{
@@ -87,13 +90,22 @@ func TestRegistration(t *testing.T) {
 			}
 
 			if test.addUserResult != nil {
+				mockRepo.EXPECT().
+					WithinTx(ctx, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+						return fn(ctx)
+					}).
+					Times(1)
 				mockRepo.EXPECT().
 					AddUser(ctx, gomock.AssignableToTypeOf(users.User{})).
 					Return(test.addUserResult.err).
 					Times(1)
+				if test.addUserResult.err == nil {
+					mockRepo.EXPECT().SetRecoveryKey(ctx, gomock.AssignableToTypeOf(users.RecoveryKey{})).Return(nil).Times(1)
+				}
 			}
 
-			_, err := userUsecase.Registration(ctx, userCreds)
+			_, _, err := userUsecase.Registration(ctx, userCreds, "")
 
 			if got, want := err, test.expResult.err; !errors.Is(got, want) {
 				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %d\n\tActual: %d", want, got)
@@ -107,9 +119,13 @@ func TestLogin(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
-	userUsecase := New(mockRepo)
+	userUsecase := New(mockRepo, Options{})
+	now := time.Unix(1700000000, 0)
+	userUsecase.now = func() time.Time { return now }
+
 	ctx := context.Background()
 	errEmptyRows := errors.New("empty rows")
+	ip := "192.0.2.1"
 	userCreds := users.UserDTO{
 		Username: "test_user",
 		Password: "test_password",
@@ -120,22 +136,47 @@ func TestLogin(t *testing.T) {
 		Username: "tetst_user",
 		Password: hashUserPassword,
 	}
+	disabledUser := foundedUser
+	disabledUser.Disabled = true
+	scheduledUser := disabledUser
+	scheduledUser.DeleteAt = time.Unix(1800000000, 0)
+	resetUser := foundedUser
+	resetUser.PasswordResetRequired = true
 
 	type findUserResult struct {
 		existedUser users.User
 		err         error
 	}
 
+	type getTOTPResult struct {
+		totp users.TOTP
+		err  error
+	}
+
+	type getPasskeysResult struct {
+		passkeys []users.WebAuthnCredential
+		err      error
+	}
+
 	type expResult struct {
-		userID uuid.UUID
+		result users.LoginResult
 		err    error
 	}
 
 	tests := []struct {
-		name           string
-		findUserResult *findUserResult
-		expResult      expResult
+		name              string
+		lockedUntil       time.Time
+		findUserResult    *findUserResult
+		failures          int64
+		getTOTPResult     *getTOTPResult
+		getPasskeysResult *getPasskeysResult
+		expResult         expResult
 	}{
+		{
+			name:        "locked",
+			lockedUntil: now.Add(time.Minute),
+			expResult:   expResult{err: errors.New("ClientError: too many login attempts, try again later")},
+		},
 		{
 			name:           "find_user_error",
 			findUserResult: &findUserResult{err: errors.New("internal error")},
@@ -144,7 +185,8 @@ func TestLogin(t *testing.T) {
 		{
 			name:           "user_not_found",
 			findUserResult: &findUserResult{err: errEmptyRows},
-			expResult:      expResult{err: errors.New("ClientError: user not found")},
+			failures:       1,
+			expResult:      expResult{err: errors.New("ClientError: invalid username or password")},
 		},
 		{
 			name:           "password_is_not_a_hash",
@@ -154,17 +196,88 @@ func TestLogin(t *testing.T) {
 		{
 			name:           "incorrect_password",
 			findUserResult: &findUserResult{existedUser: users.User{Password: "$argon2id$v=19$m=65536,t=1,p=4$deAxNTdiK57uVLnhNR+FqA$xltWpE8oWxA9nifflVJOtdXvsVXhgU13oabfsdv/GeY"}},
-			expResult:      expResult{err: errors.New("ClientError: incorrect password")},
+			failures:       1,
+			expResult:      expResult{err: errors.New("ClientError: invalid username or password")},
 		},
 		{
-			name:           "success",
+			name:           "lockout",
+			findUserResult: &findUserResult{existedUser: users.User{Password: "$argon2id$v=19$m=65536,t=1,p=4$deAxNTdiK57uVLnhNR+FqA$xltWpE8oWxA9nifflVJOtdXvsVXhgU13oabfsdv/GeY"}},
+			failures:       usernamePolicy.lockoutFailures,
+			expResult:      expResult{err: errors.New("ClientError: invalid username or password")},
+		},
+		{
+			name:           "disabled",
+			findUserResult: &findUserResult{existedUser: disabledUser},
+			expResult:      expResult{err: errors.New("ClientError: user is disabled")},
+		},
+		{
+			name:           "scheduled_for_deletion",
+			findUserResult: &findUserResult{existedUser: scheduledUser},
+			expResult:      expResult{err: errors.New("ClientError: user is scheduled for deletion")},
+		},
+		{
+			name:              "password_reset_required",
+			findUserResult:    &findUserResult{existedUser: resetUser},
+			getTOTPResult:     &getTOTPResult{err: errEmptyRows},
+			getPasskeysResult: &getPasskeysResult{},
+			expResult:         expResult{result: users.LoginResult{UserID: foundedUser.ID, PasswordResetRequired: true}},
+		},
+		{
+			name:           "failed_getting_totp",
 			findUserResult: &findUserResult{existedUser: foundedUser},
-			expResult:      expResult{userID: foundedUser.ID},
+			getTOTPResult:  &getTOTPResult{err: errors.New("internal error")},
+			expResult:      expResult{err: errors.New("Login: failed getting two-factor settings")},
+		},
+		{
+			name:              "failed_getting_passkeys",
+			findUserResult:    &findUserResult{existedUser: foundedUser},
+			getTOTPResult:     &getTOTPResult{err: errEmptyRows},
+			getPasskeysResult: &getPasskeysResult{err: errors.New("internal error")},
+			expResult:         expResult{err: errors.New("Login: failed getting passkeys")},
+		},
+		{
+			name:              "totp_required",
+			findUserResult:    &findUserResult{existedUser: foundedUser},
+			getTOTPResult:     &getTOTPResult{totp: users.TOTP{UserID: foundedUser.ID, Enabled: true}},
+			getPasskeysResult: &getPasskeysResult{},
+			expResult:         expResult{result: users.LoginResult{UserID: foundedUser.ID, TOTP: true}},
+		},
+		{
+			name:              "passkey_required",
+			findUserResult:    &findUserResult{existedUser: foundedUser},
+			getTOTPResult:     &getTOTPResult{err: errEmptyRows},
+			getPasskeysResult: &getPasskeysResult{passkeys: []users.WebAuthnCredential{{UserID: foundedUser.ID}}},
+			expResult:         expResult{result: users.LoginResult{UserID: foundedUser.ID, WebAuthn: true}},
+		},
+		{
+			name:              "success_after_lock_expired",
+			lockedUntil:       now.Add(-time.Second),
+			findUserResult:    &findUserResult{existedUser: foundedUser},
+			getTOTPResult:     &getTOTPResult{err: errEmptyRows},
+			getPasskeysResult: &getPasskeysResult{},
+			expResult:         expResult{result: users.LoginResult{UserID: foundedUser.ID}},
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().
+				GetLoginThrottle(ctx, users.ThrottleUsername, userCreds.Username).
+				Return(users.LoginThrottle{LockedUntil: test.lockedUntil}, nil).
+				Times(1)
+
+			if test.findUserResult == nil {
+				// The locked login isn't checked further
+				_, err := userUsecase.Login(ctx, userCreds, ip)
+				if got, want := err, test.expResult.err; !errors.Is(got, want) {
+					t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+				}
+				return
+			}
+
+			mockRepo.EXPECT().GetLoginThrottle(ctx, users.ThrottleIP, ip).Return(users.LoginThrottle{}, errEmptyRows).Times(1)
+			mockRepo.EXPECT().IsEmptyRows(errEmptyRows).Return(true).Times(1)
+
 			mockRepo.EXPECT().
 				GetUser(ctx, userCreds.Username).
 				Return(test.findUserResult.existedUser, test.findUserResult.err).
@@ -179,14 +292,154 @@ func TestLogin(t *testing.T) {
 				}
 			}
 
-			userID, err := userUsecase.Login(ctx, userCreds)
+			if test.failures > 0 {
+				resetBefore := now.Add(-failuresWindow)
+				mockRepo.EXPECT().
+					AddLoginFailure(ctx, users.ThrottleUsername, userCreds.Username, now, resetBefore).
+					Return(test.failures, nil).
+					Times(1)
+				mockRepo.EXPECT().AddLoginFailure(ctx, users.ThrottleIP, ip, now, resetBefore).Return(int64(1), nil).Times(1)
+				mockRepo.EXPECT().RemoveStaleLoginThrottles(ctx, resetBefore, now).Return(nil).Times(1)
+
+				if test.failures >= usernamePolicy.lockoutFailures {
+					mockRepo.EXPECT().
+						LockLogin(ctx, users.ThrottleUsername, userCreds.Username, now.Add(usernamePolicy.lockout)).
+						Return(nil).
+						Times(1)
+				}
+			}
+
+			if test.getTOTPResult != nil {
+				mockRepo.EXPECT().
+					GetTOTP(ctx, foundedUser.ID).
+					Return(test.getTOTPResult.totp, test.getTOTPResult.err).
+					Times(1)
+
+				if test.getTOTPResult.err != nil {
+					mockRepo.EXPECT().
+						IsEmptyRows(test.getTOTPResult.err).
+						Return(test.getTOTPResult.err == errEmptyRows).
+						Times(1)
+				}
+			}
+
+			if test.getPasskeysResult != nil {
+				mockRepo.EXPECT().
+					GetWebAuthnCredentials(ctx, foundedUser.ID).
+					Return(test.getPasskeysResult.passkeys, test.getPasskeysResult.err).
+					Times(1)
+			}
+
+			// The lockout is reset by the second factor of users with it
+			if test.expResult.err == nil && !test.expResult.result.TwoFactorRequired() {
+				mockRepo.EXPECT().RemoveLoginThrottle(ctx, users.ThrottleUsername, userCreds.Username).Return(nil).Times(1)
+			}
+
+			result, err := userUsecase.Login(ctx, userCreds, ip)
 
 			if got, want := err, test.expResult.err; !errors.Is(got, want) {
 				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %d\n\tActual: %d", want, got)
 			}
 
-			if got, want := userID, test.expResult.userID; got != want {
-				t.Fatalf("Wrong! Unexpected userID!\n\tExpected: %s\n\tActual: %s", want.String(), got.String())
+			if got, want := result, test.expResult.result; got != want {
+				t.Fatalf("Wrong! Unexpected result!\n\tExpected: %+v\n\tActual: %+v", want, got)
+			}
+		})
+	}
+}
+
+func TestLoginRehash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	current := &argon2id.Params{Memory: 16 * 1024, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	userUsecase := New(mockRepo, Options{Argon2Params: current})
+	now := time.Unix(1700000000, 0)
+	userUsecase.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	errEmptyRows := errors.New("empty rows")
+	ip := "192.0.2.1"
+	userCreds := users.UserDTO{
+		Username: "test_user",
+		Password: "test_password",
+	}
+
+	weakHash, _ := argon2id.CreateHash(userCreds.Password, &argon2id.Params{Memory: 8 * 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	currentHash, _ := argon2id.CreateHash(userCreds.Password, current)
+	// Parallelism of the current host doesn't matter
+	otherHostHash, _ := argon2id.CreateHash(userCreds.Password, &argon2id.Params{Memory: 16 * 1024, Iterations: 2, Parallelism: 2, SaltLength: 16, KeyLength: 32})
+
+	tests := []struct {
+		name      string
+		hash      string
+		rehash    bool
+		rehashErr error
+		expErr    error
+	}{
+		{
+			name:   "weaker_params",
+			hash:   weakHash,
+			rehash: true,
+		},
+		{
+			name:      "failed_rehash",
+			hash:      weakHash,
+			rehash:    true,
+			rehashErr: errors.New("internal error"),
+			expErr:    errors.New("Login: failed updating password hash"),
+		},
+		{
+			name: "current_params",
+			hash: currentHash,
+		},
+		{
+			name: "other_parallelism",
+			hash: otherHostHash,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			user := users.User{ID: uuid.New(), Username: userCreds.Username, Password: test.hash}
+
+			mockRepo.EXPECT().GetLoginThrottle(ctx, users.ThrottleUsername, userCreds.Username).Return(users.LoginThrottle{}, nil).Times(1)
+			mockRepo.EXPECT().GetLoginThrottle(ctx, users.ThrottleIP, ip).Return(users.LoginThrottle{}, nil).Times(1)
+			mockRepo.EXPECT().GetUser(ctx, userCreds.Username).Return(user, nil).Times(1)
+
+			var newHash string
+			if test.rehash {
+				mockRepo.EXPECT().
+					UpdatePasswordHash(ctx, user.ID, test.hash, gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ uuid.UUID, _, hash string) error {
+						newHash = hash
+						return test.rehashErr
+					}).
+					Times(1)
+			}
+			if test.expErr == nil {
+				mockRepo.EXPECT().RemoveLoginThrottle(ctx, users.ThrottleUsername, userCreds.Username).Return(nil).Times(1)
+				mockRepo.EXPECT().GetTOTP(ctx, user.ID).Return(users.TOTP{}, errEmptyRows).Times(1)
+				mockRepo.EXPECT().IsEmptyRows(errEmptyRows).Return(true).Times(1)
+				mockRepo.EXPECT().GetWebAuthnCredentials(ctx, user.ID).Return(nil, nil).Times(1)
+			}
+
+			_, err := userUsecase.Login(ctx, userCreds, ip)
+
+			if got, want := err, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if !test.rehash {
+				return
+			}
+
+			match, params, err := argon2id.CheckHash(userCreds.Password, newHash)
+			if err != nil || !match {
+				t.Fatalf("Wrong! The new hash doesn't match the password: %v", err)
+			}
+			if got, want := *params, *current; got != want {
+				t.Errorf("Wrong! Unexpected params!\n\tExpected: %+v\n\tActual: %+v", want, got)
 			}
 		})
 	}
@@ -197,7 +450,7 @@ func TestUpdateUser(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
-	userUsecase := New(mockRepo)
+	userUsecase := New(mockRepo, Options{})
 
 	ctx := context.Background()
 	userFromDB := users.User{
@@ -219,6 +472,14 @@ func TestUpdateUser(t *testing.T) {
 		},
 	}
 
+	resetUserFromDB := userFromDB
+	resetUserFromDB.PasswordResetRequired = true
+	samePasswordParams := users.UpdatedUserParams{
+		UserID:      uuid.New(),
+		OldPassword: "user_password",
+		UserDTO:     users.UserDTO{Password: "user_password"},
+	}
+
 	type getUserResult struct {
 		user users.User
 		err  error
@@ -228,10 +489,16 @@ func TestUpdateUser(t *testing.T) {
 		err error
 	}
 
+	errEmptyRows := errors.New("empty rows")
+	errUnique := errors.New("unique constraint failed")
+	takenParams := correctParams
+	takenParams.Password = ""
+
 	tests := []struct {
 		name             string
 		input            users.UpdatedUserParams
 		getUserResult    *getUserResult
+		newUsername      *getUserResult
 		updateUserResult *updateUserResult
 		expResult        error
 	}{
@@ -246,10 +513,32 @@ func TestUpdateUser(t *testing.T) {
 			getUserResult: &getUserResult{user: userFromDB},
 			expResult:     errors.New("ClientError: incorrect password"),
 		},
+		{
+			name:          "same_password_after_reset",
+			input:         samePasswordParams,
+			getUserResult: &getUserResult{user: resetUserFromDB},
+			expResult:     errors.New("ClientError: new password must differ from the old one"),
+		},
+		{
+			name:          "username_taken",
+			input:         takenParams,
+			getUserResult: &getUserResult{user: userFromDB},
+			newUsername:   &getUserResult{user: users.User{ID: uuid.New(), Username: "some_user"}},
+			expResult:     errors.New("ClientError: username is taken"),
+		},
+		{
+			name:             "username_taken_concurrently",
+			input:            takenParams,
+			getUserResult:    &getUserResult{user: userFromDB},
+			newUsername:      &getUserResult{err: errEmptyRows},
+			updateUserResult: &updateUserResult{err: errUnique},
+			expResult:        errors.New("ClientError: username is taken"),
+		},
 		{
 			name:             "failed_updating_user",
 			input:            correctParams,
 			getUserResult:    &getUserResult{user: userFromDB},
+			newUsername:      &getUserResult{err: errEmptyRows},
 			updateUserResult: &updateUserResult{err: errors.New("internal error")},
 			expResult:        errors.New("UpdateUser: failed updating user"),
 		},
@@ -257,6 +546,7 @@ func TestUpdateUser(t *testing.T) {
 			name:             "success",
 			input:            correctParams,
 			getUserResult:    &getUserResult{user: userFromDB},
+			newUsername:      &getUserResult{err: errEmptyRows},
 			updateUserResult: &updateUserResult{err: nil},
 			expResult:        nil,
 		},
@@ -269,11 +559,22 @@ func TestUpdateUser(t *testing.T) {
 				Return(test.getUserResult.user, test.getUserResult.err).
 				Times(1)
 
+			mockRepo.EXPECT().IsEmptyRows(errEmptyRows).Return(true).AnyTimes()
+			if test.newUsername != nil {
+				mockRepo.EXPECT().
+					GetUser(ctx, test.input.Username).
+					Return(test.newUsername.user, test.newUsername.err).
+					Times(1)
+			}
+
 			if test.updateUserResult != nil {
 				mockRepo.EXPECT().
 					UpdateUser(ctx, gomock.AssignableToTypeOf(users.User{})).
 					Return(test.updateUserResult.err).
 					Times(1)
+				if test.updateUserResult.err != nil {
+					mockRepo.EXPECT().IsUniqueViolation(test.updateUserResult.err).Return(test.updateUserResult.err == errUnique).Times(1)
+				}
 			}
 
 			actErr := userUsecase.UpdateUser(ctx, test.input)
@@ -284,3 +585,242 @@ func TestUpdateUser(t *testing.T) {
 		})
 	}
 }
+
+func TestVerifyPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	userUsecase := New(mockRepo, Options{})
+
+	ctx := context.Background()
+	userID := uuid.New()
+	userFromDB := users.User{
+		Username: "user",
+		Password: "$argon2id$v=19$m=65536,t=1,p=4$XAPvqtpAVs/NGpyd1H5Fmg$pvbpnBLwlbXfFyuRmochGwJwetm1rv2m1/MmCw7qcPc",
+	}
+
+	tests := []struct {
+		name       string
+		password   string
+		getUserErr error
+		expMatch   bool
+		expErr     error
+	}{
+		{
+			name:       "failed_finding_user",
+			getUserErr: errors.New("internal error"),
+			expErr:     errors.New("VerifyPassword: failed finding user"),
+		},
+		{
+			name:     "incorrect_password",
+			password: "incorrect_password",
+		},
+		{
+			name:     "success",
+			password: "user_password",
+			expMatch: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().
+				GetUserByID(ctx, userID).
+				Return(userFromDB, test.getUserErr).
+				Times(1)
+
+			actMatch, actErr := userUsecase.VerifyPassword(ctx, userID, test.password)
+
+			if got, want := actErr, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+
+			if got, want := actMatch, test.expMatch; got != want {
+				t.Fatalf("Wrong! Unexpected result!\n\tExpected: %t\n\tActual: %t", want, got)
+			}
+		})
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	userUsecase := New(mockRepo, Options{})
+
+	ctx := context.Background()
+	userID := uuid.New()
+
+	tests := []struct {
+		name          string
+		getUserErr    error
+		updateUserErr error
+		updateCalls   int
+		expErr        error
+	}{
+		{
+			name:       "failed_finding_user",
+			getUserErr: errors.New("internal error"),
+			expErr:     errors.New("ResetPassword: failed finding user"),
+		},
+		{
+			name:          "failed_updating_user",
+			updateUserErr: errors.New("internal error"),
+			updateCalls:   1,
+			expErr:        errors.New("ResetPassword: failed updating user"),
+		},
+		{
+			name:        "success",
+			updateCalls: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().
+				GetUserByID(ctx, userID).
+				Return(users.User{ID: userID, Username: "user", Password: "old_hash"}, test.getUserErr).
+				Times(1)
+
+			mockRepo.EXPECT().
+				WithinTx(ctx, gomock.Any()).
+				DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+					return fn(ctx)
+				}).
+				Times(test.updateCalls)
+
+			mockRepo.EXPECT().
+				UpdateUser(ctx, gomock.Cond(func(u users.User) bool {
+					match, err := argon2id.ComparePasswordAndHash("new_password", u.Password)
+					return err == nil && match && u.Username == "user"
+				})).
+				Return(test.updateUserErr).
+				Times(test.updateCalls)
+
+			// Second factors and access tokens are reset with the password
+			if test.updateCalls > 0 && test.updateUserErr == nil {
+				mockRepo.EXPECT().RemoveTOTP(ctx, userID).Return(nil).Times(1)
+				mockRepo.EXPECT().RemoveRecoveryCodes(ctx, userID).Return(nil).Times(1)
+				mockRepo.EXPECT().RemoveWebAuthnCredentials(ctx, userID).Return(nil).Times(1)
+				mockRepo.EXPECT().RemoveAccessTokens(ctx, userID).Return(nil).Times(1)
+			}
+
+			actErr := userUsecase.ResetPassword(ctx, userID, "new_password")
+
+			if got, want := actErr, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	userUsecase := New(mockRepo, Options{
+		PasswordPolicy: password.Policy{MinLength: 10, MinEntropy: 40, CheckCommon: true, CheckUsername: true},
+	})
+
+	ctx := context.Background()
+	errEmptyRows := errors.New("empty rows")
+	userID := uuid.New()
+	userFromDB := users.User{
+		ID:       userID,
+		Username: "user",
+		Password: "$argon2id$v=19$m=65536,t=1,p=4$XAPvqtpAVs/NGpyd1H5Fmg$pvbpnBLwlbXfFyuRmochGwJwetm1rv2m1/MmCw7qcPc",
+	}
+
+	tests := []struct {
+		name          string
+		call          func() error
+		expViolations []string
+	}{
+		{
+			name: "registration_common",
+			call: func() error {
+				mockRepo.EXPECT().GetUser(ctx, "newuser").Return(users.User{}, errEmptyRows).Times(1)
+				mockRepo.EXPECT().IsEmptyRows(errEmptyRows).Return(true).Times(1)
+
+				_, _, err := userUsecase.Registration(ctx, users.UserDTO{Username: "newuser", Password: "password1"}, "")
+				return err
+			},
+			expViolations: []string{"at least 10 characters", "not a common password"},
+		},
+		{
+			name: "update_with_username",
+			call: func() error {
+				mockRepo.EXPECT().GetUserByID(ctx, userID).Return(userFromDB, nil).Times(1)
+
+				return userUsecase.UpdateUser(ctx, users.UpdatedUserParams{
+					UserID:      userID,
+					OldPassword: "user_password",
+					UserDTO:     users.UserDTO{Password: "Long-user-Secret-42"},
+				})
+			},
+			expViolations: []string{"no username in the password"},
+		},
+		{
+			name: "update_with_new_username",
+			call: func() error {
+				mockRepo.EXPECT().GetUserByID(ctx, userID).Return(userFromDB, nil).Times(1)
+
+				return userUsecase.UpdateUser(ctx, users.UpdatedUserParams{
+					UserID:      userID,
+					OldPassword: "user_password",
+					UserDTO:     users.UserDTO{Username: "robert", Password: "Robert-Secret-42"},
+				})
+			},
+			expViolations: []string{"no username in the password"},
+		},
+		{
+			name: "reset_low_entropy",
+			call: func() error {
+				mockRepo.EXPECT().GetUserByID(ctx, userID).Return(userFromDB, nil).Times(1)
+
+				return userUsecase.ResetPassword(ctx, userID, "aaaaaaaaaaaa")
+			},
+			expViolations: []string{"estimated entropy of at least 40 bits"},
+		},
+		{
+			name: "strong_password",
+			call: func() error {
+				mockRepo.EXPECT().GetUserByID(ctx, userID).Return(userFromDB, nil).Times(1)
+				mockRepo.EXPECT().UpdateUser(ctx, gomock.AssignableToTypeOf(users.User{})).Return(nil).Times(1)
+
+				return userUsecase.UpdateUser(ctx, users.UpdatedUserParams{
+					UserID:      userID,
+					OldPassword: "user_password",
+					UserDTO:     users.UserDTO{Password: "Correct-Horse-42"},
+				})
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.call()
+
+			if len(test.expViolations) == 0 {
+				if err != nil {
+					t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", nil, err)
+				}
+				return
+			}
+
+			if got, want := err, errors.New("ClientError: weak password"); !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			var policyErr *password.PolicyError
+			if !errors.As(err, &policyErr) {
+				t.Fatalf("Wrong! The policy error is lost: %v", err)
+			}
+			if got, want := policyErr.Violations, test.expViolations; !slices.Equal(got, want) {
+				t.Errorf("Wrong! Unexpected violations!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+
+	"passman/internal/server/users"
+	"passman/pkg/webauthn"
+
+	"github.com/google/uuid"
+)
+
+var errInvalidPasskey = newClientError("invalid passkey")
+
+// BeginWebAuthnRegistration returns options for navigator.credentials.create().
+// The user id is the user handle, registered passkeys are excluded.
+func (uu *userUsecase) BeginWebAuthnRegistration(ctx context.Context, userID uuid.UUID) (webauthn.CreationOptions, error) {
+	user, err := uu.dbRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return webauthn.CreationOptions{}, newInternalError("BeginWebAuthnRegistration", "failed finding user", err)
+	}
+
+	passkeys, err := uu.dbRepo.GetWebAuthnCredentials(ctx, userID)
+	if err != nil {
+		return webauthn.CreationOptions{}, newInternalError("BeginWebAuthnRegistration", "failed getting passkeys", err)
+	}
+
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return webauthn.CreationOptions{}, newInternalError("BeginWebAuthnRegistration", "failed generating challenge", err)
+	}
+
+	webauthnUser := webauthn.User{ID: userID[:], Name: user.Username, DisplayName: user.Username}
+	return uu.rp.CreationOptions(challenge, webauthnUser, passkeyIDs(passkeys)), nil
+}
+
+func (uu *userUsecase) FinishWebAuthnRegistration(ctx context.Context, userID uuid.UUID, challenge, name string, resp webauthn.RegistrationResponse) error {
+	cred, err := uu.rp.VerifyRegistration(challenge, resp, false)
+	if err != nil {
+		return errInvalidPasskey
+	}
+
+	if _, err := uu.dbRepo.GetWebAuthnCredential(ctx, cred.ID); err == nil {
+		return newClientError("passkey already registered")
+	} else if !uu.dbRepo.IsEmptyRows(err) {
+		return newInternalError("FinishWebAuthnRegistration", "failed checking dublicates", err)
+	}
+
+	passkey := users.WebAuthnCredential{
+		ID:        cred.ID,
+		UserID:    userID,
+		Name:      name,
+		PublicKey: cred.PublicKey,
+		SignCount: cred.SignCount,
+		CreatedAt: uu.now(),
+	}
+	if err := uu.dbRepo.AddWebAuthnCredential(ctx, passkey); err != nil {
+		return newInternalError("FinishWebAuthnRegistration", "failed adding passkey", err)
+	}
+
+	return nil
+}
+
+func (uu *userUsecase) GetWebAuthnCredentials(ctx context.Context, userID uuid.UUID) ([]users.WebAuthnCredential, error) {
+	passkeys, err := uu.dbRepo.GetWebAuthnCredentials(ctx, userID)
+	if err != nil {
+		return nil, newInternalError("GetWebAuthnCredentials", "failed getting passkeys", err)
+	}
+	return passkeys, nil
+}
+
+func (uu *userUsecase) RemoveWebAuthnCredential(ctx context.Context, userID uuid.UUID, id []byte) error {
+	removed, err := uu.dbRepo.RemoveWebAuthnCredential(ctx, userID, id)
+	if err != nil {
+		return newInternalError("RemoveWebAuthnCredential", "failed removing passkey", err)
+	}
+	if !removed {
+		return newClientError("passkey not found")
+	}
+	return nil
+}
+
+// BeginWebAuthnLogin returns options for navigator.credentials.get(). With the
+// user id it's the second factor after the password, without it it's the
+// passwordless login with a discoverable passkey.
+func (uu *userUsecase) BeginWebAuthnLogin(ctx context.Context, userID uuid.UUID) (webauthn.RequestOptions, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return webauthn.RequestOptions{}, newInternalError("BeginWebAuthnLogin", "failed generating challenge", err)
+	}
+
+	if userID == uuid.Nil {
+		return uu.rp.RequestOptions(challenge, nil, webauthn.VerificationRequired), nil
+	}
+
+	passkeys, err := uu.dbRepo.GetWebAuthnCredentials(ctx, userID)
+	if err != nil {
+		return webauthn.RequestOptions{}, newInternalError("BeginWebAuthnLogin", "failed getting passkeys", err)
+	}
+	if len(passkeys) == 0 {
+		return webauthn.RequestOptions{}, newClientError("passkeys are not registered")
+	}
+
+	return uu.rp.RequestOptions(challenge, passkeyIDs(passkeys), webauthn.VerificationPreferred), nil
+}
+
+// FinishWebAuthnLogin checks the assertion and returns the login result of the
+// user. The passwordless login requires user verification, so the passkey
+// serves as both factors and the user is checked like by Login. Failed logins
+// of the username are forgotten after it.
+func (uu *userUsecase) FinishWebAuthnLogin(ctx context.Context, userID uuid.UUID, challenge string, resp webauthn.AssertionResponse) (users.LoginResult, error) {
+	passkeyUserID, err := uu.verifyPasskey(ctx, userID, challenge, resp)
+	if err != nil {
+		return users.LoginResult{}, err
+	}
+
+	user, err := uu.dbRepo.GetUserByID(ctx, passkeyUserID)
+	if err != nil {
+		return users.LoginResult{}, newInternalError("FinishWebAuthnLogin", "failed finding user", err)
+	}
+	// The user can restore the account during the grace period
+	if !user.DeleteAt.IsZero() {
+		return users.LoginResult{}, errDeletionScheduled
+	}
+	if user.Disabled {
+		return users.LoginResult{}, errUserDisabled
+	}
+
+	if err := uu.completeLogin(ctx, "FinishWebAuthnLogin", user); err != nil {
+		return users.LoginResult{}, err
+	}
+
+	return users.LoginResult{UserID: user.ID, PasswordResetRequired: user.PasswordResetRequired}, nil
+}
+
+// verifyPasskey checks the assertion of the passkey of the user or, if the
+// user is unknown, of the passkey with the user verification and returns the
+// owner of the passkey
+func (uu *userUsecase) verifyPasskey(ctx context.Context, userID uuid.UUID, challenge string, resp webauthn.AssertionResponse) (uuid.UUID, error) {
+	id, err := resp.CredentialID()
+	if err != nil {
+		return uuid.Nil, errInvalidPasskey
+	}
+
+	passkey, err := uu.dbRepo.GetWebAuthnCredential(ctx, id)
+	if err != nil {
+		if uu.dbRepo.IsEmptyRows(err) {
+			return uuid.Nil, errInvalidPasskey
+		}
+		return uuid.Nil, newInternalError("FinishWebAuthnLogin", "failed getting passkey", err)
+	}
+
+	passwordless := userID == uuid.Nil
+	if passwordless {
+		userHandle, err := resp.UserHandle()
+		if err != nil || !bytes.Equal(userHandle, passkey.UserID[:]) {
+			return uuid.Nil, errInvalidPasskey
+		}
+	} else if passkey.UserID != userID {
+		return uuid.Nil, errInvalidPasskey
+	}
+
+	cred := webauthn.Credential{ID: passkey.ID, PublicKey: passkey.PublicKey, SignCount: passkey.SignCount}
+	signCount, err := uu.rp.VerifyAssertion(challenge, cred, resp, passwordless)
+	if err != nil {
+		return uuid.Nil, errInvalidPasskey
+	}
+
+	if err := uu.dbRepo.UpdateWebAuthnSignCount(ctx, passkey.ID, signCount, uu.now()); err != nil {
+		return uuid.Nil, newInternalError("FinishWebAuthnLogin", "failed updating passkey", err)
+	}
+
+	return passkey.UserID, nil
+}
+
+func passkeyIDs(passkeys []users.WebAuthnCredential) [][]byte {
+	ids := make([][]byte, 0, len(passkeys))
+	for _, passkey := range passkeys {
+		ids = append(ids, passkey.ID)
+	}
+	return ids
+}
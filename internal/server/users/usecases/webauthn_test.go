@@ -0,0 +1,233 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"passman/internal/server/users"
+	mock_usecases "passman/internal/server/users/usecases/mock"
+	"passman/pkg/webauthn"
+	"passman/pkg/webauthn/webauthntest"
+
+	"github.com/google/uuid"
+	"go.uber.org/mock/gomock"
+)
+
+const testOrigin = "http://localhost:5000"
+
+var testRelyingParty = webauthn.New("localhost", "passman", testOrigin)
+
+func TestFinishWebAuthnRegistration(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	userUsecase := New(mockRepo, Options{RelyingParty: testRelyingParty})
+	now := time.Unix(1700000000, 0)
+	userUsecase.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	errEmptyRows := errors.New("empty rows")
+	userID := uuid.New()
+
+	tests := []struct {
+		name          string
+		origin        string
+		getPasskeyErr error
+		addCalls      int
+		expErr        error
+	}{
+		{
+			name:   "wrong_origin",
+			origin: "http://phishing.localhost",
+			expErr: errors.New("ClientError: invalid passkey"),
+		},
+		{
+			name:   "already_registered",
+			origin: testOrigin,
+			expErr: errors.New("ClientError: passkey already registered"),
+		},
+		{
+			name:          "success",
+			origin:        testOrigin,
+			getPasskeyErr: errEmptyRows,
+			addCalls:      1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().GetUserByID(ctx, userID).Return(users.User{ID: userID, Username: "user1"}, nil).Times(1)
+			mockRepo.EXPECT().GetWebAuthnCredentials(ctx, userID).Return(nil, nil).Times(1)
+
+			opts, err := userUsecase.BeginWebAuthnRegistration(ctx, userID)
+			if err != nil {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: nil\n\tActual: %v", err)
+			}
+
+			authenticator := webauthntest.NewAuthenticator(test.origin)
+			resp, err := authenticator.Register(opts)
+			if err != nil {
+				t.Fatalf("Wrong! Unexpected authenticator error!\n\tExpected: nil\n\tActual: %v", err)
+			}
+
+			if test.origin == testOrigin {
+				mockRepo.EXPECT().
+					GetWebAuthnCredential(ctx, authenticator.CredentialID()).
+					Return(users.WebAuthnCredential{}, test.getPasskeyErr).
+					Times(1)
+			}
+			if test.getPasskeyErr != nil {
+				mockRepo.EXPECT().IsEmptyRows(test.getPasskeyErr).Return(true).Times(1)
+			}
+
+			mockRepo.EXPECT().
+				AddWebAuthnCredential(ctx, gomock.Cond(func(p users.WebAuthnCredential) bool {
+					return p.UserID == userID && p.Name == "laptop" && p.CreatedAt.Equal(now)
+				})).
+				Return(nil).
+				Times(test.addCalls)
+
+			actErr := userUsecase.FinishWebAuthnRegistration(ctx, userID, opts.Challenge, "laptop", resp)
+
+			if got, want := actErr, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestFinishWebAuthnLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_usecases.NewMockdbRepo(ctrl)
+	userUsecase := New(mockRepo, Options{RelyingParty: testRelyingParty})
+	now := time.Unix(1700000000, 0)
+	userUsecase.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	errEmptyRows := errors.New("empty rows")
+	userID := uuid.New()
+
+	// Every case has its own authenticator registered for the user
+	register := func(uv bool) (*webauthntest.Authenticator, users.WebAuthnCredential) {
+		authenticator := webauthntest.NewAuthenticator(testOrigin)
+		challenge, _ := webauthn.NewChallenge()
+		resp, err := authenticator.Register(testRelyingParty.CreationOptions(challenge, webauthn.User{ID: userID[:], Name: "user1"}, nil))
+		if err != nil {
+			t.Fatalf("Wrong! Unexpected authenticator error!\n\tExpected: nil\n\tActual: %v", err)
+		}
+		cred, err := testRelyingParty.VerifyRegistration(challenge, resp, false)
+		if err != nil {
+			t.Fatalf("Wrong! Unexpected error!\n\tExpected: nil\n\tActual: %v", err)
+		}
+		authenticator.UserVerified = uv
+
+		return authenticator, users.WebAuthnCredential{ID: cred.ID, UserID: userID, PublicKey: cred.PublicKey}
+	}
+
+	tests := []struct {
+		name          string
+		pendingUserID uuid.UUID
+		uv            bool
+		getPasskeyErr error
+		updateCalls   int
+		// user is set if the passkey is verified
+		user      *users.User
+		expResult users.LoginResult
+		expErr    error
+	}{
+		{
+			name:          "unknown_passkey",
+			pendingUserID: userID,
+			getPasskeyErr: errEmptyRows,
+			expErr:        errors.New("ClientError: invalid passkey"),
+		},
+		{
+			name:          "passkey_of_another_user",
+			pendingUserID: uuid.New(),
+			expErr:        errors.New("ClientError: invalid passkey"),
+		},
+		{
+			name:   "passwordless_without_verification",
+			expErr: errors.New("ClientError: invalid passkey"),
+		},
+		{
+			name:          "second_factor",
+			pendingUserID: userID,
+			updateCalls:   1,
+			user:          &users.User{ID: userID, Username: "user1"},
+			expResult:     users.LoginResult{UserID: userID},
+		},
+		{
+			name:        "passwordless",
+			uv:          true,
+			updateCalls: 1,
+			user:        &users.User{ID: userID, Username: "user1"},
+			expResult:   users.LoginResult{UserID: userID},
+		},
+		{
+			name:        "passwordless_disabled_user",
+			uv:          true,
+			updateCalls: 1,
+			user:        &users.User{ID: userID, Username: "user1", Disabled: true},
+			expErr:      errors.New("ClientError: user is disabled"),
+		},
+		{
+			name:        "passwordless_deletion_scheduled",
+			uv:          true,
+			updateCalls: 1,
+			user:        &users.User{ID: userID, Username: "user1", DeleteAt: now.Add(time.Hour)},
+			expErr:      errors.New("ClientError: user is scheduled for deletion"),
+		},
+		{
+			name:        "passwordless_password_reset_required",
+			uv:          true,
+			updateCalls: 1,
+			user:        &users.User{ID: userID, Username: "user1", PasswordResetRequired: true},
+			expResult:   users.LoginResult{UserID: userID, PasswordResetRequired: true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			authenticator, passkey := register(test.uv)
+
+			challenge, _ := webauthn.NewChallenge()
+			resp, err := authenticator.Login(testRelyingParty.RequestOptions(challenge, nil, webauthn.VerificationPreferred))
+			if err != nil {
+				t.Fatalf("Wrong! Unexpected authenticator error!\n\tExpected: nil\n\tActual: %v", err)
+			}
+
+			mockRepo.EXPECT().GetWebAuthnCredential(ctx, passkey.ID).Return(passkey, test.getPasskeyErr).Times(1)
+			if test.getPasskeyErr != nil {
+				mockRepo.EXPECT().IsEmptyRows(test.getPasskeyErr).Return(true).Times(1)
+			}
+
+			mockRepo.EXPECT().
+				UpdateWebAuthnSignCount(ctx, passkey.ID, authenticator.SignCount, now).
+				Return(nil).
+				Times(test.updateCalls)
+
+			if test.user != nil {
+				mockRepo.EXPECT().GetUserByID(ctx, userID).Return(*test.user, nil).Times(1)
+			}
+			// Disabled users keep failed logins
+			if test.expErr == nil {
+				mockRepo.EXPECT().RemoveLoginThrottle(ctx, users.ThrottleUsername, test.user.Username).Return(nil).Times(1)
+			}
+
+			actResult, actErr := userUsecase.FinishWebAuthnLogin(ctx, test.pendingUserID, challenge, resp)
+
+			if got, want := actErr, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if got, want := actResult, test.expResult; got != want {
+				t.Errorf("Wrong! Unexpected result!\n\tExpected: %+v\n\tActual: %+v", want, got)
+			}
+		})
+	}
+}
@@ -1,11 +1,54 @@
 package users
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"passman/pkg/webauthn"
+
+	"github.com/google/uuid"
+)
 
 type User struct {
 	ID       uuid.UUID
 	Username string
 	Password string
+	IsAdmin  bool
+	// Disabled users can't log in and use access tokens
+	Disabled              bool
+	PasswordResetRequired bool
+	Profile               Profile
+	// CreatedAt is zero for users registered before it was tracked,
+	// LastLoginAt is zero until the first login
+	CreatedAt   time.Time
+	LastLoginAt time.Time
+	// DeleteAt is set when the user asks for the deletion, the user can't log
+	// in until it and is removed after it. Disabled is left to the admin.
+	DeleteAt time.Time
+}
+
+// Profile is the optional personal information of the user, empty fields
+// aren't set
+type Profile struct {
+	DisplayName string
+	Email       string
+	// Locale is the BCP 47 language tag, e.g. en-US
+	Locale string
+	// Timezone is the IANA time zone name, e.g. Europe/Berlin
+	Timezone string
+}
+
+// UserSummary is the user shown to admins with the size of stored data
+type UserSummary struct {
+	ID                    uuid.UUID
+	Username              string
+	IsAdmin               bool
+	Disabled              bool
+	PasswordResetRequired bool
+	DeleteAt              time.Time
+	Accounts              int64
+	Sends                 int64
+	// StorageBytes is the size of encrypted accounts and sends
+	StorageBytes int64
 }
 
 type UserDTO struct {
@@ -18,3 +61,189 @@ type UpdatedUserParams struct {
 	OldPassword string
 	UserDTO
 }
+
+// LoginResult of the correct password. If the user has second factors, the
+// session is authenticated only after one of them.
+type LoginResult struct {
+	UserID   uuid.UUID
+	TOTP     bool
+	WebAuthn bool
+	// PasswordResetRequired limits the session to the password change
+	PasswordResetRequired bool
+}
+
+func (lr LoginResult) TwoFactorRequired() bool {
+	return lr.TOTP || lr.WebAuthn
+}
+
+type TOTP struct {
+	UserID uuid.UUID
+	Secret string
+	// Enabled is false until the user confirms the secret with the first code
+	Enabled  bool
+	LastStep int64
+}
+
+type TOTPSetup struct {
+	Secret string
+	// URI is the otpauth:// provisioning URI for QR code
+	URI string
+}
+
+type TwoFactorStatus struct {
+	Enabled           bool
+	RecoveryCodesLeft int64
+}
+
+// WebAuthnCredential is the passkey of the user
+type WebAuthnCredential struct {
+	ID         []byte
+	UserID     uuid.UUID
+	Name       string
+	PublicKey  []byte
+	SignCount  uint32
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+}
+
+// Kinds of the login throttles
+const (
+	ThrottleUsername = "username"
+	ThrottleIP       = "ip"
+	// ThrottleSend counts wrong passwords of the send, subject is its id
+	ThrottleSend = "send"
+)
+
+// LoginThrottle tracks failed logins of the username or the client ip
+type LoginThrottle struct {
+	Kind          string
+	Subject       string
+	Failures      int64
+	LockedUntil   time.Time
+	LastFailureAt time.Time
+}
+
+// Scopes of the access tokens
+const (
+	ScopeAccountsRead  = "accounts:read"
+	ScopeAccountsWrite = "accounts:write"
+	// ScopeAccountsReveal lets tokens read passwords, sessions confirm the
+	// password instead
+	ScopeAccountsReveal = "accounts:reveal"
+	ScopeServices       = "services:manage"
+)
+
+// AccessToken is the personal token for scripts and automation. Only the hash
+// of the token is stored, the token itself is shown once after creation.
+type AccessToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+	Hash      []byte
+	Scopes    []string
+	CreatedAt time.Time
+	// ExpiresAt is zero for tokens without expiry
+	ExpiresAt  time.Time
+	LastUsedAt time.Time
+}
+
+func (at AccessToken) Expired(now time.Time) bool {
+	return !at.ExpiresAt.IsZero() && !now.Before(at.ExpiresAt)
+}
+
+// Registration modes, new users of the closed instance are added only by
+// invites
+const (
+	RegistrationOpen   = "open"
+	RegistrationInvite = "invite"
+	RegistrationClosed = "closed"
+)
+
+// Invite allows one registration on the instance with invite-only registration.
+// Only the hash of the invite token is stored.
+type Invite struct {
+	ID   uuid.UUID
+	Hash []byte
+	// Username is empty if the invite isn't bound to a username
+	Username  string
+	CreatedBy uuid.UUID
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	// UsedAt is zero until the registration, UsedBy is the registered username
+	UsedAt time.Time
+	UsedBy string
+}
+
+func (i Invite) Expired(now time.Time) bool {
+	return !now.Before(i.ExpiresAt)
+}
+
+func (i Invite) Used() bool {
+	return !i.UsedAt.IsZero()
+}
+
+// Identity links the user of the OpenID Connect provider to the local user
+type Identity struct {
+	Issuer    string
+	Subject   string
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+// OIDCClaims are claims of the verified ID token mapped by the configuration
+type OIDCClaims struct {
+	Issuer   string
+	Subject  string
+	Username string
+	// Roles are values of the configured roles claim
+	Roles []string
+}
+
+// RecoveryKey resets the forgotten password of the user. Only the hash of the
+// key is stored, the key itself is shown once.
+type RecoveryKey struct {
+	UserID    uuid.UUID
+	Hash      []byte
+	CreatedAt time.Time
+}
+
+// AccountRecovery is the password reset by the recovery key
+type AccountRecovery struct {
+	Username    string
+	RecoveryKey string
+	Password    string
+}
+
+// DeletionConfirmation proves that the owner asks for the deletion: the
+// password and one of second factors if the user has them
+type DeletionConfirmation struct {
+	Password     string
+	Code         string
+	RecoveryCode string
+	// Passkey answers PasskeyChallenge of BeginWebAuthnLogin
+	PasskeyChallenge string
+	Passkey          *webauthn.AssertionResponse
+}
+
+// Actions of the audit log
+const (
+	AuditPasswordResetRequired = "password_reset_required"
+	AuditTemporaryPassword     = "temporary_password_set"
+	AuditAccountRecovered      = "account_recovered"
+	AuditDeletionScheduled     = "deletion_scheduled"
+	AuditDeletionCanceled      = "deletion_canceled"
+	AuditUserPurged            = "user_purged"
+	AuditUserRemoved           = "user_removed"
+)
+
+// AuditEvent records the security-sensitive action. Usernames are copied, so
+// events stay readable after users are removed.
+type AuditEvent struct {
+	ID             uuid.UUID
+	Action         string
+	ActorID        uuid.UUID
+	ActorUsername  string
+	TargetID       uuid.UUID
+	TargetUsername string
+	CreatedAt      time.Time
+}
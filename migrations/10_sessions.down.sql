@@ -0,0 +1,3 @@
+drop index sessions_expiry;
+
+drop table sessions;
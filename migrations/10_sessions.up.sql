@@ -0,0 +1,8 @@
+-- Store of the session manager, data is encoded by it
+create table sessions (
+  token text primary key,
+  data blob not null,
+  expiry integer not null
+);
+
+create index sessions_expiry on sessions(expiry);
@@ -0,0 +1,5 @@
+drop trigger accounts_user_delete;
+
+alter table users drop column password_reset_required;
+alter table users drop column disabled;
+alter table users drop column is_admin;
@@ -0,0 +1,16 @@
+alter table users add column is_admin boolean not null default false;
+alter table users add column disabled boolean not null default false;
+-- The user has to change the password after the next login
+alter table users add column password_reset_required boolean not null default false;
+
+-- The first registered user of the upgraded installation becomes the admin,
+-- later the first user is made the admin by the registration
+update users set is_admin = true where rowid = (select min(rowid) from users);
+
+-- Foreign keys are not enforced by the connection, so accounts of the removed
+-- user are cleaned up by the trigger. Shares of the accounts are removed by
+-- account_shares_account_delete.
+create trigger accounts_user_delete after delete on users
+begin
+  delete from accounts where user_id = old.id;
+end;
@@ -0,0 +1,3 @@
+drop trigger invites_user_delete;
+
+drop table invites;
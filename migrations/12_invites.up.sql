@@ -0,0 +1,19 @@
+-- Invites of the invite-only registration, only SHA-256 of the token is stored
+create table invites (
+  id uuid primary key,
+  token_hash blob not null unique,
+  -- Empty username lets the invite register any username
+  username text not null default '',
+  created_by uuid not null,
+  created_at integer not null,
+  expires_at integer not null,
+  -- 0 until the invite is used, used_by is the registered username
+  used_at integer not null default 0,
+  used_by text not null default '',
+  foreign key (created_by) references users(id) on delete cascade
+);
+
+create trigger invites_user_delete after delete on users
+begin
+  delete from invites where created_by = old.id;
+end;
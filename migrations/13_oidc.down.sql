@@ -0,0 +1,3 @@
+drop trigger user_identities_user_delete;
+
+drop table user_identities;
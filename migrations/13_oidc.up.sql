@@ -0,0 +1,17 @@
+-- Identities of the OpenID Connect provider linked to users. Users provisioned
+-- by the provider have the empty password, they log in only by the provider.
+create table user_identities (
+  issuer text not null,
+  subject text not null,
+  user_id uuid not null,
+  created_at integer not null,
+  primary key (issuer, subject),
+  -- One identity of the provider per user
+  unique (user_id, issuer),
+  foreign key (user_id) references users(id) on delete cascade
+);
+
+create trigger user_identities_user_delete after delete on users
+begin
+  delete from user_identities where user_id = old.id;
+end;
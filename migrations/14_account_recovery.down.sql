@@ -0,0 +1,7 @@
+drop index audit_events_created_at;
+
+drop table audit_events;
+
+drop trigger user_recovery_keys_user_delete;
+
+drop table user_recovery_keys;
@@ -0,0 +1,25 @@
+-- Recovery keys reset forgotten passwords, only SHA-256 of the key is stored
+create table user_recovery_keys (
+  user_id uuid primary key,
+  key_hash blob not null,
+  created_at integer not null,
+  foreign key (user_id) references users(id) on delete cascade
+);
+
+create trigger user_recovery_keys_user_delete after delete on users
+begin
+  delete from user_recovery_keys where user_id = old.id;
+end;
+
+-- Security-sensitive actions. Events outlive users, so usernames are copied.
+create table audit_events (
+  id uuid primary key,
+  action text not null,
+  actor_id uuid not null,
+  actor_username text not null,
+  target_id uuid not null,
+  target_username text not null,
+  created_at integer not null
+);
+
+create index audit_events_created_at on audit_events (created_at);
@@ -0,0 +1,6 @@
+alter table users drop column last_login_at;
+alter table users drop column created_at;
+alter table users drop column timezone;
+alter table users drop column locale;
+alter table users drop column email;
+alter table users drop column display_name;
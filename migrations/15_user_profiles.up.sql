@@ -0,0 +1,7 @@
+alter table users add column display_name text not null default '';
+alter table users add column email text not null default '';
+alter table users add column locale text not null default '';
+alter table users add column timezone text not null default '';
+-- Zero for users registered before the column was added
+alter table users add column created_at integer not null default 0;
+alter table users add column last_login_at integer not null default 0;
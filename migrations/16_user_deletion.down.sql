@@ -0,0 +1,3 @@
+drop index users_delete_at;
+
+alter table users drop column delete_at;
@@ -0,0 +1,6 @@
+-- The user who asked for the deletion can't log in until delete_at and is
+-- removed after it, disabled is left to the admin. Zero if the deletion isn't
+-- scheduled.
+alter table users add column delete_at integer not null default 0;
+
+create index users_delete_at on users (delete_at) where delete_at > 0;
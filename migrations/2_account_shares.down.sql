@@ -0,0 +1,5 @@
+drop trigger account_shares_grantee_delete;
+
+drop trigger account_shares_account_delete;
+
+drop table account_shares;
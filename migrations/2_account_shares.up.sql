@@ -0,0 +1,19 @@
+create table account_shares (
+  account_id uuid not null,
+  grantee_id uuid not null,
+  permission text not null check (permission in ('read', 'write')),
+  primary key (account_id, grantee_id),
+  foreign key (account_id) references accounts(id) on delete cascade,
+  foreign key (grantee_id) references users(id) on delete cascade
+);
+
+-- Foreign keys are not enforced by the connection, so shares are cleaned up by triggers
+create trigger account_shares_account_delete after delete on accounts
+begin
+  delete from account_shares where account_id = old.id;
+end;
+
+create trigger account_shares_grantee_delete after delete on users
+begin
+  delete from account_shares where grantee_id = old.id;
+end;
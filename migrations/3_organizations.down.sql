@@ -0,0 +1,39 @@
+drop trigger organization_members_user_delete;
+
+drop trigger collections_delete;
+
+drop trigger organizations_delete;
+
+create table user_accounts (
+  id uuid primary key,
+  user_id uuid not null,
+  service_id uuid not null,
+  name text not null,
+  secret integer not null,
+  payload text not null,
+  foreign key (user_id) references users(id) on delete cascade,
+  foreign key (service_id) references services(id) on delete set null
+);
+
+-- Accounts of collections are removed with the collections
+insert into user_accounts (id, user_id, service_id, name, secret, payload)
+  select id, user_id, service_id, name, secret, payload from accounts where user_id is not null;
+
+delete from account_shares where account_id in (select id from accounts where user_id is null);
+
+drop table accounts;
+
+alter table user_accounts rename to accounts;
+
+create trigger account_shares_account_delete after delete on accounts
+begin
+  delete from account_shares where account_id = old.id;
+end;
+
+drop table collections;
+
+drop table organization_invites;
+
+drop table organization_members;
+
+drop table organizations;
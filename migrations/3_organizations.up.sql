@@ -0,0 +1,80 @@
+create table organizations (
+  id uuid primary key,
+  name text unique not null
+);
+
+create table organization_members (
+  organization_id uuid not null,
+  user_id uuid not null,
+  role text not null check (role in ('owner', 'admin', 'member', 'readonly')),
+  primary key (organization_id, user_id),
+  foreign key (organization_id) references organizations(id) on delete cascade,
+  foreign key (user_id) references users(id) on delete cascade
+);
+
+create table organization_invites (
+  organization_id uuid not null,
+  user_id uuid not null,
+  role text not null check (role in ('owner', 'admin', 'member', 'readonly')),
+  primary key (organization_id, user_id),
+  foreign key (organization_id) references organizations(id) on delete cascade,
+  foreign key (user_id) references users(id) on delete cascade
+);
+
+create table collections (
+  id uuid primary key,
+  organization_id uuid not null,
+  name text not null,
+  unique (organization_id, name),
+  foreign key (organization_id) references organizations(id) on delete cascade
+);
+
+-- Accounts of a collection have collection_id and no user, so user_id is made
+-- nullable by rebuilding the table
+create table collection_accounts (
+  id uuid primary key,
+  user_id uuid,
+  collection_id uuid,
+  service_id uuid not null,
+  name text not null,
+  secret integer not null,
+  payload text not null,
+  check ((user_id is null) <> (collection_id is null)),
+  foreign key (user_id) references users(id) on delete cascade,
+  foreign key (collection_id) references collections(id) on delete cascade,
+  foreign key (service_id) references services(id) on delete set null
+);
+
+insert into collection_accounts (id, user_id, service_id, name, secret, payload)
+  select id, user_id, service_id, name, secret, payload from accounts;
+
+drop table accounts;
+
+alter table collection_accounts rename to accounts;
+
+create index accounts_collection_id on accounts (collection_id) where collection_id is not null;
+
+-- Triggers are dropped with the table, so they are created again
+create trigger account_shares_account_delete after delete on accounts
+begin
+  delete from account_shares where account_id = old.id;
+end;
+
+-- Foreign keys are not enforced by the connection, so rows are cleaned up by triggers
+create trigger organizations_delete after delete on organizations
+begin
+  delete from organization_members where organization_id = old.id;
+  delete from organization_invites where organization_id = old.id;
+  delete from collections where organization_id = old.id;
+end;
+
+create trigger collections_delete after delete on collections
+begin
+  delete from accounts where collection_id = old.id;
+end;
+
+create trigger organization_members_user_delete after delete on users
+begin
+  delete from organization_members where user_id = old.id;
+  delete from organization_invites where user_id = old.id;
+end;
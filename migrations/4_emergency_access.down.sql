@@ -0,0 +1,5 @@
+drop trigger emergency_access_user_delete;
+
+drop table emergency_access_events;
+
+drop table emergency_access;
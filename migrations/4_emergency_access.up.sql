@@ -0,0 +1,30 @@
+create table emergency_access (
+  id uuid primary key,
+  grantor_id uuid not null,
+  grantee_id uuid not null,
+  type text not null check (type in ('view', 'takeover')),
+  wait_days integer not null,
+  status text not null check (status in ('invited', 'accepted', 'recovery_initiated', 'recovery_approved')),
+  -- Unix time of the recovery request, zero when there is no request
+  recovery_initiated_at integer not null default 0,
+  unique (grantor_id, grantee_id),
+  foreign key (grantor_id) references users(id) on delete cascade,
+  foreign key (grantee_id) references users(id) on delete cascade
+);
+
+-- History of the state transitions, it outlives the removed emergency access
+create table emergency_access_events (
+  id integer primary key autoincrement,
+  grantor_id uuid not null,
+  grantee_id uuid not null,
+  status text not null,
+  created_at integer not null,
+  foreign key (grantor_id) references users(id) on delete cascade,
+  foreign key (grantee_id) references users(id) on delete cascade
+);
+
+create trigger emergency_access_user_delete after delete on users
+begin
+  delete from emergency_access where grantor_id = old.id or grantee_id = old.id;
+  delete from emergency_access_events where grantor_id = old.id or grantee_id = old.id;
+end;
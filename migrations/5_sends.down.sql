@@ -0,0 +1,3 @@
+drop trigger sends_user_delete;
+
+drop table sends;
@@ -0,0 +1,22 @@
+-- Only the ciphertext is stored, the key is given to the creator in the URL fragment
+create table sends (
+  id uuid primary key,
+  user_id uuid not null,
+  type text not null check (type in ('text', 'file')),
+  file_name text not null default '',
+  nonce blob not null,
+  data blob not null,
+  -- Argon2id hash of the optional password
+  password text not null default '',
+  max_views integer not null,
+  views integer not null default 0,
+  -- Unix time
+  expires_at integer not null,
+  created_at integer not null,
+  foreign key (user_id) references users(id) on delete cascade
+);
+
+create trigger sends_user_delete after delete on users
+begin
+  delete from sends where user_id = old.id;
+end;
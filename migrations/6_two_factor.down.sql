@@ -0,0 +1,5 @@
+drop trigger two_factor_user_delete;
+
+drop table user_recovery_codes;
+
+drop table user_totp;
@@ -0,0 +1,23 @@
+-- The secret is pending until the user confirms it with the first code
+create table user_totp (
+  user_id uuid primary key,
+  secret text not null,
+  enabled boolean not null default false,
+  -- Last accepted time step, codes can't be used twice
+  last_step integer not null default 0,
+  foreign key (user_id) references users(id) on delete cascade
+);
+
+-- One-time recovery codes are stored as SHA-256 hashes
+create table user_recovery_codes (
+  user_id uuid not null,
+  code_hash text not null,
+  primary key (user_id, code_hash),
+  foreign key (user_id) references users(id) on delete cascade
+);
+
+create trigger two_factor_user_delete after delete on users
+begin
+  delete from user_totp where user_id = old.id;
+  delete from user_recovery_codes where user_id = old.id;
+end;
@@ -0,0 +1,5 @@
+drop trigger webauthn_user_delete;
+
+drop index webauthn_credentials_user_id;
+
+drop table webauthn_credentials;
@@ -0,0 +1,19 @@
+-- The id is the raw credential id chosen by the authenticator
+create table webauthn_credentials (
+  id blob primary key,
+  user_id uuid not null,
+  name text not null,
+  -- COSE_Key of the credential
+  public_key blob not null,
+  sign_count integer not null default 0,
+  created_at integer not null,
+  last_used_at integer not null default 0,
+  foreign key (user_id) references users(id) on delete cascade
+);
+
+create index webauthn_credentials_user_id on webauthn_credentials(user_id);
+
+create trigger webauthn_user_delete after delete on users
+begin
+  delete from webauthn_credentials where user_id = old.id;
+end;
@@ -0,0 +1,3 @@
+drop index login_throttles_last_failure_at;
+
+drop table login_throttles;
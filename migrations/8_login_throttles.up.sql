@@ -0,0 +1,12 @@
+-- Failed logins of the username or the client ip and wrong passwords of the
+-- send, subject is the value of kind
+create table login_throttles (
+  kind text not null check (kind in ('username', 'ip', 'send')),
+  subject text not null,
+  failures integer not null default 0,
+  locked_until integer not null default 0,
+  last_failure_at integer not null,
+  primary key (kind, subject)
+);
+
+create index login_throttles_last_failure_at on login_throttles(last_failure_at);
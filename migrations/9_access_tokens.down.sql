@@ -0,0 +1,3 @@
+drop trigger access_tokens_user_delete;
+
+drop table access_tokens;
@@ -0,0 +1,20 @@
+-- Personal access tokens, only SHA-256 of the token is stored
+create table access_tokens (
+  id uuid primary key,
+  user_id uuid not null,
+  name text not null,
+  token_hash blob not null unique,
+  -- Space separated scopes
+  scopes text not null,
+  created_at integer not null,
+  -- 0 means the token never expires
+  expires_at integer not null default 0,
+  last_used_at integer not null default 0,
+  unique (user_id, name),
+  foreign key (user_id) references users(id) on delete cascade
+);
+
+create trigger access_tokens_user_delete after delete on users
+begin
+  delete from access_tokens where user_id = old.id;
+end;
@@ -0,0 +1,33 @@
+package argon2d
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestDeriveKey(t *testing.T) {
+	// Test vector from RFC 9106, section 5.1
+	password := bytes.Repeat([]byte{0x01}, 32)
+	salt := bytes.Repeat([]byte{0x02}, 16)
+	secret := bytes.Repeat([]byte{0x03}, 8)
+	data := bytes.Repeat([]byte{0x04}, 12)
+	want := "512b391b6f1162975371d30919734294f868e3be3984f3c1a13a4db9fabe4acb"
+
+	if got := hex.EncodeToString(deriveKey(password, salt, secret, data, 3, 32, 4, 32)); got != want {
+		t.Fatalf("Wrong! Unexpected key!\n\tExpected: %s\n\tActual: %s", want, got)
+	}
+}
+
+func TestKey(t *testing.T) {
+	first := Key([]byte("password"), []byte("somesaltsomesalt"), 2, 64, 2, 32)
+	second := Key([]byte("password"), []byte("somesaltsomesalt"), 2, 64, 2, 32)
+	other := Key([]byte("Password"), []byte("somesaltsomesalt"), 2, 64, 2, 32)
+
+	if !bytes.Equal(first, second) {
+		t.Fatalf("Wrong! Key is not deterministic!\n\tFirst: %x\n\tSecond: %x", first, second)
+	}
+	if bytes.Equal(first, other) {
+		t.Fatalf("Wrong! Different passwords produce the same key: %x", first)
+	}
+}
@@ -0,0 +1,87 @@
+package cipher
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const KeySize = 32
+
+// KDFParams are Argon2id parameters for deriving a key from a password
+type KDFParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	Salt        []byte
+}
+
+// NewKDFParams returns recommended parameters with a random salt
+func NewKDFParams() (KDFParams, error) {
+	salt, err := generateRandom(16)
+	if err != nil {
+		return KDFParams{}, err
+	}
+
+	return KDFParams{
+		Memory:      64 * 1024,
+		Iterations:  3,
+		Parallelism: 2,
+		Salt:        salt,
+	}, nil
+}
+
+func DeriveKey(password string, params KDFParams) []byte {
+	return argon2.IDKey([]byte(password), params.Salt, params.Iterations, params.Memory, params.Parallelism, KeySize)
+}
+
+func GenerateKey() ([]byte, error) {
+	return generateRandom(KeySize)
+}
+
+// SealGCM encrypts and authenticates plaintext with AES-256-GCM using a random nonce
+func SealGCM(key, plaintext, additionalData []byte) (nonce []byte, ciphertext []byte, err error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if nonce, err = generateRandom(aead.NonceSize()); err != nil {
+		return nil, nil, err
+	}
+
+	return nonce, aead.Seal(nil, nonce, plaintext, additionalData), nil
+}
+
+func OpenGCM(key, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce size")
+	}
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("wrong key or corrupted data")
+	}
+
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes", KeySize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,37 @@
+package cipher
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGCM(t *testing.T) {
+	params, err := NewKDFParams()
+	if err != nil {
+		t.Fatalf("failed generating kdf params: %v", err)
+	}
+	params.Memory = 1024
+
+	key := DeriveKey("password", params)
+	src := []byte("some source string")
+	ad := []byte("header")
+
+	nonce, ciphertext, err := SealGCM(key, src, ad)
+	if err != nil {
+		t.Fatalf("Wrong! Unexpected error!\n\tExpected: nil\n\tActual: %v", err)
+	}
+
+	if decrypted, err := OpenGCM(DeriveKey("password", params), nonce, ciphertext, ad); err != nil {
+		t.Errorf("Wrong! Unexpected error!\n\tExpected: nil\n\tActual: %v", err)
+	} else if !bytes.Equal(decrypted, src) {
+		t.Errorf("Wrong! Unexpected result!\n\tExpected: %s\n\tActual: %s", src, decrypted)
+	}
+
+	if _, err := OpenGCM(DeriveKey("wrong password", params), nonce, ciphertext, ad); err == nil {
+		t.Error("Wrong! Data decrypted with wrong password!")
+	}
+
+	if _, err := OpenGCM(key, nonce, ciphertext, []byte("another header")); err == nil {
+		t.Error("Wrong! Data decrypted with wrong additional data!")
+	}
+}
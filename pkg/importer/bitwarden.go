@@ -0,0 +1,70 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const bitwardenLoginType = 1
+
+type bitwardenExport struct {
+	Encrypted bool `json:"encrypted"`
+	Folders   []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"folders"`
+	Items []struct {
+		Type     int    `json:"type"`
+		Name     string `json:"name"`
+		FolderID string `json:"folderId"`
+		Notes    string `json:"notes"`
+		Login    *struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			TOTP     string `json:"totp"`
+			URIs     []struct {
+				URI string `json:"uri"`
+			} `json:"uris"`
+		} `json:"login"`
+	} `json:"items"`
+}
+
+func parseBitwarden(r io.Reader) ([]Entry, error) {
+	var export bitwardenExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("failed decoding json: %w", err)
+	}
+
+	if export.Encrypted {
+		return nil, fmt.Errorf("encrypted exports are not supported")
+	}
+
+	folders := make(map[string]string, len(export.Folders))
+	for _, folder := range export.Folders {
+		folders[folder.ID] = folder.Name
+	}
+
+	entries := make([]Entry, 0, len(export.Items))
+	for _, item := range export.Items {
+		entry := Entry{
+			Group: folders[item.FolderID],
+			Title: item.Name,
+			Notes: item.Notes,
+		}
+
+		// Non-login items (cards, identities, notes) are kept without credentials
+		if item.Type == bitwardenLoginType && item.Login != nil {
+			entry.Username = item.Login.Username
+			entry.Password = item.Login.Password
+			entry.TOTP = item.Login.TOTP
+			if len(item.Login.URIs) > 0 {
+				entry.URL = item.Login.URIs[0].URI
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
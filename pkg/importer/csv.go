@@ -0,0 +1,93 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type csvColumn int
+
+const (
+	columnTitle csvColumn = iota
+	columnURL
+	columnUsername
+	columnPassword
+	columnNotes
+	columnTOTP
+)
+
+// Header names of the supported CSV exports (in lower case)
+var csvHeaders = map[string]csvColumn{
+	"title":    columnTitle,
+	"name":     columnTitle,
+	"url":      columnURL,
+	"username": columnUsername,
+	"password": columnPassword,
+	"note":     columnNotes,
+	"notes":    columnNotes,
+	"otpauth":  columnTOTP,
+}
+
+var csvRequiredColumns = map[Format][]csvColumn{
+	OnePasswordCSV: {columnTitle, columnUsername, columnPassword},
+	ChromeCSV:      {columnTitle, columnURL, columnUsername, columnPassword},
+	FirefoxCSV:     {columnURL, columnUsername, columnPassword},
+}
+
+func parseCSV(format Format, r io.Reader) ([]Entry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed reading header: %w", err)
+	}
+
+	columns := make(map[csvColumn]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if column, ok := csvHeaders[name]; ok {
+			if _, exist := columns[column]; !exist {
+				columns[column] = i
+			}
+		}
+	}
+
+	for _, column := range csvRequiredColumns[format] {
+		if _, ok := columns[column]; !ok {
+			return nil, fmt.Errorf("invalid header: %v", header)
+		}
+	}
+
+	var entries []Entry
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed reading record: %w", err)
+		}
+
+		value := func(column csvColumn) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+
+		entries = append(entries, Entry{
+			Title:    value(columnTitle),
+			URL:      value(columnURL),
+			Username: value(columnUsername),
+			Password: value(columnPassword),
+			Notes:    value(columnNotes),
+			TOTP:     value(columnTOTP),
+		})
+	}
+
+	return entries, nil
+}
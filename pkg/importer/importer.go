@@ -0,0 +1,80 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+type Format string
+
+const (
+	Bitwarden       Format = "bitwarden"
+	KeePassXML      Format = "keepass"
+	KeePassKDBX     Format = "kdbx"
+	OnePassword1PUX Format = "1pux"
+	OnePasswordCSV  Format = "1password"
+	ChromeCSV       Format = "chrome"
+	FirefoxCSV      Format = "firefox"
+	Passman         Format = "passman"
+)
+
+type Options struct {
+	// Password of the encrypted export file or KeePass database
+	Password string
+}
+
+// Entry is a single login record found in the export of another password manager
+type Entry struct {
+	// Service is set only by the formats which know the service name
+	Service  string
+	Group    string
+	Title    string
+	URL      string
+	Username string
+	Password string
+	Notes    string
+	TOTP     string
+}
+
+// Host returns the host of the entry URL without "www." prefix
+func (e Entry) Host() string {
+	raw := strings.TrimSpace(e.URL)
+	if len(raw) == 0 {
+		return ""
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+func Formats() []Format {
+	return []Format{Bitwarden, KeePassXML, KeePassKDBX, OnePassword1PUX, OnePasswordCSV, ChromeCSV, FirefoxCSV, Passman}
+}
+
+func Parse(format Format, r io.Reader, opts Options) ([]Entry, error) {
+	switch format {
+	case Bitwarden:
+		return parseBitwarden(r)
+	case KeePassXML:
+		return parseKeePassXML(r)
+	case KeePassKDBX:
+		return parseKDBX(r, opts.Password)
+	case OnePassword1PUX:
+		return parse1PUX(r)
+	case OnePasswordCSV, ChromeCSV, FirefoxCSV:
+		return parseCSV(format, r)
+	case Passman:
+		return parsePassman(r, opts.Password)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
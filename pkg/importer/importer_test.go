@@ -0,0 +1,260 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"passman/pkg/kdbx"
+	"passman/pkg/vaultfile"
+)
+
+func build1PUX(t *testing.T, exportData string) io.Reader {
+	t.Helper()
+
+	buf := bytes.NewBuffer(nil)
+	w := zip.NewWriter(buf)
+	f, err := w.Create(onePasswordExportData)
+	if err != nil {
+		t.Fatalf("failed creating zip entity: %v", err)
+	}
+	if _, err := f.Write([]byte(exportData)); err != nil {
+		t.Fatalf("failed writing zip entity: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed closing zip: %v", err)
+	}
+	return buf
+}
+
+func TestParse(t *testing.T) {
+	bitwardenJSON := `{
+  "encrypted": false,
+  "folders": [{"id": "f1", "name": "Work"}],
+  "items": [
+    {"type": 1, "name": "Google", "folderId": "f1", "notes": "n",
+     "login": {"username": "user", "password": "pass", "totp": "SECRET", "uris": [{"uri": "https://accounts.google.com"}]}},
+    {"type": 3, "name": "Visa", "folderId": null}
+  ]
+}`
+	keepassXML := `<?xml version="1.0" encoding="UTF-8"?>
+<KeePassFile>
+  <Root>
+    <Group>
+      <Name>Database</Name>
+      <Entry>
+        <String><Key>Title</Key><Value>Root entry</Value></String>
+        <String><Key>UserName</Key><Value>root</Value></String>
+        <String><Key>Password</Key><Value>rootpass</Value></String>
+      </Entry>
+      <Group>
+        <Name>Internet</Name>
+        <Entry>
+          <String><Key>Title</Key><Value>GitHub</Value></String>
+          <String><Key>UserName</Key><Value>octo</Value></String>
+          <String><Key>Password</Key><Value>cat</Value></String>
+          <String><Key>URL</Key><Value>https://github.com</Value></String>
+          <String><Key>otp</Key><Value>otpauth://totp/x?secret=ABC</Value></String>
+          <History>
+            <Entry>
+              <String><Key>Title</Key><Value>Old GitHub</Value></String>
+            </Entry>
+          </History>
+        </Entry>
+      </Group>
+      <Group>
+        <Name>Recycle Bin</Name>
+        <Entry>
+          <String><Key>Title</Key><Value>Deleted</Value></String>
+        </Entry>
+      </Group>
+    </Group>
+  </Root>
+</KeePassFile>`
+	onePasswordData := `{"accounts": [{"vaults": [{"attrs": {"name": "Private"}, "items": [
+  {"state": "active", "overview": {"title": "Yandex", "url": "https://mail.yandex.ru"},
+   "details": {"loginFields": [{"value": "ya", "designation": "username"}, {"value": "yapass", "designation": "password"}],
+               "notesPlain": "note", "sections": [{"fields": [{"value": {"totp": "TOTPSECRET"}}]}]}},
+  {"state": "archived", "overview": {"title": "Archived"}}
+]}]}]}`
+
+	tests := []struct {
+		name       string
+		format     Format
+		password   string
+		input      func(t *testing.T) io.Reader
+		expEntries []Entry
+		expErr     string
+	}{
+		{
+			name:   "unsupported_format",
+			format: Format("lastpass"),
+			input:  func(*testing.T) io.Reader { return strings.NewReader("") },
+			expErr: `unsupported format "lastpass"`,
+		},
+		{
+			name:   "bitwarden_encrypted",
+			format: Bitwarden,
+			input:  func(*testing.T) io.Reader { return strings.NewReader(`{"encrypted": true}`) },
+			expErr: "encrypted exports are not supported",
+		},
+		{
+			name:   "bitwarden",
+			format: Bitwarden,
+			input:  func(*testing.T) io.Reader { return strings.NewReader(bitwardenJSON) },
+			expEntries: []Entry{
+				{Group: "Work", Title: "Google", URL: "https://accounts.google.com", Username: "user", Password: "pass", Notes: "n", TOTP: "SECRET"},
+				{Title: "Visa"},
+			},
+		},
+		{
+			name:   "keepass",
+			format: KeePassXML,
+			input:  func(*testing.T) io.Reader { return strings.NewReader(keepassXML) },
+			expEntries: []Entry{
+				{Title: "Root entry", Username: "root", Password: "rootpass"},
+				{Group: "Internet", Title: "GitHub", URL: "https://github.com", Username: "octo", Password: "cat", TOTP: "otpauth://totp/x?secret=ABC"},
+			},
+		},
+		{
+			name:     "kdbx",
+			format:   KeePassKDBX,
+			password: "database password",
+			input: func(t *testing.T) io.Reader {
+				db := kdbx.Database{Name: "Database", Root: kdbx.Group{
+					Name:    "Database",
+					Entries: []kdbx.Entry{{Title: "Root entry", UserName: "root", Password: "rootpass"}},
+					Groups: []kdbx.Group{{Name: "github", Entries: []kdbx.Entry{
+						{Title: "main", UserName: "octo", Password: "cat", OTP: "otpauth://totp/x?secret=ABC"},
+					}}},
+				}}
+				opts := kdbx.DefaultOptions()
+				opts.Memory, opts.Iterations = 1024, 1
+				buf := bytes.NewBuffer(nil)
+				if err := kdbx.Write(buf, db, "database password", opts); err != nil {
+					t.Fatalf("failed writing kdbx: %v", err)
+				}
+				return buf
+			},
+			expEntries: []Entry{
+				{Title: "Root entry", Username: "root", Password: "rootpass"},
+				{Service: "github", Group: "github", Title: "main", Username: "octo", Password: "cat", TOTP: "otpauth://totp/x?secret=ABC"},
+			},
+		},
+		{
+			name:   "kdbx_wrong_password",
+			format: KeePassKDBX,
+			input: func(t *testing.T) io.Reader {
+				opts := kdbx.DefaultOptions()
+				opts.Memory, opts.Iterations = 1024, 1
+				buf := bytes.NewBuffer(nil)
+				if err := kdbx.Write(buf, kdbx.Database{}, "database password", opts); err != nil {
+					t.Fatalf("failed writing kdbx: %v", err)
+				}
+				return buf
+			},
+			expErr: "wrong password or corrupted file",
+		},
+		{
+			name:   "1pux",
+			format: OnePassword1PUX,
+			input:  func(t *testing.T) io.Reader { return build1PUX(t, onePasswordData) },
+			expEntries: []Entry{
+				{Group: "Private", Title: "Yandex", URL: "https://mail.yandex.ru", Username: "ya", Password: "yapass", Notes: "note", TOTP: "TOTPSECRET"},
+			},
+		},
+		{
+			name:   "1password_csv",
+			format: OnePasswordCSV,
+			input: func(*testing.T) io.Reader {
+				return strings.NewReader("Title,Url,Username,Password,OTPAuth,Favorite,Archived,Tags,Notes\nMail,mail.ru,m,p,,false,false,,hi\n")
+			},
+			expEntries: []Entry{
+				{Title: "Mail", URL: "mail.ru", Username: "m", Password: "p", Notes: "hi"},
+			},
+		},
+		{
+			name:   "chrome_csv",
+			format: ChromeCSV,
+			input: func(*testing.T) io.Reader {
+				return strings.NewReader("\ufeffname,url,username,password,note\ngithub.com,https://github.com/,octo,cat,\n")
+			},
+			expEntries: []Entry{
+				{Title: "github.com", URL: "https://github.com/", Username: "octo", Password: "cat"},
+			},
+		},
+		{
+			name:   "firefox_csv",
+			format: FirefoxCSV,
+			input: func(*testing.T) io.Reader {
+				return strings.NewReader(`"url","username","password","httpRealm","formActionOrigin","guid","timeCreated","timeLastUsed","timePasswordChanged"` +
+					"\n" + `"https://www.youtube.com","yt","ytpass",,"https://www.youtube.com","{1}","1","1","1"` + "\n")
+			},
+			expEntries: []Entry{
+				{URL: "https://www.youtube.com", Username: "yt", Password: "ytpass"},
+			},
+		},
+		{
+			name:     "passman",
+			format:   Passman,
+			password: "export password",
+			input: func(t *testing.T) io.Reader {
+				vault := vaultfile.New(time.Now())
+				vault.Accounts = append(vault.Accounts, vaultfile.Account{Service: "My Service", Name: "main", Login: "l", Password: "p"})
+				buf := bytes.NewBuffer(nil)
+				if err := vaultfile.WriteEncrypted(buf, vault, "export password"); err != nil {
+					t.Fatalf("failed writing vault: %v", err)
+				}
+				return buf
+			},
+			expEntries: []Entry{
+				{Service: "My Service", Title: "main", Username: "l", Password: "p"},
+			},
+		},
+		{
+			name:   "csv_invalid_header",
+			format: FirefoxCSV,
+			input:  func(*testing.T) io.Reader { return strings.NewReader("login,secret\na,b\n") },
+			expErr: "invalid header: [login secret]",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actEntries, actErr := Parse(test.format, test.input(t), Options{Password: test.password})
+
+			if len(test.expErr) > 0 {
+				if actErr == nil || actErr.Error() != test.expErr {
+					t.Fatalf("Wrong! Unexpected error!\n\tExpected: %s\n\tActual: %v", test.expErr, actErr)
+				}
+				return
+			}
+			if actErr != nil {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: nil\n\tActual: %v", actErr)
+			}
+
+			if got, want := actEntries, test.expEntries; !reflect.DeepEqual(got, want) {
+				t.Errorf("Wrong! Unexpected entries!\n\tExpected: %+v\n\tActual: %+v", want, got)
+			}
+		})
+	}
+}
+
+func TestEntryHost(t *testing.T) {
+	tests := map[string]string{
+		"":                              "",
+		"https://www.YouTube.com/watch": "youtube.com",
+		"mail.google.com":               "mail.google.com",
+		"http://localhost:8080":         "localhost",
+	}
+
+	for input, want := range tests {
+		if got := (Entry{URL: input}).Host(); got != want {
+			t.Errorf("Wrong! Unexpected host of %q!\n\tExpected: %s\n\tActual: %s", input, want, got)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package importer
+
+import (
+	"io"
+
+	"passman/pkg/kdbx"
+)
+
+func parseKDBX(r io.Reader, password string) ([]Entry, error) {
+	db, err := kdbx.Read(r, password)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := walkKDBXGroup(nil, db.Root, "")
+
+	// Databases exported by passman have one group per service
+	if db.Generator == kdbx.Generator {
+		for i := range entries {
+			entries[i].Service = entries[i].Group
+		}
+	}
+
+	return entries, nil
+}
+
+// walkKDBXGroup collects entries of the group and its subgroups like
+// walkKeePassGroup does. The recycle bin is already skipped by the reader.
+func walkKDBXGroup(entries []Entry, group kdbx.Group, path string) []Entry {
+	for _, e := range group.Entries {
+		entries = append(entries, kdbxEntry(e, path))
+	}
+
+	for _, child := range group.Groups {
+		childPath := child.Name
+		if len(path) > 0 {
+			childPath = path + "/" + child.Name
+		}
+		entries = walkKDBXGroup(entries, child, childPath)
+	}
+
+	return entries
+}
+
+func kdbxEntry(e kdbx.Entry, path string) Entry {
+	return Entry{
+		Group:    path,
+		Title:    e.Title,
+		URL:      e.URL,
+		Username: e.UserName,
+		Password: e.Password,
+		Notes:    e.Notes,
+		TOTP:     e.OTP,
+	}
+}
@@ -0,0 +1,82 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const keePassRecycleBin = "Recycle Bin"
+
+type keePassFile struct {
+	Root struct {
+		Groups []keePassGroup `xml:"Group"`
+	} `xml:"Root"`
+}
+
+type keePassGroup struct {
+	Name    string         `xml:"Name"`
+	Entries []keePassEntry `xml:"Entry"`
+	Groups  []keePassGroup `xml:"Group"`
+}
+
+type keePassEntry struct {
+	Strings []struct {
+		Key   string `xml:"Key"`
+		Value string `xml:"Value"`
+	} `xml:"String"`
+}
+
+func parseKeePassXML(r io.Reader) ([]Entry, error) {
+	var file keePassFile
+	if err := xml.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed decoding xml: %w", err)
+	}
+
+	var entries []Entry
+	for _, group := range file.Root.Groups {
+		entries = walkKeePassGroup(entries, group, "")
+	}
+
+	return entries, nil
+}
+
+// walkKeePassGroup collects entries of the group and its subgroups. The path
+// doesn't include the root group because it's the database itself.
+func walkKeePassGroup(entries []Entry, group keePassGroup, path string) []Entry {
+	for _, e := range group.Entries {
+		entry := Entry{Group: path}
+		for _, s := range e.Strings {
+			switch strings.ToLower(s.Key) {
+			case "title":
+				entry.Title = s.Value
+			case "username":
+				entry.Username = s.Value
+			case "password":
+				entry.Password = s.Value
+			case "url":
+				entry.URL = s.Value
+			case "notes":
+				entry.Notes = s.Value
+			case "otp", "totp seed", "timeotp-secret-base32":
+				entry.TOTP = s.Value
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	for _, child := range group.Groups {
+		if child.Name == keePassRecycleBin {
+			continue
+		}
+
+		childPath := child.Name
+		if len(path) > 0 {
+			childPath = path + "/" + child.Name
+		}
+		entries = walkKeePassGroup(entries, child, childPath)
+	}
+
+	return entries
+}
@@ -0,0 +1,112 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const onePasswordExportData = "export.data"
+
+type onePasswordExport struct {
+	Accounts []struct {
+		Vaults []struct {
+			Attrs struct {
+				Name string `json:"name"`
+			} `json:"attrs"`
+			Items []onePasswordItem `json:"items"`
+		} `json:"vaults"`
+	} `json:"accounts"`
+}
+
+type onePasswordItem struct {
+	State    string `json:"state"`
+	Overview struct {
+		Title string `json:"title"`
+		URL   string `json:"url"`
+	} `json:"overview"`
+	Details struct {
+		LoginFields []struct {
+			Value       string `json:"value"`
+			Designation string `json:"designation"`
+		} `json:"loginFields"`
+		NotesPlain string `json:"notesPlain"`
+		Password   string `json:"password"`
+		Sections   []struct {
+			Fields []struct {
+				Value struct {
+					TOTP string `json:"totp"`
+				} `json:"value"`
+			} `json:"fields"`
+		} `json:"sections"`
+	} `json:"details"`
+}
+
+func parse1PUX(r io.Reader) ([]Entry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading file: %w", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed opening archive: %w", err)
+	}
+
+	exportFile, err := archive.Open(onePasswordExportData)
+	if err != nil {
+		return nil, fmt.Errorf("failed opening %s: %w", onePasswordExportData, err)
+	}
+	defer exportFile.Close()
+
+	var export onePasswordExport
+	if err := json.NewDecoder(exportFile).Decode(&export); err != nil {
+		return nil, fmt.Errorf("failed decoding json: %w", err)
+	}
+
+	var entries []Entry
+	for _, account := range export.Accounts {
+		for _, vault := range account.Vaults {
+			for _, item := range vault.Items {
+				// Archived and deleted items are not exported
+				if len(item.State) > 0 && item.State != "active" {
+					continue
+				}
+				entries = append(entries, item.toEntry(vault.Attrs.Name))
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+func (item onePasswordItem) toEntry(vault string) Entry {
+	entry := Entry{
+		Group:    vault,
+		Title:    item.Overview.Title,
+		URL:      item.Overview.URL,
+		Notes:    item.Details.NotesPlain,
+		Password: item.Details.Password,
+	}
+
+	for _, field := range item.Details.LoginFields {
+		switch field.Designation {
+		case "username":
+			entry.Username = field.Value
+		case "password":
+			entry.Password = field.Value
+		}
+	}
+
+	for _, section := range item.Details.Sections {
+		for _, field := range section.Fields {
+			if len(field.Value.TOTP) > 0 {
+				entry.TOTP = field.Value.TOTP
+			}
+		}
+	}
+
+	return entry
+}
@@ -0,0 +1,28 @@
+package importer
+
+import (
+	"io"
+
+	"passman/pkg/vaultfile"
+)
+
+func parsePassman(r io.Reader, password string) ([]Entry, error) {
+	vault, err := vaultfile.Read(r, password)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(vault.Accounts))
+	for _, acc := range vault.Accounts {
+		entries = append(entries, Entry{
+			Service:  acc.Service,
+			Group:    acc.Folder,
+			Title:    acc.Name,
+			Username: acc.Login,
+			Password: acc.Password,
+			TOTP:     acc.TOTP,
+		})
+	}
+
+	return entries, nil
+}
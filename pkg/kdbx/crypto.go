@@ -0,0 +1,194 @@
+package kdbx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"golang.org/x/crypto/chacha20"
+)
+
+const (
+	blockSize    = 1024 * 1024
+	maxBlockSize = 64 * 1024 * 1024
+)
+
+// compositeKey is the composite key of the database protected by password only
+func compositeKey(password string) []byte {
+	return sha256Sum(sha256Sum([]byte(password)))
+}
+
+func deriveKeys(masterSeed, transformedKey []byte) (encKey, hmacKey []byte) {
+	encKey = sha256Sum(masterSeed, transformedKey)
+
+	h := sha512.New()
+	h.Write(masterSeed)
+	h.Write(transformedKey)
+	h.Write([]byte{0x01})
+
+	return encKey, h.Sum(nil)
+}
+
+func blockHMACKey(hmacKey []byte, index uint64) []byte {
+	h := sha512.New()
+	h.Write(binary.LittleEndian.AppendUint64(nil, index))
+	h.Write(hmacKey)
+	return h.Sum(nil)
+}
+
+func headerHMAC(hmacKey, rawHeader []byte) []byte {
+	mac := hmac.New(sha256.New, blockHMACKey(hmacKey, math.MaxUint64))
+	mac.Write(rawHeader)
+	return mac.Sum(nil)
+}
+
+func blockHMAC(hmacKey []byte, index uint64, data []byte) []byte {
+	mac := hmac.New(sha256.New, blockHMACKey(hmacKey, index))
+	mac.Write(binary.LittleEndian.AppendUint64(nil, index))
+	mac.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(data))))
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// writeBlocks writes data as HMAC block stream terminated by an empty block
+func writeBlocks(buf *bytes.Buffer, hmacKey, data []byte) {
+	for index := uint64(0); ; index++ {
+		block := data[:min(len(data), blockSize)]
+		data = data[len(block):]
+
+		buf.Write(blockHMAC(hmacKey, index, block))
+		buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(block))))
+		buf.Write(block)
+
+		if len(block) == 0 {
+			return
+		}
+	}
+}
+
+func readBlocks(data, hmacKey []byte) ([]byte, error) {
+	res := bytes.NewBuffer(nil)
+
+	for index := uint64(0); ; index++ {
+		if len(data) < 36 {
+			return nil, ErrCorrupted
+		}
+		mac := data[:32]
+		size := binary.LittleEndian.Uint32(data[32:36])
+		if size > maxBlockSize || uint32(len(data)-36) < size {
+			return nil, ErrCorrupted
+		}
+		block := data[36 : 36+size]
+		data = data[36+size:]
+
+		if !hmacEqual(mac, blockHMAC(hmacKey, index, block)) {
+			return nil, fmt.Errorf("%w: block %d", ErrCorrupted, index)
+		}
+
+		if size == 0 {
+			return res.Bytes(), nil
+		}
+		res.Write(block)
+	}
+}
+
+func encrypt(cipherID [16]byte, key, iv, data []byte) ([]byte, error) {
+	switch cipherID {
+	case cipherAES256:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		padding := aes.BlockSize - len(data)%aes.BlockSize
+		res := append(bytes.Clone(data), bytes.Repeat([]byte{byte(padding)}, padding)...)
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(res, res)
+		return res, nil
+	case cipherChaCha20:
+		stream, err := chacha20.NewUnauthenticatedCipher(key, iv)
+		if err != nil {
+			return nil, err
+		}
+		res := make([]byte, len(data))
+		stream.XORKeyStream(res, data)
+		return res, nil
+	default:
+		return nil, fmt.Errorf("unsupported cipher")
+	}
+}
+
+func decrypt(cipherID [16]byte, key, iv, data []byte) ([]byte, error) {
+	switch cipherID {
+	case cipherAES256:
+		if len(iv) != aes.BlockSize || len(data) == 0 || len(data)%aes.BlockSize != 0 {
+			return nil, ErrCorrupted
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		res := make([]byte, len(data))
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(res, data)
+
+		padding := int(res[len(res)-1])
+		if padding == 0 || padding > aes.BlockSize || !bytes.Equal(res[len(res)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+			return nil, ErrCorrupted
+		}
+		return res[:len(res)-padding], nil
+	case cipherChaCha20:
+		if len(iv) != chacha20.NonceSize {
+			return nil, ErrCorrupted
+		}
+		return encrypt(cipherID, key, iv, data)
+	default:
+		return nil, fmt.Errorf("unsupported cipher, only AES-256 and ChaCha20 are supported")
+	}
+}
+
+// newInnerStream returns the ChaCha20 stream protecting values in XML
+func newInnerStream(key []byte) cipher.Stream {
+	h := sha512.Sum512(key)
+	stream, _ := chacha20.NewUnauthenticatedCipher(h[:32], h[32:32+chacha20.NonceSize])
+	return stream
+}
+
+func compress(data []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	zw := gzip.NewWriter(buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decompress(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	return io.ReadAll(zr)
+}
+
+func sha256Sum(data ...[]byte) []byte {
+	h := sha256.New()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+func hmacEqual(a, b []byte) bool {
+	return hmac.Equal(a, b)
+}
@@ -0,0 +1,240 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	signature1   uint32 = 0x9AA2D903
+	signature2   uint32 = 0xB54BFB67
+	majorVersion uint32 = 4
+	// Version 4.0 is written, 4.x is read
+	fileVersion = majorVersion << 16
+
+	compressionGzip uint32 = 1
+
+	maxHeaderFieldSize = 1024 * 1024
+)
+
+// Outer header field IDs
+const (
+	fieldEndOfHeader      byte = 0
+	fieldCipherID         byte = 2
+	fieldCompressionFlags byte = 3
+	fieldMasterSeed       byte = 4
+	fieldEncryptionIV     byte = 7
+	fieldKdfParameters    byte = 11
+)
+
+// Inner header field IDs
+const (
+	innerFieldEnd       byte = 0
+	innerFieldStreamID  byte = 1
+	innerFieldStreamKey byte = 2
+)
+
+var (
+	cipherAES256   = mustUUID("31c1f2e6bf714350be5805216afc5aff")
+	cipherChaCha20 = mustUUID("d6038a2b8b6f4cb5a524339a31dbb59a")
+)
+
+type header struct {
+	cipherID   [16]byte
+	compressed bool
+	masterSeed []byte
+	iv         []byte
+	kdf        kdfParams
+}
+
+func newHeader(opts Options) (header, error) {
+	h := header{compressed: true}
+
+	switch opts.Cipher {
+	case CipherAES256:
+		h.cipherID, h.iv = cipherAES256, make([]byte, 16)
+	case CipherChaCha20:
+		h.cipherID, h.iv = cipherChaCha20, make([]byte, 12)
+	default:
+		return header{}, fmt.Errorf("unsupported cipher %q", opts.Cipher)
+	}
+
+	kdf, err := newKDFParams(opts)
+	if err != nil {
+		return header{}, err
+	}
+	h.kdf = kdf
+
+	h.masterSeed = make([]byte, 32)
+	if _, err := rand.Read(h.masterSeed); err != nil {
+		return header{}, err
+	}
+	if _, err := rand.Read(h.iv); err != nil {
+		return header{}, err
+	}
+
+	return h, nil
+}
+
+func (h header) marshal() []byte {
+	buf := bytes.NewBuffer(nil)
+	_ = binary.Write(buf, binary.LittleEndian, []uint32{signature1, signature2, fileVersion})
+
+	compression := uint32(0)
+	if h.compressed {
+		compression = compressionGzip
+	}
+
+	writeField(buf, fieldCipherID, h.cipherID[:])
+	writeField(buf, fieldCompressionFlags, binary.LittleEndian.AppendUint32(nil, compression))
+	writeField(buf, fieldMasterSeed, h.masterSeed)
+	writeField(buf, fieldEncryptionIV, h.iv)
+	writeField(buf, fieldKdfParameters, h.kdf.marshal())
+	writeField(buf, fieldEndOfHeader, []byte("\r\n\r\n"))
+
+	return buf.Bytes()
+}
+
+// parseHeader parses the outer header and returns it with its raw bytes
+func parseHeader(data []byte) (header, []byte, error) {
+	if len(data) < 12 {
+		return header{}, nil, ErrInvalidSignature
+	}
+	if binary.LittleEndian.Uint32(data[0:4]) != signature1 || binary.LittleEndian.Uint32(data[4:8]) != signature2 {
+		return header{}, nil, ErrInvalidSignature
+	}
+	if binary.LittleEndian.Uint32(data[8:12])>>16 != majorVersion {
+		return header{}, nil, ErrUnsupportedVersion
+	}
+
+	var (
+		h                         header
+		hasCipher, hasSeed, hasIV bool
+		hasKDF                    bool
+	)
+
+	offset := 12
+	for {
+		if len(data) < offset+5 {
+			return header{}, nil, ErrCorrupted
+		}
+		id := data[offset]
+		size := binary.LittleEndian.Uint32(data[offset+1 : offset+5])
+		offset += 5
+		if size > maxHeaderFieldSize || len(data) < offset+int(size) {
+			return header{}, nil, ErrCorrupted
+		}
+		value := data[offset : offset+int(size)]
+		offset += int(size)
+
+		switch id {
+		case fieldEndOfHeader:
+			if !hasCipher || !hasSeed || !hasIV || !hasKDF {
+				return header{}, nil, fmt.Errorf("%w: missing header fields", ErrCorrupted)
+			}
+			return h, data[:offset], nil
+		case fieldCipherID:
+			if len(value) != 16 {
+				return header{}, nil, ErrCorrupted
+			}
+			copy(h.cipherID[:], value)
+			hasCipher = true
+		case fieldCompressionFlags:
+			if len(value) != 4 {
+				return header{}, nil, ErrCorrupted
+			}
+			switch binary.LittleEndian.Uint32(value) {
+			case 0:
+				h.compressed = false
+			case compressionGzip:
+				h.compressed = true
+			default:
+				return header{}, nil, fmt.Errorf("unsupported compression")
+			}
+		case fieldMasterSeed:
+			if len(value) != 32 {
+				return header{}, nil, ErrCorrupted
+			}
+			h.masterSeed, hasSeed = value, true
+		case fieldEncryptionIV:
+			h.iv, hasIV = value, true
+		case fieldKdfParameters:
+			kdf, err := parseKDFParams(value)
+			if err != nil {
+				return header{}, nil, err
+			}
+			h.kdf, hasKDF = kdf, true
+		default:
+			// Public custom data and unknown fields are ignored
+		}
+	}
+}
+
+type innerHeader struct {
+	streamID  uint32
+	streamKey []byte
+}
+
+const innerStreamChaCha20 uint32 = 3
+
+func newInnerHeader() (innerHeader, error) {
+	key := make([]byte, 64)
+	if _, err := rand.Read(key); err != nil {
+		return innerHeader{}, err
+	}
+	return innerHeader{streamID: innerStreamChaCha20, streamKey: key}, nil
+}
+
+func (h innerHeader) marshal(buf *bytes.Buffer) {
+	writeField(buf, innerFieldStreamID, binary.LittleEndian.AppendUint32(nil, h.streamID))
+	writeField(buf, innerFieldStreamKey, h.streamKey)
+	writeField(buf, innerFieldEnd, nil)
+}
+
+// parseInnerHeader parses the inner header and returns the rest of the payload
+func parseInnerHeader(data []byte) (innerHeader, []byte, error) {
+	var h innerHeader
+
+	offset := 0
+	for {
+		if len(data) < offset+5 {
+			return innerHeader{}, nil, ErrCorrupted
+		}
+		id := data[offset]
+		size := binary.LittleEndian.Uint32(data[offset+1 : offset+5])
+		offset += 5
+		if len(data) < offset+int(size) {
+			return innerHeader{}, nil, ErrCorrupted
+		}
+		value := data[offset : offset+int(size)]
+		offset += int(size)
+
+		switch id {
+		case innerFieldEnd:
+			if h.streamID != innerStreamChaCha20 {
+				return innerHeader{}, nil, fmt.Errorf("unsupported inner random stream %d", h.streamID)
+			}
+			if len(h.streamKey) == 0 {
+				return innerHeader{}, nil, fmt.Errorf("%w: missing inner stream key", ErrCorrupted)
+			}
+			return h, data[offset:], nil
+		case innerFieldStreamID:
+			if len(value) != 4 {
+				return innerHeader{}, nil, ErrCorrupted
+			}
+			h.streamID = binary.LittleEndian.Uint32(value)
+		case innerFieldStreamKey:
+			h.streamKey = value
+		default:
+			// Attachments and unknown fields are ignored
+		}
+	}
+}
+
+func writeField(buf *bytes.Buffer, id byte, value []byte) {
+	buf.WriteByte(id)
+	buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(value))))
+	buf.Write(value)
+}
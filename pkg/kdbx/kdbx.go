@@ -0,0 +1,189 @@
+// Package kdbx reads and writes password protected KeePass KDBX 4 databases.
+//
+// Only the password key is supported (no key files). Outer ciphers are
+// AES-256-CBC and ChaCha20, key derivation functions are Argon2d, Argon2id
+// and AES-KDF (read only). Protected values are encrypted by the ChaCha20
+// inner stream.
+package kdbx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+type Cipher string
+
+const (
+	CipherAES256   Cipher = "aes256"
+	CipherChaCha20 Cipher = "chacha20"
+)
+
+type KDF string
+
+const (
+	KDFArgon2d  KDF = "argon2d"
+	KDFArgon2id KDF = "argon2id"
+)
+
+// Options of the written database
+type Options struct {
+	Cipher Cipher
+	KDF    KDF
+	// Memory of Argon2 in KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+var (
+	ErrInvalidSignature   = errors.New("not a kdbx file")
+	ErrUnsupportedVersion = errors.New("unsupported kdbx version, only kdbx 4 is supported")
+	ErrWrongPassword      = errors.New("wrong password or corrupted file")
+	ErrCorrupted          = errors.New("corrupted file")
+)
+
+// Database is a tree of groups. Root is the group of the database itself.
+type Database struct {
+	Name string
+	// Generator is the application which wrote the database. It's ignored by Write.
+	Generator string
+	Root      Group
+}
+
+type Group struct {
+	Name    string
+	Groups  []Group
+	Entries []Entry
+}
+
+type Entry struct {
+	Title    string
+	UserName string
+	Password string
+	URL      string
+	Notes    string
+	// OTP is the value of the standard "otp" attribute (otpauth:// URI).
+	// KeePass 2 "TimeOtp-Secret-Base32" is read into it as well.
+	OTP string
+}
+
+// DefaultOptions returns options compatible with KeePassXC 2.7 defaults
+// except the KDF which is Argon2id.
+func DefaultOptions() Options {
+	return Options{
+		Cipher:      CipherAES256,
+		KDF:         KDFArgon2id,
+		Memory:      64 * 1024,
+		Iterations:  3,
+		Parallelism: 2,
+	}
+}
+
+func Write(w io.Writer, db Database, password string, opts Options) error {
+	h, err := newHeader(opts)
+	if err != nil {
+		return err
+	}
+
+	return write(w, h, db, password)
+}
+
+func write(w io.Writer, h header, db Database, password string) error {
+	rawHeader := h.marshal()
+
+	transformedKey, err := h.kdf.transform(compositeKey(password))
+	if err != nil {
+		return err
+	}
+	encKey, hmacKey := deriveKeys(h.masterSeed, transformedKey)
+
+	inner, err := newInnerHeader()
+	if err != nil {
+		return err
+	}
+
+	payload := bytes.NewBuffer(nil)
+	inner.marshal(payload)
+	if err := encodeXML(payload, db, newInnerStream(inner.streamKey)); err != nil {
+		return fmt.Errorf("failed encoding xml: %w", err)
+	}
+
+	compressed, err := compress(payload.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed compressing payload: %w", err)
+	}
+
+	encrypted, err := encrypt(h.cipherID, encKey, h.iv, compressed)
+	if err != nil {
+		return err
+	}
+
+	out := bytes.NewBuffer(nil)
+	out.Write(rawHeader)
+	out.Write(sha256Sum(rawHeader))
+	out.Write(headerHMAC(hmacKey, rawHeader))
+	writeBlocks(out, hmacKey, encrypted)
+
+	_, err = w.Write(out.Bytes())
+	return err
+}
+
+func Read(r io.Reader, password string) (Database, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Database{}, err
+	}
+
+	h, rawHeader, err := parseHeader(data)
+	if err != nil {
+		return Database{}, err
+	}
+	data = data[len(rawHeader):]
+
+	if len(data) < 64 {
+		return Database{}, ErrCorrupted
+	}
+	if !bytes.Equal(data[:32], sha256Sum(rawHeader)) {
+		return Database{}, ErrCorrupted
+	}
+
+	transformedKey, err := h.kdf.transform(compositeKey(password))
+	if err != nil {
+		return Database{}, err
+	}
+	encKey, hmacKey := deriveKeys(h.masterSeed, transformedKey)
+
+	if !hmacEqual(data[32:64], headerHMAC(hmacKey, rawHeader)) {
+		return Database{}, ErrWrongPassword
+	}
+
+	encrypted, err := readBlocks(data[64:], hmacKey)
+	if err != nil {
+		return Database{}, err
+	}
+
+	payload, err := decrypt(h.cipherID, encKey, h.iv, encrypted)
+	if err != nil {
+		return Database{}, err
+	}
+
+	if h.compressed {
+		if payload, err = decompress(payload); err != nil {
+			return Database{}, fmt.Errorf("failed decompressing payload: %w", err)
+		}
+	}
+
+	inner, rest, err := parseInnerHeader(payload)
+	if err != nil {
+		return Database{}, err
+	}
+
+	db, err := decodeXML(bytes.NewReader(rest), newInnerStream(inner.streamKey))
+	if err != nil {
+		return Database{}, fmt.Errorf("failed decoding xml: %w", err)
+	}
+
+	return db, nil
+}
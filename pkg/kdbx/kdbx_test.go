@@ -0,0 +1,183 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testDatabase() Database {
+	return Database{
+		Name:      "passman",
+		Generator: Generator,
+		Root: Group{
+			Name: "passman",
+			Groups: []Group{
+				{
+					Name: "google",
+					Entries: []Entry{
+						{Title: "main", UserName: "user@gmail.com", Password: "p@ss<word>&", OTP: "otpauth://totp/google:main?secret=JBSWY3DPEHPK3PXP"},
+						{Title: "work", UserName: "work@gmail.com", Password: ""},
+					},
+				},
+				{
+					Name:    "github",
+					Entries: []Entry{{Title: "main", UserName: "user", Password: "пароль", URL: "https://github.com", Notes: "line1\nline2"}},
+					Groups:  []Group{{Name: "nested", Entries: []Entry{{Title: "deep", UserName: "u", Password: "p"}}}},
+				},
+			},
+		},
+	}
+}
+
+func testOptions(c Cipher, kdf KDF) Options {
+	return Options{Cipher: c, KDF: kdf, Memory: 1024, Iterations: 1, Parallelism: 2}
+}
+
+func TestWriteRead(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "aes_argon2id", opts: testOptions(CipherAES256, KDFArgon2id)},
+		{name: "aes_argon2d", opts: testOptions(CipherAES256, KDFArgon2d)},
+		{name: "chacha20_argon2id", opts: testOptions(CipherChaCha20, KDFArgon2id)},
+		{name: "chacha20_argon2d", opts: testOptions(CipherChaCha20, KDFArgon2d)},
+	}
+
+	db := testDatabase()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			if err := Write(buf, db, "password", test.opts); err != nil {
+				t.Fatalf("failed writing database: %v", err)
+			}
+
+			if bytes.Contains(buf.Bytes(), []byte("user@gmail.com")) {
+				t.Fatalf("Wrong! Database is not encrypted!")
+			}
+
+			actDB, err := Read(bytes.NewReader(buf.Bytes()), "password")
+			if err != nil {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", nil, err)
+			}
+
+			if !reflect.DeepEqual(actDB, db) {
+				t.Fatalf("Wrong! Unexpected database!\n\tExpected: %+v\n\tActual: %+v", db, actDB)
+			}
+		})
+	}
+}
+
+func TestRead(t *testing.T) {
+	valid := bytes.NewBuffer(nil)
+	if err := Write(valid, testDatabase(), "password", testOptions(CipherAES256, KDFArgon2id)); err != nil {
+		t.Fatalf("failed writing database: %v", err)
+	}
+
+	tampered := bytes.Clone(valid.Bytes())
+	tampered[len(tampered)-50] ^= 0xFF
+
+	kdbx3 := bytes.Clone(valid.Bytes())
+	binary.LittleEndian.PutUint32(kdbx3[8:12], 3<<16|1)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		password string
+		expErr   error
+	}{
+		{name: "wrong_password", data: valid.Bytes(), password: "wrong", expErr: ErrWrongPassword},
+		{name: "tampered_block", data: tampered, password: "password", expErr: ErrCorrupted},
+		{name: "not_kdbx", data: []byte("<KeePassFile></KeePassFile>"), password: "password", expErr: ErrInvalidSignature},
+		{name: "kdbx3", data: kdbx3, password: "password", expErr: ErrUnsupportedVersion},
+		{name: "truncated", data: valid.Bytes()[:len(valid.Bytes())/2], password: "password", expErr: ErrCorrupted},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Read(bytes.NewReader(test.data), test.password)
+			if got, want := err, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestReadAESKDF(t *testing.T) {
+	h, err := newHeader(testOptions(CipherChaCha20, KDFArgon2id))
+	if err != nil {
+		t.Fatalf("failed creating header: %v", err)
+	}
+	h.kdf = kdfParams{uuid: kdfAES, salt: make([]byte, 32), rounds: 1000}
+	_, _ = rand.Read(h.kdf.salt)
+
+	db := testDatabase()
+
+	buf := bytes.NewBuffer(nil)
+	if err := write(buf, h, db, "password"); err != nil {
+		t.Fatalf("failed writing database: %v", err)
+	}
+
+	actDB, err := Read(bytes.NewReader(buf.Bytes()), "password")
+	if err != nil {
+		t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", nil, err)
+	}
+
+	if !reflect.DeepEqual(actDB, db) {
+		t.Fatalf("Wrong! Unexpected database!\n\tExpected: %+v\n\tActual: %+v", db, actDB)
+	}
+}
+
+func TestReadProtectedHistory(t *testing.T) {
+	stream := newInnerStream([]byte("inner key"))
+	protect := func(value string) string {
+		return newXMLString("", value, stream).Value.Content
+	}
+
+	doc := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<KeePassFile>
+	<Meta><DatabaseName>db</DatabaseName><RecycleBinEnabled>True</RecycleBinEnabled><RecycleBinUUID>AAAAAAAAAAAAAAAAAAAAAQ==</RecycleBinUUID></Meta>
+	<Root><Group><Name>Root</Name>
+		<Entry>
+			<String><Key>Title</Key><Value>first</Value></String>
+			<String><Key>Password</Key><Value Protected="True">` + protect("current") + `</Value></String>
+			<History><Entry><String><Key>Password</Key><Value Protected="True">` + protect("old") + `</Value></String></Entry></History>
+		</Entry>
+		<Group><Name>Bin</Name><UUID>AAAAAAAAAAAAAAAAAAAAAQ==</UUID>
+			<Entry><String><Key>Password</Key><Value Protected="True">` + protect("deleted") + `</Value></String></Entry>
+		</Group>
+		<Group><Name>Next</Name>
+			<Entry>
+				<String><Key>Title</Key><Value>second</Value></String>
+				<String><Key>Password</Key><Value Protected="True">` + protect("second password") + `</Value></String>
+				<String><Key>TimeOtp-Secret-Base32</Key><Value Protected="True">` + protect("JBSWY3DPEHPK3PXP") + `</Value></String>
+			</Entry>
+		</Group>
+	</Group></Root>
+</KeePassFile>`
+
+	db, err := decodeXML(strings.NewReader(doc), newInnerStream([]byte("inner key")))
+	if err != nil {
+		t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", nil, err)
+	}
+
+	want := Database{
+		Name: "db",
+		Root: Group{
+			Name:    "Root",
+			Entries: []Entry{{Title: "first", Password: "current"}},
+			Groups: []Group{
+				{Name: "Next", Entries: []Entry{{Title: "second", Password: "second password", OTP: "JBSWY3DPEHPK3PXP"}}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(db, want) {
+		t.Fatalf("Wrong! Unexpected database!\n\tExpected: %+v\n\tActual: %+v", want, db)
+	}
+}
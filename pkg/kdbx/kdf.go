@@ -0,0 +1,245 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"passman/pkg/argon2d"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2Version uint32 = 0x13
+
+// Limits of KDF parameters accepted from a file
+const (
+	maxArgon2Memory      = 1024 * 1024 * 1024
+	maxArgon2Iterations  = 256
+	maxArgon2Parallelism = 64
+	maxAESRounds         = 100_000_000
+)
+
+var (
+	kdfArgon2d  = mustUUID("ef636ddf8c29444b91f7a9a403e30a0c")
+	kdfArgon2id = mustUUID("9e298b1956db4773b23dfc3ec6f0a1e6")
+	kdfAES      = mustUUID("c9d9f39a628a4460bf740d08c18a4fea")
+)
+
+// Variant dictionary version and used value types
+const (
+	vdVersion          uint16 = 0x0100
+	vdTypeEnd          byte   = 0x00
+	vdTypeUInt32       byte   = 0x04
+	vdTypeUInt64       byte   = 0x05
+	vdTypeByteArray    byte   = 0x42
+	maxVariantDictSize        = 64 * 1024
+)
+
+type kdfParams struct {
+	uuid [16]byte
+	salt []byte
+	// Argon2 parameters, memory is in bytes
+	parallelism uint32
+	memory      uint64
+	iterations  uint64
+	version     uint32
+	// AES-KDF rounds, salt is the seed
+	rounds uint64
+}
+
+func newKDFParams(opts Options) (kdfParams, error) {
+	p := kdfParams{
+		salt:        make([]byte, 32),
+		parallelism: uint32(opts.Parallelism),
+		memory:      uint64(opts.Memory) * 1024,
+		iterations:  uint64(opts.Iterations),
+		version:     argon2Version,
+	}
+
+	switch opts.KDF {
+	case KDFArgon2d:
+		p.uuid = kdfArgon2d
+	case KDFArgon2id:
+		p.uuid = kdfArgon2id
+	default:
+		return kdfParams{}, fmt.Errorf("unsupported kdf %q", opts.KDF)
+	}
+
+	if err := p.validate(); err != nil {
+		return kdfParams{}, err
+	}
+
+	if _, err := rand.Read(p.salt); err != nil {
+		return kdfParams{}, err
+	}
+
+	return p, nil
+}
+
+func (p kdfParams) validate() error {
+	switch p.uuid {
+	case kdfArgon2d, kdfArgon2id:
+		if p.version != argon2Version {
+			return fmt.Errorf("unsupported argon2 version %#x", p.version)
+		}
+		if p.memory < 8*1024 || p.memory > maxArgon2Memory ||
+			p.iterations < 1 || p.iterations > maxArgon2Iterations ||
+			p.parallelism < 1 || p.parallelism > maxArgon2Parallelism {
+			return fmt.Errorf("unsupported argon2 parameters")
+		}
+	case kdfAES:
+		if len(p.salt) != 32 || p.rounds > maxAESRounds {
+			return fmt.Errorf("unsupported aes-kdf parameters")
+		}
+	default:
+		return fmt.Errorf("unsupported kdf")
+	}
+
+	return nil
+}
+
+// transform derives the transformed key from the composite key
+func (p kdfParams) transform(key []byte) ([]byte, error) {
+	switch p.uuid {
+	case kdfArgon2d:
+		return argon2d.Key(key, p.salt, uint32(p.iterations), uint32(p.memory/1024), uint8(p.parallelism), 32), nil
+	case kdfArgon2id:
+		return argon2.IDKey(key, p.salt, uint32(p.iterations), uint32(p.memory/1024), uint8(p.parallelism), 32), nil
+	case kdfAES:
+		block, err := aes.NewCipher(p.salt)
+		if err != nil {
+			return nil, err
+		}
+		transformed := bytes.Clone(key)
+		for range p.rounds {
+			block.Encrypt(transformed[:16], transformed[:16])
+			block.Encrypt(transformed[16:], transformed[16:])
+		}
+		return sha256Sum(transformed), nil
+	default:
+		return nil, fmt.Errorf("unsupported kdf")
+	}
+}
+
+func (p kdfParams) marshal() []byte {
+	buf := bytes.NewBuffer(nil)
+	_ = binary.Write(buf, binary.LittleEndian, vdVersion)
+
+	writeVariant(buf, vdTypeByteArray, "$UUID", p.uuid[:])
+	writeVariant(buf, vdTypeByteArray, "S", p.salt)
+	if p.uuid == kdfAES {
+		writeVariant(buf, vdTypeUInt64, "R", binary.LittleEndian.AppendUint64(nil, p.rounds))
+	} else {
+		writeVariant(buf, vdTypeUInt32, "P", binary.LittleEndian.AppendUint32(nil, p.parallelism))
+		writeVariant(buf, vdTypeUInt64, "M", binary.LittleEndian.AppendUint64(nil, p.memory))
+		writeVariant(buf, vdTypeUInt64, "I", binary.LittleEndian.AppendUint64(nil, p.iterations))
+		writeVariant(buf, vdTypeUInt32, "V", binary.LittleEndian.AppendUint32(nil, p.version))
+	}
+	buf.WriteByte(vdTypeEnd)
+
+	return buf.Bytes()
+}
+
+func parseKDFParams(data []byte) (kdfParams, error) {
+	if len(data) < 2 || len(data) > maxVariantDictSize {
+		return kdfParams{}, ErrCorrupted
+	}
+	if binary.LittleEndian.Uint16(data[:2])&0xFF00 != vdVersion&0xFF00 {
+		return kdfParams{}, fmt.Errorf("unsupported kdf parameters version")
+	}
+
+	var p kdfParams
+	offset := 2
+	for {
+		if len(data) < offset+1 {
+			return kdfParams{}, ErrCorrupted
+		}
+		typ := data[offset]
+		offset++
+		if typ == vdTypeEnd {
+			break
+		}
+
+		key, n, ok := readSized(data[offset:])
+		if !ok {
+			return kdfParams{}, ErrCorrupted
+		}
+		offset += n
+		value, n, ok := readSized(data[offset:])
+		if !ok {
+			return kdfParams{}, ErrCorrupted
+		}
+		offset += n
+
+		switch string(key) {
+		case "$UUID":
+			if typ != vdTypeByteArray || len(value) != 16 {
+				return kdfParams{}, ErrCorrupted
+			}
+			copy(p.uuid[:], value)
+		case "S":
+			p.salt = value
+		case "P":
+			if typ != vdTypeUInt32 || len(value) != 4 {
+				return kdfParams{}, ErrCorrupted
+			}
+			p.parallelism = binary.LittleEndian.Uint32(value)
+		case "V":
+			if typ != vdTypeUInt32 || len(value) != 4 {
+				return kdfParams{}, ErrCorrupted
+			}
+			p.version = binary.LittleEndian.Uint32(value)
+		case "M", "I", "R":
+			if typ != vdTypeUInt64 || len(value) != 8 {
+				return kdfParams{}, ErrCorrupted
+			}
+			v := binary.LittleEndian.Uint64(value)
+			switch string(key) {
+			case "M":
+				p.memory = v
+			case "I":
+				p.iterations = v
+			default:
+				p.rounds = v
+			}
+		}
+	}
+
+	if err := p.validate(); err != nil {
+		return kdfParams{}, err
+	}
+
+	return p, nil
+}
+
+func writeVariant(buf *bytes.Buffer, typ byte, key string, value []byte) {
+	buf.WriteByte(typ)
+	buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(key))))
+	buf.WriteString(key)
+	buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(value))))
+	buf.Write(value)
+}
+
+// readSized reads the value prefixed by its int32 size
+func readSized(data []byte) ([]byte, int, bool) {
+	if len(data) < 4 {
+		return nil, 0, false
+	}
+	size := binary.LittleEndian.Uint32(data[:4])
+	if size > uint32(len(data)-4) {
+		return nil, 0, false
+	}
+	return data[4 : 4+size], 4 + int(size), true
+}
+
+func mustUUID(s string) [16]byte {
+	var id [16]byte
+	if n, err := hex.Decode(id[:], []byte(s)); err != nil || n != 16 {
+		panic("kdbx: invalid uuid " + s)
+	}
+	return id
+}
@@ -0,0 +1,300 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Generator written to the database meta
+const Generator = "passman"
+
+// Seconds between 0001-01-01 and 1970-01-01
+const unixEpochOffset = 62135596800
+
+type xmlFile struct {
+	XMLName xml.Name `xml:"KeePassFile"`
+	Meta    xmlMeta  `xml:"Meta"`
+	Root    struct {
+		Group xmlGroup `xml:"Group"`
+	} `xml:"Root"`
+}
+
+type xmlMeta struct {
+	Generator           string `xml:"Generator"`
+	DatabaseName        string `xml:"DatabaseName"`
+	DatabaseNameChanged string `xml:"DatabaseNameChanged"`
+	MemoryProtection    struct {
+		ProtectTitle    string `xml:"ProtectTitle"`
+		ProtectUserName string `xml:"ProtectUserName"`
+		ProtectPassword string `xml:"ProtectPassword"`
+		ProtectURL      string `xml:"ProtectURL"`
+		ProtectNotes    string `xml:"ProtectNotes"`
+	} `xml:"MemoryProtection"`
+	RecycleBinEnabled string `xml:"RecycleBinEnabled"`
+	RecycleBinUUID    string `xml:"RecycleBinUUID"`
+}
+
+type xmlGroup struct {
+	UUID       string     `xml:"UUID"`
+	Name       string     `xml:"Name"`
+	Times      xmlTimes   `xml:"Times"`
+	IsExpanded string     `xml:"IsExpanded"`
+	Entries    []xmlEntry `xml:"Entry"`
+	Groups     []xmlGroup `xml:"Group"`
+}
+
+type xmlEntry struct {
+	UUID    string      `xml:"UUID"`
+	Times   xmlTimes    `xml:"Times"`
+	Strings []xmlString `xml:"String"`
+}
+
+type xmlString struct {
+	Key   string `xml:"Key"`
+	Value struct {
+		Protected string `xml:"Protected,attr,omitempty"`
+		Content   string `xml:",chardata"`
+	} `xml:"Value"`
+}
+
+type xmlTimes struct {
+	CreationTime         string `xml:"CreationTime"`
+	LastModificationTime string `xml:"LastModificationTime"`
+	LastAccessTime       string `xml:"LastAccessTime"`
+	ExpiryTime           string `xml:"ExpiryTime"`
+	Expires              string `xml:"Expires"`
+	UsageCount           int    `xml:"UsageCount"`
+	LocationChanged      string `xml:"LocationChanged"`
+}
+
+// encodeXML writes the database XML. Passwords and OTP are protected by the
+// inner stream in the document order.
+func encodeXML(w io.Writer, db Database, stream cipher.Stream) error {
+	now := encodeTime(time.Now())
+
+	var file xmlFile
+	file.Meta.Generator = Generator
+	file.Meta.DatabaseName = db.Name
+	file.Meta.DatabaseNameChanged = now
+	file.Meta.MemoryProtection.ProtectTitle = xmlFalse
+	file.Meta.MemoryProtection.ProtectUserName = xmlFalse
+	file.Meta.MemoryProtection.ProtectPassword = xmlTrue
+	file.Meta.MemoryProtection.ProtectURL = xmlFalse
+	file.Meta.MemoryProtection.ProtectNotes = xmlFalse
+	file.Meta.RecycleBinEnabled = xmlFalse
+	file.Meta.RecycleBinUUID = encodeUUID(uuid.Nil)
+	file.Root.Group = newXMLGroup(db.Root, now, stream)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	return enc.Encode(file)
+}
+
+// newXMLGroup converts the group. Entries are converted before subgroups
+// because they are encoded in this order.
+func newXMLGroup(group Group, now string, stream cipher.Stream) xmlGroup {
+	res := xmlGroup{
+		UUID:       encodeUUID(uuid.New()),
+		Name:       group.Name,
+		Times:      newXMLTimes(now),
+		IsExpanded: xmlTrue,
+	}
+
+	for _, entry := range group.Entries {
+		xe := xmlEntry{UUID: encodeUUID(uuid.New()), Times: newXMLTimes(now)}
+		xe.Strings = append(xe.Strings,
+			newXMLString("Title", entry.Title, nil),
+			newXMLString("UserName", entry.UserName, nil),
+			newXMLString("Password", entry.Password, stream),
+			newXMLString("URL", entry.URL, nil),
+			newXMLString("Notes", entry.Notes, nil),
+		)
+		if len(entry.OTP) > 0 {
+			xe.Strings = append(xe.Strings, newXMLString("otp", entry.OTP, stream))
+		}
+		res.Entries = append(res.Entries, xe)
+	}
+
+	for _, child := range group.Groups {
+		res.Groups = append(res.Groups, newXMLGroup(child, now, stream))
+	}
+
+	return res
+}
+
+// newXMLString returns the string field protected by stream if it's not nil
+func newXMLString(key, value string, stream cipher.Stream) xmlString {
+	s := xmlString{Key: key}
+	if stream == nil {
+		s.Value.Content = value
+		return s
+	}
+
+	protected := []byte(value)
+	stream.XORKeyStream(protected, protected)
+	s.Value.Protected = xmlTrue
+	s.Value.Content = base64.StdEncoding.EncodeToString(protected)
+
+	return s
+}
+
+func newXMLTimes(now string) xmlTimes {
+	return xmlTimes{
+		CreationTime:         now,
+		LastModificationTime: now,
+		LastAccessTime:       now,
+		ExpiryTime:           now,
+		Expires:              xmlFalse,
+		LocationChanged:      now,
+	}
+}
+
+// decodeXML reads the database XML. Protected values are decrypted in
+// a first pass because the inner stream depends on the document order.
+func decodeXML(r io.Reader, stream cipher.Stream) (Database, error) {
+	plain, err := unprotectXML(r, stream)
+	if err != nil {
+		return Database{}, err
+	}
+
+	var file xmlFile
+	if err := xml.Unmarshal(plain, &file); err != nil {
+		return Database{}, err
+	}
+
+	recycleBin := ""
+	if !strings.EqualFold(file.Meta.RecycleBinEnabled, xmlFalse) && file.Meta.RecycleBinUUID != encodeUUID(uuid.Nil) {
+		recycleBin = file.Meta.RecycleBinUUID
+	}
+
+	return Database{
+		Name:      file.Meta.DatabaseName,
+		Generator: file.Meta.Generator,
+		Root:      convertXMLGroup(file.Root.Group, recycleBin),
+	}, nil
+}
+
+// unprotectXML copies the document replacing protected values by plain text
+func unprotectXML(r io.Reader, stream cipher.Stream) ([]byte, error) {
+	dec := xml.NewDecoder(r)
+	buf := bytes.NewBuffer(nil)
+	enc := xml.NewEncoder(buf)
+
+	protected := false
+	for {
+		token, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			protected = t.Name.Local == "Value" && isProtected(t.Attr)
+			if protected {
+				// The value is decrypted below
+				t.Attr = nil
+			}
+			token = t
+		case xml.EndElement:
+			protected = false
+		case xml.CharData:
+			if !protected {
+				break
+			}
+			value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(t)))
+			if err != nil {
+				return nil, err
+			}
+			stream.XORKeyStream(value, value)
+			token = xml.CharData(value)
+		case xml.ProcInst, xml.Directive:
+			continue
+		}
+
+		if err := enc.EncodeToken(token); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func isProtected(attrs []xml.Attr) bool {
+	for _, attr := range attrs {
+		if attr.Name.Local == "Protected" && strings.EqualFold(attr.Value, xmlTrue) {
+			return true
+		}
+	}
+	return false
+}
+
+func convertXMLGroup(group xmlGroup, recycleBin string) Group {
+	res := Group{Name: group.Name}
+
+	for _, xe := range group.Entries {
+		var entry Entry
+		for _, s := range xe.Strings {
+			switch s.Key {
+			case "Title":
+				entry.Title = s.Value.Content
+			case "UserName":
+				entry.UserName = s.Value.Content
+			case "Password":
+				entry.Password = s.Value.Content
+			case "URL":
+				entry.URL = s.Value.Content
+			case "Notes":
+				entry.Notes = s.Value.Content
+			case "otp":
+				entry.OTP = s.Value.Content
+			case "TimeOtp-Secret-Base32":
+				if len(entry.OTP) == 0 {
+					entry.OTP = s.Value.Content
+				}
+			}
+		}
+		res.Entries = append(res.Entries, entry)
+	}
+
+	for _, child := range group.Groups {
+		if len(recycleBin) > 0 && child.UUID == recycleBin {
+			continue
+		}
+		res.Groups = append(res.Groups, convertXMLGroup(child, recycleBin))
+	}
+
+	return res
+}
+
+const (
+	xmlTrue  = "True"
+	xmlFalse = "False"
+)
+
+func encodeUUID(id uuid.UUID) string {
+	return base64.StdEncoding.EncodeToString(id[:])
+}
+
+// encodeTime encodes time as KDBX 4 does: base64 of seconds since 0001-01-01
+func encodeTime(t time.Time) string {
+	return base64.StdEncoding.EncodeToString(binary.LittleEndian.AppendUint64(nil, uint64(t.Unix()+unixEpochOffset)))
+}
@@ -0,0 +1,372 @@
+package ldap
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"passman/pkg/ldap/internal/ber"
+)
+
+// Application tags of protocol operations
+const (
+	opBindRequest      = 0
+	opBindResponse     = 1
+	opUnbindRequest    = 2
+	opSearchRequest    = 3
+	opSearchEntry      = 4
+	opSearchDone       = 5
+	opSearchReference  = 19
+	opExtendedRequest  = 23
+	opExtendedResponse = 24
+)
+
+const (
+	protocolVersion  = 3
+	startTLSOID      = "1.3.6.1.4.1.1466.20037"
+	defaultLDAPPort  = "389"
+	defaultLDAPSPort = "636"
+	// noAttributes requested by the search returns only DNs
+	noAttributes = "1.1"
+)
+
+// Result codes used by the package
+const (
+	ResultSuccess            = 0
+	ResultSizeLimitExceeded  = 4
+	ResultNoSuchObject       = 32
+	ResultInvalidCredentials = 49
+	ResultInsufficientAccess = 50
+	ResultUnavailable        = 52
+	ResultProtocolError      = 2
+)
+
+// Error is the result of the operation other than success
+type Error struct {
+	Code    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	if len(e.Message) == 0 {
+		return fmt.Sprintf("ldap result code %d", e.Code)
+	}
+	return fmt.Sprintf("ldap result code %d: %s", e.Code, e.Message)
+}
+
+// IsCode reports whether err is the result with the code
+func IsCode(err error, code int) bool {
+	var ldapErr *Error
+	return errors.As(err, &ldapErr) && ldapErr.Code == code
+}
+
+// Search scopes
+const (
+	ScopeBaseObject   = 0
+	ScopeSingleLevel  = 1
+	ScopeWholeSubtree = 2
+)
+
+type SearchRequest struct {
+	BaseDN string
+	Scope  int
+	// Filter is the string representation of RFC 4515, substring and
+	// ordering matches aren't supported
+	Filter string
+	// Attributes are returned by entries, empty returns all of them
+	Attributes []string
+	// SizeLimit of entries, zero is the limit of the server
+	SizeLimit int
+}
+
+// Entry of the search result. Attribute names are case-insensitive.
+type Entry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+// Values returns values of the attribute
+func (e Entry) Values(name string) []string {
+	return e.Attributes[strings.ToLower(name)]
+}
+
+// Value returns the first value of the attribute or the empty string
+func (e Entry) Value(name string) string {
+	if values := e.Values(name); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Conn is the connection to the server. Operations are sent one by one, Conn
+// isn't safe for concurrent use.
+type Conn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	// host of the URL is verified by StartTLS
+	host  string
+	msgID int64
+	// stop detaches the context of Dial
+	stop func() bool
+}
+
+// Dial connects to the ldap:// or ldaps:// URL. The deadline of ctx applies
+// to all operations of the connection, the cancellation interrupts them.
+func Dial(ctx context.Context, rawURL string, tlsConfig *tls.Config) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	host := u.Host
+	switch u.Scheme {
+	case "ldap":
+		if len(u.Port()) == 0 {
+			host = net.JoinHostPort(u.Hostname(), defaultLDAPPort)
+		}
+	case "ldaps":
+		if len(u.Port()) == 0 {
+			host = net.JoinHostPort(u.Hostname(), defaultLDAPSPort)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "ldaps" {
+		tlsConn := tls.Client(conn, withServerName(tlsConfig, u.Hostname()))
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	c := &Conn{conn: conn, r: bufio.NewReader(conn), host: u.Hostname()}
+	// The past deadline unblocks reads and writes
+	c.stop = context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Unix(1, 0)) })
+
+	return c, nil
+}
+
+func withServerName(cfg *tls.Config, host string) *tls.Config {
+	if cfg == nil {
+		cfg = &tls.Config{}
+	}
+	cfg = cfg.Clone()
+	if len(cfg.ServerName) == 0 {
+		cfg.ServerName = host
+	}
+	if cfg.MinVersion == 0 {
+		cfg.MinVersion = tls.VersionTLS12
+	}
+	return cfg
+}
+
+// StartTLS upgrades the plain connection, the certificate is verified for
+// tlsConfig.ServerName or the host of the URL
+func (c *Conn) StartTLS(tlsConfig *tls.Config) error {
+	if _, ok := c.conn.(*tls.Conn); ok {
+		return errors.New("connection is already encrypted")
+	}
+
+	req := ber.Constructed(ber.ClassApplication, opExtendedRequest,
+		ber.Primitive(ber.ClassContext, 0, []byte(startTLSOID)),
+	)
+	resp, err := c.do(req, opExtendedResponse)
+	if err != nil {
+		return err
+	}
+	if err := parseResult(resp); err != nil {
+		return err
+	}
+
+	tlsConn := tls.Client(c.conn, withServerName(tlsConfig, c.host))
+	if err := tlsConn.Handshake(); err != nil {
+		return err
+	}
+	c.conn = tlsConn
+	c.r = bufio.NewReader(tlsConn)
+	return nil
+}
+
+// Bind authenticates the connection by the simple bind. The empty password is
+// the unauthenticated bind of RFC 4513 section 5.1.2 and is refused, servers
+// accept it for any DN.
+func (c *Conn) Bind(dn, password string) error {
+	if len(password) == 0 && len(dn) > 0 {
+		return &Error{Code: ResultInvalidCredentials, Message: "empty password"}
+	}
+
+	req := ber.Constructed(ber.ClassApplication, opBindRequest,
+		ber.Integer(protocolVersion),
+		ber.OctetString(dn),
+		ber.Primitive(ber.ClassContext, 0, []byte(password)),
+	)
+	resp, err := c.do(req, opBindResponse)
+	if err != nil {
+		return err
+	}
+	return parseResult(resp)
+}
+
+// Search returns found entries. The error of the result is returned with
+// entries received before it, e.g. of the exceeded size limit.
+func (c *Conn) Search(req SearchRequest) ([]Entry, error) {
+	filter, err := compileFilter(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes := make([]*ber.Packet, 0, len(req.Attributes))
+	for _, attr := range req.Attributes {
+		attributes = append(attributes, ber.OctetString(attr))
+	}
+
+	msgID, err := c.send(ber.Constructed(ber.ClassApplication, opSearchRequest,
+		ber.OctetString(req.BaseDN),
+		ber.Enumerated(int64(req.Scope)),
+		// Aliases aren't dereferenced
+		ber.Enumerated(0),
+		ber.Integer(int64(req.SizeLimit)),
+		ber.Integer(0),
+		ber.Boolean(false),
+		filter,
+		ber.Sequence(attributes...),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for {
+		op, err := c.receive(msgID)
+		if err != nil {
+			return entries, err
+		}
+
+		switch {
+		case op.Is(ber.ClassApplication, opSearchEntry):
+			entry, err := parseEntry(op)
+			if err != nil {
+				return entries, err
+			}
+			entries = append(entries, entry)
+		case op.Is(ber.ClassApplication, opSearchReference):
+			// Referrals to other servers aren't followed
+		case op.Is(ber.ClassApplication, opSearchDone):
+			return entries, parseResult(op)
+		default:
+			return entries, fmt.Errorf("%w: unexpected search response", ber.ErrMalformed)
+		}
+	}
+}
+
+// Close sends the unbind request and closes the connection
+func (c *Conn) Close() error {
+	c.stop()
+	_, _ = c.send(ber.Primitive(ber.ClassApplication, opUnbindRequest, nil))
+	return c.conn.Close()
+}
+
+// do sends the request and receives the response with the tag
+func (c *Conn) do(req *ber.Packet, respTag byte) (*ber.Packet, error) {
+	msgID, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.receive(msgID)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Is(ber.ClassApplication, respTag) {
+		return nil, fmt.Errorf("%w: unexpected response", ber.ErrMalformed)
+	}
+	return resp, nil
+}
+
+func (c *Conn) send(op *ber.Packet) (int64, error) {
+	c.msgID++
+	msg := ber.Sequence(ber.Integer(c.msgID), op)
+	if _, err := c.conn.Write(msg.Bytes()); err != nil {
+		return 0, err
+	}
+	return c.msgID, nil
+}
+
+// receive returns the protocol operation of the response to msgID
+func (c *Conn) receive(msgID int64) (*ber.Packet, error) {
+	msg, err := ber.ReadPacket(c.r)
+	if err != nil {
+		return nil, err
+	}
+	if !msg.Is(ber.ClassUniversal, ber.TagSequence) || len(msg.Children) < 2 {
+		return nil, ber.ErrMalformed
+	}
+	id, err := msg.Children[0].Int()
+	if err != nil {
+		return nil, err
+	}
+
+	op := msg.Children[1]
+	// The notice of disconnection is the unsolicited response
+	if id == 0 && op.Is(ber.ClassApplication, opExtendedResponse) {
+		if err := parseResult(op); err != nil {
+			return nil, err
+		}
+		return nil, &Error{Code: ResultUnavailable, Message: "server closed the connection"}
+	}
+	if id != msgID {
+		return nil, fmt.Errorf("%w: unexpected message id %d", ber.ErrMalformed, id)
+	}
+	return op, nil
+}
+
+// parseResult returns *Error for the LDAPResult other than success
+func parseResult(op *ber.Packet) error {
+	if len(op.Children) < 3 {
+		return ber.ErrMalformed
+	}
+	code, err := op.Children[0].Int()
+	if err != nil {
+		return err
+	}
+	if code == ResultSuccess {
+		return nil
+	}
+	return &Error{Code: int(code), Message: string(op.Children[2].Value)}
+}
+
+func parseEntry(op *ber.Packet) (Entry, error) {
+	if len(op.Children) < 2 {
+		return Entry{}, ber.ErrMalformed
+	}
+
+	entry := Entry{
+		DN:         string(op.Children[0].Value),
+		Attributes: make(map[string][]string),
+	}
+	for _, attr := range op.Children[1].Children {
+		if len(attr.Children) < 2 {
+			return Entry{}, ber.ErrMalformed
+		}
+		name := strings.ToLower(string(attr.Children[0].Value))
+		for _, value := range attr.Children[1].Children {
+			entry.Attributes[name] = append(entry.Attributes[name], string(value.Value))
+		}
+	}
+	return entry, nil
+}
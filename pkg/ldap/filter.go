@@ -0,0 +1,172 @@
+package ldap
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"passman/pkg/ldap/internal/ber"
+)
+
+// Context tags of filter choices
+const (
+	filterAnd      = 0
+	filterOr       = 1
+	filterNot      = 2
+	filterEquality = 3
+	filterPresent  = 7
+)
+
+var errInvalidFilter = errors.New("invalid filter")
+
+// EscapeFilter escapes the value for the filter, otherwise the username like
+// "*)(uid=*" would change the filter
+func EscapeFilter(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '*', '(', ')', '\\', 0:
+			fmt.Fprintf(&b, "\\%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// compileFilter encodes the filter string. And, or, not, equality and
+// presence filters are supported.
+func compileFilter(filter string) (*ber.Packet, error) {
+	p := &filterParser{s: filter}
+	packet, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.s) {
+		return nil, fmt.Errorf("%w: unexpected %q at %d", errInvalidFilter, p.s[p.pos:], p.pos)
+	}
+	return packet, nil
+}
+
+type filterParser struct {
+	s   string
+	pos int
+}
+
+func (p *filterParser) parse() (*ber.Packet, error) {
+	if !p.consume('(') {
+		return nil, fmt.Errorf("%w: expected ( at %d", errInvalidFilter, p.pos)
+	}
+	if p.pos >= len(p.s) {
+		return nil, fmt.Errorf("%w: unexpected end", errInvalidFilter)
+	}
+
+	var (
+		packet *ber.Packet
+		err    error
+	)
+	switch p.s[p.pos] {
+	case '&':
+		p.pos++
+		packet, err = p.parseSet(filterAnd)
+	case '|':
+		p.pos++
+		packet, err = p.parseSet(filterOr)
+	case '!':
+		p.pos++
+		var inner *ber.Packet
+		if inner, err = p.parse(); err == nil {
+			packet = ber.Constructed(ber.ClassContext, filterNot, inner)
+		}
+	default:
+		packet, err = p.parseItem()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.consume(')') {
+		return nil, fmt.Errorf("%w: expected ) at %d", errInvalidFilter, p.pos)
+	}
+	return packet, nil
+}
+
+func (p *filterParser) parseSet(tag byte) (*ber.Packet, error) {
+	set := ber.Constructed(ber.ClassContext, tag)
+	for p.pos < len(p.s) && p.s[p.pos] == '(' {
+		child, err := p.parse()
+		if err != nil {
+			return nil, err
+		}
+		set.Children = append(set.Children, child)
+	}
+	if len(set.Children) == 0 {
+		return nil, fmt.Errorf("%w: empty set at %d", errInvalidFilter, p.pos)
+	}
+	return set, nil
+}
+
+func (p *filterParser) parseItem() (*ber.Packet, error) {
+	end := strings.IndexByte(p.s[p.pos:], ')')
+	if end < 0 {
+		return nil, fmt.Errorf("%w: unexpected end", errInvalidFilter)
+	}
+	item := p.s[p.pos : p.pos+end]
+
+	attr, value, ok := strings.Cut(item, "=")
+	if !ok || len(attr) == 0 || strings.ContainsAny(attr, "()") {
+		return nil, fmt.Errorf("%w: invalid item %q", errInvalidFilter, item)
+	}
+	if strings.ContainsAny(attr[len(attr)-1:], "<>~:") {
+		return nil, fmt.Errorf("%w: only equality and presence are supported", errInvalidFilter)
+	}
+	p.pos += end
+
+	if value == "*" {
+		return ber.Primitive(ber.ClassContext, filterPresent, []byte(attr)), nil
+	}
+	if strings.ContainsAny(value, "*(") {
+		return nil, fmt.Errorf("%w: substrings aren't supported, escape * in %q", errInvalidFilter, item)
+	}
+	unescaped, err := unescapeFilter(value)
+	if err != nil {
+		return nil, err
+	}
+	return ber.Constructed(ber.ClassContext, filterEquality,
+		ber.OctetString(attr),
+		ber.OctetString(unescaped),
+	), nil
+}
+
+func (p *filterParser) consume(c byte) bool {
+	if p.pos < len(p.s) && p.s[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func unescapeFilter(value string) (string, error) {
+	if !strings.Contains(value, `\`) {
+		return value, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' {
+			b.WriteByte(value[i])
+			continue
+		}
+		if i+3 > len(value) {
+			return "", fmt.Errorf("%w: truncated escape in %q", errInvalidFilter, value)
+		}
+		decoded, err := hex.DecodeString(value[i+1 : i+3])
+		if err != nil {
+			return "", fmt.Errorf("%w: invalid escape in %q", errInvalidFilter, value)
+		}
+		b.Write(decoded)
+		i += 2
+	}
+	return b.String(), nil
+}
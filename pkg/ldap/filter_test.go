@@ -0,0 +1,61 @@
+package ldap
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"passman/pkg/ldap/internal/ber"
+)
+
+func TestCompileFilter(t *testing.T) {
+	equality := func(attr, value string) *ber.Packet {
+		return ber.Constructed(ber.ClassContext, filterEquality, ber.OctetString(attr), ber.OctetString(value))
+	}
+
+	tests := []struct {
+		name      string
+		filter    string
+		expPacket *ber.Packet
+		expErr    error
+	}{
+		{name: "equality", filter: "(uid=alice)", expPacket: equality("uid", "alice")},
+		{name: "present", filter: "(objectClass=*)", expPacket: ber.Primitive(ber.ClassContext, filterPresent, []byte("objectClass"))},
+		{
+			name:   "and_or_not",
+			filter: "(&(uid=alice)(|(ou=a)(!(ou=b))))",
+			expPacket: ber.Constructed(ber.ClassContext, filterAnd,
+				equality("uid", "alice"),
+				ber.Constructed(ber.ClassContext, filterOr,
+					equality("ou", "a"),
+					ber.Constructed(ber.ClassContext, filterNot, equality("ou", "b")),
+				),
+			),
+		},
+		{name: "escaped", filter: "(cn=" + EscapeFilter("a*(b)\\") + ")", expPacket: equality("cn", "a*(b)\\")},
+		{name: "substring", filter: "(uid=al*)", expErr: errInvalidFilter},
+		{name: "ordering", filter: "(age>=18)", expErr: errInvalidFilter},
+		{name: "unbalanced", filter: "(uid=alice", expErr: errInvalidFilter},
+		{name: "trailing", filter: "(uid=alice)(uid=bob)", expErr: errInvalidFilter},
+		{name: "empty_and", filter: "(&)", expErr: errInvalidFilter},
+		{name: "no_attribute", filter: "(=alice)", expErr: errInvalidFilter},
+		{name: "truncated_escape", filter: "(uid=a\\2)", expErr: errInvalidFilter},
+		{name: "injected", filter: "(uid=*)(uid=*)", expErr: errInvalidFilter},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			packet, err := compileFilter(test.filter)
+
+			if got, want := err, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if test.expErr != nil {
+				return
+			}
+			if got, want := packet.Bytes(), test.expPacket.Bytes(); !bytes.Equal(got, want) {
+				t.Errorf("Wrong! Unexpected packet!\n\tExpected: %x\n\tActual: %x", want, got)
+			}
+		})
+	}
+}
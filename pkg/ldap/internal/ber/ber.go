@@ -0,0 +1,226 @@
+// Package ber implements the subset of ASN.1 BER used by LDAP (RFC 4511
+// section 5.1): definite lengths and tags below 31
+package ber
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Classes of BER tags
+const (
+	ClassUniversal   = 0x00
+	ClassApplication = 0x40
+	ClassContext     = 0x80
+)
+
+// Universal tags used by LDAP
+const (
+	TagBoolean     = 0x01
+	TagInteger     = 0x02
+	TagOctetString = 0x04
+	TagNull        = 0x05
+	TagEnumerated  = 0x0a
+	TagSequence    = 0x10
+	TagSet         = 0x11
+)
+
+// MaxPacketSize limits elements read from the peer
+const MaxPacketSize = 1 << 20
+
+var ErrMalformed = errors.New("malformed ber packet")
+
+// Packet is the BER element. Constructed packets have children, primitive ones
+// have the value. Only the definite length and tags below 31 are used by LDAP.
+type Packet struct {
+	Class       byte
+	Constructed bool
+	Tag         byte
+	Value       []byte
+	Children    []*Packet
+}
+
+// Is reports whether the packet has the class and the tag
+func (p *Packet) Is(class, tag byte) bool {
+	return p.Class == class && p.Tag == tag
+}
+
+func Primitive(class, tag byte, value []byte) *Packet {
+	return &Packet{Class: class, Tag: tag, Value: value}
+}
+
+func Constructed(class, tag byte, children ...*Packet) *Packet {
+	return &Packet{Class: class, Constructed: true, Tag: tag, Children: children}
+}
+
+func Sequence(children ...*Packet) *Packet {
+	return Constructed(ClassUniversal, TagSequence, children...)
+}
+
+func OctetString(s string) *Packet {
+	return Primitive(ClassUniversal, TagOctetString, []byte(s))
+}
+
+func Boolean(b bool) *Packet {
+	if b {
+		return Primitive(ClassUniversal, TagBoolean, []byte{0xff})
+	}
+	return Primitive(ClassUniversal, TagBoolean, []byte{0x00})
+}
+
+func Integer(n int64) *Packet {
+	return Primitive(ClassUniversal, TagInteger, encodeInt(n))
+}
+
+func Enumerated(n int64) *Packet {
+	return Primitive(ClassUniversal, TagEnumerated, encodeInt(n))
+}
+
+// encodeInt is the minimal two's complement big-endian encoding
+func encodeInt(n int64) []byte {
+	b := []byte{byte(n)}
+	for n > 127 || n < -128 {
+		n >>= 8
+		b = append([]byte{byte(n)}, b...)
+	}
+	return b
+}
+
+// Int decodes INTEGER and ENUMERATED values
+func (p *Packet) Int() (int64, error) {
+	if p.Constructed || len(p.Value) == 0 || len(p.Value) > 8 {
+		return 0, ErrMalformed
+	}
+	// Sign extension of the first byte
+	n := int64(int8(p.Value[0]))
+	for _, b := range p.Value[1:] {
+		n = n<<8 | int64(b)
+	}
+	return n, nil
+}
+
+// Bytes encodes the packet
+func (p *Packet) Bytes() []byte {
+	out := []byte{p.Class | p.Tag}
+	if p.Constructed {
+		out[0] |= 0x20
+	}
+
+	content := p.Value
+	if p.Constructed {
+		content = nil
+		for _, child := range p.Children {
+			content = append(content, child.Bytes()...)
+		}
+	}
+
+	return append(append(out, encodeLength(len(content))...), content...)
+}
+
+func encodeLength(n int) []byte {
+	if n < 128 {
+		return []byte{byte(n)}
+	}
+	var b []byte
+	for ; n > 0; n >>= 8 {
+		b = append([]byte{byte(n)}, b...)
+	}
+	return append([]byte{0x80 | byte(len(b))}, b...)
+}
+
+// ReadPacket reads one element from the stream
+func ReadPacket(r *bufio.Reader) (*Packet, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	length, err := readLength(r)
+	if err != nil {
+		return nil, err
+	}
+	if length > MaxPacketSize {
+		return nil, fmt.Errorf("%w: %d bytes exceed the limit", ErrMalformed, length)
+	}
+
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return nil, err
+	}
+	return parsePacket(header, content)
+}
+
+func readLength(r io.ByteReader) (int, error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if first < 0x80 {
+		return int(first), nil
+	}
+	// The indefinite length isn't allowed by LDAP
+	size := int(first & 0x7f)
+	if size == 0 || size > 4 {
+		return 0, ErrMalformed
+	}
+	length := 0
+	for range size {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		length = length<<8 | int(b)
+	}
+	return length, nil
+}
+
+func parsePacket(header byte, content []byte) (*Packet, error) {
+	p := &Packet{
+		Class:       header & 0xc0,
+		Constructed: header&0x20 != 0,
+		Tag:         header & 0x1f,
+	}
+	if p.Tag == 0x1f {
+		return nil, fmt.Errorf("%w: long tags aren't supported", ErrMalformed)
+	}
+	if !p.Constructed {
+		p.Value = content
+		return p, nil
+	}
+
+	for len(content) > 0 {
+		if len(content) < 2 {
+			return nil, ErrMalformed
+		}
+		childHeader := content[0]
+		reader := &sliceReader{b: content[1:]}
+		length, err := readLength(reader)
+		if err != nil {
+			return nil, ErrMalformed
+		}
+		if length > len(reader.b) {
+			return nil, ErrMalformed
+		}
+		child, err := parsePacket(childHeader, reader.b[:length])
+		if err != nil {
+			return nil, err
+		}
+		p.Children = append(p.Children, child)
+		content = reader.b[length:]
+	}
+	return p, nil
+}
+
+type sliceReader struct {
+	b []byte
+}
+
+func (r *sliceReader) ReadByte() (byte, error) {
+	if len(r.b) == 0 {
+		return 0, ErrMalformed
+	}
+	b := r.b[0]
+	r.b = r.b[1:]
+	return b, nil
+}
@@ -0,0 +1,64 @@
+package ber
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	long := strings.Repeat("a", 300)
+	packet := Sequence(
+		Integer(0),
+		Integer(127),
+		Integer(128),
+		Integer(-129),
+		Integer(1<<40),
+		Boolean(true),
+		Enumerated(49),
+		OctetString(long),
+		Constructed(ClassApplication, 3, Primitive(ClassContext, 7, []byte("uid"))),
+	)
+
+	read, err := ReadPacket(bufio.NewReader(bytes.NewReader(packet.Bytes())))
+	if err != nil {
+		t.Fatalf("Wrong! Unexpected error!\n\tExpected: nil\n\tActual: %v", err)
+	}
+	if got, want := read.Bytes(), packet.Bytes(); !bytes.Equal(got, want) {
+		t.Fatalf("Wrong! Unexpected encoding!\n\tExpected: %x\n\tActual: %x", want, got)
+	}
+
+	for i, want := range []int64{0, 127, 128, -129, 1 << 40} {
+		if got, err := read.Children[i].Int(); err != nil || got != want {
+			t.Errorf("Wrong! Unexpected integer!\n\tExpected: %d\n\tActual: %d (%v)", want, got, err)
+		}
+	}
+	if got := string(read.Children[7].Value); got != long {
+		t.Errorf("Wrong! Unexpected long string length: %d", len(got))
+	}
+	if !read.Children[8].Is(ClassApplication, 3) || !read.Children[8].Children[0].Is(ClassContext, 7) {
+		t.Errorf("Wrong! Unexpected tags: %+v", read.Children[8])
+	}
+}
+
+func TestReadPacketMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "indefinite_length", data: []byte{0x30, 0x80, 0x00, 0x00}},
+		{name: "child_overflow", data: []byte{0x30, 0x03, 0x04, 0x05, 0x61}},
+		{name: "too_large", data: []byte{0x04, 0x84, 0x7f, 0xff, 0xff, 0xff}},
+		{name: "long_tag", data: []byte{0x1f, 0x00}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ReadPacket(bufio.NewReader(bytes.NewReader(test.data))); !errors.Is(err, ErrMalformed) {
+				t.Errorf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", ErrMalformed, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+// Package ldap implements the client of LDAP v3 (RFC 4511) sufficient for the
+// search-then-bind authentication: the simple bind, the search and StartTLS.
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const defaultTimeout = 10 * time.Second
+
+var (
+	// ErrInvalidCredentials is returned for unknown users and wrong passwords
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrDirectory wraps failed connections and operations
+	ErrDirectory = errors.New("ldap directory error")
+)
+
+// Config of the directory. Filters contain placeholders replaced by escaped
+// values: {username} in UserFilter and {dn} in GroupFilter.
+type Config struct {
+	// URL is ldap://host[:389] or ldaps://host[:636]
+	URL string
+	// StartTLS encrypts ldap:// connections
+	StartTLS bool
+	// TLS of ldaps:// and StartTLS, nil verifies by system roots
+	TLS *tls.Config
+	// BindDN and BindPassword of the service account searching users, empty
+	// BindDN searches anonymously
+	BindDN       string
+	BindPassword string
+	// BaseDN of users, e.g. "ou=people,dc=example,dc=com"
+	BaseDN string
+	// UserFilter finds the user by the username, e.g. "(uid={username})"
+	UserFilter string
+	// UsernameAttribute is the username of found users, e.g. "uid". Empty
+	// keeps the username of the login.
+	UsernameAttribute string
+	// GroupAttribute lists group DNs in the user entry, e.g. "memberOf"
+	GroupAttribute string
+	// GroupBaseDN and GroupFilter search groups of the user, e.g.
+	// "(member={dn})". Empty GroupFilter disables the search, empty
+	// GroupBaseDN is BaseDN.
+	GroupBaseDN string
+	GroupFilter string
+	// Timeout of the authentication, 10 seconds by default
+	Timeout time.Duration
+}
+
+// User is the authenticated user of the directory
+type User struct {
+	DN       string
+	Username string
+	// Groups are DNs of groups of GroupAttribute and GroupFilter
+	Groups []string
+}
+
+// Directory authenticates users by the directory. Every authentication opens
+// the new connection, so the server restart doesn't break logins.
+type Directory struct {
+	cfg Config
+}
+
+func New(cfg Config) *Directory {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if len(cfg.GroupBaseDN) == 0 {
+		cfg.GroupBaseDN = cfg.BaseDN
+	}
+	return &Directory{cfg: cfg}
+}
+
+// Authenticate finds the user by the service account and binds as the user
+// with the password
+func (d *Directory) Authenticate(ctx context.Context, username, password string) (User, error) {
+	if len(username) == 0 || len(password) == 0 {
+		return User{}, ErrInvalidCredentials
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.Timeout)
+	defer cancel()
+
+	conn, err := d.connect(ctx)
+	if err != nil {
+		return User{}, err
+	}
+	defer conn.Close()
+
+	var attributes []string
+	for _, attr := range []string{d.cfg.UsernameAttribute, d.cfg.GroupAttribute} {
+		if len(attr) > 0 {
+			attributes = append(attributes, attr)
+		}
+	}
+	if len(attributes) == 0 {
+		attributes = []string{noAttributes}
+	}
+	// The second entry means the ambiguous filter
+	entries, err := conn.Search(SearchRequest{
+		BaseDN:     d.cfg.BaseDN,
+		Scope:      ScopeWholeSubtree,
+		Filter:     strings.ReplaceAll(d.cfg.UserFilter, "{username}", EscapeFilter(username)),
+		Attributes: attributes,
+		SizeLimit:  2,
+	})
+	if err != nil && !IsCode(err, ResultSizeLimitExceeded) {
+		return User{}, fmt.Errorf("%w: failed searching user: %w", ErrDirectory, err)
+	}
+	switch {
+	case len(entries) == 0:
+		return User{}, ErrInvalidCredentials
+	case len(entries) > 1:
+		return User{}, fmt.Errorf("%w: username %q matches several entries", ErrDirectory, username)
+	}
+	entry := entries[0]
+
+	if err := d.bind(conn, entry.DN, password); err != nil {
+		return User{}, err
+	}
+
+	user := User{
+		DN:       entry.DN,
+		Username: username,
+		Groups:   entry.Values(d.cfg.GroupAttribute),
+	}
+	if len(d.cfg.UsernameAttribute) > 0 {
+		if user.Username = entry.Value(d.cfg.UsernameAttribute); len(user.Username) == 0 {
+			return User{}, fmt.Errorf("%w: user %q has no %s", ErrDirectory, entry.DN, d.cfg.UsernameAttribute)
+		}
+	}
+
+	if len(d.cfg.GroupFilter) > 0 {
+		// The user may not read groups, the service account searches them
+		if err := conn.Bind(d.cfg.BindDN, d.cfg.BindPassword); err != nil {
+			return User{}, fmt.Errorf("%w: failed binding service account: %w", ErrDirectory, err)
+		}
+		groups, err := conn.Search(SearchRequest{
+			BaseDN:     d.cfg.GroupBaseDN,
+			Scope:      ScopeWholeSubtree,
+			Filter:     strings.ReplaceAll(d.cfg.GroupFilter, "{dn}", EscapeFilter(entry.DN)),
+			Attributes: []string{noAttributes},
+		})
+		if err != nil {
+			return User{}, fmt.Errorf("%w: failed searching groups: %w", ErrDirectory, err)
+		}
+		for _, group := range groups {
+			user.Groups = append(user.Groups, group.DN)
+		}
+	}
+
+	return user, nil
+}
+
+// Bind checks the password of the known user without the search
+func (d *Directory) Bind(ctx context.Context, dn, password string) error {
+	if len(dn) == 0 || len(password) == 0 {
+		return ErrInvalidCredentials
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.Timeout)
+	defer cancel()
+
+	conn, err := d.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return d.bind(conn, dn, password)
+}
+
+func (d *Directory) bind(conn *Conn, dn, password string) error {
+	if err := conn.Bind(dn, password); err != nil {
+		if IsCode(err, ResultInvalidCredentials) {
+			return ErrInvalidCredentials
+		}
+		return fmt.Errorf("%w: failed binding user: %w", ErrDirectory, err)
+	}
+	return nil
+}
+
+// connect opens the connection bound by the service account
+func (d *Directory) connect(ctx context.Context) (*Conn, error) {
+	conn, err := d.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(d.cfg.BindDN) > 0 {
+		if err := conn.Bind(d.cfg.BindDN, d.cfg.BindPassword); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("%w: failed binding service account: %w", ErrDirectory, err)
+		}
+	}
+	return conn, nil
+}
+
+func (d *Directory) dial(ctx context.Context) (*Conn, error) {
+	conn, err := Dial(ctx, d.cfg.URL, d.cfg.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDirectory, err)
+	}
+	if d.cfg.StartTLS {
+		if err := conn.StartTLS(d.cfg.TLS); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("%w: failed starting tls: %w", ErrDirectory, err)
+		}
+	}
+	return conn, nil
+}
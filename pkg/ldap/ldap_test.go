@@ -0,0 +1,214 @@
+package ldap_test
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"slices"
+	"testing"
+
+	"passman/pkg/ldap"
+	"passman/pkg/ldap/ldaptest"
+)
+
+const (
+	baseDN    = "dc=example,dc=com"
+	peopleDN  = "ou=people,dc=example,dc=com"
+	groupsDN  = "ou=groups,dc=example,dc=com"
+	serviceDN = "cn=passman,dc=example,dc=com"
+	aliceDN   = "uid=alice,ou=people,dc=example,dc=com"
+	adminsDN  = "cn=admins,ou=groups,dc=example,dc=com"
+	usersDN   = "cn=users,ou=groups,dc=example,dc=com"
+)
+
+func newServer() *ldaptest.Server {
+	srv := ldaptest.NewServer()
+	srv.AddEntry(baseDN, map[string][]string{"objectClass": {"domain"}})
+	srv.AddEntry(peopleDN, map[string][]string{"objectClass": {"organizationalUnit"}})
+	srv.AddEntry(groupsDN, map[string][]string{"objectClass": {"organizationalUnit"}})
+	srv.AddEntry(serviceDN, map[string][]string{"objectClass": {"person"}, "userPassword": {"service-secret"}})
+	srv.AddEntry(aliceDN, map[string][]string{
+		"objectClass":  {"person"},
+		"uid":          {"Alice"},
+		"mail":         {"alice@example.com"},
+		"memberOf":     {usersDN},
+		"userPassword": {"alice-secret"},
+	})
+	// The same uid in another branch makes the filter ambiguous
+	srv.AddEntry("uid=twin,ou=people,dc=example,dc=com", map[string][]string{"objectClass": {"person"}, "uid": {"twin"}, "userPassword": {"a"}})
+	srv.AddEntry("uid=twin,ou=groups,dc=example,dc=com", map[string][]string{"objectClass": {"person"}, "uid": {"twin"}, "userPassword": {"b"}})
+	srv.AddEntry(adminsDN, map[string][]string{"objectClass": {"groupOfNames"}, "member": {aliceDN}})
+	srv.AddEntry(usersDN, map[string][]string{"objectClass": {"groupOfNames"}, "member": {aliceDN, serviceDN}})
+	return srv
+}
+
+func newConfig(srv *ldaptest.Server) ldap.Config {
+	return ldap.Config{
+		URL:               srv.URL(),
+		BindDN:            serviceDN,
+		BindPassword:      "service-secret",
+		BaseDN:            baseDN,
+		UserFilter:        "(&(objectClass=person)(uid={username}))",
+		UsernameAttribute: "uid",
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+
+	tests := []struct {
+		name      string
+		modify    func(cfg *ldap.Config)
+		username  string
+		password  string
+		expUser   ldap.User
+		expErr    error
+		unavaible bool
+	}{
+		{
+			name:     "valid",
+			username: "alice",
+			password: "alice-secret",
+			expUser:  ldap.User{DN: aliceDN, Username: "Alice"},
+		},
+		{
+			name:     "group_attribute",
+			modify:   func(cfg *ldap.Config) { cfg.GroupAttribute = "memberOf" },
+			username: "alice",
+			password: "alice-secret",
+			expUser:  ldap.User{DN: aliceDN, Username: "Alice", Groups: []string{usersDN}},
+		},
+		{
+			name: "group_filter",
+			modify: func(cfg *ldap.Config) {
+				cfg.GroupBaseDN = groupsDN
+				cfg.GroupFilter = "(&(objectClass=groupOfNames)(member={dn}))"
+			},
+			username: "alice",
+			password: "alice-secret",
+			expUser:  ldap.User{DN: aliceDN, Username: "Alice", Groups: []string{adminsDN, usersDN}},
+		},
+		{
+			name:     "login_username",
+			modify:   func(cfg *ldap.Config) { cfg.UsernameAttribute = "" },
+			username: "ALICE",
+			password: "alice-secret",
+			expUser:  ldap.User{DN: aliceDN, Username: "ALICE"},
+		},
+		{
+			name:     "filter_by_mail",
+			modify:   func(cfg *ldap.Config) { cfg.UserFilter = "(mail={username})" },
+			username: "alice@example.com",
+			password: "alice-secret",
+			expUser:  ldap.User{DN: aliceDN, Username: "Alice"},
+		},
+		{name: "wrong_password", username: "alice", password: "wrong", expErr: ldap.ErrInvalidCredentials},
+		{name: "empty_password", username: "alice", password: "", expErr: ldap.ErrInvalidCredentials},
+		{name: "unknown_user", username: "bob", password: "alice-secret", expErr: ldap.ErrInvalidCredentials},
+		// The escaped value can't match every user
+		{name: "filter_injection", username: "*)(uid=*", password: "alice-secret", expErr: ldap.ErrInvalidCredentials},
+		{name: "ambiguous_username", username: "twin", password: "a", expErr: ldap.ErrDirectory},
+		{
+			name:     "wrong_service_password",
+			modify:   func(cfg *ldap.Config) { cfg.BindPassword = "wrong" },
+			username: "alice",
+			password: "alice-secret",
+			expErr:   ldap.ErrDirectory,
+		},
+		{
+			name:     "anonymous_search_denied",
+			modify:   func(cfg *ldap.Config) { cfg.BindDN, cfg.BindPassword = "", "" },
+			username: "alice",
+			password: "alice-secret",
+			expErr:   ldap.ErrDirectory,
+		},
+		{name: "unavailable", username: "alice", password: "alice-secret", expErr: ldap.ErrDirectory, unavaible: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := newConfig(srv)
+			if test.modify != nil {
+				test.modify(&cfg)
+			}
+			srv.SetUnavailable(test.unavaible)
+			defer srv.SetUnavailable(false)
+
+			user, err := ldap.New(cfg).Authenticate(context.Background(), test.username, test.password)
+
+			if got, want := err, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if got, want := user.DN, test.expUser.DN; got != want {
+				t.Errorf("Wrong! Unexpected DN!\n\tExpected: %s\n\tActual: %s", want, got)
+			}
+			if got, want := user.Username, test.expUser.Username; got != want {
+				t.Errorf("Wrong! Unexpected username!\n\tExpected: %s\n\tActual: %s", want, got)
+			}
+			slices.Sort(user.Groups)
+			if got, want := user.Groups, test.expUser.Groups; !slices.Equal(got, want) {
+				t.Errorf("Wrong! Unexpected groups!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestBind(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+	directory := ldap.New(newConfig(srv))
+
+	if err := directory.Bind(context.Background(), aliceDN, "alice-secret"); err != nil {
+		t.Fatalf("Wrong! Unexpected error!\n\tExpected: nil\n\tActual: %v", err)
+	}
+	if err := directory.Bind(context.Background(), aliceDN, "wrong"); !errors.Is(err, ldap.ErrInvalidCredentials) {
+		t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", ldap.ErrInvalidCredentials, err)
+	}
+
+	// Only the user binds, the service account doesn't search
+	if got, want := srv.Binds(), []string{aliceDN}; !slices.Equal(got, want) {
+		t.Errorf("Wrong! Unexpected binds!\n\tExpected: %v\n\tActual: %v", want, got)
+	}
+}
+
+func TestStartTLS(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+	pool := srv.EnableStartTLS()
+
+	tests := []struct {
+		name   string
+		tls    *tls.Config
+		expErr error
+	}{
+		{name: "trusted", tls: &tls.Config{RootCAs: pool}},
+		{name: "untrusted", expErr: ldap.ErrDirectory},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := newConfig(srv)
+			cfg.StartTLS = true
+			cfg.TLS = test.tls
+
+			_, err := ldap.New(cfg).Authenticate(context.Background(), "alice", "alice-secret")
+
+			if got, want := err, test.expErr; !errors.Is(got, want) {
+				t.Errorf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestContextCancel(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := ldap.New(newConfig(srv)).Authenticate(ctx, "alice", "alice-secret"); !errors.Is(err, ldap.ErrDirectory) {
+		t.Errorf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", ldap.ErrDirectory, err)
+	}
+}
@@ -0,0 +1,401 @@
+// Package ldaptest provides the in-process LDAP server for testing the
+// authentication without the real directory
+package ldaptest
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"passman/pkg/ldap/internal/ber"
+)
+
+// Application tags of protocol operations
+const (
+	opBindRequest      = 0
+	opBindResponse     = 1
+	opUnbindRequest    = 2
+	opSearchRequest    = 3
+	opSearchEntry      = 4
+	opSearchDone       = 5
+	opExtendedRequest  = 23
+	opExtendedResponse = 24
+)
+
+// Result codes sent by the server
+const (
+	resultSuccess            = 0
+	resultProtocolError      = 2
+	resultSizeLimitExceeded  = 4
+	resultNoSuchObject       = 32
+	resultInvalidCredentials = 49
+	resultInsufficientAccess = 50
+	resultUnavailable        = 52
+)
+
+const startTLSOID = "1.3.6.1.4.1.1466.20037"
+
+// Server keeps entries in memory. Passwords are userPassword attributes in
+// plain text, they are never returned by the search. Searches require the
+// authenticated bind, like most directories.
+type Server struct {
+	listener net.Listener
+
+	mu        sync.Mutex
+	entries   []entry
+	tlsConfig *tls.Config
+	down      bool
+	binds     []string
+	wg        sync.WaitGroup
+}
+
+type entry struct {
+	dn         string
+	attributes map[string][]string
+}
+
+// NewServer starts the server on the random local port
+func NewServer() *Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	s := &Server{listener: listener}
+
+	s.wg.Add(1)
+	go s.serve()
+
+	return s
+}
+
+// URL is ldap:// address of the server
+func (s *Server) URL() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+func (s *Server) Close() {
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+// AddEntry adds the entry, names of attributes are case-insensitive
+func (s *Server) AddEntry(dn string, attributes map[string][]string) {
+	lower := make(map[string][]string, len(attributes))
+	for name, values := range attributes {
+		lower[strings.ToLower(name)] = values
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry{dn: dn, attributes: lower})
+}
+
+// SetUnavailable makes the server answer every operation by "unavailable"
+func (s *Server) SetUnavailable(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down = down
+}
+
+// Binds returns DNs of successful binds in order
+func (s *Server) Binds() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.binds...)
+}
+
+// EnableStartTLS allows StartTLS by the new self-signed certificate for
+// 127.0.0.1 and returns the pool trusting it
+func (s *Server) EnableStartTLS() *x509.CertPool {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "ldaptest"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		panic(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		panic(err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tlsConfig = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return pool
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			s.handle(conn)
+		}()
+	}
+}
+
+// session is the state of one connection
+type session struct {
+	conn  net.Conn
+	r     *bufio.Reader
+	bound string
+}
+
+func (s *Server) handle(conn net.Conn) {
+	sess := &session{conn: conn, r: bufio.NewReader(conn)}
+
+	for {
+		msg, err := ber.ReadPacket(sess.r)
+		if err != nil {
+			return
+		}
+		if !msg.Is(ber.ClassUniversal, ber.TagSequence) || len(msg.Children) < 2 {
+			return
+		}
+		msgID, err := msg.Children[0].Int()
+		if err != nil {
+			return
+		}
+		op := msg.Children[1]
+
+		s.mu.Lock()
+		down := s.down
+		s.mu.Unlock()
+
+		switch {
+		case op.Is(ber.ClassApplication, opUnbindRequest):
+			return
+		case down:
+			// The notice of disconnection
+			_ = sess.write(0, result(opExtendedResponse, resultUnavailable, "server is down"))
+			return
+		case op.Is(ber.ClassApplication, opBindRequest):
+			err = sess.write(msgID, s.bind(sess, op))
+		case op.Is(ber.ClassApplication, opSearchRequest):
+			err = s.search(sess, msgID, op)
+		case op.Is(ber.ClassApplication, opExtendedRequest):
+			err = s.extended(sess, msgID, op)
+		default:
+			err = sess.write(msgID, result(opExtendedResponse, resultProtocolError, "unsupported operation"))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) bind(sess *session, op *ber.Packet) *ber.Packet {
+	if len(op.Children) < 3 || !op.Children[2].Is(ber.ClassContext, 0) {
+		return result(opBindResponse, resultProtocolError, "only the simple bind is supported")
+	}
+	dn := string(op.Children[1].Value)
+	password := string(op.Children[2].Value)
+
+	sess.bound = ""
+	if len(dn) == 0 && len(password) == 0 {
+		return result(opBindResponse, resultSuccess, "")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.entries {
+		if strings.EqualFold(e.dn, dn) && len(password) > 0 && slices.Contains(e.attributes["userpassword"], password) {
+			sess.bound = e.dn
+			s.binds = append(s.binds, e.dn)
+			return result(opBindResponse, resultSuccess, "")
+		}
+	}
+	return result(opBindResponse, resultInvalidCredentials, "invalid credentials")
+}
+
+func (s *Server) search(sess *session, msgID int64, op *ber.Packet) error {
+	if len(sess.bound) == 0 {
+		return sess.write(msgID, result(opSearchDone, resultInsufficientAccess, "anonymous search is not allowed"))
+	}
+	if len(op.Children) < 8 {
+		return sess.write(msgID, result(opSearchDone, resultProtocolError, "invalid search request"))
+	}
+
+	baseDN := strings.ToLower(string(op.Children[0].Value))
+	scope, _ := op.Children[1].Int()
+	sizeLimit, _ := op.Children[3].Int()
+	filter := op.Children[6]
+	var requested []string
+	for _, attr := range op.Children[7].Children {
+		requested = append(requested, strings.ToLower(string(attr.Value)))
+	}
+
+	s.mu.Lock()
+	var found []entry
+	baseExists := false
+	for _, e := range s.entries {
+		dn := strings.ToLower(e.dn)
+		if dn == baseDN {
+			baseExists = true
+		}
+		if inScope(dn, baseDN, scope) && matches(e, filter) {
+			found = append(found, e)
+		}
+	}
+	s.mu.Unlock()
+
+	if !baseExists {
+		return sess.write(msgID, result(opSearchDone, resultNoSuchObject, "base object not found"))
+	}
+
+	for i, e := range found {
+		if sizeLimit > 0 && int64(i) >= sizeLimit {
+			return sess.write(msgID, result(opSearchDone, resultSizeLimitExceeded, ""))
+		}
+		if err := sess.write(msgID, searchEntry(e, requested)); err != nil {
+			return err
+		}
+	}
+	return sess.write(msgID, result(opSearchDone, resultSuccess, ""))
+}
+
+func (s *Server) extended(sess *session, msgID int64, op *ber.Packet) error {
+	if len(op.Children) == 0 || string(op.Children[0].Value) != startTLSOID {
+		return sess.write(msgID, result(opExtendedResponse, resultProtocolError, "unsupported extended operation"))
+	}
+
+	s.mu.Lock()
+	tlsConfig := s.tlsConfig
+	s.mu.Unlock()
+	if tlsConfig == nil {
+		return sess.write(msgID, result(opExtendedResponse, resultUnavailable, "starttls is not enabled"))
+	}
+
+	if err := sess.write(msgID, result(opExtendedResponse, resultSuccess, "")); err != nil {
+		return err
+	}
+	tlsConn := tls.Server(sess.conn, tlsConfig)
+	if err := tlsConn.Handshake(); err != nil {
+		return err
+	}
+	sess.conn = tlsConn
+	sess.r = bufio.NewReader(tlsConn)
+	return nil
+}
+
+func (sess *session) write(msgID int64, op *ber.Packet) error {
+	_, err := sess.conn.Write(ber.Sequence(ber.Integer(msgID), op).Bytes())
+	return err
+}
+
+func result(tag byte, code int64, message string) *ber.Packet {
+	return ber.Constructed(ber.ClassApplication, tag,
+		ber.Enumerated(code),
+		ber.OctetString(""),
+		ber.OctetString(message),
+	)
+}
+
+func searchEntry(e entry, requested []string) *ber.Packet {
+	attributes := ber.Sequence()
+	for name, values := range e.attributes {
+		if name == "userpassword" {
+			continue
+		}
+		if len(requested) > 0 && !contains(requested, name) {
+			continue
+		}
+		set := ber.Constructed(ber.ClassUniversal, ber.TagSet)
+		for _, value := range values {
+			set.Children = append(set.Children, ber.OctetString(value))
+		}
+		attributes.Children = append(attributes.Children, ber.Sequence(ber.OctetString(name), set))
+	}
+	return ber.Constructed(ber.ClassApplication, opSearchEntry, ber.OctetString(e.dn), attributes)
+}
+
+func inScope(dn, baseDN string, scope int64) bool {
+	switch scope {
+	case 0:
+		return dn == baseDN
+	case 1:
+		_, parent, ok := strings.Cut(dn, ",")
+		return ok && parent == baseDN
+	default:
+		return dn == baseDN || strings.HasSuffix(dn, ","+baseDN)
+	}
+}
+
+// matches evaluates and, or, not, equality and presence filters, values are
+// compared case-insensitively
+func matches(e entry, filter *ber.Packet) bool {
+	if filter.Class != ber.ClassContext {
+		return false
+	}
+
+	switch filter.Tag {
+	case 0:
+		for _, child := range filter.Children {
+			if !matches(e, child) {
+				return false
+			}
+		}
+		return true
+	case 1:
+		for _, child := range filter.Children {
+			if matches(e, child) {
+				return true
+			}
+		}
+		return false
+	case 2:
+		return len(filter.Children) == 1 && !matches(e, filter.Children[0])
+	case 3:
+		if len(filter.Children) != 2 {
+			return false
+		}
+		name := strings.ToLower(string(filter.Children[0].Value))
+		return contains(e.attributes[name], string(filter.Children[1].Value))
+	case 7:
+		_, ok := e.attributes[strings.ToLower(string(filter.Value))]
+		return ok
+	default:
+		return false
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,239 @@
+// Package oidc implements the relying party of the OpenID Connect authorization
+// code flow (https://openid.net/specs/openid-connect-core-1_0.html) with PKCE
+// (RFC 7636). Only the ID token is used, RS256 and ES256 signatures are
+// verified by keys of the provider.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Leeway of the token time claims for clocks of the provider and the server
+const leeway = time.Minute
+
+// maxResponseSize limits responses of the provider
+const maxResponseSize = 1 << 20
+
+var (
+	// ErrInvalidToken wraps every reason of rejecting the ID token
+	ErrInvalidToken = errors.New("invalid id token")
+	// ErrProvider wraps failed requests to the provider
+	ErrProvider = errors.New("oidc provider error")
+)
+
+type Config struct {
+	// Issuer is the exact issuer identifier, the discovery document is at
+	// Issuer + "/.well-known/openid-configuration"
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the callback registered at the provider
+	RedirectURL string
+	// Scopes are requested in addition to "openid"
+	Scopes []string
+}
+
+// Provider is the client of one OpenID provider. The discovery document and
+// keys are fetched on the first use, so the server starts while the provider
+// is unavailable.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+	now    func() time.Time
+
+	mu       sync.Mutex
+	metadata *metadata
+	keys     map[string]publicKey
+}
+
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+func New(cfg Config) *Provider {
+	return &Provider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+		now:    time.Now,
+	}
+}
+
+func (p *Provider) Issuer() string {
+	return p.cfg.Issuer
+}
+
+// AuthRequest is kept by the client between the redirect to the provider and
+// the callback
+type AuthRequest struct {
+	State string
+	Nonce string
+	// Verifier is the PKCE code verifier, only its hash is sent to the provider
+	Verifier string
+}
+
+func NewAuthRequest() (AuthRequest, error) {
+	values := make([]string, 3)
+	for i := range values {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return AuthRequest{}, err
+		}
+		values[i] = base64.RawURLEncoding.EncodeToString(b)
+	}
+	return AuthRequest{State: values[0], Nonce: values[1], Verifier: values[2]}, nil
+}
+
+// CodeChallenge is the S256 PKCE challenge of the verifier
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL returns the authorization endpoint URL the user is redirected to
+func (p *Provider) AuthCodeURL(ctx context.Context, req AuthRequest) (string, error) {
+	md, err := p.getMetadata(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	scopes := []string{"openid"}
+	for _, scope := range p.cfg.Scopes {
+		if scope != "openid" {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {req.State},
+		"nonce":                 {req.Nonce},
+		"code_challenge":        {CodeChallenge(req.Verifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(md.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return md.AuthorizationEndpoint + sep + query.Encode(), nil
+}
+
+// Exchange redeems the code of the callback and returns the verified ID token
+func (p *Provider) Exchange(ctx context.Context, code string, req AuthRequest) (IDToken, error) {
+	md, err := p.getMetadata(ctx)
+	if err != nil {
+		return IDToken{}, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {req.Verifier},
+	}
+	// Public clients send only the id, confidential ones use client_secret_basic
+	if len(p.cfg.ClientSecret) == 0 {
+		form.Set("client_id", p.cfg.ClientID)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, md.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return IDToken{}, fmt.Errorf("%w: %w", ErrProvider, err)
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpReq.Header.Set("Accept", "application/json")
+	if len(p.cfg.ClientSecret) > 0 {
+		httpReq.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	var tokenResp struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := p.do(httpReq, &tokenResp)
+	if err != nil {
+		return IDToken{}, err
+	}
+	if status != http.StatusOK {
+		return IDToken{}, fmt.Errorf("%w: token endpoint returned %d: %s %s", ErrProvider, status, tokenResp.Error, tokenResp.ErrorDescription)
+	}
+	if len(tokenResp.IDToken) == 0 {
+		return IDToken{}, fmt.Errorf("%w: no id_token in the response", ErrInvalidToken)
+	}
+
+	return p.verify(ctx, md, tokenResp.IDToken, req.Nonce)
+}
+
+func (p *Provider) getMetadata(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	md := p.metadata
+	p.mu.Unlock()
+	if md != nil {
+		return md, nil
+	}
+
+	wellKnown := strings.TrimSuffix(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrProvider, err)
+	}
+
+	md = &metadata{}
+	status, err := p.do(req, md)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%w: discovery returned %d", ErrProvider, status)
+	}
+	// The issuer of the document must be the configured one, otherwise tokens
+	// of another issuer would be accepted
+	if md.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("%w: discovery issuer %q doesn't match %q", ErrProvider, md.Issuer, p.cfg.Issuer)
+	}
+	if len(md.AuthorizationEndpoint) == 0 || len(md.TokenEndpoint) == 0 || len(md.JWKSURI) == 0 {
+		return nil, fmt.Errorf("%w: incomplete discovery document", ErrProvider)
+	}
+
+	p.mu.Lock()
+	p.metadata = md
+	p.mu.Unlock()
+	return md, nil
+}
+
+// do sends the request and decodes the JSON response of any status
+func (p *Provider) do(req *http.Request, dst any) (int, error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrProvider, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrProvider, err)
+	}
+	if err := json.Unmarshal(body, dst); err != nil && resp.StatusCode == http.StatusOK {
+		return 0, fmt.Errorf("%w: failed parsing response: %w", ErrProvider, err)
+	}
+	return resp.StatusCode, nil
+}
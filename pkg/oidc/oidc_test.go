@@ -0,0 +1,213 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"passman/pkg/oidc"
+	"passman/pkg/oidc/oidctest"
+)
+
+const redirectURL = "https://pm.example.com/users/oidc/callback"
+
+// login runs the flow against the provider and returns the result of Exchange
+func login(t *testing.T, p *oidc.Provider, idp *oidctest.Provider, modify func(code *string, req *oidc.AuthRequest)) (oidc.IDToken, error) {
+	t.Helper()
+	ctx := context.Background()
+
+	req, err := oidc.NewAuthRequest()
+	if err != nil {
+		t.Fatalf("Wrong! Unexpected error!\n\tExpected: nil\n\tActual: %v", err)
+	}
+	authURL, err := p.AuthCodeURL(ctx, req)
+	if err != nil {
+		t.Fatalf("Wrong! Unexpected error!\n\tExpected: nil\n\tActual: %v", err)
+	}
+
+	code, state, err := idp.Authorize(authURL)
+	if err != nil {
+		t.Fatalf("Wrong! Unexpected provider error!\n\tExpected: nil\n\tActual: %v", err)
+	}
+	if state != req.State {
+		t.Fatalf("Wrong! Unexpected state!\n\tExpected: %s\n\tActual: %s", req.State, state)
+	}
+
+	if modify != nil {
+		modify(&code, &req)
+	}
+	return p.Exchange(ctx, code, req)
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	idp := oidctest.NewProvider("passman", "secret")
+	defer idp.Close()
+
+	p := oidc.New(oidc.Config{Issuer: idp.Issuer(), ClientID: "passman", RedirectURL: redirectURL, Scopes: []string{"openid", "profile"}})
+	req, _ := oidc.NewAuthRequest()
+
+	authURL, err := p.AuthCodeURL(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Wrong! Unexpected error!\n\tExpected: nil\n\tActual: %v", err)
+	}
+	parsed, _ := url.Parse(authURL)
+	query := parsed.Query()
+
+	expected := map[string]string{
+		"response_type":         "code",
+		"client_id":             "passman",
+		"redirect_uri":          redirectURL,
+		"scope":                 "openid profile",
+		"state":                 req.State,
+		"nonce":                 req.Nonce,
+		"code_challenge":        oidc.CodeChallenge(req.Verifier),
+		"code_challenge_method": "S256",
+	}
+	for name, want := range expected {
+		if got := query.Get(name); got != want {
+			t.Errorf("Wrong! Unexpected %s!\n\tExpected: %s\n\tActual: %s", name, want, got)
+		}
+	}
+	if query.Has("code_verifier") {
+		t.Errorf("Wrong! The verifier is sent to the provider")
+	}
+}
+
+func TestExchange(t *testing.T) {
+	subject := map[string]any{"sub": "user-1", "preferred_username": "alice"}
+
+	tests := []struct {
+		name         string
+		clientSecret string
+		idpSecret    string
+		claims       map[string]any
+		modify       func(code *string, req *oidc.AuthRequest)
+		expErr       error
+	}{
+		{name: "confidential_client", clientSecret: "secret", idpSecret: "secret"},
+		{name: "public_client"},
+		{name: "wrong_secret", clientSecret: "other", idpSecret: "secret", expErr: oidc.ErrProvider},
+		{name: "secret_required", idpSecret: "secret", expErr: oidc.ErrProvider},
+		{
+			name:   "wrong_verifier",
+			modify: func(_ *string, req *oidc.AuthRequest) { req.Verifier = "another verifier" },
+			expErr: oidc.ErrProvider,
+		},
+		{
+			name:   "unknown_code",
+			modify: func(code *string, _ *oidc.AuthRequest) { *code = "unknown" },
+			expErr: oidc.ErrProvider,
+		},
+		{
+			name:   "wrong_nonce",
+			modify: func(_ *string, req *oidc.AuthRequest) { req.Nonce = "another nonce" },
+			expErr: oidc.ErrInvalidToken,
+		},
+		{name: "expired", claims: map[string]any{"exp": time.Now().Add(-time.Hour).Unix()}, expErr: oidc.ErrInvalidToken},
+		{name: "issued_in_future", claims: map[string]any{"iat": time.Now().Add(time.Hour).Unix()}, expErr: oidc.ErrInvalidToken},
+		{name: "another_audience", claims: map[string]any{"aud": "another"}, expErr: oidc.ErrInvalidToken},
+		{name: "multiple_audiences", claims: map[string]any{"aud": []string{"passman", "another"}, "azp": "passman"}},
+		{name: "multiple_audiences_without_azp", claims: map[string]any{"aud": []string{"passman", "another"}}, expErr: oidc.ErrInvalidToken},
+		{name: "another_issuer", claims: map[string]any{"iss": "https://evil.example.com"}, expErr: oidc.ErrInvalidToken},
+		{name: "no_subject", claims: map[string]any{"sub": ""}, expErr: oidc.ErrInvalidToken},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			idp := oidctest.NewProvider("passman", test.idpSecret)
+			defer idp.Close()
+
+			claims := maps.Clone(subject)
+			maps.Copy(claims, test.claims)
+			idp.SetClaims(claims)
+
+			p := oidc.New(oidc.Config{Issuer: idp.Issuer(), ClientID: "passman", ClientSecret: test.clientSecret, RedirectURL: redirectURL})
+
+			token, err := login(t, p, idp, test.modify)
+
+			if got, want := err, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+			if test.expErr != nil {
+				return
+			}
+			if got, want := token.Subject, "user-1"; got != want {
+				t.Errorf("Wrong! Unexpected subject!\n\tExpected: %s\n\tActual: %s", want, got)
+			}
+			if got, want := token.Issuer, idp.Issuer(); got != want {
+				t.Errorf("Wrong! Unexpected issuer!\n\tExpected: %s\n\tActual: %s", want, got)
+			}
+			if got, want := token.String("preferred_username"), "alice"; got != want {
+				t.Errorf("Wrong! Unexpected username!\n\tExpected: %s\n\tActual: %s", want, got)
+			}
+		})
+	}
+}
+
+func TestExchangeKeyRotation(t *testing.T) {
+	idp := oidctest.NewProvider("passman", "")
+	defer idp.Close()
+	idp.SetClaims(map[string]any{"sub": "user-1"})
+
+	p := oidc.New(oidc.Config{Issuer: idp.Issuer(), ClientID: "passman", RedirectURL: redirectURL})
+
+	if _, err := login(t, p, idp, nil); err != nil {
+		t.Fatalf("Wrong! Unexpected error!\n\tExpected: nil\n\tActual: %v", err)
+	}
+
+	// Cached keys don't have the new key id, the key set is fetched again
+	idp.RotateKey()
+	if _, err := login(t, p, idp, nil); err != nil {
+		t.Fatalf("Wrong! Unexpected error after rotation!\n\tExpected: nil\n\tActual: %v", err)
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	idp := oidctest.NewProvider("passman", "")
+	defer idp.Close()
+
+	// The trailing slash makes the configured issuer differ from the document
+	p := oidc.New(oidc.Config{Issuer: idp.Issuer() + "/", ClientID: "passman", RedirectURL: redirectURL})
+	req, _ := oidc.NewAuthRequest()
+
+	if _, err := p.AuthCodeURL(context.Background(), req); !errors.Is(err, oidc.ErrProvider) {
+		t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", oidc.ErrProvider, err)
+	}
+}
+
+func TestIDTokenClaims(t *testing.T) {
+	token := oidc.IDToken{Claims: map[string]any{
+		"email":        "alice@example.com",
+		"groups":       []any{"users", "admins", 1},
+		"role":         "admins",
+		"realm_access": map[string]any{"roles": []any{"passman-admin"}},
+	}}
+
+	tests := []struct {
+		name     string
+		claim    string
+		expValue []string
+	}{
+		{name: "array", claim: "groups", expValue: []string{"users", "admins"}},
+		{name: "single_string", claim: "role", expValue: []string{"admins"}},
+		{name: "nested", claim: "realm_access.roles", expValue: []string{"passman-admin"}},
+		{name: "missing", claim: "realm_access.groups", expValue: nil},
+		{name: "not_object", claim: "email.domain", expValue: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := token.Strings(test.claim), test.expValue; !slices.Equal(got, want) {
+				t.Errorf("Wrong! Unexpected value!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+
+	if got, want := token.String("email"), "alice@example.com"; got != want {
+		t.Errorf("Wrong! Unexpected value!\n\tExpected: %s\n\tActual: %s", want, got)
+	}
+}
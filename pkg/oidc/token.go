@@ -0,0 +1,309 @@
+package oidc
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// IDToken is the verified ID token
+type IDToken struct {
+	Issuer  string
+	Subject string
+	// Claims are all claims of the token, including standard ones
+	Claims map[string]any
+}
+
+// String returns the string claim. Dots in the name address nested objects,
+// e.g. "realm_access.roles".
+func (t IDToken) String(name string) string {
+	s, _ := t.claim(name).(string)
+	return s
+}
+
+// Strings returns the array of strings or the single string claim
+func (t IDToken) Strings(name string) []string {
+	switch v := t.claim(name).(type) {
+	case string:
+		return []string{v}
+	case []any:
+		res := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				res = append(res, s)
+			}
+		}
+		return res
+	}
+	return nil
+}
+
+func (t IDToken) claim(name string) any {
+	var v any = t.Claims
+	for _, key := range strings.Split(name, ".") {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = obj[key]
+	}
+	return v
+}
+
+type publicKey struct {
+	alg string
+	key crypto.PublicKey
+}
+
+func (p *Provider) verify(ctx context.Context, md *metadata, raw, nonce string) (IDToken, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return IDToken{}, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return IDToken{}, fmt.Errorf("%w: header: %w", ErrInvalidToken, err)
+	}
+	// Algorithms are fixed, so "none" and HMAC with the public key are rejected
+	if header.Alg != "RS256" && header.Alg != "ES256" {
+		return IDToken{}, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, header.Alg)
+	}
+
+	key, err := p.getKey(ctx, md, header.Kid, header.Alg)
+	if err != nil {
+		return IDToken{}, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return IDToken{}, fmt.Errorf("%w: signature: %w", ErrInvalidToken, err)
+	}
+	if err := verifySignature(key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return IDToken{}, err
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return IDToken{}, fmt.Errorf("%w: payload: %w", ErrInvalidToken, err)
+	}
+	token := IDToken{Claims: claims}
+	token.Issuer = token.String("iss")
+	token.Subject = token.String("sub")
+
+	if token.Issuer != md.Issuer {
+		return IDToken{}, fmt.Errorf("%w: issuer %q", ErrInvalidToken, token.Issuer)
+	}
+	if len(token.Subject) == 0 {
+		return IDToken{}, fmt.Errorf("%w: no subject", ErrInvalidToken)
+	}
+	audience := token.Strings("aud")
+	if !slices.Contains(audience, p.cfg.ClientID) {
+		return IDToken{}, fmt.Errorf("%w: audience %v", ErrInvalidToken, audience)
+	}
+	if azp := token.String("azp"); len(audience) > 1 && azp != p.cfg.ClientID {
+		return IDToken{}, fmt.Errorf("%w: authorized party %q", ErrInvalidToken, azp)
+	}
+	if token.String("nonce") != nonce {
+		return IDToken{}, fmt.Errorf("%w: nonce mismatch", ErrInvalidToken)
+	}
+
+	now := p.now()
+	exp, ok := claims["exp"].(float64)
+	if !ok || now.After(time.Unix(int64(exp), 0).Add(leeway)) {
+		return IDToken{}, fmt.Errorf("%w: expired", ErrInvalidToken)
+	}
+	if iat, ok := claims["iat"].(float64); ok && time.Unix(int64(iat), 0).After(now.Add(leeway)) {
+		return IDToken{}, fmt.Errorf("%w: issued in the future", ErrInvalidToken)
+	}
+
+	return token, nil
+}
+
+func decodeSegment(segment string, dst any) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, dst)
+}
+
+func verifySignature(key publicKey, signed, signature []byte) error {
+	digest := sha256.Sum256(signed)
+
+	switch k := key.key.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature); err != nil {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		// JWS signatures are r||s, not ASN.1
+		if len(signature) != 64 {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(k, digest[:], r, s) {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: unsupported key", ErrInvalidToken)
+}
+
+// getKey returns the key of the token. Unknown key ids refetch the key set
+// once, the provider may have rotated keys.
+func (p *Provider) getKey(ctx context.Context, md *metadata, kid, alg string) (publicKey, error) {
+	p.mu.Lock()
+	keys := p.keys
+	p.mu.Unlock()
+
+	key, ok := findKey(keys, kid, alg)
+	if ok {
+		return key, nil
+	}
+
+	keys, err := p.fetchKeys(ctx, md)
+	if err != nil {
+		return publicKey{}, err
+	}
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+
+	if key, ok = findKey(keys, kid, alg); !ok {
+		return publicKey{}, fmt.Errorf("%w: unknown key %q", ErrInvalidToken, kid)
+	}
+	return key, nil
+}
+
+// findKey matches the key id. Tokens without the id are accepted only if the
+// provider has the single key of the algorithm.
+func findKey(keys map[string]publicKey, kid, alg string) (publicKey, bool) {
+	if len(kid) > 0 {
+		key, ok := keys[kid]
+		return key, ok && key.alg == alg
+	}
+
+	var found []publicKey
+	for _, key := range keys {
+		if key.alg == alg {
+			found = append(found, key)
+		}
+	}
+	if len(found) != 1 {
+		return publicKey{}, false
+	}
+	return found[0], true
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (p *Provider) fetchKeys(ctx context.Context, md *metadata) (map[string]publicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, md.JWKSURI, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrProvider, err)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	status, err := p.do(req, &set)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%w: jwks returned %d", ErrProvider, status)
+	}
+
+	keys := make(map[string]publicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		// Unsupported and malformed keys are skipped, tokens signed by them fail
+		if key, err := parseJWK(k); err == nil && (k.Alg == "" || k.Alg == key.alg) {
+			keys[k.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+func parseJWK(k jwk) (publicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return publicKey{}, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return publicKey{}, err
+		}
+		// Leading zeros don't change the exponent, the limit keeps it in int
+		e = bytes.TrimLeft(e, "\x00")
+		if len(e) == 0 || len(e) > 4 {
+			return publicKey{}, fmt.Errorf("invalid rsa exponent")
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if key.N.BitLen() < 2048 {
+			return publicKey{}, fmt.Errorf("rsa key is too short")
+		}
+		return publicKey{alg: "RS256", key: key}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return publicKey{}, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return publicKey{}, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return publicKey{}, err
+		}
+		// crypto/ecdh rejects points which aren't on the curve
+		point := slices.Concat([]byte{4}, leftPad(x, 32), leftPad(y, 32))
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return publicKey{}, err
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		return publicKey{alg: "ES256", key: key}, nil
+	}
+	return publicKey{}, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func leftPad(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	return append(make([]byte, size-len(b)), b...)
+}
@@ -0,0 +1,53 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestES256(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	encode := base64.RawURLEncoding.EncodeToString
+
+	pub, err := parseJWK(jwk{Kty: "EC", Crv: "P-256", X: encode(key.X.FillBytes(make([]byte, 32))), Y: encode(key.Y.FillBytes(make([]byte, 32)))})
+	if err != nil {
+		t.Fatalf("Wrong! Unexpected error!\n\tExpected: nil\n\tActual: %v", err)
+	}
+	if got, want := pub.alg, "ES256"; got != want {
+		t.Fatalf("Wrong! Unexpected algorithm!\n\tExpected: %s\n\tActual: %s", want, got)
+	}
+
+	signed := []byte("header.payload")
+	digest := sha256.Sum256(signed)
+	r, s, _ := ecdsa.Sign(rand.Reader, key, digest[:])
+	signature := append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+
+	tests := []struct {
+		name      string
+		signed    []byte
+		signature []byte
+		expErr    error
+	}{
+		{name: "valid", signed: signed, signature: signature},
+		{name: "another_payload", signed: []byte("header.another"), signature: signature, expErr: ErrInvalidToken},
+		{name: "asn1_signature", signed: signed, signature: append(signature, 0), expErr: ErrInvalidToken},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := verifySignature(pub, test.signed, test.signature), test.expErr; !errors.Is(got, want) {
+				t.Errorf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+
+	// The point isn't on the curve
+	if _, err := parseJWK(jwk{Kty: "EC", Crv: "P-256", X: encode(make([]byte, 32)), Y: encode([]byte{1})}); err == nil {
+		t.Errorf("Wrong! Invalid point is accepted")
+	}
+}
@@ -0,0 +1,61 @@
+package password
+
+import (
+	"time"
+
+	"github.com/alexedwards/argon2id"
+)
+
+// Weaker reports whether the hash was created with weaker parameters than the
+// current ones. Parallelism isn't compared, it changes the latency but not the
+// total work of the attacker, so hashes aren't recomputed when the host changes.
+func Weaker(params, current *argon2id.Params) bool {
+	return params.Memory < current.Memory ||
+		params.Iterations < current.Iterations ||
+		params.SaltLength < current.SaltLength ||
+		params.KeyLength < current.KeyLength
+}
+
+// Benchmark returns the strongest parameters hashing within the target time on
+// this host. The memory (KiB) is doubled up to maxMemory first, the iterations
+// are increased after that, as the memory cost is harder for the attacker.
+func Benchmark(target time.Duration, maxMemory uint32, parallelism uint8) *argon2id.Params {
+	return benchmark(target, maxMemory, parallelism, func(params *argon2id.Params) time.Duration {
+		start := time.Now()
+		_, _ = argon2id.CreateHash("benchmark password", params)
+		return time.Since(start)
+	})
+}
+
+func benchmark(target time.Duration, maxMemory uint32, parallelism uint8, measure func(*argon2id.Params) time.Duration) *argon2id.Params {
+	params := &argon2id.Params{
+		Memory:      min(64*1024, maxMemory),
+		Iterations:  1,
+		Parallelism: parallelism,
+		SaltLength:  argon2id.DefaultParams.SaltLength,
+		KeyLength:   argon2id.DefaultParams.KeyLength,
+	}
+
+	// The minimal parameters are returned even if they're slower than the target
+	if measure(params) > target {
+		return params
+	}
+
+	for params.Memory*2 <= maxMemory {
+		params.Memory *= 2
+		if measure(params) > target {
+			params.Memory /= 2
+			break
+		}
+	}
+
+	for {
+		params.Iterations++
+		if measure(params) > target {
+			params.Iterations--
+			break
+		}
+	}
+
+	return params
+}
@@ -0,0 +1,70 @@
+package password
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alexedwards/argon2id"
+)
+
+func TestWeaker(t *testing.T) {
+	current := &argon2id.Params{Memory: 128 * 1024, Iterations: 2, Parallelism: 4, SaltLength: 16, KeyLength: 32}
+
+	tests := []struct {
+		name   string
+		params argon2id.Params
+		exp    bool
+	}{
+		{name: "same", params: *current, exp: false},
+		{name: "less_memory", params: argon2id.Params{Memory: 64 * 1024, Iterations: 2, Parallelism: 4, SaltLength: 16, KeyLength: 32}, exp: true},
+		{name: "less_iterations", params: argon2id.Params{Memory: 128 * 1024, Iterations: 1, Parallelism: 4, SaltLength: 16, KeyLength: 32}, exp: true},
+		{name: "shorter_key", params: argon2id.Params{Memory: 128 * 1024, Iterations: 2, Parallelism: 4, SaltLength: 16, KeyLength: 16}, exp: true},
+		{name: "other_parallelism", params: argon2id.Params{Memory: 128 * 1024, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32}, exp: false},
+		{name: "stronger", params: argon2id.Params{Memory: 256 * 1024, Iterations: 3, Parallelism: 4, SaltLength: 16, KeyLength: 32}, exp: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := Weaker(&test.params, current), test.exp; got != want {
+				t.Errorf("Wrong! Unexpected result!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
+
+func TestBenchmark(t *testing.T) {
+	// The fake cost grows linearly with the memory and the iterations, 64 MiB
+	// and 1 iteration take 100ms
+	measure := func(params *argon2id.Params) time.Duration {
+		return time.Duration(params.Memory) * time.Duration(params.Iterations) * 100 * time.Millisecond / (64 * 1024)
+	}
+
+	tests := []struct {
+		name          string
+		target        time.Duration
+		maxMemory     uint32
+		expMemory     uint32
+		expIterations uint32
+	}{
+		{name: "too_slow_host", target: 50 * time.Millisecond, maxMemory: 1024 * 1024, expMemory: 64 * 1024, expIterations: 1},
+		{name: "memory_only", target: 450 * time.Millisecond, maxMemory: 1024 * 1024, expMemory: 256 * 1024, expIterations: 1},
+		{name: "memory_limit", target: 500 * time.Millisecond, maxMemory: 128 * 1024, expMemory: 128 * 1024, expIterations: 2},
+		{name: "small_memory_limit", target: 100 * time.Millisecond, maxMemory: 32 * 1024, expMemory: 32 * 1024, expIterations: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			params := benchmark(test.target, test.maxMemory, 2, measure)
+
+			if got, want := params.Memory, test.expMemory; got != want {
+				t.Errorf("Wrong! Unexpected memory!\n\tExpected: %d\n\tActual: %d", want, got)
+			}
+			if got, want := params.Iterations, test.expIterations; got != want {
+				t.Errorf("Wrong! Unexpected iterations!\n\tExpected: %d\n\tActual: %d", want, got)
+			}
+			if got, want := params.Parallelism, uint8(2); got != want {
+				t.Errorf("Wrong! Unexpected parallelism!\n\tExpected: %d\n\tActual: %d", want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+// Package password checks master passwords against the server-wide policy and
+// tunes parameters of their hashes
+package password
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed common.txt
+var commonList string
+
+// common passwords in lower case
+var common = parseList(commonList)
+
+// Policy of master passwords. The zero policy accepts any password.
+type Policy struct {
+	MinLength int
+	// MinEntropy is the minimal estimated entropy in bits, see Entropy
+	MinEntropy float64
+	// CheckCommon rejects passwords of the bundled list of common passwords
+	CheckCommon bool
+	// CheckUsername rejects passwords containing the username
+	CheckUsername bool
+}
+
+// PolicyError lists requirements of the policy which the password doesn't meet
+type PolicyError struct {
+	Policy     Policy
+	Violations []string
+}
+
+func (pe *PolicyError) Error() string {
+	return "weak password: " + strings.Join(pe.Violations, ", ")
+}
+
+// Check returns *PolicyError if the password doesn't meet the policy
+func (p Policy) Check(username, password string) error {
+	var violations []string
+
+	if utf8.RuneCountInString(password) < p.MinLength {
+		violations = append(violations, fmt.Sprintf("at least %d characters", p.MinLength))
+	}
+	if Entropy(password) < p.MinEntropy {
+		violations = append(violations, fmt.Sprintf("estimated entropy of at least %.0f bits", p.MinEntropy))
+	}
+	if _, ok := common[strings.ToLower(password)]; p.CheckCommon && ok {
+		violations = append(violations, "not a common password")
+	}
+	if p.CheckUsername && len(username) > 0 && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		violations = append(violations, "no username in the password")
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Policy: p, Violations: violations}
+	}
+	return nil
+}
+
+// Entropy estimates bits of the password by the size of used character
+// classes. Repeated characters count for half, so "aaaaaaaa" is weaker than
+// "abcdefgh".
+func Entropy(password string) float64 {
+	var lower, upper, digit, symbol, other bool
+	seen := make(map[rune]struct{})
+	length := 0.0
+
+	for _, r := range password {
+		switch {
+		case r < utf8.RuneSelf && unicode.IsLower(r):
+			lower = true
+		case r < utf8.RuneSelf && unicode.IsUpper(r):
+			upper = true
+		case r < utf8.RuneSelf && unicode.IsDigit(r):
+			digit = true
+		case r < utf8.RuneSelf:
+			symbol = true
+		default:
+			other = true
+		}
+
+		if _, ok := seen[r]; ok {
+			length += 0.5
+		} else {
+			seen[r] = struct{}{}
+			length++
+		}
+	}
+
+	pool := 0
+	for _, class := range []struct {
+		used bool
+		size int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if class.used {
+			pool += class.size
+		}
+	}
+	if pool == 0 {
+		return 0
+	}
+
+	return length * math.Log2(float64(pool))
+}
+
+func parseList(list string) map[string]struct{} {
+	passwords := make(map[string]struct{})
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords[strings.ToLower(line)] = struct{}{}
+	}
+	return passwords
+}
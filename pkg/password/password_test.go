@@ -0,0 +1,96 @@
+package password
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestEntropy(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		min, max float64
+	}{
+		{name: "empty", password: "", min: 0, max: 0},
+		{name: "repeated", password: "aaaaaaaa", min: 21, max: 22},
+		{name: "lower", password: "abcdefgh", min: 37, max: 38},
+		{name: "all_classes", password: "Tr0ub4dor&3", min: 68, max: 70},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Entropy(test.password); got < test.min || got > test.max {
+				t.Errorf("Wrong! Unexpected entropy!\n\tExpected: %v-%v\n\tActual: %v", test.min, test.max, got)
+			}
+		})
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	policy := Policy{MinLength: 10, MinEntropy: 40, CheckCommon: true, CheckUsername: true}
+
+	tests := []struct {
+		name          string
+		username      string
+		password      string
+		policy        Policy
+		expViolations []string
+	}{
+		{
+			name:     "zero_policy",
+			password: "a",
+		},
+		{
+			name:          "short",
+			password:      "Xy7#kLm",
+			policy:        policy,
+			expViolations: []string{"at least 10 characters"},
+		},
+		{
+			name:          "predictable",
+			password:      "aaaaaaaaaaaaaaaa",
+			policy:        policy,
+			expViolations: []string{"estimated entropy of at least 40 bits"},
+		},
+		{
+			name:          "common",
+			password:      "Password1234",
+			policy:        policy,
+			expViolations: []string{"not a common password"},
+		},
+		{
+			name:          "username",
+			username:      "Alice",
+			password:      "xx-alice-2024!",
+			policy:        policy,
+			expViolations: []string{"no username in the password"},
+		},
+		{
+			name:     "strong",
+			username: "alice",
+			password: "correct horse battery",
+			policy:   policy,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.policy.Check(test.username, test.password)
+
+			var policyErr *PolicyError
+			if len(test.expViolations) == 0 {
+				if err != nil {
+					t.Fatalf("Wrong! Unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &policyErr) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", test.expViolations, err)
+			}
+			if got, want := policyErr.Violations, test.expViolations; !slices.Equal(got, want) {
+				t.Errorf("Wrong! Unexpected violations!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
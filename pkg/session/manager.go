@@ -3,6 +3,7 @@ package session
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"net/http"
 	"time"
 
@@ -10,14 +11,24 @@ import (
 )
 
 type SessionManagerOptions struct {
-	Lifetime       time.Duration
+	// Lifetime is the absolute maximum of the session, activity doesn't extend it
+	Lifetime time.Duration
+	// IdleTimeout expires the session without requests
+	IdleTimeout time.Duration
+	// ReauthTimeout is the age of the last authentication treated as recent
+	ReauthTimeout  time.Duration
 	CookieName     string
 	CookieHTTPOnly bool
 	CookieSameSite http.SameSite
+	// DB with the sessions table, sessions are kept in memory without it
+	DB *sql.DB
+	// CleanupInterval of expired sessions in DB
+	CleanupInterval time.Duration
 }
 
 type SessionManager struct {
-	sm *scs.SessionManager
+	sm            *scs.SessionManager
+	reauthTimeout time.Duration
 }
 
 func NewSessionManager(ctx context.Context, opts SessionManagerOptions) (*SessionManager, error) {
@@ -26,11 +37,17 @@ func NewSessionManager(ctx context.Context, opts SessionManagerOptions) (*Sessio
 	sm := scs.New()
 
 	sm.Lifetime = opts.Lifetime
+	sm.IdleTimeout = opts.IdleTimeout
 	sm.Cookie.Name = opts.CookieName
 	sm.Cookie.HttpOnly = opts.CookieHTTPOnly
 	sm.Cookie.SameSite = opts.CookieSameSite
 
-	return &SessionManager{sm: sm}, nil
+	// The cleanup of the store lives until ctx is done
+	if opts.DB != nil {
+		sm.Store = newSQLiteStore(ctx, opts.DB, opts.CleanupInterval)
+	}
+
+	return &SessionManager{sm: sm, reauthTimeout: opts.ReauthTimeout}, nil
 }
 
 func (smw *SessionManager) LoadAndSave(next http.Handler) http.Handler {
@@ -53,9 +70,12 @@ func (smw *SessionManager) LoadAndSave(next http.Handler) http.Handler {
 
 		next.ServeHTTP(wb, rs)
 
-		if smw.sm.Status(ctx) == scs.Destroyed {
+		switch {
+		case smw.sm.Status(ctx) == scs.Destroyed:
 			smw.sm.WriteSessionCookie(ctx, w, "", time.Time{})
-		} else {
+		case smw.sm.Status(ctx) == scs.Unmodified && len(smw.sm.Keys(ctx)) == 0:
+			// Empty sessions of anonymous requests and unknown tokens aren't stored
+		default:
 			if err := smw.sm.RenewToken(ctx); err != nil {
 				smw.sm.ErrorFunc(w, r, err)
 				return
@@ -81,10 +101,32 @@ func (smw *SessionManager) GetString(ctx context.Context, key string) string {
 	return smw.sm.GetString(ctx, key)
 }
 
+// PopString returns the value and removes it from the session, it's used for
+// single-use values like challenges
+func (smw *SessionManager) PopString(ctx context.Context, key string) string {
+	return smw.sm.PopString(ctx, key)
+}
+
+func (smw *SessionManager) GetInt(ctx context.Context, key string) int {
+	return smw.sm.GetInt(ctx, key)
+}
+
+func (smw *SessionManager) GetInt64(ctx context.Context, key string) int64 {
+	return smw.sm.GetInt64(ctx, key)
+}
+
+func (smw *SessionManager) GetBool(ctx context.Context, key string) bool {
+	return smw.sm.GetBool(ctx, key)
+}
+
 func (smw *SessionManager) Put(ctx context.Context, key string, value any) {
 	smw.sm.Put(ctx, key, value)
 }
 
+func (smw *SessionManager) Remove(ctx context.Context, key string) {
+	smw.sm.Remove(ctx, key)
+}
+
 func (smw *SessionManager) Destroy(cxt context.Context) error {
 	return smw.sm.Destroy(cxt)
 }
@@ -93,15 +135,45 @@ func (smw *SessionManager) Keys(ctx context.Context) []string {
 	return smw.sm.Keys(ctx)
 }
 
+// MarkAuthenticated records that the user has just entered credentials
+func (smw *SessionManager) MarkAuthenticated(ctx context.Context) {
+	smw.sm.Put(ctx, "authenticated_at", time.Now().Unix())
+}
+
+// AuthenticatedRecently reports whether the session was authenticated within
+// ReauthTimeout, sensitive actions require it
+func (smw *SessionManager) AuthenticatedRecently(ctx context.Context) bool {
+	authenticatedAt := smw.sm.GetInt64(ctx, "authenticated_at")
+	return authenticatedAt > 0 && time.Since(time.Unix(authenticatedAt, 0)) < smw.reauthTimeout
+}
+
+// Iterate runs fn for every active session loaded into ctx, fn may destroy
+// the session. Changes of other values aren't saved.
+func (smw *SessionManager) Iterate(ctx context.Context, fn func(context.Context) error) error {
+	return smw.sm.Iterate(ctx, fn)
+}
+
 func prepareOptions(opts SessionManagerOptions) SessionManagerOptions {
 	if opts.Lifetime == 0 {
 		opts.Lifetime = 24 * time.Hour
 	}
 
+	if opts.IdleTimeout == 0 {
+		opts.IdleTimeout = time.Hour
+	}
+
+	if opts.ReauthTimeout == 0 {
+		opts.ReauthTimeout = 15 * time.Minute
+	}
+
 	if len(opts.CookieName) == 0 {
 		opts.CookieName = "session"
 	}
 
+	if opts.CleanupInterval == 0 {
+		opts.CleanupInterval = 5 * time.Minute
+	}
+
 	if opts.CookieSameSite == 0 {
 		opts.CookieSameSite = http.SameSiteLaxMode
 	}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -90,3 +91,60 @@ func TestLoadAndSave(t *testing.T) {
 	}
 }
 
+func TestIdleTimeout(t *testing.T) {
+	sm, _ := NewSessionManager(context.Background(), SessionManagerOptions{IdleTimeout: 100 * time.Millisecond})
+	router := chi.NewRouter()
+	router.Use(sm.LoadAndSave)
+	router.Get("/set", func(w http.ResponseWriter, r *http.Request) {
+		sm.Put(r.Context(), "user_id", "userID")
+	})
+	router.Get("/get", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(sm.GetString(r.Context(), "user_id")))
+	})
+
+	w1 := httptest.NewRecorder()
+	router.ServeHTTP(w1, httptest.NewRequest(http.MethodGet, "/set", nil))
+
+	time.Sleep(200 * time.Millisecond)
+
+	r2 := httptest.NewRequest(http.MethodGet, "/get", nil)
+	for _, cookie := range w1.Result().Cookies() {
+		r2.AddCookie(cookie)
+	}
+	w2 := httptest.NewRecorder()
+	router.ServeHTTP(w2, r2)
+
+	if data := w2.Body.String(); len(data) > 0 {
+		t.Fatalf("Wrong! Idle session is not expired, data: %s", data)
+	}
+}
+
+func TestAuthenticatedRecently(t *testing.T) {
+	sm, _ := NewSessionManager(context.Background(), SessionManagerOptions{ReauthTimeout: time.Minute})
+
+	tests := []struct {
+		name            string
+		authenticatedAt int64
+		expResult       bool
+	}{
+		{name: "never", expResult: false},
+		{name: "recently", authenticatedAt: time.Now().Add(-30 * time.Second).Unix(), expResult: true},
+		{name: "long_ago", authenticatedAt: time.Now().Add(-2 * time.Minute).Unix(), expResult: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, err := sm.sm.Load(context.Background(), "")
+			if err != nil {
+				t.Fatalf("Wrong! Unexpected error: %v", err)
+			}
+			if test.authenticatedAt > 0 {
+				sm.Put(ctx, "authenticated_at", test.authenticatedAt)
+			}
+
+			if got, want := sm.AuthenticatedRecently(ctx), test.expResult; got != want {
+				t.Errorf("Wrong! Unexpected result!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+		})
+	}
+}
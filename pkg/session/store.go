@@ -0,0 +1,108 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// sqliteStore keeps sessions in the sessions table, so they survive restarts
+type sqliteStore struct {
+	db *sql.DB
+}
+
+func newSQLiteStore(ctx context.Context, db *sql.DB, cleanupInterval time.Duration) *sqliteStore {
+	s := &sqliteStore{db: db}
+	go s.cleanup(ctx, cleanupInterval)
+	return s
+}
+
+func (s *sqliteStore) Find(token string) ([]byte, bool, error) {
+	return s.FindCtx(context.Background(), token)
+}
+
+func (s *sqliteStore) FindCtx(ctx context.Context, token string) ([]byte, bool, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, "select data from sessions where token = ? and expiry > ?", token, time.Now().Unix()).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+func (s *sqliteStore) Commit(token string, data []byte, expiry time.Time) error {
+	return s.CommitCtx(context.Background(), token, data, expiry)
+}
+
+func (s *sqliteStore) CommitCtx(ctx context.Context, token string, data []byte, expiry time.Time) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`insert into sessions (token, data, expiry) values (?, ?, ?)
+		  on conflict (token) do update set data = excluded.data, expiry = excluded.expiry`,
+		token, data, expiry.Unix(),
+	)
+	return err
+}
+
+func (s *sqliteStore) Delete(token string) error {
+	return s.DeleteCtx(context.Background(), token)
+}
+
+func (s *sqliteStore) DeleteCtx(ctx context.Context, token string) error {
+	_, err := s.db.ExecContext(ctx, "delete from sessions where token = ?", token)
+	return err
+}
+
+func (s *sqliteStore) All() (map[string][]byte, error) {
+	return s.AllCtx(context.Background())
+}
+
+// AllCtx returns all active sessions by their tokens
+func (s *sqliteStore) AllCtx(ctx context.Context) (map[string][]byte, error) {
+	rows, err := s.db.QueryContext(ctx, "select token, data from sessions where expiry > ?", time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make(map[string][]byte)
+	for rows.Next() {
+		var (
+			token string
+			data  []byte
+		)
+		if err := rows.Scan(&token, &data); err != nil {
+			return nil, err
+		}
+		sessions[token] = data
+	}
+	return sessions, rows.Err()
+}
+
+func (s *sqliteStore) deleteExpired(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, "delete from sessions where expiry <= ?", time.Now().Unix())
+	return err
+}
+
+// cleanup removes expired sessions until ctx is done. Find skips them anyway,
+// it only keeps the table small.
+func (s *sqliteStore) cleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.deleteExpired(ctx); err != nil && ctx.Err() == nil {
+				slog.Default().Warn("Failed removing expired sessions", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
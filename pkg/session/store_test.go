@@ -0,0 +1,168 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "data.db"))
+	if err != nil {
+		t.Fatalf("Wrong! Unexpected error: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	schema, err := os.ReadFile("../../migrations/10_sessions.up.sql")
+	if err != nil {
+		t.Fatalf("Wrong! Unexpected error: %v", err)
+	}
+	if _, err := db.Exec(string(schema)); err != nil {
+		t.Fatalf("Wrong! Unexpected error: %v", err)
+	}
+
+	return db
+}
+
+func TestSQLiteStore(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := newSQLiteStore(ctx, newTestDB(t), time.Hour)
+	now := time.Now()
+
+	if err := store.Commit("active", []byte("data"), now.Add(time.Hour)); err != nil {
+		t.Fatalf("Wrong! Unexpected error: %v", err)
+	}
+	if err := store.Commit("active", []byte("updated"), now.Add(time.Hour)); err != nil {
+		t.Fatalf("Wrong! Unexpected error: %v", err)
+	}
+	if err := store.Commit("expired", []byte("data"), now.Add(-time.Second)); err != nil {
+		t.Fatalf("Wrong! Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		expFound bool
+		expData  string
+	}{
+		{name: "active", token: "active", expFound: true, expData: "updated"},
+		{name: "expired", token: "expired", expFound: false},
+		{name: "unknown", token: "unknown", expFound: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, found, err := store.Find(test.token)
+			if err != nil {
+				t.Fatalf("Wrong! Unexpected error: %v", err)
+			}
+			if found != test.expFound || string(data) != test.expData {
+				t.Errorf("Wrong! Unexpected session!\n\tExpected: %v %q\n\tActual: %v %q", test.expFound, test.expData, found, data)
+			}
+		})
+	}
+
+	all, err := store.All()
+	if err != nil {
+		t.Fatalf("Wrong! Unexpected error: %v", err)
+	}
+	if _, ok := all["active"]; len(all) != 1 || !ok {
+		t.Errorf("Wrong! Only the active session is expected: %v", all)
+	}
+
+	if err := store.deleteExpired(ctx); err != nil {
+		t.Fatalf("Wrong! Unexpected error: %v", err)
+	}
+	var count int
+	if err := store.db.QueryRow("select count(*) from sessions").Scan(&count); err != nil {
+		t.Fatalf("Wrong! Unexpected error: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Wrong! Expired session is not removed, sessions: %d", count)
+	}
+
+	if err := store.Delete("active"); err != nil {
+		t.Fatalf("Wrong! Unexpected error: %v", err)
+	}
+	if _, found, _ := store.Find("active"); found {
+		t.Error("Wrong! Session is not deleted!")
+	}
+}
+
+func TestSessionSurvivesRestart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db := newTestDB(t)
+
+	newRouter := func() chi.Router {
+		sm, _ := NewSessionManager(ctx, SessionManagerOptions{DB: db})
+		router := chi.NewRouter()
+		router.Use(sm.LoadAndSave)
+		router.Get("/set", func(w http.ResponseWriter, r *http.Request) {
+			sm.Put(r.Context(), "user_id", "userID")
+		})
+		router.Get("/get", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(sm.GetString(r.Context(), "user_id")))
+		})
+		return router
+	}
+
+	w1 := httptest.NewRecorder()
+	newRouter().ServeHTTP(w1, httptest.NewRequest(http.MethodGet, "/set", nil))
+
+	// The new manager is the restarted application
+	r2 := httptest.NewRequest(http.MethodGet, "/get", nil)
+	for _, cookie := range w1.Result().Cookies() {
+		r2.AddCookie(cookie)
+	}
+	w2 := httptest.NewRecorder()
+	newRouter().ServeHTTP(w2, r2)
+
+	if got, want := w2.Body.String(), "userID"; got != want {
+		t.Fatalf("Wrong! Session is lost after restart!\n\tExpected: %s\n\tActual: %s", want, got)
+	}
+}
+
+func TestAnonymousSessionNotStored(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db := newTestDB(t)
+	sm, _ := NewSessionManager(ctx, SessionManagerOptions{DB: db})
+	router := chi.NewRouter()
+	router.Use(sm.LoadAndSave)
+	router.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	anonymous := httptest.NewRequest(http.MethodGet, "/", nil)
+	unknownToken := httptest.NewRequest(http.MethodGet, "/", nil)
+	unknownToken.AddCookie(&http.Cookie{Name: "session", Value: "unknown"})
+
+	for _, r := range []*http.Request{anonymous, unknownToken} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if cookies := w.Result().Cookies(); len(cookies) > 0 {
+			t.Errorf("Wrong! Cookie of the empty session is set: %v", cookies)
+		}
+	}
+	var count int
+	if err := db.QueryRow("select count(*) from sessions").Scan(&count); err != nil {
+		t.Fatalf("Wrong! Unexpected error: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Wrong! Empty session is stored, sessions: %d", count)
+	}
+}
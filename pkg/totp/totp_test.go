@@ -0,0 +1,88 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Secret of the test vectors from RFC 6238, appendix B
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+
+	for _, test := range tests {
+		got, err := Code(rfcSecret, time.Unix(test.unix, 0))
+		if err != nil {
+			t.Fatalf("Wrong! Unexpected error!\n\tExpected: nil\n\tActual: %v", err)
+		}
+		if got != test.want {
+			t.Errorf("Wrong! Unexpected code for %d!\n\tExpected: %s\n\tActual: %s", test.unix, test.want, got)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := Step(now)
+
+	tests := []struct {
+		name     string
+		codeTime time.Time
+		code     string
+		expStep  int64
+		expOK    bool
+	}{
+		{name: "current", codeTime: now, expStep: step, expOK: true},
+		{name: "previous", codeTime: now.Add(-Period), expStep: step - 1, expOK: true},
+		{name: "next", codeTime: now.Add(Period), expStep: step + 1, expOK: true},
+		{name: "expired", codeTime: now.Add(-2 * Period)},
+		{name: "wrong_length", code: "12345"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code := test.code
+			if len(code) == 0 {
+				code, _ = Code(rfcSecret, test.codeTime)
+			}
+
+			actStep, actOK, err := Validate(rfcSecret, code, now)
+			if err != nil {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: nil\n\tActual: %v", err)
+			}
+
+			if actOK != test.expOK || actStep != test.expStep {
+				t.Errorf("Wrong! Unexpected result!\n\tExpected: %d %t\n\tActual: %d %t", test.expStep, test.expOK, actStep, actOK)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("Wrong! Unexpected error!\n\tExpected: nil\n\tActual: %v", err)
+	}
+
+	if _, err := Code(secret, time.Now()); err != nil {
+		t.Errorf("Wrong! Generated secret is invalid: %v", err)
+	}
+
+	uri := URI("passman", "user", secret)
+	if !strings.HasPrefix(uri, "otpauth://totp/passman:user?") || !strings.Contains(uri, "secret="+secret) {
+		t.Errorf("Wrong! Unexpected URI: %s", uri)
+	}
+}
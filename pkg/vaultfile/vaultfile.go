@@ -0,0 +1,233 @@
+// Package vaultfile implements the portable export format of the vault.
+// The format is described in docs/export_format.md.
+package vaultfile
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"passman/pkg/cipher"
+)
+
+const (
+	PlainFormat     = "passman-export"
+	EncryptedFormat = "passman-encrypted-export"
+	Version         = 1
+
+	kdfArgon2id  = "argon2id"
+	cipherAESGCM = "aes-256-gcm"
+
+	// Limits of KDF parameters accepted from a file
+	maxKDFMemory      = 1024 * 1024
+	maxKDFIterations  = 16
+	maxKDFParallelism = 16
+)
+
+var (
+	ErrPasswordRequired = errors.New("password required")
+	ErrWrongPassword    = errors.New("wrong password or corrupted file")
+)
+
+type Vault struct {
+	Format      string       `json:"format"`
+	Version     int          `json:"version"`
+	ExportedAt  time.Time    `json:"exported_at"`
+	Services    []Service    `json:"services"`
+	Folders     []Folder     `json:"folders"`
+	Accounts    []Account    `json:"accounts"`
+	Attachments []Attachment `json:"attachments"`
+}
+
+type Service struct {
+	Name string `json:"name"`
+}
+
+type Folder struct {
+	Name string `json:"name"`
+}
+
+type Account struct {
+	Service  string `json:"service"`
+	Folder   string `json:"folder,omitempty"`
+	Name     string `json:"name"`
+	Login    string `json:"login"`
+	Password string `json:"password"`
+	TOTP     string `json:"totp,omitempty"`
+}
+
+type Attachment struct {
+	Service     string `json:"service"`
+	Account     string `json:"account"`
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+type envelope struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+	KDF     struct {
+		Name        string `json:"name"`
+		Memory      uint32 `json:"memory"`
+		Iterations  uint32 `json:"iterations"`
+		Parallelism uint8  `json:"parallelism"`
+		Salt        []byte `json:"salt"`
+	} `json:"kdf"`
+	Cipher struct {
+		Name  string `json:"name"`
+		Nonce []byte `json:"nonce"`
+	} `json:"cipher"`
+	Data []byte `json:"data"`
+}
+
+// New returns an empty vault with filled header
+func New(exportedAt time.Time) Vault {
+	return Vault{
+		Format:      PlainFormat,
+		Version:     Version,
+		ExportedAt:  exportedAt.UTC(),
+		Services:    []Service{},
+		Folders:     []Folder{},
+		Accounts:    []Account{},
+		Attachments: []Attachment{},
+	}
+}
+
+func WriteJSON(w io.Writer, v Vault) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func WriteCSV(w io.Writer, v Vault) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"service", "folder", "name", "login", "password", "totp"}); err != nil {
+		return err
+	}
+	for _, acc := range v.Accounts {
+		if err := cw.Write([]string{acc.Service, acc.Folder, acc.Name, acc.Login, acc.Password, acc.TOTP}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteEncrypted writes the vault encrypted by the key derived from password
+func WriteEncrypted(w io.Writer, v Vault, password string) error {
+	plain := bytes.NewBuffer(nil)
+	if err := json.NewEncoder(plain).Encode(v); err != nil {
+		return err
+	}
+
+	params, err := cipher.NewKDFParams()
+	if err != nil {
+		return fmt.Errorf("failed generating kdf parameters: %w", err)
+	}
+
+	env := envelope{Format: EncryptedFormat, Version: Version}
+	env.KDF.Name = kdfArgon2id
+	env.KDF.Memory = params.Memory
+	env.KDF.Iterations = params.Iterations
+	env.KDF.Parallelism = params.Parallelism
+	env.KDF.Salt = params.Salt
+	env.Cipher.Name = cipherAESGCM
+
+	env.Cipher.Nonce, env.Data, err = cipher.SealGCM(cipher.DeriveKey(password, params), plain.Bytes(), additionalData(env))
+	if err != nil {
+		return fmt.Errorf("failed encrypting vault: %w", err)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(env)
+}
+
+// Read reads the plain or encrypted vault. The password is required only
+// for the encrypted one.
+func Read(r io.Reader, password string) (Vault, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Vault{}, err
+	}
+
+	header := struct {
+		Format  string `json:"format"`
+		Version int    `json:"version"`
+	}{}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return Vault{}, fmt.Errorf("failed decoding json: %w", err)
+	}
+
+	if header.Version != Version {
+		return Vault{}, fmt.Errorf("unsupported version %d", header.Version)
+	}
+
+	switch header.Format {
+	case PlainFormat:
+	case EncryptedFormat:
+		if data, err = decrypt(data, password); err != nil {
+			return Vault{}, err
+		}
+	default:
+		return Vault{}, fmt.Errorf("unknown format %q", header.Format)
+	}
+
+	var v Vault
+	if err := json.Unmarshal(data, &v); err != nil {
+		return Vault{}, fmt.Errorf("failed decoding vault: %w", err)
+	}
+	if v.Format != PlainFormat {
+		return Vault{}, fmt.Errorf("unknown format %q", v.Format)
+	}
+
+	return v, nil
+}
+
+func decrypt(data []byte, password string) ([]byte, error) {
+	if len(password) == 0 {
+		return nil, ErrPasswordRequired
+	}
+
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("failed decoding envelope: %w", err)
+	}
+
+	if env.KDF.Name != kdfArgon2id || env.Cipher.Name != cipherAESGCM {
+		return nil, fmt.Errorf("unsupported kdf %q or cipher %q", env.KDF.Name, env.Cipher.Name)
+	}
+
+	if env.KDF.Memory > maxKDFMemory || env.KDF.Iterations > maxKDFIterations || env.KDF.Parallelism > maxKDFParallelism ||
+		env.KDF.Iterations == 0 || env.KDF.Parallelism == 0 {
+		return nil, fmt.Errorf("unsupported kdf parameters")
+	}
+
+	params := cipher.KDFParams{
+		Memory:      env.KDF.Memory,
+		Iterations:  env.KDF.Iterations,
+		Parallelism: env.KDF.Parallelism,
+		Salt:        env.KDF.Salt,
+	}
+
+	plain, err := cipher.OpenGCM(cipher.DeriveKey(password, params), env.Cipher.Nonce, env.Data, additionalData(env))
+	if err != nil {
+		return nil, ErrWrongPassword
+	}
+
+	return plain, nil
+}
+
+// additionalData binds the envelope header to the ciphertext
+func additionalData(env envelope) []byte {
+	return fmt.Appendf(nil, "%s/%d/%s/%d/%d/%d/%x/%s",
+		env.Format, env.Version,
+		env.KDF.Name, env.KDF.Memory, env.KDF.Iterations, env.KDF.Parallelism, env.KDF.Salt,
+		env.Cipher.Name,
+	)
+}
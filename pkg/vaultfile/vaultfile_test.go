@@ -0,0 +1,69 @@
+package vaultfile
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadWrite(t *testing.T) {
+	vault := New(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+	vault.Services = append(vault.Services, Service{Name: "google"})
+	vault.Accounts = append(vault.Accounts, Account{Service: "google", Name: "main", Login: "login", Password: "'pass,word\""})
+
+	plain := bytes.NewBuffer(nil)
+	if err := WriteJSON(plain, vault); err != nil {
+		t.Fatalf("failed writing json: %v", err)
+	}
+
+	encrypted := bytes.NewBuffer(nil)
+	if err := WriteEncrypted(encrypted, vault, "secret password"); err != nil {
+		t.Fatalf("failed writing encrypted json: %v", err)
+	}
+	if strings.Contains(encrypted.String(), "pass,word") {
+		t.Fatal("Wrong! Encrypted file contains plain password!")
+	}
+
+	tests := []struct {
+		name     string
+		input    []byte
+		password string
+		expErr   error
+	}{
+		{name: "plain", input: plain.Bytes()},
+		{name: "encrypted", input: encrypted.Bytes(), password: "secret password"},
+		{name: "password_required", input: encrypted.Bytes(), expErr: ErrPasswordRequired},
+		{name: "wrong_password", input: encrypted.Bytes(), password: "wrong", expErr: ErrWrongPassword},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actVault, actErr := Read(bytes.NewReader(test.input), test.password)
+
+			if got, want := actErr, test.expErr; !errors.Is(got, want) {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", want, got)
+			}
+
+			if test.expErr == nil && !reflect.DeepEqual(actVault, vault) {
+				t.Errorf("Wrong! Unexpected vault!\n\tExpected: %+v\n\tActual: %+v", vault, actVault)
+			}
+		})
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	vault := New(time.Now())
+	vault.Accounts = append(vault.Accounts, Account{Service: "google", Name: "main", Login: "login", Password: "pass,word", TOTP: "JBSWY3DPEHPK3PXP"})
+
+	buf := bytes.NewBuffer(nil)
+	if err := WriteCSV(buf, vault); err != nil {
+		t.Fatalf("failed writing csv: %v", err)
+	}
+
+	if got, want := buf.String(), "service,folder,name,login,password,totp\ngoogle,,main,login,\"pass,word\",JBSWY3DPEHPK3PXP\n"; got != want {
+		t.Errorf("Wrong! Unexpected csv!\n\tExpected: %q\n\tActual: %q", want, got)
+	}
+}
@@ -0,0 +1,51 @@
+package webauthn
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeCBOR(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    any
+		wantLen int
+		expErr  bool
+	}{
+		{name: "small_uint", data: []byte{0x17}, want: int64(23), wantLen: 1},
+		{name: "uint16", data: []byte{0x19, 0x01, 0x00}, want: int64(256), wantLen: 3},
+		{name: "negative", data: []byte{0x38, 0x63}, want: int64(-100), wantLen: 2},
+		{name: "bytes", data: []byte{0x42, 0x01, 0x02}, want: []byte{1, 2}, wantLen: 3},
+		{name: "text", data: []byte{0x63, 'f', 'm', 't'}, want: "fmt", wantLen: 4},
+		{name: "array", data: []byte{0x82, 0x01, 0xf5}, want: []any{int64(1), true}, wantLen: 3},
+		{name: "map", data: []byte{0xa1, 0x20, 0xf6}, want: map[any]any{int64(-1): nil}, wantLen: 3},
+		{name: "trailing_data", data: []byte{0x01, 0x02}, want: int64(1), wantLen: 1},
+		{name: "truncated_bytes", data: []byte{0x45, 0x01}, expErr: true},
+		{name: "indefinite_length", data: []byte{0x5f, 0x41, 0x01, 0xff}, expErr: true},
+		{name: "duplicate_key", data: []byte{0xa2, 0x01, 0x01, 0x01, 0x02}, expErr: true},
+		{name: "tag", data: []byte{0xc1, 0x01}, expErr: true},
+		{name: "huge_array", data: []byte{0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, expErr: true},
+		{name: "too_deep", data: []byte{0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x81, 0x01}, expErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, n, err := decodeCBOR(test.data)
+
+			if test.expErr {
+				if !errors.Is(err, errMalformedCBOR) {
+					t.Fatalf("Wrong! Unexpected error!\n\tExpected: %v\n\tActual: %v", errMalformedCBOR, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: nil\n\tActual: %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) || n != test.wantLen {
+				t.Errorf("Wrong! Unexpected item!\n\tExpected: %#v (%d bytes)\n\tActual: %#v (%d bytes)", test.want, test.wantLen, got, n)
+			}
+		})
+	}
+}
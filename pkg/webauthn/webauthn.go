@@ -0,0 +1,423 @@
+// Package webauthn implements the relying party side of WebAuthn registration
+// and authentication ceremonies (https://www.w3.org/TR/webauthn-2/).
+//
+// Attestation statements aren't verified: the relying party always asks for
+// "none" attestation, so the authenticator model isn't trusted or checked.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	challengeSize = 32
+	// Timeout of the ceremony for the client
+	Timeout = 5 * time.Minute
+
+	maxCredentialIDSize = 1023
+)
+
+// User verification requirements
+const (
+	VerificationRequired  = "required"
+	VerificationPreferred = "preferred"
+)
+
+// Flags of the authenticator data
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttested     = 0x40
+)
+
+// ErrInvalidResponse wraps every reason of rejecting the client response
+var ErrInvalidResponse = errors.New("invalid webauthn response")
+
+// RelyingParty verifies ceremonies for the single RP ID. Origins are the
+// exact origins the client pages are served from, e.g. https://pm.example.com.
+type RelyingParty struct {
+	ID      string
+	Name    string
+	Origins []string
+}
+
+func New(id, name string, origins ...string) *RelyingParty {
+	return &RelyingParty{ID: id, Name: name, Origins: origins}
+}
+
+// User is the account the credential is created for. ID is the user handle,
+// it must not contain personal information.
+type User struct {
+	ID          []byte
+	Name        string
+	DisplayName string
+}
+
+// Credential is the registered public key credential
+type Credential struct {
+	ID []byte
+	// PublicKey is the COSE_Key of the credential
+	PublicKey []byte
+	SignCount uint32
+}
+
+type RelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type UserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions are passed to navigator.credentials.create() as publicKey.
+// Binary values are base64url encoded.
+type CreationOptions struct {
+	Challenge              string                 `json:"challenge"`
+	RP                     RelyingPartyEntity     `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions are passed to navigator.credentials.get() as publicKey.
+// Empty AllowCredentials asks for a discoverable credential.
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int64                  `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// RegistrationResponse is the JSON form of the PublicKeyCredential returned
+// by navigator.credentials.create()
+type RegistrationResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AttestationObject string `json:"attestationObject"`
+	} `json:"response"`
+}
+
+// AssertionResponse is the JSON form of the PublicKeyCredential returned by
+// navigator.credentials.get()
+type AssertionResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle"`
+	} `json:"response"`
+}
+
+// CredentialID returns the decoded raw id of the credential
+func (ar AssertionResponse) CredentialID() ([]byte, error) {
+	return credentialID(ar.ID, ar.RawID)
+}
+
+// UserHandle returns the decoded user handle, it's empty if the
+// authenticator didn't return it
+func (ar AssertionResponse) UserHandle() ([]byte, error) {
+	userHandle, err := DecodeBase64(ar.Response.UserHandle)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed user handle", ErrInvalidResponse)
+	}
+	return userHandle, nil
+}
+
+// NewChallenge returns the random base64url encoded challenge
+func NewChallenge() (string, error) {
+	challenge := make([]byte, challengeSize)
+	if _, err := rand.Read(challenge); err != nil {
+		return "", err
+	}
+	return EncodeBase64(challenge), nil
+}
+
+func (rp *RelyingParty) CreationOptions(challenge string, user User, exclude [][]byte) CreationOptions {
+	return CreationOptions{
+		Challenge: challenge,
+		RP:        RelyingPartyEntity{ID: rp.ID, Name: rp.Name},
+		User: UserEntity{
+			ID:          EncodeBase64(user.ID),
+			Name:        user.Name,
+			DisplayName: user.DisplayName,
+		},
+		PubKeyCredParams: []CredentialParameter{
+			{Type: "public-key", Alg: AlgES256},
+			{Type: "public-key", Alg: AlgEdDSA},
+			{Type: "public-key", Alg: AlgRS256},
+		},
+		Timeout:            Timeout.Milliseconds(),
+		ExcludeCredentials: descriptors(exclude),
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: VerificationPreferred,
+		},
+		Attestation: "none",
+	}
+}
+
+func (rp *RelyingParty) RequestOptions(challenge string, allow [][]byte, userVerification string) RequestOptions {
+	return RequestOptions{
+		Challenge:        challenge,
+		Timeout:          Timeout.Milliseconds(),
+		RPID:             rp.ID,
+		AllowCredentials: descriptors(allow),
+		UserVerification: userVerification,
+	}
+}
+
+// VerifyRegistration checks the response of the registration ceremony started
+// with the challenge and returns the new credential
+func (rp *RelyingParty) VerifyRegistration(challenge string, resp RegistrationResponse, requireUV bool) (Credential, error) {
+	if resp.Type != "public-key" {
+		return Credential{}, fmt.Errorf("%w: unexpected credential type", ErrInvalidResponse)
+	}
+
+	id, err := credentialID(resp.ID, resp.RawID)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	if _, err := rp.verifyClientData(resp.Response.ClientDataJSON, "webauthn.create", challenge); err != nil {
+		return Credential{}, err
+	}
+
+	rawAttestation, err := DecodeBase64(resp.Response.AttestationObject)
+	if err != nil {
+		return Credential{}, fmt.Errorf("%w: malformed attestation object", ErrInvalidResponse)
+	}
+	item, _, err := decodeCBOR(rawAttestation)
+	if err != nil {
+		return Credential{}, fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+	}
+	attestation, ok := item.(map[any]any)
+	if !ok {
+		return Credential{}, fmt.Errorf("%w: malformed attestation object", ErrInvalidResponse)
+	}
+	rawAuthData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return Credential{}, fmt.Errorf("%w: missing authenticator data", ErrInvalidResponse)
+	}
+
+	authData, err := rp.verifyAuthenticatorData(rawAuthData, requireUV)
+	if err != nil {
+		return Credential{}, err
+	}
+	if authData.flags&flagAttested == 0 {
+		return Credential{}, fmt.Errorf("%w: missing attested credential data", ErrInvalidResponse)
+	}
+	if !bytes.Equal(authData.credentialID, id) {
+		return Credential{}, fmt.Errorf("%w: credential id mismatch", ErrInvalidResponse)
+	}
+	if _, err := parsePublicKey(authData.publicKey); err != nil {
+		return Credential{}, fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+	}
+
+	return Credential{ID: id, PublicKey: authData.publicKey, SignCount: authData.signCount}, nil
+}
+
+// VerifyAssertion checks the response of the authentication ceremony started
+// with the challenge and returns the new signature counter of the credential
+func (rp *RelyingParty) VerifyAssertion(challenge string, cred Credential, resp AssertionResponse, requireUV bool) (uint32, error) {
+	if resp.Type != "public-key" {
+		return 0, fmt.Errorf("%w: unexpected credential type", ErrInvalidResponse)
+	}
+
+	id, err := resp.CredentialID()
+	if err != nil {
+		return 0, err
+	}
+	if !bytes.Equal(id, cred.ID) {
+		return 0, fmt.Errorf("%w: credential id mismatch", ErrInvalidResponse)
+	}
+
+	clientData, err := rp.verifyClientData(resp.Response.ClientDataJSON, "webauthn.get", challenge)
+	if err != nil {
+		return 0, err
+	}
+
+	rawAuthData, err := DecodeBase64(resp.Response.AuthenticatorData)
+	if err != nil {
+		return 0, fmt.Errorf("%w: malformed authenticator data", ErrInvalidResponse)
+	}
+	authData, err := rp.verifyAuthenticatorData(rawAuthData, requireUV)
+	if err != nil {
+		return 0, err
+	}
+
+	signature, err := DecodeBase64(resp.Response.Signature)
+	if err != nil {
+		return 0, fmt.Errorf("%w: malformed signature", ErrInvalidResponse)
+	}
+
+	key, err := parsePublicKey(cred.PublicKey)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+	}
+
+	// The signature covers authenticator data followed by the client data hash
+	clientDataHash := sha256.Sum256(clientData)
+	signed := slices.Concat(rawAuthData, clientDataHash[:])
+	if !key.verify(signed, signature) {
+		return 0, fmt.Errorf("%w: invalid signature", ErrInvalidResponse)
+	}
+
+	// Authenticators without a counter always return zero. Otherwise the
+	// counter has to grow, a smaller value means the authenticator is cloned.
+	if (authData.signCount != 0 || cred.SignCount != 0) && authData.signCount <= cred.SignCount {
+		return 0, fmt.Errorf("%w: signature counter did not increase", ErrInvalidResponse)
+	}
+
+	return authData.signCount, nil
+}
+
+// verifyClientData checks the client data and returns it decoded
+func (rp *RelyingParty) verifyClientData(encoded, ceremony, challenge string) ([]byte, error) {
+	raw, err := DecodeBase64(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed client data", ErrInvalidResponse)
+	}
+
+	clientData := struct {
+		Type        string `json:"type"`
+		Challenge   string `json:"challenge"`
+		Origin      string `json:"origin"`
+		CrossOrigin bool   `json:"crossOrigin"`
+	}{}
+	if err := json.Unmarshal(raw, &clientData); err != nil {
+		return nil, fmt.Errorf("%w: malformed client data", ErrInvalidResponse)
+	}
+
+	if clientData.Type != ceremony {
+		return nil, fmt.Errorf("%w: unexpected ceremony type", ErrInvalidResponse)
+	}
+	if len(challenge) == 0 || subtle.ConstantTimeCompare([]byte(clientData.Challenge), []byte(challenge)) != 1 {
+		return nil, fmt.Errorf("%w: challenge mismatch", ErrInvalidResponse)
+	}
+	if !slices.Contains(rp.Origins, clientData.Origin) || clientData.CrossOrigin {
+		return nil, fmt.Errorf("%w: unexpected origin", ErrInvalidResponse)
+	}
+
+	return raw, nil
+}
+
+type authenticatorData struct {
+	flags        byte
+	signCount    uint32
+	credentialID []byte
+	publicKey    []byte
+}
+
+func (rp *RelyingParty) verifyAuthenticatorData(raw []byte, requireUV bool) (authenticatorData, error) {
+	// rpIdHash (32) | flags (1) | signCount (4) | attested credential data
+	if len(raw) < 37 {
+		return authenticatorData{}, fmt.Errorf("%w: authenticator data is too short", ErrInvalidResponse)
+	}
+
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(raw[:32], rpIDHash[:]) {
+		return authenticatorData{}, fmt.Errorf("%w: unexpected relying party", ErrInvalidResponse)
+	}
+
+	authData := authenticatorData{flags: raw[32], signCount: binary.BigEndian.Uint32(raw[33:37])}
+	if authData.flags&flagUserPresent == 0 {
+		return authenticatorData{}, fmt.Errorf("%w: user is not present", ErrInvalidResponse)
+	}
+	if requireUV && authData.flags&flagUserVerified == 0 {
+		return authenticatorData{}, fmt.Errorf("%w: user is not verified", ErrInvalidResponse)
+	}
+
+	if authData.flags&flagAttested == 0 {
+		return authData, nil
+	}
+
+	// aaguid (16) | credentialIdLength (2) | credentialId | credentialPublicKey
+	rest := raw[37:]
+	if len(rest) < 18 {
+		return authenticatorData{}, fmt.Errorf("%w: attested credential data is too short", ErrInvalidResponse)
+	}
+	idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if idLength == 0 || idLength > maxCredentialIDSize || len(rest) < idLength {
+		return authenticatorData{}, fmt.Errorf("%w: invalid credential id", ErrInvalidResponse)
+	}
+	authData.credentialID, rest = rest[:idLength], rest[idLength:]
+
+	// The public key may be followed by extensions
+	_, keyLength, err := decodeCBOR(rest)
+	if err != nil {
+		return authenticatorData{}, fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+	}
+	authData.publicKey = rest[:keyLength]
+
+	return authData, nil
+}
+
+func credentialID(id, rawID string) ([]byte, error) {
+	raw, err := DecodeBase64(rawID)
+	if err != nil || len(raw) == 0 || len(raw) > maxCredentialIDSize {
+		return nil, fmt.Errorf("%w: malformed credential id", ErrInvalidResponse)
+	}
+	if id != EncodeBase64(raw) {
+		return nil, fmt.Errorf("%w: credential id mismatch", ErrInvalidResponse)
+	}
+	return raw, nil
+}
+
+func descriptors(ids [][]byte) []CredentialDescriptor {
+	res := make([]CredentialDescriptor, 0, len(ids))
+	for _, id := range ids {
+		res = append(res, CredentialDescriptor{Type: "public-key", ID: EncodeBase64(id)})
+	}
+	return res
+}
+
+// EncodeBase64 encodes binary values the way WebAuthn JSON does: base64url
+// without padding
+func EncodeBase64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeBase64 accepts base64url with or without padding
+func DecodeBase64(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,160 @@
+package webauthn_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"passman/pkg/webauthn"
+	"passman/pkg/webauthn/webauthntest"
+)
+
+const origin = "https://pm.example.com"
+
+var rp = webauthn.New("pm.example.com", "passman", origin)
+
+func register(t *testing.T, authenticator *webauthntest.Authenticator) webauthn.Credential {
+	t.Helper()
+
+	challenge, _ := webauthn.NewChallenge()
+	opts := rp.CreationOptions(challenge, webauthn.User{ID: []byte("user-handle"), Name: "user1"}, nil)
+
+	resp, err := authenticator.Register(opts)
+	if err != nil {
+		t.Fatalf("Wrong! Unexpected authenticator error!\n\tExpected: nil\n\tActual: %v", err)
+	}
+
+	cred, err := rp.VerifyRegistration(challenge, resp, true)
+	if err != nil {
+		t.Fatalf("Wrong! Unexpected error!\n\tExpected: nil\n\tActual: %v", err)
+	}
+	return cred
+}
+
+func TestVerifyRegistration(t *testing.T) {
+	tests := []struct {
+		name      string
+		origin    string
+		uv        bool
+		requireUV bool
+		challenge string
+		modify    func(*webauthn.RegistrationResponse)
+		expErr    bool
+	}{
+		{name: "success", origin: origin, uv: true, requireUV: true},
+		{name: "user_presence_only", origin: origin, requireUV: false},
+		{name: "user_not_verified", origin: origin, requireUV: true, expErr: true},
+		{name: "wrong_origin", origin: "https://phishing.example.com", uv: true, expErr: true},
+		{name: "wrong_challenge", origin: origin, uv: true, challenge: "another", expErr: true},
+		{
+			name:   "id_mismatch",
+			origin: origin,
+			uv:     true,
+			modify: func(r *webauthn.RegistrationResponse) { r.ID = webauthn.EncodeBase64([]byte("other")) },
+			expErr: true,
+		},
+		{
+			name:   "broken_attestation",
+			origin: origin,
+			uv:     true,
+			modify: func(r *webauthn.RegistrationResponse) { r.Response.AttestationObject = "oWNmbXQ" },
+			expErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			authenticator := webauthntest.NewAuthenticator(test.origin)
+			authenticator.UserVerified = test.uv
+
+			challenge, _ := webauthn.NewChallenge()
+			resp, err := authenticator.Register(rp.CreationOptions(challenge, webauthn.User{ID: []byte("user-handle"), Name: "user1"}, nil))
+			if err != nil {
+				t.Fatalf("Wrong! Unexpected authenticator error!\n\tExpected: nil\n\tActual: %v", err)
+			}
+			if test.modify != nil {
+				test.modify(&resp)
+			}
+			if len(test.challenge) > 0 {
+				challenge = test.challenge
+			}
+
+			cred, err := rp.VerifyRegistration(challenge, resp, test.requireUV)
+
+			if got, want := err != nil, test.expErr; got != want {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %t\n\tActual: %v", want, err)
+			}
+			if err != nil && !errors.Is(err, webauthn.ErrInvalidResponse) {
+				t.Fatalf("Wrong! Error isn't ErrInvalidResponse!\n\tActual: %v", err)
+			}
+			if err == nil && !bytes.Equal(cred.ID, authenticator.CredentialID()) {
+				t.Fatalf("Wrong! Unexpected credential id!\n\tExpected: %x\n\tActual: %x", authenticator.CredentialID(), cred.ID)
+			}
+		})
+	}
+}
+
+func TestVerifyAssertion(t *testing.T) {
+	tests := []struct {
+		name      string
+		signCount uint32
+		uv        bool
+		requireUV bool
+		challenge string
+		modify    func(*webauthn.AssertionResponse)
+		expErr    bool
+	}{
+		{name: "success", uv: true, requireUV: true},
+		{name: "second_factor", requireUV: false},
+		{name: "user_not_verified", requireUV: true, expErr: true},
+		{name: "wrong_challenge", uv: true, challenge: "another", expErr: true},
+		{name: "cloned_authenticator", uv: true, signCount: 10, expErr: true},
+		{
+			name: "wrong_signature",
+			uv:   true,
+			modify: func(r *webauthn.AssertionResponse) {
+				r.Response.Signature = webauthn.EncodeBase64([]byte("0"))
+			},
+			expErr: true,
+		},
+		{
+			name: "another_credential",
+			uv:   true,
+			modify: func(r *webauthn.AssertionResponse) {
+				r.ID = webauthn.EncodeBase64([]byte("other"))
+				r.RawID = r.ID
+			},
+			expErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			authenticator := webauthntest.NewAuthenticator(origin)
+			cred := register(t, authenticator)
+			cred.SignCount = test.signCount
+			authenticator.UserVerified = test.uv
+
+			challenge, _ := webauthn.NewChallenge()
+			resp, err := authenticator.Login(rp.RequestOptions(challenge, [][]byte{cred.ID}, webauthn.VerificationPreferred))
+			if err != nil {
+				t.Fatalf("Wrong! Unexpected authenticator error!\n\tExpected: nil\n\tActual: %v", err)
+			}
+			if test.modify != nil {
+				test.modify(&resp)
+			}
+			if len(test.challenge) > 0 {
+				challenge = test.challenge
+			}
+
+			signCount, err := rp.VerifyAssertion(challenge, cred, resp, test.requireUV)
+
+			if got, want := err != nil, test.expErr; got != want {
+				t.Fatalf("Wrong! Unexpected error!\n\tExpected: %t\n\tActual: %v", want, err)
+			}
+			if err == nil && signCount != authenticator.SignCount {
+				t.Fatalf("Wrong! Unexpected sign count!\n\tExpected: %d\n\tActual: %d", authenticator.SignCount, signCount)
+			}
+		})
+	}
+}
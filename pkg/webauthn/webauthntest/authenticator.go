@@ -0,0 +1,157 @@
+// Package webauthntest provides the software authenticator for testing
+// WebAuthn ceremonies without a browser
+package webauthntest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"slices"
+
+	"passman/pkg/webauthn"
+)
+
+// Authenticator holds one ES256 credential. It's created by Register and
+// used by Login.
+type Authenticator struct {
+	Origin string
+	// UserVerified sets the UV flag in responses
+	UserVerified bool
+	SignCount    uint32
+
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	rpID         string
+}
+
+func NewAuthenticator(origin string) *Authenticator {
+	return &Authenticator{Origin: origin, UserVerified: true}
+}
+
+func (a *Authenticator) CredentialID() []byte {
+	return a.credentialID
+}
+
+// Register creates the credential like navigator.credentials.create()
+func (a *Authenticator) Register(opts webauthn.CreationOptions) (webauthn.RegistrationResponse, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return webauthn.RegistrationResponse{}, err
+	}
+	credentialID := make([]byte, 16)
+	if _, err := rand.Read(credentialID); err != nil {
+		return webauthn.RegistrationResponse{}, err
+	}
+	userHandle, err := webauthn.DecodeBase64(opts.User.ID)
+	if err != nil {
+		return webauthn.RegistrationResponse{}, err
+	}
+
+	a.key, a.credentialID, a.userHandle, a.rpID = key, credentialID, userHandle, opts.RP.ID
+
+	clientData, err := a.clientData("webauthn.create", opts.Challenge)
+	if err != nil {
+		return webauthn.RegistrationResponse{}, err
+	}
+
+	authData := a.authenticatorData(0x40)
+	authData = append(authData, make([]byte, 16)...)
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(credentialID)))
+	authData = append(authData, credentialID...)
+	authData = append(authData, a.publicKey()...)
+
+	attestation := encodeMap(
+		encodeText("fmt"), encodeText("none"),
+		encodeText("attStmt"), encodeMap(),
+		encodeText("authData"), encodeBytes(authData),
+	)
+
+	resp := webauthn.RegistrationResponse{
+		ID:    webauthn.EncodeBase64(credentialID),
+		RawID: webauthn.EncodeBase64(credentialID),
+		Type:  "public-key",
+	}
+	resp.Response.ClientDataJSON = webauthn.EncodeBase64(clientData)
+	resp.Response.AttestationObject = webauthn.EncodeBase64(attestation)
+
+	return resp, nil
+}
+
+// Login signs the challenge like navigator.credentials.get()
+func (a *Authenticator) Login(opts webauthn.RequestOptions) (webauthn.AssertionResponse, error) {
+	if a.key == nil {
+		return webauthn.AssertionResponse{}, errors.New("credential is not registered")
+	}
+	if opts.RPID != a.rpID {
+		return webauthn.AssertionResponse{}, errors.New("credential of another relying party")
+	}
+	if len(opts.AllowCredentials) > 0 && !slices.ContainsFunc(opts.AllowCredentials, func(d webauthn.CredentialDescriptor) bool {
+		return d.ID == webauthn.EncodeBase64(a.credentialID)
+	}) {
+		return webauthn.AssertionResponse{}, errors.New("credential is not allowed")
+	}
+
+	clientData, err := a.clientData("webauthn.get", opts.Challenge)
+	if err != nil {
+		return webauthn.AssertionResponse{}, err
+	}
+
+	a.SignCount++
+	authData := a.authenticatorData(0)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(slices.Concat(authData, clientDataHash[:]))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		return webauthn.AssertionResponse{}, err
+	}
+
+	resp := webauthn.AssertionResponse{
+		ID:    webauthn.EncodeBase64(a.credentialID),
+		RawID: webauthn.EncodeBase64(a.credentialID),
+		Type:  "public-key",
+	}
+	resp.Response.ClientDataJSON = webauthn.EncodeBase64(clientData)
+	resp.Response.AuthenticatorData = webauthn.EncodeBase64(authData)
+	resp.Response.Signature = webauthn.EncodeBase64(signature)
+	resp.Response.UserHandle = webauthn.EncodeBase64(a.userHandle)
+
+	return resp, nil
+}
+
+func (a *Authenticator) clientData(ceremony, challenge string) ([]byte, error) {
+	return json.Marshal(struct {
+		Type      string `json:"type"`
+		Challenge string `json:"challenge"`
+		Origin    string `json:"origin"`
+	}{Type: ceremony, Challenge: challenge, Origin: a.Origin})
+}
+
+func (a *Authenticator) authenticatorData(flags byte) []byte {
+	flags |= 0x01
+	if a.UserVerified {
+		flags |= 0x04
+	}
+
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	authData := append(rpIDHash[:], flags)
+	return binary.BigEndian.AppendUint32(authData, a.SignCount)
+}
+
+// publicKey encodes the key as COSE_Key
+func (a *Authenticator) publicKey() []byte {
+	x := a.key.X.FillBytes(make([]byte, 32))
+	y := a.key.Y.FillBytes(make([]byte, 32))
+	return encodeMap(
+		encodeInt(1), encodeInt(2),
+		encodeInt(3), encodeInt(webauthn.AlgES256),
+		encodeInt(-1), encodeInt(1),
+		encodeInt(-2), encodeBytes(x),
+		encodeInt(-3), encodeBytes(y),
+	)
+}
@@ -1,23 +1,30 @@
 -- name: AddAccount :exec
-insert into accounts (id, user_id, service_id, name, secret, payload) values (?, ?, ?, ?, ?, ?);
+insert into accounts (id, user_id, collection_id, service_id, name, secret, payload) values (?, ?, ?, ?, ?, ?, ?);
 
 -- name: GetUserAccountsInService :many
 select accounts.id, accounts.name, accounts.secret, accounts.payload from accounts
   left join services on services.id = accounts.service_id
-  where accounts.user_id = ? and services.name = ?;
+  where accounts.user_id is ? and accounts.collection_id is ? and services.name = ?;
+
+-- name: GetUserAccounts :many
+select accounts.id, accounts.name, accounts.secret, accounts.payload, services.name as service_name from accounts
+  join services on services.id = accounts.service_id
+  where accounts.user_id = ?
+  order by services.name, accounts.name;
 
 -- name: GetServiceID :one
 select id from services where name = ?;
 
 -- name: GetAccountID :one
-select id from accounts where name = ? and service_id = ? and user_id = ?;
+select id from accounts where name = ? and service_id = ? and user_id is ? and collection_id is ?;
 
 -- name: UpdateAccount :exec
-update accounts set name = ?, secret = ?, payload = ? where user_id = ? and name = sqlc.arg(old_name);
+update accounts set name = ?, secret = ?, payload = ? where user_id is ? and collection_id is ? and name = sqlc.arg(old_name);
 
 -- name: RemoveAccount :exec
 delete from accounts
-  where accounts.user_id = ? and
+  where accounts.user_id is ? and
+  accounts.collection_id is ? and
   accounts.name = ? and
   accounts.service_id in (
     select services.id from services
@@ -29,5 +36,53 @@ delete from accounts
   where id in (
     select accounts.id from accounts
       left join services on services.id = accounts.service_id
-      where accounts.user_id = ? and services.name = ?
+      where accounts.user_id is ? and accounts.collection_id is ? and services.name = ?
   );
+
+-- name: UpdateAccountByID :exec
+update accounts set secret = ?, payload = ? where id = ?;
+
+-- name: GetUserID :one
+select id from users where username = ?;
+
+-- name: AddShare :exec
+insert into account_shares (account_id, grantee_id, permission) values (?, ?, ?)
+  on conflict (account_id, grantee_id) do update set permission = excluded.permission;
+
+-- name: GetAccountShares :many
+select account_shares.grantee_id, users.username, account_shares.permission from account_shares
+  join users on users.id = account_shares.grantee_id
+  where account_shares.account_id = ?
+  order by users.username;
+
+-- name: RemoveShare :exec
+delete from account_shares where account_id = ? and grantee_id = ?;
+
+-- name: GetSharedAccounts :many
+select accounts.id, accounts.name, accounts.secret, accounts.payload, services.name as service_name,
+  users.username as owner, account_shares.permission from account_shares
+  join accounts on accounts.id = account_shares.account_id
+  join services on services.id = accounts.service_id
+  join users on users.id = accounts.user_id
+  where account_shares.grantee_id = ?
+  order by services.name, accounts.name, users.username;
+
+-- name: GetSharedAccountsInService :many
+select accounts.id, accounts.name, accounts.secret, accounts.payload, users.username as owner, account_shares.permission from account_shares
+  join accounts on accounts.id = account_shares.account_id
+  join services on services.id = accounts.service_id
+  join users on users.id = accounts.user_id
+  where account_shares.grantee_id = ? and services.name = ?
+  order by accounts.name, users.username;
+
+-- name: GetSharedAccount :one
+select accounts.id, account_shares.permission from account_shares
+  join accounts on accounts.id = account_shares.account_id
+  join users on users.id = accounts.user_id
+  where account_shares.grantee_id = ? and users.username = ? and accounts.service_id = ? and accounts.name = ?;
+
+-- name: GetCollectionRole :one
+select collections.id, organization_members.role from collections
+  join organizations on organizations.id = collections.organization_id
+  join organization_members on organization_members.organization_id = collections.organization_id
+  where organization_members.user_id = ? and organizations.name = sqlc.arg(organization_name) and collections.name = sqlc.arg(collection_name);
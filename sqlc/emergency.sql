@@ -0,0 +1,39 @@
+-- name: GetUserID :one
+select id from users where username = ?;
+
+-- name: AddAccess :exec
+insert into emergency_access (id, grantor_id, grantee_id, type, wait_days, status) values (?, ?, ?, ?, ?, ?);
+
+-- name: GetAccess :one
+select id, type, wait_days, status, recovery_initiated_at from emergency_access where grantor_id = ? and grantee_id = ?;
+
+-- name: GetGrantorAccesses :many
+select ea.id, ea.grantee_id, u.username, ea.type, ea.wait_days, ea.status, ea.recovery_initiated_at
+from emergency_access ea
+join users u on u.id = ea.grantee_id
+where ea.grantor_id = ?
+order by u.username;
+
+-- name: GetGranteeAccesses :many
+select ea.id, ea.grantor_id, u.username, ea.type, ea.wait_days, ea.status, ea.recovery_initiated_at
+from emergency_access ea
+join users u on u.id = ea.grantor_id
+where ea.grantee_id = ?
+order by u.username;
+
+-- name: UpdateAccessStatus :exec
+update emergency_access set status = ?, recovery_initiated_at = ? where id = ?;
+
+-- name: RemoveAccess :exec
+delete from emergency_access where id = ?;
+
+-- name: AddEvent :exec
+insert into emergency_access_events (grantor_id, grantee_id, status, created_at) values (?, ?, ?, ?);
+
+-- name: GetEvents :many
+select e.grantor_id, gr.username as grantor, e.grantee_id, ge.username as grantee, e.status, e.created_at
+from emergency_access_events e
+join users gr on gr.id = e.grantor_id
+join users ge on ge.id = e.grantee_id
+where e.grantor_id = ?1 or e.grantee_id = ?1
+order by e.id;
@@ -0,0 +1,66 @@
+-- name: AddOrganization :exec
+insert into organizations (id, name) values (?, ?);
+
+-- name: GetOrganizationID :one
+select id from organizations where name = ?;
+
+-- name: GetUserOrganizations :many
+select organizations.name, organization_members.role from organization_members
+  join organizations on organizations.id = organization_members.organization_id
+  where organization_members.user_id = ?
+  order by organizations.name;
+
+-- name: RemoveOrganization :exec
+delete from organizations where id = ?;
+
+-- name: AddMember :exec
+insert into organization_members (organization_id, user_id, role) values (?, ?, ?);
+
+-- name: GetMemberRole :one
+select role from organization_members where organization_id = ? and user_id = ?;
+
+-- name: GetMembers :many
+select organization_members.user_id, users.username, organization_members.role from organization_members
+  join users on users.id = organization_members.user_id
+  where organization_members.organization_id = ?
+  order by users.username;
+
+-- name: CountOwners :one
+select count(*) from organization_members where organization_id = ? and role = 'owner';
+
+-- name: UpdateMemberRole :exec
+update organization_members set role = ? where organization_id = ? and user_id = ?;
+
+-- name: RemoveMember :exec
+delete from organization_members where organization_id = ? and user_id = ?;
+
+-- name: GetUserID :one
+select id from users where username = ?;
+
+-- name: AddInvite :exec
+insert into organization_invites (organization_id, user_id, role) values (?, ?, ?)
+  on conflict (organization_id, user_id) do update set role = excluded.role;
+
+-- name: GetInviteRole :one
+select role from organization_invites where organization_id = ? and user_id = ?;
+
+-- name: GetUserInvites :many
+select organizations.id, organizations.name, organization_invites.role from organization_invites
+  join organizations on organizations.id = organization_invites.organization_id
+  where organization_invites.user_id = ?
+  order by organizations.name;
+
+-- name: RemoveInvite :exec
+delete from organization_invites where organization_id = ? and user_id = ?;
+
+-- name: AddCollection :exec
+insert into collections (id, organization_id, name) values (?, ?, ?);
+
+-- name: GetCollectionID :one
+select id from collections where organization_id = ? and name = ?;
+
+-- name: GetCollections :many
+select id, name from collections where organization_id = ? order by name;
+
+-- name: RemoveCollection :exec
+delete from collections where id = ?;
@@ -0,0 +1,18 @@
+-- name: AddSend :exec
+insert into sends (id, user_id, type, file_name, nonce, data, password, max_views, expires_at, created_at)
+  values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetSend :one
+select user_id, type, file_name, nonce, data, password, max_views, views, expires_at, created_at from sends where id = ?;
+
+-- name: GetUserSends :many
+select id, type, file_name, password, max_views, views, expires_at, created_at from sends where user_id = ? order by created_at;
+
+-- name: AddView :execrows
+update sends set views = views + 1 where id = ? and views < max_views and expires_at > ?;
+
+-- name: RemoveSend :exec
+delete from sends where id = ?;
+
+-- name: RemoveExpiredSends :exec
+delete from sends where expires_at <= ? or views >= max_views;
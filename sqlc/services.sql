@@ -6,7 +6,7 @@ select id, logo from services where name = ?;
 
 -- name: CheckExistingRecord :one
 select accounts.id from accounts
-  where accounts.user_id <> ?
+  where accounts.user_id is not ?
   and accounts.service_id in (
     select services.id from services
       where services.name = ?
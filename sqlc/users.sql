@@ -1,14 +1,195 @@
 -- name: AddUser :exec
-insert into users (id, username, password) values (?, ?, ?);
+-- The first registered user becomes the admin
+insert into users (id, username, password, created_at, is_admin) values (?, ?, ?, ?, not exists (select 1 from users));
 
 -- name: GetUser :one
-select id, password from users where username = ?;
+select id, password, is_admin, disabled, password_reset_required, display_name, email, locale, timezone, created_at, last_login_at, delete_at
+from users where username = ?;
 
 -- name: GetUserByID :one
-select username, password from users where id = ?;
+select username, password, is_admin, disabled, password_reset_required, display_name, email, locale, timezone, created_at, last_login_at, delete_at
+from users where id = ?;
 
 -- name: UpdateUser :exec
-update users set username = ?, password = ? where id = ?;
+update users set username = ?, password = ?, password_reset_required = ? where id = ?;
+
+-- name: UpdateProfile :exec
+update users set display_name = ?, email = ?, locale = ?, timezone = ? where id = ?;
+
+-- name: UpdateLastLogin :exec
+update users set last_login_at = ? where id = ?;
+
+-- name: UpdatePasswordHash :exec
+-- Rehashed password isn't saved if the password is changed concurrently
+update users set password = ? where id = ? and password = sqlc.arg(old_password);
 
 -- name: RemoveUser :exec
 delete from users where id = ?;
+
+-- name: GetUsers :many
+select
+  u.id, u.username, u.is_admin, u.disabled, u.password_reset_required, u.delete_at,
+  (select count(*) from accounts where accounts.user_id = u.id) as accounts,
+  (select count(*) from sends where sends.user_id = u.id) as sends,
+  cast(
+    coalesce((select sum(length(payload)) from accounts where accounts.user_id = u.id), 0) +
+    coalesce((select sum(length(data)) from sends where sends.user_id = u.id), 0)
+  as integer) as storage_bytes
+from users u order by u.username;
+
+-- name: SetUserDisabled :execrows
+-- Enabling the user cancels the scheduled deletion
+update users set disabled = ?1, delete_at = case when ?1 then delete_at else 0 end where id = ?2;
+
+-- name: ScheduleDeletion :execrows
+update users set delete_at = ? where id = ? and delete_at = 0;
+
+-- name: CancelDeletion :execrows
+-- The user disabled by the admin stays disabled
+update users set delete_at = 0 where id = ? and delete_at > 0;
+
+-- name: GetDueDeletions :many
+select id, username from users where delete_at > 0 and delete_at <= ?;
+
+-- name: RemoveDeletedUser :execrows
+-- The user restored in the meantime isn't removed
+delete from users where id = ? and delete_at > 0 and delete_at <= ?;
+
+-- name: RequirePasswordReset :execrows
+update users set password_reset_required = true where id = ?;
+
+-- name: GetTOTP :one
+select secret, enabled, last_step from user_totp where user_id = ?;
+
+-- name: SetTOTP :exec
+insert into user_totp (user_id, secret, enabled, last_step) values (?, ?, ?, ?)
+  on conflict (user_id) do update set secret = excluded.secret, enabled = excluded.enabled, last_step = excluded.last_step;
+
+-- name: UpdateTOTPStep :execrows
+update user_totp set last_step = ?2 where user_id = ?1 and last_step < ?2;
+
+-- name: RemoveTOTP :exec
+delete from user_totp where user_id = ?;
+
+-- name: AddRecoveryCode :exec
+insert into user_recovery_codes (user_id, code_hash) values (?, ?);
+
+-- name: CountRecoveryCodes :one
+select count(*) from user_recovery_codes where user_id = ?;
+
+-- name: RemoveRecoveryCode :execrows
+delete from user_recovery_codes where user_id = ? and code_hash = ?;
+
+-- name: RemoveRecoveryCodes :exec
+delete from user_recovery_codes where user_id = ?;
+
+-- name: AddWebAuthnCredential :exec
+insert into webauthn_credentials (id, user_id, name, public_key, sign_count, created_at) values (?, ?, ?, ?, ?, ?);
+
+-- name: GetWebAuthnCredential :one
+select user_id, name, public_key, sign_count, created_at, last_used_at from webauthn_credentials where id = ?;
+
+-- name: GetWebAuthnCredentials :many
+select id, name, public_key, sign_count, created_at, last_used_at from webauthn_credentials where user_id = ? order by created_at;
+
+-- name: UpdateWebAuthnSignCount :exec
+update webauthn_credentials set sign_count = ?, last_used_at = ? where id = ?;
+
+-- name: RemoveWebAuthnCredential :execrows
+delete from webauthn_credentials where id = ? and user_id = ?;
+
+-- name: RemoveWebAuthnCredentials :exec
+delete from webauthn_credentials where user_id = ?;
+
+-- name: GetLoginThrottle :one
+select failures, locked_until, last_failure_at from login_throttles where kind = ? and subject = ?;
+
+-- name: AddLoginFailure :one
+-- Failures are counted from scratch if the last one is older than ?4
+insert into login_throttles (kind, subject, failures, last_failure_at) values (?1, ?2, 1, ?3)
+  on conflict (kind, subject) do update set
+    failures = case when login_throttles.last_failure_at < ?4 then 1 else login_throttles.failures + 1 end,
+    last_failure_at = excluded.last_failure_at
+  returning failures;
+
+-- name: LockLogin :exec
+update login_throttles set locked_until = ? where kind = ? and subject = ?;
+
+-- name: RemoveLoginThrottle :exec
+delete from login_throttles where kind = ? and subject = ?;
+
+-- name: RemoveStaleLoginThrottles :exec
+delete from login_throttles where last_failure_at < ?1 and locked_until < ?2;
+
+-- name: GetLockedLogins :many
+select subject, failures, locked_until, last_failure_at from login_throttles where kind = ? and locked_until > ? order by locked_until desc;
+
+-- name: AddAccessToken :exec
+insert into access_tokens (id, user_id, name, token_hash, scopes, created_at, expires_at) values (?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetAccessTokenByHash :one
+-- Tokens of disabled users and users scheduled for deletion aren't found
+select t.id, t.user_id, t.name, t.scopes, t.created_at, t.expires_at, t.last_used_at from access_tokens t
+  join users u on u.id = t.user_id
+  where t.token_hash = ? and not u.disabled and u.delete_at = 0;
+
+-- name: GetAccessTokens :many
+select id, name, scopes, created_at, expires_at, last_used_at from access_tokens where user_id = ? order by created_at;
+
+-- name: UpdateAccessTokenLastUsed :exec
+update access_tokens set last_used_at = ? where id = ?;
+
+-- name: RemoveAccessToken :execrows
+delete from access_tokens where id = ? and user_id = ?;
+
+-- name: RemoveAccessTokens :exec
+delete from access_tokens where user_id = ?;
+
+-- name: CountUsers :one
+select count(*) from users;
+
+-- name: CountAdmins :one
+select count(*) from users where is_admin and not disabled and delete_at = 0;
+
+-- name: AddInvite :exec
+insert into invites (id, token_hash, username, created_by, created_at, expires_at) values (?, ?, ?, ?, ?, ?);
+
+-- name: GetInviteByHash :one
+select id, username, created_by, created_at, expires_at, used_at, used_by from invites where token_hash = ?;
+
+-- name: GetInvites :many
+select id, username, created_by, created_at, expires_at, used_at, used_by from invites order by created_at desc;
+
+-- name: UseInvite :execrows
+update invites set used_at = ?, used_by = ? where id = ? and used_at = 0;
+
+-- name: RemoveInvite :execrows
+delete from invites where id = ?;
+
+-- name: SetUserAdmin :exec
+update users set is_admin = ? where id = ?;
+
+-- name: AddIdentity :exec
+insert into user_identities (issuer, subject, user_id, created_at) values (?, ?, ?, ?);
+
+-- name: GetIdentityUserID :one
+select user_id from user_identities where issuer = ? and subject = ?;
+
+-- name: GetUserIdentity :one
+select subject, created_at from user_identities where user_id = ? and issuer = ?;
+
+-- name: RemoveIdentity :execrows
+delete from user_identities where user_id = ? and issuer = ?;
+
+-- name: SetRecoveryKey :exec
+insert into user_recovery_keys (user_id, key_hash, created_at) values (?, ?, ?)
+on conflict (user_id) do update set key_hash = excluded.key_hash, created_at = excluded.created_at;
+
+-- name: GetRecoveryKey :one
+select key_hash, created_at from user_recovery_keys where user_id = ?;
+
+-- name: AddAuditEvent :exec
+insert into audit_events (id, action, actor_id, actor_username, target_id, target_username, created_at) values (?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetAuditEvents :many
+select id, action, actor_id, actor_username, target_id, target_username, created_at from audit_events order by created_at desc, rowid desc;